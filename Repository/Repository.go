@@ -1,45 +1,229 @@
 package Repository
 
 import (
+	"Distributed-Health-Monitoring/assertions"
 	"Distributed-Health-Monitoring/cache"
+	"Distributed-Health-Monitoring/checker"
+	"Distributed-Health-Monitoring/clock"
+	"Distributed-Health-Monitoring/cron"
+	"Distributed-Health-Monitoring/dns"
+	"Distributed-Health-Monitoring/logstore"
 	"Distributed-Health-Monitoring/models"
+	"Distributed-Health-Monitoring/secrets"
 	"context"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+const (
+	maxMetadataKey   = 64
+	maxMetadataBytes = 4096
+
+	maxConfirmationChecks = 5
+
+	// maxRetryDelayMs keeps one service's retries from holding a worker for long
+	maxRetryDelayMs = 60000
+)
+
+// ErrNoServices is returned by GetAllServices when nothing is registered
+var ErrNoServices = errors.New("no services found")
+
+// ErrNeedsPostgres is returned by the checks that read Postgres catalogs
+var ErrNeedsPostgres = errors.New("only available with the postgres database driver")
+
 type DbRepository struct {
-	db *gorm.DB
+	db     *gorm.DB
+	logs   logstore.LogStore
+	writer *logWriter // nil when check logs are written synchronously
 	IRepository
 }
 
 type IRepository interface {
 	RegisterService(ctx context.Context, service *models.ExternalService) error
 	GetAllServices(ctx context.Context) (map[uint]*models.ExternalService, error)
-	SaveServiceCheckLog(service models.ExternalService, status string, statusCode int, responseTimeMs int64, errMsg string) error
-	UpdateServiceState(ctx context.Context, service *models.ExternalService, success bool) (*models.StateChange, error)
+	SaveServiceCheckLog(service models.ExternalService, region string, status string, statusCode int, responseTimeMs int64, errMsg string, timings models.CheckTimings) (models.ServiceCheckLog, error)
+	UpdateServiceState(ctx context.Context, service *models.ExternalService, result models.CheckResult) (*models.StateChange, error)
 	GetServiceByName(ctx context.Context, name string) (*models.ExternalService, error)
+	GetServicesByName(ctx context.Context, names []string) ([]*models.ExternalService, error)
+	GetBenchServices(ctx context.Context) ([]*models.ExternalService, error)
+	GetServiceByID(ctx context.Context, id uint) (*models.ExternalService, error)
+	RecordHeartbeat(ctx context.Context, token string, at time.Time) (*models.ExternalService, error)
+	SetServiceFlapping(ctx context.Context, id uint, flapping bool) error
+	AcknowledgeService(ctx context.Context, serviceID uint, ack models.Acknowledgement, status string) error
+	ClearServiceAcknowledgement(ctx context.Context, serviceID uint, action string, by string, status string, at time.Time) (bool, error)
+	ListServiceAcknowledgements(ctx context.Context, serviceID uint, limit int) ([]models.ServiceAcknowledgement, error)
+	SaveStateTransition(ctx context.Context, transition *models.ServiceStateTransition) error
+	ListStateTransitions(ctx context.Context, filter models.TransitionFilter) ([]models.ServiceStateTransition, int64, error)
+	MarkServiceScheduled(ctx context.Context, id uint, nextRunAt time.Time, inFlightUntil time.Time) error
+	SaveLastResponse(ctx context.Context, response *models.LastResponse) error
+	GetLastResponse(ctx context.Context, serviceID uint) (*models.LastResponse, error)
 	GetServiceCheckLogs(ctx context.Context, serviceID uint, limit int, offset int) ([]*models.ServiceCheckLog, error)
+	QueryServiceCheckLogs(ctx context.Context, filter models.CheckLogFilter) ([]*models.ServiceCheckLog, int64, error)
+	GetServiceCheckLogsInRange(ctx context.Context, serviceID uint, from time.Time, to time.Time) ([]*models.ServiceCheckLog, error)
+	GetServiceCheckLogBuckets(ctx context.Context, serviceID uint, from time.Time, to time.Time, step time.Duration) ([]models.CheckLogBucket, error)
+	CheckLogServiceIDs(ctx context.Context) ([]uint, error)
+	PruneCheckLogs(ctx context.Context, before time.Time) (int64, error)
+	CheckLogID(ctx context.Context, entry models.ServiceCheckLog) (uint, error)
+	LogWriterStats() LogWriterStats
+	FlushCheckLogs(ctx context.Context) error
+
+	CreateMaintenanceWindow(ctx context.Context, window *models.MaintenanceWindow) error
+	ListMaintenanceWindows(ctx context.Context, serviceID uint) ([]*models.MaintenanceWindow, error)
+	GetMaintenanceWindow(ctx context.Context, id uint) (*models.MaintenanceWindow, error)
+	DeleteMaintenanceWindow(ctx context.Context, id uint) error
+	ServicesInMaintenance(ctx context.Context, t time.Time) (map[uint]bool, error)
+
+	OpenIncident(ctx context.Context, serviceID uint, reason string, cause string, at time.Time) (*models.Incident, error)
+	ResolveIncident(ctx context.Context, serviceID uint, at time.Time) (*models.Incident, error)
+	GetOpenIncident(ctx context.Context, serviceID uint) (*models.Incident, error)
+	ListIncidents(ctx context.Context, serviceID uint) ([]models.Incident, error)
+	ListOpenIncidents(ctx context.Context) ([]models.Incident, error)
+	ListIncidentsInRange(ctx context.Context, from time.Time, to time.Time) ([]models.Incident, error)
+	GetIncident(ctx context.Context, id uint) (*models.Incident, error)
+	AcknowledgeIncident(ctx context.Context, id uint, by string, at time.Time) (*models.Incident, error)
+	ClaimEscalationStep(ctx context.Context, incidentID uint, level int) (bool, error)
+	SaveIncidentEscalation(ctx context.Context, escalation *models.IncidentEscalation) error
+	ListIncidentEscalations(ctx context.Context, incidentID uint) ([]models.IncidentEscalation, error)
+	SaveRegionResult(ctx context.Context, result *models.RegionResult) error
+	ListRegionResults(ctx context.Context, serviceID uint) ([]models.RegionResult, error)
+	ClaimRegionRound(ctx context.Context, serviceID uint, roundAt time.Time) (bool, error)
+	SaveConfirmationResult(ctx context.Context, result *models.ConfirmationResult) error
+	ListConfirmationResults(ctx context.Context, serviceID uint) ([]models.ConfirmationResult, error)
+	PruneConfirmationResults(ctx context.Context, serviceID uint, before time.Time) error
+	ClearConfirmationResults(ctx context.Context, serviceID uint) error
+	CreateWebhook(ctx context.Context, webhook *models.Webhook) error
+	ListWebhooks(ctx context.Context) ([]models.Webhook, error)
+	GetWebhook(ctx context.Context, id uint) (*models.Webhook, error)
+	DeleteWebhook(ctx context.Context, id uint) error
+	SaveWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	ListWebhookDeliveries(ctx context.Context, webhookID uint, limit int) ([]models.WebhookDelivery, error)
+	ClaimRemediation(ctx context.Context, serviceID uint, attempts int64, maxAttempts int64, cooldown time.Duration, at time.Time) (bool, error)
+	SaveRemediationRun(ctx context.Context, run *models.RemediationRun) error
+	ListRemediationRuns(ctx context.Context, serviceID uint, limit int) ([]models.RemediationRun, error)
+	CreateAuditLog(ctx context.Context, entry *models.AuditLog) error
+	ListAuditLogs(ctx context.Context, filter models.AuditFilter) ([]models.AuditLog, int64, error)
+
+	ArchiveAndDeleteService(ctx context.Context, archive *models.ServiceArchive, permanent bool) error
+	GetDeletedService(ctx context.Context, id uint) (*models.ExternalService, error)
+	GetDeletedServiceByName(ctx context.Context, name string) (*models.ExternalService, error)
+	DeleteServicePermanently(ctx context.Context, id uint) error
+	RestoreService(ctx context.Context, id uint, at time.Time) (*models.ExternalService, error)
+	ListServiceArchives(ctx context.Context, name string) ([]*models.ServiceArchive, error)
+	GetServiceArchive(ctx context.Context, id uint) (*models.ServiceArchive, error)
+	PurgeServices(ctx context.Context, serviceIDs []uint, archiveIDs []uint) (map[string]int64, error)
+
+	GetUptime(ctx context.Context, serviceID uint, since time.Time) (models.UptimeStat, error)
+
+	EncryptSecrets(ctx context.Context) (int, error)
+
+	GetSchedulerControl(ctx context.Context) (*models.SchedulerControl, error)
+	SetSchedulerControl(ctx context.Context, control *models.SchedulerControl) error
+	DatabaseNow(ctx context.Context) (time.Time, error)
+
+	ClaimJob(ctx context.Context, job *models.ProcessedJob) (bool, error)
+	ReleaseJob(ctx context.Context, jobID string) error
+	PruneProcessedJobs(ctx context.Context, before time.Time) (int64, error)
+
+	CheckDBHealth(ctx context.Context, createMissing bool) (*models.DBHealthReport, error)
+	CheckConsistency(ctx context.Context, repair bool) (*models.ConsistencyReport, error)
+	Ping(ctx context.Context) error
 }
 
-func NewRepository(db *gorm.DB) IRepository {
-	return &DbRepository{
-		db: db,
+// NewRepository builds the repository; check logs go to logs, or to the
+// main database when logs is nil, through the buffered writer when
+// writer.Enabled is set
+func NewRepository(db *gorm.DB, logs logstore.LogStore, writer LogWriterOptions) IRepository {
+	if logs == nil {
+		logs = logstore.NewGormStore(db)
+	}
+	r := &DbRepository{
+		db:   db,
+		logs: logs,
+	}
+	if writer.Enabled {
+		r.writer = newLogWriter(logs, writer)
 	}
+	return r
 }
 
 func (r *DbRepository) RegisterService(ctx context.Context, service *models.ExternalService) error {
+	if err := ValidateService(service); err != nil {
+		return err
+	}
+
+	if service.Protocol == models.ProtocolHeartbeat && (service.HeartbeatToken == nil || *service.HeartbeatToken == "") {
+		token, err := newHeartbeatToken()
+		if err != nil {
+			return err
+		}
+		service.HeartbeatToken = &token
+	}
+
+	if err := r.checkDependencyCycle(ctx, service); err != nil {
+		return err
+	}
 
-	// some validations
+	// New services have no result yet, whatever the request body claims
+	update := service.ID != 0
+	if !update {
+		if err := r.checkDeletedName(ctx, service.Name); err != nil {
+			return err
+		}
+		service.Status = models.StatusPending
+		service.ConsecutiveFailures = 0
+		service.LastCheckedAt = nil
+		service.Acknowledgement = nil
+	}
+
+	if err := r.db.WithContext(ctx).Save(service).Error; err != nil {
+		return err
+	}
+	// Votes of a confirmation that was turned off would never be decided
+	if update && !service.Confirmation.Enabled() {
+		return r.ClearConfirmationResults(ctx, service.ID)
+	}
+	return nil
+}
+
+// ValidateService checks a service definition and normalizes it in place
+// (defaults, tag cleanup, upper-case record types) without touching the database
+func ValidateService(service *models.ExternalService) error {
 	if service == nil {
 		return errors.New("service is nil")
 	}
 	if service.Name == "" {
 		return errors.New("service name is empty")
 	}
-	if service.URL == "" {
+	// Checkers may add their own rules, for protocols this package doesn't know
+	if err := checker.Validate(service); err != nil {
+		return err
+	}
+	if service.Protocol == models.ProtocolHeartbeat {
+		// Heartbeat services push to us, so there is nothing to probe or retry
+		if service.TimeoutSeconds == 0 {
+			service.TimeoutSeconds = 1
+		}
+		service.Retries = 0
+		if service.HeartbeatGrace < 0 {
+			return errors.New("service heartbeat grace is invalid")
+		}
+	}
+	if err := validateDatabase(service); err != nil {
+		return err
+	}
+	if service.Protocol != models.ProtocolHeartbeat && service.URL == "" {
 		return errors.New("service url is empty")
 	}
 	if (service.Protocol == "HTTP" || service.Protocol == "") && service.HTTPMethod == "" {
@@ -54,29 +238,315 @@ func (r *DbRepository) RegisterService(ctx context.Context, service *models.Exte
 	if service.FailureThreshold == 0 || service.FailureThreshold < 0 {
 		return errors.New("service failure threshold is invalid")
 	}
+	if err := validateSchedule(service); err != nil {
+		return err
+	}
 	if service.Interval == 0 || service.Interval < 0 {
 		return errors.New("service interval is invalid")
 	}
+	if service.Protocol == "TCP" {
+		if _, _, err := net.SplitHostPort(service.URL); err != nil {
+			return errors.New("service url must be host:port for TCP checks")
+		}
+	}
+	if service.Protocol == "DNS" {
+		if service.DNSRecordType == "" {
+			service.DNSRecordType = "A"
+		}
+		service.DNSRecordType = strings.ToUpper(service.DNSRecordType)
+		if !dns.RecordTypes[service.DNSRecordType] {
+			return errors.New("service dns record type is invalid")
+		}
+		if strings.Contains(service.URL, "/") {
+			return errors.New("service url must be a bare hostname for DNS checks")
+		}
+	}
+	if service.Protocol == "gRPC" {
+		if _, _, err := net.SplitHostPort(service.URL); err != nil {
+			return errors.New("service url must be host:port for gRPC checks")
+		}
+		if service.GRPCTLS != nil && service.GRPCTLS.CACert != "" && !x509.NewCertPool().AppendCertsFromPEM([]byte(service.GRPCTLS.CACert)) {
+			return errors.New("service grpc_tls ca_cert holds no PEM certificate")
+		}
+	} else if service.GRPCServiceName != "" || service.GRPCWatch || service.GRPCRequireHealth || service.GRPCTLS != nil {
+		return errors.New("service grpc_service_name, grpc_watch, grpc_require_health and grpc_tls are only supported for gRPC checks")
+	}
+	if service.Retries < 0 || service.Retries > 10 {
+		return errors.New("service retries must be between 0 and 10")
+	}
+	if service.RetryDelayMs < 0 || service.RetryDelayMs > maxRetryDelayMs {
+		return fmt.Errorf("service retry_delay_ms must be between 0 and %d", maxRetryDelayMs)
+	}
+	if service.LatencyWarnMs < 0 || service.LatencyCritMs < 0 {
+		return errors.New("service latency thresholds must not be negative")
+	}
+	if service.LatencyWarnMs > 0 && service.LatencyCritMs > 0 && service.LatencyCritMs <= service.LatencyWarnMs {
+		return errors.New("service latency_crit_ms must be above latency_warn_ms")
+	}
+	if service.ProxyURL != "" {
+		u, err := url.Parse(service.ProxyURL)
+		if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "socks5") {
+			return errors.New("service proxy url must be an http, https or socks5 url")
+		}
+		if u.User.Username() == secrets.RedactedPassword {
+			return errors.New("service proxy url user is redacted; send the real user")
+		}
+		if password, ok := u.User.Password(); ok {
+			if password == secrets.RedactedPassword {
+				return errors.New("service proxy url password is redacted; send the real password")
+			}
+			if !secrets.Enabled() {
+				return errors.New("service proxy url credentials require secrets.key to be configured")
+			}
+		}
+	}
+	for host, ip := range service.ResolveOverride {
+		if host == "" || net.ParseIP(ip) == nil {
+			return fmt.Errorf("service resolve override for %q must be an IP address", host)
+		}
+	}
+	if service.Auth != nil {
+		if service.Protocol != "HTTP" && service.Protocol != "" {
+			return errors.New("service auth is only supported for HTTP checks")
+		}
+		if err := validateAuth(service.Auth); err != nil {
+			return err
+		}
+	}
+	service.Tags = NormalizeTags(service.Tags)
+	service.DependsOn = NormalizeTags(service.DependsOn)
+	for _, name := range service.DependsOn {
+		if name == service.Name {
+			return errors.New("service can't depend on itself")
+		}
+	}
+	if err := validateRegions(service); err != nil {
+		return err
+	}
+	if err := validateConfirmation(service); err != nil {
+		return err
+	}
+	if err := validateMetadata(service.Metadata); err != nil {
+		return err
+	}
+	if err := validateOwnership(service); err != nil {
+		return err
+	}
+	if err := validateSLO(service.SLO); err != nil {
+		return err
+	}
+	if err := validateRemediation(service); err != nil {
+		return err
+	}
+	if err := validateSuccessCriteria(service); err != nil {
+		return err
+	}
+	for _, a := range service.Assertions {
+		if err := assertions.Validate(a); err != nil {
+			return fmt.Errorf("service assertion is invalid: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// validateSchedule checks the cron expression and time zone of a service.
+// The interval still sizes heatmap buckets, so a scheduled service without
+// one gets the default 60 seconds.
+func validateSchedule(service *models.ExternalService) error {
+	service.Schedule = strings.TrimSpace(service.Schedule)
+	if service.Schedule == "" {
+		if service.ScheduleTimezone != "" {
+			return errors.New("service schedule_timezone needs a schedule")
+		}
+		return nil
+	}
+	if service.Protocol == models.ProtocolHeartbeat {
+		return errors.New("service schedule is not supported for heartbeat checks")
+	}
+
+	sched, err := cron.Parse(service.Schedule)
+	if err != nil {
+		return fmt.Errorf("service schedule is invalid: %w", err)
+	}
+	loc, err := time.LoadLocation(service.ScheduleTimezone)
+	if err != nil {
+		return fmt.Errorf("service schedule_timezone %q is unknown", service.ScheduleTimezone)
+	}
+	if _, err := sched.Next(time.Now().In(loc)); err != nil {
+		return fmt.Errorf("service schedule %q never matches", service.Schedule)
+	}
+
+	if service.Interval == 0 {
+		service.Interval = 60
+	}
+	return nil
+}
+
+func validateAuth(auth *models.CheckAuth) error {
+	auth.Type = strings.ToLower(auth.Type)
+	switch auth.Type {
+	case models.AuthBasic:
+		if auth.Username == "" {
+			return errors.New("service auth username is empty")
+		}
+	case models.AuthBearer:
+		if auth.Token == "" {
+			return errors.New("service auth token is empty")
+		}
+	case models.AuthOAuth2:
+		u, err := url.Parse(auth.TokenURL)
+		if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			return errors.New("service auth token_url must be an http or https url")
+		}
+		if auth.ClientID == "" || auth.ClientSecret == "" {
+			return errors.New("service auth client_id and client_secret are required")
+		}
+	default:
+		return errors.New("service auth type must be basic, bearer or oauth2")
+	}
+
+	for field, value := range map[string]string{"password": auth.Password, "token": auth.Token, "client_secret": auth.ClientSecret} {
+		if value == secrets.RedactedPassword {
+			return fmt.Errorf("service auth %s is redacted; send the real value", field)
+		}
+	}
+	if !secrets.Enabled() {
+		return errors.New("service auth requires secrets.key to be configured")
+	}
+	return nil
+}
+
+// regionName keeps region names usable in queue and stream names
+var regionName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,49}$`)
+
+// validateRegions checks the required regions and that the quorum fits them
+func validateRegions(service *models.ExternalService) error {
+	if len(service.Regions) == 0 {
+		if service.RegionQuorum != 0 {
+			return errors.New("service region quorum requires regions")
+		}
+		return nil
+	}
+	if service.Protocol == models.ProtocolHeartbeat {
+		return errors.New("service regions are not supported for heartbeat checks")
+	}
+	if err := validateRegionNames("service region", service.Regions); err != nil {
+		return err
+	}
+	if service.RegionQuorum < 0 || service.RegionQuorum > int64(len(service.Regions)) {
+		return fmt.Errorf("service region quorum must be between 1 and %d", len(service.Regions))
+	}
+	return nil
+}
+
+func validateRegionNames(field string, regions []string) error {
+	seen := make(map[string]bool, len(regions))
+	for _, r := range regions {
+		if !regionName.MatchString(r) {
+			return fmt.Errorf("%s %q must be lower-case letters, digits, - and _", field, r)
+		}
+		if seen[r] {
+			return fmt.Errorf("%s %q is listed twice", field, r)
+		}
+		seen[r] = true
+	}
+	return nil
+}
+
+// validateConfirmation checks the re-checks of a failure and that the
+// quorum fits them. Regions already confirm each other, and a missed
+// heartbeat can't be checked again.
+func validateConfirmation(service *models.ExternalService) error {
+	c := service.Confirmation
+	if c == nil {
+		return nil
+	}
+	if len(service.Regions) > 0 {
+		return errors.New("service confirmation is not supported for services with regions; use region_quorum")
+	}
+	if service.Protocol == models.ProtocolHeartbeat {
+		return errors.New("service confirmation is not supported for heartbeat checks")
+	}
+	if err := validateRegionNames("service confirmation region", c.Regions); err != nil {
+		return err
+	}
+	if c.Checks == 0 {
+		c.Checks = int64(len(c.Regions))
+	}
+	if c.Checks < 1 || c.Checks > maxConfirmationChecks {
+		return fmt.Errorf("service confirmation checks must be between 1 and %d", maxConfirmationChecks)
+	}
+	if len(c.Regions) > 0 && c.Checks != int64(len(c.Regions)) {
+		return errors.New("service confirmation checks must match its regions, which run one each")
+	}
+	if c.Quorum < 0 || c.Quorum > c.Checks {
+		return fmt.Errorf("service confirmation quorum must be between 1 and %d", c.Checks)
+	}
+	return nil
+}
 
-	return r.db.WithContext(ctx).Save(service).Error
+// validateMetadata bounds the metadata, which is copied into every alert
+func validateMetadata(metadata map[string]interface{}) error {
+	for key := range metadata {
+		if strings.TrimSpace(key) == "" || len(key) > maxMetadataKey {
+			return fmt.Errorf("service metadata keys must be 1 to %d characters", maxMetadataKey)
+		}
+	}
+	raw, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("service metadata is invalid: %w", err)
+	}
+	if len(raw) > maxMetadataBytes {
+		return fmt.Errorf("service metadata must be at most %d bytes of JSON", maxMetadataBytes)
+	}
+	return nil
 }
 
+// NormalizeTags trims and de-duplicates tags, dropping empty ones. Service
+// writes apply it, so a definition is compared after it too.
+func NormalizeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	out := make([]string, 0, len(tags))
+	for _, t := range tags {
+		t = strings.TrimSpace(t)
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		out = append(out, t)
+	}
+	return out
+}
+
+// GetAllServices loads every service and replaces the service cache with
+// them, dropping services deleted elsewhere since the last load. The
+// services of a bench run are left out of both: only the run checks them,
+// and they must not show up in lists, metrics or SLO totals.
 func (r *DbRepository) GetAllServices(ctx context.Context) (map[uint]*models.ExternalService, error) {
-	var services []*models.ExternalService
+	var loaded []*models.ExternalService
 
-	if err := r.db.WithContext(ctx).Model(&models.ExternalService{}).Find(&services).Error; err != nil {
+	if err := r.db.WithContext(ctx).Model(&models.ExternalService{}).Find(&loaded).Error; err != nil {
 		return nil, err
 	}
 
+	services := make([]*models.ExternalService, 0, len(loaded))
+	for _, service := range loaded {
+		if !service.HasTag(models.BenchTag) {
+			services = append(services, service)
+		}
+	}
+	cache.Services.Replace(services)
+
 	if len(services) == 0 {
-		return nil, errors.New("no services found")
+		return nil, ErrNoServices
 	}
 
+	byID := make(map[uint]*models.ExternalService, len(services))
 	for _, service := range services {
-		cache.MapExternalServices[service.ID] = service
+		byID[service.ID] = service
 	}
-
-	return cache.MapExternalServices, nil
+	return byID, nil
 }
 
 func (r *DbRepository) GetServiceByName(ctx context.Context, name string) (*models.ExternalService, error) {
@@ -89,7 +559,77 @@ func (r *DbRepository) GetServiceByName(ctx context.Context, name string) (*mode
 	return &service, nil
 }
 
-func (r *DbRepository) SaveServiceCheckLog(service models.ExternalService, status string, statusCode int, responseTimeMs int64, errMsg string) error {
+// GetBenchServices loads the services of bench runs, which GetAllServices
+// leaves out, without touching the service cache
+func (r *DbRepository) GetBenchServices(ctx context.Context) ([]*models.ExternalService, error) {
+	var loaded []*models.ExternalService
+	if err := r.db.WithContext(ctx).Find(&loaded).Error; err != nil {
+		return nil, err
+	}
+	services := make([]*models.ExternalService, 0)
+	for _, service := range loaded {
+		if service.HasTag(models.BenchTag) {
+			services = append(services, service)
+		}
+	}
+	return services, nil
+}
+
+// GetServicesByName loads the services with the given names, without
+// touching the service cache
+func (r *DbRepository) GetServicesByName(ctx context.Context, names []string) ([]*models.ExternalService, error) {
+	var services []*models.ExternalService
+	if len(names) == 0 {
+		return services, nil
+	}
+	if err := r.db.WithContext(ctx).Where("name IN ?", names).Find(&services).Error; err != nil {
+		return nil, err
+	}
+	return services, nil
+}
+
+func (r *DbRepository) GetServiceByID(ctx context.Context, id uint) (*models.ExternalService, error) {
+	var service models.ExternalService
+
+	if err := r.db.WithContext(ctx).First(&service, id).Error; err != nil {
+		return nil, err
+	}
+
+	return &service, nil
+}
+
+// RecordHeartbeat stores a ping for the heartbeat service owning token
+func (r *DbRepository) RecordHeartbeat(ctx context.Context, token string, at time.Time) (*models.ExternalService, error) {
+	res := r.db.WithContext(ctx).
+		Model(&models.ExternalService{}).
+		Where("heartbeat_token = ? AND protocol = ?", token, models.ProtocolHeartbeat).
+		Update("last_heartbeat_at", at)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var service models.ExternalService
+	if err := r.db.WithContext(ctx).Where("heartbeat_token = ?", token).First(&service).Error; err != nil {
+		return nil, err
+	}
+
+	return &service, nil
+}
+
+func newHeartbeatToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate heartbeat token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// SaveServiceCheckLog stores the log of one check and returns it. Its ID is
+// 0 when the log went to the batch writer or the store assigns none.
+func (r *DbRepository) SaveServiceCheckLog(service models.ExternalService, region string, status string, statusCode int, responseTimeMs int64, errMsg string, timings models.CheckTimings) (models.ServiceCheckLog, error) {
 
 	logEntry := models.ServiceCheckLog{
 		ExternalServiceID: service.ID,
@@ -97,23 +637,47 @@ func (r *DbRepository) SaveServiceCheckLog(service models.ExternalService, statu
 		StatusCode:        statusCode,
 		ResponseTimeMs:    responseTimeMs,
 		ErrorMessage:      errMsg,
-		CheckedAt:         time.Now(),
+		Region:            region,
+		CheckTimings:      timings,
+		CheckedAt:         clock.Now(),
 	}
 
-	return r.db.Create(&logEntry).Error
+	if r.writer != nil {
+		// Copied first: the writer sets the ID of the queued entry when it flushes
+		saved := logEntry
+		return saved, r.writer.enqueue(context.Background(), &logEntry)
+	}
+	err := r.logs.Save(context.Background(), &logEntry)
+	return logEntry, err
 }
 
-func (r *DbRepository) UpdateServiceState(ctx context.Context, service *models.ExternalService, success bool) (*models.StateChange, error) {
+func (r *DbRepository) UpdateServiceState(ctx context.Context, service *models.ExternalService, result models.CheckResult) (*models.StateChange, error) {
 
 	previousStatus := service.Status
 
-	if success {
-		service.RecordSuccess()
-	} else {
+	switch {
+	case !result.Success:
 		service.RecordFailure()
+	case result.Status == models.StatusDegraded:
+		service.RecordDegraded()
+	default:
+		service.RecordSuccess()
 	}
 
-	if err := r.db.WithContext(ctx).Save(service).Error; err != nil {
+	// The job is finished, so release the scheduler's in-flight lease. Only the
+	// state columns are written so concurrent scheduler updates aren't clobbered.
+	// Remediation attempts are counted by ClaimRemediation; a failure leaves
+	// them alone, so a stale copy can't undo another replica's claim.
+	service.InFlightUntil = nil
+	columns := []interface{}{"consecutive_failures", "last_checked_at", "in_flight_until"}
+	if result.Success {
+		columns = append(columns, "remediation_attempts")
+	}
+
+	if err := r.db.WithContext(ctx).
+		Model(service).
+		Select("status", columns...).
+		Updates(service).Error; err != nil {
 		return nil, err
 	}
 
@@ -126,21 +690,73 @@ func (r *DbRepository) UpdateServiceState(ctx context.Context, service *models.E
 
 	return nil, nil
 }
-func (r *DbRepository) GetServiceCheckLogs(ctx context.Context, serviceID uint, limit int, offset int) ([]*models.ServiceCheckLog, error) {
-	var logs []*models.ServiceCheckLog
 
-	if limit == 0 {
-		limit = 100 // default limit
-	}
+func (r *DbRepository) SetServiceFlapping(ctx context.Context, id uint, flapping bool) error {
+	return r.db.WithContext(ctx).
+		Model(&models.ExternalService{}).
+		Where("id = ?", id).
+		Update("flapping", flapping).Error
+}
 
-	if err := r.db.WithContext(ctx).
-		Where("external_service_id = ?", serviceID).
-		Order("checked_at DESC").
-		Limit(limit).
-		Offset(offset).
-		Find(&logs).Error; err != nil {
+// SaveLastResponse replaces the stored last response of the service
+func (r *DbRepository) SaveLastResponse(ctx context.Context, response *models.LastResponse) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{UpdateAll: true}).
+		Create(response).Error
+}
+
+func (r *DbRepository) GetLastResponse(ctx context.Context, serviceID uint) (*models.LastResponse, error) {
+	var response models.LastResponse
+
+	if err := r.db.WithContext(ctx).First(&response, "external_service_id = ?", serviceID).Error; err != nil {
 		return nil, err
 	}
 
-	return logs, nil
+	return &response, nil
+}
+
+func (r *DbRepository) MarkServiceScheduled(ctx context.Context, id uint, nextRunAt time.Time, inFlightUntil time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&models.ExternalService{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"next_run_at":     nextRunAt,
+			"in_flight_until": inFlightUntil,
+		}).Error
+}
+
+func (r *DbRepository) GetServiceCheckLogs(ctx context.Context, serviceID uint, limit int, offset int) ([]*models.ServiceCheckLog, error) {
+	return r.logs.List(ctx, serviceID, limit, offset)
+}
+
+// GetServiceCheckLogsInRange returns the logs of a service checked in [from, to), oldest first
+func (r *DbRepository) GetServiceCheckLogsInRange(ctx context.Context, serviceID uint, from time.Time, to time.Time) ([]*models.ServiceCheckLog, error) {
+	return r.logs.Range(ctx, serviceID, from, to)
+}
+
+// GetServiceCheckLogBuckets aggregates the logs of a service checked in
+// [from, to) per step, oldest first, in the log store where it can
+func (r *DbRepository) GetServiceCheckLogBuckets(ctx context.Context, serviceID uint, from time.Time, to time.Time, step time.Duration) ([]models.CheckLogBucket, error) {
+	return logstore.Buckets(ctx, r.logs, serviceID, from, to, step)
+}
+
+// CheckLogServiceIDs returns the ids of the services that have check logs,
+// deleted services included
+func (r *DbRepository) CheckLogServiceIDs(ctx context.Context) ([]uint, error) {
+	return r.logs.ServiceIDs(ctx)
+}
+
+// PruneCheckLogs removes the check logs of every service checked before the given time
+func (r *DbRepository) PruneCheckLogs(ctx context.Context, before time.Time) (int64, error) {
+	return r.logs.DeleteBefore(ctx, before)
+}
+
+// QueryServiceCheckLogs returns the page of logs matching a structured filter and the total number of matches
+func (r *DbRepository) QueryServiceCheckLogs(ctx context.Context, filter models.CheckLogFilter) ([]*models.ServiceCheckLog, int64, error) {
+	return r.logs.Query(ctx, filter)
+}
+
+// GetUptime counts the checks of a service since the given time and the share that succeeded
+func (r *DbRepository) GetUptime(ctx context.Context, serviceID uint, since time.Time) (models.UptimeStat, error) {
+	return r.logs.Uptime(ctx, serviceID, since)
 }
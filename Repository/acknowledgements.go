@@ -0,0 +1,76 @@
+package Repository
+
+import (
+	"Distributed-Health-Monitoring/models"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AcknowledgeService sets the acknowledgement of a service, replacing any
+// earlier one, and records it in the acknowledgement trail
+func (r *DbRepository) AcknowledgeService(ctx context.Context, serviceID uint, ack models.Acknowledgement, status string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.ExternalService{ID: serviceID}).
+			Select("acknowledgement").
+			Updates(&models.ExternalService{Acknowledgement: &ack})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		expires := ack.ExpiresAt
+		return tx.Create(&models.ServiceAcknowledgement{
+			ExternalServiceID: serviceID,
+			Action:            models.AckAcknowledged,
+			By:                ack.By,
+			Note:              ack.Note,
+			Status:            status,
+			ExpiresAt:         &expires,
+			CreatedAt:         ack.At,
+		}).Error
+	})
+}
+
+// ClearServiceAcknowledgement removes the acknowledgement of a service and
+// records who ended it. It reports false when there was none, so replicas
+// clearing the same acknowledgement on recovery record it once.
+func (r *DbRepository) ClearServiceAcknowledgement(ctx context.Context, serviceID uint, action string, by string, status string, at time.Time) (bool, error) {
+	cleared := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.ExternalService{}).
+			Where("id = ? AND acknowledgement IS NOT NULL", serviceID).
+			Update("acknowledgement", gorm.Expr("NULL"))
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		cleared = true
+
+		return tx.Create(&models.ServiceAcknowledgement{
+			ExternalServiceID: serviceID,
+			Action:            action,
+			By:                by,
+			Status:            status,
+			CreatedAt:         at,
+		}).Error
+	})
+	return cleared, err
+}
+
+// ListServiceAcknowledgements returns the acknowledgement trail of a service, newest first
+func (r *DbRepository) ListServiceAcknowledgements(ctx context.Context, serviceID uint, limit int) ([]models.ServiceAcknowledgement, error) {
+	var trail []models.ServiceAcknowledgement
+
+	if err := r.db.WithContext(ctx).
+		Where("external_service_id = ?", serviceID).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Find(&trail).Error; err != nil {
+		return nil, err
+	}
+
+	return trail, nil
+}
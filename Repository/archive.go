@@ -0,0 +1,169 @@
+package Repository
+
+import (
+	"Distributed-Health-Monitoring/cache"
+	"Distributed-Health-Monitoring/models"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ArchiveAndDeleteService stores the snapshot and soft-deletes the service in
+// one transaction, resolving its open incident. Its logs, incidents and
+// maintenance windows stay so it can be restored. A permanent delete removes
+// the row instead, and they go with it through ON DELETE CASCADE.
+func (r *DbRepository) ArchiveAndDeleteService(ctx context.Context, archive *models.ServiceArchive, permanent bool) error {
+	if archive == nil {
+		return errors.New("archive is nil")
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(archive).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.Incident{}).
+			Where("external_service_id = ? AND status = ?", archive.ServiceID, "open").
+			Updates(map[string]interface{}{"status": "resolved", "resolved_at": archive.DeletedAt}).Error; err != nil {
+			return err
+		}
+
+		if permanent {
+			tx = tx.Unscoped()
+		}
+		res := tx.Delete(&models.ExternalService{}, archive.ServiceID)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	cache.Services.Delete(archive.ServiceID)
+	return nil
+}
+
+// GetDeletedService returns a soft-deleted service
+func (r *DbRepository) GetDeletedService(ctx context.Context, id uint) (*models.ExternalService, error) {
+	var service models.ExternalService
+
+	if err := r.db.WithContext(ctx).Unscoped().Where("deleted_at IS NOT NULL").First(&service, id).Error; err != nil {
+		return nil, err
+	}
+
+	return &service, nil
+}
+
+// GetDeletedServiceByName returns the soft-deleted service holding a name
+func (r *DbRepository) GetDeletedServiceByName(ctx context.Context, name string) (*models.ExternalService, error) {
+	var service models.ExternalService
+
+	if err := r.db.WithContext(ctx).Unscoped().Where("name = ? AND deleted_at IS NOT NULL", name).Take(&service).Error; err != nil {
+		return nil, err
+	}
+
+	return &service, nil
+}
+
+// DeleteServicePermanently removes a soft-deleted service; its logs,
+// incidents and maintenance windows go with it through ON DELETE CASCADE
+func (r *DbRepository) DeleteServicePermanently(ctx context.Context, id uint) error {
+	res := r.db.WithContext(ctx).Unscoped().
+		Where("deleted_at IS NOT NULL").
+		Delete(&models.ExternalService{}, id)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// RestoreService brings back a soft-deleted service and marks its archives
+// restored. It comes back PENDING with its failure streak, acknowledgement
+// and schedule cleared, so the scheduler checks it as if newly registered.
+func (r *DbRepository) RestoreService(ctx context.Context, id uint, at time.Time) (*models.ExternalService, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Unscoped().Model(&models.ExternalService{}).
+			Where("id = ? AND deleted_at IS NOT NULL", id).
+			Updates(map[string]interface{}{
+				"deleted_at":           gorm.Expr("NULL"),
+				"status":               models.StatusPending,
+				"consecutive_failures": 0,
+				"flapping":             false,
+				"acknowledgement":      gorm.Expr("NULL"),
+				"next_run_at":          gorm.Expr("NULL"),
+				"in_flight_until":      gorm.Expr("NULL"),
+				"last_round_at":        gorm.Expr("NULL"),
+				"remediation_attempts": 0,
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		return tx.Model(&models.ServiceArchive{}).
+			Where("service_id = ? AND restored_at IS NULL", id).
+			Update("restored_at", at).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.GetServiceByID(ctx, id)
+}
+
+// checkDeletedName refuses the name of a soft-deleted service, whose row
+// holds the unique name until it is restored or deleted permanently
+func (r *DbRepository) checkDeletedName(ctx context.Context, name string) error {
+	var deleted models.ExternalService
+
+	err := r.db.WithContext(ctx).Unscoped().
+		Select("id").
+		Where("name = ? AND deleted_at IS NOT NULL", name).
+		Take(&deleted).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("service name %q belongs to deleted service %d; restore it or delete it permanently", name, deleted.ID)
+}
+
+// ListServiceArchives returns archived services, newest first, optionally filtered by name
+func (r *DbRepository) ListServiceArchives(ctx context.Context, name string) ([]*models.ServiceArchive, error) {
+	var archives []*models.ServiceArchive
+
+	query := r.db.WithContext(ctx).Order("deleted_at DESC")
+	if name != "" {
+		query = query.Where("name = ?", name)
+	}
+
+	if err := query.Find(&archives).Error; err != nil {
+		return nil, err
+	}
+
+	return archives, nil
+}
+
+func (r *DbRepository) GetServiceArchive(ctx context.Context, id uint) (*models.ServiceArchive, error) {
+	var archive models.ServiceArchive
+
+	if err := r.db.WithContext(ctx).First(&archive, id).Error; err != nil {
+		return nil, err
+	}
+
+	return &archive, nil
+}
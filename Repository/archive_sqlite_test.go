@@ -0,0 +1,64 @@
+//go:build sqlite
+
+package Repository
+
+import (
+	"Distributed-Health-Monitoring/config"
+	"Distributed-Health-Monitoring/models"
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckDeletedName(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Database.Driver = "sqlite"
+	cfg.Database.SQLite.Path = filepath.Join(t.TempDir(), "monitor.db")
+	db, err := config.ConnectDatabase(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := config.AutoMigrate(db, &models.ExternalService{}); err != nil {
+		t.Fatal(err)
+	}
+	r := &DbRepository{db: db}
+
+	create := func(name string) *models.ExternalService {
+		s := &models.ExternalService{Name: name, URL: "https://example.com/health", Protocol: models.ProtocolHTTP, Interval: 60}
+		if err := db.Create(s).Error; err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	create("live")
+	deleted := create("deleted")
+	if err := db.Delete(deleted).Error; err != nil {
+		t.Fatal(err)
+	}
+	purged := create("purged")
+	if err := db.Unscoped().Delete(purged).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"unused", ""},
+		{"live", ""},
+		{"purged", ""},
+		{"deleted", fmt.Sprintf("service name %q belongs to deleted service %d; restore it or delete it permanently", "deleted", deleted.ID)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := r.checkDeletedName(context.Background(), tt.name)
+			switch {
+			case tt.want == "" && err != nil:
+				t.Errorf("checkDeletedName(%q) = %v, want nil", tt.name, err)
+			case tt.want != "" && (err == nil || err.Error() != tt.want):
+				t.Errorf("checkDeletedName(%q) = %v, want %q", tt.name, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package Repository
+
+import (
+	"Distributed-Health-Monitoring/models"
+	"context"
+	"errors"
+)
+
+func (r *DbRepository) CreateAuditLog(ctx context.Context, entry *models.AuditLog) error {
+	if entry == nil {
+		return errors.New("audit log entry is nil")
+	}
+	return r.db.WithContext(ctx).Create(entry).Error
+}
+
+// ListAuditLogs returns a page of entries matching the filter, newest first,
+// and the total number of matches
+func (r *DbRepository) ListAuditLogs(ctx context.Context, filter models.AuditFilter) ([]models.AuditLog, int64, error) {
+	var entries []models.AuditLog
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.AuditLog{})
+	if filter.Entity != "" {
+		query = query.Where("entity = ?", filter.Entity)
+	}
+	if filter.EntityID != 0 {
+		query = query.Where("entity_id = ?", filter.EntityID)
+	}
+	if filter.Actor != "" {
+		query = query.Where("actor = ?", filter.Actor)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if err := query.
+		Order("created_at DESC, id DESC").
+		Limit(filter.Limit).
+		Offset(filter.Offset).
+		Find(&entries).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return entries, total, nil
+}
@@ -0,0 +1,29 @@
+package Repository
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// epochQueries read the database clock as Unix seconds with a fraction, so
+// one scan works on every driver
+var epochQueries = map[string]string{
+	"postgres": "SELECT extract(epoch from clock_timestamp())::float8",
+	"mysql":    "SELECT UNIX_TIMESTAMP(NOW(6))",
+	"sqlite":   "SELECT (julianday('now') - 2440587.5) * 86400.0",
+}
+
+// DatabaseNow reads the current time of the database server in UTC
+func (r *DbRepository) DatabaseNow(ctx context.Context) (time.Time, error) {
+	query, ok := epochQueries[r.db.Dialector.Name()]
+	if !ok {
+		query = epochQueries["postgres"]
+	}
+	var epoch float64
+	if err := r.db.WithContext(ctx).Raw(query).Scan(&epoch).Error; err != nil {
+		return time.Time{}, err
+	}
+	sec, frac := math.Modf(epoch)
+	return time.Unix(int64(sec), int64(frac*1e9)).UTC(), nil
+}
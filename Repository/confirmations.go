@@ -0,0 +1,45 @@
+package Repository
+
+import (
+	"Distributed-Health-Monitoring/models"
+	"context"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+// SaveConfirmationResult records one vote on a failed check, replacing a
+// vote the same check cast before
+func (r *DbRepository) SaveConfirmationResult(ctx context.Context, result *models.ConfirmationResult) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{UpdateAll: true}).
+		Create(result).Error
+}
+
+func (r *DbRepository) ListConfirmationResults(ctx context.Context, serviceID uint) ([]models.ConfirmationResult, error) {
+	var results []models.ConfirmationResult
+
+	if err := r.db.WithContext(ctx).
+		Where("external_service_id = ?", serviceID).
+		Order("round_at ASC, recheck ASC").
+		Find(&results).Error; err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// PruneConfirmationResults deletes the votes on the service's failed checks
+// that were due before the given round
+func (r *DbRepository) PruneConfirmationResults(ctx context.Context, serviceID uint, before time.Time) error {
+	return r.db.WithContext(ctx).
+		Where("external_service_id = ? AND round_at < ?", serviceID, before).
+		Delete(&models.ConfirmationResult{}).Error
+}
+
+// ClearConfirmationResults deletes every vote on the service's failed checks
+func (r *DbRepository) ClearConfirmationResults(ctx context.Context, serviceID uint) error {
+	return r.db.WithContext(ctx).
+		Where("external_service_id = ?", serviceID).
+		Delete(&models.ConfirmationResult{}).Error
+}
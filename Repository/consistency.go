@@ -0,0 +1,146 @@
+package Repository
+
+import (
+	"Distributed-Health-Monitoring/cache"
+	"Distributed-Health-Monitoring/models"
+	"context"
+	"fmt"
+	"time"
+)
+
+// consistencySettle skips services checked more recently than this: the
+// worker writes the status and the incident in separate steps, and a replica
+// booting between them must not "repair" a transition in progress
+const consistencySettle = time.Minute
+
+// CheckConsistency looks for state that contradicts itself and, with repair,
+// fixes it:
+//   - a service short of DOWN whose consecutive failures reached the threshold is marked DOWN
+//   - an open incident of an UP or DEGRADED service is resolved at its last check
+//   - a DOWN service without an open incident gets one, starting at its last check
+//   - logs of ids that are neither a service nor an archive are deleted
+func (r *DbRepository) CheckConsistency(ctx context.Context, repair bool) (*models.ConsistencyReport, error) {
+	report := &models.ConsistencyReport{Issues: make([]models.ConsistencyIssue, 0), CheckedAt: time.Now()}
+	db := r.db.WithContext(ctx)
+
+	record := func(issue models.ConsistencyIssue, fix func() error) {
+		if repair {
+			if err := fix(); err != nil {
+				issue.Error = err.Error()
+			} else {
+				issue.Repaired = true
+				report.Repaired++
+			}
+		}
+		report.Issues = append(report.Issues, issue)
+	}
+
+	// Logged ids are read before the services, so a service registered in
+	// between can't have its first logs mistaken for orphans
+	logIDs, err := r.logs.ServiceIDs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list logged services: %w", err)
+	}
+
+	var services []*models.ExternalService
+	if err := db.Find(&services).Error; err != nil {
+		return nil, err
+	}
+	var open []models.Incident
+	if err := db.Where("status = ?", "open").Find(&open).Error; err != nil {
+		return nil, err
+	}
+	openFor := make(map[uint]models.Incident, len(open))
+	for _, i := range open {
+		openFor[i.ExternalServiceID] = i
+	}
+
+	settled := report.CheckedAt.Add(-consistencySettle)
+	for _, s := range services {
+		if s.LastCheckedAt != nil && s.LastCheckedAt.After(settled) {
+			continue
+		}
+		lastCheck := report.CheckedAt
+		if s.LastCheckedAt != nil {
+			lastCheck = *s.LastCheckedAt
+		}
+
+		if s.Status != "DOWN" && s.FailureThreshold > 0 && s.ConsecutiveFailures >= s.FailureThreshold {
+			record(models.ConsistencyIssue{
+				Check:     "status_failures_mismatch",
+				ServiceID: s.ID,
+				Service:   s.Name,
+				Detail:    fmt.Sprintf("status is %s with %d consecutive failures, threshold %d", s.Status, s.ConsecutiveFailures, s.FailureThreshold),
+				Repair:    "mark DOWN",
+			}, func() error {
+				if err := db.Model(&models.ExternalService{}).Where("id = ?", s.ID).Update("status", "DOWN").Error; err != nil {
+					return err
+				}
+				s.Status = "DOWN"
+				cache.Services.Update(s.ID, func(cached *models.ExternalService) { cached.Status = "DOWN" })
+				return nil
+			})
+		}
+
+		incident, hasOpen := openFor[s.ID]
+		switch {
+		case hasOpen && models.IsAvailable(s.Status):
+			at := lastCheck
+			if at.Before(incident.StartedAt) {
+				at = incident.StartedAt
+			}
+			record(models.ConsistencyIssue{
+				Check:     "open_incident_for_available_service",
+				ServiceID: s.ID,
+				Service:   s.Name,
+				Detail:    fmt.Sprintf("incident %d is open but the service is %s", incident.ID, s.Status),
+				Repair:    "resolve the incident at the last check",
+			}, func() error {
+				_, err := r.ResolveIncident(ctx, s.ID, at)
+				return err
+			})
+
+		case !hasOpen && s.Status == "DOWN":
+			record(models.ConsistencyIssue{
+				Check:     "down_without_incident",
+				ServiceID: s.ID,
+				Service:   s.Name,
+				Detail:    "service is DOWN without an open incident",
+				Repair:    "open an incident at the last check",
+			}, func() error {
+				_, err := r.OpenIncident(ctx, s.ID, "consistency_repair", "opened by the consistency check", lastCheck)
+				return err
+			})
+		}
+	}
+
+	// Archived services keep their logs in external log stores, so those ids aren't orphans
+	known := make(map[uint]bool, len(services))
+	for _, s := range services {
+		known[s.ID] = true
+	}
+	var archived []uint
+	if err := db.Model(&models.ServiceArchive{}).Pluck("service_id", &archived).Error; err != nil {
+		return nil, err
+	}
+	for _, id := range archived {
+		known[id] = true
+	}
+
+	for _, id := range logIDs {
+		if known[id] {
+			continue
+		}
+		record(models.ConsistencyIssue{
+			Check:     "orphaned_logs",
+			ServiceID: id,
+			Detail:    "check logs belong to a service that neither exists nor is archived",
+			Repair:    "delete the logs",
+		}, func() error {
+			_, err := r.logs.DeleteService(ctx, id)
+			return err
+		})
+	}
+
+	return report, nil
+}
@@ -0,0 +1,51 @@
+package Repository
+
+import (
+	"Distributed-Health-Monitoring/database"
+	"Distributed-Health-Monitoring/models"
+	"Distributed-Health-Monitoring/secrets"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// validateDatabase checks the DSN and query of a DATABASE service and sets
+// its URL to the DSN without credentials, so lists and alerts can show it
+func validateDatabase(service *models.ExternalService) error {
+	if service.Protocol != models.ProtocolDatabase {
+		if service.DatabaseDSN != "" || service.DatabaseQuery != "" {
+			return errors.New("service database_dsn and database_query are only supported for DATABASE checks")
+		}
+		return nil
+	}
+	if service.DatabaseDSN == "" {
+		return errors.New("service database_dsn is empty")
+	}
+	if _, err := database.Engine(service.DatabaseDSN); err != nil {
+		return fmt.Errorf("service database_dsn is invalid: %w", err)
+	}
+	u, _ := url.Parse(service.DatabaseDSN)
+	for key := range u.Query() {
+		if strings.EqualFold(key, "password") {
+			return errors.New("service database_dsn password must be in the user info, not a parameter")
+		}
+	}
+	if u.User.Username() == secrets.RedactedPassword {
+		return errors.New("service database_dsn user is redacted; send the real user")
+	}
+	if password, ok := u.User.Password(); ok {
+		if password == secrets.RedactedPassword {
+			return errors.New("service database_dsn password is redacted; send the real password")
+		}
+		if !secrets.Enabled() {
+			return errors.New("service database_dsn credentials require secrets.key to be configured")
+		}
+	}
+	service.DatabaseQuery = strings.TrimSpace(service.DatabaseQuery)
+	if err := database.ValidateQuery(service.DatabaseDSN, service.DatabaseQuery); err != nil {
+		return fmt.Errorf("service database_query is invalid: %w", err)
+	}
+	service.URL = database.Target(service.DatabaseDSN)
+	return nil
+}
@@ -0,0 +1,266 @@
+package Repository
+
+import (
+	"Distributed-Health-Monitoring/logstore"
+	"Distributed-Health-Monitoring/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type requiredIndex struct {
+	Table   string
+	Name    string
+	Columns []string
+}
+
+// requiredIndexes back the logs, stats and scheduler queries; the names match
+// the gorm tags in models so AutoMigrate and this check agree
+var requiredIndexes = []requiredIndex{
+	{Table: "service_check_logs", Name: "idx_service_time", Columns: []string{"external_service_id", "checked_at"}},
+	{Table: "incidents", Name: "idx_incident_service_status", Columns: []string{"external_service_id", "status"}},
+	{Table: "maintenance_windows", Name: "idx_maintenance_windows_external_service_id", Columns: []string{"external_service_id"}},
+	{Table: "external_services", Name: "idx_external_services_status", Columns: []string{"status"}},
+}
+
+const (
+	// Bloat is only worth a warning once the table is big enough to matter
+	bloatMinDeadRows = 10000
+	bloatDeadPercent = 20.0
+
+	// Below this many rows Postgres prefers a sequential scan regardless of indexes
+	planMinRows = 10000
+)
+
+// CheckDBHealth verifies the required indexes exist, reports dead-tuple bloat
+// and checks that the hot log queries are planned as index scans. Missing
+// indexes are created with CREATE INDEX CONCURRENTLY when createMissing is set.
+func (r *DbRepository) CheckDBHealth(ctx context.Context, createMissing bool) (*models.DBHealthReport, error) {
+	if r.db.Dialector.Name() != "postgres" {
+		return nil, ErrNeedsPostgres
+	}
+
+	report := &models.DBHealthReport{
+		Indexes:   make([]models.IndexStatus, 0, len(requiredIndexes)),
+		Tables:    make([]models.TableHealth, 0),
+		Plans:     make([]models.PlanCheck, 0),
+		Warnings:  make([]string, 0),
+		CheckedAt: time.Now(),
+	}
+
+	db := r.db.WithContext(ctx)
+
+	// Check logs only live in the main database with the gorm-backed stores
+	_, logsInDB := logstore.Unwrap(r.logs).(*logstore.GormStore)
+
+	var tables []string
+	for _, idx := range requiredIndexes {
+		if idx.Table == "service_check_logs" && !logsInDB {
+			continue
+		}
+
+		var exists bool
+		if err := db.Raw(
+			"SELECT EXISTS (SELECT 1 FROM pg_indexes WHERE schemaname = current_schema() AND tablename = ? AND indexname = ?)",
+			idx.Table, idx.Name,
+		).Scan(&exists).Error; err != nil {
+			return nil, err
+		}
+
+		status := models.IndexStatus{Table: idx.Table, Name: idx.Name, Columns: idx.Columns, Exists: exists}
+		if !exists && createMissing {
+			// Identifiers come from the static list above, never from input
+			err := db.Exec(fmt.Sprintf(
+				"CREATE INDEX CONCURRENTLY IF NOT EXISTS %s ON %s (%s)",
+				idx.Name, idx.Table, strings.Join(idx.Columns, ", "),
+			)).Error
+			if err != nil {
+				status.Error = err.Error()
+				report.Warnings = append(report.Warnings, fmt.Sprintf("failed to create index %s on %s: %v", idx.Name, idx.Table, err))
+			} else {
+				status.Exists = true
+				status.Created = true
+			}
+		} else if !exists {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("missing index %s on %s(%s)", idx.Name, idx.Table, strings.Join(idx.Columns, ", ")))
+		}
+		report.Indexes = append(report.Indexes, status)
+
+		if len(tables) == 0 || tables[len(tables)-1] != idx.Table {
+			tables = append(tables, idx.Table)
+		}
+	}
+
+	for _, table := range tables {
+		health, ok, err := r.tableHealth(ctx, table)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		report.Tables = append(report.Tables, health)
+
+		if health.DeadRows >= bloatMinDeadRows && health.DeadPercent >= bloatDeadPercent {
+			report.Warnings = append(report.Warnings, fmt.Sprintf(
+				"table %s is bloated: %.1f%% dead rows (%d); run VACUUM or tune autovacuum",
+				health.Table, health.DeadPercent, health.DeadRows,
+			))
+		}
+	}
+
+	if !logsInDB {
+		return report, nil
+	}
+
+	plans, err := r.checkLogPlans(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range plans {
+		report.Plans = append(report.Plans, p)
+		if p.SeqScan {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("%s uses a sequential scan on service_check_logs", p.Query))
+		}
+	}
+
+	return report, nil
+}
+
+func (r *DbRepository) tableHealth(ctx context.Context, table string) (models.TableHealth, bool, error) {
+	var rows []struct {
+		LiveRows   int64
+		DeadRows   int64
+		TotalBytes int64
+		LastVacuum *time.Time
+	}
+	if err := r.db.WithContext(ctx).Raw(`
+		SELECT n_live_tup AS live_rows,
+		       n_dead_tup AS dead_rows,
+		       pg_total_relation_size(relid) AS total_bytes,
+		       GREATEST(last_vacuum, last_autovacuum) AS last_vacuum
+		FROM pg_stat_user_tables
+		WHERE schemaname = current_schema() AND relname = ?`, table,
+	).Scan(&rows).Error; err != nil {
+		return models.TableHealth{}, false, err
+	}
+	if len(rows) == 0 {
+		return models.TableHealth{}, false, nil
+	}
+
+	health := models.TableHealth{
+		Table:      table,
+		LiveRows:   rows[0].LiveRows,
+		DeadRows:   rows[0].DeadRows,
+		TotalBytes: rows[0].TotalBytes,
+		LastVacuum: rows[0].LastVacuum,
+	}
+	if total := health.LiveRows + health.DeadRows; total > 0 {
+		health.DeadPercent = float64(health.DeadRows) * 100 / float64(total)
+	}
+	return health, true, nil
+}
+
+// checkLogPlans runs EXPLAIN on the queries behind the logs and stats
+// endpoints. Skipped while the table is too small for the planner to care.
+func (r *DbRepository) checkLogPlans(ctx context.Context) ([]models.PlanCheck, error) {
+	health, ok, err := r.tableHealth(ctx, "service_check_logs")
+	if err != nil || !ok || health.LiveRows < planMinRows {
+		return nil, err
+	}
+
+	var serviceID uint
+	if err := r.db.WithContext(ctx).Raw("SELECT COALESCE(MIN(id), 0) FROM external_services").Scan(&serviceID).Error; err != nil {
+		return nil, err
+	}
+
+	queries := []struct {
+		name string
+		sql  string
+		args []interface{}
+	}{
+		{
+			name: "recent logs",
+			sql:  "SELECT * FROM service_check_logs WHERE external_service_id = ? ORDER BY checked_at DESC LIMIT 100",
+			args: []interface{}{serviceID},
+		},
+		{
+			name: "uptime since",
+			sql:  "SELECT count(*) FROM service_check_logs WHERE external_service_id = ? AND checked_at >= ?",
+			args: []interface{}{serviceID, time.Now().Add(-24 * time.Hour)},
+		},
+	}
+
+	checks := make([]models.PlanCheck, 0, len(queries))
+	for _, q := range queries {
+		var raw string
+		if err := r.db.WithContext(ctx).Raw("EXPLAIN (FORMAT JSON) "+q.sql, q.args...).Scan(&raw).Error; err != nil {
+			return nil, err
+		}
+
+		var plans []struct {
+			Plan planNode `json:"Plan"`
+		}
+		if err := json.Unmarshal([]byte(raw), &plans); err != nil || len(plans) == 0 {
+			return nil, fmt.Errorf("failed to parse query plan: %v", err)
+		}
+
+		plan := plans[0].Plan.scan()
+		if plan == "" {
+			plan = plans[0].Plan.NodeType
+		}
+		checks = append(checks, models.PlanCheck{
+			Query:   q.name,
+			SeqScan: plans[0].Plan.seqScanOn("service_check_logs"),
+			Plan:    plan,
+		})
+	}
+
+	return checks, nil
+}
+
+type planNode struct {
+	NodeType  string     `json:"Node Type"`
+	Relation  string     `json:"Relation Name"`
+	IndexName string     `json:"Index Name"`
+	Plans     []planNode `json:"Plans"`
+}
+
+func (n planNode) seqScanOn(table string) bool {
+	if n.NodeType == "Seq Scan" && n.Relation == table {
+		return true
+	}
+	for _, child := range n.Plans {
+		if child.seqScanOn(table) {
+			return true
+		}
+	}
+	return false
+}
+
+// scan describes the first scan node, which is what decides the cost here
+func (n planNode) scan() string {
+	if strings.Contains(n.NodeType, "Scan") {
+		if n.IndexName != "" {
+			return n.NodeType + " using " + n.IndexName
+		}
+		return n.NodeType + " on " + n.Relation
+	}
+	for _, child := range n.Plans {
+		if d := child.scan(); d != "" {
+			return d
+		}
+	}
+	return ""
+}
+
+// Ping checks that the main database accepts connections
+func (r *DbRepository) Ping(ctx context.Context) error {
+	sqlDB, err := r.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
@@ -0,0 +1,69 @@
+package Repository
+
+import (
+	"Distributed-Health-Monitoring/models"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// checkDependencyCycle rejects a service whose dependencies lead back to it.
+// Unknown names are allowed, so an import may list a service before the
+// services it depends on.
+func (r *DbRepository) checkDependencyCycle(ctx context.Context, service *models.ExternalService) error {
+	if len(service.DependsOn) == 0 {
+		return nil
+	}
+
+	var rows []models.ExternalService
+	if err := r.db.WithContext(ctx).Select("id", "name", "depends_on").Find(&rows).Error; err != nil {
+		return err
+	}
+	graph := make(map[string][]string, len(rows)+1)
+	for _, row := range rows {
+		// Skip the stored copy, which may still have an old name
+		if service.ID != 0 && row.ID == service.ID {
+			continue
+		}
+		graph[row.Name] = row.DependsOn
+	}
+	graph[service.Name] = service.DependsOn
+
+	if cycle := dependencyCycle(graph, service.Name); cycle != nil {
+		return fmt.Errorf("service dependencies form a cycle: %s", strings.Join(cycle, " -> "))
+	}
+	return nil
+}
+
+// dependencyCycle returns a path from start back to itself, or nil
+func dependencyCycle(graph map[string][]string, start string) []string {
+	visited := map[string]bool{start: true}
+	var path []string
+
+	var walk func(name string) bool
+	walk = func(name string) bool {
+		for _, parent := range graph[name] {
+			if parent == start {
+				path = append(path, parent)
+				return true
+			}
+			if visited[parent] {
+				continue
+			}
+			visited[parent] = true
+			if walk(parent) {
+				path = append(path, parent)
+				return true
+			}
+		}
+		return false
+	}
+
+	if !walk(start) {
+		return nil
+	}
+	path = append(path, start)
+	slices.Reverse(path)
+	return path
+}
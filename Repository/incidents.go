@@ -0,0 +1,184 @@
+package Repository
+
+import (
+	"Distributed-Health-Monitoring/models"
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// OpenIncident opens an incident for the service unless one is already open
+func (r *DbRepository) OpenIncident(ctx context.Context, serviceID uint, reason string, cause string, at time.Time) (*models.Incident, error) {
+	open, err := r.GetOpenIncident(ctx, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	if open != nil {
+		return open, nil
+	}
+
+	incident := &models.Incident{
+		ExternalServiceID: serviceID,
+		Status:            "open",
+		Reason:            reason,
+		Cause:             cause,
+		StartedAt:         at,
+	}
+
+	if err := r.db.WithContext(ctx).Create(incident).Error; err != nil {
+		return nil, err
+	}
+
+	return incident, nil
+}
+
+// ResolveIncident closes the open incident of the service, returning nil if there was none
+func (r *DbRepository) ResolveIncident(ctx context.Context, serviceID uint, at time.Time) (*models.Incident, error) {
+	open, err := r.GetOpenIncident(ctx, serviceID)
+	if err != nil || open == nil {
+		return nil, err
+	}
+
+	open.Status = "resolved"
+	open.ResolvedAt = &at
+
+	if err := r.db.WithContext(ctx).
+		Model(open).
+		Select("status", "resolved_at").
+		Updates(open).Error; err != nil {
+		return nil, err
+	}
+
+	return open, nil
+}
+
+// GetOpenIncident returns the open incident of the service, or nil
+func (r *DbRepository) GetOpenIncident(ctx context.Context, serviceID uint) (*models.Incident, error) {
+	var incident models.Incident
+
+	err := r.db.WithContext(ctx).
+		Where("external_service_id = ? AND status = ?", serviceID, "open").
+		Order("started_at DESC").
+		First(&incident).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &incident, nil
+}
+
+// ListIncidents returns every incident of the service, newest first
+func (r *DbRepository) ListIncidents(ctx context.Context, serviceID uint) ([]models.Incident, error) {
+	var incidents []models.Incident
+
+	if err := r.db.WithContext(ctx).
+		Where("external_service_id = ?", serviceID).
+		Order("started_at DESC").
+		Find(&incidents).Error; err != nil {
+		return nil, err
+	}
+
+	return incidents, nil
+}
+
+// ListIncidentsInRange returns every incident that was open at some point in
+// [from, to), including ones that started earlier or are still open, oldest first
+func (r *DbRepository) ListIncidentsInRange(ctx context.Context, from time.Time, to time.Time) ([]models.Incident, error) {
+	var incidents []models.Incident
+
+	if err := r.db.WithContext(ctx).
+		Where("started_at < ? AND (resolved_at IS NULL OR resolved_at >= ?)", to, from).
+		Order("started_at ASC").
+		Find(&incidents).Error; err != nil {
+		return nil, err
+	}
+
+	return incidents, nil
+}
+
+// ListOpenIncidents returns every open incident, oldest first
+func (r *DbRepository) ListOpenIncidents(ctx context.Context) ([]models.Incident, error) {
+	var incidents []models.Incident
+
+	if err := r.db.WithContext(ctx).
+		Where("status = ?", "open").
+		Order("started_at ASC").
+		Find(&incidents).Error; err != nil {
+		return nil, err
+	}
+
+	return incidents, nil
+}
+
+func (r *DbRepository) GetIncident(ctx context.Context, id uint) (*models.Incident, error) {
+	var incident models.Incident
+
+	if err := r.db.WithContext(ctx).First(&incident, id).Error; err != nil {
+		return nil, err
+	}
+
+	return &incident, nil
+}
+
+// AcknowledgeIncident marks an open incident as acknowledged; acknowledging twice keeps the first
+func (r *DbRepository) AcknowledgeIncident(ctx context.Context, id uint, by string, at time.Time) (*models.Incident, error) {
+	incident, err := r.GetIncident(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if incident.Status != "open" {
+		return nil, errors.New("incident is already resolved")
+	}
+	if incident.AcknowledgedAt != nil {
+		return incident, nil
+	}
+
+	incident.AcknowledgedAt = &at
+	incident.AcknowledgedBy = by
+
+	if err := r.db.WithContext(ctx).
+		Model(incident).
+		Select("acknowledged_at", "acknowledged_by").
+		Updates(incident).Error; err != nil {
+		return nil, err
+	}
+
+	return incident, nil
+}
+
+// ClaimEscalationStep moves an open, unacknowledged incident from level to
+// level+1. Only one caller wins, so replicas never fire the same step twice.
+func (r *DbRepository) ClaimEscalationStep(ctx context.Context, incidentID uint, level int) (bool, error) {
+	res := r.db.WithContext(ctx).
+		Model(&models.Incident{}).
+		Where("id = ? AND status = ? AND acknowledged_at IS NULL AND escalation_level = ?", incidentID, "open", level).
+		Update("escalation_level", level+1)
+	if res.Error != nil {
+		return false, res.Error
+	}
+
+	return res.RowsAffected == 1, nil
+}
+
+func (r *DbRepository) SaveIncidentEscalation(ctx context.Context, escalation *models.IncidentEscalation) error {
+	return r.db.WithContext(ctx).Create(escalation).Error
+}
+
+// ListIncidentEscalations returns the steps fired for an incident in order
+func (r *DbRepository) ListIncidentEscalations(ctx context.Context, incidentID uint) ([]models.IncidentEscalation, error) {
+	var escalations []models.IncidentEscalation
+
+	if err := r.db.WithContext(ctx).
+		Where("incident_id = ?", incidentID).
+		Order("fired_at ASC").
+		Find(&escalations).Error; err != nil {
+		return nil, err
+	}
+
+	return escalations, nil
+}
@@ -0,0 +1,36 @@
+package Repository
+
+import (
+	"Distributed-Health-Monitoring/models"
+	"context"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+// ClaimJob records that a worker took the job. It reports false when the
+// job was already taken, which is how a redelivered message is recognised.
+func (r *DbRepository) ClaimJob(ctx context.Context, job *models.ProcessedJob) (bool, error) {
+	res := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(job)
+	if res.Error != nil {
+		return false, res.Error
+	}
+
+	return res.RowsAffected == 1, nil
+}
+
+// ReleaseJob forgets a claim, so the job runs when it is delivered again
+func (r *DbRepository) ReleaseJob(ctx context.Context, jobID string) error {
+	return r.db.WithContext(ctx).
+		Delete(&models.ProcessedJob{}, "job_id = ?", jobID).Error
+}
+
+// PruneProcessedJobs deletes the claims that expired before the given time
+func (r *DbRepository) PruneProcessedJobs(ctx context.Context, before time.Time) (int64, error) {
+	res := r.db.WithContext(ctx).
+		Where("expires_at < ?", before).
+		Delete(&models.ProcessedJob{})
+	return res.RowsAffected, res.Error
+}
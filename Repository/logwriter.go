@@ -0,0 +1,309 @@
+package Repository
+
+import (
+	"Distributed-Health-Monitoring/logging"
+	"Distributed-Health-Monitoring/logstore"
+	"Distributed-Health-Monitoring/models"
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultLogBatchSize     = 500
+	defaultLogFlushInterval = 200 * time.Millisecond
+	defaultLogQueueSize     = 10000
+
+	// logFlushTimeout bounds one batch insert, and again its row by row retry
+	logFlushTimeout = 30 * time.Second
+)
+
+// LogWriterOptions turns on the buffered check log writer. Sizes that aren't
+// positive fall back to 500 rows, 200ms and 10000 queued logs.
+type LogWriterOptions struct {
+	Enabled       bool
+	BatchSize     int
+	FlushInterval time.Duration
+	QueueSize     int
+}
+
+// LogWriterStats are the counters of the buffered check log writer
+type LogWriterStats struct {
+	Enabled       bool
+	QueueDepth    int
+	QueueCapacity int
+	Written       uint64 // logs stored
+	Dropped       uint64 // logs lost after the row by row retry failed too
+	Flushes       uint64
+	FlushErrors   uint64  // batches the store rejected
+	FlushSeconds  float64 // total time spent writing batches
+	LastFlushMs   int64
+	LastBatchRows int64
+	// Checks that found the queue full and waited for the writer
+	BackpressureWaits   uint64
+	BackpressureSeconds float64
+}
+
+// logWriter queues check logs and writes them in batches from one goroutine,
+// so a worker's check costs one channel send instead of an insert. A batch is
+// written when it is full or when the flush interval passes. A full queue
+// makes callers wait, which holds back the queue consumer instead of
+// dropping logs or growing without bound.
+type logWriter struct {
+	store logstore.LogStore
+	opts  LogWriterOptions
+	queue chan logWrite
+	done  chan struct{}
+
+	mu     sync.RWMutex // held for reading while sending, so close can't race a send
+	closed bool
+
+	written, dropped, flushes, flushErrors, waits atomic.Uint64
+	flushNanos, waitNanos                         atomic.Int64
+	lastFlushMs, lastBatchRows                    atomic.Int64
+}
+
+// logWrite is one queued log, or a barrier: flushed is closed once every
+// log queued before it is written
+type logWrite struct {
+	entry   *models.ServiceCheckLog
+	flushed chan struct{}
+}
+
+func newLogWriter(store logstore.LogStore, opts LogWriterOptions) *logWriter {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultLogBatchSize
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = defaultLogFlushInterval
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = defaultLogQueueSize
+	}
+
+	w := &logWriter{
+		store: store,
+		opts:  opts,
+		queue: make(chan logWrite, opts.QueueSize),
+		done:  make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// enqueue hands a log to the writer, waiting while the queue is full. After
+// close the log is saved directly.
+func (w *logWriter) enqueue(ctx context.Context, entry *models.ServiceCheckLog) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.closed {
+		return w.store.Save(ctx, entry)
+	}
+
+	select {
+	case w.queue <- logWrite{entry: entry}:
+		return nil
+	default:
+	}
+
+	start := time.Now()
+	w.waits.Add(1)
+	defer func() { w.waitNanos.Add(int64(time.Since(start))) }()
+
+	select {
+	case w.queue <- logWrite{entry: entry}:
+		return nil
+	case <-ctx.Done():
+		w.dropped.Add(1)
+		return ctx.Err()
+	}
+}
+
+// sync waits until every log queued before the call is written
+func (w *logWriter) sync(ctx context.Context) error {
+	w.mu.RLock()
+	if w.closed {
+		w.mu.RUnlock()
+		select {
+		case <-w.done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	barrier := logWrite{flushed: make(chan struct{})}
+	select {
+	case w.queue <- barrier:
+		w.mu.RUnlock()
+	case <-ctx.Done():
+		w.mu.RUnlock()
+		return ctx.Err()
+	}
+
+	select {
+	case <-barrier.flushed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (w *logWriter) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.opts.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*models.ServiceCheckLog, 0, w.opts.BatchSize)
+	for {
+		select {
+		case write, ok := <-w.queue:
+			if !ok {
+				w.flush(batch)
+				return
+			}
+			if write.flushed != nil {
+				w.flush(batch)
+				batch = batch[:0]
+				close(write.flushed)
+				continue
+			}
+			batch = append(batch, write.entry)
+			if len(batch) >= w.opts.BatchSize {
+				w.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				w.flush(batch)
+				batch = batch[:0]
+			}
+		}
+	}
+}
+
+// flush writes one batch. When the store refuses it, the rows are retried
+// one at a time so a single bad row doesn't cost the others.
+func (w *logWriter) flush(batch []*models.ServiceCheckLog) {
+	if len(batch) == 0 {
+		return
+	}
+
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), logFlushTimeout)
+	err := logstore.SaveBatch(ctx, w.store, batch)
+	cancel()
+
+	if err == nil {
+		w.written.Add(uint64(len(batch)))
+	} else {
+		w.flushErrors.Add(1)
+		logger := logging.For(context.Background(), "log_writer")
+		logger.Warn("batch_write_failed", "rows", len(batch), "err", err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), logFlushTimeout)
+		var dropped int
+		var lastErr error
+		for _, entry := range batch {
+			entry.ID = 0 // the failed insert may have assigned one
+			if err := w.store.Save(ctx, entry); err != nil {
+				dropped++
+				lastErr = err
+				continue
+			}
+			w.written.Add(1)
+		}
+		cancel()
+
+		if dropped > 0 {
+			w.dropped.Add(uint64(dropped))
+			logger.Error("logs_dropped", "rows", dropped, "err", lastErr)
+		}
+	}
+
+	elapsed := time.Since(start)
+	w.flushes.Add(1)
+	w.flushNanos.Add(int64(elapsed))
+	w.lastFlushMs.Store(elapsed.Milliseconds())
+	w.lastBatchRows.Store(int64(len(batch)))
+}
+
+// close writes what is still queued and stops the writer
+func (w *logWriter) close(ctx context.Context) error {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.queue)
+	}
+	w.mu.Unlock()
+
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (w *logWriter) stats() LogWriterStats {
+	return LogWriterStats{
+		Enabled:             true,
+		QueueDepth:          len(w.queue),
+		QueueCapacity:       cap(w.queue),
+		Written:             w.written.Load(),
+		Dropped:             w.dropped.Load(),
+		Flushes:             w.flushes.Load(),
+		FlushErrors:         w.flushErrors.Load(),
+		FlushSeconds:        time.Duration(w.flushNanos.Load()).Seconds(),
+		LastFlushMs:         w.lastFlushMs.Load(),
+		LastBatchRows:       w.lastBatchRows.Load(),
+		BackpressureWaits:   w.waits.Load(),
+		BackpressureSeconds: time.Duration(w.waitNanos.Load()).Seconds(),
+	}
+}
+
+// LogWriterStats reports the buffered writer; Enabled is false when check
+// logs are written synchronously
+func (r *DbRepository) LogWriterStats() LogWriterStats {
+	if r.writer == nil {
+		return LogWriterStats{}
+	}
+	return r.writer.stats()
+}
+
+// CheckLogID returns the id of a log returned by SaveServiceCheckLog. One
+// that went to the buffered writer has none yet, so the writer is flushed up
+// to it and the log is looked up by its service and check time. It is 0 when
+// the store assigns no ids.
+func (r *DbRepository) CheckLogID(ctx context.Context, entry models.ServiceCheckLog) (uint, error) {
+	if entry.ID != 0 || r.writer == nil {
+		return entry.ID, nil
+	}
+	if err := r.writer.sync(ctx); err != nil {
+		return 0, err
+	}
+
+	// The database may round the check time to its precision
+	logs, err := r.logs.Range(ctx, entry.ExternalServiceID, entry.CheckedAt.Add(-time.Microsecond), entry.CheckedAt.Add(time.Microsecond))
+	if err != nil {
+		return 0, err
+	}
+	for _, l := range logs {
+		if l.Region == entry.Region && l.Status == entry.Status {
+			return l.ID, nil
+		}
+	}
+	return 0, nil
+}
+
+// FlushCheckLogs writes the buffered check logs and stops buffering; later
+// logs are saved directly. Call it on shutdown.
+func (r *DbRepository) FlushCheckLogs(ctx context.Context) error {
+	if r.writer == nil {
+		return nil
+	}
+	return r.writer.close(ctx)
+}
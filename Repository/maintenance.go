@@ -0,0 +1,95 @@
+package Repository
+
+import (
+	"Distributed-Health-Monitoring/models"
+	"context"
+	"errors"
+	"time"
+)
+
+func (r *DbRepository) CreateMaintenanceWindow(ctx context.Context, window *models.MaintenanceWindow) error {
+
+	if window == nil {
+		return errors.New("maintenance window is nil")
+	}
+	if window.ExternalServiceID == 0 {
+		return errors.New("maintenance window service id is empty")
+	}
+	if !window.EndsAt.After(window.StartsAt) {
+		return errors.New("maintenance window must end after it starts")
+	}
+	if window.Recurrence == "" {
+		window.Recurrence = "none"
+	}
+
+	switch window.Recurrence {
+	case "none":
+	case "daily":
+		if window.EndsAt.Sub(window.StartsAt) >= 24*time.Hour {
+			return errors.New("daily maintenance window must be shorter than a day")
+		}
+	case "weekly":
+		if window.EndsAt.Sub(window.StartsAt) >= 7*24*time.Hour {
+			return errors.New("weekly maintenance window must be shorter than a week")
+		}
+	default:
+		return errors.New("maintenance window recurrence is invalid")
+	}
+
+	return r.db.WithContext(ctx).Create(window).Error
+}
+
+// ListMaintenanceWindows returns the windows of one service, or of all services when serviceID is 0
+func (r *DbRepository) ListMaintenanceWindows(ctx context.Context, serviceID uint) ([]*models.MaintenanceWindow, error) {
+	var windows []*models.MaintenanceWindow
+
+	query := r.db.WithContext(ctx).Order("starts_at ASC")
+	if serviceID != 0 {
+		query = query.Where("external_service_id = ?", serviceID)
+	}
+
+	if err := query.Find(&windows).Error; err != nil {
+		return nil, err
+	}
+
+	return windows, nil
+}
+
+// GetMaintenanceWindow returns gorm.ErrRecordNotFound when there is no such window
+func (r *DbRepository) GetMaintenanceWindow(ctx context.Context, id uint) (*models.MaintenanceWindow, error) {
+	var window models.MaintenanceWindow
+
+	if err := r.db.WithContext(ctx).First(&window, id).Error; err != nil {
+		return nil, err
+	}
+
+	return &window, nil
+}
+
+func (r *DbRepository) DeleteMaintenanceWindow(ctx context.Context, id uint) error {
+	res := r.db.WithContext(ctx).Delete(&models.MaintenanceWindow{}, id)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errors.New("maintenance window not found")
+	}
+	return nil
+}
+
+// ServicesInMaintenance returns the ids of services with a window active at t
+func (r *DbRepository) ServicesInMaintenance(ctx context.Context, t time.Time) (map[uint]bool, error) {
+	windows, err := r.ListMaintenanceWindows(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	active := make(map[uint]bool)
+	for _, w := range windows {
+		if w.ActiveAt(t) {
+			active[w.ExternalServiceID] = true
+		}
+	}
+
+	return active, nil
+}
@@ -0,0 +1,41 @@
+package Repository
+
+import (
+	"Distributed-Health-Monitoring/models"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const (
+	maxOwnerLength       = 255
+	maxTeamLength        = 100
+	maxDescriptionLength = 1000
+	maxRunbookURLLength  = 500
+)
+
+// validateOwnership trims the ownership fields, which are repeated in every
+// alert, and checks the runbook is a link a responder can open
+func validateOwnership(service *models.ExternalService) error {
+	service.Owner = strings.TrimSpace(service.Owner)
+	service.Team = strings.TrimSpace(service.Team)
+	service.RunbookURL = strings.TrimSpace(service.RunbookURL)
+	service.Description = strings.TrimSpace(service.Description)
+
+	if len(service.Owner) > maxOwnerLength {
+		return fmt.Errorf("service owner must be at most %d characters", maxOwnerLength)
+	}
+	if len(service.Team) > maxTeamLength {
+		return fmt.Errorf("service team must be at most %d characters", maxTeamLength)
+	}
+	if len(service.Description) > maxDescriptionLength {
+		return fmt.Errorf("service description must be at most %d characters", maxDescriptionLength)
+	}
+	if service.RunbookURL != "" {
+		u, err := url.Parse(service.RunbookURL)
+		if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") || len(service.RunbookURL) > maxRunbookURLLength {
+			return fmt.Errorf("service runbook_url must be an http or https url of at most %d characters", maxRunbookURLLength)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package Repository
+
+import (
+	"Distributed-Health-Monitoring/cache"
+	"Distributed-Health-Monitoring/models"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// PurgeServices permanently removes services, everything recorded about them
+// and the given archives, returning the number of rows removed per table.
+// Check logs go first since the log store may live outside the main database;
+// if the transaction then fails the purge can simply be run again.
+func (r *DbRepository) PurgeServices(ctx context.Context, serviceIDs []uint, archiveIDs []uint) (map[string]int64, error) {
+	counts := map[string]int64{}
+
+	// Archived services may still be soft-deleted rows with their history, and
+	// keep their logs in external log stores either way, so their ids count too
+	logIDs := append([]uint{}, serviceIDs...)
+	if len(archiveIDs) > 0 {
+		var archived []uint
+		if err := r.db.WithContext(ctx).Model(&models.ServiceArchive{}).Where("id IN ?", archiveIDs).Pluck("service_id", &archived).Error; err != nil {
+			return nil, err
+		}
+		logIDs = append(logIDs, archived...)
+	}
+	for _, id := range logIDs {
+		n, err := r.logs.DeleteService(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		counts["service_check_logs"] += n
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(logIDs) > 0 {
+			incidents := tx.Model(&models.Incident{}).Select("id").Where("external_service_id IN ?", logIDs)
+			steps := []struct {
+				table string
+				query *gorm.DB
+				model interface{}
+			}{
+				{"incident_escalations", tx.Where("incident_id IN (?)", incidents), &models.IncidentEscalation{}},
+				{"incidents", tx.Where("external_service_id IN ?", logIDs), &models.Incident{}},
+				{"maintenance_windows", tx.Where("external_service_id IN ?", logIDs), &models.MaintenanceWindow{}},
+				{"last_responses", tx.Where("external_service_id IN ?", logIDs), &models.LastResponse{}},
+				{"external_services", tx.Unscoped().Where("id IN ?", logIDs), &models.ExternalService{}},
+			}
+			for _, step := range steps {
+				res := step.query.Delete(step.model)
+				if res.Error != nil {
+					return res.Error
+				}
+				counts[step.table] = res.RowsAffected
+			}
+		}
+
+		if len(archiveIDs) > 0 {
+			res := tx.Where("id IN ?", archiveIDs).Delete(&models.ServiceArchive{})
+			if res.Error != nil {
+				return res.Error
+			}
+			counts["service_archives"] = res.RowsAffected
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range serviceIDs {
+		cache.Services.Delete(id)
+	}
+	return counts, nil
+}
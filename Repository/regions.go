@@ -0,0 +1,50 @@
+package Repository
+
+import (
+	"Distributed-Health-Monitoring/models"
+	"context"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+// SaveRegionResult replaces the latest result of the service from the region
+func (r *DbRepository) SaveRegionResult(ctx context.Context, result *models.RegionResult) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{UpdateAll: true}).
+		Create(result).Error
+}
+
+func (r *DbRepository) ListRegionResults(ctx context.Context, serviceID uint) ([]models.RegionResult, error) {
+	var results []models.RegionResult
+
+	if err := r.db.WithContext(ctx).
+		Where("external_service_id = ?", serviceID).
+		Order("region ASC").
+		Find(&results).Error; err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// ClaimRegionRound records that the round's result is being applied, so the
+// state changes once per round even when several regions, or several
+// confirmation re-checks, decide it at once. It reports false when the round
+// or a later one was already applied.
+//
+// Regional and confirmed rounds share last_round_at. That is only safe
+// because validateConfirmation rejects confirmation on a service with
+// regions: a service claims rounds of one kind, and both are keyed by the
+// job's due time, so switching kinds keeps the claims ordered.
+func (r *DbRepository) ClaimRegionRound(ctx context.Context, serviceID uint, roundAt time.Time) (bool, error) {
+	res := r.db.WithContext(ctx).
+		Model(&models.ExternalService{}).
+		Where("id = ? AND (last_round_at IS NULL OR last_round_at < ?)", serviceID, roundAt).
+		Update("last_round_at", roundAt)
+	if res.Error != nil {
+		return false, res.Error
+	}
+
+	return res.RowsAffected == 1, nil
+}
@@ -0,0 +1,106 @@
+package Repository
+
+import (
+	"Distributed-Health-Monitoring/models"
+	"context"
+	"errors"
+	"net/url"
+	"regexp"
+	"time"
+)
+
+const (
+	defaultRemediationCooldown    = 300
+	defaultRemediationMaxAttempts = 3
+)
+
+// kubernetesName matches namespace and deployment names (RFC 1123 labels)
+var kubernetesName = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// validateRemediation checks the remediation action of a service and fills
+// in its defaults. Command names are checked against the config when the
+// action runs, since the repository doesn't see it.
+func validateRemediation(service *models.ExternalService) error {
+	r := service.Remediation
+	if r == nil {
+		return nil
+	}
+	if service.Protocol == models.ProtocolHeartbeat {
+		return errors.New("service remediation is not supported for heartbeat checks")
+	}
+
+	switch r.Type {
+	case models.RemediationWebhook:
+		u, err := url.Parse(r.URL)
+		if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			return errors.New("service remediation url must be an http or https url")
+		}
+	case models.RemediationCommand:
+		if r.Command == "" {
+			return errors.New("service remediation command is empty")
+		}
+	case models.RemediationKubernetes:
+		if !kubernetesName.MatchString(r.Namespace) || len(r.Namespace) > 63 {
+			return errors.New("service remediation namespace is invalid")
+		}
+		if !kubernetesName.MatchString(r.Deployment) || len(r.Deployment) > 253 {
+			return errors.New("service remediation deployment is invalid")
+		}
+	default:
+		return errors.New("service remediation type must be webhook, command or kubernetes")
+	}
+
+	if r.AfterFailures < 0 || r.CooldownSeconds < 0 || r.MaxAttempts < 0 {
+		return errors.New("service remediation after_failures, cooldown_seconds and max_attempts must not be negative")
+	}
+	if r.AfterFailures == 0 {
+		r.AfterFailures = service.FailureThreshold
+	}
+	if r.CooldownSeconds == 0 {
+		r.CooldownSeconds = defaultRemediationCooldown
+	}
+	if r.MaxAttempts == 0 {
+		r.MaxAttempts = defaultRemediationMaxAttempts
+	}
+	return nil
+}
+
+// ClaimRemediation counts a remediation run for the service's current
+// failure streak. attempts is the count the caller read; the claim fails
+// when another replica ran the action since, when the attempts are used up
+// or when the cooldown since the last run hasn't passed, so replicas never
+// run the same attempt twice.
+func (r *DbRepository) ClaimRemediation(ctx context.Context, serviceID uint, attempts int64, maxAttempts int64, cooldown time.Duration, at time.Time) (bool, error) {
+	res := r.db.WithContext(ctx).
+		Model(&models.ExternalService{}).
+		Where("id = ? AND remediation_attempts = ? AND remediation_attempts < ?", serviceID, attempts, maxAttempts).
+		Where("last_remediation_at IS NULL OR last_remediation_at <= ?", at.Add(-cooldown)).
+		Updates(map[string]interface{}{
+			"remediation_attempts": attempts + 1,
+			"last_remediation_at":  at,
+		})
+	if res.Error != nil {
+		return false, res.Error
+	}
+
+	return res.RowsAffected == 1, nil
+}
+
+func (r *DbRepository) SaveRemediationRun(ctx context.Context, run *models.RemediationRun) error {
+	return r.db.WithContext(ctx).Create(run).Error
+}
+
+// ListRemediationRuns returns the most recent remediation runs of a service, newest first
+func (r *DbRepository) ListRemediationRuns(ctx context.Context, serviceID uint, limit int) ([]models.RemediationRun, error) {
+	var runs []models.RemediationRun
+
+	if err := r.db.WithContext(ctx).
+		Where("external_service_id = ?", serviceID).
+		Order("started_at DESC, id DESC").
+		Limit(limit).
+		Find(&runs).Error; err != nil {
+		return nil, err
+	}
+
+	return runs, nil
+}
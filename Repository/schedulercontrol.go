@@ -0,0 +1,35 @@
+package Repository
+
+import (
+	"Distributed-Health-Monitoring/models"
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const schedulerControlID = 1
+
+// GetSchedulerControl returns the scheduler control row, or running when it
+// was never changed
+func (r *DbRepository) GetSchedulerControl(ctx context.Context) (*models.SchedulerControl, error) {
+	var control models.SchedulerControl
+
+	err := r.db.WithContext(ctx).First(&control, schedulerControlID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.SchedulerControl{ID: schedulerControlID, Mode: models.SchedulerRunning}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &control, nil
+}
+
+// SetSchedulerControl replaces the scheduler control row
+func (r *DbRepository) SetSchedulerControl(ctx context.Context, control *models.SchedulerControl) error {
+	control.ID = schedulerControlID
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{UpdateAll: true}).
+		Create(control).Error
+}
@@ -0,0 +1,48 @@
+package Repository
+
+import (
+	"Distributed-Health-Monitoring/models"
+	"Distributed-Health-Monitoring/secrets"
+	"context"
+)
+
+// EncryptSecrets encrypts credentials written before a key was configured
+// and returns how many values were rewritten. The raw columns are read so
+// the serializer doesn't hide whether a value is still plaintext, and
+// soft-deleted services are included since they can be restored.
+func (r *DbRepository) EncryptSecrets(ctx context.Context) (int, error) {
+	if !secrets.Enabled() {
+		return 0, nil
+	}
+
+	updated := 0
+	for _, column := range []string{"proxy_url", "database_dsn"} {
+		var rows []struct {
+			ID    uint
+			Value string
+		}
+		if err := r.db.WithContext(ctx).Unscoped().
+			Model(&models.ExternalService{}).
+			Select("id", column+" AS value").
+			Where(column + " <> ''").
+			Scan(&rows).Error; err != nil {
+			return updated, err
+		}
+
+		for _, row := range rows {
+			if secrets.IsEncrypted(row.Value) {
+				continue
+			}
+			encrypted, err := secrets.Encrypt(row.Value)
+			if err != nil {
+				return updated, err
+			}
+			if err := r.db.WithContext(ctx).
+				Exec("UPDATE external_services SET "+column+" = ? WHERE id = ?", encrypted, row.ID).Error; err != nil {
+				return updated, err
+			}
+			updated++
+		}
+	}
+	return updated, nil
+}
@@ -0,0 +1,42 @@
+package Repository
+
+import (
+	"Distributed-Health-Monitoring/models"
+	"errors"
+)
+
+const (
+	defaultSLOWindowDays = 30
+	maxSLOWindowDays     = 90
+)
+
+// validateSLO checks the objectives of a service and fills in the window
+func validateSLO(slo *models.SLO) error {
+	if slo == nil {
+		return nil
+	}
+	if slo.SuccessTarget == 0 && slo.LatencyPercentile == 0 {
+		return errors.New("service slo needs success_target, latency_percentile or both")
+	}
+	if slo.SuccessTarget != 0 && (slo.SuccessTarget <= 0 || slo.SuccessTarget >= 100) {
+		return errors.New("service slo success_target must be a percentage between 0 and 100, exclusive")
+	}
+	if slo.LatencyPercentile != 0 {
+		if slo.LatencyPercentile <= 0 || slo.LatencyPercentile >= 100 {
+			return errors.New("service slo latency_percentile must be between 0 and 100, exclusive")
+		}
+		if slo.LatencyThresholdMs <= 0 {
+			return errors.New("service slo latency_threshold_ms must be positive with latency_percentile")
+		}
+	} else if slo.LatencyThresholdMs != 0 {
+		return errors.New("service slo latency_threshold_ms needs latency_percentile")
+	}
+
+	if slo.WindowDays < 0 || slo.WindowDays > maxSLOWindowDays {
+		return errors.New("service slo window_days must be between 1 and 90")
+	}
+	if slo.WindowDays == 0 {
+		slo.WindowDays = defaultSLOWindowDays
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package Repository
+
+import (
+	"Distributed-Health-Monitoring/models"
+	"errors"
+	"fmt"
+)
+
+const maxRedirects = 20
+
+// validateSuccessCriteria checks the status codes and redirect settings of an HTTP service
+func validateSuccessCriteria(service *models.ExternalService) error {
+	c := service.SuccessCriteria
+	if c == nil {
+		return nil
+	}
+	if service.Protocol != "HTTP" && service.Protocol != "" {
+		return errors.New("service success_criteria is only supported for HTTP checks")
+	}
+	for _, r := range c.StatusCodes {
+		if _, _, err := models.ParseStatusRange(r); err != nil {
+			return fmt.Errorf("service success_criteria is invalid: %w", err)
+		}
+	}
+	for _, code := range append(append([]int{}, c.Accept...), c.Reject...) {
+		if code < 100 || code > 599 {
+			return fmt.Errorf("service success_criteria status code %d must be within 100-599", code)
+		}
+	}
+	if c.MaxRedirects < 0 || c.MaxRedirects > maxRedirects {
+		return fmt.Errorf("service success_criteria max_redirects must be between 0 and %d", maxRedirects)
+	}
+	if c.MaxRedirects != 0 && c.FollowRedirects != nil && !*c.FollowRedirects {
+		return errors.New("service success_criteria max_redirects needs follow_redirects")
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package Repository
+
+import (
+	"Distributed-Health-Monitoring/models"
+	"context"
+)
+
+// SaveStateTransition records a change of a service's status
+func (r *DbRepository) SaveStateTransition(ctx context.Context, transition *models.ServiceStateTransition) error {
+	return r.db.WithContext(ctx).Create(transition).Error
+}
+
+// ListStateTransitions returns a page of the transitions of a service and
+// how many match the filter in total
+func (r *DbRepository) ListStateTransitions(ctx context.Context, filter models.TransitionFilter) ([]models.ServiceStateTransition, int64, error) {
+	q := r.db.WithContext(ctx).Model(&models.ServiceStateTransition{}).Where("external_service_id = ?", filter.ServiceID)
+	if filter.From != nil {
+		q = q.Where("changed_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		q = q.Where("changed_at < ?", *filter.To)
+	}
+
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	order := "changed_at DESC, id DESC"
+	if filter.Order == "asc" {
+		order = "changed_at ASC, id ASC"
+	}
+	var transitions []models.ServiceStateTransition
+	if err := q.Order(order).Limit(filter.Limit).Offset(filter.Offset).Find(&transitions).Error; err != nil {
+		return nil, 0, err
+	}
+	return transitions, total, nil
+}
@@ -0,0 +1,68 @@
+package Repository
+
+import (
+	"Distributed-Health-Monitoring/models"
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+func (r *DbRepository) CreateWebhook(ctx context.Context, webhook *models.Webhook) error {
+	if webhook == nil {
+		return errors.New("webhook is nil")
+	}
+	return r.db.WithContext(ctx).Create(webhook).Error
+}
+
+func (r *DbRepository) ListWebhooks(ctx context.Context) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+
+	if err := r.db.WithContext(ctx).Order("id ASC").Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+// GetWebhook returns gorm.ErrRecordNotFound when there is no such webhook
+func (r *DbRepository) GetWebhook(ctx context.Context, id uint) (*models.Webhook, error) {
+	var webhook models.Webhook
+
+	if err := r.db.WithContext(ctx).First(&webhook, id).Error; err != nil {
+		return nil, err
+	}
+
+	return &webhook, nil
+}
+
+// DeleteWebhook removes the webhook with its delivery log
+func (r *DbRepository) DeleteWebhook(ctx context.Context, id uint) error {
+	res := r.db.WithContext(ctx).Delete(&models.Webhook{}, id)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *DbRepository) SaveWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	return r.db.WithContext(ctx).Create(delivery).Error
+}
+
+// ListWebhookDeliveries returns the most recent delivery attempts of a webhook, newest first
+func (r *DbRepository) ListWebhookDeliveries(ctx context.Context, webhookID uint, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+
+	if err := r.db.WithContext(ctx).
+		Where("webhook_id = ?", webhookID).
+		Order("attempted_at DESC, id DESC").
+		Limit(limit).
+		Find(&deliveries).Error; err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
@@ -2,25 +2,51 @@ package service
 
 import (
 	"Distributed-Health-Monitoring/Repository"
+	"Distributed-Health-Monitoring/apiv1"
 	"Distributed-Health-Monitoring/cache"
+	"Distributed-Health-Monitoring/clock"
 	"Distributed-Health-Monitoring/config"
+	"Distributed-Health-Monitoring/httpcheck"
+	"Distributed-Health-Monitoring/logging"
+	"Distributed-Health-Monitoring/logstore"
 	"Distributed-Health-Monitoring/models"
+	"Distributed-Health-Monitoring/notify"
+	"Distributed-Health-Monitoring/secrets"
 	"context"
 	"errors"
-	"log"
+	"fmt"
 	"net/http"
 	"runtime/debug"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/gorilla/websocket"
+	"gorm.io/gorm"
 )
 
 type Engine struct {
-	Repo   Repository.IRepository
-	router *gin.Engine
-	Cnfg   *config.Config
+	Repo     Repository.IRepository
+	router   *gin.Engine
+	Cnfg     *config.Config
+	Chaos    *ChaosInjector
+	Flapping *FlapDetector
+	Leader   *LeaderElector // nil unless ha.enabled; then only the leader schedules
+	Notifier *notify.Dispatcher
+
+	statusPage  statusPageCache
+	offboarding offboardJobs
+	bench       benchRuns
+	health      runtimeHealth
+	sched       schedulerState
+	clock       clockState
+	auth        authBackends
+	memQueue    *memoryQueue // shared by the scheduler and the workers with queue.driver memory
+	fairness    *fairnessTracker
+	webhooks    *webhookSender
+	ws          *wsServer
+	limits      *apiLimits
+	jobs        jobLedger  // nil with queue.driver memory
+	confirms    *Scheduler // publishes the re-checks confirming failures; nil unless this process runs a worker
 }
 
 func NewEngine() (*Engine, error) {
@@ -29,30 +55,119 @@ func NewEngine() (*Engine, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := logging.Setup(cnfg.Logging); err != nil {
+		return nil, err
+	}
+	if err := secrets.Setup(cnfg.Secrets); err != nil {
+		return nil, err
+	}
 
-	db, err := config.ConnectPostgres(cnfg)
+	db, err := config.ConnectDatabase(cnfg)
 	if err != nil {
 		return nil, err
 	}
 
-	db.AutoMigrate(&models.ExternalService{}, &models.ServiceCheckLog{})
+	if err := config.AutoMigrate(db, &models.ExternalService{}, &models.MaintenanceWindow{}, &models.Incident{}, &models.ServiceArchive{}, &models.LastResponse{}, &models.IncidentEscalation{}, &models.SchedulerControl{}, &models.Webhook{}, &models.WebhookDelivery{}, &models.RegionResult{}, &models.ConfirmationResult{}, &models.AuditLog{}, &models.RemediationRun{}, &models.ServiceAcknowledgement{}, &models.ServiceStateTransition{}, &models.ProcessedJob{}); err != nil {
+		return nil, err
+	}
+	if logstore.UsesMainDatabase(cnfg.LogStore) {
+		if err := config.AutoMigrate(db, &models.ServiceCheckLog{}); err != nil {
+			return nil, err
+		}
+	}
 
-	log.Println(cnfg.PostgreSQL.Database + "DATABASE " + "CONNECTED ")
+	logging.For(context.Background(), "db").Info("database_connected", "driver", db.Dialector.Name())
 
-	NuRepository := Repository.NewRepository(db)
+	logs, err := logstore.Open(cnfg.LogStore, db)
+	if err != nil {
+		return nil, err
+	}
+
+	NuRepository := Repository.NewRepository(db, logs, Repository.LogWriterOptions{
+		Enabled:       cnfg.LogStore.Batch.Enabled,
+		BatchSize:     cnfg.LogStore.Batch.BatchSize,
+		FlushInterval: time.Duration(cnfg.LogStore.Batch.FlushIntervalMs) * time.Millisecond,
+		QueueSize:     cnfg.LogStore.Batch.QueueSize,
+	})
 
 	if NuRepository == nil {
 		return nil, errors.New("repository is nil")
 	}
 
-	ginEngine := gin.Default()
+	if n, err := NuRepository.EncryptSecrets(context.Background()); err != nil {
+		return nil, fmt.Errorf("failed to encrypt stored secrets: %w", err)
+	} else if n > 0 {
+		logging.For(context.Background(), "db").Info("secrets_encrypted", "services", n)
+	}
 
-	
+	notifier, err := notify.NewDispatcher(cnfg.Notifications)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateEscalation(cnfg.Escalation, notifier); err != nil {
+		return nil, err
+	}
+	if err := validateGates(cnfg.Gates); err != nil {
+		return nil, err
+	}
+	if err := validateWebSocket(cnfg.WebSocket); err != nil {
+		return nil, err
+	}
+	if err := validateSLOAlerts(cnfg.SLO); err != nil {
+		return nil, err
+	}
+	if err := validateLogArchive(cnfg.LogArchive); err != nil {
+		return nil, err
+	}
+
+	httpcheck.Setup(cnfg.Worker.HTTP)
+	clusterBus = newCluster(cnfg.Cluster, cnfg.HA.Instance())
+
+	auth, err := newAuthBackends(cnfg.Auth)
+	if err != nil {
+		return nil, err
+	}
+
+	ginEngine := gin.New()
+	// nil trusts no proxy: the client IP is the connection's, so rate limits
+	// can't be dodged with a made-up X-Forwarded-For
+	if err := ginEngine.SetTrustedProxies(cnfg.Server.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("server.trusted_proxies: %w", err)
+	}
+	limits := newAPILimits(cnfg.Server)
+	ginEngine.Use(gin.Recovery(), logging.Middleware(), limits.rateLimit(), limits.limitBody())
+
+	var leader *LeaderElector
+	if cnfg.HA.Enabled {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return nil, err
+		}
+		leader, err = NewLeaderElector(sqlDB, db.Dialector.Name(), cnfg.HA.LockKey, cnfg.HA.Instance())
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	return &Engine{
 		Repo:   NuRepository,
 		router: ginEngine,
 		Cnfg:   cnfg,
+		Chaos:  NewChaosInjector(),
+		Flapping: NewFlapDetector(
+			time.Duration(cnfg.Flapping.WindowSeconds)*time.Second,
+			cnfg.Flapping.Threshold,
+		),
+		Leader:   leader,
+		Notifier: notifier,
+		health:   runtimeHealth{startedAt: time.Now()},
+		auth:     auth,
+		memQueue: newMemoryQueue(cnfg.Queue.Memory),
+		fairness: newFairnessTracker(cnfg.Fairness.Window, cnfg.Fairness.MinSamples),
+		webhooks: newWebhookSender(cnfg.Webhooks),
+		ws:       newWSServer(cnfg.WebSocket),
+		limits:   limits,
+		jobs:     newJobLedger(cnfg.Queue, NuRepository),
 	}, nil
 }
 
@@ -75,6 +190,10 @@ func (e *Engine) RegisterService(c *gin.Context) {
 		c.JSON(400, gin.H{"error": "service is nil"})
 		return
 	}
+	if err := checkReservedTags(service); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
 
 	err := e.Repo.RegisterService(c.Request.Context(), service)
 	if err != nil {
@@ -82,16 +201,80 @@ func (e *Engine) RegisterService(c *gin.Context) {
 		return
 	}
 
-	cache.MapExternalServices[service.ID] = service
+	cache.Services.Set(service)
+	e.audit(c.Request.Context(), auditCreate, auditService, service.ID, service.Name, "", nil, auditedService(service))
+	clusterBus.serviceChanged(service.ID)
+
+	BroadcastEvent(service.Name, models.ServiceStateChangeEvent{
+		Type:      "service_registered",
+		ServiceID: service.ID,
+		Name:      service.Name,
+		To:        service.Status,
+		Timestamp: time.Now(),
+	})
 
-	c.JSON(201, gin.H{"message": "service registered successfully", "service": service})
+	view := *service
+	redactSecrets(&view)
+	c.JSON(201, gin.H{"message": "service registered successfully", "service": view})
 }
 
 func (e *Engine) Run() error {
 
 	addr := config.GetServerAddress(e.Cnfg)
 
-	return e.router.Run(addr)
+	if e.Cnfg.GRPCAPI.Enabled {
+		return e.runWithGRPC(addr)
+	}
+	return newHTTPServer(addr, e.router, e.Cnfg.Server).ListenAndServe()
+}
+
+// newHTTPServer serves the REST API with the timeouts of the server config,
+// so slow clients can't hold connections open indefinitely
+func newHTTPServer(addr string, handler http.Handler, cfg config.Server) *http.Server {
+	seconds := func(v, def int) time.Duration {
+		if v <= 0 {
+			v = def
+		}
+		return time.Duration(v) * time.Second
+	}
+	maxHeader := cfg.MaxHeaderBytes
+	if maxHeader <= 0 {
+		maxHeader = 64 << 10
+	}
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: seconds(cfg.ReadHeaderTimeoutSeconds, 10),
+		ReadTimeout:       seconds(cfg.ReadTimeoutSeconds, 30),
+		WriteTimeout:      seconds(cfg.WriteTimeoutSeconds, 60),
+		IdleTimeout:       seconds(cfg.IdleTimeoutSeconds, 120),
+		MaxHeaderBytes:    maxHeader,
+	}
+}
+
+// clearDeadlines lifts the server's read and write timeouts from a
+// long-lived response, like a stream
+func clearDeadlines(w http.ResponseWriter) {
+	rc := http.NewResponseController(w)
+	rc.SetReadDeadline(time.Time{})
+	rc.SetWriteDeadline(time.Time{})
+}
+
+// shutdownTimeout bounds writing the buffered check logs on shutdown
+const shutdownTimeout = 10 * time.Second
+
+// Shutdown writes the check logs still buffered, so a restart doesn't lose
+// the last results
+func (e *Engine) Shutdown() {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	logger := logging.For(ctx, "main")
+	if err := e.Repo.FlushCheckLogs(ctx); err != nil {
+		logger.Error("check_log_flush_failed", "err", err, "queued", e.Repo.LogWriterStats().QueueDepth)
+		return
+	}
+	logger.Info("shutdown_complete")
 }
 
 func (e *Engine) SetupRoutes() {
@@ -101,24 +284,162 @@ func (e *Engine) SetupRoutes() {
 		c.JSON(200, gin.H{"message": "pong"})
 	})
 
+	// Orchestrator probes: liveness of this process, readiness including its dependencies
+	e.router.GET("/healthz", e.Healthz)
+	e.router.GET("/readyz", e.Readyz)
+
+	// OIDC login; /auth/me shows the role the caller ends up with
+	e.router.GET("/auth/login", e.Login)
+	e.router.GET("/auth/callback", e.LoginCallback)
+	e.router.POST("/auth/logout", e.Logout)
+	e.router.GET("/auth/me", e.requireRole(RoleViewer), e.WhoAmI)
+	e.router.POST("/auth/ws-token", e.requireRole(RoleViewer), e.IssueWebSocketToken)
+
+	// Public status page (no auth, only services flagged public)
+	e.router.GET("/status", e.GetStatusPage)
+	e.router.GET("/status.json", e.GetStatusJSON)
+
+	// Embedded dashboard; its script signs in and calls the API like any client
+	e.router.GET("/dashboard", e.GetDashboard)
+	e.router.GET("/dashboard/*filepath", e.GetDashboard)
+
+	// Batch status for deploy pipelines; unlike /status it covers private services
+	e.router.POST("/status/query", e.requireRole(RoleViewer), e.QueryStatuses)
+	e.router.GET("/gates/:name", e.requireRole(roleByMethod), e.EvaluateGate)
+	e.router.GET("/stats/incidents", e.requireRole(roleByMethod), e.GetIncidentStats)
+	e.router.GET("/slo/:serviceId", e.requireRole(roleByMethod), e.GetSLO)
+
+	// Grafana JSON datasource; its queries are read-only POSTs
+	grafana := e.router.Group("/grafana", e.requireRole(RoleViewer))
+	grafana.GET("", e.GrafanaTestConnection)
+	grafana.GET("/", e.GrafanaTestConnection)
+	grafana.POST("/search", e.GrafanaSearch)
+	grafana.POST("/query", e.GrafanaQuery)
+	grafana.POST("/annotations", e.GrafanaAnnotations)
+
+	// Prometheus scrape endpoint for this replica
+	e.router.GET("/metrics", e.requireRole(RoleViewer), e.Metrics)
+
 	// health-app group
 	health := e.router.Group("/health-app")
 	{
 		// External services routes
 		externalServices := health.Group("/externalServices")
-		externalServices.Use(BasicAuthMiddleware(e.Cnfg.Auth))
+		externalServices.Use(e.requireRole(roleByMethod))
 		{
-			externalServices.POST("/register", e.RegisterService)
-			externalServices.GET("/list", e.ListServices)
+			externalServices.POST("/register", deprecated(apiv1.Prefix+"/services"), e.RegisterService)
+			externalServices.GET("/list", deprecated(apiv1.Prefix+"/services"), e.ListServices)
+			externalServices.POST("/import", e.ImportServices)
+			externalServices.GET("/export", e.ExportServices)
+			externalServices.POST("/lint", e.LintServices)
+			externalServices.GET("/:id/heatmap", e.GetServiceHeatmap)
+			externalServices.GET("/:id/overview", e.GetServiceOverview)
+			externalServices.GET("/:id/last-response", e.GetLastResponse)
+			externalServices.GET("/:id/regions", e.GetServiceRegions)
+			externalServices.GET("/:id/remediations", e.ListRemediationRuns)
+			externalServices.POST("/:id/ack", e.AcknowledgeService)
+			externalServices.DELETE("/:id/ack", e.ClearServiceAcknowledgement)
+			externalServices.GET("/:id/acks", e.ListServiceAcknowledgements)
+			externalServices.DELETE("/:id", deprecated(apiv1.Prefix+"/services/:id"), e.DeleteService)
+			externalServices.POST("/:id/restore", deprecated(apiv1.Prefix+"/services/:id/restore"), e.RestoreService)
 		}
 
-		// Health check logs routes
+		// Incidents and acknowledgement
+		incidents := health.Group("/incidents")
+		incidents.Use(e.requireRole(roleByMethod))
+		{
+			incidents.GET("", deprecated(apiv1.Prefix+"/incidents"), e.ListOpenIncidents)
+			incidents.GET("/:id", deprecated(apiv1.Prefix+"/incidents/:id"), e.GetIncident)
+			incidents.POST("/:id/ack", deprecated(apiv1.Prefix+"/incidents/:id/ack"), e.AcknowledgeIncident)
+		}
+
+		// Notifier setup checks
+		notifiers := health.Group("/notifiers")
+		notifiers.Use(e.requireRole(roleByMethod))
+		{
+			notifiers.GET("", e.ListNotifiers)
+			notifiers.POST("/:id/test", e.TestNotifier)
+		}
+
+		// Webhook endpoints and their delivery log
+		webhooks := health.Group("/webhooks")
+		webhooks.Use(e.requireRole(roleByMethod))
+		{
+			webhooks.POST("", e.CreateWebhook)
+			webhooks.GET("", e.ListWebhooks)
+			webhooks.GET("/:id", e.GetWebhook)
+			webhooks.DELETE("/:id", e.DeleteWebhook)
+			webhooks.GET("/:id/deliveries", e.ListWebhookDeliveries)
+		}
+
+		// Snapshots of deleted services
+		archive := health.Group("/archive")
+		archive.Use(e.requireRole(roleByMethod))
+		{
+			archive.GET("", e.ListArchives)
+			archive.GET("/:id", e.GetArchive)
+		}
+
+		// Tag group rollups
+		health.GET("/groups", e.requireRole(roleByMethod), e.ListGroups)
+
+		// Service dependency graph
+		health.GET("/dependencies", e.requireRole(roleByMethod), e.GetDependencies)
+
+		// Maintenance window routes
+		maintenance := health.Group("/maintenance")
+		maintenance.Use(e.requireRole(roleByMethod))
+		{
+			maintenance.POST("", e.CreateMaintenanceWindow)
+			maintenance.GET("", e.ListMaintenanceWindows)
+			maintenance.DELETE("/:id", e.DeleteMaintenanceWindow)
+		}
+
+		// Admin routes
+		admin := health.Group("/admin")
+		admin.Use(e.requireRole(RoleAdmin))
+		{
+			admin.GET("/chaos", e.ListChaos)
+			admin.POST("/chaos/:serviceId", e.InjectChaos)
+			admin.DELETE("/chaos/:serviceId", e.ClearChaos)
+			admin.GET("/leader", e.GetLeaderStatus)
+			admin.GET("/dead-letters", e.ListDeadLetters)
+			admin.POST("/dead-letters/replay", e.ReplayDeadLetters)
+			admin.GET("/db-health", e.GetDBHealth)
+			admin.GET("/consistency", e.GetConsistency)
+			admin.GET("/websocket", e.GetWebSocketStats)
+			admin.POST("/orgs/:org/offboard", e.StartOffboarding)
+			admin.GET("/offboarding/:id", e.GetOffboardingJob)
+			admin.POST("/bench", e.StartBench)
+			admin.GET("/bench/:id", e.GetBench)
+			admin.GET("/scheduler", e.GetSchedulerStatus)
+			admin.GET("/scheduler/status", e.GetSchedulerStatus)
+			admin.POST("/scheduler/pause", e.PauseScheduler)
+			admin.POST("/scheduler/drain", e.DrainScheduler)
+			admin.POST("/scheduler/resume", e.ResumeScheduler)
+			admin.GET("/scheduler/fairness", e.GetSchedulingFairness)
+			admin.GET("/audit", e.ListAuditLog)
+		}
+
+		// Heartbeat pings authenticate with the per-service token
+		health.POST("/heartbeat/:token", e.ReceiveHeartbeat)
+
+		// Status changes of a service, for outage timelines
+		health.GET("/transitions/:serviceId", e.requireRole(roleByMethod), e.ListStateTransitions)
+
+		// Health check logs routes; the query is a read-only POST
 		healthLogs := health.Group("/healthLogs")
+		healthLogs.Use(e.requireRole(RoleViewer))
 		{
-			healthLogs.GET("/:serviceId", e.GetHealthCheckLogs)
+			healthLogs.GET("/:serviceId", deprecated(apiv1.Prefix+"/services/:serviceId/logs"), e.GetHealthCheckLogs)
+			healthLogs.GET("/:serviceId/export", e.ExportHealthCheckLogs)
+			healthLogs.POST("/query", e.QueryHealthCheckLogs)
 		}
 	}
 
+	// Versioned API for generated clients
+	e.setupV1Routes()
+
 	// WebSocket endpoint for live updates
 	e.router.GET("/ws", e.HandleWebSocket)
 }
@@ -128,178 +449,275 @@ func (e *Engine) Router() *gin.Engine {
 	return e.router
 }
 
-func BasicAuthMiddleware(cfg config.AuthConfig) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		user, pass, ok := c.Request.BasicAuth()
-		if !ok || user != cfg.Username || pass != cfg.Password {
-			c.AbortWithStatusJSON(401, gin.H{
-				"error": "unauthorized",
-			})
-			return
-		}
-		c.Next()
+func (e *Engine) ListServices(c *gin.Context) {
+	q, err := parseServiceListQuery(c)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
 	}
-}
 
-
-func (e *Engine) ListServices(c *gin.Context) {
 	services, err := e.Repo.GetAllServices(c.Request.Context())
-	if err != nil {
+	if err != nil && !errors.Is(err, Repository.ErrNoServices) {
 		c.JSON(500, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(200, gin.H{"services": services})
+	matched, page := q.apply(e.presentServices(c.Request.Context(), services))
+	c.JSON(200, struct {
+		Services []models.ExternalService `json:"services"`
+		apiv1.Page
+	}{matched, page})
 }
 
-func (e *Engine) GetHealthCheckLogs(c *gin.Context) {
-	serviceID := c.Param("serviceId")
-	limit := c.Query("limit")
-	offset := c.Query("offset")
-
-	id, err := strconv.ParseUint(serviceID, 10, 32)
+// serviceByID loads a service and writes a 404/500 response when it can't
+func (e *Engine) serviceByID(c *gin.Context, id uint) (*models.ExternalService, bool) {
+	service, err := e.Repo.GetServiceByID(c.Request.Context(), id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(404, gin.H{"error": "service not found"})
+		return nil, false
+	}
 	if err != nil {
-		c.JSON(400, gin.H{"error": "invalid service id"})
-		return
+		c.JSON(500, gin.H{"error": err.Error()})
+		return nil, false
 	}
 
-	limitInt := 100
-	offsetInt := 0
+	return service, true
+}
 
-	if limit != "" {
-		if l, err := strconv.Atoi(limit); err == nil && l > 0 {
-			limitInt = l
-		}
+func (e *Engine) GetHealthCheckLogs(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("serviceId"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "invalid service id"})
+		return
 	}
 
-	if offset != "" {
-		if o, err := strconv.Atoi(offset); err == nil && o >= 0 {
-			offsetInt = o
-		}
+	filter, err := checkLogListFilter(c, uint(id))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
 	}
 
-	logs, err := e.Repo.GetServiceCheckLogs(c.Request.Context(), uint(id), limitInt, offsetInt)
+	logs, total, err := e.Repo.QueryServiceCheckLogs(c.Request.Context(), filter)
 	if err != nil {
 		c.JSON(500, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(200, gin.H{"logs": logs})
+	c.JSON(200, struct {
+		Logs []*models.ServiceCheckLog `json:"logs"`
+		apiv1.Page
+	}{logs, apiv1.NewPage(total, filter.Limit, filter.Offset)})
 }
 
-var wsUpgrader = websocket.Upgrader{
-	ReadBufferSize:  1024,
-	WriteBufferSize: 1024,
-	CheckOrigin: func(r *http.Request) bool {
-		return true // Allow all origins (configure as needed)
-	},
-}
+// QueryHealthCheckLogs returns logs matching a structured filter across services
+func (e *Engine) QueryHealthCheckLogs(c *gin.Context) {
+	var filter models.CheckLogFilter
 
-func (e *Engine) HandleWebSocket(c *gin.Context) {
-	conn, err := wsUpgrader.Upgrade(c.Writer, c.Request, nil)
-	if err != nil {
-		log.Printf("[WS] upgrade_failed err=%v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to upgrade websocket"})
+	if err := c.ShouldBindJSON(&filter); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
 		return
 	}
 
-	client := &models.Client{
-		Conn: conn,
-		Send: make(chan []byte, 256),
+	if filter.Order != "" && filter.Order != "asc" && filter.Order != "desc" {
+		c.JSON(400, gin.H{"error": "order must be asc or desc"})
+		return
+	}
+	if filter.Limit < 0 || filter.Offset < 0 {
+		c.JSON(400, gin.H{"error": "limit and offset must not be negative"})
+		return
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		c.JSON(400, gin.H{"error": "from must be before to"})
+		return
 	}
 
-	GlobalHub.register <- client
-
-	go func() {
-		defer func() {
-			GlobalHub.unregister <- client
-			conn.Close()
-		}()
-
-		for {
-			_, message, err := conn.ReadMessage()
-			if err != nil {
-				if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-					log.Printf("[WS] read_error err=%v", err)
-				}
-				return
-			}
-			_ = message // Handle ping/pong if needed
+	if tag := c.Query("tag"); tag != "" {
+		ids, err := e.serviceIDsWithTag(c.Request.Context(), tag, filter.ServiceIDs)
+		if err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
 		}
-	}()
-
-	go func() {
-		for message := range client.Send {
-			if err := conn.WriteMessage(websocket.TextMessage, message); err != nil {
-				log.Printf("[WS] write_error err=%v", err)
-				return
-			}
+		if len(ids) == 0 {
+			c.JSON(200, gin.H{"logs": []*models.ServiceCheckLog{}, "total": 0})
+			return
 		}
-	}()
+		filter.ServiceIDs = ids
+	}
+
+	logs, total, err := e.Repo.QueryServiceCheckLogs(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, gin.H{"logs": logs, "total": total})
 }
 
+// SchedulerTick is how often the scheduler looks for services that are due
+const SchedulerTick = 5 * time.Second
+
 func (e *Engine) Scheduler(ctx context.Context) error {
 	defer func() {
 		if r := recover(); r != nil {
-			log.Printf("[SCHEDULER] panic: %v\n%s", r, debug.Stack())
+			logging.For(ctx, "scheduler").Error("panic", "err", r, "stack", string(debug.Stack()))
 		}
 	}()
 
-	sched, err := e.NewScheduler(e.Cnfg)
+	sched, err := e.NewScheduler()
 	if err != nil {
 		return err
 	}
 	defer sched.Close()
+	e.sched.setPublisher(sched)
 
-	log.Println("[SCHEDULER] started")
+	logger := logging.For(ctx, "scheduler")
+	logger.Info("started")
 
-	ticker := time.NewTicker(5 * time.Second)
+	mode := models.SchedulerRunning
+	lagging := false
+
+	ticker := time.NewTicker(SchedulerTick)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
-			log.Println("[SCHEDULER] stopped")
+			if e.Leader != nil {
+				e.Leader.Release()
+			}
+			logger.Info("stopped")
 			return nil
 
 		case <-ticker.C:
+			e.health.tick(time.Now())
+
+			// Every replica serves the API and consumes jobs, but only the
+			// leader publishes them
+			if e.Leader != nil && !e.Leader.IsLeader(ctx) {
+				continue
+			}
+
+			// A pause is kept in the database so it holds across restarts and
+			// leader changes; if it can't be read the last known mode applies
+			if control, err := e.Repo.GetSchedulerControl(ctx); err != nil {
+				logger.Error("fetch_scheduler_control_failed", "err", err)
+			} else if control.Mode != mode {
+				logger.Info("mode_changed", "from", mode, "to", control.Mode, "reason", control.Reason)
+				mode = control.Mode
+			}
+			if mode != models.SchedulerRunning {
+				continue
+			}
+
 			services, err := e.Repo.GetAllServices(ctx)
 			if err != nil {
-				log.Println("[SCHEDULER] fetch services failed:", err)
+				logger.Error("fetch_services_failed", "err", err)
 				continue
 			}
 
-			now := time.Now()
+			now := clock.Now()
+
+			inMaintenance, err := e.Repo.ServicesInMaintenance(ctx, now)
+			if err != nil {
+				logger.Error("fetch_maintenance_windows_failed", "err", err)
+				inMaintenance = map[uint]bool{}
+			}
 
 			for _, s := range services {
 				if !shouldRun(s, now) {
 					continue
 				}
 
-				job := HealthCheckJob{
-					ServiceName: s.Name,
-					URL:         s.URL,
-					Method:      s.HTTPMethod,
-					Timeout:     time.Duration(s.TimeoutSeconds) * time.Second,
+				job := newHealthCheckJob(s, inMaintenance[s.ID])
+				job.DueAt = dueAt(s, now)
+
+				if err := scheduleRegions(sched, s, job); err != nil {
+					logger.Error("schedule_failed", "service", s.Name, "err", err)
+					continue
 				}
+				e.fairness.scheduled(s.ID)
 
-				if err := sched.Schedule(job); err != nil {
-					log.Printf(
-						"[SCHEDULER] schedule_failed service=%s err=%v",
-						s.Name,
-						err,
-					)
+				// Claim the slot so later ticks don't enqueue the service again
+				// while this job is still queued or running
+				nextRunAt, inFlightUntil := markScheduled(s, now, e.Cnfg.Scheduler)
+				if err := e.Repo.MarkServiceScheduled(ctx, s.ID, nextRunAt, inFlightUntil); err != nil {
+					logger.Error("mark_scheduled_failed", "service", s.Name, "err", err)
 				}
 			}
+
+			lagging = e.checkLag(ctx, sched, services, now, lagging)
 		}
 	}
 }
 
+func newHealthCheckJob(s *models.ExternalService, inMaintenance bool) HealthCheckJob {
+	return HealthCheckJob{
+		JobID:         logging.NewID(),
+		ServiceID:     s.ID,
+		ServiceName:   s.Name,
+		URL:           s.URL,
+		Method:        s.HTTPMethod,
+		Timeout:       time.Duration(s.TimeoutSeconds) * time.Second,
+		CorrelationID: logging.NewID(),
+		ScheduledAt:   clock.Now(),
+
+		InMaintenance: inMaintenance,
+	}
+}
+
+// shouldRun reports whether a service is due. A service with an outstanding
+// job is never due until the worker finishes it or the in-flight lease
+// expires. A lease longer than any job can take was set by a clock running
+// ahead and is ignored.
 func shouldRun(s *models.ExternalService, now time.Time) bool {
-	if s.LastCheckedAt == nil {
-		return true
+	if s.InFlightUntil != nil && now.Before(*s.InFlightUntil) && s.InFlightUntil.Sub(now) <= leaseDuration(s) {
+		return false
+	}
+
+	return !now.Before(dueAt(s, now))
+}
+
+// dueAt is when a service should run: its next run time, the next run after
+// its last check, or now for a service never checked. A new service with a
+// cron schedule waits for its first match instead. Interval services are
+// never due more than two intervals out, the most jitter can add, so a time
+// stamped by a clock running ahead doesn't hold them back.
+func dueAt(s *models.ExternalService, now time.Time) time.Time {
+	due := now
+	switch {
+	case s.NextRunAt != nil:
+		due = *s.NextRunAt
+	case s.LastCheckedAt != nil:
+		due = nextRun(s, *s.LastCheckedAt)
+	case serviceSchedule(s) != nil:
+		// Looking back one tick keeps a match in the current minute
+		return nextRun(s, now.Add(-SchedulerTick))
 	}
+	if limit := now.Add(2 * time.Duration(s.Interval) * time.Second); serviceSchedule(s) == nil && due.After(limit) {
+		return limit
+	}
+	return due
+}
 
-	next := s.LastCheckedAt.Add(time.Duration(s.Interval) * time.Second)
-	return now.After(next)
-}
\ No newline at end of file
+// markScheduled sets and returns the next run time and the in-flight lease for
+// a job published at now. The next run gets the configured jitter on top. The
+// lease covers the worst-case check duration (every attempt timing out) plus
+// one scheduler tick for queueing.
+func markScheduled(s *models.ExternalService, now time.Time, cfg config.Scheduler) (time.Time, time.Time) {
+	next := nextRun(s, now)
+	nextRunAt := next.Add(scheduleJitter(cfg, next.Sub(now), serviceSchedule(s) != nil))
+	inFlightUntil := now.Add(leaseDuration(s))
+
+	s.NextRunAt = &nextRunAt
+	s.InFlightUntil = &inFlightUntil
+
+	return nextRunAt, inFlightUntil
+}
+
+// leaseDuration is how long the in-flight lease of a job lasts
+func leaseDuration(s *models.ExternalService) time.Duration {
+	attempts := time.Duration(s.Retries + 1)
+	worstCase := attempts*time.Duration(s.TimeoutSeconds)*time.Second +
+		(attempts-1)*time.Duration(s.RetryDelayMs)*time.Millisecond
+	return worstCase + SchedulerTick
+}
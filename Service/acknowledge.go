@@ -0,0 +1,273 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/logging"
+	"Distributed-Health-Monitoring/models"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultAckTTL = 4 * time.Hour
+	maxAckTTL     = 7 * 24 * time.Hour
+	maxAckNote    = 1000
+)
+
+var errNotFailing = errors.New("only a DOWN, DEGRADED or flapping service can be acknowledged")
+
+type serviceAckRequest struct {
+	Note       string `json:"note"`
+	TTLSeconds int64  `json:"ttl_seconds"` // 0 for 4 hours
+}
+
+// AcknowledgeService records that the caller is handling a failing service.
+// Until the acknowledgement expires or the service is UP again its alerts,
+// flapping notices and escalations are held back.
+func (e *Engine) AcknowledgeService(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "invalid service id"})
+		return
+	}
+
+	var req serviceAckRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	ttl, err := ackTTL(req)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	service, ok := e.serviceByID(c, uint(id))
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	ack, err := e.acknowledgeService(ctx, service, callerName(principalFrom(ctx)), req.Note, ttl)
+	switch {
+	case errors.Is(err, errNotFailing):
+		c.JSON(409, gin.H{"error": err.Error(), "status": service.Status})
+		return
+	case err != nil:
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "service acknowledged", "service_id": service.ID, "acknowledgement": ack})
+}
+
+// ClearServiceAcknowledgement ends an acknowledgement early, so alerts resume
+func (e *Engine) ClearServiceAcknowledgement(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "invalid service id"})
+		return
+	}
+
+	service, ok := e.serviceByID(c, uint(id))
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	cleared, err := e.clearAcknowledgement(ctx, service, models.AckCleared, callerName(principalFrom(ctx)))
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	if !cleared {
+		c.JSON(404, gin.H{"error": "service is not acknowledged"})
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "acknowledgement cleared", "service_id": service.ID})
+}
+
+// ListServiceAcknowledgements returns who acknowledged a service and when
+// each acknowledgement ended, newest first
+func (e *Engine) ListServiceAcknowledgements(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "invalid service id"})
+		return
+	}
+
+	service, ok := e.serviceByID(c, uint(id))
+	if !ok {
+		return
+	}
+
+	trail, err := e.Repo.ListServiceAcknowledgements(c.Request.Context(), service.ID, queryLimit(c, 50))
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	var active *models.Acknowledgement
+	if service.Acknowledgement.Active(time.Now()) {
+		active = service.Acknowledgement
+	}
+	c.JSON(200, gin.H{"service_id": service.ID, "acknowledgement": active, "trail": trail})
+}
+
+func ackTTL(req serviceAckRequest) (time.Duration, error) {
+	if len(req.Note) > maxAckNote {
+		return 0, fmt.Errorf("note must be at most %d characters", maxAckNote)
+	}
+	if req.TTLSeconds < 0 {
+		return 0, errors.New("ttl_seconds must not be negative")
+	}
+	if req.TTLSeconds == 0 {
+		return defaultAckTTL, nil
+	}
+	ttl := time.Duration(req.TTLSeconds) * time.Second
+	if ttl > maxAckTTL {
+		return 0, fmt.Errorf("ttl_seconds must be at most %d", int64(maxAckTTL.Seconds()))
+	}
+	return ttl, nil
+}
+
+// acknowledgeService stores and broadcasts a new acknowledgement of a
+// failing service; acknowledging again replaces the note and expiry
+func (e *Engine) acknowledgeService(ctx context.Context, service *models.ExternalService, by, note string, ttl time.Duration) (*models.Acknowledgement, error) {
+	if !service.Flapping && service.Status != "DOWN" && service.Status != models.StatusDegraded {
+		return nil, errNotFailing
+	}
+
+	now := time.Now().UTC()
+	ack := models.Acknowledgement{By: by, Note: note, At: now, ExpiresAt: now.Add(ttl)}
+	if err := e.Repo.AcknowledgeService(ctx, service.ID, ack, service.Status); err != nil {
+		return nil, err
+	}
+	previous := service.Acknowledgement
+	service.Acknowledgement = &ack
+
+	// Acknowledging again replaces the note and expiry of the running one
+	action, before := auditCreate, interface{}(nil)
+	if previous.Active(now) {
+		action, before = auditUpdate, previous
+	}
+	e.audit(ctx, action, auditAcknowledgement, service.ID, service.Name, note, before, &ack)
+
+	logging.For(ctx, "acknowledgement").Info("service_acknowledged", "service", service.Name, "by", by, "expires_at", ack.ExpiresAt)
+	event := models.ServiceAcknowledgementEvent{
+		Type:            "service_acknowledged",
+		ServiceID:       service.ID,
+		Name:            service.Name,
+		Status:          service.Status,
+		Acknowledgement: &ack,
+		Timestamp:       now,
+	}
+	BroadcastEvent(service.Name, event)
+	e.emitWebhook(ctx, WebhookServiceAcknowledged, service, event)
+	return &ack, nil
+}
+
+// clearAcknowledgement removes the acknowledgement of a service, if it has
+// one, and broadcasts its end
+func (e *Engine) clearAcknowledgement(ctx context.Context, service *models.ExternalService, action, by string) (bool, error) {
+	now := time.Now().UTC()
+	cleared, err := e.Repo.ClearServiceAcknowledgement(ctx, service.ID, action, by, service.Status, now)
+	if err != nil || !cleared {
+		return false, err
+	}
+	previous := service.Acknowledgement
+	service.Acknowledgement = nil
+
+	if action == models.AckRecovered {
+		ctx = withSystemActor(ctx, "worker")
+	}
+	e.audit(ctx, auditDelete, auditAcknowledgement, service.ID, service.Name, action, previous, nil)
+
+	logging.For(ctx, "acknowledgement").Info("acknowledgement_cleared", "service", service.Name, "by", by, "action", action)
+	BroadcastEvent(service.Name, models.ServiceAcknowledgementEvent{
+		Type:      "service_acknowledgement_cleared",
+		ServiceID: service.ID,
+		Name:      service.Name,
+		Status:    service.Status,
+		By:        by,
+		Timestamp: now,
+	})
+	return true, nil
+}
+
+// callerName is how a caller appears in acknowledgement trails
+func callerName(p *Principal) string {
+	switch {
+	case p == nil:
+		return "unknown"
+	case p.Email != "":
+		return p.Email
+	case p.Name != "":
+		return p.Name
+	}
+	return p.Subject
+}
+
+// wsAcknowledge handles {"type": "ack"} and {"type": "unack"} messages sent
+// by dashboards over their WebSocket, answering the sender with an ack_result
+func (e *Engine) wsAcknowledge(ctx context.Context, client *models.Client, principal *Principal, req wsRequest) {
+	result := wsAckResult{Type: "ack_result", Request: req.Type, ServiceID: req.ServiceID, OK: true}
+	defer func() { GlobalHub.SendTo(client, result) }()
+
+	fail := func(err error) {
+		result.OK, result.Error = false, err.Error()
+	}
+	if principal == nil || roleRank[principal.Role] < roleRank[RoleOperator] {
+		fail(errors.New("acknowledging needs the operator role"))
+		return
+	}
+
+	service, err := e.Repo.GetServiceByID(ctx, req.ServiceID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		fail(errors.New("service not found"))
+		return
+	}
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	ctx = withPrincipal(ctx, principal)
+	if req.Type == "unack" {
+		cleared, err := e.clearAcknowledgement(ctx, service, models.AckCleared, callerName(principal))
+		switch {
+		case err != nil:
+			fail(err)
+		case !cleared:
+			fail(errors.New("service is not acknowledged"))
+		}
+		return
+	}
+
+	ttl, err := ackTTL(serviceAckRequest{Note: req.Note, TTLSeconds: req.TTLSeconds})
+	if err != nil {
+		fail(err)
+		return
+	}
+	if result.Acknowledgement, err = e.acknowledgeService(ctx, service, callerName(principal), req.Note, ttl); err != nil {
+		fail(err)
+	}
+}
+
+// wsAckResult answers one WebSocket acknowledgement message
+type wsAckResult struct {
+	Type            string                  `json:"type"`    // ack_result
+	Request         string                  `json:"request"` // ack or unack
+	ServiceID       uint                    `json:"service_id"`
+	OK              bool                    `json:"ok"`
+	Error           string                  `json:"error,omitempty"`
+	Acknowledgement *models.Acknowledgement `json:"acknowledgement,omitempty"`
+}
@@ -0,0 +1,329 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/Repository"
+	"Distributed-Health-Monitoring/apiv1"
+	"Distributed-Health-Monitoring/cache"
+	"Distributed-Health-Monitoring/logging"
+	"Distributed-Health-Monitoring/models"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// setupV1Routes registers the versioned API. Handlers only exchange apiv1
+// types, so the wire format survives model changes.
+func (e *Engine) setupV1Routes() {
+	v1 := e.router.Group(apiv1.Prefix)
+	v1.Use(e.requireRole(roleByMethod))
+	{
+		v1.POST("/services", e.V1CreateService)
+		v1.GET("/services", e.V1ListServices)
+		v1.GET("/services/:id", e.V1GetService)
+		v1.DELETE("/services/:id", e.V1DeleteService)
+		v1.POST("/services/:id/restore", e.V1RestoreService)
+		v1.GET("/services/:id/logs", e.V1ListCheckLogs)
+		v1.GET("/services/:id/logs/stream", e.V1StreamCheckLogs)
+		v1.GET("/logs/recent", e.V1RecentCheckLogs)
+
+		v1.GET("/incidents", e.V1ListIncidents)
+		v1.GET("/incidents/:id", e.V1GetIncident)
+		v1.POST("/incidents/:id/ack", e.V1AcknowledgeIncident)
+	}
+}
+
+// deprecated marks an unversioned route that has a v1 successor. Path
+// parameters in the successor (":id") are filled in from the request.
+func deprecated(successor string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		link := successor
+		for _, p := range c.Params {
+			link = strings.Replace(link, ":"+p.Key, p.Value, 1)
+		}
+		c.Header("Deprecation", "true")
+		c.Header("Link", "<"+link+`>; rel="successor-version"`)
+		c.Next()
+	}
+}
+
+func (e *Engine) V1CreateService(c *gin.Context) {
+	var req apiv1.ServiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, apiv1.Error{Error: err.Error()})
+		return
+	}
+
+	service := req.Model()
+	if err := checkReservedTags(service); err != nil {
+		c.JSON(400, apiv1.Error{Error: err.Error()})
+		return
+	}
+	if err := e.Repo.RegisterService(c.Request.Context(), service); err != nil {
+		c.JSON(400, apiv1.Error{Error: err.Error()})
+		return
+	}
+
+	cache.Services.Set(service)
+	e.audit(c.Request.Context(), auditCreate, auditService, service.ID, service.Name, "", nil, auditedService(service))
+	clusterBus.serviceChanged(service.ID)
+
+	BroadcastEvent(service.Name, models.ServiceStateChangeEvent{
+		Type:      "service_registered",
+		ServiceID: service.ID,
+		Name:      service.Name,
+		To:        service.Status,
+		Timestamp: time.Now(),
+	})
+
+	out := apiv1.NewService(*service)
+	if service.HeartbeatToken != nil {
+		out.HeartbeatToken = *service.HeartbeatToken
+	}
+	c.JSON(201, apiv1.ServiceResponse{Service: out})
+}
+
+func (e *Engine) V1ListServices(c *gin.Context) {
+	q, err := parseServiceListQuery(c)
+	if err != nil {
+		c.JSON(400, apiv1.Error{Error: err.Error()})
+		return
+	}
+
+	services, err := e.Repo.GetAllServices(c.Request.Context())
+	if err != nil && !errors.Is(err, Repository.ErrNoServices) {
+		c.JSON(500, apiv1.Error{Error: err.Error()})
+		return
+	}
+
+	matched, page := q.apply(e.presentServices(c.Request.Context(), services))
+	out := make([]apiv1.Service, 0, len(matched))
+	for _, s := range matched {
+		out = append(out, apiv1.NewService(s))
+	}
+
+	c.JSON(200, apiv1.ServiceListResponse{Services: out, Page: page})
+}
+
+func (e *Engine) V1GetService(c *gin.Context) {
+	service, ok := e.v1Service(c)
+	if !ok {
+		return
+	}
+
+	presented := e.presentServices(c.Request.Context(), map[uint]*models.ExternalService{service.ID: service})
+	c.JSON(200, apiv1.ServiceResponse{Service: apiv1.NewService(presented[service.ID])})
+}
+
+func (e *Engine) V1DeleteService(c *gin.Context) {
+	permanent := c.Query("permanent") == "true"
+	if permanent {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.JSON(400, apiv1.Error{Error: "invalid service id"})
+			return
+		}
+		done, err := e.deletePermanently(c.Request.Context(), uint(id))
+		if err != nil {
+			c.JSON(500, apiv1.Error{Error: err.Error()})
+			return
+		}
+		if done {
+			c.JSON(200, apiv1.DeleteServiceResponse{Permanent: true})
+			return
+		}
+	}
+
+	service, ok := e.v1Service(c)
+	if !ok {
+		return
+	}
+
+	archive, err := e.archiveAndDelete(c.Request.Context(), service, c.Query("reason"), permanent)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(404, apiv1.Error{Error: "service not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, apiv1.Error{Error: err.Error()})
+		return
+	}
+
+	c.JSON(200, apiv1.DeleteServiceResponse{ArchiveID: archive.ID, Permanent: permanent})
+}
+
+func (e *Engine) V1RestoreService(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, apiv1.Error{Error: "invalid service id"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	service, err := e.restoreService(ctx, uint(id))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(404, apiv1.Error{Error: "deleted service not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, apiv1.Error{Error: err.Error()})
+		return
+	}
+
+	presented := e.presentServices(ctx, map[uint]*models.ExternalService{service.ID: service})
+	c.JSON(200, apiv1.ServiceResponse{Service: apiv1.NewService(presented[service.ID])})
+}
+
+func (e *Engine) V1ListCheckLogs(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, apiv1.Error{Error: "invalid service id"})
+		return
+	}
+
+	filter, err := checkLogListFilter(c, uint(id))
+	if err != nil {
+		c.JSON(400, apiv1.Error{Error: err.Error()})
+		return
+	}
+
+	logs, total, err := e.Repo.QueryServiceCheckLogs(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(500, apiv1.Error{Error: err.Error()})
+		return
+	}
+
+	out := make([]apiv1.CheckLog, 0, len(logs))
+	for _, l := range logs {
+		out = append(out, apiv1.NewCheckLog(l))
+	}
+
+	c.JSON(200, apiv1.CheckLogListResponse{Logs: out, Page: apiv1.NewPage(total, filter.Limit, filter.Offset)})
+}
+
+// Bounds of one recent logs request
+const (
+	maxRecentLogServices = 200
+	maxRecentLogs        = 100
+)
+
+// V1RecentCheckLogs returns the newest ?limit= logs (default 30) of each
+// ?service_id=, so a dashboard draws all its sparklines with a few requests
+// instead of one per service
+func (e *Engine) V1RecentCheckLogs(c *gin.Context) {
+	raw := queryList(c, "service_id")
+	if len(raw) == 0 {
+		c.JSON(400, apiv1.Error{Error: "service_id is required"})
+		return
+	}
+	if len(raw) > maxRecentLogServices {
+		c.JSON(400, apiv1.Error{Error: fmt.Sprintf("at most %d services per request", maxRecentLogServices)})
+		return
+	}
+	ids := make([]uint, 0, len(raw))
+	for _, r := range raw {
+		id, err := strconv.ParseUint(r, 10, 32)
+		if err != nil {
+			c.JSON(400, apiv1.Error{Error: "invalid service id " + strconv.Quote(r)})
+			return
+		}
+		ids = append(ids, uint(id))
+	}
+	limit := min(queryLimit(c, 30), maxRecentLogs)
+
+	out := apiv1.RecentCheckLogsResponse{Services: make([]apiv1.ServiceCheckLogs, 0, len(ids))}
+	for _, id := range ids {
+		logs, err := e.Repo.GetServiceCheckLogs(c.Request.Context(), id, limit, 0)
+		if err != nil {
+			c.JSON(500, apiv1.Error{Error: err.Error()})
+			return
+		}
+		entry := apiv1.ServiceCheckLogs{ServiceID: id, Logs: make([]apiv1.CheckLog, 0, len(logs))}
+		for _, l := range logs {
+			entry.Logs = append(entry.Logs, apiv1.NewCheckLog(l))
+		}
+		out.Services = append(out.Services, entry)
+	}
+
+	c.JSON(200, out)
+}
+
+func (e *Engine) V1ListIncidents(c *gin.Context) {
+	incidents, err := e.Repo.ListOpenIncidents(c.Request.Context())
+	if err != nil {
+		c.JSON(500, apiv1.Error{Error: err.Error()})
+		return
+	}
+
+	sort.Slice(incidents, func(i, j int) bool { return incidents[i].StartedAt.After(incidents[j].StartedAt) })
+
+	out := make([]apiv1.Incident, 0, len(incidents))
+	for _, i := range incidents {
+		out = append(out, apiv1.NewIncident(i))
+	}
+
+	c.JSON(200, apiv1.IncidentListResponse{Incidents: out})
+}
+
+func (e *Engine) V1GetIncident(c *gin.Context) {
+	incident, ok := e.incidentByID(c)
+	if !ok {
+		return
+	}
+
+	escalations, err := e.Repo.ListIncidentEscalations(c.Request.Context(), incident.ID)
+	if err != nil {
+		c.JSON(500, apiv1.Error{Error: err.Error()})
+		return
+	}
+
+	out := apiv1.IncidentResponse{Incident: apiv1.NewIncident(*incident), Escalations: make([]apiv1.Escalation, 0, len(escalations))}
+	for _, esc := range escalations {
+		out.Escalations = append(out.Escalations, apiv1.NewEscalation(esc))
+	}
+
+	c.JSON(200, out)
+}
+
+func (e *Engine) V1AcknowledgeIncident(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, apiv1.Error{Error: "invalid incident id"})
+		return
+	}
+
+	var req apiv1.AckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, apiv1.Error{Error: err.Error()})
+		return
+	}
+
+	incident, err := e.Repo.AcknowledgeIncident(c.Request.Context(), uint(id), req.By, time.Now())
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(404, apiv1.Error{Error: "incident not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(409, apiv1.Error{Error: err.Error()})
+		return
+	}
+
+	logging.For(c.Request.Context(), "escalation").Info("incident_acknowledged", "incident_id", incident.ID, "by", incident.AcknowledgedBy)
+	e.incidentWebhook(c.Request.Context(), WebhookIncidentAcknowledged, incident, nil)
+
+	c.JSON(200, apiv1.IncidentResponse{Incident: apiv1.NewIncident(*incident), Escalations: []apiv1.Escalation{}})
+}
+
+func (e *Engine) v1Service(c *gin.Context) (*models.ExternalService, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, apiv1.Error{Error: "invalid service id"})
+		return nil, false
+	}
+	return e.serviceByID(c, uint(id))
+}
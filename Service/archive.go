@@ -0,0 +1,241 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/cache"
+	"Distributed-Health-Monitoring/logging"
+	"Distributed-Health-Monitoring/models"
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// archiveWindows are the overview windows plus a 90-day SLA period
+var archiveWindows = []uptimeWindow{
+	{"24h", 24 * time.Hour},
+	{"7d", 7 * 24 * time.Hour},
+	{"30d", 30 * 24 * time.Hour},
+	{"90d", 90 * 24 * time.Hour},
+}
+
+// DeleteService archives a snapshot of the service (config, uptime, incidents)
+// and then soft-deletes it, keeping its history until it is restored or
+// purged. ?reason= is stored with the snapshot. ?permanent=true removes the
+// service and its history for good, including one deleted earlier.
+func (e *Engine) DeleteService(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "invalid service id"})
+		return
+	}
+
+	permanent := c.Query("permanent") == "true"
+	if permanent {
+		done, err := e.deletePermanently(c.Request.Context(), uint(id))
+		if err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+		if done {
+			c.JSON(200, gin.H{"message": "deleted service removed permanently", "service_id": id})
+			return
+		}
+	}
+
+	service, ok := e.serviceByID(c, uint(id))
+	if !ok {
+		return
+	}
+
+	archive, err := e.archiveAndDelete(c.Request.Context(), service, c.Query("reason"), permanent)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(404, gin.H{"error": "service not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "service archived and deleted", "archive": archive})
+}
+
+// RestoreService brings back a deleted service with its logs and incidents.
+// It starts PENDING and is checked on the next scheduler tick.
+func (e *Engine) RestoreService(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "invalid service id"})
+		return
+	}
+
+	service, err := e.restoreService(c.Request.Context(), uint(id))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(404, gin.H{"error": "deleted service not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "service restored", "service": service})
+}
+
+// archiveAndDelete snapshots the service's config, uptime and incidents,
+// then deletes it; softly unless permanent
+func (e *Engine) archiveAndDelete(ctx context.Context, service *models.ExternalService, reason string, permanent bool) (*models.ServiceArchive, error) {
+	now := time.Now()
+
+	uptime := make(map[string]models.UptimeStat, len(archiveWindows)+1)
+	for _, w := range archiveWindows {
+		stat, err := e.Repo.GetUptime(ctx, service.ID, now.Add(-w.Duration))
+		if err != nil {
+			return nil, err
+		}
+		uptime[w.Label] = stat
+	}
+	lifetime, err := e.Repo.GetUptime(ctx, service.ID, service.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	uptime["lifetime"] = lifetime
+
+	incidents, err := e.Repo.ListIncidents(ctx, service.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The heartbeat token and other credentials are live secrets, not history
+	config := *service
+	config.HeartbeatToken = nil
+	redactSecrets(&config)
+
+	archive := &models.ServiceArchive{
+		ServiceID: service.ID,
+		Name:      service.Name,
+		Config:    config,
+		Uptime:    uptime,
+		Incidents: incidents,
+		Reason:    reason,
+		DeletedAt: now,
+	}
+
+	if err := e.Repo.ArchiveAndDeleteService(ctx, archive, permanent); err != nil {
+		return nil, err
+	}
+
+	e.forgetService(service.ID)
+	e.audit(ctx, auditDelete, auditService, service.ID, service.Name, reason, auditedService(service), nil)
+	clusterBus.serviceChanged(service.ID)
+
+	logging.For(ctx, "archive").Info("service_deleted", "service", service.Name, "archive_id", archive.ID, "incidents", len(incidents), "permanent", permanent)
+
+	BroadcastEvent(service.Name, models.ServiceStateChangeEvent{
+		Type:      "service_deleted",
+		ServiceID: service.ID,
+		Name:      service.Name,
+		From:      service.Status,
+		Timestamp: now,
+	})
+
+	return archive, nil
+}
+
+// deletePermanently removes a service deleted earlier, reporting false when
+// id is not a soft-deleted service
+func (e *Engine) deletePermanently(ctx context.Context, id uint) (bool, error) {
+	service, err := e.Repo.GetDeletedService(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if err := e.Repo.DeleteServicePermanently(ctx, id); err != nil {
+		return false, err
+	}
+
+	e.audit(ctx, auditDelete, auditService, service.ID, service.Name, "permanent", nil, nil)
+	logging.For(ctx, "archive").Info("service_purged", "service", service.Name)
+	return true, nil
+}
+
+// restoreService undeletes a service and puts it back in this replica's
+// cache; the others reload it through the cluster bus
+func (e *Engine) restoreService(ctx context.Context, id uint) (*models.ExternalService, error) {
+	now := time.Now()
+	service, err := e.Repo.RestoreService(ctx, id, now)
+	if err != nil {
+		return nil, err
+	}
+
+	cache.Services.Set(service)
+	e.audit(ctx, auditRestore, auditService, service.ID, service.Name, "", nil, auditedService(service))
+	clusterBus.serviceChanged(service.ID)
+
+	logging.For(ctx, "archive").Info("service_restored", "service", service.Name)
+
+	BroadcastEvent(service.Name, models.ServiceStateChangeEvent{
+		Type:      "service_restored",
+		ServiceID: service.ID,
+		Name:      service.Name,
+		To:        service.Status,
+		Timestamp: now,
+	})
+
+	return service, nil
+}
+
+// restoreSyncedService restores the soft-deleted service holding name when
+// it carries tag, so a sync that registers a service it removed earlier
+// brings back its history instead of failing on the name. It returns nil
+// when there is no such service.
+func (e *Engine) restoreSyncedService(ctx context.Context, name, tag string) (*models.ExternalService, error) {
+	deleted, err := e.Repo.GetDeletedServiceByName(ctx, name)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !deleted.HasTag(tag) {
+		return nil, nil
+	}
+	return e.restoreService(ctx, deleted.ID)
+}
+
+// ListArchives returns archived service snapshots, optionally ?name= filtered
+func (e *Engine) ListArchives(c *gin.Context) {
+	archives, err := e.Repo.ListServiceArchives(c.Request.Context(), c.Query("name"))
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, gin.H{"archives": archives})
+}
+
+func (e *Engine) GetArchive(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "invalid archive id"})
+		return
+	}
+
+	archive, err := e.Repo.GetServiceArchive(c.Request.Context(), uint(id))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(404, gin.H{"error": "archive not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, archive)
+}
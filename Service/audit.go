@@ -0,0 +1,163 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/apiv1"
+	"Distributed-Health-Monitoring/logging"
+	"Distributed-Health-Monitoring/models"
+	"context"
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Audited entities, as they appear in ?entity=
+const (
+	auditService           = "service"
+	auditWebhook           = "webhook"
+	auditMaintenanceWindow = "maintenance_window"
+	auditAcknowledgement   = "acknowledgement" // keyed by the service id
+)
+
+var auditEntities = []string{auditService, auditWebhook, auditMaintenanceWindow, auditAcknowledgement}
+
+const (
+	auditCreate  = "create"
+	auditUpdate  = "update"
+	auditDelete  = "delete"
+	auditRestore = "restore"
+)
+
+// auditIgnoredFields change on every write and say nothing about intent
+var auditIgnoredFields = []string{"updated_at"}
+
+// withSystemActor attributes the changes made with ctx to a component of
+// the monitor itself, like the Consul sync
+func withSystemActor(ctx context.Context, component string) context.Context {
+	return withPrincipal(ctx, &Principal{Subject: "system:" + component, Method: "system"})
+}
+
+// audit records a change by the caller in ctx. before is nil on create and
+// after is nil on delete; both are compared as JSON, so pass the redacted
+// views from auditedService and auditedWebhook. A failed write is logged and
+// doesn't undo the change.
+func (e *Engine) audit(ctx context.Context, action, entity string, id uint, name, reason string, before, after interface{}) {
+	entry := &models.AuditLog{
+		Actor:      "unknown",
+		AuthMethod: "none",
+		Action:     action,
+		Entity:     entity,
+		EntityID:   id,
+		EntityName: name,
+		Reason:     reason,
+		RequestID:  logging.RequestID(ctx),
+	}
+	if p := principalFrom(ctx); p != nil {
+		entry.Actor, entry.ActorName, entry.Role, entry.AuthMethod = p.Subject, p.Name, p.Role, p.Method
+	}
+
+	logger := logging.For(ctx, "audit").With("entity", entity, "entity_id", id, "action", action)
+	changes, err := auditChanges(before, after)
+	if err != nil {
+		logger.Error("diff_failed", "err", err)
+	}
+	entry.Changes = changes
+
+	if err := e.Repo.CreateAuditLog(ctx, entry); err != nil {
+		logger.Error("write_failed", "actor", entry.Actor, "err", err)
+	}
+}
+
+// auditChanges compares the JSON fields of two values; nil stands for the
+// missing side of a create or delete
+func auditChanges(before, after interface{}) (map[string]models.AuditChange, error) {
+	from, err := auditFields(before)
+	if err != nil {
+		return nil, err
+	}
+	to, err := auditFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]models.AuditChange)
+	for k, v := range from {
+		if w, ok := to[k]; !ok || !reflect.DeepEqual(v, w) {
+			changes[k] = models.AuditChange{From: v, To: to[k]}
+		}
+	}
+	for k, w := range to {
+		if _, ok := from[k]; !ok {
+			changes[k] = models.AuditChange{To: w}
+		}
+	}
+	for _, k := range auditIgnoredFields {
+		delete(changes, k)
+	}
+	return changes, nil
+}
+
+func auditFields(v interface{}) (map[string]interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// auditedService is the copy of a service kept in the audit log
+func auditedService(s *models.ExternalService) *models.ExternalService {
+	view := *s
+	redactSecrets(&view)
+	if view.HeartbeatToken != nil {
+		redacted := redactValue(*view.HeartbeatToken)
+		view.HeartbeatToken = &redacted
+	}
+	return &view
+}
+
+// auditedWebhook is the copy of a webhook kept in the audit log
+func auditedWebhook(w *models.Webhook) *models.Webhook {
+	view := *w
+	view.Secret = redactValue(view.Secret)
+	return &view
+}
+
+// ListAuditLog returns configuration changes, newest first, filtered by
+// ?entity=, ?id= and ?actor=
+func (e *Engine) ListAuditLog(c *gin.Context) {
+	filter := models.AuditFilter{Entity: c.Query("entity"), Actor: c.Query("actor")}
+	if filter.Entity != "" && !slices.Contains(auditEntities, filter.Entity) {
+		c.JSON(400, gin.H{"error": "unknown entity", "entities": auditEntities})
+		return
+	}
+	if v := c.Query("id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "invalid id"})
+			return
+		}
+		filter.EntityID = uint(id)
+	}
+	filter.Limit, filter.Offset = pageParams(c)
+
+	entries, total, err := e.Repo.ListAuditLogs(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, struct {
+		Entries []models.AuditLog `json:"entries"`
+		apiv1.Page
+	}{entries, apiv1.NewPage(total, filter.Limit, filter.Offset)})
+}
@@ -0,0 +1,460 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/config"
+	"Distributed-Health-Monitoring/logging"
+	"Distributed-Health-Monitoring/oidc"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	RoleViewer   = "viewer"   // read-only access
+	RoleOperator = "operator" // also registers services, acknowledges incidents, schedules maintenance
+	RoleAdmin    = "admin"    // also the /admin routes
+
+	// roleByMethod lets viewers read and requires an operator for anything else
+	roleByMethod = ""
+
+	sessionCookie     = "monitor_session"
+	loginCookie       = "monitor_oidc"
+	loginTTL          = 10 * time.Minute
+	defaultSessionTTL = 8 * time.Hour
+	principalKey      = "auth.principal"
+)
+
+var roleRank = map[string]int{RoleViewer: 1, RoleOperator: 2, RoleAdmin: 3}
+
+var (
+	errUnauthorized = errors.New("invalid credentials")
+	errNoRole       = errors.New("none of your groups is mapped to a role")
+)
+
+type principalContextKey struct{}
+
+// withPrincipal stores the caller in a context, so code below the handlers
+// (the audit log, background jobs a request starts) knows who acted
+func withPrincipal(ctx context.Context, principal *Principal) context.Context {
+	if principal == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, principalContextKey{}, principal)
+}
+
+// principalFrom returns the caller stored by requireRole or the gRPC
+// interceptors, or nil
+func principalFrom(ctx context.Context) *Principal {
+	principal, _ := ctx.Value(principalContextKey{}).(*Principal)
+	return principal
+}
+
+// Principal is the authenticated caller of a protected route
+type Principal struct {
+	Subject string   `json:"subject"`
+	Name    string   `json:"name,omitempty"`
+	Email   string   `json:"email,omitempty"`
+	Groups  []string `json:"groups,omitempty"`
+	Role    string   `json:"role"`
+	Method  string   `json:"method"` // basic, session or bearer
+}
+
+// Authenticator is one way for a caller to prove who they are
+type Authenticator interface {
+	// Authenticate returns nil without an error when the request carries no
+	// credentials this backend understands, so the next one is tried
+	Authenticate(c *gin.Context) (*Principal, error)
+}
+
+// authBackends holds the configured authenticators in the order they are tried
+type authBackends struct {
+	backends []Authenticator
+	oidc     *oidcAuthenticator // nil unless auth.oidc.enabled
+	tokenKey []byte             // signs WebSocket tokens
+}
+
+func newAuthBackends(cfg config.AuthConfig) (authBackends, error) {
+	var auth authBackends
+	if cfg.Username != "" {
+		auth.backends = append(auth.backends, basicAuthenticator{username: cfg.Username, password: cfg.Password})
+	}
+	if cfg.OIDC.Enabled {
+		o, err := newOIDCAuthenticator(cfg.OIDC)
+		if err != nil {
+			return authBackends{}, err
+		}
+		auth.oidc = o
+		auth.backends = append(auth.backends, o)
+	}
+	if len(auth.backends) == 0 {
+		return authBackends{}, errors.New("auth: set auth.username or enable auth.oidc")
+	}
+	// Derived from the configured secrets, so every replica accepts the
+	// tokens of the others and changing a secret revokes them
+	sum := sha256.Sum256([]byte("monitor-ws-token\x00" + cfg.Password + "\x00" + cfg.OIDC.SessionKey + "\x00" + cfg.OIDC.ClientSecret))
+	auth.tokenKey = sum[:]
+	return auth, nil
+}
+
+// requireRole authenticates the caller and checks their role; roleByMethod
+// picks viewer or operator from the request method
+func (e *Engine) requireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, err := e.authenticate(c)
+		if errors.Is(err, errNoRole) {
+			c.AbortWithStatusJSON(403, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil || principal == nil {
+			if err != nil {
+				e.limits.authFailed(c)
+			}
+			c.AbortWithStatusJSON(401, gin.H{
+				"error": "unauthorized",
+			})
+			return
+		}
+
+		need := role
+		if need == roleByMethod {
+			need = RoleOperator
+			if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+				need = RoleViewer
+			}
+		}
+		if roleRank[principal.Role] < roleRank[need] {
+			c.AbortWithStatusJSON(403, gin.H{"error": "forbidden", "required_role": need})
+			return
+		}
+
+		c.Set(principalKey, principal)
+		c.Request = c.Request.WithContext(withPrincipal(c.Request.Context(), principal))
+		c.Next()
+	}
+}
+
+// authenticate tries each backend in turn; nil without an error means the
+// request carried no credentials at all
+func (e *Engine) authenticate(c *gin.Context) (*Principal, error) {
+	for _, backend := range e.auth.backends {
+		if principal, err := backend.Authenticate(c); err != nil || principal != nil {
+			return principal, err
+		}
+	}
+	return nil, nil
+}
+
+// basicAuthenticator accepts the shared credential from auth.username and
+// auth.password, which has full access
+type basicAuthenticator struct {
+	username string
+	password string
+}
+
+func (b basicAuthenticator) Authenticate(c *gin.Context) (*Principal, error) {
+	user, pass, ok := c.Request.BasicAuth()
+	if !ok {
+		return nil, nil
+	}
+	userOK := subtle.ConstantTimeCompare([]byte(user), []byte(b.username)) == 1
+	passOK := subtle.ConstantTimeCompare([]byte(pass), []byte(b.password)) == 1
+	if !userOK || !passOK {
+		return nil, errUnauthorized
+	}
+	return &Principal{Subject: user, Name: user, Role: RoleAdmin, Method: "basic"}, nil
+}
+
+// oidcAuthenticator accepts the session cookie set after an OIDC login, or
+// an ID token from the provider as a bearer token for scripts
+type oidcAuthenticator struct {
+	cfg        config.OIDC
+	provider   *oidc.Provider
+	key        []byte
+	sessionTTL time.Duration
+	secure     bool // cookies are only sent over https when the callback is https
+}
+
+func newOIDCAuthenticator(cfg config.OIDC) (*oidcAuthenticator, error) {
+	if cfg.Issuer == "" || cfg.ClientID == "" || cfg.RedirectURL == "" {
+		return nil, errors.New("auth.oidc: issuer, client_id and redirect_url are required")
+	}
+	redirect, err := url.Parse(cfg.RedirectURL)
+	if err != nil || (redirect.Scheme != "http" && redirect.Scheme != "https") {
+		return nil, fmt.Errorf("auth.oidc: invalid redirect_url %q", cfg.RedirectURL)
+	}
+	for group, role := range cfg.RoleMapping {
+		if roleRank[role] == 0 {
+			return nil, fmt.Errorf("auth.oidc: group %q maps to unknown role %q", group, role)
+		}
+	}
+	if cfg.DefaultRole != "" && roleRank[cfg.DefaultRole] == 0 {
+		return nil, fmt.Errorf("auth.oidc: unknown default_role %q", cfg.DefaultRole)
+	}
+	if cfg.GroupsClaim == "" {
+		cfg.GroupsClaim = "groups"
+	}
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"openid", "profile", "email", "groups"}
+	}
+
+	var key []byte
+	switch {
+	case cfg.SessionKey != "":
+		key = []byte(cfg.SessionKey)
+	case cfg.ClientSecret != "":
+		sum := sha256.Sum256([]byte("monitor-session:" + cfg.ClientSecret))
+		key = sum[:]
+	default:
+		return nil, errors.New("auth.oidc: session_key is required for a client without a secret")
+	}
+
+	ttl := defaultSessionTTL
+	if cfg.SessionTTLSeconds > 0 {
+		ttl = time.Duration(cfg.SessionTTLSeconds) * time.Second
+	}
+
+	return &oidcAuthenticator{
+		cfg:        cfg,
+		provider:   oidc.NewProvider(cfg.Issuer, cfg.ClientID, cfg.ClientSecret, cfg.RedirectURL, cfg.Scopes),
+		key:        key,
+		sessionTTL: ttl,
+		secure:     redirect.Scheme == "https",
+	}, nil
+}
+
+type session struct {
+	Principal
+	Expires int64 `json:"exp"`
+}
+
+func (o *oidcAuthenticator) Authenticate(c *gin.Context) (*Principal, error) {
+	if raw, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok {
+		token, err := o.provider.Verify(c.Request.Context(), strings.TrimSpace(raw), "")
+		if err != nil {
+			logging.For(c.Request.Context(), "auth").Warn("bearer_rejected", "error", err)
+			return nil, errUnauthorized
+		}
+		return o.principal(token, "bearer")
+	}
+
+	cookie, err := c.Cookie(sessionCookie)
+	if err != nil {
+		return nil, nil
+	}
+	var s session
+	if err := o.open("session", cookie, &s); err != nil || time.Now().Unix() > s.Expires {
+		return nil, errUnauthorized
+	}
+	p := s.Principal
+	return &p, nil
+}
+
+// principal maps the token's groups to the highest role they grant
+func (o *oidcAuthenticator) principal(token *oidc.IDToken, method string) (*Principal, error) {
+	groups := token.Strings(o.cfg.GroupsClaim)
+	role := o.cfg.DefaultRole
+	for _, g := range groups {
+		if r := o.cfg.RoleMapping[g]; roleRank[r] > roleRank[role] {
+			role = r
+		}
+	}
+	if role == "" {
+		return nil, errNoRole
+	}
+
+	name := token.String("name")
+	if name == "" {
+		name = token.String("preferred_username")
+	}
+	return &Principal{
+		Subject: token.Subject,
+		Name:    name,
+		Email:   token.String("email"),
+		Groups:  groups,
+		Role:    role,
+		Method:  method,
+	}, nil
+}
+
+// seal signs a JSON value for a cookie; purpose keeps a value signed for
+// one cookie from being accepted as another
+func (o *oidcAuthenticator) seal(purpose string, v interface{}) (string, error) {
+	return sealValue(o.key, purpose, v)
+}
+
+func (o *oidcAuthenticator) open(purpose, value string, v interface{}) error {
+	return openValue(o.key, purpose, value, v)
+}
+
+// sealValue encodes v as base64 JSON followed by its HMAC under key
+func sealValue(key []byte, purpose string, v interface{}) (string, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(raw)
+	return payload + "." + base64.RawURLEncoding.EncodeToString(sealMAC(key, purpose, payload)), nil
+}
+
+func openValue(key []byte, purpose, value string, v interface{}) error {
+	payload, sig, ok := strings.Cut(value, ".")
+	if !ok {
+		return errUnauthorized
+	}
+	got, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(got, sealMAC(key, purpose, payload)) {
+		return errUnauthorized
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return errUnauthorized
+	}
+	return json.Unmarshal(raw, v)
+}
+
+func sealMAC(key []byte, purpose, payload string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(purpose + "." + payload))
+	return h.Sum(nil)
+}
+
+func (o *oidcAuthenticator) setCookie(c *gin.Context, name, value string, maxAge int) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(name, value, maxAge, "/", "", o.secure, true)
+}
+
+// loginState travels in a short-lived cookie from /auth/login to the callback
+type loginState struct {
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+	Next     string `json:"next"`
+	Expires  int64  `json:"exp"`
+}
+
+// Login sends the browser to the identity provider. ?next= is where to
+// return afterwards and must be a path on this server.
+func (e *Engine) Login(c *gin.Context) {
+	o := e.auth.oidc
+	if o == nil {
+		c.JSON(404, gin.H{"error": "oidc login is not enabled"})
+		return
+	}
+
+	state := loginState{Next: safeNext(c.Query("next")), Expires: time.Now().Add(loginTTL).Unix()}
+	for _, v := range []*string{&state.State, &state.Nonce, &state.Verifier} {
+		random, err := oidc.RandomString(32)
+		if err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+		*v = random
+	}
+
+	target, err := o.provider.AuthCodeURL(c.Request.Context(), state.State, state.Nonce, state.Verifier)
+	if err != nil {
+		c.JSON(502, gin.H{"error": err.Error()})
+		return
+	}
+	sealed, err := o.seal("login", state)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	o.setCookie(c, loginCookie, sealed, int(loginTTL.Seconds()))
+	c.Redirect(http.StatusFound, target)
+}
+
+// LoginCallback completes the login: it redeems the code, verifies the ID
+// token and starts a session with the role mapped from the user's groups
+func (e *Engine) LoginCallback(c *gin.Context) {
+	o := e.auth.oidc
+	if o == nil {
+		c.JSON(404, gin.H{"error": "oidc login is not enabled"})
+		return
+	}
+	ctx := c.Request.Context()
+
+	if errCode := c.Query("error"); errCode != "" {
+		c.JSON(401, gin.H{"error": "login failed: " + errCode, "description": c.Query("error_description")})
+		return
+	}
+
+	var state loginState
+	cookie, err := c.Cookie(loginCookie)
+	if err == nil {
+		err = o.open("login", cookie, &state)
+	}
+	if err != nil || time.Now().Unix() > state.Expires {
+		c.JSON(400, gin.H{"error": "login expired, start again at /auth/login"})
+		return
+	}
+	o.setCookie(c, loginCookie, "", -1)
+	if subtle.ConstantTimeCompare([]byte(c.Query("state")), []byte(state.State)) != 1 {
+		c.JSON(400, gin.H{"error": "login state does not match"})
+		return
+	}
+
+	raw, err := o.provider.Exchange(ctx, c.Query("code"), state.Verifier)
+	if err != nil {
+		logging.For(ctx, "auth").Warn("oidc_exchange_failed", "error", err)
+		c.JSON(502, gin.H{"error": err.Error()})
+		return
+	}
+	token, err := o.provider.Verify(ctx, raw, state.Nonce)
+	if err != nil {
+		logging.For(ctx, "auth").Warn("oidc_token_rejected", "error", err)
+		c.JSON(401, gin.H{"error": err.Error()})
+		return
+	}
+
+	principal, err := o.principal(token, "session")
+	if err != nil {
+		logging.For(ctx, "auth").Warn("oidc_login_refused", "subject", token.Subject, "error", err)
+		c.JSON(403, gin.H{"error": err.Error()})
+		return
+	}
+	sealed, err := o.seal("session", session{Principal: *principal, Expires: time.Now().Add(o.sessionTTL).Unix()})
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	o.setCookie(c, sessionCookie, sealed, int(o.sessionTTL.Seconds()))
+
+	logging.For(ctx, "auth").Info("oidc_login", "subject", principal.Subject, "email", principal.Email, "role", principal.Role)
+	c.Redirect(http.StatusFound, state.Next)
+}
+
+// Logout ends the session on this server; the provider session is untouched
+func (e *Engine) Logout(c *gin.Context) {
+	if o := e.auth.oidc; o != nil {
+		o.setCookie(c, sessionCookie, "", -1)
+	}
+	c.JSON(200, gin.H{"message": "logged out"})
+}
+
+// WhoAmI returns the authenticated caller and their role
+func (e *Engine) WhoAmI(c *gin.Context) {
+	principal, _ := c.Get(principalKey)
+	c.JSON(200, principal)
+}
+
+// safeNext only allows returning to a path on this server, so the login
+// can't be used as an open redirect
+func safeNext(next string) string {
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		return "/"
+	}
+	return next
+}
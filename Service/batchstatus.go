@@ -0,0 +1,96 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/cache"
+	"Distributed-Health-Monitoring/logging"
+	"Distributed-Health-Monitoring/models"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxBatchStatus bounds one batch query
+const maxBatchStatus = 500
+
+type batchStatusRequest struct {
+	Names []string `json:"names"`
+	IDs   []uint   `json:"ids"`
+}
+
+type BatchServiceStatus struct {
+	Query         string     `json:"query"` // the name or id as requested
+	Found         bool       `json:"found"`
+	ID            uint       `json:"id,omitempty"`
+	Name          string     `json:"name,omitempty"`
+	Status        string     `json:"status,omitempty"` // presented status: MAINTENANCE and FLAPPING included
+	LastCheckedAt *time.Time `json:"last_checked_at,omitempty"`
+}
+
+// QueryStatuses returns the current state of many services in one call,
+// served from the service cache while the scheduler keeps it fresh
+func (e *Engine) QueryStatuses(c *gin.Context) {
+	var req batchStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.Names)+len(req.IDs) == 0 {
+		c.JSON(400, gin.H{"error": "names or ids are required"})
+		return
+	}
+	if len(req.Names)+len(req.IDs) > maxBatchStatus {
+		c.JSON(400, gin.H{"error": "at most 500 services per query"})
+		return
+	}
+
+	services := cache.Services.Snapshot()
+	asOf := cache.Services.RefreshedAt()
+	if time.Since(asOf) > 2*SchedulerTick {
+		var err error
+		if services, err = e.Repo.GetAllServices(c.Request.Context()); err != nil {
+			services = map[uint]*models.ExternalService{}
+		}
+		asOf = time.Now()
+	}
+
+	byName := make(map[string]*models.ExternalService, len(services))
+	for _, s := range services {
+		byName[s.Name] = s
+	}
+	inMaintenance, err := e.Repo.ServicesInMaintenance(c.Request.Context(), time.Now())
+	if err != nil {
+		logging.For(c.Request.Context(), "maintenance").Error("fetch_windows_failed", "err", err)
+	}
+
+	results := make([]BatchServiceStatus, 0, len(req.Names)+len(req.IDs))
+	allUp := true
+	add := func(query string, s *models.ExternalService) {
+		if s == nil {
+			allUp = false
+			results = append(results, BatchServiceStatus{Query: query})
+			return
+		}
+		view := presentService(s, inMaintenance[s.ID])
+		if view.Status != "UP" {
+			allUp = false
+		}
+		results = append(results, BatchServiceStatus{
+			Query:         query,
+			Found:         true,
+			ID:            s.ID,
+			Name:          s.Name,
+			Status:        view.Status,
+			LastCheckedAt: s.LastCheckedAt,
+		})
+	}
+
+	for _, name := range req.Names {
+		add(name, byName[name])
+	}
+	for _, id := range req.IDs {
+		add(strconv.FormatUint(uint64(id), 10), services[id])
+	}
+
+	c.JSON(200, gin.H{"services": results, "all_up": allUp, "as_of": asOf})
+}
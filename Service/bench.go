@@ -0,0 +1,560 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/clock"
+	"Distributed-Health-Monitoring/logging"
+	"Distributed-Health-Monitoring/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// BenchTag marks the synthetic services of a bench run. GetAllServices
+	// leaves them out, so only the run checks them, and clients can't use it.
+	BenchTag = models.BenchTag
+
+	benchQueueSuffix    = ".bench"
+	benchPublishBackoff = 10 * time.Millisecond
+
+	defaultBenchServices = 1000
+	defaultBenchInterval = 60
+	defaultBenchTimeout  = 600
+	maxBenchServices     = 20000
+	maxBenchRounds       = 100
+	maxBenchJobs         = 200000
+	maxBenchTimeout      = 3600
+	maxBenchLatencyMs    = 10000
+)
+
+type benchRequest struct {
+	Services        int   `json:"services"`
+	Rounds          int   `json:"rounds"`
+	IntervalSeconds int64 `json:"interval_seconds"`
+	TargetLatencyMs int64 `json:"target_latency_ms"`
+	TimeoutSeconds  int   `json:"timeout_seconds"`
+}
+
+// benchRuns tracks bench runs in memory; only one runs at a time, since two
+// would measure each other
+type benchRuns struct {
+	mu      sync.Mutex
+	runs    map[string]*models.BenchRun
+	running bool
+}
+
+func (b *benchRuns) start(run *models.BenchRun) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.running {
+		return false
+	}
+	if b.runs == nil {
+		b.runs = make(map[string]*models.BenchRun)
+	}
+	b.runs[run.ID] = run
+	b.running = true
+	return true
+}
+
+func (b *benchRuns) update(id string, fn func(run *models.BenchRun)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if run, ok := b.runs[id]; ok {
+		fn(run)
+		if run.Status != models.BenchRunning {
+			b.running = false
+		}
+	}
+}
+
+func (b *benchRuns) get(id string) (models.BenchRun, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	run, ok := b.runs[id]
+	if !ok {
+		return models.BenchRun{}, false
+	}
+	return *run, true
+}
+
+// StartBench starts a load test: it registers synthetic services pointing
+// at a mock target served by this replica, publishes one job per service and
+// round through its own queue on the configured broker, and measures the
+// jobs as this replica's worker would run them. Notifications, webhooks and
+// broadcasts are skipped. The services are deleted with their logs when the
+// run ends.
+func (e *Engine) StartBench(c *gin.Context) {
+	if !e.Cnfg.Bench.Enabled {
+		c.JSON(403, gin.H{"error": "bench mode is disabled"})
+		return
+	}
+
+	var req benchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Services == 0 {
+		req.Services = defaultBenchServices
+	}
+	if req.Rounds == 0 {
+		req.Rounds = 1
+	}
+	if req.IntervalSeconds == 0 {
+		req.IntervalSeconds = defaultBenchInterval
+	}
+	if req.TimeoutSeconds == 0 {
+		req.TimeoutSeconds = defaultBenchTimeout
+	}
+	switch {
+	case req.Services < 1 || req.Services > maxBenchServices:
+		c.JSON(400, gin.H{"error": fmt.Sprintf("services must be between 1 and %d", maxBenchServices)})
+		return
+	case req.Rounds < 1 || req.Rounds > maxBenchRounds:
+		c.JSON(400, gin.H{"error": fmt.Sprintf("rounds must be between 1 and %d", maxBenchRounds)})
+		return
+	case req.Services*req.Rounds > maxBenchJobs:
+		c.JSON(400, gin.H{"error": fmt.Sprintf("services times rounds must be at most %d", maxBenchJobs)})
+		return
+	case req.IntervalSeconds < 1:
+		c.JSON(400, gin.H{"error": "interval_seconds must be positive"})
+		return
+	case req.TargetLatencyMs < 0 || req.TargetLatencyMs > maxBenchLatencyMs:
+		c.JSON(400, gin.H{"error": fmt.Sprintf("target_latency_ms must be between 0 and %d", maxBenchLatencyMs)})
+		return
+	case req.TimeoutSeconds < 1 || req.TimeoutSeconds > maxBenchTimeout:
+		c.JSON(400, gin.H{"error": fmt.Sprintf("timeout_seconds must be between 1 and %d", maxBenchTimeout)})
+		return
+	}
+
+	run := &models.BenchRun{
+		ID:                    logging.NewID(),
+		Status:                models.BenchRunning,
+		Queue:                 e.Cnfg.Queue.DriverName(),
+		Services:              req.Services,
+		Rounds:                req.Rounds,
+		IntervalSeconds:       req.IntervalSeconds,
+		TargetLatencyMs:       req.TargetLatencyMs,
+		RequiredJobsPerSecond: math.Round(float64(req.Services)/float64(req.IntervalSeconds)*100) / 100,
+		StartedAt:             time.Now(),
+	}
+	if !e.bench.start(run) {
+		c.JSON(409, gin.H{"error": "a bench run is already running"})
+		return
+	}
+
+	snapshot := *run
+	ctx := logging.WithCorrelationID(context.Background(), logging.CorrelationID(c.Request.Context()))
+	ctx = withPrincipal(ctx, principalFrom(c.Request.Context()))
+	go e.runBench(ctx, snapshot, time.Duration(req.TimeoutSeconds)*time.Second)
+
+	c.JSON(202, gin.H{"run": snapshot})
+}
+
+// GetBench reports a bench run, with its progress while it runs
+func (e *Engine) GetBench(c *gin.Context) {
+	run, ok := e.bench.get(c.Param("id"))
+	if !ok {
+		c.JSON(404, gin.H{"error": "bench run not found"})
+		return
+	}
+	c.JSON(200, gin.H{"run": run})
+}
+
+func (e *Engine) runBench(ctx context.Context, run models.BenchRun, timeout time.Duration) {
+	logger := logging.For(ctx, "bench").With("run_id", run.ID)
+	logger.Info("started", "services", run.Services, "rounds", run.Rounds, "queue", run.Queue)
+
+	// Services of a run cut short by a restart are still registered
+	e.purgeBenchServices(ctx)
+	err := e.benchPipeline(ctx, run, timeout)
+	e.purgeBenchServices(ctx)
+
+	finished := time.Now()
+	var result models.BenchRun
+	e.bench.update(run.ID, func(r *models.BenchRun) {
+		r.Status, r.FinishedAt = models.BenchCompleted, &finished
+		if err != nil {
+			r.Status, r.Error = models.BenchFailed, err.Error()
+		}
+		result = *r
+	})
+	if err != nil {
+		logger.Error("failed", "completed", result.Completed, "failed", result.Failed, "err", err)
+		return
+	}
+	logger.Info("completed", "jobs", result.Completed+result.Failed, "failed", result.Failed, "jobs_per_second", result.JobsPerSecond)
+}
+
+// benchPipeline serves the mock target, registers the services and runs
+// their jobs through a bench queue until all are done or timeout passes
+func (e *Engine) benchPipeline(ctx context.Context, run models.BenchRun, timeout time.Duration) error {
+	target, url, err := startBenchTarget(time.Duration(run.TargetLatencyMs) * time.Millisecond)
+	if err != nil {
+		return fmt.Errorf("mock target: %w", err)
+	}
+	defer target.Close()
+
+	services, err := e.registerBenchServices(ctx, run, url)
+	if err != nil {
+		return fmt.Errorf("register services: %w", err)
+	}
+
+	queue, err := e.openBenchQueue()
+	if err != nil {
+		return fmt.Errorf("open queue: %w", err)
+	}
+	defer queue.Close()
+	if deletable, ok := queue.(DeletableQueue); ok {
+		// Runs once the consumer has stopped, and before Close
+		defer func() {
+			if err := deletable.Delete(context.WithoutCancel(ctx)); err != nil {
+				logging.For(ctx, "bench").Warn("queue_delete_failed", "run", run.ID, "err", err)
+			}
+		}()
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	rec := &benchRecorder{engine: e, runID: run.ID, services: services, total: len(services) * run.Rounds, done: make(chan struct{})}
+	consumeCtx, stopConsumer := context.WithCancel(ctx)
+	var consumeErr error
+	consumed := make(chan struct{})
+	go func() {
+		consumeErr = queue.Consume(consumeCtx, func(d Delivery) { e.benchDelivery(consumeCtx, rec, d) })
+		close(consumed)
+	}()
+
+	// The consumer must be done with the services before they are deleted
+	defer func() {
+		stopConsumer()
+		<-consumed
+		rec.report()
+	}()
+
+	if err := rec.publish(ctx, queue, run.Rounds); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("timed out after %s publishing %d of %d jobs", timeout, rec.report().Published, rec.total)
+		}
+		return fmt.Errorf("publish: %w", err)
+	}
+
+	select {
+	case <-rec.done:
+		return nil
+	case <-ctx.Done():
+	case <-consumed:
+		if ctx.Err() == nil {
+			if consumeErr == nil {
+				return errors.New("consume: consumer stopped")
+			}
+			return fmt.Errorf("consume: %w", consumeErr)
+		}
+	}
+	report := rec.report()
+	return fmt.Errorf("timed out after %s with %d of %d jobs done", timeout, report.Completed+report.Failed, rec.total)
+}
+
+// startBenchTarget serves the mock target checked by bench services. It
+// answers 200 after latency.
+func startBenchTarget(latency time.Duration) (*http.Server, string, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, "", err
+	}
+	srv := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-time.After(latency):
+			case <-r.Context().Done():
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go srv.Serve(ln)
+	return srv, "http://" + ln.Addr().String() + "/health", nil
+}
+
+// registerBenchServices registers the synthetic services of a run, keyed by id
+func (e *Engine) registerBenchServices(ctx context.Context, run models.BenchRun, url string) (map[uint]*models.ExternalService, error) {
+	services := make(map[uint]*models.ExternalService, run.Services)
+	for i := 1; i <= run.Services; i++ {
+		s := &models.ExternalService{
+			Name:             fmt.Sprintf("bench-%s-%05d", run.ID[:8], i),
+			URL:              url,
+			Protocol:         "HTTP",
+			HTTPMethod:       "GET",
+			Interval:         run.IntervalSeconds,
+			TimeoutSeconds:   5,
+			FailureThreshold: 3,
+			Tags:             []string{BenchTag},
+		}
+		if err := e.Repo.RegisterService(ctx, s); err != nil {
+			return services, err
+		}
+		services[s.ID] = s
+	}
+	return services, nil
+}
+
+// purgeBenchServices deletes every bench service with its logs and state
+func (e *Engine) purgeBenchServices(ctx context.Context) {
+	logger := logging.For(ctx, "bench")
+
+	bench, err := e.Repo.GetBenchServices(ctx)
+	if err != nil {
+		logger.Error("fetch_services_failed", "err", err)
+		return
+	}
+	var ids []uint
+	for _, s := range bench {
+		ids = append(ids, s.ID)
+	}
+	if len(ids) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Minute)
+	defer cancel()
+	deleted, err := e.Repo.PurgeServices(ctx, ids, nil)
+	if err != nil {
+		logger.Error("purge_failed", "services", len(ids), "err", err)
+		return
+	}
+	for _, id := range ids {
+		e.forgetService(id)
+	}
+	logger.Info("purged", "deleted", deleted)
+}
+
+// openBenchQueue opens a queue next to the job queue, so the workers never
+// see bench jobs. With queue.driver memory it is a pool of its own, sized
+// like the real one.
+func (e *Engine) openBenchQueue() (Queue, error) {
+	switch driver := e.Cnfg.Queue.DriverName(); driver {
+	case "rabbitmq":
+		cfg := e.Cnfg.RabbitMQ
+		cfg.QueueName += benchQueueSuffix
+		// Dead letters get names of their own too, since the run deletes them
+		cfg.DeadLetterExchange, cfg.DeadLetterQueue = "", ""
+		return openAMQPQueue(e.AMQPURL(), cfg)
+	case "redis":
+		cfg := e.Cnfg.Queue.Redis
+		if cfg.Stream == "" {
+			cfg.Stream = defaultRedisStream
+		}
+		cfg.Stream += benchQueueSuffix
+		cfg.DeadStream = ""
+		return openRedisQueue(context.Background(), cfg, e.Cnfg.HA.Instance())
+	case "memory":
+		return newMemoryQueue(e.Cnfg.Queue.Memory), nil
+	default:
+		return nil, fmt.Errorf("unknown queue driver %q", driver)
+	}
+}
+
+// benchDelivery runs a bench job the way processJob runs a real one, up to
+// the state update, timing each step
+func (e *Engine) benchDelivery(ctx context.Context, rec *benchRecorder, d Delivery) {
+	taken := clock.Now()
+
+	var job HealthCheckJob
+	if err := json.Unmarshal(d.Body(), &job); err != nil {
+		d.Reject()
+		return
+	}
+	// Left in the queue by an earlier run that timed out
+	if _, ok := rec.services[job.ServiceID]; !ok {
+		d.Ack()
+		return
+	}
+
+	sample := benchSample{queue: taken.Sub(job.ScheduledAt)}
+	var passed bool
+	err := func() error {
+		service, err := e.jobService(ctx, job)
+		if err != nil {
+			return err
+		}
+
+		start := time.Now()
+		result, err := e.runCheck(ctx, service)
+		sample.check = time.Since(start)
+		if err != nil {
+			return err
+		}
+
+		start = time.Now()
+		if e.jobs != nil {
+			if _, err := e.jobs.claim(ctx, job, clock.Now().Add(jobClaimMargin)); err != nil {
+				return err
+			}
+		}
+		if _, err := e.Repo.SaveServiceCheckLog(*service, e.Cnfg.Worker.Region, result.Status, result.StatusCode, result.LatencyMs, result.ErrorMessage, result.Timings); err != nil {
+			return err
+		}
+		if _, err := e.Repo.UpdateServiceState(ctx, service, result); err != nil {
+			return err
+		}
+		sample.dbWrite = time.Since(start)
+		passed = result.Success
+		return nil
+	}()
+	if err != nil {
+		d.Reject()
+		logging.For(ctx, "bench").Debug("job_failed", "service", job.ServiceName, "err", err)
+	} else {
+		d.Ack()
+	}
+	sample.total = clock.Now().Sub(job.ScheduledAt)
+	sample.ok = err == nil && passed
+	rec.record(sample)
+}
+
+type benchSample struct {
+	queue, check, dbWrite, total time.Duration
+	ok                           bool
+}
+
+// benchRecorder publishes the jobs of a run and collects their timings,
+// closing done once every published job is accounted for
+type benchRecorder struct {
+	engine   *Engine
+	runID    string
+	services map[uint]*models.ExternalService
+	total    int
+
+	mu         sync.Mutex
+	samples    []benchSample
+	published  int
+	failed     int
+	firstAt    time.Time
+	lastAt     time.Time
+	done       chan struct{}
+	doneClosed bool
+}
+
+// publish sends one job per service and round as fast as the queue takes
+// them. A full memory queue is retried, as the scheduler would on its next
+// tick; the job is stamped when it goes in, so waiting isn't queue latency.
+func (r *benchRecorder) publish(ctx context.Context, queue Queue, rounds int) error {
+	r.mu.Lock()
+	r.firstAt = time.Now()
+	r.mu.Unlock()
+
+	for round := 0; round < rounds; round++ {
+		for _, s := range r.services {
+			for {
+				job := newHealthCheckJob(s, false)
+				body, err := json.Marshal(job)
+				if err != nil {
+					return err
+				}
+				err = queue.Publish(ctx, body)
+				if err == nil {
+					break
+				}
+				if !errors.Is(err, errMemoryQueueFull) {
+					return err
+				}
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-time.After(benchPublishBackoff):
+				}
+			}
+
+			r.mu.Lock()
+			r.published++
+			r.mu.Unlock()
+		}
+		r.report()
+	}
+	return nil
+}
+
+func (r *benchRecorder) record(sample benchSample) {
+	r.mu.Lock()
+	r.samples = append(r.samples, sample)
+	if !sample.ok {
+		r.failed++
+	}
+	r.lastAt = time.Now()
+	finished := len(r.samples) >= r.total && !r.doneClosed
+	if finished {
+		r.doneClosed = true
+	}
+	progress := len(r.samples)%1000 == 0
+	r.mu.Unlock()
+
+	if finished {
+		close(r.done)
+	}
+	if finished || progress {
+		r.report()
+	}
+}
+
+// report computes the statistics so far and stores them on the run
+func (r *benchRecorder) report() models.BenchRun {
+	r.mu.Lock()
+	samples := append([]benchSample{}, r.samples...)
+	published, failed, firstAt, lastAt := r.published, r.failed, r.firstAt, r.lastAt
+	r.mu.Unlock()
+
+	var stats models.BenchRun
+	stats.Published = published
+	stats.Completed = len(samples) - failed
+	stats.Failed = failed
+	if len(samples) > 0 {
+		elapsed := lastAt.Sub(firstAt)
+		stats.DurationMs = elapsed.Milliseconds()
+		if elapsed > 0 {
+			stats.JobsPerSecond = math.Round(float64(len(samples))/elapsed.Seconds()*100) / 100
+		}
+	}
+	stats.QueueLatency = benchLatency(samples, func(s benchSample) time.Duration { return s.queue })
+	stats.CheckLatency = benchLatency(samples, func(s benchSample) time.Duration { return s.check })
+	stats.DBWriteLatency = benchLatency(samples, func(s benchSample) time.Duration { return s.dbWrite })
+	stats.EndToEndLatency = benchLatency(samples, func(s benchSample) time.Duration { return s.total })
+
+	r.engine.bench.update(r.runID, func(run *models.BenchRun) {
+		run.Published, run.Completed, run.Failed = stats.Published, stats.Completed, stats.Failed
+		run.DurationMs, run.JobsPerSecond = stats.DurationMs, stats.JobsPerSecond
+		run.QueueLatency, run.CheckLatency = stats.QueueLatency, stats.CheckLatency
+		run.DBWriteLatency, run.EndToEndLatency = stats.DBWriteLatency, stats.EndToEndLatency
+	})
+	return stats
+}
+
+// benchLatency takes nearest-rank percentiles of one timing of the samples
+func benchLatency(samples []benchSample, timing func(benchSample) time.Duration) models.BenchLatency {
+	if len(samples) == 0 {
+		return models.BenchLatency{}
+	}
+	values := make([]time.Duration, len(samples))
+	for i, s := range samples {
+		values[i] = timing(s)
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+
+	ms := func(p float64) float64 {
+		v := values[int(math.Ceil(p*float64(len(values))))-1]
+		return math.Round(float64(v)/float64(time.Millisecond)*10) / 10
+	}
+	return models.BenchLatency{P50Ms: ms(0.5), P95Ms: ms(0.95), P99Ms: ms(0.99), MaxMs: ms(1)}
+}
@@ -1,18 +1,29 @@
 package service
 
 import (
+	"Distributed-Health-Monitoring/config"
+	"Distributed-Health-Monitoring/logging"
 	"Distributed-Health-Monitoring/models"
+	"bytes"
+	"context"
 	"encoding/json"
-	"log"
+	"errors"
+	"fmt"
+	"sort"
 	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
 )
 
 var GlobalHub *Hub
 
-func BroadcastStateChange(
+// NewStateChangeEvent builds the WebSocket event for a service transition
+func NewStateChangeEvent(
 	service models.ExternalService,
 	change *models.StateChange,
-) {
+	result models.CheckResult,
+) models.ServiceStateChangeEvent {
 	event := models.ServiceStateChangeEvent{
 		Type:      "service_state_change",
 		ServiceID: service.ID,
@@ -20,38 +31,187 @@ func BroadcastStateChange(
 		From:      change.From,
 		To:        change.To,
 		Timestamp: time.Now(),
+		Ownership: service.Ownership(),
+	}
+
+	// Failure details only matter when the service is going down or slowing down
+	if change.To == "DOWN" || change.To == models.StatusDegraded {
+		event.Reason = result.Reason
+		event.Error = result.ErrorMessage
+		event.AssertionFailure = result.AssertionFailure
 	}
 
+	return event
+}
+
+func BroadcastStateChange(event models.ServiceStateChangeEvent) {
+	BroadcastEvent(event.Name, event)
+}
+
+// BroadcastEvent marshals any event and sends it to every WebSocket client
+func BroadcastEvent(serviceName string, event interface{}) {
 	payload, err := json.Marshal(event)
 	if err != nil {
-		log.Printf("[WS] marshal_failed service=%s err=%v", service.Name, err)
+		logging.For(context.Background(), "ws").Error("marshal_failed", "service", serviceName, "err", err)
 		return
 	}
 
 	GlobalHub.Broadcast(payload)
+	clusterBus.publishEvent(payload)
+}
+
+// Slow client policies: what the hub does when a client's queue is full
+const (
+	SlowClientDisconnect = "disconnect"  // close the connection; the client reconnects with last_seq
+	SlowClientDropOldest = "drop_oldest" // discard the oldest queued event; the client sees a seq gap
+)
+
+const defaultSendQueue = 256
+
+func validateWebSocket(cfg config.WebSocket) error {
+	switch cfg.SlowClientPolicy {
+	case "", SlowClientDisconnect, SlowClientDropOldest:
+	default:
+		return fmt.Errorf("websocket: unknown slow_client_policy %q", cfg.SlowClientPolicy)
+	}
+	if cfg.SendQueue < 0 || cfg.ReplayBuffer < 0 {
+		return errors.New("websocket: send_queue and replay_buffer must not be negative")
+	}
+	if cfg.MaxConnections < 0 || cfg.PingIntervalSeconds < 0 || cfg.PongTimeoutSeconds < 0 || cfg.WriteTimeoutSeconds < 0 || cfg.TokenTTLSeconds < 0 {
+		return errors.New("websocket: limits and timeouts must not be negative")
+	}
+	ping, pong := wsDurations(cfg)
+	if pong <= ping {
+		return errors.New("websocket: pong_timeout_seconds must be longer than ping_interval_seconds")
+	}
+	return nil
 }
 
 type Hub struct {
-	clients    map[*models.Client]bool
+	clients    map[*models.Client]*clientStats
 	broadcast  chan []byte
 	register   chan *models.Client
 	unregister chan *models.Client
+	resume     chan resumeRequest
+	direct     chan directMessage
+	stats      chan chan HubStats
+
+	policy    string
+	queueSize int // per client: the live backlog plus room for a full replay
+
+	// Totals since startup, including clients that are gone. Only Run touches these.
+	sent         uint64
+	dropped      uint64
+	disconnected uint64
+
+	// Every broadcast gets the next seq and is kept in a ring buffer so
+	// reconnecting clients can ask for what they missed. Only Run touches these.
+	seq     uint64
+	history [][]byte // event seq is at position (seq-1) % len
+}
+
+// clientStats counts what one client was sent and lost
+type clientStats struct {
+	connectedAt time.Time
+	sent        uint64
+	dropped     uint64
+}
+
+// HubStats is a snapshot of the hub's delivery counters
+type HubStats struct {
+	Policy       string        `json:"policy"`
+	QueueSize    int           `json:"queue_size"`
+	Seq          uint64        `json:"seq"`
+	Sent         uint64        `json:"sent"`
+	Dropped      uint64        `json:"dropped"`      // events discarded under drop_oldest
+	Disconnected uint64        `json:"disconnected"` // clients closed for falling behind
+	Clients      []ClientStats `json:"clients"`
+}
+
+type ClientStats struct {
+	Remote      string    `json:"remote"`
+	Subject     string    `json:"subject,omitempty"`
+	ConnectedAt time.Time `json:"connected_at"`
+	Queued      int       `json:"queued"`
+	Sent        uint64    `json:"sent"`
+	Dropped     uint64    `json:"dropped"`
+}
+
+// directMessage is a reply for one client; it gets no seq and isn't replayed
+type directMessage struct {
+	client *models.Client
+	msg    []byte
+}
+
+type resumeRequest struct {
+	client    *models.Client
+	lastSeq   uint64
+	connected bool // sent over an open connection rather than on connect
+}
+
+// ReplayGapEvent tells a client that events after its last_seq are no longer
+// buffered (or the server restarted), so it must reload full state
+type ReplayGapEvent struct {
+	Type      string `json:"type"` // replay_gap
+	LastSeq   uint64 `json:"last_seq"`
+	OldestSeq uint64 `json:"oldest_seq"` // oldest seq still buffered, 0 when empty
+	Seq       uint64 `json:"seq"`        // current seq
 }
 
 func (e *Engine) NewHub() *Hub {
+	cfg := e.Cnfg.WebSocket
+	policy := cfg.SlowClientPolicy
+	if policy == "" {
+		policy = SlowClientDisconnect
+	}
+	sendQueue := cfg.SendQueue
+	if sendQueue == 0 {
+		sendQueue = defaultSendQueue
+	}
+
 	return &Hub{
-		clients:    make(map[*models.Client]bool),
+		clients:    make(map[*models.Client]*clientStats),
 		broadcast:  make(chan []byte, 256),
 		register:   make(chan *models.Client),
 		unregister: make(chan *models.Client),
+		resume:     make(chan resumeRequest),
+		direct:     make(chan directMessage),
+		stats:      make(chan chan HubStats),
+		policy:     policy,
+		queueSize:  sendQueue + cfg.ReplayBuffer,
+		history:    make([][]byte, cfg.ReplayBuffer),
 	}
 }
 
+// NewClient creates a client with a send queue of the configured size
+func (h *Hub) NewClient(conn *websocket.Conn) *models.Client {
+	return &models.Client{Conn: conn, Send: make(chan []byte, h.queueSize)}
+}
+
 func (h *Hub) Run() {
 	for {
 		select {
 		case client := <-h.register:
-			h.clients[client] = true
+			h.clients[client] = &clientStats{connectedAt: time.Now()}
+
+		case req := <-h.resume:
+			if req.connected {
+				// A connected client that was dropped has a closed Send channel
+				if _, ok := h.clients[req.client]; !ok {
+					continue
+				}
+			} else {
+				h.clients[req.client] = &clientStats{connectedAt: time.Now()}
+			}
+			// Nothing else runs on the hub until the replay is queued, so
+			// replayed events stay ahead of live ones
+			h.replay(req.client, req.lastSeq)
+
+		case d := <-h.direct:
+			h.send(d.client, d.msg)
+
+		case reply := <-h.stats:
+			reply <- h.snapshot()
 
 		case client := <-h.unregister:
 			if _, ok := h.clients[client]; ok {
@@ -60,13 +220,9 @@ func (h *Hub) Run() {
 			}
 
 		case msg := <-h.broadcast:
+			msg = h.record(msg)
 			for c := range h.clients {
-				select {
-				case c.Send <- msg:
-				default:
-					delete(h.clients, c)
-					close(c.Send)
-				}
+				h.send(c, msg)
 			}
 		}
 	}
@@ -75,3 +231,155 @@ func (h *Hub) Run() {
 func (h *Hub) Broadcast(msg []byte) {
 	h.broadcast <- msg
 }
+
+// SendTo sends a reply to one client only
+func (h *Hub) SendTo(client *models.Client, reply interface{}) {
+	payload, err := json.Marshal(reply)
+	if err != nil {
+		logging.For(context.Background(), "ws").Error("marshal_failed", "err", err)
+		return
+	}
+	h.direct <- directMessage{client: client, msg: payload}
+}
+
+// Stats asks the hub for its counters; it is answered between events
+func (h *Hub) Stats() HubStats {
+	reply := make(chan HubStats, 1)
+	h.stats <- reply
+	return <-reply
+}
+
+func (h *Hub) snapshot() HubStats {
+	out := HubStats{
+		Policy:       h.policy,
+		QueueSize:    h.queueSize,
+		Seq:          h.seq,
+		Sent:         h.sent,
+		Dropped:      h.dropped,
+		Disconnected: h.disconnected,
+		Clients:      make([]ClientStats, 0, len(h.clients)),
+	}
+	for c, st := range h.clients {
+		out.Clients = append(out.Clients, ClientStats{
+			Remote:      remoteAddr(c),
+			Subject:     c.Subject,
+			ConnectedAt: st.connectedAt,
+			Queued:      len(c.Send),
+			Sent:        st.sent,
+			Dropped:     st.dropped,
+		})
+	}
+	sort.Slice(out.Clients, func(i, j int) bool { return out.Clients[i].ConnectedAt.Before(out.Clients[j].ConnectedAt) })
+	return out
+}
+
+// Resume registers the client, first sending it the buffered events after
+// lastSeq. connected is true when an already registered client asks again.
+func (h *Hub) Resume(client *models.Client, lastSeq uint64, connected bool) {
+	h.resume <- resumeRequest{client: client, lastSeq: lastSeq, connected: connected}
+}
+
+// record stamps the event with the next sequence number and buffers it
+func (h *Hub) record(msg []byte) []byte {
+	h.seq++
+	msg = withSeq(msg, h.seq)
+
+	if len(h.history) > 0 {
+		h.history[(h.seq-1)%uint64(len(h.history))] = msg
+	}
+	return msg
+}
+
+// replay sends the events after lastSeq, or a replay_gap event when some of
+// them are gone. It returns false if the client was dropped meanwhile.
+func (h *Hub) replay(client *models.Client, lastSeq uint64) bool {
+	buffered := uint64(len(h.history))
+	if buffered > h.seq {
+		buffered = h.seq
+	}
+	oldest := h.seq - buffered + 1
+
+	if lastSeq > h.seq || lastSeq+1 < oldest {
+		gap := ReplayGapEvent{Type: "replay_gap", LastSeq: lastSeq, Seq: h.seq}
+		if buffered > 0 {
+			gap.OldestSeq = oldest
+		}
+		payload, _ := json.Marshal(gap)
+		return h.send(client, payload)
+	}
+
+	for seq := lastSeq + 1; seq <= h.seq; seq++ {
+		if !h.send(client, h.history[(seq-1)%uint64(len(h.history))]) {
+			return false
+		}
+	}
+	return true
+}
+
+// send never blocks the hub. When the client's queue is full the policy
+// decides: drop its oldest queued event, or disconnect it.
+func (h *Hub) send(c *models.Client, msg []byte) bool {
+	st := h.clients[c]
+	if st == nil {
+		return false
+	}
+
+	select {
+	case c.Send <- msg:
+		st.sent++
+		h.sent++
+		return true
+	default:
+	}
+
+	if h.policy == SlowClientDropOldest {
+		// Only the hub sends, so once an event is taken out there is room
+		select {
+		case <-c.Send:
+			st.dropped++
+			h.dropped++
+			if st.dropped == 1 {
+				logging.For(context.Background(), "ws").Warn("slow_client_dropping", "remote", remoteAddr(c), "queue_size", h.queueSize)
+			}
+		default:
+		}
+		select {
+		case c.Send <- msg:
+			st.sent++
+			h.sent++
+			return true
+		default:
+		}
+	}
+
+	h.disconnected++
+	logging.For(context.Background(), "ws").Warn("slow_client_disconnected", "remote", remoteAddr(c), "queue_size", h.queueSize, "sent", st.sent, "dropped", st.dropped)
+	delete(h.clients, c)
+	close(c.Send)
+	return false
+}
+
+func remoteAddr(c *models.Client) string {
+	if c.Conn == nil {
+		return ""
+	}
+	return c.Conn.RemoteAddr().String()
+}
+
+// GetWebSocketStats reports the hub's delivery counters and each client's queue
+func (e *Engine) GetWebSocketStats(c *gin.Context) {
+	c.JSON(200, GlobalHub.Stats())
+}
+
+// withSeq adds a "seq" field to a JSON object payload
+func withSeq(msg []byte, seq uint64) []byte {
+	if len(msg) < 2 || msg[0] != '{' {
+		return msg
+	}
+
+	prefix := fmt.Sprintf(`{"seq":%d`, seq)
+	if len(bytes.TrimSpace(msg[1:len(msg)-1])) > 0 {
+		prefix += ","
+	}
+	return append([]byte(prefix), msg[1:]...)
+}
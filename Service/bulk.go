@@ -0,0 +1,293 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/Repository"
+	"Distributed-Health-Monitoring/cache"
+	"Distributed-Health-Monitoring/logging"
+	"Distributed-Health-Monitoring/models"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/goccy/go-yaml"
+)
+
+// maxImportBytes bounds an import document
+const maxImportBytes = 5 << 20
+
+// runtimeFields are the service fields owned by the monitor rather than the
+// definition. They are left out of exports and ignored on import; the
+// heartbeat token is a secret and doesn't belong in Git either.
+var runtimeFields = []string{
+	"id",
+	"status",
+	"consecutive_failures",
+	"flapping",
+	"acknowledgement",
+	"root_cause",
+	"last_checked_at",
+	"next_run_at",
+	"in_flight_until",
+	"last_round_at",
+	"remediation_attempts",
+	"last_remediation_at",
+	"heartbeat_token",
+	"last_heartbeat_at",
+	"created_at",
+	"updated_at",
+}
+
+type ImportResult struct {
+	Name   string `json:"name"`
+	Action string `json:"action"` // created, updated, unchanged, failed
+	ID     uint   `json:"id,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// serviceDefinition returns the declarative part of a service as a JSON object
+func serviceDefinition(s *models.ExternalService) (map[string]interface{}, error) {
+	raw, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	var def map[string]interface{}
+	if err := json.Unmarshal(raw, &def); err != nil {
+		return nil, err
+	}
+	for _, f := range runtimeFields {
+		delete(def, f)
+	}
+	return def, nil
+}
+
+// ImportServices upserts a YAML or JSON list of service definitions by name.
+// Importing the same document twice changes nothing.
+func (e *Engine) ImportServices(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	defs, err := ParseServiceDefinitions(body)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	seen := make(map[string]bool, len(defs))
+	for _, def := range defs {
+		if def == nil || def.Name == "" {
+			c.JSON(400, gin.H{"error": "every service definition needs a name"})
+			return
+		}
+		if seen[def.Name] {
+			c.JSON(400, gin.H{"error": "duplicate service " + def.Name})
+			return
+		}
+		seen[def.Name] = true
+	}
+
+	services, err := e.Repo.GetAllServices(ctx)
+	if err != nil && !errors.Is(err, Repository.ErrNoServices) {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	byName := make(map[string]*models.ExternalService, len(services))
+	for _, s := range services {
+		byName[s.Name] = s
+	}
+
+	results := make([]ImportResult, 0, len(defs))
+	counts := map[string]int{}
+	for _, def := range defs {
+		var result ImportResult
+		if err := checkReservedTags(def); err != nil {
+			result = ImportResult{Name: def.Name, Action: "failed", Error: err.Error()}
+		} else {
+			result = e.importService(ctx, def, byName[def.Name])
+		}
+		counts[result.Action]++
+		results = append(results, result)
+	}
+
+	logging.For(ctx, "import").Info(
+		"services_imported",
+		"created", counts["created"],
+		"updated", counts["updated"],
+		"unchanged", counts["unchanged"],
+		"failed", counts["failed"],
+	)
+
+	status := 200
+	if counts["failed"] > 0 {
+		status = 422
+	}
+	c.JSON(status, gin.H{
+		"created":   counts["created"],
+		"updated":   counts["updated"],
+		"unchanged": counts["unchanged"],
+		"failed":    counts["failed"],
+		"services":  results,
+	})
+}
+
+func (e *Engine) importService(ctx context.Context, def *models.ExternalService, existing *models.ExternalService) ImportResult {
+	result := ImportResult{Name: def.Name}
+
+	if existing == nil {
+		// Runtime fields in the document are ignored, as they are on update
+		def.ID = 0
+		def.HeartbeatToken = nil
+		def.LastHeartbeatAt = nil
+		if err := e.Repo.RegisterService(ctx, def); err != nil {
+			result.Action, result.Error = "failed", err.Error()
+			return result
+		}
+
+		cache.Services.Set(def)
+		e.audit(ctx, auditCreate, auditService, def.ID, def.Name, "", nil, auditedService(def))
+		clusterBus.serviceChanged(def.ID)
+		BroadcastEvent(def.Name, models.ServiceStateChangeEvent{
+			Type:      "service_registered",
+			ServiceID: def.ID,
+			Name:      def.Name,
+			To:        def.Status,
+			Timestamp: time.Now(),
+		})
+
+		result.Action, result.ID = "created", def.ID
+		return result
+	}
+
+	result.ID = existing.ID
+
+	// Keep the monitor's own state and apply the definition fields on top
+	update := *def
+	update.ID = existing.ID
+	update.Status = existing.Status
+	update.ConsecutiveFailures = existing.ConsecutiveFailures
+	update.Flapping = existing.Flapping
+	update.Acknowledgement = existing.Acknowledgement
+	update.LastCheckedAt = existing.LastCheckedAt
+	update.NextRunAt = existing.NextRunAt
+	if update.Schedule != existing.Schedule || update.ScheduleTimezone != existing.ScheduleTimezone {
+		// The next run was planned from the old schedule
+		update.NextRunAt = nil
+	}
+	update.InFlightUntil = existing.InFlightUntil
+	update.LastRoundAt = existing.LastRoundAt
+	update.RemediationAttempts = existing.RemediationAttempts
+	update.LastRemediationAt = existing.LastRemediationAt
+	update.HeartbeatToken = existing.HeartbeatToken
+	keepRedactedSecrets(&update, existing)
+	update.LastHeartbeatAt = existing.LastHeartbeatAt
+	update.CreatedAt = existing.CreatedAt
+	update.UpdatedAt = existing.UpdatedAt
+	update.Tags = Repository.NormalizeTags(update.Tags)
+	update.DependsOn = Repository.NormalizeTags(update.DependsOn)
+
+	// Skip the write when the definition already matches, so re-applying a document is a no-op
+	if sameDefinition(existing, &update) {
+		result.Action = "unchanged"
+		return result
+	}
+
+	if err := e.Repo.RegisterService(ctx, &update); err != nil {
+		result.Action, result.Error = "failed", err.Error()
+		return result
+	}
+
+	cache.Services.Set(&update)
+	e.audit(ctx, auditUpdate, auditService, update.ID, update.Name, "", auditedService(existing), auditedService(&update))
+	clusterBus.serviceChanged(update.ID)
+
+	result.Action = "updated"
+	return result
+}
+
+// sameDefinition compares two normalized definitions. Tags that differ only
+// in order or case, and dependencies only in order, are the same.
+func sameDefinition(a, b *models.ExternalService) bool {
+	defA, errA := serviceDefinition(canonicalLists(a))
+	defB, errB := serviceDefinition(canonicalLists(b))
+	if errA != nil || errB != nil {
+		return false
+	}
+	// Marshalling maps sorts their keys, so equal definitions encode identically
+	rawA, errA := json.Marshal(defA)
+	rawB, errB := json.Marshal(defB)
+	return errA == nil && errB == nil && bytes.Equal(rawA, rawB)
+}
+
+func canonicalLists(s *models.ExternalService) *models.ExternalService {
+	c := *s
+	c.Tags = make([]string, len(s.Tags))
+	for i, t := range s.Tags {
+		c.Tags[i] = strings.ToLower(t)
+	}
+	sort.Strings(c.Tags)
+	c.DependsOn = slices.Sorted(slices.Values(s.DependsOn))
+	return &c
+}
+
+// ExportServices dumps every service definition as JSON, or as YAML with
+// ?format=yaml, in a form ImportServices accepts
+func (e *Engine) ExportServices(c *gin.Context) {
+	services, err := e.Repo.GetAllServices(c.Request.Context())
+	if err != nil && !errors.Is(err, Repository.ErrNoServices) {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	list := make([]*models.ExternalService, 0, len(services))
+	for _, s := range services {
+		// Self-monitoring checks come from config, not from the exported document
+		if s.HasTag(SystemTag) {
+			continue
+		}
+		list = append(list, s)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+
+	defs := make([]map[string]interface{}, 0, len(list))
+	for _, s := range list {
+		view := *s
+		redactSecrets(&view)
+		def, err := serviceDefinition(&view)
+		if err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+		defs = append(defs, def)
+	}
+
+	doc, err := json.Marshal(gin.H{"services": defs})
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	switch c.DefaultQuery("format", "json") {
+	case "json":
+		c.Data(200, "application/json; charset=utf-8", doc)
+	case "yaml":
+		out, err := yaml.JSONToYAML(doc)
+		if err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+		c.Data(200, "application/yaml; charset=utf-8", out)
+	default:
+		c.JSON(400, gin.H{"error": "format must be json or yaml"})
+	}
+}
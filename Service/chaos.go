@@ -0,0 +1,203 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/logging"
+	"Distributed-Health-Monitoring/models"
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	ChaosModeFail    = "fail"
+	ChaosModeTimeout = "timeout"
+	ChaosModeSlow    = "slow"
+
+	defaultChaosSlowMs = 5000
+)
+
+// ChaosInjection forces the result of the next Remaining checks of a service
+type ChaosInjection struct {
+	Mode      string    `json:"mode"`     // fail, timeout, slow
+	DelayMs   int64     `json:"delay_ms"` // how long slow injections hold the check
+	Remaining int       `json:"remaining"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ChaosInjector holds pending synthetic results keyed by service id
+type ChaosInjector struct {
+	mu         sync.Mutex
+	injections map[uint]*ChaosInjection
+}
+
+func NewChaosInjector() *ChaosInjector {
+	return &ChaosInjector{
+		injections: make(map[uint]*ChaosInjection),
+	}
+}
+
+// Set replaces any pending injection for the service
+func (c *ChaosInjector) Set(serviceID uint, injection *ChaosInjection) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.injections[serviceID] = injection
+}
+
+// Clear removes the pending injection for the service
+func (c *ChaosInjector) Clear(serviceID uint) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.injections, serviceID)
+}
+
+// Take consumes one pending injection for the service, if any
+func (c *ChaosInjector) Take(serviceID uint) (ChaosInjection, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	injection, ok := c.injections[serviceID]
+	if !ok {
+		return ChaosInjection{}, false
+	}
+
+	injection.Remaining--
+	if injection.Remaining <= 0 {
+		delete(c.injections, serviceID)
+	}
+
+	return *injection, true
+}
+
+// List returns a snapshot of all pending injections
+func (c *ChaosInjector) List() map[uint]ChaosInjection {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	out := make(map[uint]ChaosInjection, len(c.injections))
+	for id, injection := range c.injections {
+		out[id] = *injection
+	}
+	return out
+}
+
+// Wait holds a slow injection for its delay, so the worker is as busy as it
+// would be with a slow target. It returns early when ctx is done.
+func (i ChaosInjection) Wait(ctx context.Context) {
+	if i.Mode != ChaosModeSlow || i.DelayMs <= 0 {
+		return
+	}
+	t := time.NewTimer(time.Duration(i.DelayMs) * time.Millisecond)
+	defer t.Stop()
+	select {
+	case <-t.C:
+	case <-ctx.Done():
+	}
+}
+
+// Result builds the synthetic check result for the injection without touching the target
+func (i ChaosInjection) Result(service *models.ExternalService) models.CheckResult {
+	switch i.Mode {
+	case ChaosModeTimeout:
+		return models.CheckResult{
+			Status:       "DOWN",
+			LatencyMs:    service.TimeoutSeconds * 1000,
+			ErrorMessage: "chaos: injected timeout",
+			Reason:       "unreachable",
+		}
+
+	case ChaosModeSlow:
+		return models.CheckResult{
+			Status:     "UP",
+			StatusCode: 200,
+			LatencyMs:  i.DelayMs,
+			Success:    true,
+		}
+
+	default:
+		return models.CheckResult{
+			Status:       "DOWN",
+			StatusCode:   500,
+			ErrorMessage: "chaos: injected failure",
+			Reason:       "http_status",
+		}
+	}
+}
+
+type chaosRequest struct {
+	Mode    string `json:"mode" binding:"required"`
+	DelayMs int64  `json:"delay_ms"`
+	Count   int    `json:"count"`
+}
+
+// InjectChaos forces the next check result(s) of a service
+func (e *Engine) InjectChaos(c *gin.Context) {
+	if !e.Cnfg.Chaos.Enabled {
+		c.JSON(403, gin.H{"error": "chaos mode is disabled"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("serviceId"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "invalid service id"})
+		return
+	}
+
+	var req chaosRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Mode != ChaosModeFail && req.Mode != ChaosModeTimeout && req.Mode != ChaosModeSlow {
+		c.JSON(400, gin.H{"error": fmt.Sprintf("invalid chaos mode %q", req.Mode)})
+		return
+	}
+	if req.Count <= 0 {
+		req.Count = 1
+	}
+	if req.Mode == ChaosModeSlow && req.DelayMs <= 0 {
+		req.DelayMs = defaultChaosSlowMs
+	}
+
+	if _, ok := e.serviceByID(c, uint(id)); !ok {
+		return
+	}
+
+	injection := &ChaosInjection{
+		Mode:      req.Mode,
+		DelayMs:   req.DelayMs,
+		Remaining: req.Count,
+		CreatedAt: time.Now(),
+	}
+	e.Chaos.Set(uint(id), injection)
+
+	logging.For(c.Request.Context(), "chaos").Info("injection_set", "service_id", id, "mode", req.Mode, "count", req.Count)
+
+	c.JSON(201, gin.H{"message": "chaos injection scheduled", "injection": injection})
+}
+
+// ClearChaos removes a pending injection for a service
+func (e *Engine) ClearChaos(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("serviceId"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "invalid service id"})
+		return
+	}
+
+	e.Chaos.Clear(uint(id))
+
+	c.JSON(200, gin.H{"message": "chaos injection cleared"})
+}
+
+// ListChaos returns all pending injections
+func (e *Engine) ListChaos(c *gin.Context) {
+	c.JSON(200, gin.H{"enabled": e.Cnfg.Chaos.Enabled, "injections": e.Chaos.List()})
+}
+
+func LogChaosInjected(ctx context.Context, serviceName string, injection ChaosInjection) {
+	logging.For(ctx, "chaos").Info("result_injected", "service", serviceName, "mode", injection.Mode, "remaining", injection.Remaining)
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/clock"
+	"Distributed-Health-Monitoring/config"
+	"Distributed-Health-Monitoring/logging"
+	"Distributed-Health-Monitoring/notify"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	defaultClockSync     = time.Minute
+	defaultClockSkewWarn = 2 * time.Second
+)
+
+// ClockStatus compares the clock of the replica that answered with the
+// database clock, which every replica schedules by
+type ClockStatus struct {
+	OffsetSeconds   float64    `json:"offset_seconds"`    // database clock minus this machine's clock
+	RoundTripMs     int64      `json:"round_trip_ms"`     // of the last reading; the offset is accurate to half of it
+	SkewWarnSeconds float64    `json:"skew_warn_seconds"` // 0 when skew warnings are off
+	Skewed          bool       `json:"skewed"`            // the offset is above skew_warn_seconds
+	SyncedAt        *time.Time `json:"synced_at,omitempty"`
+	Error           string     `json:"error,omitempty"` // why the last reading failed; the previous offset still applies
+}
+
+type clockState struct {
+	mu     sync.Mutex
+	status ClockStatus
+}
+
+func (s *clockState) get() ClockStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status
+}
+
+func (s *clockState) set(status ClockStatus) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = status
+}
+
+// clockSync is scheduler.clock_sync_seconds; 0 when syncing is disabled
+func clockSync(cfg config.Scheduler) time.Duration {
+	switch {
+	case cfg.ClockSyncSeconds < 0:
+		return 0
+	case cfg.ClockSyncSeconds == 0:
+		return defaultClockSync
+	}
+	return time.Duration(cfg.ClockSyncSeconds) * time.Second
+}
+
+// clockSkewWarn is scheduler.clock_skew_warn_seconds; 0 when warnings are disabled
+func clockSkewWarn(cfg config.Scheduler) time.Duration {
+	switch {
+	case cfg.ClockSkewWarnSeconds < 0:
+		return 0
+	case cfg.ClockSkewWarnSeconds == 0:
+		return defaultClockSkewWarn
+	}
+	return time.Duration(cfg.ClockSkewWarnSeconds * float64(time.Second))
+}
+
+// ClockSync keeps this replica on the database clock. Every replica runs
+// it, leader or not: workers stamp check times and the previous leader's
+// next run times are read by the next one, so they all need one clock.
+func (e *Engine) ClockSync(ctx context.Context) error {
+	interval := clockSync(e.Cnfg.Scheduler)
+	if interval == 0 {
+		return nil
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		e.syncClock(ctx)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// syncClock reads the database clock and warns once when the offset goes
+// above clock_skew_warn_seconds, announcing the recovery once it is back
+// under half of that
+func (e *Engine) syncClock(ctx context.Context) {
+	logger := logging.For(ctx, "clock")
+	warn := clockSkewWarn(e.Cnfg.Scheduler)
+	previous := e.clock.get()
+	status := previous
+	status.SkewWarnSeconds = warn.Seconds()
+
+	offset, rtt, err := clock.Sync(ctx, e.Repo.DatabaseNow)
+	if err != nil {
+		status.Error = err.Error()
+		e.clock.set(status)
+		logger.Error("clock_sync_failed", "err", err)
+		return
+	}
+	now := clock.Now()
+	status.OffsetSeconds = offset.Seconds()
+	status.RoundTripMs = rtt.Milliseconds()
+	status.SyncedAt = &now
+	status.Error = ""
+
+	skew := offset.Abs()
+	switch {
+	case warn == 0:
+		status.Skewed = false
+	case !previous.Skewed && skew > warn:
+		status.Skewed = true
+	case previous.Skewed && skew <= warn/2:
+		status.Skewed = false
+	}
+	e.clock.set(status)
+	if status.Skewed == previous.Skewed {
+		return
+	}
+
+	instance := e.Cnfg.HA.Instance()
+	alert := notify.Alert{
+		Type:      "clock_skew",
+		Instance:  instance,
+		Reason:    fmt.Sprintf("clock of %s is %s off the database clock", instance, offset.Round(time.Millisecond)),
+		Timestamp: now,
+	}
+	if status.Skewed {
+		alert.Severity = e.Notifier.Severity("clock_skew")
+		logger.Warn("clock_skew", "instance", instance, "offset", offset.Round(time.Millisecond), "round_trip", rtt, "warn", warn)
+	} else {
+		alert.Type = "clock_skew_end"
+		alert.Severity = e.Notifier.Severity("clock_skew_end")
+		logger.Info("clock_skew_end", "instance", instance, "offset", offset.Round(time.Millisecond))
+	}
+	e.Notifier.Dispatch(alert)
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/cache"
+	"Distributed-Health-Monitoring/config"
+	"Distributed-Health-Monitoring/logging"
+	"Distributed-Health-Monitoring/models"
+	"Distributed-Health-Monitoring/redis"
+	"context"
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultClusterChannel = "dhm:cluster"
+
+	// clusterQueue buffers outgoing messages so a slow Redis never holds up
+	// the worker that broadcast an event
+	clusterQueue = 1024
+
+	clusterReconnectDelay = 2 * time.Second
+)
+
+// Kinds of cluster messages
+const (
+	clusterEvent          = "event"           // a WebSocket event to rebroadcast
+	clusterServiceChanged = "service_changed" // a service was created, edited or deleted
+)
+
+// clusterBus is nil unless cluster.enabled is set; its methods accept a nil receiver
+var clusterBus *cluster
+
+// cluster relays WebSocket events and service cache invalidations between
+// replicas over one Redis pub/sub channel. Every replica keeps its own
+// in-memory service cache and Hub; the channel only tells the others what
+// changed. Service definitions themselves are reloaded from the database,
+// since in memory they hold decrypted secrets.
+type cluster struct {
+	client  *redis.Client
+	channel string
+	origin  string // this replica, so it skips its own messages
+
+	outgoing chan []byte
+
+	connected                    atomic.Bool
+	published, received, dropped atomic.Uint64
+}
+
+type clusterMessage struct {
+	Origin    string          `json:"origin"`
+	Kind      string          `json:"kind"`
+	ServiceID uint            `json:"service_id,omitempty"`
+	Event     json.RawMessage `json:"event,omitempty"`
+}
+
+func newCluster(cfg config.Cluster, origin string) *cluster {
+	if !cfg.Enabled {
+		return nil
+	}
+	channel := cfg.Channel
+	if channel == "" {
+		channel = defaultClusterChannel
+	}
+	return &cluster{
+		client:   redis.NewClient(cfg.Address, cfg.Password, cfg.DB),
+		channel:  channel,
+		origin:   origin,
+		outgoing: make(chan []byte, clusterQueue),
+	}
+}
+
+// publishEvent sends a marshalled WebSocket event to the other replicas
+func (b *cluster) publishEvent(payload []byte) {
+	if b == nil {
+		return
+	}
+	b.send(clusterMessage{Kind: clusterEvent, Event: payload})
+}
+
+// serviceChanged tells the other replicas to reload one service
+func (b *cluster) serviceChanged(id uint) {
+	if b == nil {
+		return
+	}
+	b.send(clusterMessage{Kind: clusterServiceChanged, ServiceID: id})
+}
+
+func (b *cluster) send(msg clusterMessage) {
+	msg.Origin = b.origin
+	raw, err := json.Marshal(msg)
+	if err != nil {
+		logging.For(context.Background(), "cluster").Error("marshal_failed", "kind", msg.Kind, "err", err)
+		return
+	}
+	select {
+	case b.outgoing <- raw:
+	default:
+		b.dropped.Add(1)
+	}
+}
+
+// ClusterEvents publishes this replica's events and cache invalidations to
+// Redis and applies those of the other replicas
+func (e *Engine) ClusterEvents(ctx context.Context) error {
+	b := clusterBus
+	if b == nil {
+		return nil
+	}
+	logger := logging.For(ctx, "cluster")
+	logger.Info("started", "channel", b.channel, "origin", b.origin)
+
+	go b.publishLoop(ctx)
+
+	for reconnect := false; ; reconnect = true {
+		err := b.client.Subscribe(ctx, b.channel, func() {
+			b.connected.Store(true)
+			logger.Info("subscribed", "reconnect", reconnect)
+			if reconnect {
+				// Invalidations sent while disconnected are lost, so the
+				// whole cache is reloaded on the next read
+				cache.Services.Invalidate()
+			}
+		}, func(raw []byte) {
+			e.applyClusterMessage(ctx, raw)
+		})
+		b.connected.Store(false)
+
+		if ctx.Err() != nil {
+			return nil
+		}
+		logger.Warn("subscription_lost", "err", err)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(clusterReconnectDelay):
+		}
+	}
+}
+
+func (b *cluster) publishLoop(ctx context.Context) {
+	logger := logging.For(ctx, "cluster")
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case raw := <-b.outgoing:
+			callCtx, cancel := context.WithTimeout(ctx, redisCallTimeout)
+			_, err := b.client.Publish(callCtx, b.channel, raw)
+			cancel()
+			if err != nil {
+				b.dropped.Add(1)
+				logger.Warn("publish_failed", "err", err)
+				continue
+			}
+			b.published.Add(1)
+		}
+	}
+}
+
+func (e *Engine) applyClusterMessage(ctx context.Context, raw []byte) {
+	var msg clusterMessage
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		logging.For(ctx, "cluster").Warn("invalid_message", "err", err)
+		return
+	}
+	if msg.Origin == clusterBus.origin {
+		return
+	}
+	clusterBus.received.Add(1)
+
+	switch msg.Kind {
+	case clusterEvent:
+		GlobalHub.Broadcast(msg.Event)
+
+		// State changes are frequent, so the cached status is patched from
+		// the event instead of reloading the service
+		var event models.ServiceStateChangeEvent
+		if json.Unmarshal(msg.Event, &event) == nil && event.Type == "service_state_change" {
+			cache.Services.Update(event.ServiceID, func(cached *models.ExternalService) { cached.Status = event.To })
+		}
+
+	case clusterServiceChanged:
+		e.reloadCachedService(ctx, msg.ServiceID)
+	}
+}
+
+// reloadCachedService replaces one entry of the service cache, or drops it
+// when the service is gone
+func (e *Engine) reloadCachedService(ctx context.Context, id uint) {
+	service, err := e.Repo.GetServiceByID(ctx, id)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		e.forgetService(id)
+	case err != nil:
+		logging.For(ctx, "cluster").Error("reload_failed", "service_id", id, "err", err)
+		cache.Services.Invalidate()
+	default:
+		cache.Services.Set(service)
+	}
+}
@@ -0,0 +1,213 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/clock"
+	"Distributed-Health-Monitoring/logging"
+	"Distributed-Health-Monitoring/models"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// needsConfirmation reports whether a failed check waits for its re-checks
+// before it counts. A service that is already DOWN isn't confirmed again, so
+// the outage is tracked at the usual pace.
+func (e *Engine) needsConfirmation(service *models.ExternalService, result models.CheckResult) bool {
+	return !result.Success && service.Status != "DOWN" && len(service.Regions) == 0 &&
+		service.Confirmation.Enabled() && e.confirms != nil
+}
+
+// recheckDropReason names why a re-check no longer applies to its service,
+// whose confirmation may have changed since it was queued
+func recheckDropReason(service *models.ExternalService, job HealthCheckJob) string {
+	c := service.Confirmation
+	switch {
+	case !c.Enabled() || int64(job.Recheck) > c.Checks:
+		return "confirmation_removed"
+	case job.Region != "" && !slices.Contains(c.Regions, job.Region):
+		return "region_removed"
+	}
+	return ""
+}
+
+// startConfirmation records a failed check as the first vote of its round
+// and publishes the re-checks. It reports false when the failure must count
+// at once instead: an earlier round was never decided (its re-checks were
+// lost, or no worker of a region took them), or too few re-checks went out
+// to reach the quorum. Lost re-checks thus delay an outage by one check at
+// most.
+func (e *Engine) startConfirmation(ctx context.Context, service *models.ExternalService, job HealthCheckJob, result models.CheckResult) bool {
+	logger := logging.For(ctx, "confirmation").With("service", service.Name)
+	round := regionRound(job)
+	c := service.Confirmation
+
+	results, err := e.Repo.ListConfirmationResults(ctx, service.ID)
+	if err != nil {
+		logger.Error("results_fetch_failed", "err", err)
+		return false
+	}
+	var undecided bool
+	for _, r := range results {
+		if r.RoundAt.Before(round) && !roundApplied(service, r) {
+			undecided = true
+		}
+	}
+	if err := e.Repo.PruneConfirmationResults(ctx, service.ID, round); err != nil {
+		logger.Error("results_prune_failed", "err", err)
+	}
+	if undecided {
+		logger.Warn("confirmation_skipped", "reason", "previous_round_undecided")
+		return false
+	}
+
+	if err := e.Repo.SaveConfirmationResult(ctx, e.confirmationVote(service, round, 0, result)); err != nil {
+		logger.Error("result_save_failed", "err", err)
+		return false
+	}
+
+	var published int
+	for i := 1; i <= int(c.Checks); i++ {
+		recheck := job
+		recheck.JobID = fmt.Sprintf("%s.recheck.%d", job.JobID, i)
+		recheck.Recheck = i
+		recheck.Region = ""
+		if len(c.Regions) > 0 {
+			recheck.Region = c.Regions[i-1]
+		}
+		recheck.DueAt = round
+		recheck.ScheduledAt = clock.Now()
+		if err := e.confirms.Schedule(recheck); err != nil {
+			continue
+		}
+		published++
+	}
+
+	quorum := c.QuorumSize()
+	if published < quorum {
+		// Claiming the round keeps the re-checks that did go out from deciding it again
+		if _, err := e.Repo.ClaimRegionRound(ctx, service.ID, round); err != nil {
+			logger.Error("round_claim_failed", "err", err)
+		}
+		logger.Warn("confirmation_skipped", "reason", "rechecks_unpublished", "published", published, "quorum", quorum)
+		return false
+	}
+
+	logger.Info("confirmation_started", "rechecks", published, "quorum", quorum, "reason", result.Reason, "error", result.ErrorMessage)
+	return true
+}
+
+// applyConfirmation records the vote of a re-check and reports the result
+// of the failed check once its round is decided: quorum re-checks failed
+// too, which counts the failure, or so many passed that the quorum can no
+// longer be reached, which counts the check as passed. As with regions,
+// only the worker that claims the round gets ok.
+func (e *Engine) applyConfirmation(ctx context.Context, service *models.ExternalService, job HealthCheckJob, result models.CheckResult) (models.CheckResult, bool) {
+	logger := logging.For(ctx, "confirmation").With("service", service.Name, "recheck", job.Recheck)
+	round := regionRound(job)
+	c := service.Confirmation
+
+	// A later failure, or one that counted at once, replaced the round
+	results, err := e.Repo.ListConfirmationResults(ctx, service.ID)
+	if err != nil {
+		logger.Error("results_fetch_failed", "err", err)
+		return result, false
+	}
+	if !slices.ContainsFunc(results, func(r models.ConfirmationResult) bool { return r.RoundAt.Equal(round) && r.Recheck == 0 }) {
+		logger.Info("recheck_dropped", "reason", "superseded")
+		return result, false
+	}
+
+	if err := e.Repo.SaveConfirmationResult(ctx, e.confirmationVote(service, round, job.Recheck, result)); err != nil {
+		logger.Error("result_save_failed", "err", err)
+		return result, false
+	}
+	if !result.Success {
+		logger.Warn("recheck_failed", "reason", result.Reason, "error", result.ErrorMessage)
+	}
+
+	results, err = e.Repo.ListConfirmationResults(ctx, service.ID)
+	if err != nil {
+		logger.Error("results_fetch_failed", "err", err)
+		return result, false
+	}
+
+	var failure *models.ConfirmationResult
+	var passed, failed []models.ConfirmationResult
+	for i, r := range results {
+		switch {
+		case !r.RoundAt.Equal(round) || r.Recheck > int(c.Checks):
+		case r.Recheck == 0:
+			failure = &results[i]
+		case r.Success:
+			passed = append(passed, r)
+		default:
+			failed = append(failed, r)
+		}
+	}
+	if failure == nil {
+		logger.Info("recheck_dropped", "reason", "superseded")
+		return result, false
+	}
+
+	quorum := c.QuorumSize()
+	confirmed := len(failed) >= quorum
+	if !confirmed && len(passed) <= int(c.Checks)-quorum {
+		return result, false
+	}
+
+	claimed, err := e.Repo.ClaimRegionRound(ctx, service.ID, round)
+	if err != nil {
+		logger.Error("round_claim_failed", "err", err)
+		return result, false
+	}
+	if !claimed {
+		return result, false
+	}
+
+	var decided models.CheckResult
+	switch {
+	case confirmed:
+		decided = models.CheckResult{
+			Status:       "DOWN",
+			StatusCode:   failure.StatusCode,
+			LatencyMs:    failure.LatencyMs,
+			Reason:       failure.Reason,
+			ErrorMessage: fmt.Sprintf("%s (confirmed by %d of %d re-checks)", failure.Error, len(failed), c.Checks),
+		}
+	case result.Success:
+		decided = result
+	default:
+		last := passed[len(passed)-1]
+		decided = models.CheckResult{Status: last.Status, Success: true, StatusCode: last.StatusCode, LatencyMs: last.LatencyMs}
+	}
+	decided.Attempts = result.Attempts
+	decided.Response = result.Response
+	if !decided.Success {
+		decided.AssertionFailure = result.AssertionFailure
+	}
+
+	logger.Info("confirmation_decided", "confirmed", confirmed, "failed", len(failed), "passed", len(passed), "quorum", quorum)
+	return decided, true
+}
+
+func (e *Engine) confirmationVote(service *models.ExternalService, round time.Time, recheck int, result models.CheckResult) *models.ConfirmationResult {
+	return &models.ConfirmationResult{
+		ExternalServiceID: service.ID,
+		RoundAt:           round,
+		Recheck:           recheck,
+		Region:            e.Cnfg.Worker.Region,
+		Status:            result.Status,
+		Success:           result.Success,
+		StatusCode:        result.StatusCode,
+		LatencyMs:         result.LatencyMs,
+		Reason:            result.Reason,
+		Error:             result.ErrorMessage,
+		CheckedAt:         clock.Now(),
+	}
+}
+
+// roundApplied reports whether the round of a vote was decided, or a later one
+func roundApplied(service *models.ExternalService, r models.ConfirmationResult) bool {
+	return service.LastRoundAt != nil && !service.LastRoundAt.Before(r.RoundAt)
+}
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/Repository"
+	"Distributed-Health-Monitoring/config"
+	"Distributed-Health-Monitoring/models"
+	"context"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// confirmationRepo keeps the votes of one service in memory. The methods
+// applyConfirmation doesn't use panic through the nil IRepository.
+type confirmationRepo struct {
+	Repository.IRepository
+	votes   []models.ConfirmationResult
+	claim   bool
+	claimed []time.Time
+}
+
+func (r *confirmationRepo) ListConfirmationResults(ctx context.Context, serviceID uint) ([]models.ConfirmationResult, error) {
+	out := append([]models.ConfirmationResult(nil), r.votes...)
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].RoundAt.Equal(out[j].RoundAt) {
+			return out[i].RoundAt.Before(out[j].RoundAt)
+		}
+		return out[i].Recheck < out[j].Recheck
+	})
+	return out, nil
+}
+
+func (r *confirmationRepo) SaveConfirmationResult(ctx context.Context, result *models.ConfirmationResult) error {
+	for i, v := range r.votes {
+		if v.RoundAt.Equal(result.RoundAt) && v.Recheck == result.Recheck {
+			r.votes[i] = *result
+			return nil
+		}
+	}
+	r.votes = append(r.votes, *result)
+	return nil
+}
+
+func (r *confirmationRepo) ClaimRegionRound(ctx context.Context, serviceID uint, roundAt time.Time) (bool, error) {
+	r.claimed = append(r.claimed, roundAt)
+	return r.claim, nil
+}
+
+func TestApplyConfirmation(t *testing.T) {
+	round := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	failure := models.ConfirmationResult{RoundAt: round, Status: "DOWN", StatusCode: 503, LatencyMs: 40, Reason: "status_code", Error: "unexpected status 503"}
+	vote := func(recheck int, success bool) models.ConfirmationResult {
+		v := models.ConfirmationResult{RoundAt: round, Recheck: recheck, Status: "DOWN", Success: success, StatusCode: 503}
+		if success {
+			v.Status, v.StatusCode = "UP", 200
+		}
+		return v
+	}
+	passed := models.CheckResult{Status: "UP", Success: true, StatusCode: 200, LatencyMs: 12}
+	failed := models.CheckResult{Status: "DOWN", StatusCode: 503, Reason: "status_code", ErrorMessage: "unexpected status 503"}
+
+	tests := []struct {
+		name         string
+		confirmation models.Confirmation
+		votes        []models.ConfirmationResult
+		recheck      int
+		result       models.CheckResult
+		lostClaim    bool
+		decided      bool
+		want         models.CheckResult
+	}{
+		{
+			name:         "superseded round",
+			confirmation: models.Confirmation{Checks: 3},
+			recheck:      1,
+			result:       failed,
+		},
+		{
+			name:         "undecided",
+			confirmation: models.Confirmation{Checks: 3},
+			votes:        []models.ConfirmationResult{failure, vote(1, true)},
+			recheck:      2,
+			result:       failed,
+		},
+		{
+			name:         "confirmed by a quorum",
+			confirmation: models.Confirmation{Checks: 3},
+			votes:        []models.ConfirmationResult{failure, vote(1, false)},
+			recheck:      2,
+			result:       failed,
+			decided:      true,
+			want:         models.CheckResult{Status: "DOWN", StatusCode: 503, LatencyMs: 40, Reason: "status_code", ErrorMessage: "unexpected status 503 (confirmed by 2 of 3 re-checks)"},
+		},
+		{
+			name:         "quorum out of reach",
+			confirmation: models.Confirmation{Checks: 3},
+			votes:        []models.ConfirmationResult{failure, vote(1, true)},
+			recheck:      2,
+			result:       passed,
+			decided:      true,
+			want:         passed,
+		},
+		{
+			name:         "quorum out of reach on a failed re-check",
+			confirmation: models.Confirmation{Checks: 3, Quorum: 3},
+			votes:        []models.ConfirmationResult{failure, vote(1, true)},
+			recheck:      2,
+			result:       failed,
+			decided:      true,
+			want:         models.CheckResult{Status: "UP", Success: true, StatusCode: 200},
+		},
+		{
+			name:         "explicit quorum of one",
+			confirmation: models.Confirmation{Checks: 3, Quorum: 1},
+			votes:        []models.ConfirmationResult{failure},
+			recheck:      1,
+			result:       failed,
+			decided:      true,
+			want:         models.CheckResult{Status: "DOWN", StatusCode: 503, LatencyMs: 40, Reason: "status_code", ErrorMessage: "unexpected status 503 (confirmed by 1 of 3 re-checks)"},
+		},
+		{
+			name:         "votes of removed re-checks are ignored",
+			confirmation: models.Confirmation{Checks: 2},
+			votes:        []models.ConfirmationResult{failure, vote(3, false)},
+			recheck:      1,
+			result:       failed,
+		},
+		{
+			name:         "claimed by another worker",
+			confirmation: models.Confirmation{Checks: 3},
+			votes:        []models.ConfirmationResult{failure, vote(1, false)},
+			recheck:      2,
+			result:       failed,
+			lostClaim:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &confirmationRepo{votes: append([]models.ConfirmationResult(nil), tt.votes...), claim: !tt.lostClaim}
+			e := &Engine{Repo: repo, Cnfg: &config.Config{}}
+			service := &models.ExternalService{Name: "checkout", Confirmation: &tt.confirmation}
+			job := HealthCheckJob{ServiceName: "checkout", Recheck: tt.recheck, DueAt: round}
+
+			got, ok := e.applyConfirmation(context.Background(), service, job, tt.result)
+			if ok != tt.decided {
+				t.Fatalf("applyConfirmation() decided = %v, want %v", ok, tt.decided)
+			}
+			if !ok {
+				if got.Status != tt.result.Status || got.ErrorMessage != tt.result.ErrorMessage {
+					t.Errorf("applyConfirmation() = %+v, want the re-check's result back", got)
+				}
+				return
+			}
+			if got.Status != tt.want.Status || got.Success != tt.want.Success || got.StatusCode != tt.want.StatusCode ||
+				got.LatencyMs != tt.want.LatencyMs || got.Reason != tt.want.Reason || got.ErrorMessage != tt.want.ErrorMessage {
+				t.Errorf("applyConfirmation() = %+v, want %+v", got, tt.want)
+			}
+			if len(repo.claimed) != 1 || !repo.claimed[0].Equal(round) {
+				t.Errorf("claimed rounds = %v, want [%s]", repo.claimed, round)
+			}
+		})
+	}
+
+	t.Run("records the vote", func(t *testing.T) {
+		repo := &confirmationRepo{votes: []models.ConfirmationResult{failure}}
+		e := &Engine{Repo: repo, Cnfg: &config.Config{Worker: config.Worker{Region: "eu-west"}}}
+		service := &models.ExternalService{Name: "checkout", Confirmation: &models.Confirmation{Checks: 3}}
+
+		e.applyConfirmation(context.Background(), service, HealthCheckJob{Recheck: 1, DueAt: round.Add(300 * time.Millisecond)}, failed)
+		if len(repo.votes) != 2 {
+			t.Fatalf("votes = %d, want 2", len(repo.votes))
+		}
+		v := repo.votes[1]
+		if v.Recheck != 1 || !v.RoundAt.Equal(round) || v.Region != "eu-west" || v.Success || !strings.Contains(v.Error, "503") {
+			t.Errorf("vote = %+v", v)
+		}
+	})
+}
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/Repository"
+	"Distributed-Health-Monitoring/consul"
+	"Distributed-Health-Monitoring/logging"
+	"Distributed-Health-Monitoring/models"
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// consulSourceTag marks services owned by the sync; only those are updated or pruned
+	consulSourceTag = "source:consul"
+
+	defaultConsulSyncInterval = 60 * time.Second
+)
+
+// ConsulSync periodically mirrors the Consul catalog into the monitor.
+// With HA only the scheduler leader syncs.
+func (e *Engine) ConsulSync(ctx context.Context) error {
+	cfg := e.Cnfg.Consul
+	if !cfg.Enabled {
+		return nil
+	}
+
+	interval := time.Duration(cfg.SyncIntervalSeconds) * time.Second
+	if interval <= 0 {
+		interval = defaultConsulSyncInterval
+	}
+	client := consul.NewClient(cfg.Address, cfg.Token, cfg.Datacenter)
+
+	logging.For(ctx, "consul").Info("sync_started", "address", cfg.Address, "tag", cfg.Tag, "interval", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if e.Leader == nil || e.Leader.Leader() {
+			e.syncConsul(ctx, client)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (e *Engine) syncConsul(ctx context.Context, client *consul.Client) {
+	ctx = withSystemActor(ctx, "consul")
+	logger := logging.For(ctx, "consul")
+
+	desired, err := e.consulServices(ctx, client)
+	if err != nil {
+		// Never prune on a failed read, or a Consul outage would empty the monitor
+		logger.Error("catalog_fetch_failed", "err", err)
+		return
+	}
+
+	services, err := e.Repo.GetAllServices(ctx)
+	if err != nil && !errors.Is(err, Repository.ErrNoServices) {
+		logger.Error("fetch_services_failed", "err", err)
+		return
+	}
+
+	existing := make(map[string]*models.ExternalService, len(services))
+	for _, s := range services {
+		existing[s.Name] = s
+	}
+
+	var created, updated, restored, pruned int
+	for name, want := range desired {
+		have, ok := existing[name]
+		if !ok {
+			s, err := e.restoreSyncedService(ctx, name, consulSourceTag)
+			if err != nil {
+				logger.Error("restore_failed", "service", name, "err", err)
+				continue
+			}
+			if s != nil {
+				have, ok = s, true
+				restored++
+			}
+		}
+		switch {
+		case !ok:
+			if err := e.Repo.RegisterService(ctx, want); err != nil {
+				logger.Error("create_failed", "service", name, "err", err)
+				continue
+			}
+			e.audit(ctx, auditCreate, auditService, want.ID, want.Name, "added to consul catalog", nil, auditedService(want))
+			clusterBus.serviceChanged(want.ID)
+			created++
+
+		case !have.HasTag(consulSourceTag):
+			logger.Warn("name_conflict", "service", name, "skipped", true)
+
+		case consulServiceChanged(have, want):
+			update := *have
+			update.URL = want.URL
+			update.Protocol = want.Protocol
+			update.HTTPMethod = want.HTTPMethod
+			update.Interval = want.Interval
+			update.TimeoutSeconds = want.TimeoutSeconds
+			update.Tags = want.Tags
+			if err := e.Repo.RegisterService(ctx, &update); err != nil {
+				logger.Error("update_failed", "service", name, "err", err)
+				continue
+			}
+			e.audit(ctx, auditUpdate, auditService, update.ID, update.Name, "changed in consul catalog", auditedService(have), auditedService(&update))
+			clusterBus.serviceChanged(update.ID)
+			updated++
+		}
+	}
+
+	for name, have := range existing {
+		if _, ok := desired[name]; ok || !have.HasTag(consulSourceTag) {
+			continue
+		}
+		if _, err := e.archiveAndDelete(ctx, have, "removed from consul catalog", false); err != nil {
+			logger.Error("prune_failed", "service", name, "err", err)
+			continue
+		}
+		pruned++
+	}
+
+	logger.Info("sync_completed", "desired", len(desired), "created", created, "updated", updated, "restored", restored, "pruned", pruned)
+}
+
+// consulServices builds one monitor service per HTTP/TCP check of every
+// matching instance; an instance without such checks gets a TCP check on its port
+func (e *Engine) consulServices(ctx context.Context, client *consul.Client) (map[string]*models.ExternalService, error) {
+	cfg := e.Cnfg.Consul
+
+	names, err := client.Services(ctx, cfg.Tag)
+	if err != nil {
+		return nil, err
+	}
+
+	desired := make(map[string]*models.ExternalService)
+	for _, name := range names {
+		entries, err := client.Instances(ctx, name, cfg.Tag)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			tags := []string{consulSourceTag, "consul:" + name}
+			base := models.ExternalService{
+				Interval:         cfg.CheckInterval,
+				TimeoutSeconds:   cfg.TimeoutSeconds,
+				FailureThreshold: cfg.FailureThreshold,
+				Tags:             tags,
+			}
+			if base.Interval <= 0 {
+				base.Interval = 30
+			}
+			if base.TimeoutSeconds <= 0 {
+				base.TimeoutSeconds = 5
+			}
+			if base.FailureThreshold <= 0 {
+				base.FailureThreshold = 3
+			}
+
+			found := false
+			for _, check := range entry.Checks {
+				if check.ServiceID != entry.Service.ID {
+					continue
+				}
+
+				s := base
+				switch {
+				case check.Definition.HTTP != "":
+					s.Protocol = "HTTP"
+					s.URL = check.Definition.HTTP
+					s.HTTPMethod = strings.ToUpper(check.Definition.Method)
+					if s.HTTPMethod == "" {
+						s.HTTPMethod = "GET"
+					}
+				case check.Definition.TCP != "":
+					s.Protocol = "TCP"
+					s.URL = check.Definition.TCP
+				default:
+					continue
+				}
+				if d, err := time.ParseDuration(check.Definition.Interval); err == nil && d >= time.Second {
+					s.Interval = int64(d.Seconds())
+				}
+				if d, err := time.ParseDuration(check.Definition.Timeout); err == nil && d >= time.Second {
+					s.TimeoutSeconds = int64(d.Seconds())
+				}
+
+				s.Name = "consul/" + entry.Service.ID + "/" + check.CheckID
+				desired[s.Name] = &s
+				found = true
+			}
+
+			if !found && entry.Service.Port > 0 && entry.Address() != "" {
+				s := base
+				s.Protocol = "TCP"
+				s.URL = net.JoinHostPort(entry.Address(), strconv.Itoa(entry.Service.Port))
+				s.Name = "consul/" + entry.Service.ID + "/tcp"
+				desired[s.Name] = &s
+			}
+		}
+	}
+
+	return desired, nil
+}
+
+func consulServiceChanged(have, want *models.ExternalService) bool {
+	return have.URL != want.URL ||
+		have.Protocol != want.Protocol ||
+		(want.Protocol == "HTTP" && have.HTTPMethod != want.HTTPMethod) ||
+		have.Interval != want.Interval ||
+		have.TimeoutSeconds != want.TimeoutSeconds ||
+		!reflect.DeepEqual(have.Tags, want.Tags)
+}
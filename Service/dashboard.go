@@ -0,0 +1,64 @@
+package service
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"io/fs"
+	"mime"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// dashboardAssets is the single-page dashboard, built into the binary so
+// it always matches the API it talks to
+//
+//go:embed dashboard
+var dashboardAssets embed.FS
+
+// GetDashboard serves the embedded dashboard at /dashboard. The page holds
+// no data and needs no credentials; its script calls the API and /ws as the
+// signed-in user, so it sees exactly what that user's role allows.
+func (e *Engine) GetDashboard(c *gin.Context) {
+	name := strings.TrimPrefix(c.Param("filepath"), "/")
+	if name == "" {
+		name = "index.html"
+	}
+	if !fs.ValidPath(name) {
+		c.String(404, "not found")
+		return
+	}
+	body, err := dashboardAssets.ReadFile(path.Join("dashboard", name))
+	if err != nil {
+		c.String(404, "not found")
+		return
+	}
+
+	// Revalidated on every load, so an upgrade shows up without a hard refresh
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+	c.Header("Cache-Control", "no-cache")
+	c.Header("ETag", etag)
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(304)
+		return
+	}
+
+	c.Header("Content-Security-Policy", dashboardCSP(c.Request.Host))
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Referrer-Policy", "same-origin")
+	c.Data(200, mime.TypeByExtension(path.Ext(name)), body)
+}
+
+// dashboardCSP limits the page to its own assets and this server. The
+// WebSocket origins are listed since not every browser counts them as 'self'.
+func dashboardCSP(host string) string {
+	connect := "'self'"
+	if host != "" && !strings.ContainsAny(host, " ;,'\"") {
+		connect += " ws://" + host + " wss://" + host
+	}
+	return "default-src 'none'; script-src 'self'; style-src 'self'; img-src 'self' data:; connect-src " + connect +
+		"; base-uri 'none'; form-action 'self'; frame-ancestors 'none'"
+}
@@ -0,0 +1,452 @@
+// Embedded dashboard: service tiles with latency sparklines and incident
+// banners, kept live by the /ws event feed. Plain browser JavaScript, no
+// build step; everything it shows comes from the public API.
+'use strict';
+
+(function () {
+  const SPARK_POINTS = 30;          // newest check logs drawn per tile
+  const REFRESH_MS = 60 * 1000;     // sparklines and incident stats
+  const LOG_BATCH = 100;            // services per recent logs request
+  const STATS_WINDOW = '7d';
+  const MAX_RETRY_MS = 30 * 1000;
+  const AUTH_KEY = 'dhm.dashboard.auth';
+
+  const state = {
+    services: new Map(), // id -> apiv1 service
+    logs: new Map(),     // id -> check logs, newest first
+    stats: new Map(),    // id -> incident stats over STATS_WINDOW
+    summary: null,
+    incidents: [],
+    lag: null,           // last scheduler_lagging event, until caught up
+    error: '',           // why the last refresh failed, until one succeeds
+    filter: '',
+    lastSeq: 0,
+    socket: null,
+    reconnect: null,     // pending reconnect timer
+    timer: null,         // periodic refresh
+    retry: 0,
+    changed: new Set(),  // tiles to flash on the next render
+  };
+
+  const $ = (id) => document.getElementById(id);
+
+  class AuthError extends Error {
+    constructor() { super('unauthorized'); }
+  }
+
+  async function api(path, options = {}) {
+    const headers = Object.assign({ Accept: 'application/json' }, options.headers);
+    const auth = sessionStorage.getItem(AUTH_KEY);
+    if (auth) headers.Authorization = auth;
+
+    const resp = await fetch(path, Object.assign({}, options, { headers, credentials: 'same-origin' }));
+    if (resp.status === 401) throw new AuthError();
+    const body = await resp.json().catch(() => ({}));
+    if (!resp.ok) throw new Error(body.error || resp.status + ' ' + resp.statusText);
+    return body;
+  }
+
+  // --- sign in ---
+
+  async function start() {
+    let me;
+    try {
+      me = await api('/auth/me');
+    } catch (err) {
+      showLogin(err instanceof AuthError ? '' : err.message);
+      return;
+    }
+
+    $('login').hidden = true;
+    $('app').hidden = false;
+    $('signout').hidden = false;
+    $('user').textContent = (me.email || me.name || me.subject) + ' (' + me.role + ')';
+
+    try {
+      await reload();
+    } catch (err) {
+      if (err instanceof AuthError) { showLogin(''); return; }
+      showBannerError(err.message);
+    }
+    if (!state.socket && !state.reconnect) connect();
+    if (!state.timer) state.timer = setInterval(refresh, REFRESH_MS);
+  }
+
+  function showLogin(message) {
+    $('app').hidden = true;
+    $('signout').hidden = true;
+    $('login').hidden = false;
+    $('login-error').textContent = message;
+  }
+
+  function onLogin(event) {
+    event.preventDefault();
+    const form = event.target;
+    const user = form.elements.user.value.trim();
+    const secret = form.elements.secret.value;
+    if (!secret) {
+      $('login-error').textContent = 'Enter a password or token';
+      return;
+    }
+    const auth = user ? 'Basic ' + btoa(unescape(encodeURIComponent(user + ':' + secret))) : 'Bearer ' + secret;
+    sessionStorage.setItem(AUTH_KEY, auth);
+    form.reset();
+    start();
+  }
+
+  async function signOut() {
+    sessionStorage.removeItem(AUTH_KEY);
+    try {
+      await api('/auth/logout', { method: 'POST' });
+    } catch (err) {
+      // Basic and bearer callers have no session to end
+    }
+    location.reload();
+  }
+
+  // --- data ---
+
+  // reload fetches the full state; used on start and after a replay gap
+  async function reload() {
+    const services = new Map();
+    let offset = 0;
+    for (;;) {
+      const page = await api('/api/v1/services?limit=1000&offset=' + offset);
+      page.services.forEach((s) => services.set(s.id, s));
+      if (page.next_offset == null) break;
+      offset = page.next_offset;
+    }
+    state.services = services;
+    for (const id of state.logs.keys()) {
+      if (!services.has(id)) state.logs.delete(id);
+    }
+
+    await Promise.all([loadIncidents(), loadStats()]);
+    render();
+    await loadAllLogs();
+  }
+
+  async function refresh() {
+    try {
+      await Promise.all([loadIncidents(), loadStats(), loadAllLogs()]);
+      state.error = '';
+    } catch (err) {
+      if (err instanceof AuthError) { showLogin('Your sign-in expired'); return; }
+      state.error = err.message;
+    }
+    render();
+  }
+
+  async function loadIncidents() {
+    const body = await api('/api/v1/incidents');
+    state.incidents = body.incidents || [];
+  }
+
+  async function loadStats() {
+    const body = await api('/stats/incidents?window=' + STATS_WINDOW);
+    state.summary = body;
+    state.stats = new Map((body.services || []).map((s) => [s.id, s]));
+  }
+
+  async function loadLogs(ids) {
+    const body = await api('/api/v1/logs/recent?limit=' + SPARK_POINTS + '&service_id=' + ids.join(','));
+    for (const s of body.services || []) {
+      state.logs.set(s.service_id, s.logs || []);
+      renderTile(s.service_id);
+    }
+  }
+
+  // loadAllLogs fetches the sparklines LOG_BATCH services at a time, one
+  // request after the other, so a large fleet stays under the rate limit
+  async function loadAllLogs() {
+    const ids = Array.from(state.services.keys());
+    for (let i = 0; i < ids.length; i += LOG_BATCH) {
+      await loadLogs(ids.slice(i, i + LOG_BATCH));
+    }
+  }
+
+  async function loadService(id) {
+    try {
+      const body = await api('/api/v1/services/' + id);
+      state.services.set(id, body.service);
+      state.changed.add(id);
+      render();
+      await loadLogs([id]);
+    } catch (err) {
+      // Gone again, or not visible to this caller
+    }
+  }
+
+  // --- live events ---
+
+  async function connect() {
+    state.reconnect = null;
+    let url = (location.protocol === 'https:' ? 'wss://' : 'ws://') + location.host + '/ws';
+    const params = [];
+    try {
+      const token = await api('/auth/ws-token', { method: 'POST' });
+      params.push('token=' + encodeURIComponent(token.token));
+    } catch (err) {
+      if (err instanceof AuthError) { showLogin('Your sign-in expired'); return; }
+      scheduleReconnect();
+      return;
+    }
+    if (state.lastSeq > 0) params.push('last_seq=' + state.lastSeq);
+    url += '?' + params.join('&');
+
+    const socket = new WebSocket(url);
+    state.socket = socket;
+    socket.onopen = () => {
+      state.retry = 0;
+      setLive(true);
+    };
+    socket.onmessage = (msg) => {
+      let event;
+      try {
+        event = JSON.parse(msg.data);
+      } catch (err) {
+        return;
+      }
+      if (event.seq) state.lastSeq = event.seq;
+      handleEvent(event);
+    };
+    socket.onclose = () => {
+      state.socket = null;
+      setLive(false);
+      scheduleReconnect();
+    };
+  }
+
+  function scheduleReconnect() {
+    const delay = Math.min(1000 * Math.pow(2, state.retry), MAX_RETRY_MS);
+    state.retry++;
+    state.reconnect = setTimeout(connect, delay);
+  }
+
+  function setLive(on) {
+    const el = $('live');
+    el.textContent = on ? 'live' : 'reconnecting';
+    el.className = on ? 'live on' : 'live';
+  }
+
+  function handleEvent(event) {
+    const service = state.services.get(event.service_id);
+    switch (event.type) {
+      case 'replay_gap':
+        reload().catch(() => {});
+        return;
+
+      case 'service_state_change':
+        if (!service) return;
+        service.status = event.to;
+        service.acknowledgement = event.acknowledgement || null;
+        service.last_checked_at = event.timestamp;
+        state.changed.add(service.id);
+        loadIncidents().then(render, () => {});
+        loadLogs([service.id]).catch(() => {});
+        break;
+
+      case 'service_flapping_start':
+      case 'service_flapping_end':
+        if (!service) return;
+        service.status = event.type === 'service_flapping_start' ? 'FLAPPING' : event.status;
+        state.changed.add(service.id);
+        break;
+
+      case 'service_acknowledged':
+      case 'service_acknowledgement_cleared':
+        if (!service) return;
+        service.acknowledgement = event.acknowledgement || null;
+        loadIncidents().then(render, () => {});
+        break;
+
+      case 'service_registered':
+      case 'service_restored':
+        loadService(event.service_id);
+        return;
+
+      case 'service_deleted':
+        state.services.delete(event.service_id);
+        state.logs.delete(event.service_id);
+        state.incidents = state.incidents.filter((i) => i.service_id !== event.service_id);
+        break;
+
+      case 'scheduler_lagging':
+        state.lag = event;
+        break;
+
+      case 'scheduler_caught_up':
+        state.lag = null;
+        break;
+
+      default:
+        return;
+    }
+    render();
+  }
+
+  // --- rendering ---
+
+  function el(tag, className, text) {
+    const node = document.createElement(tag);
+    if (className) node.className = className;
+    if (text != null) node.textContent = text;
+    return node;
+  }
+
+  function matches(service) {
+    if (!state.filter) return true;
+    const needle = state.filter.toLowerCase();
+    return service.name.toLowerCase().includes(needle) ||
+      (service.tags || []).some((t) => t.toLowerCase().includes(needle));
+  }
+
+  function render() {
+    renderSummary();
+    renderBanners();
+
+    const tiles = $('tiles');
+    const shown = Array.from(state.services.values()).filter(matches).sort(byUrgency);
+    tiles.replaceChildren(...shown.map(tile));
+    $('empty').hidden = shown.length > 0;
+    state.changed.clear();
+  }
+
+  // Failing services first, then by name
+  function byUrgency(a, b) {
+    const rank = { DOWN: 0, FLAPPING: 1, DEGRADED: 2, PENDING: 3, MAINTENANCE: 4, UP: 5 };
+    const diff = (rank[a.status] ?? 3) - (rank[b.status] ?? 3);
+    return diff !== 0 ? diff : a.name.localeCompare(b.name);
+  }
+
+  function renderSummary() {
+    const counts = {};
+    for (const s of state.services.values()) counts[s.status] = (counts[s.status] || 0) + 1;
+
+    const parts = [state.services.size + ' services'];
+    for (const status of ['UP', 'DEGRADED', 'FLAPPING', 'DOWN', 'MAINTENANCE', 'PENDING']) {
+      if (counts[status]) parts.push(counts[status] + ' ' + status);
+    }
+    if (state.summary) {
+      parts.push(state.summary.incidents + ' incidents (' + STATS_WINDOW + ')');
+      if (state.summary.mttr_seconds != null) parts.push('MTTR ' + duration(state.summary.mttr_seconds));
+    }
+    $('summary').replaceChildren(...parts.map((p) => el('span', '', p)));
+  }
+
+  function renderBanners() {
+    const banners = [];
+    if (state.error) {
+      banners.push(el('div', 'banner warning', 'Could not refresh the dashboard: ' + state.error));
+    }
+    if (state.lag) {
+      const b = el('div', 'banner warning');
+      b.append(el('strong', '', 'Checks are running late'),
+        el('small', '', state.lag.lagging + ' services behind, up to ' + duration(state.lag.max_lag_seconds)));
+      banners.push(b);
+    }
+    for (const incident of state.incidents) {
+      const service = state.services.get(incident.service_id);
+      const name = service ? service.name : 'service ' + incident.service_id;
+      const acked = incident.acknowledged_at || (service && service.acknowledgement);
+      const b = el('div', acked ? 'banner acknowledged' : 'banner');
+      const what = el('div');
+      what.append(el('strong', '', name + ' is down'));
+      if (incident.reason || incident.cause) {
+        what.append(document.createTextNode(' · ' + [incident.reason, incident.cause].filter(Boolean).join(': ')));
+      }
+      const when = 'since ' + new Date(incident.started_at).toLocaleString() +
+        (acked ? ' · acknowledged' + (incident.acknowledged_by ? ' by ' + incident.acknowledged_by : '') : '');
+      b.append(what, el('small', '', when));
+      banners.push(b);
+    }
+    $('banners').replaceChildren(...banners);
+  }
+
+  function renderTile(id) {
+    const old = document.querySelector('.tile[data-id="' + id + '"]');
+    const service = state.services.get(id);
+    if (old && service) old.replaceWith(tile(service));
+  }
+
+  function tile(service) {
+    const t = el('div', 'tile ' + service.status + (state.changed.has(service.id) ? ' changed' : ''));
+    t.dataset.id = service.id;
+
+    const head = el('div', 'head');
+    head.append(el('span', 'name', service.name), el('span', 'status', service.acknowledgement ? service.status + ' · ack' : service.status));
+    head.firstChild.title = service.url;
+    t.append(head);
+
+    if (service.tags && service.tags.length) t.append(el('div', 'tags', service.tags.join(', ')));
+
+    const logs = state.logs.get(service.id) || [];
+    t.append(sparkline(logs));
+
+    const meta = el('div', 'meta');
+    const latest = logs.find((l) => l.status !== 'DOWN');
+    meta.append(el('span', '', latest ? latest.response_time_ms + ' ms' : '–'));
+    const stats = state.stats.get(service.id);
+    meta.append(el('span', '', stats && stats.incidents ? stats.incidents + ' incidents (' + STATS_WINDOW + ')' : ''));
+    meta.append(el('span', '', service.last_checked_at ? ago(service.last_checked_at) : 'not checked yet'));
+    t.append(meta);
+    return t;
+  }
+
+  // sparkline draws the latency of the newest logs, oldest on the left;
+  // failed checks are marked with a red dot on the baseline
+  function sparkline(logs) {
+    const ns = 'http://www.w3.org/2000/svg';
+    const svg = document.createElementNS(ns, 'svg');
+    svg.setAttribute('class', 'spark');
+    svg.setAttribute('viewBox', '0 0 100 36');
+    svg.setAttribute('preserveAspectRatio', 'none');
+
+    const points = logs.slice().reverse();
+    if (points.length < 2) return svg;
+    const peak = Math.max(1, ...points.map((l) => l.response_time_ms));
+    const x = (i) => (i / (points.length - 1)) * 100;
+    const y = (ms) => 34 - (ms / peak) * 32;
+
+    const line = document.createElementNS(ns, 'polyline');
+    line.setAttribute('points', points.map((l, i) => x(i).toFixed(1) + ',' + y(l.status === 'DOWN' ? 0 : l.response_time_ms).toFixed(1)).join(' '));
+    line.setAttribute('vector-effect', 'non-scaling-stroke');
+    svg.append(line);
+
+    points.forEach((l, i) => {
+      if (l.status !== 'DOWN') return;
+      const dot = document.createElementNS(ns, 'circle');
+      dot.setAttribute('cx', x(i).toFixed(1));
+      dot.setAttribute('cy', '34');
+      dot.setAttribute('r', '1.5');
+      svg.append(dot);
+    });
+    return svg;
+  }
+
+  function showBannerError(message) {
+    const b = el('div', 'banner warning', 'Could not load the dashboard: ' + message);
+    $('banners').replaceChildren(b);
+  }
+
+  function duration(seconds) {
+    if (seconds < 60) return Math.round(seconds) + 's';
+    if (seconds < 3600) return Math.round(seconds / 60) + 'm';
+    if (seconds < 86400) return (seconds / 3600).toFixed(1) + 'h';
+    return (seconds / 86400).toFixed(1) + 'd';
+  }
+
+  function ago(time) {
+    return duration(Math.max(0, (Date.now() - new Date(time).getTime()) / 1000)) + ' ago';
+  }
+
+  document.addEventListener('DOMContentLoaded', () => {
+    $('login-form').addEventListener('submit', onLogin);
+    $('signout').addEventListener('click', signOut);
+    $('filter').addEventListener('input', (e) => {
+      state.filter = e.target.value.trim();
+      render();
+    });
+    start();
+  });
+})();
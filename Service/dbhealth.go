@@ -0,0 +1,105 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/Repository"
+	"Distributed-Health-Monitoring/logging"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetDBHealth reports missing indexes, table bloat and sequential scans on
+// the log table. ?create=true also creates the missing indexes.
+func (e *Engine) GetDBHealth(c *gin.Context) {
+	create := c.Query("create") == "true"
+
+	report, err := e.Repo.CheckDBHealth(c.Request.Context(), create)
+	if errors.Is(err, Repository.ErrNeedsPostgres) {
+		c.JSON(501, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, report)
+}
+
+// GetConsistency reports contradicting service and incident state and
+// orphaned logs. ?repair=true also repairs what it finds.
+func (e *Engine) GetConsistency(c *gin.Context) {
+	repair := c.Query("repair") == "true"
+
+	report, err := e.Repo.CheckConsistency(c.Request.Context(), repair)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, report)
+}
+
+// CheckConsistencyOnStartup logs every inconsistency, repairing them when
+// configured; like the index check it never blocks startup
+func (e *Engine) CheckConsistencyOnStartup() {
+	if !e.Cnfg.Consistency.CheckOnStartup {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	logger := logging.For(ctx, "consistency")
+
+	report, err := e.Repo.CheckConsistency(ctx, e.Cnfg.Consistency.RepairOnStartup)
+	if err != nil {
+		logger.Error("check_failed", "err", err)
+		return
+	}
+
+	for _, issue := range report.Issues {
+		logger.Warn(
+			issue.Check,
+			"service_id", issue.ServiceID,
+			"service", issue.Service,
+			"detail", issue.Detail,
+			"repaired", issue.Repaired,
+			"err", issue.Error,
+		)
+	}
+	logger.Info("check_completed", "issues", len(report.Issues), "repaired", report.Repaired)
+}
+
+// CheckDBHealthOnStartup logs a warning for every problem found; it never
+// blocks startup
+func (e *Engine) CheckDBHealthOnStartup() {
+	if !e.Cnfg.DBHealth.CheckOnStartup {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	logger := logging.For(ctx, "db_health")
+
+	report, err := e.Repo.CheckDBHealth(ctx, e.Cnfg.DBHealth.CreateMissing)
+	if errors.Is(err, Repository.ErrNeedsPostgres) {
+		logger.Info("check_skipped", "driver", e.Cnfg.Database.DriverName())
+		return
+	}
+	if err != nil {
+		logger.Error("check_failed", "err", err)
+		return
+	}
+
+	for _, idx := range report.Indexes {
+		if idx.Created {
+			logger.Info("index_created", "table", idx.Table, "index", idx.Name)
+		}
+	}
+	for _, w := range report.Warnings {
+		logger.Warn("warning", "detail", w)
+	}
+	logger.Info("check_completed", "indexes", len(report.Indexes), "warnings", len(report.Warnings))
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/logging"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DeadLetter is a rejected job as seen in the dead-letter queue
+type DeadLetter struct {
+	Job         json.RawMessage `json:"job,omitempty"`
+	RawBody     string          `json:"raw_body,omitempty"` // set when the body isn't valid JSON
+	Reason      string          `json:"reason,omitempty"`   // rejected, expired, maxlen; max_deliveries with Redis
+	Queue       string          `json:"queue,omitempty"`
+	DeathCount  int64           `json:"death_count"`
+	PublishedAt time.Time       `json:"published_at"`
+}
+
+const defaultDeadLetterLimit = 50
+
+// withDeadLetterQueue opens a short-lived queue connection for admin operations
+func (e *Engine) withDeadLetterQueue(fn func(q DeadLetterQueue) error) error {
+	queue, err := e.openQueue("")
+	if err != nil {
+		return err
+	}
+	defer queue.Close()
+
+	dlq, ok := queue.(DeadLetterQueue)
+	if !ok {
+		return fmt.Errorf("queue driver %s keeps no dead letters", e.Cnfg.Queue.DriverName())
+	}
+	return fn(dlq)
+}
+
+// ListDeadLetters peeks at rejected jobs without removing them
+func (e *Engine) ListDeadLetters(c *gin.Context) {
+	limit := queryLimit(c, defaultDeadLetterLimit)
+
+	var (
+		name    string
+		depth   int
+		letters []DeadLetter
+	)
+	err := e.withDeadLetterQueue(func(q DeadLetterQueue) error {
+		var err error
+		name, depth, letters, err = q.DeadLetters(c.Request.Context(), limit)
+		return err
+	})
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, gin.H{"queue": name, "depth": depth, "dead_letters": letters})
+}
+
+// ReplayDeadLetters moves up to limit rejected jobs back onto the job queue
+func (e *Engine) ReplayDeadLetters(c *gin.Context) {
+	limit := queryLimit(c, defaultDeadLetterLimit)
+
+	replayed := 0
+	err := e.withDeadLetterQueue(func(q DeadLetterQueue) error {
+		var err error
+		replayed, err = q.ReplayDeadLetters(c.Request.Context(), limit)
+		return err
+	})
+
+	logging.For(c.Request.Context(), "admin").Info("dead_letters_replayed", "count", replayed, "err", err)
+
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error(), "replayed": replayed})
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "dead letters replayed", "replayed": replayed})
+}
+
+// newDeadLetter keeps a JSON body as the job and anything else as raw text
+func newDeadLetter(body []byte, publishedAt time.Time) DeadLetter {
+	letter := DeadLetter{PublishedAt: publishedAt}
+	if json.Valid(body) {
+		letter.Job = json.RawMessage(body)
+	} else {
+		letter.RawBody = string(body)
+	}
+	return letter
+}
+
+func queryLimit(c *gin.Context, def int) int {
+	if l, err := strconv.Atoi(c.Query("limit")); err == nil && l > 0 {
+		return l
+	}
+	return def
+}
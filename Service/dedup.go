@@ -0,0 +1,148 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/Repository"
+	"Distributed-Health-Monitoring/clock"
+	"Distributed-Health-Monitoring/config"
+	"Distributed-Health-Monitoring/logging"
+	"Distributed-Health-Monitoring/models"
+	"Distributed-Health-Monitoring/redis"
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	jobKeyPrefix   = "dhm:job:"
+	jobPruneEvery  = 10 * time.Minute
+	jobClaimMargin = time.Minute
+)
+
+// jobLedger remembers the jobs this deployment's workers have taken, so a
+// message the broker delivers twice (a redelivery after a lost ack, or a
+// duplicate publish) is checked once and doesn't count a failure twice
+type jobLedger interface {
+	// claim reports false when the job was taken before. The claim is kept
+	// until expires, after which the job is stale and dropped anyway.
+	claim(ctx context.Context, job HealthCheckJob, expires time.Time) (bool, error)
+	// release forgets a claim, so a job that couldn't be processed runs
+	// when it is delivered again
+	release(ctx context.Context, job HealthCheckJob) error
+}
+
+// newJobLedger keeps claims in Redis with queue.driver redis and in the
+// database otherwise. The memory queue never delivers a job twice, so it
+// needs none.
+func newJobLedger(cfg config.Queue, repo Repository.IRepository) jobLedger {
+	switch cfg.DriverName() {
+	case "memory":
+		return nil
+	case "redis":
+		return &redisLedger{client: redis.NewClient(cfg.Redis.Address, cfg.Redis.Password, cfg.Redis.DB)}
+	}
+	return &dbLedger{repo: repo}
+}
+
+// redisLedger claims a job with SET NX, expiring with the claim
+type redisLedger struct {
+	client *redis.Client
+}
+
+func (l *redisLedger) claim(ctx context.Context, job HealthCheckJob, expires time.Time) (bool, error) {
+	ttl := max(expires.Sub(clock.Now()), time.Second)
+	reply, err := l.client.Do(ctx, "SET", jobKeyPrefix+job.JobID, job.ServiceID, "NX", "PX", ttl.Milliseconds())
+	if err != nil {
+		return false, err
+	}
+	return reply != nil, nil
+}
+
+func (l *redisLedger) release(ctx context.Context, job HealthCheckJob) error {
+	_, err := l.client.Do(ctx, "DEL", jobKeyPrefix+job.JobID)
+	return err
+}
+
+// dbLedger claims a job by inserting its row into processed_jobs, and
+// prunes expired rows every ten minutes
+type dbLedger struct {
+	repo Repository.IRepository
+
+	mu       sync.Mutex
+	prunedAt time.Time
+}
+
+func (l *dbLedger) claim(ctx context.Context, job HealthCheckJob, expires time.Time) (bool, error) {
+	now := clock.Now()
+	l.prune(ctx, now)
+
+	return l.repo.ClaimJob(ctx, &models.ProcessedJob{
+		JobID:             job.JobID,
+		ExternalServiceID: job.ServiceID,
+		ProcessedAt:       now,
+		ExpiresAt:         expires,
+	})
+}
+
+func (l *dbLedger) release(ctx context.Context, job HealthCheckJob) error {
+	return l.repo.ReleaseJob(ctx, job.JobID)
+}
+
+func (l *dbLedger) prune(ctx context.Context, now time.Time) {
+	l.mu.Lock()
+	if now.Sub(l.prunedAt) < jobPruneEvery {
+		l.mu.Unlock()
+		return
+	}
+	l.prunedAt = now
+	l.mu.Unlock()
+
+	if n, err := l.repo.PruneProcessedJobs(ctx, now); err != nil {
+		logging.For(ctx, "worker").Error("processed_jobs_prune_failed", "err", err)
+	} else if n > 0 {
+		logging.For(ctx, "worker").Debug("processed_jobs_pruned", "rows", n)
+	}
+}
+
+// jobStaleAfter is how old a job may get before the worker drops it: one
+// interval, or the in-flight lease when that is longer, since until the
+// lease expires the scheduler publishes no newer job for the service
+func jobStaleAfter(s *models.ExternalService) time.Duration {
+	return max(time.Duration(s.Interval)*time.Second, leaseDuration(s))
+}
+
+// admitJob decides whether the worker runs a job: it must not be stale,
+// and must not have been taken before. The reason is set when it is
+// dropped. Jobs published before jobs carried an ID are always admitted.
+func (e *Engine) admitJob(ctx context.Context, service *models.ExternalService, job HealthCheckJob) (bool, string, error) {
+	if job.ScheduledAt.IsZero() || job.JobID == "" {
+		return true, "", nil
+	}
+
+	staleAt := job.ScheduledAt.Add(jobStaleAfter(service))
+	if clock.Now().After(staleAt) {
+		return false, "stale", nil
+	}
+	if e.jobs == nil {
+		return true, "", nil
+	}
+
+	claimed, err := e.jobs.claim(ctx, job, staleAt.Add(jobClaimMargin))
+	if err != nil {
+		return false, "", err
+	}
+	if !claimed {
+		return false, "duplicate", nil
+	}
+	return true, "", nil
+}
+
+// releaseJob gives up the claim of a job that failed to process, so the
+// broker's redelivery of it is checked
+func (e *Engine) releaseJob(ctx context.Context, job HealthCheckJob) {
+	if e.jobs == nil || job.JobID == "" {
+		return
+	}
+	if err := e.jobs.release(ctx, job); err != nil {
+		logging.For(ctx, "worker").Error("job_release_failed", "job_id", job.JobID, "err", err)
+	}
+}
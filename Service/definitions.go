@@ -0,0 +1,63 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/models"
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/goccy/go-yaml"
+)
+
+// ParseServiceDefinitions decodes a YAML or JSON document holding either a
+// list of services or an object with a "services" list. Field names are the
+// same as the JSON API.
+func ParseServiceDefinitions(data []byte) ([]*models.ExternalService, error) {
+	raws, err := splitServiceDefinitions(data)
+	if err != nil {
+		return nil, err
+	}
+
+	services := make([]*models.ExternalService, 0, len(raws))
+	for i, raw := range raws {
+		var s *models.ExternalService
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return nil, fmt.Errorf("failed to parse service definition %d: %w", i, err)
+		}
+		services = append(services, s)
+	}
+
+	return services, nil
+}
+
+// splitServiceDefinitions converts the document to JSON and returns each
+// service definition undecoded, so callers can inspect the fields as written
+func splitServiceDefinitions(data []byte) ([]json.RawMessage, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, fmt.Errorf("service definitions are empty")
+	}
+
+	if data[0] != '[' && data[0] != '{' {
+		converted, err := yaml.YAMLToJSON(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse YAML: %w", err)
+		}
+		data = bytes.TrimSpace(converted)
+	}
+
+	var raws []json.RawMessage
+	if len(data) > 0 && data[0] == '{' {
+		var wrapper struct {
+			Services []json.RawMessage `json:"services"`
+		}
+		if err := json.Unmarshal(data, &wrapper); err != nil {
+			return nil, fmt.Errorf("failed to parse service definitions: %w", err)
+		}
+		raws = wrapper.Services
+	} else if err := json.Unmarshal(data, &raws); err != nil {
+		return nil, fmt.Errorf("failed to parse service definitions: %w", err)
+	}
+
+	return raws, nil
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/Repository"
+	"Distributed-Health-Monitoring/cache"
+	"Distributed-Health-Monitoring/logging"
+	"Distributed-Health-Monitoring/models"
+	"context"
+	"errors"
+	"slices"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DependencyNode is one service of the dependency graph
+type DependencyNode struct {
+	ID        uint   `json:"id"`
+	Name      string `json:"name"`
+	Status    string `json:"status"`               // presented status, as in the service list
+	RootCause string `json:"root_cause,omitempty"` // while DOWN: the DOWN dependency blamed for it
+}
+
+// DependencyEdge points from a service to a service it depends on
+type DependencyEdge struct {
+	From uint `json:"from"`
+	To   uint `json:"to"`
+}
+
+// MissingDependency is a depends_on entry naming no registered service
+type MissingDependency struct {
+	Service   string `json:"service"`
+	DependsOn string `json:"depends_on"`
+}
+
+// DependencyGraph is the response of GET /health-app/dependencies
+type DependencyGraph struct {
+	Nodes   []DependencyNode    `json:"nodes"`
+	Edges   []DependencyEdge    `json:"edges"`
+	Missing []MissingDependency `json:"missing"`
+}
+
+// servicesByName indexes services for resolving depends_on entries
+func servicesByName(services map[uint]*models.ExternalService) map[string]*models.ExternalService {
+	byName := make(map[string]*models.ExternalService, len(services))
+	for _, s := range services {
+		byName[s.Name] = s
+	}
+	return byName
+}
+
+// rootCause returns the DOWN service that s depends on, directly or through
+// other DOWN services, and that has no DOWN dependency of its own. It is ""
+// when none of the dependencies of s is DOWN. Only DOWN links are followed:
+// an UP service in between means its own dependencies aren't what broke s.
+func rootCause(s *models.ExternalService, byName map[string]*models.ExternalService) string {
+	seen := map[string]bool{s.Name: true}
+
+	var walk func(s *models.ExternalService) string
+	walk = func(s *models.ExternalService) string {
+		for _, name := range s.DependsOn {
+			parent := byName[name]
+			if parent == nil || parent.Status != "DOWN" || seen[name] {
+				continue
+			}
+			seen[name] = true
+			if cause := walk(parent); cause != "" {
+				return cause
+			}
+			return name
+		}
+		return ""
+	}
+	return walk(s)
+}
+
+// dependencyRootCause looks up the root cause of a service going DOWN from
+// the stored state of its dependencies, so a dependency that went DOWN on
+// another replica counts too. Only the dependencies of DOWN services are
+// loaded, one level at a time, since rootCause follows no other links.
+func (e *Engine) dependencyRootCause(ctx context.Context, service *models.ExternalService) string {
+	byName := map[string]*models.ExternalService{service.Name: service}
+	pending := service.DependsOn
+	for len(pending) > 0 {
+		parents, err := e.Repo.GetServicesByName(ctx, pending)
+		if err != nil {
+			logging.For(ctx, "dependencies").Error("fetch_services_failed", "service", service.Name, "err", err)
+			return ""
+		}
+		for _, name := range pending {
+			byName[name] = nil // not registered, unless loaded below
+		}
+		pending = nil
+		for _, p := range parents {
+			byName[p.Name] = p
+		}
+		for _, p := range parents {
+			if p.Status != "DOWN" {
+				continue
+			}
+			for _, name := range p.DependsOn {
+				if _, ok := byName[name]; !ok && !slices.Contains(pending, name) {
+					pending = append(pending, name)
+				}
+			}
+		}
+	}
+	return rootCause(service, byName)
+}
+
+// dependencyOutage reports whether the outage a recovery ends had its alert
+// suppressed for a dependency. The recovery hasn't been recorded yet, so the
+// latest transition is the one into DOWN.
+func (e *Engine) dependencyOutage(ctx context.Context, service *models.ExternalService) bool {
+	last, _, err := e.Repo.ListStateTransitions(ctx, models.TransitionFilter{ServiceID: service.ID, Limit: 1})
+	if err != nil {
+		logging.For(ctx, "dependencies").Error("fetch_transitions_failed", "service", service.Name, "err", err)
+		return false
+	}
+	return len(last) == 1 && last[0].To == "DOWN" && last[0].Suppressed == "dependency"
+}
+
+// annotateRootCauses sets RootCause on presented DOWN services, resolving
+// dependencies against the service cache
+func annotateRootCauses(presented map[uint]models.ExternalService) {
+	byName := servicesByName(cache.Services.Snapshot())
+	for id, view := range presented {
+		if view.Status != "DOWN" || len(view.DependsOn) == 0 {
+			continue
+		}
+		view.RootCause = rootCause(&view, byName)
+		presented[id] = view
+	}
+}
+
+// GetDependencies returns every service with the services it depends on, as
+// nodes and edges for drawing the graph
+func (e *Engine) GetDependencies(c *gin.Context) {
+	ctx := c.Request.Context()
+	services, err := e.Repo.GetAllServices(ctx)
+	if err != nil && !errors.Is(err, Repository.ErrNoServices) {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	presented := e.presentServices(ctx, services)
+	byName := servicesByName(services)
+
+	graph := DependencyGraph{
+		Nodes:   make([]DependencyNode, 0, len(presented)),
+		Edges:   []DependencyEdge{},
+		Missing: []MissingDependency{},
+	}
+	for id, s := range presented {
+		graph.Nodes = append(graph.Nodes, DependencyNode{ID: id, Name: s.Name, Status: s.Status, RootCause: s.RootCause})
+		for _, name := range s.DependsOn {
+			parent := byName[name]
+			if parent == nil {
+				graph.Missing = append(graph.Missing, MissingDependency{Service: s.Name, DependsOn: name})
+				continue
+			}
+			graph.Edges = append(graph.Edges, DependencyEdge{From: id, To: parent.ID})
+		}
+	}
+
+	sort.Slice(graph.Nodes, func(i, j int) bool { return graph.Nodes[i].ID < graph.Nodes[j].ID })
+	sort.Slice(graph.Edges, func(i, j int) bool {
+		if graph.Edges[i].From != graph.Edges[j].From {
+			return graph.Edges[i].From < graph.Edges[j].From
+		}
+		return graph.Edges[i].To < graph.Edges[j].To
+	})
+	sort.Slice(graph.Missing, func(i, j int) bool {
+		return graph.Missing[i].Service+"\x00"+graph.Missing[i].DependsOn < graph.Missing[j].Service+"\x00"+graph.Missing[j].DependsOn
+	})
+
+	c.JSON(200, graph)
+}
@@ -0,0 +1,288 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/config"
+	"Distributed-Health-Monitoring/logging"
+	"Distributed-Health-Monitoring/models"
+	"Distributed-Health-Monitoring/notify"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const defaultEscalationInterval = 30 * time.Second
+
+// validateEscalation checks that policies reference configured notifiers and
+// that their steps are in order
+func validateEscalation(cfg config.Escalation, notifier *notify.Dispatcher) error {
+	for _, p := range cfg.Policies {
+		if p.ID == "" {
+			return errors.New("escalation: policy id is empty")
+		}
+		if len(p.Steps) == 0 {
+			return fmt.Errorf("escalation: policy %s has no steps", p.ID)
+		}
+		for i, step := range p.Steps {
+			if i > 0 && step.AfterMinutes < p.Steps[i-1].AfterMinutes {
+				return fmt.Errorf("escalation: policy %s steps must be in after_minutes order", p.ID)
+			}
+			for _, id := range step.Notifiers {
+				if !notifier.Has(id) {
+					return fmt.Errorf("escalation: policy %s references unknown notifier %q", p.ID, id)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// escalationPolicy returns the policy listing the service by name, or else
+// the first policy sharing one of its tags
+func escalationPolicy(policies []config.EscalationPolicy, service *models.ExternalService) *config.EscalationPolicy {
+	for i, p := range policies {
+		for _, name := range p.Services {
+			if name == service.Name {
+				return &policies[i]
+			}
+		}
+	}
+	for i, p := range policies {
+		for _, tag := range p.Tags {
+			if service.HasTag(tag) {
+				return &policies[i]
+			}
+		}
+	}
+	return nil
+}
+
+// Escalator periodically fires the due escalation steps of open,
+// unacknowledged incidents. With HA only the scheduler leader escalates.
+func (e *Engine) Escalator(ctx context.Context) error {
+	if len(e.Cnfg.Escalation.Policies) == 0 {
+		return nil
+	}
+
+	interval := time.Duration(e.Cnfg.Escalation.IntervalSeconds) * time.Second
+	if interval <= 0 {
+		interval = defaultEscalationInterval
+	}
+
+	logging.For(ctx, "escalation").Info("started", "policies", len(e.Cnfg.Escalation.Policies), "interval", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if e.Leader != nil && !e.Leader.Leader() {
+				continue
+			}
+			e.escalate(ctx, time.Now())
+		}
+	}
+}
+
+func (e *Engine) escalate(ctx context.Context, now time.Time) {
+	logger := logging.For(ctx, "escalation")
+
+	incidents, err := e.Repo.ListOpenIncidents(ctx)
+	if err != nil {
+		logger.Error("fetch_incidents_failed", "err", err)
+		return
+	}
+	if len(incidents) == 0 {
+		return
+	}
+
+	services, err := e.Repo.GetAllServices(ctx)
+	if err != nil {
+		logger.Error("fetch_services_failed", "err", err)
+		return
+	}
+
+	// Nobody gets paged for a service that is being worked on
+	inMaintenance, err := e.Repo.ServicesInMaintenance(ctx, now)
+	if err != nil {
+		logger.Error("fetch_maintenance_windows_failed", "err", err)
+		inMaintenance = map[uint]bool{}
+	}
+
+	for _, incident := range incidents {
+		service, ok := services[incident.ExternalServiceID]
+		if !ok || incident.AcknowledgedAt != nil || inMaintenance[service.ID] || service.Acknowledgement.Active(now) {
+			continue
+		}
+
+		policy := escalationPolicy(e.Cnfg.Escalation.Policies, service)
+		if policy == nil {
+			continue
+		}
+
+		for level := incident.EscalationLevel; level < len(policy.Steps); level++ {
+			step := policy.Steps[level]
+			if now.Before(incident.StartedAt.Add(time.Duration(step.AfterMinutes) * time.Minute)) {
+				break
+			}
+
+			claimed, err := e.Repo.ClaimEscalationStep(ctx, incident.ID, level)
+			if err != nil {
+				logger.Error("claim_failed", "incident_id", incident.ID, "err", err)
+				break
+			}
+			if !claimed {
+				// Acknowledged, resolved or fired elsewhere since we read it
+				break
+			}
+
+			e.fireEscalationStep(ctx, incident, service, policy, level, now)
+		}
+	}
+}
+
+func (e *Engine) fireEscalationStep(
+	ctx context.Context,
+	incident models.Incident,
+	service *models.ExternalService,
+	policy *config.EscalationPolicy,
+	level int,
+	now time.Time,
+) {
+	alert := notify.Alert{
+		Type:           "escalation",
+		Severity:       e.Notifier.Severity("escalation"),
+		ServiceID:      service.ID,
+		Service:        service.Name,
+		Tags:           service.Tags,
+		Ownership:      service.Ownership(),
+		Metadata:       service.Metadata,
+		To:             service.Status,
+		Reason:         incident.Reason,
+		Error:          incident.Cause,
+		IncidentID:     incident.ID,
+		EscalationStep: level + 1,
+		Timestamp:      now,
+	}
+
+	results := make(map[string]string, len(policy.Steps[level].Notifiers))
+	for _, id := range policy.Steps[level].Notifiers {
+		sendCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		if err := e.Notifier.SendTo(sendCtx, id, alert); err != nil {
+			results[id] = err.Error()
+		} else {
+			results[id] = "ok"
+		}
+		cancel()
+	}
+
+	if err := e.Repo.SaveIncidentEscalation(ctx, &models.IncidentEscalation{
+		IncidentID: incident.ID,
+		Policy:     policy.ID,
+		Step:       level,
+		Results:    results,
+		FiredAt:    now,
+	}); err != nil {
+		logging.For(ctx, "escalation").Error("record_failed", "incident_id", incident.ID, "err", err)
+	}
+
+	logging.For(ctx, "escalation").Info(
+		"step_fired",
+		"service", service.Name,
+		"incident_id", incident.ID,
+		"policy", policy.ID,
+		"step", level+1,
+		"notifiers", len(results),
+	)
+}
+
+// ListOpenIncidents returns the open incidents across all services
+func (e *Engine) ListOpenIncidents(c *gin.Context) {
+	incidents, err := e.Repo.ListOpenIncidents(c.Request.Context())
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	sort.Slice(incidents, func(i, j int) bool { return incidents[i].StartedAt.After(incidents[j].StartedAt) })
+
+	c.JSON(200, gin.H{"incidents": incidents})
+}
+
+// GetIncident returns an incident with the escalation steps fired so far
+func (e *Engine) GetIncident(c *gin.Context) {
+	incident, ok := e.incidentByID(c)
+	if !ok {
+		return
+	}
+
+	escalations, err := e.Repo.ListIncidentEscalations(c.Request.Context(), incident.ID)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, gin.H{"incident": incident, "escalations": escalations})
+}
+
+type ackRequest struct {
+	By string `json:"by" binding:"required"`
+}
+
+// AcknowledgeIncident stops further escalation of an open incident
+func (e *Engine) AcknowledgeIncident(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "invalid incident id"})
+		return
+	}
+
+	var req ackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	incident, err := e.Repo.AcknowledgeIncident(c.Request.Context(), uint(id), req.By, time.Now())
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(404, gin.H{"error": "incident not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(409, gin.H{"error": err.Error()})
+		return
+	}
+
+	logging.For(c.Request.Context(), "escalation").Info("incident_acknowledged", "incident_id", incident.ID, "by", incident.AcknowledgedBy)
+	e.incidentWebhook(c.Request.Context(), WebhookIncidentAcknowledged, incident, nil)
+
+	c.JSON(200, gin.H{"message": "incident acknowledged", "incident": incident})
+}
+
+func (e *Engine) incidentByID(c *gin.Context) (*models.Incident, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "invalid incident id"})
+		return nil, false
+	}
+
+	incident, err := e.Repo.GetIncident(c.Request.Context(), uint(id))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(404, gin.H{"error": "incident not found"})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	return incident, true
+}
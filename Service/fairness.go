@@ -0,0 +1,232 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/Repository"
+	"Distributed-Health-Monitoring/clock"
+	"Distributed-Health-Monitoring/logging"
+	"Distributed-Health-Monitoring/models"
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultFairnessWindow     = 20
+	defaultFairnessMinSamples = 5
+)
+
+// fairnessTracker counts scheduled and executed checks per service and
+// keeps the most recent waits, to spot services the workers can't keep up
+// with. Counts are per replica: the leader counts scheduled jobs and every
+// replica counts the jobs its worker ran.
+type fairnessTracker struct {
+	window     int
+	minSamples int
+	since      time.Time
+
+	mu       sync.Mutex
+	services map[uint]*serviceFairness
+}
+
+type serviceFairness struct {
+	scheduled uint64
+	executed  uint64
+	samples   []fairnessSample // newest last, at most window
+	starved   bool
+}
+
+type fairnessSample struct {
+	wait  time.Duration // from publishing the job to the worker starting it
+	delay time.Duration // from the service becoming due to the worker starting it
+	late  bool          // delay exceeded the interval
+}
+
+func newFairnessTracker(window, minSamples int) *fairnessTracker {
+	if window <= 0 {
+		window = defaultFairnessWindow
+	}
+	if minSamples <= 0 {
+		minSamples = defaultFairnessMinSamples
+	}
+	if minSamples > window {
+		minSamples = window
+	}
+	return &fairnessTracker{
+		window:     window,
+		minSamples: minSamples,
+		since:      time.Now(),
+		services:   make(map[uint]*serviceFairness),
+	}
+}
+
+func (t *fairnessTracker) get(id uint) *serviceFairness {
+	f, ok := t.services[id]
+	if !ok {
+		f = &serviceFairness{}
+		t.services[id] = f
+	}
+	return f
+}
+
+// forget drops the counters of a deleted service
+func (t *fairnessTracker) forget(id uint) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.services, id)
+}
+
+func (t *fairnessTracker) scheduled(id uint) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.get(id).scheduled++
+}
+
+// executed records a job the worker started; it reports when the service
+// became starved or recovered, so the caller can log the change once
+func (t *fairnessTracker) executed(service *models.ExternalService, job HealthCheckJob, startedAt time.Time) (changed bool, starved bool) {
+	sample := fairnessSample{}
+	if !job.ScheduledAt.IsZero() {
+		sample.wait = startedAt.Sub(job.ScheduledAt)
+	}
+	if !job.DueAt.IsZero() {
+		sample.delay = startedAt.Sub(job.DueAt)
+	}
+	sample.late = service.Interval > 0 && sample.delay > time.Duration(service.Interval)*time.Second
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	f := t.get(service.ID)
+	f.executed++
+	f.samples = append(f.samples, sample)
+	if len(f.samples) > t.window {
+		f.samples = f.samples[len(f.samples)-t.window:]
+	}
+
+	was := f.starved
+	f.starved = t.isStarved(f)
+	return was != f.starved, f.starved
+}
+
+// isStarved: at least minSamples checks seen, and at least half of the
+// window started more than one interval after they were due
+func (t *fairnessTracker) isStarved(f *serviceFairness) bool {
+	if len(f.samples) < t.minSamples {
+		return false
+	}
+	late := 0
+	for _, s := range f.samples {
+		if s.late {
+			late++
+		}
+	}
+	return late*2 >= len(f.samples)
+}
+
+// ServiceFairness is one service's line of the fairness report
+type ServiceFairness struct {
+	ServiceID  uint    `json:"service_id"`
+	Name       string  `json:"name"`
+	Interval   int64   `json:"interval"`
+	Scheduled  uint64  `json:"scheduled"`
+	Executed   uint64  `json:"executed"`
+	Samples    int     `json:"samples"`      // recent checks the averages cover
+	AvgWaitMs  int64   `json:"avg_wait_ms"`  // queue wait: published to started
+	MaxWaitMs  int64   `json:"max_wait_ms"`  // longest recent queue wait
+	AvgDelayMs int64   `json:"avg_delay_ms"` // due to started, including the wait
+	LateChecks int     `json:"late_checks"`  // recent checks that started more than one interval late
+	LateRatio  float64 `json:"late_ratio"`   // late_checks / samples
+	Starved    bool    `json:"starved"`      // consistently started more than one interval late
+}
+
+// FairnessReport is the response of GET /health-app/admin/scheduler/fairness
+type FairnessReport struct {
+	Since      time.Time         `json:"since"` // counts start when this replica started
+	Window     int               `json:"window"`
+	MinSamples int               `json:"min_samples"`
+	Starved    int               `json:"starved"`
+	Services   []ServiceFairness `json:"services"`
+}
+
+// report covers the given services, starved ones first, then by average delay
+func (t *fairnessTracker) report(services map[uint]*models.ExternalService) FairnessReport {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	r := FairnessReport{Since: t.since, Window: t.window, MinSamples: t.minSamples, Services: make([]ServiceFairness, 0, len(services))}
+	for id := range t.services {
+		if services[id] == nil {
+			delete(t.services, id) // deleted since
+		}
+	}
+
+	for id, s := range services {
+		line := ServiceFairness{ServiceID: id, Name: s.Name, Interval: s.Interval}
+		if f, ok := t.services[id]; ok {
+			line.Scheduled, line.Executed = f.scheduled, f.executed
+			line.Samples = len(f.samples)
+			line.Starved = f.starved
+
+			var wait, delay time.Duration
+			for _, sample := range f.samples {
+				wait += sample.wait
+				delay += sample.delay
+				if sample.wait > time.Duration(line.MaxWaitMs)*time.Millisecond {
+					line.MaxWaitMs = sample.wait.Milliseconds()
+				}
+				if sample.late {
+					line.LateChecks++
+				}
+			}
+			if n := len(f.samples); n > 0 {
+				line.AvgWaitMs = (wait / time.Duration(n)).Milliseconds()
+				line.AvgDelayMs = (delay / time.Duration(n)).Milliseconds()
+				line.LateRatio = float64(line.LateChecks) / float64(n)
+			}
+		}
+		if line.Starved {
+			r.Starved++
+		}
+		r.Services = append(r.Services, line)
+	}
+
+	sort.Slice(r.Services, func(i, j int) bool {
+		a, b := r.Services[i], r.Services[j]
+		if a.Starved != b.Starved {
+			return a.Starved
+		}
+		if a.AvgDelayMs != b.AvgDelayMs {
+			return a.AvgDelayMs > b.AvgDelayMs
+		}
+		return a.ServiceID < b.ServiceID
+	})
+	return r
+}
+
+// recordExecution feeds a started job into the tracker and logs starvation changes
+func (e *Engine) recordExecution(ctx context.Context, service *models.ExternalService, job HealthCheckJob) {
+	changed, starved := e.fairness.executed(service, job, clock.Now())
+	if !changed {
+		return
+	}
+	logger := logging.For(ctx, "scheduler")
+	if starved {
+		logger.Warn("service_starved", "service", service.Name, "interval", service.Interval)
+	} else {
+		logger.Info("service_starvation_cleared", "service", service.Name)
+	}
+}
+
+// GetSchedulingFairness reports scheduled vs executed checks, queue wait and
+// delay per service, flagging services whose checks keep starting late
+func (e *Engine) GetSchedulingFairness(c *gin.Context) {
+	services, err := e.Repo.GetAllServices(c.Request.Context())
+	if err != nil && !errors.Is(err, Repository.ErrNoServices) {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, e.fairness.report(services))
+}
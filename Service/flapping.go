@@ -0,0 +1,122 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/logging"
+	"Distributed-Health-Monitoring/models"
+	"Distributed-Health-Monitoring/notify"
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+const StatusFlapping = "FLAPPING"
+
+// FlapDetector tracks state transitions per service in a rolling window
+type FlapDetector struct {
+	mu          sync.Mutex
+	window      time.Duration
+	threshold   int
+	transitions map[uint][]time.Time
+}
+
+func NewFlapDetector(window time.Duration, threshold int) *FlapDetector {
+	return &FlapDetector{
+		window:      window,
+		threshold:   threshold,
+		transitions: make(map[uint][]time.Time),
+	}
+}
+
+// Forget drops the transitions of a deleted service
+func (d *FlapDetector) Forget(serviceID uint) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.transitions, serviceID)
+}
+
+// Observe records the outcome of one check and returns whether the service is
+// flapping afterwards, plus the number of transitions currently in the window.
+// A service starts flapping at threshold transitions and stops once it falls
+// to half of that, so it doesn't toggle in and out of FLAPPING every check.
+func (d *FlapDetector) Observe(serviceID uint, wasFlapping, transitioned bool, now time.Time) (bool, int) {
+	if d.threshold <= 0 {
+		return false, 0
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	cutoff := now.Add(-d.window)
+	kept := d.transitions[serviceID][:0]
+	for _, t := range d.transitions[serviceID] {
+		if t.After(cutoff) {
+			kept = append(kept, t)
+		}
+	}
+	if transitioned {
+		kept = append(kept, now)
+	}
+	d.transitions[serviceID] = kept
+
+	count := len(kept)
+	if wasFlapping {
+		return count > d.threshold/2, count
+	}
+	return count >= d.threshold, count
+}
+
+// trackFlapping updates the flapping state after a check and emits a single
+// event when the service starts or stops flapping. It reports whether the
+// service is flapping so per-flip notifications can be suppressed.
+func (e *Engine) trackFlapping(ctx context.Context, service *models.ExternalService, transitioned bool) bool {
+	wasFlapping := service.Flapping
+	flapping, count := e.Flapping.Observe(service.ID, wasFlapping, transitioned, time.Now())
+
+	if flapping == wasFlapping {
+		return flapping
+	}
+
+	logger := logging.For(ctx, "flapping").With("service", service.Name)
+	if err := e.Repo.SetServiceFlapping(ctx, service.ID, flapping); err != nil {
+		logger.Error("update_failed", "err", err)
+	}
+	service.Flapping = flapping
+
+	eventType := "service_flapping_end"
+	if flapping {
+		eventType = "service_flapping_start"
+	}
+
+	logger.Info(eventType, "transitions", count, "window", e.Flapping.window, "status", service.Status)
+
+	alertType := strings.TrimPrefix(eventType, "service_")
+	event := models.ServiceFlappingEvent{
+		Type:          eventType,
+		ServiceID:     service.ID,
+		Name:          service.Name,
+		Status:        service.Status,
+		Transitions:   count,
+		WindowSeconds: int64(e.Flapping.window.Seconds()),
+		Severity:      e.Notifier.Severity(alertType),
+		Timestamp:     time.Now(),
+	}
+	BroadcastEvent(service.Name, event)
+	if service.Acknowledgement.Active(event.Timestamp) {
+		return flapping
+	}
+
+	e.Notifier.Dispatch(notify.Alert{
+		Type:      alertType,
+		Severity:  event.Severity,
+		ServiceID: service.ID,
+		Service:   service.Name,
+		Tags:      service.Tags,
+		Ownership: service.Ownership(),
+		Metadata:  service.Metadata,
+		To:        service.Status,
+		Timestamp: event.Timestamp,
+	})
+
+	return flapping
+}
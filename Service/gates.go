@@ -0,0 +1,185 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/Repository"
+	"Distributed-Health-Monitoring/config"
+	"Distributed-Health-Monitoring/logging"
+	"Distributed-Health-Monitoring/models"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type GateServiceResult struct {
+	ID             uint       `json:"id"`
+	Name           string     `json:"name"`
+	Status         string     `json:"status"`             // presented status: MAINTENANCE and FLAPPING included
+	UpSince        *time.Time `json:"up_since,omitempty"` // last recovery, or registration when it never went down
+	OpenIncidentID uint       `json:"open_incident_id,omitempty"`
+	Pass           bool       `json:"pass"`
+	Reason         string     `json:"reason,omitempty"`
+}
+
+type GateResult struct {
+	Gate        string              `json:"gate"`
+	Pass        bool                `json:"pass"`
+	Reasons     []string            `json:"reasons"`
+	Services    []GateServiceResult `json:"services"`
+	EvaluatedAt time.Time           `json:"evaluated_at"`
+}
+
+// validateGates checks that gate names are unique and every gate selects something
+func validateGates(gates []config.Gate) error {
+	seen := make(map[string]bool, len(gates))
+	for _, g := range gates {
+		if g.Name == "" {
+			return errors.New("gates: gate name is empty")
+		}
+		if seen[g.Name] {
+			return fmt.Errorf("gates: duplicate gate %s", g.Name)
+		}
+		seen[g.Name] = true
+		if len(g.Services) == 0 && len(g.Tags) == 0 {
+			return fmt.Errorf("gates: gate %s has no services or tags", g.Name)
+		}
+		if g.UpForMinutes < 0 {
+			return fmt.Errorf("gates: gate %s up_for_minutes is negative", g.Name)
+		}
+	}
+	return nil
+}
+
+// EvaluateGate checks a configured deploy-gate policy, answering 200 when it
+// passes and 412 when it doesn't so pipelines can rely on the status code
+func (e *Engine) EvaluateGate(c *gin.Context) {
+	var gate *config.Gate
+	for i, g := range e.Cnfg.Gates {
+		if g.Name == c.Param("name") {
+			gate = &e.Cnfg.Gates[i]
+			break
+		}
+	}
+	if gate == nil {
+		c.JSON(404, gin.H{"error": "gate not found"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	services, err := e.Repo.GetAllServices(ctx)
+	if err != nil && !errors.Is(err, Repository.ErrNoServices) {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	now := time.Now()
+	inMaintenance, err := e.Repo.ServicesInMaintenance(ctx, now)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	result := GateResult{
+		Gate:        gate.Name,
+		Pass:        true,
+		Reasons:     []string{},
+		Services:    []GateServiceResult{},
+		EvaluatedAt: now,
+	}
+	fail := func(reason string) {
+		result.Pass = false
+		result.Reasons = append(result.Reasons, reason)
+	}
+
+	selected, missing := gateServices(gate, services)
+	for _, name := range missing {
+		fail(fmt.Sprintf("service %s is not registered", name))
+	}
+	if len(selected) == 0 && len(missing) == 0 {
+		fail("no services match the gate")
+	}
+
+	stableSince := now.Add(-time.Duration(gate.UpForMinutes) * time.Minute)
+	for _, s := range selected {
+		view := presentService(s, inMaintenance[s.ID])
+		r := GateServiceResult{ID: s.ID, Name: s.Name, Status: view.Status, Pass: true}
+
+		incidents, err := e.Repo.ListIncidents(ctx, s.ID)
+		if err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Incidents come newest first, so the first one tells when the service last recovered
+		upSince := s.CreatedAt
+		if len(incidents) > 0 {
+			if latest := incidents[0]; latest.ResolvedAt == nil {
+				r.OpenIncidentID = latest.ID
+			} else {
+				upSince = *latest.ResolvedAt
+			}
+		}
+
+		switch {
+		case view.Status == StatusMaintenance && gate.AllowMaintenance:
+			// skipped: neither status nor incidents count while under maintenance
+		case view.Status != "UP":
+			r.Pass, r.Reason = false, "status is "+view.Status
+		case gate.NoOpenIncidents && r.OpenIncidentID != 0:
+			r.Pass, r.Reason = false, fmt.Sprintf("incident %d is open", r.OpenIncidentID)
+		case upSince.After(stableSince):
+			r.Pass, r.Reason = false, fmt.Sprintf("up for %s, needs %dm", now.Sub(upSince).Truncate(time.Second), gate.UpForMinutes)
+		}
+		if r.OpenIncidentID == 0 && view.Status == "UP" {
+			r.UpSince = &upSince
+		}
+
+		if !r.Pass {
+			fail(fmt.Sprintf("service %s: %s", s.Name, r.Reason))
+		}
+		result.Services = append(result.Services, r)
+	}
+
+	logging.For(ctx, "gate").Info("evaluated", "gate", gate.Name, "pass", result.Pass, "services", len(result.Services), "failures", len(result.Reasons))
+
+	status := 200
+	if !result.Pass {
+		status = 412
+	}
+	c.JSON(status, result)
+}
+
+// gateServices returns the services a gate applies to, sorted by name, and
+// the listed names that aren't registered
+func gateServices(gate *config.Gate, services map[uint]*models.ExternalService) ([]*models.ExternalService, []string) {
+	byName := make(map[string]*models.ExternalService, len(services))
+	for _, s := range services {
+		byName[s.Name] = s
+	}
+
+	picked := make(map[uint]*models.ExternalService)
+	var missing []string
+	for _, name := range gate.Services {
+		if s, ok := byName[name]; ok {
+			picked[s.ID] = s
+		} else {
+			missing = append(missing, name)
+		}
+	}
+	for _, s := range services {
+		for _, tag := range gate.Tags {
+			if s.HasTag(tag) {
+				picked[s.ID] = s
+				break
+			}
+		}
+	}
+
+	out := make([]*models.ExternalService, 0, len(picked))
+	for _, s := range picked {
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, missing
+}
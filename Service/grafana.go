@@ -0,0 +1,359 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/Repository"
+	"Distributed-Health-Monitoring/models"
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	maxGrafanaRangeDays     = 90
+	defaultGrafanaMaxPoints = 1000
+	maxGrafanaPoints        = 5000 // per series, whatever maxDataPoints asks for
+	maxGrafanaSeries        = 100  // per query, over all targets
+)
+
+// grafanaMetrics are the series every service has, queried as
+// "<service>:<metric>"
+var grafanaMetrics = []string{"latency", "latency_p95", "latency_p99", "uptime", "status", "checks"}
+
+// grafanaRange is the dashboard time range of a query or annotation request
+type grafanaRange struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+type grafanaTarget struct {
+	Target string `json:"target"`
+	RefID  string `json:"refId"`
+	Type   string `json:"type"` // timeserie (default) or table
+}
+
+type grafanaQuery struct {
+	Range         grafanaRange    `json:"range"`
+	IntervalMs    int64           `json:"intervalMs"`
+	MaxDataPoints int             `json:"maxDataPoints"`
+	Targets       []grafanaTarget `json:"targets"`
+}
+
+type grafanaSeries struct {
+	Target     string       `json:"target"`
+	Datapoints [][2]float64 `json:"datapoints"` // [value, unix ms]
+}
+
+type grafanaColumn struct {
+	Text string `json:"text"`
+	Type string `json:"type"`
+}
+
+type grafanaTable struct {
+	Type    string           `json:"type"` // table
+	Columns []grafanaColumn  `json:"columns"`
+	Rows    [][2]interface{} `json:"rows"`
+}
+
+type grafanaAnnotation struct {
+	Annotation interface{} `json:"annotation"`
+	Time       int64       `json:"time"`
+	TimeEnd    int64       `json:"timeEnd,omitempty"`
+	IsRegion   bool        `json:"isRegion"`
+	Title      string      `json:"title"`
+	Text       string      `json:"text"`
+	Tags       []string    `json:"tags"`
+}
+
+// GrafanaTestConnection answers the datasource's "Save & test"
+func (e *Engine) GrafanaTestConnection(c *gin.Context) {
+	c.JSON(200, gin.H{"status": "ok"})
+}
+
+// GrafanaSearch lists the queryable targets containing the search text
+func (e *Engine) GrafanaSearch(c *gin.Context) {
+	var req struct {
+		Target string `json:"target"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	services, err := e.grafanaServices(c.Request.Context())
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	search := strings.ToLower(req.Target)
+	targets := []string{}
+	for _, s := range services {
+		for _, metric := range grafanaMetrics {
+			target := s.Name + ":" + metric
+			if strings.Contains(strings.ToLower(target), search) {
+				targets = append(targets, target)
+			}
+		}
+	}
+	c.JSON(200, targets)
+}
+
+// GrafanaQuery returns the series of each target over the range, bucketed
+// by the dashboard interval. A target's service can be a glob, which
+// returns one series per matching service. The buckets are aggregated by
+// the log store, so no logs are loaded.
+func (e *Engine) GrafanaQuery(c *gin.Context) {
+	var req grafanaQuery
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateGrafanaRange(req.Range); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	services, err := e.grafanaServices(ctx)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Every target is matched before anything is queried, so a pattern that
+	// matches too many services costs nothing
+	type match struct {
+		target  grafanaTarget
+		metric  string
+		service *models.ExternalService
+	}
+	var matches []match
+	for _, t := range req.Targets {
+		pattern, metric, ok := strings.Cut(t.Target, ":")
+		if !ok || !validGrafanaMetric(metric) {
+			c.JSON(400, gin.H{"error": fmt.Sprintf("target %q must be <service>:<metric>, metric one of %s", t.Target, strings.Join(grafanaMetrics, ", "))})
+			return
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			c.JSON(400, gin.H{"error": fmt.Sprintf("target %q has an invalid service pattern", t.Target)})
+			return
+		}
+		for _, s := range services {
+			if ok, _ := path.Match(pattern, s.Name); ok {
+				matches = append(matches, match{target: t, metric: metric, service: s})
+			}
+		}
+	}
+	if len(matches) > maxGrafanaSeries {
+		c.JSON(400, gin.H{"error": fmt.Sprintf("the targets match %d series, more than %d; narrow the service patterns", len(matches), maxGrafanaSeries)})
+		return
+	}
+
+	step := grafanaStep(req)
+	buckets := make(map[uint][]models.CheckLogBucket) // fetched once per service
+	results := []interface{}{}
+	for _, m := range matches {
+		serviceBuckets, fetched := buckets[m.service.ID]
+		if !fetched {
+			if serviceBuckets, err = e.Repo.GetServiceCheckLogBuckets(ctx, m.service.ID, req.Range.From, req.Range.To, step); err != nil {
+				c.JSON(500, gin.H{"error": err.Error()})
+				return
+			}
+			buckets[m.service.ID] = serviceBuckets
+		}
+
+		series := grafanaSeries{Target: m.service.Name + ":" + m.metric, Datapoints: grafanaDatapoints(serviceBuckets, m.metric)}
+		if m.target.Type == "table" {
+			results = append(results, series.table())
+		} else {
+			results = append(results, series)
+		}
+	}
+	c.JSON(200, results)
+}
+
+// GrafanaAnnotations returns the incidents open during the range as
+// regions. The annotation query is an optional service glob.
+func (e *Engine) GrafanaAnnotations(c *gin.Context) {
+	var req struct {
+		Range      grafanaRange `json:"range"`
+		Annotation struct {
+			Name  string `json:"name"`
+			Query string `json:"query"`
+		} `json:"annotation"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateGrafanaRange(req.Range); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	pattern := req.Annotation.Query
+	if pattern == "" {
+		pattern = "*"
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		c.JSON(400, gin.H{"error": "annotation query must be a service name or glob"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	services, err := e.Repo.GetAllServices(ctx)
+	if err != nil && !errors.Is(err, Repository.ErrNoServices) {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	incidents, err := e.Repo.ListIncidentsInRange(ctx, req.Range.From, req.Range.To)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	annotations := []grafanaAnnotation{}
+	for _, inc := range incidents {
+		s, ok := services[inc.ExternalServiceID]
+		if !ok {
+			continue
+		}
+		if match, _ := path.Match(pattern, s.Name); !match {
+			continue
+		}
+		a := grafanaAnnotation{
+			Annotation: req.Annotation,
+			Time:       inc.StartedAt.UnixMilli(),
+			IsRegion:   true,
+			Title:      s.Name + " " + inc.Status,
+			Text:       inc.Cause,
+			Tags:       append([]string{s.Name, inc.Status}, s.Tags...),
+		}
+		if inc.Reason != "" {
+			a.Text = inc.Reason + ": " + inc.Cause
+		}
+		if inc.ResolvedAt != nil {
+			a.TimeEnd = inc.ResolvedAt.UnixMilli()
+		} else {
+			a.TimeEnd = req.Range.To.UnixMilli()
+		}
+		annotations = append(annotations, a)
+	}
+	c.JSON(200, annotations)
+}
+
+// grafanaServices returns the monitored services, by name
+func (e *Engine) grafanaServices(ctx context.Context) ([]*models.ExternalService, error) {
+	services, err := e.Repo.GetAllServices(ctx)
+	if err != nil && !errors.Is(err, Repository.ErrNoServices) {
+		return nil, err
+	}
+	sorted := make([]*models.ExternalService, 0, len(services))
+	for _, s := range services {
+		sorted = append(sorted, s)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	return sorted, nil
+}
+
+func validateGrafanaRange(r grafanaRange) error {
+	if r.From.IsZero() || r.To.IsZero() || !r.From.Before(r.To) {
+		return errors.New("range.from must be before range.to")
+	}
+	if r.To.Sub(r.From) > maxGrafanaRangeDays*24*time.Hour {
+		return fmt.Errorf("ranges are limited to %d days", maxGrafanaRangeDays)
+	}
+	return nil
+}
+
+func validGrafanaMetric(metric string) bool {
+	for _, m := range grafanaMetrics {
+		if m == metric {
+			return true
+		}
+	}
+	return false
+}
+
+// grafanaStep is the bucket width: the dashboard interval, widened so the
+// range fits in maxDataPoints, of at most maxGrafanaPoints
+func grafanaStep(req grafanaQuery) time.Duration {
+	points := req.MaxDataPoints
+	if points <= 0 {
+		points = defaultGrafanaMaxPoints
+	}
+	points = min(points, maxGrafanaPoints)
+	step := time.Duration(req.IntervalMs) * time.Millisecond
+	if fit := req.Range.To.Sub(req.Range.From) / time.Duration(points); fit > step {
+		step = fit
+	}
+	return max(step, time.Second)
+}
+
+// grafanaDatapoints turns the buckets of a service into a series. Buckets
+// without a value are left out, which Grafana draws as a gap.
+func grafanaDatapoints(buckets []models.CheckLogBucket, metric string) [][2]float64 {
+	points := [][2]float64{}
+	for _, b := range buckets {
+		if v, ok := grafanaValue(b, metric); ok {
+			points = append(points, [2]float64{v, float64(b.Start.UnixMilli())})
+		}
+	}
+	return points
+}
+
+func grafanaValue(b models.CheckLogBucket, metric string) (float64, bool) {
+	if b.Checks == 0 {
+		return 0, false
+	}
+	switch metric {
+	case "latency":
+		if b.Available == 0 {
+			return 0, false
+		}
+		return float64(b.LatencySum) / float64(b.Available), true
+	case "latency_p95", "latency_p99":
+		v := b.LatencyP95
+		if metric == "latency_p99" {
+			v = b.LatencyP99
+		}
+		if v == nil {
+			return 0, false
+		}
+		return *v, true
+	case "uptime":
+		return float64(b.Available) / float64(b.Checks) * 100, true
+	case "status":
+		// The worst status of the bucket, so downsampling doesn't hide an outage
+		switch {
+		case b.Available < b.Checks:
+			return 0, true
+		case b.Degraded > 0:
+			return 0.5, true
+		}
+		return 1, true
+	case "checks":
+		return float64(b.Checks), true
+	}
+	return 0, false
+}
+
+// table is the series as Grafana's table response
+func (s grafanaSeries) table() grafanaTable {
+	t := grafanaTable{
+		Type:    "table",
+		Columns: []grafanaColumn{{Text: "Time", Type: "time"}, {Text: s.Target, Type: "number"}},
+		Rows:    make([][2]interface{}, 0, len(s.Datapoints)),
+	}
+	for _, p := range s.Datapoints {
+		t.Rows = append(t.Rows, [2]interface{}{int64(p[1]), p[0]})
+	}
+	return t
+}
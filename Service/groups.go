@@ -0,0 +1,177 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/models"
+	"context"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	GroupModeWorst  = "worst"
+	GroupModeQuorum = "quorum"
+
+	defaultGroupQuorum = 0.5
+)
+
+// statusSeverity orders presented statuses for worst-of aggregation
+var statusSeverity = map[string]int{
+	"UP":                  0,
+	StatusMaintenance:     1,
+	models.StatusPending:  2,
+	models.StatusDegraded: 3,
+	StatusFlapping:        4,
+	"DOWN":                5,
+}
+
+// GroupStatus is the rollup of every service carrying a tag
+type GroupStatus struct {
+	Tag      string         `json:"tag"`
+	Status   string         `json:"status"`
+	Services int            `json:"services"`
+	Counts   map[string]int `json:"counts"` // presented status -> number of services
+	Members  []string       `json:"members"`
+}
+
+// ListGroups returns the aggregate status per tag. ?mode=worst (default)
+// takes the worst member status; ?mode=quorum&quorum=0.5 reports UP while at
+// least that share of the members not in maintenance are UP.
+func (e *Engine) ListGroups(c *gin.Context) {
+	mode := c.DefaultQuery("mode", GroupModeWorst)
+	if mode != GroupModeWorst && mode != GroupModeQuorum {
+		c.JSON(400, gin.H{"error": "mode must be worst or quorum"})
+		return
+	}
+
+	quorum := defaultGroupQuorum
+	if q := c.Query("quorum"); q != "" {
+		v, err := strconv.ParseFloat(q, 64)
+		if err != nil || v <= 0 || v > 1 {
+			c.JSON(400, gin.H{"error": "quorum must be in (0, 1]"})
+			return
+		}
+		quorum = v
+	}
+
+	services, err := e.Repo.GetAllServices(c.Request.Context())
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	groups := buildGroups(e.presentServices(c.Request.Context(), services), mode, quorum)
+	if tag := c.Query("tag"); tag != "" {
+		filtered := groups[:0]
+		for _, g := range groups {
+			if g.Tag == tag {
+				filtered = append(filtered, g)
+			}
+		}
+		groups = filtered
+	}
+
+	c.JSON(200, gin.H{"mode": mode, "groups": groups})
+}
+
+func buildGroups(services map[uint]models.ExternalService, mode string, quorum float64) []GroupStatus {
+	byTag := make(map[string]*GroupStatus)
+	for _, s := range services {
+		for _, tag := range s.Tags {
+			g, ok := byTag[tag]
+			if !ok {
+				g = &GroupStatus{Tag: tag, Counts: make(map[string]int)}
+				byTag[tag] = g
+			}
+			g.Services++
+			g.Counts[s.Status]++
+			g.Members = append(g.Members, s.Name)
+		}
+	}
+
+	groups := make([]GroupStatus, 0, len(byTag))
+	for _, g := range byTag {
+		sort.Strings(g.Members)
+		if mode == GroupModeQuorum {
+			g.Status = quorumStatus(g.Counts, quorum)
+		} else {
+			g.Status = worstStatus(g.Counts)
+		}
+		groups = append(groups, *g)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Tag < groups[j].Tag })
+
+	return groups
+}
+
+func worstStatus(counts map[string]int) string {
+	worst := "UP"
+	for status := range counts {
+		if statusSeverity[status] > statusSeverity[worst] {
+			worst = status
+		}
+	}
+	return worst
+}
+
+// quorumStatus ignores members in maintenance; a group made only of them is in maintenance
+func quorumStatus(counts map[string]int, quorum float64) string {
+	total := 0
+	for status, n := range counts {
+		if status != StatusMaintenance {
+			total += n
+		}
+	}
+	if total == 0 {
+		return StatusMaintenance
+	}
+	// Slow members still serve, so they count towards the quorum
+	if float64(counts["UP"]+counts[models.StatusDegraded]) >= quorum*float64(total) {
+		return "UP"
+	}
+	return "DOWN"
+}
+
+func servicesWithTag(services map[uint]*models.ExternalService, tag string) map[uint]*models.ExternalService {
+	out := make(map[uint]*models.ExternalService)
+	for id, s := range services {
+		if s.HasTag(tag) {
+			out[id] = s
+		}
+	}
+	return out
+}
+
+func servicesOfTeam(services map[uint]*models.ExternalService, team string) map[uint]*models.ExternalService {
+	out := make(map[uint]*models.ExternalService)
+	for id, s := range services {
+		if s.Team == team {
+			out[id] = s
+		}
+	}
+	return out
+}
+
+// serviceIDsWithTag returns the ids of tagged services, restricted to within when it is non-empty
+func (e *Engine) serviceIDsWithTag(ctx context.Context, tag string, within []uint) ([]uint, error) {
+	services, err := e.Repo.GetAllServices(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := make(map[uint]bool, len(within))
+	for _, id := range within {
+		allowed[id] = true
+	}
+
+	ids := make([]uint, 0)
+	for id := range servicesWithTag(services, tag) {
+		if len(within) == 0 || allowed[id] {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	return ids, nil
+}
@@ -0,0 +1,384 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/Repository"
+	"Distributed-Health-Monitoring/apiv1"
+	"Distributed-Health-Monitoring/cache"
+	"Distributed-Health-Monitoring/config"
+	"Distributed-Health-Monitoring/logging"
+	"Distributed-Health-Monitoring/models"
+	monitorv1 "Distributed-Health-Monitoring/proto/monitor/v1"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+const defaultGRPCAddress = ":9090"
+
+// grpcOperatorMethods change state and need the operator role; every other
+// method only reads and needs the viewer role, like GET on the REST API
+var grpcOperatorMethods = map[string]bool{
+	monitorv1.MonitorService_RegisterService_FullMethodName: true,
+	monitorv1.MonitorService_DeleteService_FullMethodName:   true,
+}
+
+// grpcAPI implements MonitorService on the same repository and event hub as
+// the REST handlers
+type grpcAPI struct {
+	monitorv1.UnimplementedMonitorServiceServer
+	e *Engine
+}
+
+func (e *Engine) newGRPCServer() *grpc.Server {
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(e.grpcUnaryInterceptor),
+		grpc.ChainStreamInterceptor(e.grpcStreamInterceptor),
+	)
+	monitorv1.RegisterMonitorServiceServer(server, &grpcAPI{e: e})
+	return server
+}
+
+// runWithGRPC serves the REST API on addr and the gRPC API either on its own
+// listener or on the same port
+func (e *Engine) runWithGRPC(addr string) error {
+	cfg := e.Cnfg.GRPCAPI
+	server := e.newGRPCServer()
+	logger := logging.For(context.Background(), "grpc")
+
+	if cfg.SharePort {
+		logger.Info("listening", "address", addr, "shared", true)
+		return serveShared(addr, e.router, server, e.Cnfg.Server)
+	}
+
+	grpcAddr := cfg.Address
+	if grpcAddr == "" {
+		grpcAddr = defaultGRPCAddress
+	}
+	lis, err := net.Listen("tcp", grpcAddr)
+	if err != nil {
+		return err
+	}
+	go func() {
+		if err := server.Serve(lis); err != nil {
+			logger.Error("serve_failed", "err", err)
+		}
+	}()
+	logger.Info("listening", "address", grpcAddr, "shared", false)
+
+	return newHTTPServer(addr, e.router, e.Cnfg.Server).ListenAndServe()
+}
+
+// serveShared answers REST and gRPC on one port. gRPC clients speak HTTP/2
+// without TLS (prior knowledge), which net/http serves once unencrypted
+// HTTP/2 is enabled, so requests are routed by content type rather than by
+// sniffing the connection. gRPC streams outlive the REST timeouts, so
+// they are lifted for them.
+func serveShared(addr string, rest http.Handler, grpcServer *grpc.Server, cfg config.Server) error {
+	var protocols http.Protocols
+	protocols.SetHTTP1(true)
+	protocols.SetUnencryptedHTTP2(true)
+
+	server := newHTTPServer(addr, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+			clearDeadlines(w)
+			grpcServer.ServeHTTP(w, r)
+			return
+		}
+		rest.ServeHTTP(w, r)
+	}), cfg)
+	server.Protocols = &protocols
+	return server.ListenAndServe()
+}
+
+// grpcAuthorize runs the REST authenticators on the call's metadata, so the
+// Basic credential, OIDC bearer tokens and session cookies all work
+func (e *Engine) grpcAuthorize(ctx context.Context, method string) (context.Context, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, method, nil)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	for key, values := range md {
+		for _, v := range values {
+			req.Header.Add(key, v)
+		}
+	}
+
+	principal, err := e.authenticate(&gin.Context{Request: req})
+	if errors.Is(err, errNoRole) {
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+	if err != nil || principal == nil {
+		return nil, status.Error(codes.Unauthenticated, "unauthorized")
+	}
+
+	need := RoleViewer
+	if grpcOperatorMethods[method] {
+		need = RoleOperator
+	}
+	if roleRank[principal.Role] < roleRank[need] {
+		return nil, status.Errorf(codes.PermissionDenied, "forbidden: requires the %s role", need)
+	}
+	return withPrincipal(ctx, principal), nil
+}
+
+// grpcContext gives the call request and correlation ids, taken from the
+// x-request-id and x-correlation-id metadata like the HTTP headers
+func grpcContext(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	first := func(key string) string {
+		if v := md.Get(key); len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	}
+
+	requestID := first(logging.RequestIDHeader)
+	if !logging.ValidID(requestID) {
+		requestID = logging.NewID()
+	}
+	correlationID := first(logging.CorrelationIDHeader)
+	if !logging.ValidID(correlationID) {
+		correlationID = requestID
+	}
+	return logging.WithCorrelationID(logging.WithRequestID(ctx, requestID), correlationID)
+}
+
+func logGRPCCall(ctx context.Context, method string, start time.Time, err error) {
+	code := status.Code(err)
+	attrs := []any{"method", method, "code", code.String(), "latency_ms", time.Since(start).Milliseconds()}
+	logger := logging.For(ctx, "grpc")
+	switch code {
+	case codes.OK, codes.Canceled:
+		logger.Info("request_completed", attrs...)
+	case codes.Internal, codes.Unknown, codes.Unavailable:
+		logger.Error("request_completed", attrs...)
+	default:
+		logger.Warn("request_completed", attrs...)
+	}
+}
+
+func (e *Engine) grpcUnaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+	start := time.Now()
+	ctx = grpcContext(ctx)
+	defer func() { logGRPCCall(ctx, info.FullMethod, start, err) }()
+
+	authorized, err := e.grpcAuthorize(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(authorized, req)
+}
+
+// contextStream swaps the context of a server stream for the authorized one
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s contextStream) Context() context.Context { return s.ctx }
+
+func (e *Engine) grpcStreamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	start := time.Now()
+	ctx := grpcContext(ss.Context())
+	defer func() { logGRPCCall(ctx, info.FullMethod, start, err) }()
+
+	authorized, err := e.grpcAuthorize(ctx, info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, contextStream{ServerStream: ss, ctx: authorized})
+}
+
+func (g *grpcAPI) ListServices(ctx context.Context, req *monitorv1.ListServicesRequest) (*monitorv1.ListServicesResponse, error) {
+	services, err := g.e.Repo.GetAllServices(ctx)
+	if err != nil && !errors.Is(err, Repository.ErrNoServices) {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if req.GetTag() != "" {
+		services = servicesWithTag(services, req.GetTag())
+	}
+
+	presented := g.e.presentServices(ctx, services)
+	out := &monitorv1.ListServicesResponse{Services: make([]*monitorv1.Service, 0, len(presented))}
+	for _, s := range presented {
+		out.Services = append(out.Services, grpcService(apiv1.NewService(s)))
+	}
+	sort.Slice(out.Services, func(i, j int) bool { return out.Services[i].Id < out.Services[j].Id })
+	return out, nil
+}
+
+func (g *grpcAPI) GetService(ctx context.Context, req *monitorv1.GetServiceRequest) (*monitorv1.Service, error) {
+	service, err := g.service(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	presented := g.e.presentServices(ctx, map[uint]*models.ExternalService{service.ID: service})
+	return grpcService(apiv1.NewService(presented[service.ID])), nil
+}
+
+func (g *grpcAPI) RegisterService(ctx context.Context, req *monitorv1.RegisterServiceRequest) (*monitorv1.Service, error) {
+	if req.GetService() == nil {
+		return nil, status.Error(codes.InvalidArgument, "service is required")
+	}
+	service := serviceRequestFromGRPC(req.GetService()).Model()
+	if err := checkReservedTags(service); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := g.e.Repo.RegisterService(ctx, service); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	cache.Services.Set(service)
+	g.e.audit(ctx, auditCreate, auditService, service.ID, service.Name, "", nil, auditedService(service))
+	clusterBus.serviceChanged(service.ID)
+
+	BroadcastEvent(service.Name, models.ServiceStateChangeEvent{
+		Type:      "service_registered",
+		ServiceID: service.ID,
+		Name:      service.Name,
+		To:        service.Status,
+		Timestamp: time.Now(),
+	})
+
+	out := apiv1.NewService(*service)
+	if service.HeartbeatToken != nil {
+		out.HeartbeatToken = *service.HeartbeatToken
+	}
+	return grpcService(out), nil
+}
+
+func (g *grpcAPI) DeleteService(ctx context.Context, req *monitorv1.DeleteServiceRequest) (*monitorv1.DeleteServiceResponse, error) {
+	service, err := g.service(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	archive, err := g.e.archiveAndDelete(ctx, service, req.GetReason(), false)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, status.Error(codes.NotFound, "service not found")
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &monitorv1.DeleteServiceResponse{ArchiveId: uint32(archive.ID)}, nil
+}
+
+func (g *grpcAPI) ListCheckLogs(ctx context.Context, req *monitorv1.ListCheckLogsRequest) (*monitorv1.ListCheckLogsResponse, error) {
+	limit, offset := int(req.GetLimit()), int(req.GetOffset())
+	if limit < 0 || offset < 0 {
+		return nil, status.Error(codes.InvalidArgument, "limit and offset must not be negative")
+	}
+	if limit == 0 {
+		limit = 100
+	}
+
+	logs, err := g.e.Repo.GetServiceCheckLogs(ctx, uint(req.GetServiceId()), limit, offset)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &monitorv1.ListCheckLogsResponse{Logs: grpcCheckLogs(logs), Limit: int32(limit), Offset: int32(offset)}, nil
+}
+
+func (g *grpcAPI) QueryCheckLogs(ctx context.Context, req *monitorv1.QueryCheckLogsRequest) (*monitorv1.QueryCheckLogsResponse, error) {
+	filter := checkLogFilterFromGRPC(req)
+
+	if filter.Order != "" && filter.Order != "asc" && filter.Order != "desc" {
+		return nil, status.Error(codes.InvalidArgument, "order must be asc or desc")
+	}
+	if filter.Limit < 0 || filter.Offset < 0 {
+		return nil, status.Error(codes.InvalidArgument, "limit and offset must not be negative")
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, status.Error(codes.InvalidArgument, "from must be before to")
+	}
+
+	if tag := req.GetTag(); tag != "" {
+		ids, err := g.e.serviceIDsWithTag(ctx, tag, filter.ServiceIDs)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		if len(ids) == 0 {
+			return &monitorv1.QueryCheckLogsResponse{Logs: []*monitorv1.CheckLog{}}, nil
+		}
+		filter.ServiceIDs = ids
+	}
+
+	logs, total, err := g.e.Repo.QueryServiceCheckLogs(ctx, filter)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &monitorv1.QueryCheckLogsResponse{Logs: grpcCheckLogs(logs), Total: total}, nil
+}
+
+// WatchEvents subscribes to the same hub as the WebSocket clients, with the
+// same send queue, slow client policy and last_seq replay
+func (g *grpcAPI) WatchEvents(req *monitorv1.WatchEventsRequest, stream grpc.ServerStreamingServer[monitorv1.Event]) error {
+	services := make(map[uint32]bool, len(req.GetServiceIds()))
+	for _, id := range req.GetServiceIds() {
+		services[id] = true
+	}
+	types := make(map[string]bool, len(req.GetTypes()))
+	for _, t := range req.GetTypes() {
+		types[t] = true
+	}
+
+	client := GlobalHub.NewClient(nil)
+	if req.GetLastSeq() > 0 {
+		GlobalHub.Resume(client, req.GetLastSeq(), false)
+	} else {
+		GlobalHub.register <- client
+	}
+	defer func() { GlobalHub.unregister <- client }()
+
+	ctx := stream.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-client.Send:
+			if !ok {
+				return status.Error(codes.ResourceExhausted, "fell behind the event stream; resume with last_seq")
+			}
+			event, err := grpcEvent(msg)
+			if err != nil {
+				logging.For(ctx, "grpc").Error("event_decode_failed", "err", err)
+				continue
+			}
+			// A replay gap always goes through: the client has to reload
+			if event.Type != "replay_gap" {
+				if len(services) > 0 && !services[event.ServiceId] {
+					continue
+				}
+				if len(types) > 0 && !types[event.Type] {
+					continue
+				}
+			}
+			if err := stream.Send(event); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (g *grpcAPI) service(ctx context.Context, id uint32) (*models.ExternalService, error) {
+	service, err := g.e.Repo.GetServiceByID(ctx, uint(id))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, status.Error(codes.NotFound, "service not found")
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return service, nil
+}
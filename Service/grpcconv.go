@@ -0,0 +1,217 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/apiv1"
+	"Distributed-Health-Monitoring/models"
+	monitorv1 "Distributed-Health-Monitoring/proto/monitor/v1"
+	"encoding/json"
+	"time"
+
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// The gRPC messages are converted through the apiv1 types, so both APIs
+// redact secrets and present status the same way
+
+func grpcService(s apiv1.Service) *monitorv1.Service {
+	spec := &monitorv1.ServiceSpec{
+		Name:                  s.Name,
+		Url:                   s.URL,
+		Protocol:              s.Protocol,
+		HttpMethod:            s.HTTPMethod,
+		Interval:              s.Interval,
+		TimeoutSeconds:        s.TimeoutSeconds,
+		FailureThreshold:      s.FailureThreshold,
+		Retries:               s.Retries,
+		RetryDelayMs:          s.RetryDelayMs,
+		LatencyWarnMs:         s.LatencyWarnMs,
+		LatencyCritMs:         s.LatencyCritMs,
+		ProxyUrl:              s.ProxyURL,
+		ResolveOverride:       s.ResolveOverride,
+		SourceInterface:       s.SourceInterface,
+		DnsResolver:           s.DNSResolver,
+		DnsRecordType:         s.DNSRecordType,
+		DnsExpected:           s.DNSExpected,
+		HeartbeatGraceSeconds: s.HeartbeatGraceSeconds,
+		Public:                s.Public,
+		Tags:                  s.Tags,
+	}
+	for _, a := range s.Assertions {
+		spec.Assertions = append(spec.Assertions, &monitorv1.Assertion{Type: a.Type, Path: a.Path, Expected: a.Expected})
+	}
+	if s.Auth != nil {
+		spec.Auth = &monitorv1.CheckAuth{
+			Type:         s.Auth.Type,
+			Username:     s.Auth.Username,
+			Password:     s.Auth.Password,
+			Token:        s.Auth.Token,
+			TokenUrl:     s.Auth.TokenURL,
+			ClientId:     s.Auth.ClientID,
+			ClientSecret: s.Auth.ClientSecret,
+			Scopes:       s.Auth.Scopes,
+		}
+	}
+	if len(s.Metadata) > 0 {
+		// Metadata was decoded from JSON, so it always converts
+		spec.Metadata, _ = structpb.NewStruct(s.Metadata)
+	}
+
+	return &monitorv1.Service{
+		Id:                  uint32(s.ID),
+		Spec:                spec,
+		HeartbeatToken:      s.HeartbeatToken,
+		Status:              s.Status,
+		ConsecutiveFailures: s.ConsecutiveFailures,
+		LastCheckedAt:       grpcTime(s.LastCheckedAt),
+		LastHeartbeatAt:     grpcTime(s.LastHeartbeatAt),
+		CreatedAt:           timestamppb.New(s.CreatedAt),
+		UpdatedAt:           timestamppb.New(s.UpdatedAt),
+	}
+}
+
+func serviceRequestFromGRPC(spec *monitorv1.ServiceSpec) apiv1.ServiceRequest {
+	req := apiv1.ServiceRequest{
+		Name:                  spec.GetName(),
+		URL:                   spec.GetUrl(),
+		Protocol:              spec.GetProtocol(),
+		HTTPMethod:            spec.GetHttpMethod(),
+		Interval:              spec.GetInterval(),
+		TimeoutSeconds:        spec.GetTimeoutSeconds(),
+		FailureThreshold:      spec.GetFailureThreshold(),
+		Retries:               spec.GetRetries(),
+		RetryDelayMs:          spec.GetRetryDelayMs(),
+		LatencyWarnMs:         spec.GetLatencyWarnMs(),
+		LatencyCritMs:         spec.GetLatencyCritMs(),
+		ProxyURL:              spec.GetProxyUrl(),
+		ResolveOverride:       spec.GetResolveOverride(),
+		SourceInterface:       spec.GetSourceInterface(),
+		DNSResolver:           spec.GetDnsResolver(),
+		DNSRecordType:         spec.GetDnsRecordType(),
+		DNSExpected:           spec.GetDnsExpected(),
+		HeartbeatGraceSeconds: spec.GetHeartbeatGraceSeconds(),
+		Public:                spec.GetPublic(),
+		Tags:                  spec.GetTags(),
+	}
+	for _, a := range spec.GetAssertions() {
+		req.Assertions = append(req.Assertions, apiv1.Assertion{Type: a.GetType(), Path: a.GetPath(), Expected: a.GetExpected()})
+	}
+	if a := spec.GetAuth(); a != nil {
+		req.Auth = &apiv1.Auth{
+			Type:         a.GetType(),
+			Username:     a.GetUsername(),
+			Password:     a.GetPassword(),
+			Token:        a.GetToken(),
+			TokenURL:     a.GetTokenUrl(),
+			ClientID:     a.GetClientId(),
+			ClientSecret: a.GetClientSecret(),
+			Scopes:       a.GetScopes(),
+		}
+	}
+	if m := spec.GetMetadata(); m != nil {
+		req.Metadata = m.AsMap()
+	}
+	return req
+}
+
+func grpcCheckLogs(logs []*models.ServiceCheckLog) []*monitorv1.CheckLog {
+	out := make([]*monitorv1.CheckLog, 0, len(logs))
+	for _, l := range logs {
+		v := apiv1.NewCheckLog(l)
+		out = append(out, &monitorv1.CheckLog{
+			Id:             uint32(v.ID),
+			ServiceId:      uint32(v.ServiceID),
+			Status:         v.Status,
+			StatusCode:     int32(v.StatusCode),
+			ResponseTimeMs: v.ResponseTimeMs,
+			Error:          v.Error,
+			CheckedAt:      timestamppb.New(v.CheckedAt),
+		})
+	}
+	return out
+}
+
+func checkLogFilterFromGRPC(req *monitorv1.QueryCheckLogsRequest) models.CheckLogFilter {
+	filter := models.CheckLogFilter{
+		Statuses:      req.GetStatuses(),
+		ErrorContains: req.GetErrorContains(),
+		Order:         req.GetOrder(),
+		Limit:         int(req.GetLimit()),
+		Offset:        int(req.GetOffset()),
+	}
+	for _, id := range req.GetServiceIds() {
+		filter.ServiceIDs = append(filter.ServiceIDs, uint(id))
+	}
+	for _, code := range req.GetStatusCodes() {
+		filter.StatusCodes = append(filter.StatusCodes, int(code))
+	}
+	if v := req.GetLatencyGtMs(); v != nil {
+		gt := v.GetValue()
+		filter.LatencyGtMs = &gt
+	}
+	if v := req.GetLatencyLtMs(); v != nil {
+		lt := v.GetValue()
+		filter.LatencyLtMs = &lt
+	}
+	if req.GetFrom() != nil {
+		from := req.GetFrom().AsTime()
+		filter.From = &from
+	}
+	if req.GetTo() != nil {
+		to := req.GetTo().AsTime()
+		filter.To = &to
+	}
+	return filter
+}
+
+// grpcEvent converts one hub payload; the fields shared by the event types
+// are copied out and the whole event is kept as the payload
+func grpcEvent(msg []byte) (*monitorv1.Event, error) {
+	var common struct {
+		Seq       uint64    `json:"seq"`
+		Type      string    `json:"type"`
+		ServiceID uint32    `json:"service_id"`
+		Name      string    `json:"name"`
+		From      string    `json:"from"`
+		To        string    `json:"to"`
+		Timestamp time.Time `json:"timestamp"`
+		Severity  string    `json:"severity"`
+		Reason    string    `json:"reason"`
+		Error     string    `json:"error"`
+	}
+	if err := json.Unmarshal(msg, &common); err != nil {
+		return nil, err
+	}
+	var payload map[string]interface{}
+	if err := json.Unmarshal(msg, &payload); err != nil {
+		return nil, err
+	}
+	structured, err := structpb.NewStruct(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	event := &monitorv1.Event{
+		Seq:       common.Seq,
+		Type:      common.Type,
+		ServiceId: common.ServiceID,
+		Name:      common.Name,
+		From:      common.From,
+		To:        common.To,
+		Severity:  common.Severity,
+		Reason:    common.Reason,
+		Error:     common.Error,
+		Payload:   structured,
+	}
+	if !common.Timestamp.IsZero() {
+		event.Timestamp = timestamppb.New(common.Timestamp)
+	}
+	return event, nil
+}
+
+func grpcTime(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
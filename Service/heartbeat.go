@@ -0,0 +1,62 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/checker"
+	"Distributed-Health-Monitoring/clock"
+	"Distributed-Health-Monitoring/logging"
+	"Distributed-Health-Monitoring/models"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func init() {
+	checker.Register(models.ProtocolHeartbeat, checker.Func(checkHeartbeat))
+}
+
+// checkHeartbeat has nothing to probe: it only compares the last ping with
+// the deadline
+func checkHeartbeat(ctx context.Context, service *models.ExternalService) models.CheckResult {
+	if deadline := service.HeartbeatDeadline(); clock.Now().After(deadline) {
+		return models.CheckResult{
+			Status:       "DOWN",
+			Reason:       "heartbeat_missed",
+			ErrorMessage: fmt.Sprintf("no heartbeat received by %s", deadline.Format(time.RFC3339)),
+		}
+	}
+	return models.CheckResult{Status: "UP", Success: true}
+}
+
+// ReceiveHeartbeat records a ping from a push-based service. The token in the
+// URL is the only credential, so cron jobs can call it with a plain curl.
+func (e *Engine) ReceiveHeartbeat(c *gin.Context) {
+	service, err := e.Repo.RecordHeartbeat(c.Request.Context(), c.Param("token"), clock.Now())
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(404, gin.H{"error": "unknown heartbeat token"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger := logging.For(c.Request.Context(), "heartbeat").With("service", service.Name)
+	logger.Info("ping_received")
+
+	// A DOWN or PENDING service recovers now instead of on its next scheduled check
+	if service.Status != "UP" {
+		job := newHealthCheckJob(service, false)
+		job.CorrelationID = logging.CorrelationID(c.Request.Context())
+		go func() {
+			if err := e.processJob(job); err != nil {
+				logger.Error("recovery_check_failed", "err", err)
+			}
+		}()
+	}
+
+	c.JSON(200, gin.H{"message": "heartbeat received", "next_deadline": service.HeartbeatDeadline()})
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/models"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HeatmapBucket is the downtime observed in one day or hour
+type HeatmapBucket struct {
+	Start           time.Time `json:"start"`
+	DowntimeMinutes float64   `json:"downtime_minutes"`
+	Checks          int       `json:"checks"`
+	FailedChecks    int       `json:"failed_checks"`
+}
+
+const (
+	defaultHeatmapDays = 90
+	maxHeatmapDays     = 366
+	maxHourlyDays      = 31
+)
+
+// GetServiceHeatmap returns per-day (or per-hour) downtime minutes for availability heatmaps
+func (e *Engine) GetServiceHeatmap(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "invalid service id"})
+		return
+	}
+
+	days := defaultHeatmapDays
+	if v := c.Query("days"); v != "" {
+		if days, err = strconv.Atoi(v); err != nil || days <= 0 || days > maxHeatmapDays {
+			c.JSON(400, gin.H{"error": "days must be between 1 and 366"})
+			return
+		}
+	}
+
+	bucket := c.DefaultQuery("bucket", "day")
+	size := 24 * time.Hour
+	switch bucket {
+	case "day":
+	case "hour":
+		if days > maxHourlyDays {
+			c.JSON(400, gin.H{"error": "hourly heatmaps are limited to 31 days"})
+			return
+		}
+		size = time.Hour
+	default:
+		c.JSON(400, gin.H{"error": "bucket must be day or hour"})
+		return
+	}
+
+	service, ok := e.serviceByID(c, uint(id))
+	if !ok {
+		return
+	}
+
+	now := time.Now().UTC()
+	to := now.Truncate(size).Add(size)
+	from := to.Add(-time.Duration(days) * 24 * time.Hour)
+
+	logs, err := e.Repo.GetServiceCheckLogsInRange(c.Request.Context(), service.ID, from, now)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	buckets := buildHeatmap(logs, time.Duration(service.Interval)*time.Second, from, to, now, size)
+
+	c.JSON(200, gin.H{
+		"service_id": service.ID,
+		"bucket":     bucket,
+		"from":       from,
+		"to":         to,
+		"buckets":    buckets,
+	})
+}
+
+// buildHeatmap attributes each failed check the time until the next check
+// (at most one interval) as downtime, split across bucket boundaries
+func buildHeatmap(logs []*models.ServiceCheckLog, interval time.Duration, from, to, now time.Time, size time.Duration) []HeatmapBucket {
+	count := int(to.Sub(from) / size)
+	buckets := make([]HeatmapBucket, count)
+	for i := range buckets {
+		buckets[i].Start = from.Add(time.Duration(i) * size)
+	}
+
+	index := func(t time.Time) int {
+		return int(t.Sub(from) / size)
+	}
+
+	for i, l := range logs {
+		at := l.CheckedAt.UTC()
+		b := index(at)
+		if b < 0 || b >= count {
+			continue
+		}
+		buckets[b].Checks++
+
+		if models.IsAvailable(l.Status) {
+			continue
+		}
+		buckets[b].FailedChecks++
+
+		end := at.Add(interval)
+		if i+1 < len(logs) && logs[i+1].CheckedAt.Before(end) {
+			end = logs[i+1].CheckedAt.UTC()
+		}
+		if end.After(now) {
+			end = now
+		}
+
+		// Spread the span over every bucket it touches
+		for start := at; start.Before(end); {
+			b := index(start)
+			if b >= count {
+				break
+			}
+			bucketEnd := buckets[b].Start.Add(size)
+			if bucketEnd.After(end) {
+				bucketEnd = end
+			}
+			buckets[b].DowntimeMinutes += bucketEnd.Sub(start).Minutes()
+			start = bucketEnd
+		}
+	}
+
+	return buckets
+}
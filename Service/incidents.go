@@ -0,0 +1,36 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/logging"
+	"Distributed-Health-Monitoring/models"
+	"context"
+	"time"
+)
+
+// trackIncident opens an incident when a service goes DOWN and resolves it when it recovers
+func (e *Engine) trackIncident(ctx context.Context, service *models.ExternalService, change *models.StateChange, result models.CheckResult) {
+	now := time.Now()
+	logger := logging.For(ctx, "incident").With("service", service.Name)
+
+	switch {
+	case change.To == "DOWN":
+		incident, err := e.Repo.OpenIncident(ctx, service.ID, result.Reason, result.ErrorMessage, now)
+		if err != nil {
+			logger.Error("open_failed", "err", err)
+			return
+		}
+		logger.Info("opened", "incident_id", incident.ID, "reason", incident.Reason)
+		e.incidentWebhook(ctx, WebhookIncidentOpened, incident, service)
+
+	case change.Resolved():
+		incident, err := e.Repo.ResolveIncident(ctx, service.ID, now)
+		if err != nil {
+			logger.Error("resolve_failed", "err", err)
+			return
+		}
+		if incident != nil {
+			logger.Info("resolved", "incident_id", incident.ID, "duration", now.Sub(incident.StartedAt).Round(time.Second))
+			e.incidentWebhook(ctx, WebhookIncidentResolved, incident, service)
+		}
+	}
+}
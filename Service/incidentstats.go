@@ -0,0 +1,254 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/Repository"
+	"Distributed-Health-Monitoring/models"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultStatsWindow = "90d"
+	maxStatsWindow     = 366 * 24 * time.Hour
+	flakiestServices   = 5
+)
+
+// IncidentStats summarises incidents over a window. Incidents are counted
+// when they start inside the window; downtime also includes the part of
+// older incidents that overlaps it.
+type IncidentStats struct {
+	Window          string                 `json:"window"`
+	From            time.Time              `json:"from"`
+	To              time.Time              `json:"to"`
+	Incidents       int                    `json:"incidents"`
+	Open            int                    `json:"open"`
+	MTTRSeconds     *float64               `json:"mttr_seconds"` // mean time to resolve; null when nothing was resolved
+	MTBFSeconds     *float64               `json:"mtbf_seconds"` // mean up time between incidents; null without incidents
+	DowntimeSeconds float64                `json:"downtime_seconds"`
+	Services        []ServiceIncidentStats `json:"services"` // services with incidents or downtime, most incidents first
+	Tags            []TagIncidentStats     `json:"tags"`
+	Flakiest        []ServiceIncidentStats `json:"flakiest"` // top five by incidents, then downtime
+}
+
+type ServiceIncidentStats struct {
+	ID              uint     `json:"id"`
+	Name            string   `json:"name"`
+	Team            string   `json:"team,omitempty"`
+	Incidents       int      `json:"incidents"`
+	Open            int      `json:"open"`
+	MTTRSeconds     *float64 `json:"mttr_seconds"`
+	MTBFSeconds     *float64 `json:"mtbf_seconds"`
+	DowntimeSeconds float64  `json:"downtime_seconds"`
+
+	tags     []string
+	resolved int
+	repair   time.Duration // total time to resolve of the resolved incidents
+	downtime time.Duration
+	observed time.Duration // part of the window the service existed
+}
+
+type TagIncidentStats struct {
+	Tag             string   `json:"tag"`
+	Services        int      `json:"services"`
+	Incidents       int      `json:"incidents"`
+	Open            int      `json:"open"`
+	MTTRSeconds     *float64 `json:"mttr_seconds"`
+	DowntimeSeconds float64  `json:"downtime_seconds"`
+}
+
+// parseStatsWindow accepts a number of days ("90d") or a Go duration ("36h")
+func parseStatsWindow(s string) (time.Duration, error) {
+	var d time.Duration
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid window %q", s)
+		}
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		parsed, err := time.ParseDuration(s)
+		if err != nil || parsed <= 0 {
+			return 0, fmt.Errorf("invalid window %q", s)
+		}
+		d = parsed
+	}
+	if d > maxStatsWindow {
+		return 0, fmt.Errorf("window must be at most %dd", int(maxStatsWindow.Hours()/24))
+	}
+	return d, nil
+}
+
+// GetIncidentStats reports MTTR, MTBF and incident counts per service and
+// tag over ?window= (default 90d), optionally only for services with ?tag=
+// or of ?team=
+func (e *Engine) GetIncidentStats(c *gin.Context) {
+	label := c.DefaultQuery("window", defaultStatsWindow)
+	window, err := parseStatsWindow(label)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	to := time.Now()
+	from := to.Add(-window)
+
+	services, err := e.Repo.GetAllServices(ctx)
+	if err != nil && !errors.Is(err, Repository.ErrNoServices) {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	if tag := c.Query("tag"); tag != "" {
+		services = servicesWithTag(services, tag)
+	}
+	if team := c.Query("team"); team != "" {
+		services = servicesOfTeam(services, team)
+	}
+
+	incidents, err := e.Repo.ListIncidentsInRange(ctx, from, to)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, incidentStats(label, from, to, services, incidents))
+}
+
+func incidentStats(label string, from, to time.Time, services map[uint]*models.ExternalService, incidents []models.Incident) IncidentStats {
+	perService := make(map[uint]*ServiceIncidentStats, len(services))
+	for id, s := range services {
+		start := from
+		if s.CreatedAt.After(start) {
+			start = s.CreatedAt
+		}
+		perService[id] = &ServiceIncidentStats{ID: id, Name: s.Name, Team: s.Team, tags: s.Tags, observed: to.Sub(start)}
+	}
+
+	for _, i := range incidents {
+		st := perService[i.ExternalServiceID]
+		if st == nil {
+			continue // filtered out by tag
+		}
+
+		// Only the part inside the window counts as downtime
+		start, end := i.StartedAt, to
+		if i.ResolvedAt != nil {
+			end = *i.ResolvedAt
+		}
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(start) {
+			st.downtime += end.Sub(start)
+		}
+
+		if i.StartedAt.Before(from) {
+			continue
+		}
+		st.Incidents++
+		if i.ResolvedAt == nil {
+			st.Open++
+		} else {
+			st.resolved++
+			st.repair += i.ResolvedAt.Sub(i.StartedAt)
+		}
+	}
+
+	out := IncidentStats{
+		Window:   label,
+		From:     from,
+		To:       to,
+		Services: []ServiceIncidentStats{},
+		Tags:     []TagIncidentStats{},
+		Flakiest: []ServiceIncidentStats{},
+	}
+
+	var resolved int
+	var repair, downtime, uptime time.Duration
+	tags := make(map[string]*TagIncidentStats)
+	tagRepair := make(map[string]time.Duration)
+	tagResolved := make(map[string]int)
+
+	for _, st := range perService {
+		st.MTTRSeconds = meanSeconds(st.repair, st.resolved)
+		st.MTBFSeconds = meanSeconds(st.observed-st.downtime, st.Incidents)
+		st.DowntimeSeconds = roundSeconds(st.downtime)
+
+		out.Incidents += st.Incidents
+		out.Open += st.Open
+		resolved += st.resolved
+		repair += st.repair
+		downtime += st.downtime
+		uptime += st.observed - st.downtime
+
+		for _, tag := range st.tags {
+			t := tags[tag]
+			if t == nil {
+				t = &TagIncidentStats{Tag: tag}
+				tags[tag] = t
+			}
+			t.Services++
+			t.Incidents += st.Incidents
+			t.Open += st.Open
+			t.DowntimeSeconds += st.DowntimeSeconds
+			tagRepair[tag] += st.repair
+			tagResolved[tag] += st.resolved
+		}
+
+		if st.Incidents > 0 || st.downtime > 0 {
+			out.Services = append(out.Services, *st)
+		}
+	}
+
+	out.MTTRSeconds = meanSeconds(repair, resolved)
+	out.MTBFSeconds = meanSeconds(uptime, out.Incidents)
+	out.DowntimeSeconds = roundSeconds(downtime)
+
+	sort.Slice(out.Services, func(i, j int) bool {
+		a, b := out.Services[i], out.Services[j]
+		if a.Incidents != b.Incidents {
+			return a.Incidents > b.Incidents
+		}
+		if a.DowntimeSeconds != b.DowntimeSeconds {
+			return a.DowntimeSeconds > b.DowntimeSeconds
+		}
+		return a.Name < b.Name
+	})
+	for _, st := range out.Services {
+		if len(out.Flakiest) == flakiestServices {
+			break
+		}
+		if st.Incidents > 0 {
+			out.Flakiest = append(out.Flakiest, st)
+		}
+	}
+
+	for tag, t := range tags {
+		t.MTTRSeconds = meanSeconds(tagRepair[tag], tagResolved[tag])
+		t.DowntimeSeconds = math.Round(t.DowntimeSeconds*10) / 10
+		out.Tags = append(out.Tags, *t)
+	}
+	sort.Slice(out.Tags, func(i, j int) bool { return out.Tags[i].Tag < out.Tags[j].Tag })
+
+	return out
+}
+
+// meanSeconds divides a total duration by n, or returns nil when n is zero
+func meanSeconds(total time.Duration, n int) *float64 {
+	if n == 0 {
+		return nil
+	}
+	mean := roundSeconds(total / time.Duration(n))
+	return &mean
+}
+
+func roundSeconds(d time.Duration) float64 {
+	return math.Round(d.Seconds()*10) / 10
+}
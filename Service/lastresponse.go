@@ -0,0 +1,35 @@
+package service
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetLastResponse returns the raw response the last HTTP check received
+func (e *Engine) GetLastResponse(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "invalid service id"})
+		return
+	}
+
+	service, ok := e.serviceByID(c, uint(id))
+	if !ok {
+		return
+	}
+
+	last, err := e.Repo.GetLastResponse(c.Request.Context(), service.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(404, gin.H{"error": "no response recorded yet"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, last)
+}
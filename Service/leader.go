@@ -0,0 +1,127 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/logging"
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultLeaderLockKey is the advisory lock id used when ha.lock_key is unset
+const defaultLeaderLockKey int64 = 724_300_001
+
+// LeaderElector decides which replica runs the scheduler using a session
+// level lock: a Postgres advisory lock, or a MySQL named lock. The lock lives
+// on one dedicated connection, so it is released automatically if this
+// replica dies or loses its connection.
+type LeaderElector struct {
+	mu       sync.Mutex
+	db       *sql.DB
+	key      interface{} // advisory lock id, or lock name on MySQL
+	lockSQL  string
+	freeSQL  string
+	instance string
+	conn     *sql.Conn
+}
+
+// NewLeaderElector fails for databases without session locks, i.e. SQLite,
+// which only ever has one replica
+func NewLeaderElector(db *sql.DB, dialect string, key int64, instance string) (*LeaderElector, error) {
+	if key == 0 {
+		key = defaultLeaderLockKey
+	}
+	l := &LeaderElector{
+		db:       db,
+		instance: instance,
+	}
+	switch dialect {
+	case "postgres":
+		l.key, l.lockSQL, l.freeSQL = key, "SELECT pg_try_advisory_lock($1)", "SELECT pg_advisory_unlock($1)"
+	case "mysql":
+		// GET_LOCK returns NULL on error, which scans as not acquired
+		l.key, l.lockSQL, l.freeSQL = fmt.Sprintf("health-monitor-%d", key), "SELECT GET_LOCK(?, 0)", "SELECT RELEASE_LOCK(?)"
+	default:
+		return nil, fmt.Errorf("ha needs postgres or mysql, not %s", dialect)
+	}
+	return l, nil
+}
+
+// IsLeader keeps or tries to acquire the leadership and reports the result.
+// It is called once per scheduler tick.
+func (l *LeaderElector) IsLeader(ctx context.Context) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.conn != nil {
+		err := l.conn.PingContext(ctx)
+		if err == nil {
+			return true
+		}
+		logging.For(ctx, "leader").Warn("leadership_lost", "instance", l.instance, "err", err)
+		l.conn.Close()
+		l.conn = nil
+	}
+
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		logging.For(ctx, "leader").Error("connection_failed", "instance", l.instance, "err", err)
+		return false
+	}
+
+	var acquired sql.NullBool
+	if err := conn.QueryRowContext(ctx, l.lockSQL, l.key).Scan(&acquired); err != nil {
+		logging.For(ctx, "leader").Error("lock_failed", "instance", l.instance, "err", err)
+		conn.Close()
+		return false
+	}
+
+	if !acquired.Bool {
+		conn.Close()
+		return false
+	}
+
+	l.conn = conn
+	logging.For(ctx, "leader").Info("leadership_acquired", "instance", l.instance, "lock_key", l.key)
+	return true
+}
+
+// Leader reports the last known leadership without touching the database
+func (l *LeaderElector) Leader() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.conn != nil
+}
+
+// Release gives up the leadership so another replica can take over immediately
+func (l *LeaderElector) Release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.conn == nil {
+		return
+	}
+
+	if _, err := l.conn.ExecContext(context.Background(), l.freeSQL, l.key); err != nil {
+		logging.For(context.Background(), "leader").Error("unlock_failed", "instance", l.instance, "err", err)
+	}
+	l.conn.Close()
+	l.conn = nil
+	logging.For(context.Background(), "leader").Info("leadership_released", "instance", l.instance)
+}
+
+// GetLeaderStatus reports whether this replica currently runs the scheduler
+func (e *Engine) GetLeaderStatus(c *gin.Context) {
+	if e.Leader == nil {
+		c.JSON(200, gin.H{"ha_enabled": false, "leader": true})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"ha_enabled": true,
+		"instance":   e.Leader.instance,
+		"leader":     e.Leader.Leader(),
+	})
+}
@@ -0,0 +1,299 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/Repository"
+	"Distributed-Health-Monitoring/assertions"
+	"Distributed-Health-Monitoring/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	lintSeverityError   = "error"
+	lintSeverityWarning = "warning"
+
+	// lintProbeWorkers bounds concurrent reachability probes
+	lintProbeWorkers = 8
+	// lintProbeTimeout caps each probe, whatever the service timeout says
+	lintProbeTimeout = 10 * time.Second
+)
+
+// LintFinding is one problem in a definition document. Index is the
+// position of the definition in the list, -1 for the document itself.
+type LintFinding struct {
+	Index    int    `json:"index"`
+	Service  string `json:"service,omitempty"`
+	Field    string `json:"field,omitempty"`
+	Severity string `json:"severity"` // error or warning
+	Code     string `json:"code"`
+	Message  string `json:"message"`
+}
+
+type LintReport struct {
+	Valid    bool          `json:"valid"` // no errors; warnings don't count
+	Services int           `json:"services"`
+	Errors   int           `json:"errors"`
+	Warnings int           `json:"warnings"`
+	Findings []LintFinding `json:"findings"`
+}
+
+// serviceFields are the JSON keys a definition may use
+var serviceFields = jsonFields(reflect.TypeOf(models.ExternalService{}))
+
+func jsonFields(t reflect.Type) map[string]bool {
+	fields := make(map[string]bool, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			fields[name] = true
+		}
+	}
+	return fields
+}
+
+// LintServices validates a YAML or JSON service document the way the import
+// would, without saving anything. It answers 422 when there are errors so a
+// CI step fails; ?check_reachability=true also probes every valid target.
+func (e *Engine) LintServices(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	services, err := e.Repo.GetAllServices(ctx)
+	if err != nil && !errors.Is(err, Repository.ErrNoServices) {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	byName := make(map[string]*models.ExternalService, len(services))
+	for _, s := range services {
+		byName[s.Name] = s
+	}
+
+	report := e.lintDocument(ctx, body, byName, c.Query("check_reachability") == "true")
+
+	status := 200
+	if !report.Valid {
+		status = 422
+	}
+	c.JSON(status, report)
+}
+
+func (e *Engine) lintDocument(ctx context.Context, body []byte, existing map[string]*models.ExternalService, reachability bool) LintReport {
+	report := LintReport{Findings: []LintFinding{}}
+	add := func(f LintFinding) {
+		report.Findings = append(report.Findings, f)
+	}
+
+	raws, err := splitServiceDefinitions(body)
+	if err != nil {
+		add(LintFinding{Index: -1, Severity: lintSeverityError, Code: "parse_error", Message: err.Error()})
+		return finishLint(report)
+	}
+	report.Services = len(raws)
+
+	seen := make(map[string]int, len(raws))
+	var valid []*models.ExternalService
+	for i, raw := range raws {
+		def, findings := lintDefinition(i, raw)
+		for _, f := range findings {
+			add(f)
+		}
+		if def == nil {
+			continue
+		}
+
+		duplicate := false
+		if first, ok := seen[def.Name]; ok {
+			duplicate = true
+			add(LintFinding{Index: i, Service: def.Name, Field: "name", Severity: lintSeverityError, Code: "duplicate_name",
+				Message: fmt.Sprintf("name is also used by definition %d", first)})
+		} else if def.Name != "" {
+			seen[def.Name] = i
+		}
+
+		if current := existing[def.Name]; current != nil {
+			switch {
+			case current.HasTag(SystemTag):
+				add(LintFinding{Index: i, Service: def.Name, Field: "name", Severity: lintSeverityError, Code: "name_conflict",
+					Message: "name belongs to a self-monitoring check"})
+			case current.HasTag(consulSourceTag):
+				add(LintFinding{Index: i, Service: def.Name, Field: "name", Severity: lintSeverityWarning, Code: "name_conflict",
+					Message: "service is managed by Consul sync, which will overwrite the imported definition"})
+			}
+		}
+
+		if !duplicate && !hasErrors(findings) {
+			valid = append(valid, def)
+		}
+	}
+
+	if reachability {
+		for _, f := range e.lintReachability(ctx, valid, seen) {
+			add(f)
+		}
+	}
+
+	return finishLint(report)
+}
+
+// lintDefinition checks one definition on its own. The returned service is
+// nil when the definition couldn't be decoded at all.
+func lintDefinition(index int, raw json.RawMessage) (*models.ExternalService, []LintFinding) {
+	var findings []LintFinding
+	finding := func(name, field, severity, code, message string) {
+		findings = append(findings, LintFinding{Index: index, Service: name, Field: field, Severity: severity, Code: code, Message: message})
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil || fields == nil {
+		finding("", "", lintSeverityError, "invalid_definition", "definition must be an object")
+		return nil, findings
+	}
+
+	var def models.ExternalService
+	if err := json.Unmarshal(raw, &def); err != nil {
+		finding("", "", lintSeverityError, "invalid_definition", err.Error())
+		return nil, findings
+	}
+	name := def.Name
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	runtime := make(map[string]bool, len(runtimeFields))
+	for _, f := range runtimeFields {
+		runtime[f] = true
+	}
+	for _, key := range keys {
+		switch {
+		case runtime[key]:
+			finding(name, key, lintSeverityWarning, "runtime_field", "field is managed by the monitor and ignored on import")
+		case !serviceFields[key]:
+			finding(name, key, lintSeverityError, "unknown_field", "field is not part of a service definition")
+		}
+	}
+
+	if name == "" {
+		finding(name, "name", lintSeverityError, "missing_name", "every service definition needs a name")
+	}
+	if err := checkReservedTags(&def); err != nil {
+		finding(name, "tags", lintSeverityError, "reserved_tag", err.Error())
+	}
+
+	for i, a := range def.Assertions {
+		if err := assertions.Validate(a); err != nil {
+			finding(name, fmt.Sprintf("assertions[%d]", i), lintSeverityError, "invalid_assertion", err.Error())
+		}
+	}
+
+	// The repository validation stops at the first problem; assertions were covered above
+	check := def
+	check.Assertions = nil
+	if err := Repository.ValidateService(&check); err != nil {
+		finding(name, "", lintSeverityError, "invalid_definition", err.Error())
+	}
+
+	if def.Protocol != models.ProtocolHeartbeat && def.TimeoutSeconds > 0 && def.Interval > 0 && def.TimeoutSeconds > def.Interval {
+		finding(name, "timeout_seconds", lintSeverityWarning, "timeout_exceeds_interval", "checks can take longer than the interval between them")
+	}
+	if def.LatencyCritMs > 0 && def.TimeoutSeconds > 0 && def.LatencyCritMs >= def.TimeoutSeconds*1000 {
+		finding(name, "latency_crit_ms", lintSeverityWarning, "threshold_unreachable", "the check times out before latency_crit_ms is reached")
+	}
+
+	// Hand back the normalized copy so reachability probes see the defaults
+	check.Assertions = def.Assertions
+	return &check, findings
+}
+
+// lintReachability probes each valid definition once; failures are warnings
+// since a target may only be reachable from the monitor's network
+func (e *Engine) lintReachability(ctx context.Context, defs []*models.ExternalService, index map[string]int) []LintFinding {
+	var (
+		mu       sync.Mutex
+		findings []LintFinding
+		wg       sync.WaitGroup
+	)
+	sem := make(chan struct{}, lintProbeWorkers)
+
+	for _, def := range defs {
+		if def.Protocol == models.ProtocolHeartbeat {
+			continue
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(def models.ExternalService) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if time.Duration(def.TimeoutSeconds)*time.Second > lintProbeTimeout {
+				def.TimeoutSeconds = int64(lintProbeTimeout / time.Second)
+			}
+			probeCtx, cancel := context.WithTimeout(ctx, lintProbeTimeout)
+			defer cancel()
+
+			result, err := probe(probeCtx, &def)
+			if err == nil && result.Success {
+				return
+			}
+			message := result.ErrorMessage
+			if err != nil {
+				message = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			findings = append(findings, LintFinding{
+				Index:    index[def.Name],
+				Service:  def.Name,
+				Field:    "url",
+				Severity: lintSeverityWarning,
+				Code:     "unreachable",
+				Message:  message,
+			})
+		}(*def)
+	}
+	wg.Wait()
+
+	sort.Slice(findings, func(i, j int) bool { return findings[i].Index < findings[j].Index })
+	return findings
+}
+
+func hasErrors(findings []LintFinding) bool {
+	for _, f := range findings {
+		if f.Severity == lintSeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+func finishLint(report LintReport) LintReport {
+	for _, f := range report.Findings {
+		if f.Severity == lintSeverityError {
+			report.Errors++
+		} else {
+			report.Warnings++
+		}
+	}
+	report.Valid = report.Errors == 0
+	sort.SliceStable(report.Findings, func(i, j int) bool { return report.Findings[i].Index < report.Findings[j].Index })
+	return report
+}
@@ -0,0 +1,381 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/Repository"
+	"Distributed-Health-Monitoring/config"
+	"Distributed-Health-Monitoring/logging"
+	"Distributed-Health-Monitoring/models"
+	"Distributed-Health-Monitoring/s3"
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultExportDays   = 30
+	maxExportDays       = 366
+	defaultRetention    = 30
+	defaultArchiveEvery = time.Hour
+	defaultArchivePath  = "check-logs"
+
+	// maxArchiveVersions bounds the objects a day can be archived to that
+	// way, past which the day is left in the log store
+	maxArchiveVersions = 100
+)
+
+// checkLogCSVHeader is the first row of CSV exports and archives
+var checkLogCSVHeader = []string{
+	"id", "service_id", "service", "checked_at", "status", "status_code", "response_time_ms",
+	"region", "error_message", "dns_ms", "connect_ms", "tls_ms", "ttfb_ms", "total_ms", "query_ms",
+}
+
+func checkLogCSVRecord(l *models.ServiceCheckLog, service string) []string {
+	optional := func(v *int64) string {
+		if v == nil {
+			return ""
+		}
+		return strconv.FormatInt(*v, 10)
+	}
+	return []string{
+		strconv.FormatUint(uint64(l.ID), 10),
+		strconv.FormatUint(uint64(l.ExternalServiceID), 10),
+		csvText(service),
+		l.CheckedAt.UTC().Format(time.RFC3339Nano),
+		l.Status,
+		strconv.Itoa(l.StatusCode),
+		strconv.FormatInt(l.ResponseTimeMs, 10),
+		csvText(l.Region),
+		csvText(l.ErrorMessage),
+		optional(l.DNSMs),
+		optional(l.ConnectMs),
+		optional(l.TLSMs),
+		optional(l.TTFBMs),
+		optional(l.TotalMs),
+		optional(l.QueryMs),
+	}
+}
+
+// csvText keeps free text from being read as a formula when the file is
+// opened in a spreadsheet
+func csvText(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// ExportHealthCheckLogs downloads the check logs of a service checked in
+// [from, to) as CSV, oldest first. The logs of deleted services can be
+// exported too.
+func (e *Engine) ExportHealthCheckLogs(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("serviceId"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "invalid service id"})
+		return
+	}
+	if format := c.DefaultQuery("format", "csv"); format != "csv" {
+		c.JSON(400, gin.H{"error": "format must be csv"})
+		return
+	}
+
+	to := time.Now().UTC()
+	if raw := c.Query("to"); raw != "" {
+		if to, err = time.Parse(time.RFC3339, raw); err != nil {
+			c.JSON(400, gin.H{"error": "to must be an RFC 3339 time"})
+			return
+		}
+	}
+	from := to.AddDate(0, 0, -defaultExportDays)
+	if raw := c.Query("from"); raw != "" {
+		if from, err = time.Parse(time.RFC3339, raw); err != nil {
+			c.JSON(400, gin.H{"error": "from must be an RFC 3339 time"})
+			return
+		}
+	}
+	if !from.Before(to) {
+		c.JSON(400, gin.H{"error": "from must be before to"})
+		return
+	}
+	if to.Sub(from) > maxExportDays*24*time.Hour {
+		c.JSON(400, gin.H{"error": fmt.Sprintf("exports are limited to %d days", maxExportDays)})
+		return
+	}
+
+	ctx := c.Request.Context()
+	var name string
+	service, err := e.Repo.GetServiceByID(ctx, uint(id))
+	switch {
+	case err == nil:
+		name = service.Name
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	filename := fmt.Sprintf("check-logs-%d-%s-%s.csv", id, from.UTC().Format("20060102T150405Z"), to.UTC().Format("20060102T150405Z"))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	clearDeadlines(c.Writer) // a year of logs takes a while to write
+	c.Status(200)
+
+	// A day at a time, so a long range isn't held in memory
+	logger := logging.For(ctx, "log_export").With("service_id", id)
+	w := csv.NewWriter(c.Writer)
+	w.Write(checkLogCSVHeader)
+	rows := 0
+	for start := from; start.Before(to); start = start.Add(24 * time.Hour) {
+		end := start.Add(24 * time.Hour)
+		if end.After(to) {
+			end = to
+		}
+		logs, err := e.Repo.GetServiceCheckLogsInRange(ctx, uint(id), start, end)
+		if err != nil {
+			// The status is sent already; a truncated file is all we can signal
+			logger.Error("fetch_logs_failed", "from", start, "err", err)
+			break
+		}
+		for _, l := range logs {
+			w.Write(checkLogCSVRecord(l, name))
+		}
+		rows += len(logs)
+		w.Flush()
+		if w.Error() != nil {
+			break
+		}
+	}
+	logger.Info("exported", "from", from, "to", to, "rows", rows)
+}
+
+func validateLogArchive(cfg config.LogArchive) error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.S3.Bucket == "" {
+		return errors.New("log_archive: s3.bucket is required")
+	}
+	if cfg.RetentionDays < 0 {
+		return errors.New("log_archive: retention_days must not be negative")
+	}
+	return nil
+}
+
+func newS3Client(cfg config.S3) *s3.Client {
+	client := &s3.Client{
+		Endpoint:        cfg.Endpoint,
+		Region:          cfg.Region,
+		Bucket:          cfg.Bucket,
+		AccessKeyID:     cfg.AccessKeyID,
+		SecretAccessKey: cfg.SecretAccessKey,
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		PathStyle:       cfg.PathStyle,
+	}
+	if client.Region == "" {
+		client.Region = "us-east-1"
+	}
+	if client.Endpoint == "" {
+		client.Endpoint = "https://s3." + client.Region + ".amazonaws.com"
+	}
+	if client.AccessKeyID == "" {
+		client.AccessKeyID = os.Getenv("AWS_ACCESS_KEY_ID")
+	}
+	if client.SecretAccessKey == "" {
+		client.SecretAccessKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+	}
+	return client
+}
+
+// archiveKey is the object a day is archived to, partitioned by date so
+// query engines like Athena can read the prefix as a table. Version 0 is
+// the first upload; a day archived again, like after a failed prune, gets
+// the next free version next to it instead of overwriting it.
+func archiveKey(prefix string, day time.Time, version int) string {
+	if prefix == "" {
+		prefix = defaultArchivePath
+	}
+	date := day.Format("2006-01-02")
+	name := "check-logs-" + date
+	if version > 0 {
+		name += "." + strconv.Itoa(version)
+	}
+	return path.Join(prefix, "date="+date, name+".csv.gz")
+}
+
+// LogArchiver periodically archives the days of check logs older than
+// log_archive.retention_days and prunes them. With HA only the leader runs
+// it. A day is only pruned after its upload succeeded, and a day that was
+// uploaded but not pruned is simply uploaded again.
+func (e *Engine) LogArchiver(ctx context.Context) error {
+	cfg := e.Cnfg.LogArchive
+	if !cfg.Enabled {
+		return nil
+	}
+
+	interval := time.Duration(cfg.IntervalMinutes) * time.Minute
+	if interval <= 0 {
+		interval = defaultArchiveEvery
+	}
+	client := newS3Client(cfg.S3)
+
+	logging.For(ctx, "log_archive").Info("started", "bucket", cfg.S3.Bucket, "retention_days", cfg.RetentionDays, "interval", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if e.Leader != nil && !e.Leader.Leader() {
+				continue
+			}
+			e.archiveLogs(ctx, client, time.Now().UTC())
+		}
+	}
+}
+
+func (e *Engine) archiveLogs(ctx context.Context, client *s3.Client, now time.Time) {
+	cfg := e.Cnfg.LogArchive
+	logger := logging.For(ctx, "log_archive")
+
+	retention := cfg.RetentionDays
+	if retention == 0 {
+		retention = defaultRetention
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	cutoff := today.AddDate(0, 0, -retention)
+
+	oldest, _, err := e.Repo.QueryServiceCheckLogs(ctx, models.CheckLogFilter{To: &cutoff, Order: "asc", Limit: 1})
+	if err != nil {
+		logger.Error("fetch_oldest_failed", "err", err)
+		return
+	}
+	if len(oldest) == 0 {
+		return
+	}
+
+	ids, err := e.Repo.CheckLogServiceIDs(ctx)
+	if err != nil {
+		logger.Error("fetch_service_ids_failed", "err", err)
+		return
+	}
+	services, err := e.Repo.GetAllServices(ctx)
+	if err != nil && !errors.Is(err, Repository.ErrNoServices) {
+		logger.Error("fetch_services_failed", "err", err)
+		return
+	}
+
+	first := oldest[0].CheckedAt.UTC()
+	for day := time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, time.UTC); day.Before(cutoff); day = day.AddDate(0, 0, 1) {
+		if ctx.Err() != nil {
+			return
+		}
+		if !e.archiveDay(ctx, client, ids, services, day) {
+			return
+		}
+
+		pruned, err := e.Repo.PruneCheckLogs(ctx, day.AddDate(0, 0, 1))
+		if err != nil {
+			logger.Error("prune_failed", "date", day.Format("2006-01-02"), "err", err)
+			return
+		}
+		if pruned > 0 {
+			logger.Info("pruned", "before", day.AddDate(0, 0, 1), "rows", pruned)
+		}
+	}
+}
+
+// archiveDay uploads the logs of a UTC day to a new object and reports
+// whether the day may be pruned
+func (e *Engine) archiveDay(ctx context.Context, client *s3.Client, ids []uint, services map[uint]*models.ExternalService, day time.Time) bool {
+	logger := logging.For(ctx, "log_archive").With("date", day.Format("2006-01-02"))
+
+	f, err := os.CreateTemp("", "check-logs-*.csv.gz")
+	if err != nil {
+		logger.Error("archive_failed", "err", err)
+		return false
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	rows, err := e.writeArchiveDay(ctx, f, ids, services, day)
+	if err != nil {
+		logger.Error("archive_failed", "err", err)
+		return false
+	}
+	if rows == 0 {
+		return true
+	}
+	size, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		logger.Error("archive_failed", "err", err)
+		return false
+	}
+
+	for version := 0; version < maxArchiveVersions; version++ {
+		key := archiveKey(e.Cnfg.LogArchive.S3.Prefix, day, version)
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			logger.Error("archive_failed", "err", err)
+			return false
+		}
+		err := client.CreateObject(ctx, key, f, "application/gzip")
+		switch {
+		case errors.Is(err, s3.ErrExists):
+			logger.Warn("archive_exists", "key", key)
+			continue
+		case err != nil:
+			logger.Error("upload_failed", "key", key, "err", err)
+			return false
+		}
+		logger.Info("archived", "key", key, "rows", rows, "bytes", size)
+		return true
+	}
+	logger.Error("upload_failed", "err", fmt.Sprintf("%d versions of the day are archived already", maxArchiveVersions))
+	return false
+}
+
+// writeArchiveDay streams the logs of every service checked on a UTC day to
+// w as gzipped CSV, an hour of one service at a time
+func (e *Engine) writeArchiveDay(ctx context.Context, w io.Writer, ids []uint, services map[uint]*models.ExternalService, day time.Time) (int, error) {
+	gz := gzip.NewWriter(w)
+	cw := csv.NewWriter(gz)
+	cw.Write(checkLogCSVHeader)
+
+	rows := 0
+	for _, id := range ids {
+		var name string
+		if s, ok := services[id]; ok {
+			name = s.Name
+		}
+		for hour := day; hour.Before(day.AddDate(0, 0, 1)); hour = hour.Add(time.Hour) {
+			logs, err := e.Repo.GetServiceCheckLogsInRange(ctx, id, hour, hour.Add(time.Hour))
+			if err != nil {
+				return 0, err
+			}
+			for _, l := range logs {
+				cw.Write(checkLogCSVRecord(l, name))
+			}
+			rows += len(logs)
+			cw.Flush()
+			if err := cw.Error(); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	if err := gz.Close(); err != nil {
+		return 0, err
+	}
+	return rows, nil
+}
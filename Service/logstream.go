@@ -0,0 +1,109 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/apiv1"
+	"Distributed-Health-Monitoring/logging"
+	"Distributed-Health-Monitoring/logstore"
+	"Distributed-Health-Monitoring/models"
+	"io"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// Any replica's worker may write the logs, so the stream polls the log
+	// store instead of listening to this replica's hub
+	logStreamPoll = 2 * time.Second
+
+	// Each poll looks this far behind the newest log it sent, so a log
+	// written late by a replica with a slower clock isn't skipped
+	logStreamOverlap = 30 * time.Second
+
+	logStreamKeepAlive = 15 * time.Second
+)
+
+// V1StreamCheckLogs streams the new check logs of a service as server-sent
+// events ("log" events carrying an apiv1.CheckLog), starting at ?from=
+// (RFC 3339, default now)
+func (e *Engine) V1StreamCheckLogs(c *gin.Context) {
+	service, ok := e.v1Service(c)
+	if !ok {
+		return
+	}
+
+	start := time.Now()
+	if v := c.Query("from"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			c.JSON(400, apiv1.Error{Error: "from must be an RFC 3339 time"})
+			return
+		}
+		start = t
+	}
+
+	ctx := c.Request.Context()
+	logger := logging.For(ctx, "log_stream").With("service_id", service.ID)
+	logger.Info("stream_opened", "from", start)
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no") // don't let a proxy buffer the events
+	clearDeadlines(c.Writer)
+
+	poll := time.NewTicker(logStreamPoll)
+	defer poll.Stop()
+	keepAlive := time.NewTicker(logStreamKeepAlive)
+	defer keepAlive.Stop()
+
+	newest := start
+	sent := make(map[uint]time.Time) // ids already streamed within the overlap
+
+	c.Stream(func(w io.Writer) bool {
+		from := newest.Add(-logStreamOverlap)
+		if from.Before(start) {
+			from = start
+		}
+		logs, _, err := e.Repo.QueryServiceCheckLogs(ctx, models.CheckLogFilter{
+			ServiceIDs: []uint{service.ID},
+			From:       &from,
+			Order:      "asc",
+			Limit:      logstore.MaxQueryLimit,
+		})
+		if err != nil {
+			logger.Error("query_failed", "err", err)
+			c.SSEvent("error", apiv1.Error{Error: err.Error()})
+			return false
+		}
+
+		for _, l := range logs {
+			if _, ok := sent[l.ID]; ok {
+				continue
+			}
+			sent[l.ID] = l.CheckedAt
+			if l.CheckedAt.After(newest) {
+				newest = l.CheckedAt
+			}
+			c.SSEvent("log", apiv1.NewCheckLog(l))
+		}
+		for id, at := range sent {
+			if at.Before(from) {
+				delete(sent, id)
+			}
+		}
+		c.Writer.Flush()
+
+		select {
+		case <-ctx.Done():
+			logger.Info("stream_closed")
+			return false
+		case <-keepAlive.C:
+			// A comment line keeps idle connections open through proxies
+			if _, err := io.WriteString(w, ": keep-alive\n\n"); err != nil {
+				return false
+			}
+			return true
+		case <-poll.C:
+			return true
+		}
+	})
+}
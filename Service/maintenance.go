@@ -0,0 +1,130 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/logging"
+	"Distributed-Health-Monitoring/models"
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const StatusMaintenance = "MAINTENANCE"
+
+func (e *Engine) CreateMaintenanceWindow(c *gin.Context) {
+	var window models.MaintenanceWindow
+
+	if err := c.ShouldBindJSON(&window); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, ok := e.serviceByID(c, window.ExternalServiceID); !ok {
+		return
+	}
+
+	if err := e.Repo.CreateMaintenanceWindow(c.Request.Context(), &window); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	e.audit(c.Request.Context(), auditCreate, auditMaintenanceWindow, window.ID, "", window.Reason, nil, window)
+
+	logging.For(c.Request.Context(), "maintenance").Info(
+		"window_created",
+		"service_id", window.ExternalServiceID,
+		"starts_at", window.StartsAt,
+		"ends_at", window.EndsAt,
+		"recurrence", window.Recurrence,
+	)
+
+	c.JSON(201, gin.H{"message": "maintenance window created", "window": window})
+}
+
+func (e *Engine) ListMaintenanceWindows(c *gin.Context) {
+	serviceID := uint64(0)
+	if v := c.Query("service_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "invalid service id"})
+			return
+		}
+		serviceID = id
+	}
+
+	windows, err := e.Repo.ListMaintenanceWindows(c.Request.Context(), uint(serviceID))
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	now := time.Now()
+	out := make([]gin.H, 0, len(windows))
+	for _, w := range windows {
+		out = append(out, gin.H{"window": w, "active": w.ActiveAt(now)})
+	}
+
+	c.JSON(200, gin.H{"windows": out})
+}
+
+func (e *Engine) DeleteMaintenanceWindow(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "invalid maintenance window id"})
+		return
+	}
+
+	window, err := e.Repo.GetMaintenanceWindow(c.Request.Context(), uint(id))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(404, gin.H{"error": "maintenance window not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := e.Repo.DeleteMaintenanceWindow(c.Request.Context(), uint(id)); err != nil {
+		c.JSON(404, gin.H{"error": err.Error()})
+		return
+	}
+	e.audit(c.Request.Context(), auditDelete, auditMaintenanceWindow, window.ID, "", c.Query("reason"), window, nil)
+
+	c.JSON(200, gin.H{"message": "maintenance window deleted"})
+}
+
+// presentServices returns response copies of the services with their status
+// overridden to MAINTENANCE while a window is active (or FLAPPING while the
+// service is flapping), expired acknowledgements dropped and the root cause
+// of DOWN services set, leaving the cache untouched
+func (e *Engine) presentServices(ctx context.Context, services map[uint]*models.ExternalService) map[uint]models.ExternalService {
+	inMaintenance, err := e.Repo.ServicesInMaintenance(ctx, time.Now())
+	if err != nil {
+		logging.For(ctx, "maintenance").Error("fetch_windows_failed", "err", err)
+	}
+
+	out := make(map[uint]models.ExternalService, len(services))
+	for id, s := range services {
+		out[id] = presentService(s, inMaintenance[id])
+	}
+	annotateRootCauses(out)
+
+	return out
+}
+
+func presentService(s *models.ExternalService, inMaintenance bool) models.ExternalService {
+	view := *s
+	redactSecrets(&view)
+	if !view.Acknowledgement.Active(time.Now()) {
+		view.Acknowledgement = nil
+	}
+	switch {
+	case inMaintenance:
+		view.Status = StatusMaintenance
+	case s.Flapping:
+		view.Status = StatusFlapping
+	}
+	return view
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/Repository"
+	"Distributed-Health-Monitoring/clock"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// metricsWriter renders the Prometheus text exposition format
+type metricsWriter struct {
+	b strings.Builder
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// family starts a metric with its HELP and TYPE lines
+func (w *metricsWriter) family(name, kind, help string) {
+	w.b.WriteString("# HELP " + name + " " + help + "\n")
+	w.b.WriteString("# TYPE " + name + " " + kind + "\n")
+}
+
+// sample writes one value; labels are name, value pairs
+func (w *metricsWriter) sample(name string, value float64, labels ...string) {
+	w.b.WriteString(name)
+	if len(labels) > 0 {
+		w.b.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				w.b.WriteByte(',')
+			}
+			w.b.WriteString(labels[i] + `="` + labelEscaper.Replace(labels[i+1]) + `"`)
+		}
+		w.b.WriteByte('}')
+	}
+	w.b.WriteString(" " + strconv.FormatFloat(value, 'g', -1, 64) + "\n")
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// Metrics exposes this replica's scheduler and worker figures for Prometheus
+func (e *Engine) Metrics(c *gin.Context) {
+	services, err := e.Repo.GetAllServices(c.Request.Context())
+	if err != nil && !errors.Is(err, Repository.ErrNoServices) {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	fairness := e.fairness.report(services)
+
+	var w metricsWriter
+
+	w.family("monitor_checks_scheduled_total", "counter", "Jobs this replica published per service.")
+	for _, s := range fairness.Services {
+		w.sample("monitor_checks_scheduled_total", float64(s.Scheduled), "service", s.Name)
+	}
+	w.family("monitor_checks_executed_total", "counter", "Jobs this replica's worker started per service.")
+	for _, s := range fairness.Services {
+		w.sample("monitor_checks_executed_total", float64(s.Executed), "service", s.Name)
+	}
+	w.family("monitor_queue_wait_seconds", "gauge", "Average time recent jobs waited in the queue.")
+	for _, s := range fairness.Services {
+		w.sample("monitor_queue_wait_seconds", float64(s.AvgWaitMs)/1000, "service", s.Name)
+	}
+	w.family("monitor_schedule_delay_seconds", "gauge", "Average time from a service becoming due to its check starting.")
+	for _, s := range fairness.Services {
+		w.sample("monitor_schedule_delay_seconds", float64(s.AvgDelayMs)/1000, "service", s.Name)
+	}
+	w.family("monitor_service_starved", "gauge", "1 while a service's checks keep starting more than one interval late.")
+	for _, s := range fairness.Services {
+		w.sample("monitor_service_starved", boolValue(s.Starved), "service", s.Name)
+	}
+	w.family("monitor_starved_services", "gauge", "Services currently starved.")
+	w.sample("monitor_starved_services", float64(fairness.Starved))
+
+	now := clock.Now()
+	lag := schedulerLag(services, now, lagWarn(e.Cnfg.Scheduler))
+	w.family("monitor_scheduler_lag_seconds", "gauge", "How long ago a service's check became due without starting; 0 when on time.")
+	for _, s := range fairness.Services {
+		if service, ok := services[s.ServiceID]; ok {
+			w.sample("monitor_scheduler_lag_seconds", serviceLag(service, now).Seconds(), "service", s.Name)
+		}
+	}
+	w.family("monitor_scheduler_max_lag_seconds", "gauge", "Lag of the most behind service.")
+	w.sample("monitor_scheduler_max_lag_seconds", lag.MaxLagSeconds)
+	w.family("monitor_scheduler_lagging_services", "gauge", "Services behind by more than scheduler.lag_warn_seconds.")
+	w.sample("monitor_scheduler_lagging_services", float64(lag.Lagging))
+	w.family("monitor_jobs_dropped_total", "counter", "Jobs this replica's worker dropped without checking, by reason.")
+	w.sample("monitor_jobs_dropped_total", float64(e.sched.droppedStale.Load()), "reason", "stale")
+	w.sample("monitor_jobs_dropped_total", float64(e.sched.droppedDuplicate.Load()), "reason", "duplicate")
+	if status := e.clock.get(); status.SyncedAt != nil {
+		w.family("monitor_clock_offset_seconds", "gauge", "Database clock minus this replica's clock at the last reading.")
+		w.sample("monitor_clock_offset_seconds", status.OffsetSeconds)
+		w.family("monitor_clock_skewed", "gauge", "1 while this replica's clock is off by more than scheduler.clock_skew_warn_seconds.")
+		w.sample("monitor_clock_skewed", boolValue(status.Skewed))
+	}
+	if p := e.sched.getPublisher(); p != nil {
+		if depth, err := p.Pending(); err == nil {
+			w.family("monitor_queue_depth", "gauge", "Jobs waiting in the shared and regional job queues.")
+			w.sample("monitor_queue_depth", float64(depth))
+		}
+	}
+
+	hub := GlobalHub.Stats()
+	w.family("monitor_websocket_connections", "gauge", "Open WebSocket connections on this replica.")
+	w.sample("monitor_websocket_connections", float64(e.ws.connections.Load()))
+	w.family("monitor_websocket_rejected_total", "counter", "WebSocket upgrades refused, by reason.")
+	w.sample("monitor_websocket_rejected_total", float64(e.ws.rejectedAuth.Load()), "reason", "auth")
+	w.sample("monitor_websocket_rejected_total", float64(e.ws.rejectedLimit.Load()), "reason", "limit")
+	w.family("monitor_websocket_pong_timeouts_total", "counter", "WebSocket connections closed because pongs stopped.")
+	w.sample("monitor_websocket_pong_timeouts_total", float64(e.ws.pingTimeouts.Load()))
+	w.family("monitor_websocket_events_sent_total", "counter", "Events queued to WebSocket and gRPC subscribers.")
+	w.sample("monitor_websocket_events_sent_total", float64(hub.Sent))
+	w.family("monitor_websocket_events_dropped_total", "counter", "Events discarded for slow subscribers.")
+	w.sample("monitor_websocket_events_dropped_total", float64(hub.Dropped))
+	w.family("monitor_websocket_slow_disconnects_total", "counter", "Subscribers disconnected for being too slow.")
+	w.sample("monitor_websocket_slow_disconnects_total", float64(hub.Disconnected))
+
+	if b := clusterBus; b != nil {
+		w.family("monitor_cluster_connected", "gauge", "Whether this replica is subscribed to the cluster channel.")
+		w.sample("monitor_cluster_connected", boolValue(b.connected.Load()))
+		w.family("monitor_cluster_messages_published_total", "counter", "Events and invalidations sent to the other replicas.")
+		w.sample("monitor_cluster_messages_published_total", float64(b.published.Load()))
+		w.family("monitor_cluster_messages_received_total", "counter", "Events and invalidations received from the other replicas.")
+		w.sample("monitor_cluster_messages_received_total", float64(b.received.Load()))
+		w.family("monitor_cluster_messages_dropped_total", "counter", "Messages not sent because the queue was full or Redis failed.")
+		w.sample("monitor_cluster_messages_dropped_total", float64(b.dropped.Load()))
+	}
+
+	if writer := e.Repo.LogWriterStats(); writer.Enabled {
+		w.family("monitor_log_writer_queue_depth", "gauge", "Check logs waiting for the batch writer.")
+		w.sample("monitor_log_writer_queue_depth", float64(writer.QueueDepth))
+		w.family("monitor_log_writer_queue_capacity", "gauge", "Check logs the writer buffers before checks wait.")
+		w.sample("monitor_log_writer_queue_capacity", float64(writer.QueueCapacity))
+		w.family("monitor_log_writer_written_total", "counter", "Check logs written by the batch writer.")
+		w.sample("monitor_log_writer_written_total", float64(writer.Written))
+		w.family("monitor_log_writer_dropped_total", "counter", "Check logs lost after the row by row retry failed.")
+		w.sample("monitor_log_writer_dropped_total", float64(writer.Dropped))
+		w.family("monitor_log_writer_flushes_total", "counter", "Batches written.")
+		w.sample("monitor_log_writer_flushes_total", float64(writer.Flushes))
+		w.family("monitor_log_writer_flush_errors_total", "counter", "Batches the log store rejected.")
+		w.sample("monitor_log_writer_flush_errors_total", float64(writer.FlushErrors))
+		w.family("monitor_log_writer_flush_seconds_total", "counter", "Time spent writing batches.")
+		w.sample("monitor_log_writer_flush_seconds_total", writer.FlushSeconds)
+		w.family("monitor_log_writer_last_flush_milliseconds", "gauge", "Duration of the latest batch write.")
+		w.sample("monitor_log_writer_last_flush_milliseconds", float64(writer.LastFlushMs))
+		w.family("monitor_log_writer_last_batch_rows", "gauge", "Rows in the latest batch.")
+		w.sample("monitor_log_writer_last_batch_rows", float64(writer.LastBatchRows))
+		w.family("monitor_log_writer_backpressure_waits_total", "counter", "Checks that found the queue full and waited.")
+		w.sample("monitor_log_writer_backpressure_waits_total", float64(writer.BackpressureWaits))
+		w.family("monitor_log_writer_backpressure_seconds_total", "counter", "Time checks spent waiting for queue space.")
+		w.sample("monitor_log_writer_backpressure_seconds_total", writer.BackpressureSeconds)
+	}
+
+	c.Data(200, "text/plain; version=0.0.4; charset=utf-8", []byte(w.b.String()))
+}
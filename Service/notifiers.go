@@ -0,0 +1,52 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/logging"
+	"Distributed-Health-Monitoring/notify"
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// notifierTestTimeout covers a slow SMTP handshake or webhook
+const notifierTestTimeout = 15 * time.Second
+
+// ListNotifiers returns the configured notifiers without their credentials
+func (e *Engine) ListNotifiers(c *gin.Context) {
+	c.JSON(200, gin.H{"notifiers": e.Notifier.Notifiers()})
+}
+
+// TestNotifier sends a synthetic alert through one notifier, bypassing its
+// routing rules, and reports whether the channel accepted it. A PagerDuty
+// test triggers a real incident, which can page whoever is on call before it
+// is resolved, so it needs ?confirm=true.
+func (e *Engine) TestNotifier(c *gin.Context) {
+	id := c.Param("id")
+	kind := e.Notifier.Kind(id)
+	if kind == "" {
+		c.JSON(404, gin.H{"error": "notifier not found"})
+		return
+	}
+	if kind == "pagerduty" && c.Query("confirm") != "true" {
+		c.JSON(400, gin.H{"error": "a PagerDuty test triggers a real incident and may page whoever is on call; repeat with ?confirm=true"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), notifierTestTimeout)
+	defer cancel()
+
+	alert := notify.TestAlert(time.Now())
+	start := time.Now()
+	err := e.Notifier.SendTo(ctx, id, alert)
+	elapsed := time.Since(start).Milliseconds()
+
+	if err != nil {
+		logging.For(ctx, "notifier").Warn("test_failed", "notifier", id, "err", err)
+		c.JSON(502, gin.H{"notifier": id, "delivered": false, "error": err.Error(), "duration_ms": elapsed})
+		return
+	}
+
+	logging.For(ctx, "notifier").Info("test_sent", "notifier", id, "duration_ms", elapsed)
+	c.JSON(200, gin.H{"notifier": id, "delivered": true, "duration_ms": elapsed, "alert": alert})
+}
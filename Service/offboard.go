@@ -0,0 +1,449 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/Repository"
+	"Distributed-Health-Monitoring/logging"
+	"Distributed-Health-Monitoring/models"
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	// OrgTagPrefix marks the services of an organization: org:<name>
+	OrgTagPrefix = "org:"
+
+	defaultOffboardingDir = "exports"
+	manifestFile          = "manifest.json"
+	purgeFile             = "purge.json"
+)
+
+// orgName keeps organization names safe to use in a directory name
+var orgName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+type OffboardFile struct {
+	Name    string `json:"name"`
+	Records int    `json:"records"`
+	Bytes   int64  `json:"bytes"`
+	SHA256  string `json:"sha256"`
+}
+
+// OffboardManifest describes an export. Every file in the export directory
+// is listed with its hash, so the recipient can verify nothing was altered.
+type OffboardManifest struct {
+	Org        string         `json:"org"`
+	Tag        string         `json:"tag"`
+	ServiceIDs []uint         `json:"service_ids"`
+	ArchiveIDs []uint         `json:"archive_ids"`
+	Files      []OffboardFile `json:"files"`
+	ExportedAt time.Time      `json:"exported_at"`
+}
+
+// OffboardPurge records what was deleted after the export was verified
+type OffboardPurge struct {
+	ManifestSHA256 string           `json:"manifest_sha256"`
+	Deleted        map[string]int64 `json:"deleted"` // table -> rows
+	PurgedAt       time.Time        `json:"purged_at"`
+}
+
+type OffboardJob struct {
+	ID             string            `json:"id"`
+	Org            string            `json:"org"`
+	Purge          bool              `json:"purge"`
+	Status         string            `json:"status"` // running, exported, purged, failed
+	Error          string            `json:"error,omitempty"`
+	Dir            string            `json:"dir"`
+	Manifest       *OffboardManifest `json:"manifest,omitempty"`
+	ManifestSHA256 string            `json:"manifest_sha256,omitempty"`
+	Purged         *OffboardPurge    `json:"purged,omitempty"`
+	StartedAt      time.Time         `json:"started_at"`
+	FinishedAt     *time.Time        `json:"finished_at,omitempty"`
+}
+
+// offboardJobs tracks export jobs in memory; the manifest on disk is the
+// durable record, so a restart only loses the job status
+type offboardJobs struct {
+	mu   sync.Mutex
+	jobs map[string]*OffboardJob
+}
+
+// start registers a job unless one is already running for the org
+func (j *offboardJobs) start(job *OffboardJob) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.jobs == nil {
+		j.jobs = make(map[string]*OffboardJob)
+	}
+	for _, other := range j.jobs {
+		if other.Org == job.Org && other.Status == "running" {
+			return false
+		}
+	}
+	j.jobs[job.ID] = job
+	return true
+}
+
+// update applies fn to the job under the lock
+func (j *offboardJobs) update(id string, fn func(job *OffboardJob)) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if job, ok := j.jobs[id]; ok {
+		fn(job)
+	}
+}
+
+// get returns a copy that is safe to serialize while the job runs
+func (j *offboardJobs) get(id string) (OffboardJob, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	job, ok := j.jobs[id]
+	if !ok {
+		return OffboardJob{}, false
+	}
+	return *job, true
+}
+
+// StartOffboarding exports every service tagged org:<org> together with its
+// logs, incidents, escalations, maintenance windows, last response and
+// archives. With ?purge=true the data is deleted once the export is verified.
+func (e *Engine) StartOffboarding(c *gin.Context) {
+	org := c.Param("org")
+	if !orgName.MatchString(org) {
+		c.JSON(400, gin.H{"error": "invalid organization name"})
+		return
+	}
+
+	dir := e.Cnfg.Offboarding.Dir
+	if dir == "" {
+		dir = defaultOffboardingDir
+	}
+
+	now := time.Now()
+	id := logging.NewID()
+	job := &OffboardJob{
+		ID:        id,
+		Org:       org,
+		Purge:     c.Query("purge") == "true",
+		Status:    "running",
+		Dir:       filepath.Join(dir, org+"-"+now.UTC().Format("20060102T150405Z")+"-"+id),
+		StartedAt: now,
+	}
+	if !e.offboarding.start(job) {
+		c.JSON(409, gin.H{"error": "an offboarding job is already running for " + org})
+		return
+	}
+
+	snapshot := *job
+	ctx := logging.WithCorrelationID(context.Background(), logging.CorrelationID(c.Request.Context()))
+	ctx = withPrincipal(ctx, principalFrom(c.Request.Context()))
+	go e.runOffboarding(ctx, snapshot)
+
+	c.JSON(202, gin.H{"job": snapshot})
+}
+
+func (e *Engine) GetOffboardingJob(c *gin.Context) {
+	job, ok := e.offboarding.get(c.Param("id"))
+	if !ok {
+		c.JSON(404, gin.H{"error": "job not found"})
+		return
+	}
+	c.JSON(200, gin.H{"job": job})
+}
+
+func (e *Engine) runOffboarding(ctx context.Context, job OffboardJob) {
+	logger := logging.For(ctx, "offboard").With("org", job.Org, "job_id", job.ID)
+	logger.Info("started", "purge", job.Purge, "dir", job.Dir)
+
+	fail := func(step string, err error) {
+		logger.Error(step+"_failed", "err", err)
+		e.offboarding.update(job.ID, func(j *OffboardJob) {
+			finished := time.Now()
+			j.Status, j.Error, j.FinishedAt = "failed", fmt.Sprintf("%s: %v", step, err), &finished
+		})
+	}
+
+	manifest, services, err := e.exportOrganization(ctx, job.Org, job.Dir)
+	if err != nil {
+		fail("export", err)
+		return
+	}
+	manifestHash, err := verifyExport(job.Dir, manifest)
+	if err != nil {
+		fail("verify", err)
+		return
+	}
+	logger.Info("exported", "services", len(manifest.ServiceIDs), "archives", len(manifest.ArchiveIDs), "manifest_sha256", manifestHash)
+
+	e.offboarding.update(job.ID, func(j *OffboardJob) {
+		j.Manifest, j.ManifestSHA256 = manifest, manifestHash
+	})
+
+	if !job.Purge {
+		e.offboarding.update(job.ID, func(j *OffboardJob) {
+			finished := time.Now()
+			j.Status, j.FinishedAt = "exported", &finished
+		})
+		return
+	}
+
+	deleted, err := e.Repo.PurgeServices(ctx, manifest.ServiceIDs, manifest.ArchiveIDs)
+	if err != nil {
+		fail("purge", err)
+		return
+	}
+	for _, s := range services {
+		e.forgetService(s.ID)
+		e.audit(ctx, auditDelete, auditService, s.ID, s.Name, "offboarded "+job.Org+" (job "+job.ID+")", auditedService(s), nil)
+		clusterBus.serviceChanged(s.ID)
+	}
+
+	purge := &OffboardPurge{ManifestSHA256: manifestHash, Deleted: deleted, PurgedAt: time.Now()}
+	if _, err := writeJSONFile(job.Dir, purgeFile, purge, 1); err != nil {
+		fail("record_purge", err)
+		return
+	}
+	logger.Info("purged", "deleted", deleted)
+
+	e.offboarding.update(job.ID, func(j *OffboardJob) {
+		j.Status, j.Purged, j.FinishedAt = "purged", purge, &purge.PurgedAt
+	})
+}
+
+// exportOrganization writes the organization's data and its manifest into
+// dir, returning the services it exported
+func (e *Engine) exportOrganization(ctx context.Context, org string, dir string) (*OffboardManifest, []*models.ExternalService, error) {
+	tag := OrgTagPrefix + org
+
+	all, err := e.Repo.GetAllServices(ctx)
+	if err != nil && !errors.Is(err, Repository.ErrNoServices) {
+		return nil, nil, err
+	}
+	services := make([]*models.ExternalService, 0)
+	for _, s := range servicesWithTag(all, tag) {
+		services = append(services, s)
+	}
+	sort.Slice(services, func(i, j int) bool { return services[i].ID < services[j].ID })
+
+	allArchives, err := e.Repo.ListServiceArchives(ctx, "")
+	if err != nil {
+		return nil, nil, err
+	}
+	var archives []*models.ServiceArchive
+	for _, a := range allArchives {
+		if a.Config.HasTag(tag) {
+			archives = append(archives, a)
+		}
+	}
+	if len(services) == 0 && len(archives) == 0 {
+		return nil, nil, fmt.Errorf("no services or archives are tagged %s", tag)
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, nil, err
+	}
+
+	manifest := &OffboardManifest{
+		Org:        org,
+		Tag:        tag,
+		ServiceIDs: []uint{},
+		ArchiveIDs: []uint{},
+		ExportedAt: time.Now(),
+	}
+
+	var (
+		incidents   []models.Incident
+		escalations []models.IncidentEscalation
+		windows     []*models.MaintenanceWindow
+		responses   []*models.LastResponse
+	)
+	for _, s := range services {
+		manifest.ServiceIDs = append(manifest.ServiceIDs, s.ID)
+
+		list, err := e.Repo.ListIncidents(ctx, s.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+		incidents = append(incidents, list...)
+		for _, i := range list {
+			esc, err := e.Repo.ListIncidentEscalations(ctx, i.ID)
+			if err != nil {
+				return nil, nil, err
+			}
+			escalations = append(escalations, esc...)
+		}
+
+		w, err := e.Repo.ListMaintenanceWindows(ctx, s.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+		windows = append(windows, w...)
+
+		last, err := e.Repo.GetLastResponse(ctx, s.ID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, err
+		}
+		if last != nil {
+			responses = append(responses, last)
+		}
+	}
+	for _, a := range archives {
+		manifest.ArchiveIDs = append(manifest.ArchiveIDs, a.ID)
+	}
+
+	// The heartbeat token is a live credential, not organization data
+	exported := make([]models.ExternalService, 0, len(services))
+	for _, s := range services {
+		def := *s
+		def.HeartbeatToken = nil
+		redactSecrets(&def)
+		exported = append(exported, def)
+	}
+
+	files := []struct {
+		name    string
+		data    interface{}
+		records int
+	}{
+		{"services.json", exported, len(exported)},
+		{"incidents.json", incidents, len(incidents)},
+		{"incident_escalations.json", escalations, len(escalations)},
+		{"maintenance_windows.json", windows, len(windows)},
+		{"last_responses.json", responses, len(responses)},
+		{"service_archives.json", archives, len(archives)},
+	}
+	for _, f := range files {
+		entry, err := writeJSONFile(dir, f.name, f.data, f.records)
+		if err != nil {
+			return nil, nil, err
+		}
+		manifest.Files = append(manifest.Files, entry)
+	}
+
+	// Archived services keep their logs in external log stores, so include their old ids
+	logIDs := append([]uint{}, manifest.ServiceIDs...)
+	for _, a := range archives {
+		logIDs = append(logIDs, a.ServiceID)
+	}
+	entry, err := e.writeCheckLogs(ctx, dir, logIDs, manifest.ExportedAt)
+	if err != nil {
+		return nil, nil, err
+	}
+	manifest.Files = append(manifest.Files, entry)
+
+	if _, err := writeJSONFile(dir, manifestFile, manifest, 1); err != nil {
+		return nil, nil, err
+	}
+	return manifest, services, nil
+}
+
+// writeCheckLogs streams the logs as JSON lines, one service at a time
+func (e *Engine) writeCheckLogs(ctx context.Context, dir string, serviceIDs []uint, until time.Time) (OffboardFile, error) {
+	entry := OffboardFile{Name: "service_check_logs.jsonl"}
+
+	f, err := os.OpenFile(filepath.Join(dir, entry.Name), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return entry, err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	counter := &countingWriter{w: io.MultiWriter(f, hash)}
+	w := bufio.NewWriter(counter)
+
+	for _, id := range serviceIDs {
+		logs, err := e.Repo.GetServiceCheckLogsInRange(ctx, id, time.Unix(0, 0), until)
+		if err != nil {
+			return entry, err
+		}
+		for _, l := range logs {
+			line, err := json.Marshal(l)
+			if err != nil {
+				return entry, err
+			}
+			w.Write(append(line, '\n'))
+			entry.Records++
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return entry, err
+	}
+	if err := f.Close(); err != nil {
+		return entry, err
+	}
+
+	entry.Bytes = counter.n
+	entry.SHA256 = hex.EncodeToString(hash.Sum(nil))
+	return entry, nil
+}
+
+func writeJSONFile(dir string, name string, v interface{}, records int) (OffboardFile, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return OffboardFile{}, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), data, 0o600); err != nil {
+		return OffboardFile{}, err
+	}
+	sum := sha256.Sum256(data)
+	return OffboardFile{Name: name, Records: records, Bytes: int64(len(data)), SHA256: hex.EncodeToString(sum[:])}, nil
+}
+
+// verifyExport re-reads every file from disk and checks it against the
+// manifest, returning the hash of the manifest itself. Nothing is purged
+// unless this passes.
+func verifyExport(dir string, manifest *OffboardManifest) (string, error) {
+	for _, f := range append(manifest.Files, OffboardFile{Name: manifestFile}) {
+		sum, size, err := hashFile(filepath.Join(dir, f.Name))
+		if err != nil {
+			return "", err
+		}
+		if f.Name == manifestFile {
+			return sum, nil
+		}
+		if sum != f.SHA256 || size != f.Bytes {
+			return "", fmt.Errorf("%s does not match the manifest", f.Name)
+		}
+	}
+	return "", nil
+}
+
+func hashFile(path string) (string, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	n, err := io.Copy(hash, f)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), n, nil
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/cache"
+	"Distributed-Health-Monitoring/clock"
+	"Distributed-Health-Monitoring/models"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type uptimeWindow struct {
+	Label    string
+	Duration time.Duration
+}
+
+// uptimeWindows are the periods reported by the overview endpoint
+var uptimeWindows = []uptimeWindow{
+	{"24h", 24 * time.Hour},
+	{"7d", 7 * 24 * time.Hour},
+	{"30d", 30 * 24 * time.Hour},
+}
+
+const overviewRecentChecks = 10
+
+// GetServiceOverview aggregates everything a service detail page needs in one call
+func (e *Engine) GetServiceOverview(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "invalid service id"})
+		return
+	}
+
+	service, ok := e.serviceByID(c, uint(id))
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	now := clock.Now()
+
+	inMaintenance, err := e.Repo.ServicesInMaintenance(ctx, now)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	recent, err := e.Repo.GetServiceCheckLogs(ctx, service.ID, overviewRecentChecks, 0)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	incident, err := e.Repo.GetOpenIncident(ctx, service.ID)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	presented := presentService(service, inMaintenance[service.ID])
+	if presented.Status == "DOWN" {
+		presented.RootCause = rootCause(&presented, servicesByName(cache.Services.Snapshot()))
+	}
+
+	uptime := make(map[string]models.UptimeStat, len(uptimeWindows))
+	for _, w := range uptimeWindows {
+		stat, err := e.Repo.GetUptime(ctx, service.ID, now.Add(-w.Duration))
+		if err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+		uptime[w.Label] = stat
+	}
+
+	c.JSON(200, gin.H{
+		"service": presented,
+		"state": gin.H{
+			"status":               service.Status,
+			"consecutive_failures": service.ConsecutiveFailures,
+			"last_checked_at":      service.LastCheckedAt,
+			"flapping":             service.Flapping,
+			"in_maintenance":       inMaintenance[service.ID],
+			"check_in_flight":      service.InFlightUntil != nil && now.Before(*service.InFlightUntil),
+		},
+		"recent_checks": recent,
+		"open_incident": incident,
+		"uptime":        uptime,
+		"next_check_at": nextCheckAt(service, now),
+	})
+}
+
+// nextCheckAt estimates when the scheduler will next publish a job for the service
+func nextCheckAt(s *models.ExternalService, now time.Time) time.Time {
+	next := dueAt(s, now)
+	if next.Before(now) {
+		next = now
+	}
+	return next
+}
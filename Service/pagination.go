@@ -0,0 +1,162 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/apiv1"
+	"Distributed-Health-Monitoring/logstore"
+	"Distributed-Health-Monitoring/models"
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// pageParams reads ?limit= and ?offset=. The limit defaults to
+// logstore.DefaultLimit and is capped like filtered log queries.
+func pageParams(c *gin.Context) (int, int) {
+	limit := queryLimit(c, logstore.DefaultLimit)
+	if limit > logstore.MaxQueryLimit {
+		limit = logstore.MaxQueryLimit
+	}
+	offset := 0
+	if o, err := strconv.Atoi(c.Query("offset")); err == nil && o >= 0 {
+		offset = o
+	}
+	return limit, offset
+}
+
+// queryList splits repeated and comma-separated values: ?status=DOWN,DEGRADED&status=UP
+func queryList(c *gin.Context, key string) []string {
+	var out []string
+	for _, v := range c.QueryArray(key) {
+		for _, part := range strings.Split(v, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				out = append(out, part)
+			}
+		}
+	}
+	return out
+}
+
+// serviceSortKeys are the fields ?sort= accepts; a leading "-" sorts descending
+var serviceSortKeys = map[string]func(a, b *models.ExternalService) int{
+	"id":     func(a, b *models.ExternalService) int { return cmp.Compare(a.ID, b.ID) },
+	"name":   func(a, b *models.ExternalService) int { return strings.Compare(a.Name, b.Name) },
+	"status": func(a, b *models.ExternalService) int { return strings.Compare(a.Status, b.Status) },
+	"last_checked_at": func(a, b *models.ExternalService) int {
+		return compareTimes(a.LastCheckedAt, b.LastCheckedAt)
+	},
+	"created_at": func(a, b *models.ExternalService) int { return a.CreatedAt.Compare(b.CreatedAt) },
+}
+
+// compareTimes orders never-set times first
+func compareTimes(a, b *time.Time) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+	return a.Compare(*b)
+}
+
+// serviceListQuery is the filter and order of the service list endpoints
+type serviceListQuery struct {
+	statuses []string
+	tag      string
+	team     string
+	sortKey  string
+	desc     bool
+	limit    int
+	offset   int
+}
+
+func parseServiceListQuery(c *gin.Context) (serviceListQuery, error) {
+	q := serviceListQuery{tag: c.Query("tag"), team: c.Query("team"), sortKey: "id"}
+	for _, s := range queryList(c, "status") {
+		q.statuses = append(q.statuses, strings.ToUpper(s))
+	}
+
+	if sortBy := c.Query("sort"); sortBy != "" {
+		q.desc = strings.HasPrefix(sortBy, "-")
+		q.sortKey = strings.TrimPrefix(sortBy, "-")
+		if _, ok := serviceSortKeys[q.sortKey]; !ok {
+			return q, errors.New("sort must be one of id, name, status, last_checked_at, created_at, optionally prefixed with -")
+		}
+	}
+
+	q.limit, q.offset = pageParams(c)
+	return q, nil
+}
+
+// apply filters, sorts and pages the services. Status matches what the
+// caller sees, so ?status=MAINTENANCE finds services in a window.
+func (q serviceListQuery) apply(services map[uint]models.ExternalService) ([]models.ExternalService, apiv1.Page) {
+	matched := make([]models.ExternalService, 0, len(services))
+	for _, s := range services {
+		if q.tag != "" && !s.HasTag(q.tag) {
+			continue
+		}
+		if q.team != "" && s.Team != q.team {
+			continue
+		}
+		if len(q.statuses) > 0 && !slices.Contains(q.statuses, s.Status) {
+			continue
+		}
+		matched = append(matched, s)
+	}
+
+	compare := serviceSortKeys[q.sortKey]
+	sort.Slice(matched, func(i, j int) bool {
+		c := compare(&matched[i], &matched[j])
+		if c == 0 {
+			// Equal keys keep a stable order across pages
+			return matched[i].ID < matched[j].ID
+		}
+		if q.desc {
+			return c > 0
+		}
+		return c < 0
+	})
+
+	page := apiv1.NewPage(int64(len(matched)), q.limit, q.offset)
+	if q.offset >= len(matched) {
+		return []models.ExternalService{}, page
+	}
+	end := min(q.offset+q.limit, len(matched))
+	return matched[q.offset:end], page
+}
+
+// checkLogListFilter builds the filter of the per-service log endpoints
+// from ?status=, ?from= and ?to= (RFC 3339) and the page parameters
+func checkLogListFilter(c *gin.Context, serviceID uint) (models.CheckLogFilter, error) {
+	filter := models.CheckLogFilter{ServiceIDs: []uint{serviceID}}
+	for _, s := range queryList(c, "status") {
+		filter.Statuses = append(filter.Statuses, strings.ToUpper(s))
+	}
+
+	for key, dst := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		raw := c.Query(key)
+		if raw == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return filter, fmt.Errorf("%s must be an RFC 3339 time", key)
+		}
+		*dst = &t
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return filter, errors.New("from must be before to")
+	}
+
+	filter.Limit, filter.Offset = pageParams(c)
+	return filter, nil
+}
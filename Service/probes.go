@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultSchedulerStaleSeconds = 30
+	defaultMaxWorkerLagSeconds   = 60
+	defaultProbeTimeoutSeconds   = 2
+
+	// workerLagWindow is how long an observed lag stays relevant; an idle
+	// worker isn't held to the lag of a job it ran an hour ago
+	workerLagWindow = 5 * time.Minute
+)
+
+// ProbeCheck is the result for one dependency
+type ProbeCheck struct {
+	Status string `json:"status"` // ok, fail or skipped
+	Detail string `json:"detail,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+type ProbeResult struct {
+	Status    string                `json:"status"` // ok or fail
+	Checks    map[string]ProbeCheck `json:"checks"`
+	CheckedAt time.Time             `json:"checked_at"`
+}
+
+// runtimeHealth is what the background loops report about themselves
+type runtimeHealth struct {
+	startedAt time.Time
+
+	mu         sync.Mutex
+	lastTick   time.Time
+	lastJobAt  time.Time
+	lastJobLag time.Duration
+	publisher  Queue // the scheduler's connection, nil until it connects
+	consumer   Queue // the worker's connection
+}
+
+func (h *runtimeHealth) tick(at time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastTick = at
+}
+
+// jobStarted records how long a job waited between scheduling and the worker picking it up
+func (h *runtimeHealth) jobStarted(scheduledAt time.Time) {
+	if scheduledAt.IsZero() {
+		return
+	}
+	now := time.Now()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastJobAt = now
+	h.lastJobLag = now.Sub(scheduledAt)
+}
+
+func (h *runtimeHealth) setPublisher(s Queue) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.publisher = s
+}
+
+func (h *runtimeHealth) setConsumer(s Queue) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.consumer = s
+}
+
+// Healthz is the liveness probe: it fails only when this process is stuck,
+// so orchestrators don't restart replicas over an outside outage
+func (e *Engine) Healthz(c *gin.Context) {
+	checks := map[string]ProbeCheck{
+		"scheduler": e.probeScheduler(),
+	}
+	respondProbe(c, checks)
+}
+
+// Readyz is the readiness probe: this replica can serve and do its share of
+// checks. Every dependency is reported even when an earlier one failed.
+func (e *Engine) Readyz(c *gin.Context) {
+	timeout := time.Duration(e.Cnfg.Probes.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = defaultProbeTimeoutSeconds * time.Second
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+	defer cancel()
+
+	checks := map[string]ProbeCheck{
+		"database":  e.probeDatabase(ctx),
+		"queue":     e.probeQueue(),
+		"scheduler": e.probeScheduler(),
+		"worker":    e.probeWorker(),
+	}
+	respondProbe(c, checks)
+}
+
+func respondProbe(c *gin.Context, checks map[string]ProbeCheck) {
+	result := ProbeResult{Status: "ok", Checks: checks, CheckedAt: time.Now()}
+	for _, check := range checks {
+		if check.Status == "fail" {
+			result.Status = "fail"
+		}
+	}
+
+	status := 200
+	if result.Status != "ok" {
+		status = 503
+	}
+	c.JSON(status, result)
+}
+
+func (e *Engine) probeDatabase(ctx context.Context) ProbeCheck {
+	start := time.Now()
+	if err := e.Repo.Ping(ctx); err != nil {
+		return ProbeCheck{Status: "fail", Error: err.Error()}
+	}
+	return ProbeCheck{Status: "ok", Detail: fmt.Sprintf("%s ping %dms", e.Cnfg.Database.DriverName(), time.Since(start).Milliseconds())}
+}
+
+func (e *Engine) probeQueue() ProbeCheck {
+	e.health.mu.Lock()
+	publisher, consumer := e.health.publisher, e.health.consumer
+	e.health.mu.Unlock()
+
+	if consumer == nil {
+		return ProbeCheck{Status: "fail", Error: "worker is not connected"}
+	}
+	if err := consumer.Err(); err != nil {
+		return ProbeCheck{Status: "fail", Error: "worker: " + err.Error()}
+	}
+	// Only the leader publishes, so a missing publisher is normal on followers
+	if publisher != nil {
+		if err := publisher.Err(); err != nil {
+			return ProbeCheck{Status: "fail", Error: "scheduler: " + err.Error()}
+		}
+	}
+	return ProbeCheck{Status: "ok", Detail: e.Cnfg.Queue.DriverName()}
+}
+
+func (e *Engine) probeScheduler() ProbeCheck {
+	stale := time.Duration(e.Cnfg.Probes.SchedulerStaleSeconds) * time.Second
+	if stale <= 0 {
+		stale = defaultSchedulerStaleSeconds * time.Second
+	}
+
+	e.health.mu.Lock()
+	lastTick := e.health.lastTick
+	e.health.mu.Unlock()
+
+	// The first tick comes SchedulerTick after startup
+	since := e.health.startedAt
+	if !lastTick.IsZero() {
+		since = lastTick
+	}
+	age := time.Since(since).Truncate(time.Millisecond)
+
+	if age > stale {
+		if lastTick.IsZero() {
+			return ProbeCheck{Status: "fail", Error: fmt.Sprintf("no tick since startup %s ago", age)}
+		}
+		return ProbeCheck{Status: "fail", Error: fmt.Sprintf("last tick %s ago", age)}
+	}
+	if lastTick.IsZero() {
+		return ProbeCheck{Status: "ok", Detail: "starting"}
+	}
+	return ProbeCheck{Status: "ok", Detail: fmt.Sprintf("last tick %s ago", age)}
+}
+
+func (e *Engine) probeWorker() ProbeCheck {
+	maxLag := time.Duration(e.Cnfg.Probes.MaxWorkerLagSeconds) * time.Second
+	if maxLag <= 0 {
+		maxLag = defaultMaxWorkerLagSeconds * time.Second
+	}
+
+	e.health.mu.Lock()
+	lastJobAt, lag := e.health.lastJobAt, e.health.lastJobLag
+	e.health.mu.Unlock()
+
+	if lastJobAt.IsZero() || time.Since(lastJobAt) > workerLagWindow {
+		return ProbeCheck{Status: "ok", Detail: "idle"}
+	}
+
+	lag = lag.Truncate(time.Millisecond)
+	if lag > maxLag {
+		return ProbeCheck{Status: "fail", Error: fmt.Sprintf("jobs wait %s before starting, limit %s", lag, maxLag)}
+	}
+	return ProbeCheck{Status: "ok", Detail: fmt.Sprintf("lag %s", lag)}
+}
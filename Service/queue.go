@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"fmt"
+)
+
+// Queue carries health check jobs from the scheduler to the workers. The
+// broker is picked by queue.driver: RabbitMQ, Redis Streams for
+// environments that already run Redis, or memory for a single process.
+type Queue interface {
+	Publish(ctx context.Context, body []byte) error
+	// Consume hands jobs to handle until ctx ends or the broker connection
+	// is lost; broker drivers handle one job at a time
+	Consume(ctx context.Context, handle func(Delivery)) error
+	// Pending reports how many published jobs are waiting for a worker;
+	// jobs a worker has received but not yet acknowledged are not included
+	Pending(ctx context.Context) (int, error)
+	// Err reports a lost connection, nil while connected
+	Err() error
+	Close()
+}
+
+// Delivery is one job received from a Queue
+type Delivery interface {
+	Body() []byte
+	Ack() error
+	// Reject moves the job to the dead letters
+	Reject() error
+}
+
+// DeadLetterQueue is implemented by queues that keep rejected jobs
+type DeadLetterQueue interface {
+	// DeadLetters peeks at up to limit rejected jobs without removing them
+	DeadLetters(ctx context.Context, limit int) (name string, depth int, letters []DeadLetter, err error)
+	// ReplayDeadLetters moves up to limit rejected jobs back onto the job queue
+	ReplayDeadLetters(ctx context.Context, limit int) (int, error)
+}
+
+// DeletableQueue is implemented by queues that can remove what they
+// declared on the broker, for queues that only live as long as a bench run
+type DeletableQueue interface {
+	// Delete removes the queue and its dead letters, with any jobs left in them
+	Delete(ctx context.Context) error
+}
+
+// openQueue connects to the configured broker. With a region it opens that
+// region's job queue, which only workers of the region consume.
+func (e *Engine) openQueue(region string) (Queue, error) {
+	switch driver := e.Cnfg.Queue.DriverName(); driver {
+	case "rabbitmq":
+		cfg := e.Cnfg.RabbitMQ
+		if region != "" {
+			cfg.QueueName += "." + region
+		}
+		return openAMQPQueue(e.AMQPURL(), cfg)
+	case "redis":
+		cfg := e.Cnfg.Queue.Redis
+		if region != "" {
+			if cfg.Stream == "" {
+				cfg.Stream = defaultRedisStream
+			}
+			cfg.Stream += "." + region
+		}
+		return openRedisQueue(context.Background(), cfg, e.Cnfg.HA.Instance())
+	case "memory":
+		// The only worker is this process, so it can only serve its own region
+		if region != "" && region != e.Cnfg.Worker.Region {
+			return nil, fmt.Errorf("queue.driver memory has no worker in region %q", region)
+		}
+		return e.memQueue, nil
+	default:
+		return nil, fmt.Errorf("unknown queue driver %q", driver)
+	}
+}
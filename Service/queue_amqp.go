@@ -0,0 +1,301 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/config"
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+// amqpQueue is the RabbitMQ driver. Rejected jobs are published to a
+// dead-letter exchange, which routes them into a queue of their own.
+type amqpQueue struct {
+	cfg      config.RabbitMQ
+	conn     *amqp.Connection
+	ch       *amqp.Channel
+	chClosed atomic.Bool
+}
+
+func openAMQPQueue(url string, cfg config.RabbitMQ) (*amqpQueue, error) {
+	conn, err := amqp.Dial(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to RabbitMQ: %w", err)
+	}
+
+	ch, err := DeclareQueues(conn, cfg)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	q := &amqpQueue{cfg: cfg, conn: conn, ch: ch}
+	// Channels can be closed by the broker while the connection stays up, so both are tracked
+	closed := ch.NotifyClose(make(chan *amqp.Error, 1))
+	go func() {
+		for range closed {
+		}
+		q.chClosed.Store(true)
+	}()
+	return q, nil
+}
+
+// DeclareQueues declares the job queue together with its dead-letter exchange
+// and queue, so jobs the worker rejects are kept for inspection and replay,
+// and returns the channel to use.
+//
+// An existing job queue is used as it is. RabbitMQ closes the channel with
+// PRECONDITION_FAILED when a queue is redeclared with other arguments, and
+// queues declared by earlier versions carry none, or x-dead-letter-* ones.
+// The queue therefore isn't what dead-letters a job: the worker publishes a
+// rejected job to the exchange itself.
+func DeclareQueues(conn *amqp.Connection, rbtCnfg config.RabbitMQ) (*amqp.Channel, error) {
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open channel: %w", err)
+	}
+	if err := declareDeadLetters(ch, rbtCnfg); err != nil {
+		return nil, err
+	}
+
+	if _, err := ch.QueueDeclarePassive(rbtCnfg.QueueName, true, false, false, false, nil); err == nil {
+		return ch, nil
+	}
+
+	// The queue doesn't exist yet, and the failed passive declare closed the channel
+	ch, err = conn.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open channel: %w", err)
+	}
+	if _, err := ch.QueueDeclare(
+		rbtCnfg.QueueName,
+		true,  // durable
+		false, // autoDelete
+		false, // exclusive
+		false, // noWait
+		nil,   // args
+	); err != nil {
+		return nil, fmt.Errorf("failed to declare queue: %w", err)
+	}
+
+	return ch, nil
+}
+
+func declareDeadLetters(ch *amqp.Channel, rbtCnfg config.RabbitMQ) error {
+	dlx, dlq := rbtCnfg.DeadLetterNames()
+
+	if err := ch.ExchangeDeclare(
+		dlx,
+		"direct",
+		true,  // durable
+		false, // autoDelete
+		false, // internal
+		false, // noWait
+		nil,   // args
+	); err != nil {
+		return fmt.Errorf("failed to declare dead-letter exchange: %w", err)
+	}
+
+	if _, err := ch.QueueDeclare(
+		dlq,
+		true,  // durable
+		false, // autoDelete
+		false, // exclusive
+		false, // noWait
+		nil,   // args
+	); err != nil {
+		return fmt.Errorf("failed to declare dead-letter queue: %w", err)
+	}
+
+	if err := ch.QueueBind(dlq, rbtCnfg.QueueName, dlx, false, nil); err != nil {
+		return fmt.Errorf("failed to bind dead-letter queue: %w", err)
+	}
+
+	return nil
+}
+
+func (q *amqpQueue) Publish(ctx context.Context, body []byte) error {
+	return q.ch.Publish(
+		"",
+		q.cfg.QueueName,
+		false,
+		false,
+		amqp.Publishing{
+			ContentType: "application/json",
+			Body:        body,
+			Timestamp:   time.Now(),
+		},
+	)
+}
+
+func (q *amqpQueue) Consume(ctx context.Context, handle func(Delivery)) error {
+	msgs, err := q.ch.Consume(
+		q.cfg.QueueName,
+		"",
+		false,
+		false,
+		false,
+		false,
+		nil,
+	)
+	if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case msg, ok := <-msgs:
+			if !ok {
+				return nil
+			}
+			handle(amqpDelivery{msg: msg, q: q})
+		}
+	}
+}
+
+func (q *amqpQueue) Pending(ctx context.Context) (int, error) {
+	inspected, err := q.ch.QueueInspect(q.cfg.QueueName)
+	if err != nil {
+		return 0, fmt.Errorf("failed to inspect queue: %w", err)
+	}
+	return inspected.Messages, nil
+}
+
+func (q *amqpQueue) Err() error {
+	switch {
+	case q.conn.IsClosed():
+		return errors.New("connection closed")
+	case q.chClosed.Load():
+		return errors.New("channel closed")
+	}
+	return nil
+}
+
+func (q *amqpQueue) Delete(ctx context.Context) error {
+	dlx, dlq := q.cfg.DeadLetterNames()
+	if _, err := q.ch.QueueDelete(q.cfg.QueueName, false, false, false); err != nil {
+		return fmt.Errorf("failed to delete queue: %w", err)
+	}
+	if _, err := q.ch.QueueDelete(dlq, false, false, false); err != nil {
+		return fmt.Errorf("failed to delete dead-letter queue: %w", err)
+	}
+	if err := q.ch.ExchangeDelete(dlx, false, false); err != nil {
+		return fmt.Errorf("failed to delete dead-letter exchange: %w", err)
+	}
+	return nil
+}
+
+func (q *amqpQueue) Close() {
+	q.ch.Close()
+	q.conn.Close()
+}
+
+// deadLetterHeader describes why a job was dead-lettered. Jobs published to
+// the exchange by the worker carry it instead of the broker's x-death.
+const deadLetterHeader = "x-dhm-dead-letter"
+
+type amqpDelivery struct {
+	msg amqp.Delivery
+	q   *amqpQueue
+}
+
+func (d amqpDelivery) Body() []byte { return d.msg.Body }
+func (d amqpDelivery) Ack() error   { return d.msg.Ack(false) }
+
+// Reject publishes the job to the dead-letter exchange and acknowledges it.
+// When that publish fails the job is nacked, which a queue declared with
+// x-dead-letter-* arguments still dead-letters.
+func (d amqpDelivery) Reject() error {
+	dlx, _ := d.q.cfg.DeadLetterNames()
+	if err := d.q.ch.Publish(dlx, d.q.cfg.QueueName, false, false, amqp.Publishing{
+		ContentType: d.msg.ContentType,
+		Body:        d.msg.Body,
+		Timestamp:   d.msg.Timestamp,
+		Headers: amqp.Table{deadLetterHeader: amqp.Table{
+			"reason": "rejected",
+			"queue":  d.q.cfg.QueueName,
+			"count":  int64(1),
+			"time":   time.Now(),
+		}},
+	}); err != nil {
+		return d.msg.Nack(false, false)
+	}
+	return d.msg.Ack(false)
+}
+
+// DeadLetters fetches messages unacknowledged, so they return to the
+// dead-letter queue when the channel closes
+func (q *amqpQueue) DeadLetters(ctx context.Context, limit int) (string, int, []DeadLetter, error) {
+	_, dlq := q.cfg.DeadLetterNames()
+	letters := make([]DeadLetter, 0)
+
+	inspected, err := q.ch.QueueInspect(dlq)
+	if err != nil {
+		return dlq, 0, nil, err
+	}
+
+	for len(letters) < limit {
+		msg, ok, err := q.ch.Get(dlq, false)
+		if err != nil {
+			return dlq, 0, nil, err
+		}
+		if !ok {
+			break
+		}
+		letters = append(letters, newAMQPDeadLetter(msg))
+	}
+	return dlq, inspected.Messages, letters, nil
+}
+
+func (q *amqpQueue) ReplayDeadLetters(ctx context.Context, limit int) (int, error) {
+	_, dlq := q.cfg.DeadLetterNames()
+
+	replayed := 0
+	for replayed < limit {
+		msg, ok, err := q.ch.Get(dlq, false)
+		if err != nil {
+			return replayed, err
+		}
+		if !ok {
+			return replayed, nil
+		}
+
+		if err := q.ch.Publish("", q.cfg.QueueName, false, false, amqp.Publishing{
+			ContentType: msg.ContentType,
+			Body:        msg.Body,
+			Timestamp:   time.Now(),
+		}); err != nil {
+			msg.Nack(false, true)
+			return replayed, fmt.Errorf("failed to republish job: %w", err)
+		}
+
+		if err := msg.Ack(false); err != nil {
+			return replayed, err
+		}
+		replayed++
+	}
+	return replayed, nil
+}
+
+func newAMQPDeadLetter(msg amqp.Delivery) DeadLetter {
+	letter := newDeadLetter(msg.Body, msg.Timestamp)
+
+	// RabbitMQ records each dead-lettering in the x-death header, newest
+	// first; jobs the worker dead-lettered carry the same fields in its own
+	death, _ := msg.Headers[deadLetterHeader].(amqp.Table)
+	if deaths, ok := msg.Headers["x-death"].([]interface{}); ok && len(deaths) > 0 {
+		death, _ = deaths[0].(amqp.Table)
+	}
+	if death != nil {
+		letter.Reason, _ = death["reason"].(string)
+		letter.Queue, _ = death["queue"].(string)
+		letter.DeathCount, _ = death["count"].(int64)
+	}
+
+	return letter
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/config"
+	"Distributed-Health-Monitoring/logging"
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMemoryWorkers   = 10
+	defaultMemoryQueueSize = 1000
+	// maxMemoryDeadLetters bounds the rejected jobs kept; the oldest are dropped
+	maxMemoryDeadLetters = 1000
+)
+
+var errMemoryQueueFull = errors.New("memory job queue is full")
+
+// memoryQueue is the in-process driver: jobs go through a buffered channel to
+// a pool of worker goroutines in the same process, so a single binary and
+// the database are all that's needed. Queued jobs are lost on restart; the
+// scheduler publishes them again once they are due.
+type memoryQueue struct {
+	jobs    chan memoryJob
+	workers int
+
+	mu   sync.Mutex
+	dead []DeadLetter
+}
+
+func newMemoryQueue(cfg config.MemoryQueue) *memoryQueue {
+	workers, size := cfg.Workers, cfg.QueueSize
+	if workers <= 0 {
+		workers = defaultMemoryWorkers
+	}
+	if size <= 0 {
+		size = defaultMemoryQueueSize
+	}
+	return &memoryQueue{jobs: make(chan memoryJob, size), workers: workers}
+}
+
+type memoryJob struct {
+	body        []byte
+	publishedAt time.Time
+}
+
+// Publish never blocks the scheduler tick; a full buffer fails the job,
+// which is retried on a later tick
+func (q *memoryQueue) Publish(ctx context.Context, body []byte) error {
+	select {
+	case q.jobs <- memoryJob{body: body, publishedAt: time.Now()}:
+		return nil
+	default:
+		return errMemoryQueueFull
+	}
+}
+
+// Consume runs the worker pool, so unlike the broker drivers several jobs
+// are handled at once
+func (q *memoryQueue) Consume(ctx context.Context, handle func(Delivery)) error {
+	logging.For(ctx, "worker").Info("memory_pool_started", "workers", q.workers, "queue_size", cap(q.jobs))
+
+	var wg sync.WaitGroup
+	for i := 0; i < q.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case job := <-q.jobs:
+					handle(&memoryDelivery{queue: q, job: job})
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+func (q *memoryQueue) Pending(ctx context.Context) (int, error) {
+	return len(q.jobs), nil
+}
+
+func (q *memoryQueue) Err() error {
+	return nil
+}
+
+// Close is a no-op: the scheduler and the workers share the queue for the
+// life of the process
+func (q *memoryQueue) Close() {}
+
+func (q *memoryQueue) DeadLetters(ctx context.Context, limit int) (string, int, []DeadLetter, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	n := len(q.dead)
+	if limit < n {
+		n = limit
+	}
+	return "memory", len(q.dead), append([]DeadLetter{}, q.dead[:n]...), nil
+}
+
+func (q *memoryQueue) ReplayDeadLetters(ctx context.Context, limit int) (int, error) {
+	replayed := 0
+	for replayed < limit {
+		q.mu.Lock()
+		if len(q.dead) == 0 {
+			q.mu.Unlock()
+			return replayed, nil
+		}
+		letter := q.dead[0]
+		q.dead = q.dead[1:]
+		q.mu.Unlock()
+
+		body := []byte(letter.Job)
+		if body == nil {
+			body = []byte(letter.RawBody)
+		}
+		if err := q.Publish(ctx, body); err != nil {
+			q.mu.Lock()
+			q.dead = append([]DeadLetter{letter}, q.dead...)
+			q.mu.Unlock()
+			return replayed, err
+		}
+		replayed++
+	}
+	return replayed, nil
+}
+
+type memoryDelivery struct {
+	queue *memoryQueue
+	job   memoryJob
+}
+
+func (d *memoryDelivery) Body() []byte { return d.job.body }
+func (d *memoryDelivery) Ack() error   { return nil }
+
+func (d *memoryDelivery) Reject() error {
+	letter := newDeadLetter(d.job.body, d.job.publishedAt)
+	letter.Reason = "rejected"
+	letter.Queue = "memory"
+	letter.DeathCount = 1
+
+	q := d.queue
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.dead = append(q.dead, letter)
+	if len(q.dead) > maxMemoryDeadLetters {
+		q.dead = q.dead[len(q.dead)-maxMemoryDeadLetters:]
+	}
+	return nil
+}
@@ -0,0 +1,312 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/config"
+	"Distributed-Health-Monitoring/logging"
+	"Distributed-Health-Monitoring/redis"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultRedisStream        = "health_checks"
+	defaultRedisGroup         = "workers"
+	defaultRedisClaimIdle     = 60 * time.Second
+	defaultRedisMaxDeliveries = 3
+
+	redisReadBlock   = 5 * time.Second
+	redisReadCount   = 10
+	redisCallTimeout = 5 * time.Second
+)
+
+// redisQueue is the Redis Streams driver (Redis 6.2 or later). Workers share
+// a consumer group; acknowledged jobs are deleted from the stream, so its
+// length is the outstanding work. Jobs left unacknowledged by a crashed
+// worker are claimed by another one after claim_idle_seconds, and
+// dead-lettered once they have been delivered max_deliveries times.
+type redisQueue struct {
+	client        *redis.Client
+	stream        string
+	group         string
+	dead          string
+	consumer      string
+	claimIdle     time.Duration
+	maxDeliveries int64
+
+	mu      sync.Mutex
+	lastErr error // the last connection error seen by Consume
+}
+
+func openRedisQueue(ctx context.Context, cfg config.RedisQueue, consumer string) (*redisQueue, error) {
+	q := &redisQueue{
+		client:        redis.NewClient(cfg.Address, cfg.Password, cfg.DB),
+		stream:        cfg.Stream,
+		group:         cfg.Group,
+		dead:          cfg.DeadStream,
+		consumer:      consumer,
+		claimIdle:     time.Duration(cfg.ClaimIdleSeconds) * time.Second,
+		maxDeliveries: int64(cfg.MaxDeliveries),
+	}
+	if q.stream == "" {
+		q.stream = defaultRedisStream
+	}
+	if q.group == "" {
+		q.group = defaultRedisGroup
+	}
+	if q.dead == "" {
+		q.dead = q.stream + ".dead"
+	}
+	if q.claimIdle <= 0 {
+		q.claimIdle = defaultRedisClaimIdle
+	}
+	if q.maxDeliveries <= 0 {
+		q.maxDeliveries = defaultRedisMaxDeliveries
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, redisCallTimeout)
+	defer cancel()
+	_, err := q.client.Do(ctx, "XGROUP", "CREATE", q.stream, q.group, "0", "MKSTREAM")
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		q.client.Close()
+		return nil, err
+	}
+	return q, nil
+}
+
+func (q *redisQueue) Publish(ctx context.Context, body []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, redisCallTimeout)
+	defer cancel()
+	_, err := q.client.Do(ctx, "XADD", q.stream, "*", "job", body)
+	return err
+}
+
+func (q *redisQueue) Consume(ctx context.Context, handle func(Delivery)) error {
+	nextClaim := time.Now()
+	for ctx.Err() == nil {
+		if time.Now().After(nextClaim) {
+			if err := q.claimStale(ctx, handle); err != nil {
+				q.setErr(err)
+				return err
+			}
+			nextClaim = time.Now().Add(q.claimIdle / 2)
+		}
+
+		readCtx, cancel := context.WithTimeout(ctx, redisReadBlock+redisCallTimeout)
+		reply, err := redis.Values(q.client.Do(readCtx, "XREADGROUP", "GROUP", q.group, q.consumer,
+			"COUNT", redisReadCount, "BLOCK", redisReadBlock.Milliseconds(), "STREAMS", q.stream, ">"))
+		cancel()
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			q.setErr(err)
+			return err
+		}
+		q.setErr(nil)
+
+		// The reply holds one [stream, entries] pair; a timeout is a null reply
+		for _, item := range reply {
+			pair, ok := item.([]interface{})
+			if !ok || len(pair) != 2 {
+				continue
+			}
+			entries, err := redis.Entries(pair[1], nil)
+			if err != nil {
+				return err
+			}
+			for _, entry := range entries {
+				handle(&redisDelivery{queue: q, entry: entry})
+			}
+		}
+	}
+	return ctx.Err()
+}
+
+// claimStale takes over jobs other workers received but never acknowledged
+func (q *redisQueue) claimStale(ctx context.Context, handle func(Delivery)) error {
+	callCtx, cancel := context.WithTimeout(ctx, redisCallTimeout)
+	defer cancel()
+
+	pending, err := redis.Values(q.client.Do(callCtx, "XPENDING", q.stream, q.group,
+		"IDLE", q.claimIdle.Milliseconds(), "-", "+", redisReadCount))
+	if err != nil {
+		return err
+	}
+
+	deliveries := make(map[string]int64, len(pending))
+	ids := make([]interface{}, 0, len(pending))
+	for _, item := range pending {
+		// [id, consumer, idle ms, delivery count]
+		fields, ok := item.([]interface{})
+		if !ok || len(fields) != 4 {
+			continue
+		}
+		id, _ := fields[0].(string)
+		count, _ := fields[3].(int64)
+		deliveries[id] = count
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	args := append([]interface{}{"XCLAIM", q.stream, q.group, q.consumer, q.claimIdle.Milliseconds()}, ids...)
+	entries, err := redis.Entries(q.client.Do(callCtx, args...))
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		d := &redisDelivery{queue: q, entry: entry}
+		if deliveries[entry.ID] >= q.maxDeliveries {
+			logging.For(ctx, "worker").Warn("job_dead_lettered", "id", entry.ID, "deliveries", deliveries[entry.ID])
+			if err := d.deadLetter("max_deliveries", deliveries[entry.ID]); err != nil {
+				return err
+			}
+			continue
+		}
+		handle(d)
+	}
+	return nil
+}
+
+// Pending is the stream length minus the jobs delivered but not yet acknowledged
+func (q *redisQueue) Pending(ctx context.Context) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, redisCallTimeout)
+	defer cancel()
+
+	length, err := redis.Int(q.client.Do(ctx, "XLEN", q.stream))
+	if err != nil {
+		return 0, err
+	}
+	summary, err := redis.Values(q.client.Do(ctx, "XPENDING", q.stream, q.group))
+	if err != nil {
+		return 0, err
+	}
+	var delivered int64
+	if len(summary) > 0 {
+		delivered, _ = summary[0].(int64)
+	}
+	if length < delivered {
+		return 0, nil
+	}
+	return int(length - delivered), nil
+}
+
+func (q *redisQueue) Err() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.lastErr
+}
+
+func (q *redisQueue) setErr(err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.lastErr = err
+}
+
+func (q *redisQueue) Delete(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, redisCallTimeout)
+	defer cancel()
+	_, err := q.client.Do(ctx, "DEL", q.stream, q.dead)
+	return err
+}
+
+func (q *redisQueue) Close() {
+	q.client.Close()
+}
+
+type redisDelivery struct {
+	queue *redisQueue
+	entry redis.StreamEntry
+}
+
+func (d *redisDelivery) Body() []byte { return []byte(d.entry.Fields["job"]) }
+
+// Ack deletes the entry as well, keeping the stream down to outstanding jobs
+func (d *redisDelivery) Ack() error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisCallTimeout)
+	defer cancel()
+	if _, err := d.queue.client.Do(ctx, "XACK", d.queue.stream, d.queue.group, d.entry.ID); err != nil {
+		return err
+	}
+	_, err := d.queue.client.Do(ctx, "XDEL", d.queue.stream, d.entry.ID)
+	return err
+}
+
+func (d *redisDelivery) Reject() error {
+	return d.deadLetter("rejected", 1)
+}
+
+func (d *redisDelivery) deadLetter(reason string, deliveries int64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisCallTimeout)
+	defer cancel()
+	if _, err := d.queue.client.Do(ctx, "XADD", d.queue.dead, "*",
+		"job", d.entry.Fields["job"], "reason", reason, "queue", d.queue.stream,
+		"deliveries", deliveries, "published_at", streamIDTime(d.entry.ID).Format(time.RFC3339Nano)); err != nil {
+		return err
+	}
+	return d.Ack()
+}
+
+func (q *redisQueue) DeadLetters(ctx context.Context, limit int) (string, int, []DeadLetter, error) {
+	ctx, cancel := context.WithTimeout(ctx, redisCallTimeout)
+	defer cancel()
+
+	depth, err := redis.Int(q.client.Do(ctx, "XLEN", q.dead))
+	if err != nil {
+		return q.dead, 0, nil, err
+	}
+	entries, err := redis.Entries(q.client.Do(ctx, "XRANGE", q.dead, "-", "+", "COUNT", limit))
+	if err != nil {
+		return q.dead, 0, nil, err
+	}
+
+	letters := make([]DeadLetter, 0, len(entries))
+	for _, entry := range entries {
+		publishedAt, err := time.Parse(time.RFC3339Nano, entry.Fields["published_at"])
+		if err != nil {
+			publishedAt = streamIDTime(entry.ID)
+		}
+		letter := newDeadLetter([]byte(entry.Fields["job"]), publishedAt)
+		letter.Reason = entry.Fields["reason"]
+		letter.Queue = entry.Fields["queue"]
+		letter.DeathCount, _ = strconv.ParseInt(entry.Fields["deliveries"], 10, 64)
+		letters = append(letters, letter)
+	}
+	return q.dead, int(depth), letters, nil
+}
+
+func (q *redisQueue) ReplayDeadLetters(ctx context.Context, limit int) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, redisCallTimeout)
+	defer cancel()
+
+	entries, err := redis.Entries(q.client.Do(ctx, "XRANGE", q.dead, "-", "+", "COUNT", limit))
+	if err != nil {
+		return 0, err
+	}
+
+	replayed := 0
+	for _, entry := range entries {
+		if _, err := q.client.Do(ctx, "XADD", q.stream, "*", "job", entry.Fields["job"]); err != nil {
+			return replayed, fmt.Errorf("failed to republish job: %w", err)
+		}
+		if _, err := q.client.Do(ctx, "XDEL", q.dead, entry.ID); err != nil {
+			return replayed, err
+		}
+		replayed++
+	}
+	return replayed, nil
+}
+
+// streamIDTime reads the millisecond timestamp at the start of a stream entry id
+func streamIDTime(id string) time.Time {
+	ms, err := strconv.ParseInt(strings.SplitN(id, "-", 2)[0], 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
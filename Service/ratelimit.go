@@ -0,0 +1,253 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/config"
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultRateLimitRPS        = 10
+	defaultRateLimitBurst      = 40
+	defaultAuthFailureBurst    = 10
+	defaultRateLimitMaxClients = 10000
+	defaultMaxBodyBytes        = 1 << 20
+
+	// authFailureRefill is how often a failed login is forgiven
+	authFailureRefill = time.Minute
+)
+
+// unlimitedPaths are never rate limited, so probes keep working while a
+// client floods the API
+var unlimitedPaths = map[string]bool{"/ping": true, "/healthz": true, "/readyz": true}
+
+// heartbeatRoute is limited per token rather than per IP, so the cron jobs
+// of many heartbeat services behind one NAT don't use up a shared bucket
+const heartbeatRoute = "/health-app/heartbeat/:token"
+
+// bodyLimitExempt routes read their body through their own, larger cap
+var bodyLimitExempt = map[string]bool{
+	"/health-app/externalServices/import": true,
+	"/health-app/externalServices/lint":   true,
+}
+
+// tokenBucket holds up to burst tokens, refilled continuously
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rateLimiter is a set of token buckets by client key. It keeps at most
+// maxKeys of them; once full, buckets that have refilled completely are
+// dropped, and clients that still don't fit share one overflow bucket.
+type rateLimiter struct {
+	rate    float64 // tokens per second
+	burst   float64
+	maxKeys int
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	overflow  tokenBucket
+	lastSweep time.Time
+}
+
+func newRateLimiter(rate float64, burst, maxKeys int) *rateLimiter {
+	return &rateLimiter{
+		rate:     rate,
+		burst:    float64(burst),
+		maxKeys:  maxKeys,
+		buckets:  make(map[string]*tokenBucket),
+		overflow: tokenBucket{tokens: float64(burst)},
+	}
+}
+
+// refill brings a bucket up to date and returns its tokens
+func (l *rateLimiter) refill(b *tokenBucket, now time.Time) float64 {
+	if !b.last.IsZero() {
+		b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	}
+	b.last = now
+	return b.tokens
+}
+
+// bucket returns the bucket of key, creating it when there's room
+func (l *rateLimiter) bucket(key string, now time.Time) *tokenBucket {
+	if b, ok := l.buckets[key]; ok {
+		return b
+	}
+	if len(l.buckets) >= l.maxKeys && now.Sub(l.lastSweep) >= time.Second {
+		l.lastSweep = now
+		for k, idle := range l.buckets {
+			if l.refill(idle, now) >= l.burst {
+				delete(l.buckets, k)
+			}
+		}
+	}
+	if len(l.buckets) >= l.maxKeys {
+		return &l.overflow
+	}
+	b := &tokenBucket{tokens: l.burst, last: now}
+	l.buckets[key] = b
+	return b
+}
+
+// take spends a token of key. Without one left it returns how long until
+// the next one.
+func (l *rateLimiter) take(key string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.spend(l.bucket(key, now), now)
+}
+
+func (l *rateLimiter) spend(b *tokenBucket, now time.Time) (bool, time.Duration) {
+	if l.refill(b, now) >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+}
+
+// exhausted reports whether key has no token left, without spending one
+func (l *rateLimiter) exhausted(key string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[key]
+	return ok && l.refill(b, now) < 1
+}
+
+// apiLimits bounds what one client can make the REST API do
+type apiLimits struct {
+	maxBody int64
+
+	requests     *rateLimiter // nil unless server.rate_limit.enabled
+	authFailures *rateLimiter // by IP
+}
+
+func newAPILimits(cfg config.Server) *apiLimits {
+	limits := &apiLimits{maxBody: cfg.MaxBodyBytes}
+	if limits.maxBody <= 0 {
+		limits.maxBody = defaultMaxBodyBytes
+	}
+
+	rl := cfg.RateLimit
+	if !rl.Enabled {
+		return limits
+	}
+	rps, burst, failures, maxClients := rl.RequestsPerSecond, rl.Burst, rl.AuthFailureBurst, rl.MaxClients
+	if rps <= 0 {
+		rps = defaultRateLimitRPS
+	}
+	if burst <= 0 {
+		burst = defaultRateLimitBurst
+	}
+	if failures <= 0 {
+		failures = defaultAuthFailureBurst
+	}
+	if maxClients <= 0 {
+		maxClients = defaultRateLimitMaxClients
+	}
+	limits.requests = newRateLimiter(rps, burst, maxClients)
+	limits.authFailures = newRateLimiter(1/authFailureRefill.Seconds(), failures, maxClients)
+	return limits
+}
+
+// limitBody rejects bodies over server.max_body_bytes: up front when the
+// length is declared, and once that much has been read otherwise
+func (l *apiLimits) limitBody() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Body == nil || c.Request.Body == http.NoBody || bodyLimitExempt[c.FullPath()] {
+			c.Next()
+			return
+		}
+		if c.Request.ContentLength > l.maxBody {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{
+				"error":     "request body too large",
+				"max_bytes": l.maxBody,
+			})
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, l.maxBody)
+		c.Next()
+	}
+}
+
+// rateLimit spends a token of the caller's bucket: the credential's when the
+// request carries one, the client IP's otherwise. A credential seen for the
+// first time also costs its IP a token, so rotating made-up tokens gets no
+// more requests than sending none; credentials from an IP that ran out of
+// failed logins are refused until it is forgiven one. A heartbeat token is
+// treated like a credential, but it isn't a login, so failed logins from
+// its IP don't hold it up.
+func (l *apiLimits) rateLimit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if l.requests == nil || unlimitedPaths[c.Request.URL.Path] {
+			c.Next()
+			return
+		}
+
+		now := time.Now()
+		ipKey := "ip:" + c.ClientIP()
+		credential := requestCredential(c)
+
+		var ok bool
+		var wait time.Duration
+		switch {
+		case c.FullPath() == heartbeatRoute:
+			ok, wait = l.takeCredential("heartbeat:"+c.Param("token"), ipKey, now)
+		case credential == "":
+			ok, wait = l.requests.take(ipKey, now)
+		case l.authFailures.exhausted(ipKey, now):
+			ok, wait = false, authFailureRefill
+		default:
+			ok, wait = l.takeCredential(credential, ipKey, now)
+		}
+		if !ok {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "rate limit exceeded"})
+			return
+		}
+		c.Next()
+	}
+}
+
+func (l *apiLimits) takeCredential(credential, ipKey string, now time.Time) (bool, time.Duration) {
+	sum := sha256.Sum256([]byte(credential))
+	key := "cred:" + hex.EncodeToString(sum[:16])
+
+	l.requests.mu.Lock()
+	defer l.requests.mu.Unlock()
+
+	if _, known := l.requests.buckets[key]; !known {
+		if ok, wait := l.requests.spend(l.requests.bucket(ipKey, now), now); !ok {
+			return false, wait
+		}
+	}
+	return l.requests.spend(l.requests.bucket(key, now), now)
+}
+
+// authFailed counts a rejected credential against the caller's IP
+func (l *apiLimits) authFailed(c *gin.Context) {
+	if l.authFailures != nil {
+		l.authFailures.take("ip:"+c.ClientIP(), time.Now())
+	}
+}
+
+// requestCredential returns whatever the caller authenticates with, or ""
+func requestCredential(c *gin.Context) string {
+	if auth := c.GetHeader("Authorization"); auth != "" {
+		return auth
+	}
+	if cookie, err := c.Cookie(sessionCookie); err == nil && cookie != "" {
+		return cookie
+	}
+	return c.Query("token") // WebSocket tokens
+}
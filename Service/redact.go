@@ -0,0 +1,50 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/models"
+	"Distributed-Health-Monitoring/secrets"
+)
+
+// redactSecrets blanks the credentials of a service copy before it leaves the
+// process in a response, export or archive. Secrets are write-only: an import
+// that sends the redacted value back keeps the stored one.
+func redactSecrets(s *models.ExternalService) {
+	s.ProxyURL = secrets.RedactURL(s.ProxyURL)
+	s.DatabaseDSN = secrets.RedactURL(s.DatabaseDSN)
+	if s.Auth != nil {
+		auth := *s.Auth
+		auth.Password = redactValue(auth.Password)
+		auth.Token = redactValue(auth.Token)
+		auth.ClientSecret = redactValue(auth.ClientSecret)
+		s.Auth = &auth
+	}
+}
+
+func redactValue(v string) string {
+	if v == "" {
+		return ""
+	}
+	return secrets.RedactedPassword
+}
+
+// keepRedactedSecrets restores stored credentials in place of redacted ones
+func keepRedactedSecrets(def *models.ExternalService, existing *models.ExternalService) {
+	if def.ProxyURL != "" && def.ProxyURL != existing.ProxyURL && def.ProxyURL == secrets.RedactURL(existing.ProxyURL) {
+		def.ProxyURL = existing.ProxyURL
+	}
+	if def.DatabaseDSN != "" && def.DatabaseDSN != existing.DatabaseDSN && def.DatabaseDSN == secrets.RedactURL(existing.DatabaseDSN) {
+		def.DatabaseDSN = existing.DatabaseDSN
+	}
+	if def.Auth != nil && existing.Auth != nil {
+		auth := *def.Auth
+		keep := func(v *string, stored string) {
+			if *v == secrets.RedactedPassword {
+				*v = stored
+			}
+		}
+		keep(&auth.Password, existing.Auth.Password)
+		keep(&auth.Token, existing.Auth.Token)
+		keep(&auth.ClientSecret, existing.Auth.ClientSecret)
+		def.Auth = &auth
+	}
+}
@@ -0,0 +1,260 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/clock"
+	"Distributed-Health-Monitoring/logging"
+	"Distributed-Health-Monitoring/models"
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const defaultRegionStatsWindow = "24h"
+
+// scheduleRegions publishes the job, or one copy per region for services
+// that require regions. A round counts as scheduled when any region got its
+// job; the regions that didn't are logged and miss this round.
+func scheduleRegions(sched *Scheduler, s *models.ExternalService, job HealthCheckJob) error {
+	if len(s.Regions) == 0 {
+		return sched.Schedule(job)
+	}
+
+	var published int
+	var lastErr error
+	for _, region := range s.Regions {
+		regional := job
+		regional.JobID = job.JobID + "." + region
+		regional.Region = region
+		if err := sched.Schedule(regional); err != nil {
+			lastErr = err
+			continue
+		}
+		published++
+	}
+	if published == 0 {
+		return lastErr
+	}
+	return nil
+}
+
+// regionRound identifies the round of a regional job. All jobs of a round
+// share the due time; it is truncated so it compares equal after a round
+// trip through the database.
+func regionRound(job HealthCheckJob) time.Time {
+	at := job.DueAt
+	if at.IsZero() {
+		at = job.ScheduledAt
+	}
+	return at.UTC().Truncate(time.Second)
+}
+
+// applyRegionResult records the result of one region and reports the
+// combined result once the round is decided: quorum regions failed, or so
+// many passed that the quorum can no longer be reached. Only the worker that
+// claims the round gets ok; the others, and undecided rounds, leave the
+// service state alone.
+func (e *Engine) applyRegionResult(ctx context.Context, service *models.ExternalService, job HealthCheckJob, result models.CheckResult) (models.CheckResult, bool) {
+	logger := logging.For(ctx, "regions").With("service", service.Name, "region", job.Region)
+	round := regionRound(job)
+
+	if err := e.Repo.SaveRegionResult(ctx, &models.RegionResult{
+		ExternalServiceID: service.ID,
+		Region:            job.Region,
+		RoundAt:           round,
+		Status:            result.Status,
+		Success:           result.Success,
+		StatusCode:        result.StatusCode,
+		LatencyMs:         result.LatencyMs,
+		Reason:            result.Reason,
+		Error:             result.ErrorMessage,
+		CheckedAt:         clock.Now(),
+	}); err != nil {
+		logger.Error("result_save_failed", "err", err)
+		return result, false
+	}
+	if !result.Success {
+		logger.Warn("region_check_failed", "reason", result.Reason, "error", result.ErrorMessage)
+	}
+
+	results, err := e.Repo.ListRegionResults(ctx, service.ID)
+	if err != nil {
+		logger.Error("results_fetch_failed", "err", err)
+		return result, false
+	}
+
+	var passed, failed []models.RegionResult
+	for _, r := range results {
+		if !r.RoundAt.Equal(round) || !slices.Contains(service.Regions, r.Region) {
+			continue
+		}
+		if r.Success {
+			passed = append(passed, r)
+		} else {
+			failed = append(failed, r)
+		}
+	}
+
+	quorum := service.RegionQuorumSize()
+	down := len(failed) >= quorum
+	if !down && len(passed) <= len(service.Regions)-quorum {
+		return result, false
+	}
+
+	claimed, err := e.Repo.ClaimRegionRound(ctx, service.ID, round)
+	if err != nil {
+		logger.Error("round_claim_failed", "err", err)
+		return result, false
+	}
+	if !claimed {
+		return result, false
+	}
+
+	decided := combineRegionResults(passed, failed, len(service.Regions), down)
+	decided.Attempts = result.Attempts
+	decided.Response = result.Response
+	if !decided.Success {
+		decided.AssertionFailure = result.AssertionFailure
+	}
+
+	logger.Info("round_decided", "status", decided.Status, "failed", len(failed), "passed", len(passed), "quorum", quorum)
+	return decided, true
+}
+
+// combineRegionResults builds the service result of a decided round. A
+// failure names the failing regions; a success is DEGRADED when a passing
+// region was, and reports the slowest passing latency.
+func combineRegionResults(passed, failed []models.RegionResult, regions int, down bool) models.CheckResult {
+	if down {
+		errs := make([]string, 0, len(failed))
+		for _, r := range failed {
+			errs = append(errs, fmt.Sprintf("%s: %s", r.Region, r.Error))
+		}
+		first := failed[0]
+		return models.CheckResult{
+			Status:       "DOWN",
+			StatusCode:   first.StatusCode,
+			LatencyMs:    first.LatencyMs,
+			Reason:       first.Reason,
+			ErrorMessage: fmt.Sprintf("%d of %d regions failed: %s", len(failed), regions, strings.Join(errs, "; ")),
+		}
+	}
+
+	result := models.CheckResult{Status: "UP", Success: true}
+	for _, r := range passed {
+		if r.LatencyMs >= result.LatencyMs {
+			result.LatencyMs, result.StatusCode = r.LatencyMs, r.StatusCode
+		}
+		if r.Status == models.StatusDegraded && result.Status != models.StatusDegraded {
+			result.Status, result.Reason = models.StatusDegraded, r.Reason
+			result.ErrorMessage = fmt.Sprintf("%s: %s", r.Region, r.Error)
+		}
+	}
+	return result
+}
+
+// RegionStats summarises the checks of one region over the stats window
+type RegionStats struct {
+	Region       string  `json:"region"` // empty for checks by workers without a region
+	Checks       int     `json:"checks"`
+	Failures     int     `json:"failures"`
+	SuccessRate  float64 `json:"success_rate"` // percent
+	AvgLatencyMs float64 `json:"avg_latency_ms"`
+	P95LatencyMs int64   `json:"p95_latency_ms"`
+	LastError    string  `json:"last_error,omitempty"`
+
+	latencies []int64
+}
+
+// GetServiceRegions shows the latest result of each required region and per
+// region check statistics over ?window= (default 24h), so a network problem
+// in one region can be told apart from the service being down
+func (e *Engine) GetServiceRegions(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "invalid service id"})
+		return
+	}
+
+	service, ok := e.serviceByID(c, uint(id))
+	if !ok {
+		return
+	}
+
+	label := c.DefaultQuery("window", defaultRegionStatsWindow)
+	window, err := parseStatsWindow(label)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	to := time.Now()
+
+	latest, err := e.Repo.ListRegionResults(ctx, service.ID)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	logs, err := e.Repo.GetServiceCheckLogsInRange(ctx, service.ID, to.Add(-window), to)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	quorum := 0
+	if len(service.Regions) > 0 {
+		quorum = service.RegionQuorumSize()
+	}
+
+	c.JSON(200, gin.H{
+		"service_id": service.ID,
+		"regions":    service.Regions,
+		"quorum":     quorum,
+		"window":     label,
+		"latest":     latest,
+		"stats":      regionStats(logs),
+	})
+}
+
+func regionStats(logs []*models.ServiceCheckLog) []RegionStats {
+	byRegion := make(map[string]*RegionStats)
+	for _, l := range logs {
+		st, ok := byRegion[l.Region]
+		if !ok {
+			st = &RegionStats{Region: l.Region}
+			byRegion[l.Region] = st
+		}
+		st.Checks++
+		if l.Status == "DOWN" {
+			st.Failures++
+			st.LastError = l.ErrorMessage // logs are oldest first, so the last one wins
+		}
+		st.latencies = append(st.latencies, l.ResponseTimeMs)
+	}
+
+	out := make([]RegionStats, 0, len(byRegion))
+	for _, st := range byRegion {
+		st.SuccessRate = math.Round(float64(st.Checks-st.Failures)/float64(st.Checks)*10000) / 100
+
+		var sum int64
+		for _, l := range st.latencies {
+			sum += l
+		}
+		st.AvgLatencyMs = math.Round(float64(sum)/float64(len(st.latencies))*10) / 10
+
+		sort.Slice(st.latencies, func(i, j int) bool { return st.latencies[i] < st.latencies[j] })
+		st.P95LatencyMs = st.latencies[int(math.Ceil(0.95*float64(len(st.latencies))))-1]
+
+		out = append(out, *st)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Region < out[j].Region })
+	return out
+}
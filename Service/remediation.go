@@ -0,0 +1,326 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/config"
+	"Distributed-Health-Monitoring/logging"
+	"Distributed-Health-Monitoring/models"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultRemediationTimeout = 30 * time.Second
+	maxRemediationOutput      = 4096
+
+	// remediationWaitDelay bounds the wait for a killed command's output,
+	// which a child that inherited its pipes can hold open
+	remediationWaitDelay = 5 * time.Second
+
+	remediationPath = "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+
+	defaultKubernetesAPIServer = "https://kubernetes.default.svc"
+	defaultKubernetesTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	defaultKubernetesCAFile    = "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"
+)
+
+// remediationPayload is the JSON body POSTed by a webhook remediation
+type remediationPayload struct {
+	Event               string    `json:"event"` // remediation
+	ServiceID           uint      `json:"service_id"`
+	Service             string    `json:"service"`
+	URL                 string    `json:"url"`
+	Status              string    `json:"status"`
+	ConsecutiveFailures int64     `json:"consecutive_failures"`
+	Attempt             int64     `json:"attempt"`
+	Timestamp           time.Time `json:"timestamp"`
+}
+
+// remediate starts the remediation action of a failing service once its
+// failure streak is long enough, the cooldown has passed and attempts are
+// left. The action runs in the background so a slow restart doesn't hold up
+// the worker. Nothing runs inside a maintenance window.
+func (e *Engine) remediate(ctx context.Context, service *models.ExternalService, inMaintenance bool) {
+	r := service.Remediation
+	if !e.Cnfg.Remediation.Enabled || r == nil || inMaintenance {
+		return
+	}
+	if service.ConsecutiveFailures < r.AfterFailures || service.RemediationAttempts >= r.MaxAttempts {
+		return
+	}
+	now := time.Now()
+	cooldown := time.Duration(r.CooldownSeconds) * time.Second
+	if service.LastRemediationAt != nil && now.Before(service.LastRemediationAt.Add(cooldown)) {
+		return
+	}
+
+	// Another replica may have failed the same check; only one of them runs it
+	claimed, err := e.Repo.ClaimRemediation(ctx, service.ID, service.RemediationAttempts, r.MaxAttempts, cooldown, now)
+	if err != nil {
+		logging.For(ctx, "remediation").Error("claim_failed", "service", service.Name, "err", err)
+		return
+	}
+	if !claimed {
+		return
+	}
+	service.RemediationAttempts++
+	service.LastRemediationAt = &now
+
+	go e.runRemediation(context.WithoutCancel(ctx), *service, now)
+}
+
+// runRemediation executes the action and records the run
+func (e *Engine) runRemediation(ctx context.Context, service models.ExternalService, start time.Time) {
+	r := service.Remediation
+	logger := logging.For(ctx, "remediation").With("service", service.Name, "type", r.Type, "attempt", service.RemediationAttempts)
+	logger.Info("started", "target", remediationTarget(r), "consecutive_failures", service.ConsecutiveFailures)
+
+	timeout := defaultRemediationTimeout
+	if e.Cnfg.Remediation.TimeoutSeconds > 0 {
+		timeout = time.Duration(e.Cnfg.Remediation.TimeoutSeconds) * time.Second
+	}
+	runCtx, cancel := context.WithTimeout(ctx, timeout)
+	output, err := e.executeRemediation(runCtx, &service)
+	cancel()
+
+	run := models.RemediationRun{
+		ExternalServiceID:   service.ID,
+		ServiceName:         service.Name,
+		Type:                r.Type,
+		Target:              remediationTarget(r),
+		Attempt:             service.RemediationAttempts,
+		ConsecutiveFailures: service.ConsecutiveFailures,
+		Success:             err == nil,
+		Output:              truncateOutput(output),
+		DurationMs:          time.Since(start).Milliseconds(),
+		StartedAt:           start.UTC(),
+	}
+	if err != nil {
+		run.Error = err.Error()
+		logger.Error("failed", "duration_ms", run.DurationMs, "err", err)
+	} else {
+		logger.Info("succeeded", "duration_ms", run.DurationMs)
+	}
+
+	if err := e.Repo.SaveRemediationRun(ctx, &run); err != nil {
+		logger.Error("run_log_failed", "err", err)
+	}
+}
+
+func (e *Engine) executeRemediation(ctx context.Context, service *models.ExternalService) (string, error) {
+	r := service.Remediation
+	switch r.Type {
+	case models.RemediationWebhook:
+		return e.callRemediationWebhook(ctx, service)
+	case models.RemediationCommand:
+		command, ok := e.Cnfg.Remediation.Commands[r.Command]
+		if !ok || len(command.Args) == 0 {
+			return "", fmt.Errorf("command %q is not defined in remediation.commands", r.Command)
+		}
+		return runRemediationCommand(ctx, command, service)
+	case models.RemediationKubernetes:
+		return restartDeployment(ctx, e.Cnfg.Remediation.Kubernetes, r.Namespace, r.Deployment)
+	}
+	return "", fmt.Errorf("unknown remediation type %q", r.Type)
+}
+
+func remediationTarget(r *models.Remediation) string {
+	switch r.Type {
+	case models.RemediationWebhook:
+		return r.URL
+	case models.RemediationCommand:
+		return r.Command
+	case models.RemediationKubernetes:
+		return r.Namespace + "/" + r.Deployment
+	}
+	return ""
+}
+
+// callRemediationWebhook posts through the webhook sender, so the receiver
+// can verify the call with remediation.webhook_secret like any delivery
+func (e *Engine) callRemediationWebhook(ctx context.Context, service *models.ExternalService) (string, error) {
+	if e.Cnfg.Remediation.WebhookSecret == "" {
+		return "", errors.New("remediation.webhook_secret is not set")
+	}
+	body, err := json.Marshal(remediationPayload{
+		Event:               "remediation",
+		ServiceID:           service.ID,
+		Service:             service.Name,
+		URL:                 service.URL,
+		Status:              service.Status,
+		ConsecutiveFailures: service.ConsecutiveFailures,
+		Attempt:             service.RemediationAttempts,
+		Timestamp:           time.Now().UTC(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	hook := models.Webhook{URL: service.Remediation.URL, Secret: e.Cnfg.Remediation.WebhookSecret}
+	payload := webhookPayload{ID: logging.NewID(), Event: "remediation"}
+	_, output, err := e.webhooks.send(ctx, hook, payload, body, maxRemediationOutput)
+	return string(output), err
+}
+
+// runRemediationCommand runs a configured program without a shell. It
+// doesn't inherit the monitor's environment, which holds its credentials.
+func runRemediationCommand(ctx context.Context, command config.RemediationCommand, service *models.ExternalService) (string, error) {
+	cmd := exec.CommandContext(ctx, command.Args[0], command.Args[1:]...)
+	cmd.Dir = command.Dir
+	cmd.WaitDelay = remediationWaitDelay
+	cmd.Env = append([]string{"PATH=" + remediationPath}, command.Env...)
+	cmd.Env = append(cmd.Env,
+		"DHM_SERVICE_ID="+strconv.FormatUint(uint64(service.ID), 10),
+		"DHM_SERVICE_NAME="+service.Name,
+		"DHM_SERVICE_URL="+service.URL,
+		"DHM_CONSECUTIVE_FAILURES="+strconv.FormatInt(service.ConsecutiveFailures, 10),
+	)
+
+	output, err := cmd.CombinedOutput()
+	if ctx.Err() != nil {
+		return string(output), fmt.Errorf("command timed out: %w", ctx.Err())
+	}
+	return string(output), err
+}
+
+// restartDeployment does what kubectl rollout restart does: it bumps an
+// annotation of the pod template, so the deployment rolls out new pods
+func restartDeployment(ctx context.Context, cfg config.Kubernetes, namespace, name string) (string, error) {
+	server, tokenFile, caFile := cfg.APIServer, cfg.TokenFile, cfg.CAFile
+	if server == "" {
+		server = defaultKubernetesAPIServer
+	}
+	if tokenFile == "" {
+		tokenFile = defaultKubernetesTokenFile
+	}
+	if caFile == "" {
+		caFile = defaultKubernetesCAFile
+	}
+
+	token, err := os.ReadFile(tokenFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read kubernetes token: %w", err)
+	}
+	client, err := kubernetesClient(caFile)
+	if err != nil {
+		return "", err
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"annotations": map[string]string{
+						"kubectl.kubernetes.io/restartedAt": time.Now().UTC().Format(time.RFC3339),
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	endpoint := strings.TrimRight(server, "/") + "/apis/apps/v1/namespaces/" + url.PathEscape(namespace) + "/deployments/" + url.PathEscape(name)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, endpoint, bytes.NewReader(patch))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/strategic-merge-patch+json")
+	req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		// The API server explains refusals, like missing RBAC, in a Status object
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxRemediationOutput))
+		var status struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(body, &status) == nil && status.Message != "" {
+			return string(body), fmt.Errorf("kubernetes returned %d: %s", resp.StatusCode, status.Message)
+		}
+		return string(body), fmt.Errorf("kubernetes returned %d", resp.StatusCode)
+	}
+	io.Copy(io.Discard, resp.Body)
+	return fmt.Sprintf("deployment %s/%s restarted", namespace, name), nil
+}
+
+// kubernetesClient trusts the cluster CA when the file exists, and the
+// system roots otherwise
+func kubernetesClient(caFile string) (*http.Client, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	ca, err := os.ReadFile(caFile)
+	switch {
+	case err == nil:
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificates found in %s", caFile)
+		}
+		tlsConfig.RootCAs = pool
+	case !errors.Is(err, os.ErrNotExist):
+		return nil, fmt.Errorf("failed to read kubernetes CA: %w", err)
+	}
+
+	return &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig, Proxy: http.ProxyFromEnvironment}}, nil
+}
+
+// truncateOutput cuts the output on a rune boundary, so the stored text stays valid UTF-8
+func truncateOutput(output string) string {
+	if len(output) <= maxRemediationOutput {
+		return output
+	}
+	n := maxRemediationOutput
+	for n > 0 && !utf8.RuneStart(output[n]) {
+		n--
+	}
+	return output[:n] + "…"
+}
+
+// ListRemediationRuns returns the remediation runs of a service, newest first
+func (e *Engine) ListRemediationRuns(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "invalid service id"})
+		return
+	}
+
+	service, ok := e.serviceByID(c, uint(id))
+	if !ok {
+		return
+	}
+
+	runs, err := e.Repo.ListRemediationRuns(c.Request.Context(), service.ID, queryLimit(c, 50))
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"service_id":           service.ID,
+		"remediation":          service.Remediation,
+		"remediation_attempts": service.RemediationAttempts,
+		"last_remediation_at":  service.LastRemediationAt,
+		"runs":                 runs,
+	})
+}
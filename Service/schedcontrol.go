@@ -0,0 +1,152 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/Repository"
+	"Distributed-Health-Monitoring/clock"
+	"Distributed-Health-Monitoring/logging"
+	"Distributed-Health-Monitoring/models"
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// schedulerState is what the control endpoints need from the scheduler loop
+type schedulerState struct {
+	mu        sync.Mutex
+	publisher *Scheduler   // nil until the scheduler loop has started
+	running   atomic.Int64 // jobs being processed by this replica
+
+	// jobs this replica's worker dropped without checking
+	droppedStale     atomic.Int64
+	droppedDuplicate atomic.Int64
+}
+
+func (s *schedulerState) setPublisher(p *Scheduler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.publisher = p
+}
+
+func (s *schedulerState) getPublisher() *Scheduler {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.publisher
+}
+
+// SchedulerStatus reports the control mode and how much work is still outstanding
+type SchedulerStatus struct {
+	Mode       string    `json:"mode"` // running, paused or draining
+	Reason     string    `json:"reason,omitempty"`
+	UpdatedAt  time.Time `json:"updated_at,omitempty"`
+	InFlight   int       `json:"in_flight"`             // services holding a lease: queued or running anywhere
+	QueueDepth *int      `json:"queue_depth"`           // jobs waiting in the queue; null when it can't be inspected
+	QueueError string    `json:"queue_error,omitempty"` // why the queue couldn't be inspected
+	Running    int64     `json:"running"`               // jobs being processed by this replica
+	Drained    bool      `json:"drained"`               // nothing is published and no job is outstanding
+
+	Lag   SchedulerLag `json:"lag"`   // checks running behind schedule
+	Clock ClockStatus  `json:"clock"` // this replica's clock against the database's
+}
+
+type schedulerControlRequest struct {
+	Reason string `json:"reason"`
+}
+
+// GetSchedulerStatus reports whether jobs are being published, how far
+// checks are behind schedule and, while paused or draining, whether
+// outstanding jobs have finished
+func (e *Engine) GetSchedulerStatus(c *gin.Context) {
+	status, err := e.schedulerStatus(c.Request.Context())
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, status)
+}
+
+// PauseScheduler stops publishing new jobs; queued jobs are still consumed
+func (e *Engine) PauseScheduler(c *gin.Context) {
+	e.setSchedulerMode(c, models.SchedulerPaused)
+}
+
+// DrainScheduler stops publishing like a pause and is meant to be polled
+// until drained, e.g. before taking the broker down
+func (e *Engine) DrainScheduler(c *gin.Context) {
+	e.setSchedulerMode(c, models.SchedulerDraining)
+}
+
+// ResumeScheduler publishes again from the next tick. Services that became
+// due while publishing was stopped are scheduled right away.
+func (e *Engine) ResumeScheduler(c *gin.Context) {
+	e.setSchedulerMode(c, models.SchedulerRunning)
+}
+
+func (e *Engine) setSchedulerMode(c *gin.Context, mode string) {
+	var req schedulerControlRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+	control := &models.SchedulerControl{Mode: mode, Reason: req.Reason, UpdatedAt: time.Now()}
+	if err := e.Repo.SetSchedulerControl(ctx, control); err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	logging.For(ctx, "scheduler").Info("mode_set", "mode", mode, "reason", req.Reason)
+
+	status, err := e.schedulerStatus(ctx)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, status)
+}
+
+func (e *Engine) schedulerStatus(ctx context.Context) (SchedulerStatus, error) {
+	control, err := e.Repo.GetSchedulerControl(ctx)
+	if err != nil {
+		return SchedulerStatus{}, err
+	}
+	status := SchedulerStatus{
+		Mode:      control.Mode,
+		Reason:    control.Reason,
+		UpdatedAt: control.UpdatedAt,
+		Running:   e.sched.running.Load(),
+	}
+
+	// Leases are taken when a job is published and cleared when its result
+	// is saved, so they count outstanding jobs across every replica
+	services, err := e.Repo.GetAllServices(ctx)
+	if err != nil && !errors.Is(err, Repository.ErrNoServices) {
+		return SchedulerStatus{}, err
+	}
+	now := clock.Now()
+	for _, s := range services {
+		if s.InFlightUntil != nil && now.Before(*s.InFlightUntil) {
+			status.InFlight++
+		}
+	}
+	status.Lag = schedulerLag(services, now, lagWarn(e.Cnfg.Scheduler))
+	status.Clock = e.clock.get()
+
+	if p := e.sched.getPublisher(); p == nil {
+		status.QueueError = "scheduler has not started"
+	} else if depth, err := p.Pending(); err != nil {
+		status.QueueError = err.Error()
+	} else {
+		status.QueueDepth = &depth
+	}
+
+	status.Drained = control.Mode != models.SchedulerRunning &&
+		status.InFlight == 0 && status.Running == 0 &&
+		status.QueueDepth != nil && *status.QueueDepth == 0
+	return status, nil
+}
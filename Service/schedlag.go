@@ -0,0 +1,150 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/config"
+	"Distributed-Health-Monitoring/logging"
+	"Distributed-Health-Monitoring/models"
+	"Distributed-Health-Monitoring/notify"
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+const (
+	defaultLagWarn = 2 * time.Minute
+	maxLagListed   = 50
+)
+
+// ServiceLag is how far behind schedule the check of one service is
+type ServiceLag struct {
+	ServiceID  uint      `json:"service_id"`
+	Name       string    `json:"name"`
+	DueAt      time.Time `json:"due_at"`      // when the outstanding job was published, if there is one
+	LagSeconds float64   `json:"lag_seconds"` // now - due_at
+	InFlight   bool      `json:"in_flight"`   // a job is queued or running, but late
+}
+
+// SchedulerLag covers the services whose check is overdue by more than a
+// scheduler tick, the most behind first
+type SchedulerLag struct {
+	MaxLagSeconds  float64      `json:"max_lag_seconds"`
+	Lagging        int          `json:"lagging"`          // services behind by more than lag_warn_seconds
+	LagWarnSeconds float64      `json:"lag_warn_seconds"` // 0 when lag warnings are off
+	Behind         []ServiceLag `json:"behind"`           // at most 50
+}
+
+// lagWarn is scheduler.lag_warn_seconds; 0 when warnings are disabled
+func lagWarn(cfg config.Scheduler) time.Duration {
+	switch {
+	case cfg.LagWarnSeconds < 0:
+		return 0
+	case cfg.LagWarnSeconds == 0:
+		return defaultLagWarn
+	}
+	return time.Duration(cfg.LagWarnSeconds) * time.Second
+}
+
+// serviceLag is how long a service has waited for its check, or 0 if it
+// isn't due
+func serviceLag(s *models.ExternalService, now time.Time) time.Duration {
+	return max(now.Sub(lagSince(s, now)), 0)
+}
+
+// lagSince is when the wait of a service began. markScheduled moves the
+// next run forward as soon as a job is published, so a job that sits in
+// the queue is only visible through its lease: until the worker finishes it
+// the service is waiting since the job was published, which is when it
+// became due. Otherwise it waits since its next run time.
+func lagSince(s *models.ExternalService, now time.Time) time.Time {
+	if s.InFlightUntil != nil {
+		if published := s.InFlightUntil.Add(-leaseDuration(s)); published.Before(now) {
+			return published
+		}
+	}
+	return dueAt(s, now)
+}
+
+func schedulerLag(services map[uint]*models.ExternalService, now time.Time, warn time.Duration) SchedulerLag {
+	lag := SchedulerLag{LagWarnSeconds: warn.Seconds(), Behind: []ServiceLag{}}
+	for _, s := range services {
+		d := serviceLag(s, now)
+		if d <= SchedulerTick {
+			continue
+		}
+		lag.MaxLagSeconds = max(lag.MaxLagSeconds, d.Seconds())
+		if warn > 0 && d > warn {
+			lag.Lagging++
+		}
+		lag.Behind = append(lag.Behind, ServiceLag{
+			ServiceID:  s.ID,
+			Name:       s.Name,
+			DueAt:      lagSince(s, now),
+			LagSeconds: d.Seconds(),
+			InFlight:   s.InFlightUntil != nil && now.Before(*s.InFlightUntil),
+		})
+	}
+	sort.Slice(lag.Behind, func(i, j int) bool { return lag.Behind[i].LagSeconds > lag.Behind[j].LagSeconds })
+	if len(lag.Behind) > maxLagListed {
+		lag.Behind = lag.Behind[:maxLagListed]
+	}
+	return lag
+}
+
+// checkLag warns once the most behind service is later than
+// lag_warn_seconds, and announces the recovery once it is back under half
+// of that. It runs on the leader after each publishing tick, so a paused
+// scheduler doesn't warn. It returns whether the scheduler is lagging now.
+func (e *Engine) checkLag(ctx context.Context, sched *Scheduler, services map[uint]*models.ExternalService, now time.Time, lagging bool) bool {
+	warn := lagWarn(e.Cnfg.Scheduler)
+	if warn == 0 {
+		return false
+	}
+
+	lag := schedulerLag(services, now, warn)
+	maxLag := time.Duration(lag.MaxLagSeconds * float64(time.Second))
+	switch {
+	case !lagging && maxLag > warn:
+		lagging = true
+	case lagging && maxLag <= warn/2:
+		lagging = false
+	default:
+		return lagging
+	}
+
+	event := models.SchedulerLagEvent{
+		Type:           "scheduler_lagging",
+		MaxLagSeconds:  lag.MaxLagSeconds,
+		Lagging:        lag.Lagging,
+		LagWarnSeconds: lag.LagWarnSeconds,
+		Timestamp:      now,
+	}
+	if len(lag.Behind) > 0 {
+		event.Service = lag.Behind[0].Name
+	}
+	if depth, err := sched.Pending(); err == nil {
+		event.QueueDepth = &depth
+	}
+
+	alert := notify.Alert{
+		Type:      "scheduler_lag",
+		Service:   event.Service,
+		Reason:    fmt.Sprintf("%d services more than %s behind schedule, up to %s", lag.Lagging, warn, maxLag.Round(time.Second)),
+		Timestamp: now,
+	}
+	logger := logging.For(ctx, "scheduler")
+	if lagging {
+		alert.Severity = e.Notifier.Severity("scheduler_lag")
+		logger.Warn("scheduler_lagging", "max_lag", maxLag.Round(time.Second), "lagging", lag.Lagging, "service", event.Service, "queue_depth", event.QueueDepth)
+	} else {
+		event.Type = "scheduler_caught_up"
+		alert.Type = "scheduler_lag_end"
+		alert.Severity = e.Notifier.Severity("scheduler_lag_end")
+		alert.Reason = fmt.Sprintf("the most behind service is %s late", maxLag.Round(time.Second))
+		logger.Info("scheduler_caught_up", "max_lag", maxLag.Round(time.Second), "queue_depth", event.QueueDepth)
+	}
+
+	BroadcastEvent(event.Service, event)
+	e.Notifier.Dispatch(alert)
+	return lagging
+}
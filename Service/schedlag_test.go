@@ -0,0 +1,65 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/models"
+	"testing"
+	"time"
+)
+
+func TestServiceLag(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time {
+		v := now.Add(d)
+		return &v
+	}
+	// Interval 60, timeout 5 and no retries give a lease of 5s plus a tick
+	lease := 5*time.Second + SchedulerTick
+
+	tests := []struct {
+		name    string
+		service models.ExternalService
+		lag     time.Duration
+	}{
+		{
+			name:    "never checked",
+			service: models.ExternalService{},
+		},
+		{
+			name:    "not due yet",
+			service: models.ExternalService{NextRunAt: at(30 * time.Second)},
+		},
+		{
+			name:    "overdue",
+			service: models.ExternalService{NextRunAt: at(-10 * time.Second)},
+			lag:     10 * time.Second,
+		},
+		{
+			name:    "due from the last check",
+			service: models.ExternalService{LastCheckedAt: at(-70 * time.Second)},
+			lag:     10 * time.Second,
+		},
+		{
+			name:    "job waiting in the queue",
+			service: models.ExternalService{NextRunAt: at(56 * time.Second), InFlightUntil: at(lease - 4*time.Second)},
+			lag:     4 * time.Second,
+		},
+		{
+			name:    "lease expired",
+			service: models.ExternalService{NextRunAt: at(-30 * time.Second), InFlightUntil: at(-20 * time.Second)},
+			lag:     lease + 20*time.Second,
+		},
+		{
+			name:    "lease longer than the service's",
+			service: models.ExternalService{NextRunAt: at(30 * time.Second), InFlightUntil: at(lease + 10*time.Second)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := tt.service
+			s.Interval, s.TimeoutSeconds = 60, 5
+			if got := serviceLag(&s, now); got != tt.lag {
+				t.Errorf("serviceLag() = %s, want %s", got, tt.lag)
+			}
+		})
+	}
+}
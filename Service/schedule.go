@@ -0,0 +1,81 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/config"
+	"Distributed-Health-Monitoring/cron"
+	"Distributed-Health-Monitoring/models"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// cronSchedules caches parsed service schedules by expression and time zone,
+// since the scheduler looks at every service on every tick
+var cronSchedules sync.Map // "expr|zone" -> *zonedSchedule
+
+// zonedSchedule is a cron expression read in the service's time zone
+type zonedSchedule struct {
+	*cron.Schedule
+	loc *time.Location
+}
+
+// serviceSchedule returns the cron schedule of a service, or nil for services
+// checked at a fixed interval. Definitions are validated when saved, so one
+// that doesn't parse any more falls back to the interval.
+func serviceSchedule(s *models.ExternalService) *zonedSchedule {
+	if s.Schedule == "" {
+		return nil
+	}
+	key := s.Schedule + "|" + s.ScheduleTimezone
+	if cached, ok := cronSchedules.Load(key); ok {
+		return cached.(*zonedSchedule)
+	}
+
+	sched, err := cron.Parse(s.Schedule)
+	if err != nil {
+		return nil
+	}
+	loc, err := time.LoadLocation(s.ScheduleTimezone)
+	if err != nil {
+		return nil
+	}
+	z := &zonedSchedule{Schedule: sched, loc: loc}
+	cronSchedules.Store(key, z)
+	return z
+}
+
+// nextRun is the next run of a service after t without jitter: the next
+// cron match, or one interval later
+func nextRun(s *models.ExternalService, t time.Time) time.Time {
+	if z := serviceSchedule(s); z != nil {
+		if next, err := z.Next(t.In(z.loc)); err == nil {
+			return next.In(t.Location())
+		}
+	}
+	return t.Add(time.Duration(s.Interval) * time.Second)
+}
+
+// scheduleJitter is a random offset added to a run so services that share an
+// interval or a cron expression spread over several ticks instead of being
+// published together. Its range is jitter_percent of gap, the time to the
+// run, capped at max_jitter_seconds. Cron runs are only ever delayed so they
+// don't fire before their match; interval runs are moved either way around
+// the interval, so the average check rate doesn't drop.
+func scheduleJitter(cfg config.Scheduler, gap time.Duration, delayOnly bool) time.Duration {
+	percent := min(cfg.JitterPercent, 100)
+	if percent <= 0 || gap <= 0 {
+		return 0
+	}
+	limit := gap * time.Duration(percent) / 100
+	if cfg.MaxJitterSeconds > 0 {
+		limit = min(limit, time.Duration(cfg.MaxJitterSeconds)*time.Second)
+	}
+	if limit <= 0 {
+		return 0
+	}
+	jitter := rand.N(limit)
+	if !delayOnly {
+		jitter -= limit / 2
+	}
+	return jitter
+}
@@ -1,65 +1,54 @@
 package service
 
 import (
-	"Distributed-Health-Monitoring/config"
+	"Distributed-Health-Monitoring/logging"
+	"Distributed-Health-Monitoring/secrets"
+	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"sync"
 	"time"
-
-	"github.com/streadway/amqp"
 )
 
 // HealthCheckJob represents a job to check a service
 type HealthCheckJob struct {
+	JobID       string        `json:"job_id,omitempty"`     // unique per published message; workers process each once
+	ServiceID   uint          `json:"service_id,omitempty"` // jobs of a service deleted since are dropped, even if its name is reused
 	ServiceName string        `json:"service_name"`
 	URL         string        `json:"url"`
 	Timeout     time.Duration `json:"timeout"`
 	Method      string        `json:"method"`
+	Region      string        `json:"region,omitempty"`  // set on the jobs of services that require regions
+	Recheck     int           `json:"recheck,omitempty"` // set on the re-checks confirming a failure: which one, from 1
+
+	InMaintenance bool `json:"in_maintenance"` // DOWN transitions from this check must not alert
+
+	CorrelationID string    `json:"correlation_id,omitempty"` // ties the worker's logs to whoever scheduled the job
+	ScheduledAt   time.Time `json:"scheduled_at,omitempty"`   // the worker's lag is measured from here
+	DueAt         time.Time `json:"due_at,omitempty"`         // when the service became due; schedule delay is measured from here
 }
 
-// Scheduler handles scheduling health checks
+// Scheduler publishes health check jobs to the configured Queue. Jobs of
+// services that require regions go to the queue of each region, which is
+// opened the first time a job for it is published.
 type Scheduler struct {
-	amqpConn    *amqp.Connection
-	amqpChannel *amqp.Channel
-	queueName   string
-}
+	queue Queue
+	open  func(region string) (Queue, error)
 
-// NewScheduler connects to RabbitMQ and returns a Scheduler
-func (e *Engine) NewScheduler(cfg *config.Config) (*Scheduler, error) {
-	// Get RabbitMQ configuration
-	rbtCnfg := cfg.RabbitMQ
+	mu       sync.Mutex
+	regional map[string]Queue
+}
 
-	url := fmt.Sprintf("amqp://%s:%s@%s:%d%s",
-		rbtCnfg.Username, rbtCnfg.Password, rbtCnfg.Host, rbtCnfg.Port, rbtCnfg.VHost)
-	conn, err := amqp.Dial(url)
+// NewScheduler connects to the queue and returns a Scheduler
+func (e *Engine) NewScheduler() (*Scheduler, error) {
+	queue, err := e.openQueue("")
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to RabbitMQ: %w", err)
+		return nil, err
 	}
 
-	ch, err := conn.Channel()
-	if err != nil {
-		return nil, fmt.Errorf("failed to open channel: %w", err)
-	}
+	e.health.setPublisher(queue)
 
-	// declare the queue
-	_, err = ch.QueueDeclare(
-		rbtCnfg.QueueName,
-		true,  // durable
-		false, // autoDelete
-		false, // exclusive
-		false, // noWait
-		nil,   // args
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to declare queue: %w", err)
-	}
-
-	return &Scheduler{
-		amqpConn:    conn,
-		amqpChannel: ch,
-		queueName:   rbtCnfg.QueueName,
-	}, nil
+	return &Scheduler{queue: queue, open: e.openQueue, regional: make(map[string]Queue)}, nil
 }
 
 // Schedule adds a health check job to the queue
@@ -70,18 +59,13 @@ func (s *Scheduler) Schedule(job HealthCheckJob) error {
 		return fmt.Errorf("failed to marshal job: %w", err)
 	}
 
-	err = s.amqpChannel.Publish(
-		"",
-		s.queueName,
-		false,
-		false,
-		amqp.Publishing{
-			ContentType: "application/json",
-			Body:        body,
-			Timestamp:   time.Now(),
-		},
-	)
+	queue, err := s.queueFor(job.Region)
 	if err != nil {
+		LogJobScheduleError(job, err)
+		return err
+	}
+
+	if err := queue.Publish(job.Context(), body); err != nil {
 		LogJobScheduleError(job, err)
 		return fmt.Errorf("failed to publish job: %w", err)
 	}
@@ -90,27 +74,75 @@ func (s *Scheduler) Schedule(job HealthCheckJob) error {
 	return nil
 }
 
+func (s *Scheduler) queueFor(region string) (Queue, error) {
+	if region == "" {
+		return s.queue, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if q, ok := s.regional[region]; ok {
+		return q, nil
+	}
+	q, err := s.open(region)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open queue of region %s: %w", region, err)
+	}
+	s.regional[region] = q
+	return q, nil
+}
+
+// Pending reports the jobs waiting in the shared and regional queues
+func (s *Scheduler) Pending() (int, error) {
+	pending, err := s.queue.Pending(context.Background())
+	if err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, q := range s.regional {
+		if q == s.queue {
+			continue
+		}
+		n, err := q.Pending(context.Background())
+		if err != nil {
+			return 0, err
+		}
+		pending += n
+	}
+	return pending, nil
+}
+
 // Close cleans up connections
 func (s *Scheduler) Close() {
-	s.amqpChannel.Close()
-	s.amqpConn.Close()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, q := range s.regional {
+		if q != s.queue {
+			q.Close()
+		}
+	}
+	s.queue.Close()
 }
 
 func LogJobScheduled(job HealthCheckJob) {
-	log.Printf(
-		"[SCHEDULER] job_scheduled service=%s method=%s url=%s timeout=%s at=%s",
-		job.ServiceName,
-		job.Method,
-		job.URL,
-		job.Timeout,
-		time.Now().Format(time.RFC3339),
+	logging.For(job.Context(), "scheduler").Info(
+		"job_scheduled",
+		"service", job.ServiceName,
+		"method", job.Method,
+		"region", job.Region,
+		"url", secrets.RedactURL(job.URL),
+		"timeout", job.Timeout,
+		"in_maintenance", job.InMaintenance,
 	)
 }
 
 func LogJobScheduleError(job HealthCheckJob, err error) {
-	log.Printf(
-		"[SCHEDULER] job_schedule_failed service=%s error=%v",
-		job.ServiceName,
-		err,
-	)
-}
\ No newline at end of file
+	logging.For(job.Context(), "scheduler").Error("job_schedule_failed", "service", job.ServiceName, "err", err)
+}
+
+// Context carries the job's correlation id for logging
+func (job HealthCheckJob) Context() context.Context {
+	return logging.WithCorrelationID(context.Background(), job.CorrelationID)
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/Repository"
+	"Distributed-Health-Monitoring/clock"
+	"Distributed-Health-Monitoring/logging"
+	"Distributed-Health-Monitoring/models"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// SystemTag marks the monitor's own dependency checks. It is reserved:
+	// the register and import APIs reject it.
+	SystemTag = "system"
+
+	// systemReplicaPrefix names the per-replica checks; every replica
+	// registers its own, so none of them prunes another's
+	systemReplicaPrefix = "system/monitor/"
+
+	defaultSelfMonitorInterval = 30
+	defaultReplicaTTL          = 24 * time.Hour
+)
+
+// errReservedTag is returned when a client tries to use the system tag
+var errReservedTag = fmt.Errorf("the %q tag is reserved for self-monitoring", SystemTag)
+
+func checkReservedTags(s *models.ExternalService) error {
+	if s.HasTag(SystemTag) {
+		return errReservedTag
+	}
+	if s.HasTag(BenchTag) {
+		return fmt.Errorf("the %q tag is reserved for bench runs", BenchTag)
+	}
+	return nil
+}
+
+// BootstrapSelfMonitors registers checks for this monitor's database,
+// queue broker and, in HA setups, its own /ping endpoint. Existing checks are
+// updated in place, and system checks for dependencies no longer in use are removed.
+func (e *Engine) BootstrapSelfMonitors() {
+	if !e.Cnfg.SelfMonitor.Enabled {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(withSystemActor(context.Background(), "self_monitor"), 30*time.Second)
+	defer cancel()
+
+	logger := logging.For(ctx, "self_monitor")
+	desired := e.selfMonitors()
+
+	services, err := e.Repo.GetAllServices(ctx)
+	if err != nil && !errors.Is(err, Repository.ErrNoServices) {
+		logger.Error("fetch_services_failed", "err", err)
+		return
+	}
+	byName := make(map[string]*models.ExternalService, len(services))
+	for _, s := range services {
+		byName[s.Name] = s
+	}
+
+	for _, def := range desired {
+		existing := byName[def.Name]
+		if existing == nil {
+			if existing, err = e.restoreSyncedService(ctx, def.Name, SystemTag); err != nil {
+				logger.Error("restore_failed", "service", def.Name, "err", err)
+				continue
+			}
+		}
+		if existing != nil && !existing.HasTag(SystemTag) {
+			logger.Warn("name_conflict", "service", def.Name, "skipped", true)
+			continue
+		}
+
+		result := e.importService(ctx, def, existing)
+		if result.Error != "" {
+			logger.Error("register_failed", "service", def.Name, "err", result.Error)
+			continue
+		}
+		logger.Info("service_"+result.Action, "service", def.Name, "id", result.ID)
+	}
+
+	wanted := make(map[string]bool, len(desired))
+	for _, def := range desired {
+		wanted[def.Name] = true
+	}
+	for name, s := range byName {
+		if !s.HasTag(SystemTag) || wanted[name] {
+			continue
+		}
+		reason := "dependency no longer in use"
+		if strings.HasPrefix(name, systemReplicaPrefix) {
+			if !e.replicaGone(ctx, s) {
+				continue
+			}
+			reason = "replica gone"
+		}
+		if _, err := e.archiveAndDelete(ctx, s, reason, false); err != nil {
+			logger.Error("remove_failed", "service", name, "err", err)
+			continue
+		}
+		logger.Info("service_removed", "service", name, "reason", reason)
+	}
+}
+
+// replicaGone reports whether another replica's check has been DOWN for
+// longer than self_monitor.replica_ttl_seconds, so the replica was scaled
+// away rather than restarted. A replica that comes back registers its check
+// again.
+func (e *Engine) replicaGone(ctx context.Context, s *models.ExternalService) bool {
+	if s.Status != "DOWN" {
+		return false
+	}
+	ttl := defaultReplicaTTL
+	if e.Cnfg.SelfMonitor.ReplicaTTLSeconds > 0 {
+		ttl = time.Duration(e.Cnfg.SelfMonitor.ReplicaTTLSeconds) * time.Second
+	}
+
+	transitions, _, err := e.Repo.ListStateTransitions(ctx, models.TransitionFilter{ServiceID: s.ID, Limit: 1})
+	if err != nil {
+		logging.For(ctx, "self_monitor").Error("transitions_fetch_failed", "service", s.Name, "err", err)
+		return false
+	}
+	// Without a recorded transition the check has been DOWN since it was registered
+	since := s.CreatedAt
+	if len(transitions) > 0 {
+		since = transitions[0].ChangedAt
+	}
+	return clock.Now().Sub(since) > ttl
+}
+
+func (e *Engine) selfMonitors() []*models.ExternalService {
+	interval := e.Cnfg.SelfMonitor.Interval
+	if interval <= 0 {
+		interval = defaultSelfMonitorInterval
+	}
+	check := func(name, protocol, url string) *models.ExternalService {
+		s := &models.ExternalService{
+			Name:             name,
+			URL:              url,
+			Protocol:         protocol,
+			Interval:         interval,
+			TimeoutSeconds:   5,
+			FailureThreshold: 2,
+			Tags:             []string{SystemTag},
+		}
+		if protocol == "HTTP" {
+			s.HTTPMethod = "GET"
+		}
+		return s
+	}
+
+	var monitors []*models.ExternalService
+	switch e.Cnfg.Database.DriverName() {
+	case "postgres":
+		pg := e.Cnfg.PostgreSQL
+		monitors = append(monitors, check("system/postgres", "TCP", net.JoinHostPort(pg.Host, strconv.Itoa(pg.Port))))
+	case "mysql":
+		my := e.Cnfg.Database.MySQL
+		monitors = append(monitors, check("system/mysql", "TCP", net.JoinHostPort(my.Host, strconv.Itoa(my.Port))))
+	}
+
+	switch e.Cnfg.Queue.DriverName() {
+	case "rabbitmq":
+		mq := e.Cnfg.RabbitMQ
+		monitors = append(monitors, check("system/rabbitmq", "TCP", net.JoinHostPort(mq.Host, strconv.Itoa(mq.Port))))
+	case "redis":
+		monitors = append(monitors, check("system/redis", "TCP", e.Cnfg.Queue.Redis.Address))
+	}
+
+	// A replica can't usefully probe itself, but its peers can: each one
+	// registers its advertised /ping and whoever leads the scheduler checks it
+	if e.Cnfg.HA.Enabled && e.Cnfg.SelfMonitor.AdvertiseURL != "" {
+		url := strings.TrimRight(e.Cnfg.SelfMonitor.AdvertiseURL, "/") + "/ping"
+		monitors = append(monitors, check(systemReplicaPrefix+e.Cnfg.HA.Instance(), "HTTP", url))
+	}
+
+	return monitors
+}
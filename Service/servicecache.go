@@ -0,0 +1,34 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/Repository"
+	"Distributed-Health-Monitoring/cache"
+	"Distributed-Health-Monitoring/logging"
+	"context"
+	"errors"
+	"time"
+)
+
+// WarmCache loads every service into the service cache in one query, so
+// the first requests and scheduler tick after a restart are served from
+// memory. Call it once the database is up and before serving traffic.
+func (e *Engine) WarmCache(ctx context.Context) error {
+	start := time.Now()
+	services, err := e.Repo.GetAllServices(ctx)
+	if err != nil && !errors.Is(err, Repository.ErrNoServices) {
+		return err
+	}
+
+	logging.For(ctx, "cache").Info("warmed", "services", len(services), "duration_ms", time.Since(start).Milliseconds())
+	return nil
+}
+
+// forgetService drops what this replica keeps in memory about a deleted
+// service: its cache entry, pending chaos injections, flapping history and
+// fairness counters. Jobs still queued for it are dropped by the worker.
+func (e *Engine) forgetService(id uint) {
+	cache.Services.Delete(id)
+	e.Chaos.Clear(id)
+	e.Flapping.Forget(id)
+	e.fairness.forget(id)
+}
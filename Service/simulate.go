@@ -0,0 +1,175 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/config"
+	"Distributed-Health-Monitoring/models"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// SimulationOptions configures a scheduler backtest against a fake clock
+type SimulationOptions struct {
+	Duration time.Duration // simulated wall time
+	Latency  time.Duration // assumed check latency, capped by each service timeout
+	Queue    string        // queue the jobs are published to
+
+	Jitter config.Scheduler // jitter_percent and max_jitter_seconds
+}
+
+// SimulationReport summarises the load the scheduler would generate
+type SimulationReport struct {
+	Duration          string         `json:"duration"`
+	Services          int            `json:"services"`
+	Ticks             int            `json:"ticks"`
+	JobsPublished     int            `json:"jobs_published"`
+	ChecksCompleted   int            `json:"checks_completed"`
+	DuplicateJobs     int            `json:"duplicate_jobs"` // jobs published while another was outstanding for the service
+	JobsPerQueue      map[string]int `json:"jobs_per_queue"`
+	JobsPerSecond     float64        `json:"jobs_per_second"`
+	DBWrites          int            `json:"db_writes"`
+	DBWritesPerSecond float64        `json:"db_writes_per_second"`
+	DBReads           int            `json:"db_reads"`
+	PeakConcurrency   int            `json:"peak_concurrency"`
+	PeakConcurrencyAt string         `json:"peak_concurrency_at"`
+	BusiestServices   []ServiceLoad  `json:"busiest_services"`
+}
+
+type ServiceLoad struct {
+	Name string `json:"name"`
+	Jobs int    `json:"jobs"`
+}
+
+type simulatedJob struct {
+	service *models.ExternalService
+	doneAt  time.Time
+}
+
+// writesPerCheck is the number of DB writes per job: the scheduler's
+// in-flight marker, the check log insert, the last response upsert and the
+// service state update
+const writesPerCheck = 4
+
+// Simulate runs the scheduler decision logic on a fake clock. Workers are
+// assumed to pick jobs up immediately, so concurrency equals outstanding jobs.
+func Simulate(services []*models.ExternalService, opts SimulationOptions) SimulationReport {
+	start := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(opts.Duration)
+
+	// Work on copies so the caller's definitions are untouched
+	catalog := make([]*models.ExternalService, 0, len(services))
+	for _, s := range services {
+		c := *s
+		c.LastCheckedAt = nil
+		c.NextRunAt = nil
+		c.InFlightUntil = nil
+		catalog = append(catalog, &c)
+	}
+
+	report := SimulationReport{
+		Duration:     opts.Duration.String(),
+		Services:     len(catalog),
+		JobsPerQueue: make(map[string]int),
+	}
+
+	perService := make(map[string]int)
+	outstanding := make(map[*models.ExternalService]int)
+	var inFlight []simulatedJob
+
+	for now := start; now.Before(end); now = now.Add(SchedulerTick) {
+		report.Ticks++
+		report.DBReads++ // GetAllServices + maintenance windows
+
+		// Complete jobs that finished since the last tick
+		remaining := inFlight[:0]
+		for _, j := range inFlight {
+			if j.doneAt.After(now) {
+				remaining = append(remaining, j)
+				continue
+			}
+			done := j.doneAt
+			j.service.LastCheckedAt = &done
+			j.service.InFlightUntil = nil
+			outstanding[j.service]--
+			report.ChecksCompleted++
+			report.DBWrites += writesPerCheck
+		}
+		inFlight = remaining
+
+		for _, s := range catalog {
+			if !shouldRun(s, now) {
+				continue
+			}
+
+			if outstanding[s] > 0 {
+				report.DuplicateJobs++
+			}
+			outstanding[s]++
+			markScheduled(s, now, opts.Jitter)
+
+			report.JobsPublished++
+			report.JobsPerQueue[opts.Queue]++
+			report.DBReads++ // GetServiceByName in the worker
+			perService[s.Name]++
+
+			latency := opts.Latency
+			if timeout := time.Duration(s.TimeoutSeconds) * time.Second; timeout > 0 && latency > timeout {
+				latency = timeout
+			}
+			inFlight = append(inFlight, simulatedJob{service: s, doneAt: now.Add(latency)})
+		}
+
+		if len(inFlight) > report.PeakConcurrency {
+			report.PeakConcurrency = len(inFlight)
+			report.PeakConcurrencyAt = now.Sub(start).String()
+		}
+	}
+
+	seconds := opts.Duration.Seconds()
+	if seconds > 0 {
+		report.JobsPerSecond = float64(report.JobsPublished) / seconds
+		report.DBWritesPerSecond = float64(report.DBWrites) / seconds
+	}
+
+	for name, jobs := range perService {
+		report.BusiestServices = append(report.BusiestServices, ServiceLoad{Name: name, Jobs: jobs})
+	}
+	sort.Slice(report.BusiestServices, func(i, j int) bool {
+		if report.BusiestServices[i].Jobs != report.BusiestServices[j].Jobs {
+			return report.BusiestServices[i].Jobs > report.BusiestServices[j].Jobs
+		}
+		return report.BusiestServices[i].Name < report.BusiestServices[j].Name
+	})
+	if len(report.BusiestServices) > 10 {
+		report.BusiestServices = report.BusiestServices[:10]
+	}
+
+	return report
+}
+
+// Print writes a human-readable version of the report
+func (r SimulationReport) Print(w io.Writer) {
+	fmt.Fprintf(w, "Simulated %s over %d services (%d scheduler ticks)\n\n", r.Duration, r.Services, r.Ticks)
+	fmt.Fprintf(w, "Jobs published:      %d (%.2f/s)\n", r.JobsPublished, r.JobsPerSecond)
+	fmt.Fprintf(w, "Checks completed:    %d\n", r.ChecksCompleted)
+	fmt.Fprintf(w, "Duplicate jobs:      %d\n", r.DuplicateJobs)
+	fmt.Fprintf(w, "DB writes:           %d (%.2f/s)\n", r.DBWrites, r.DBWritesPerSecond)
+	fmt.Fprintf(w, "DB reads:            %d\n", r.DBReads)
+	fmt.Fprintf(w, "Peak concurrency:    %d (at +%s)\n\n", r.PeakConcurrency, r.PeakConcurrencyAt)
+
+	fmt.Fprintln(w, "Jobs per queue:")
+	queues := make([]string, 0, len(r.JobsPerQueue))
+	for q := range r.JobsPerQueue {
+		queues = append(queues, q)
+	}
+	sort.Strings(queues)
+	for _, q := range queues {
+		fmt.Fprintf(w, "  %-24s %d\n", q, r.JobsPerQueue[q])
+	}
+
+	fmt.Fprintln(w, "\nBusiest services:")
+	for _, s := range r.BusiestServices {
+		fmt.Fprintf(w, "  %-24s %d\n", s.Name, s.Jobs)
+	}
+}
@@ -0,0 +1,408 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/Repository"
+	"Distributed-Health-Monitoring/config"
+	"Distributed-Health-Monitoring/logging"
+	"Distributed-Health-Monitoring/models"
+	"Distributed-Health-Monitoring/notify"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const defaultSLOInterval = time.Minute
+
+// defaultSLOAlerts are the multiwindow rules of the Google SRE workbook: 2%
+// of a 30-day budget spent within an hour pages, 5% within six hours warns
+var defaultSLOAlerts = []config.SLOAlert{
+	{LongWindowMinutes: 60, ShortWindowMinutes: 5, BurnRate: 14.4, Severity: notify.SeverityCritical},
+	{LongWindowMinutes: 360, ShortWindowMinutes: 30, BurnRate: 6, Severity: notify.SeverityWarning},
+}
+
+const (
+	sloSuccess = "success"
+	sloLatency = "latency"
+)
+
+// SLOReport is the response of GET /slo/:serviceId
+type SLOReport struct {
+	ServiceID  uint           `json:"service_id"`
+	Service    string         `json:"service"`
+	SLO        models.SLO     `json:"slo"`
+	From       time.Time      `json:"from"` // start of the SLO window
+	To         time.Time      `json:"to"`
+	Checks     int64          `json:"checks"`
+	Objectives []SLOObjective `json:"objectives"`
+}
+
+// SLOObjective is the state of one objective over the SLO window
+type SLOObjective struct {
+	Objective   string  `json:"objective"` // success or latency
+	Target      float64 `json:"target"`    // percent of counted checks that must be good
+	ThresholdMs int64   `json:"threshold_ms,omitempty"`
+	// Counted checks: every check for success, successful ones for latency
+	GoodChecks int64   `json:"good_checks"`
+	BadChecks  int64   `json:"bad_checks"`
+	Actual     float64 `json:"actual"`                // percent of good checks; 100 without checks
+	ObservedMs *int64  `json:"observed_ms,omitempty"` // latency: the response time at the target percentile
+	// Bad checks the window allows at its current number of checks, and the
+	// share of it left: 1 untouched, 0 spent, below 0 overspent
+	ErrorBudget          float64         `json:"error_budget_checks"`
+	ErrorBudgetRemaining float64         `json:"error_budget_remaining"`
+	BurnRates            []SLOBurnRate   `json:"burn_rates"`
+	Firing               []SLOFiringRule `json:"firing"` // burn-rate rules over their threshold now
+}
+
+// SLOBurnRate is how fast an objective spends its budget over a recent
+// window: 1 spends it exactly over the SLO window, 10 in a tenth of it
+type SLOBurnRate struct {
+	WindowMinutes int     `json:"window_minutes"`
+	Checks        int64   `json:"checks"`
+	BurnRate      float64 `json:"burn_rate"`
+}
+
+// SLOFiringRule is a slo.alerts rule whose both windows burn too fast
+type SLOFiringRule struct {
+	LongWindowMinutes  int     `json:"long_window_minutes"`
+	ShortWindowMinutes int     `json:"short_window_minutes"`
+	BurnRate           float64 `json:"burn_rate"`
+	Severity           string  `json:"severity"`
+}
+
+// sloObjective classifies check logs for one objective of an SLO
+type sloObjective struct {
+	name      string
+	target    float64
+	threshold int64
+	// classify reports whether a check counts for the objective and whether
+	// it missed it
+	classify func(l *models.ServiceCheckLog) (counted, bad bool)
+}
+
+// sloObjectives returns the objectives a service defines. The latency one
+// only counts successful checks, so an outage spends the success budget
+// rather than both.
+func sloObjectives(slo *models.SLO) []sloObjective {
+	var objectives []sloObjective
+	if slo.SuccessTarget > 0 {
+		objectives = append(objectives, sloObjective{
+			name:   sloSuccess,
+			target: slo.SuccessTarget,
+			classify: func(l *models.ServiceCheckLog) (bool, bool) {
+				return true, !models.IsAvailable(l.Status)
+			},
+		})
+	}
+	if slo.LatencyPercentile > 0 {
+		threshold := slo.LatencyThresholdMs
+		objectives = append(objectives, sloObjective{
+			name:      sloLatency,
+			target:    slo.LatencyPercentile,
+			threshold: threshold,
+			classify: func(l *models.ServiceCheckLog) (bool, bool) {
+				if !models.IsAvailable(l.Status) {
+					return false, false
+				}
+				return true, l.ResponseTimeMs > threshold
+			},
+		})
+	}
+	return objectives
+}
+
+// allowed is the share of counted checks that may miss the objective
+func (o sloObjective) allowed() float64 {
+	return 1 - o.target/100
+}
+
+// tally counts the checks since the given time; logs are oldest first
+func (o sloObjective) tally(logs []*models.ServiceCheckLog, since time.Time) (counted, bad int64) {
+	start := sort.Search(len(logs), func(i int) bool { return !logs[i].CheckedAt.Before(since) })
+	for _, l := range logs[start:] {
+		if c, b := o.classify(l); c {
+			counted++
+			if b {
+				bad++
+			}
+		}
+	}
+	return counted, bad
+}
+
+// burnRate is the share of bad checks since the given time over the allowed share
+func (o sloObjective) burnRate(logs []*models.ServiceCheckLog, since time.Time) (int64, float64) {
+	counted, bad := o.tally(logs, since)
+	if counted == 0 {
+		return 0, 0
+	}
+	return counted, float64(bad) / float64(counted) / o.allowed()
+}
+
+// fires reports whether both windows of the rule burn at least its rate,
+// and the rates of the long and the short window
+func (o sloObjective) fires(logs []*models.ServiceCheckLog, rule config.SLOAlert, now time.Time) (bool, float64, float64) {
+	_, long := o.burnRate(logs, now.Add(-time.Duration(rule.LongWindowMinutes)*time.Minute))
+	_, short := o.burnRate(logs, now.Add(-time.Duration(rule.ShortWindowMinutes)*time.Minute))
+	return long >= rule.BurnRate && short >= rule.BurnRate, long, short
+}
+
+// observedLatency is the response time of the successful checks at the
+// percentile, by nearest rank
+func observedLatency(logs []*models.ServiceCheckLog, percentile float64) *int64 {
+	var latencies []int64
+	for _, l := range logs {
+		if models.IsAvailable(l.Status) {
+			latencies = append(latencies, l.ResponseTimeMs)
+		}
+	}
+	if len(latencies) == 0 {
+		return nil
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	rank := int(math.Ceil(percentile / 100 * float64(len(latencies))))
+	v := latencies[max(rank, 1)-1]
+	return &v
+}
+
+// sloAlertRules returns the configured burn-rate rules, or the defaults
+func sloAlertRules(cfg config.SLO) []config.SLOAlert {
+	if len(cfg.Alerts) == 0 {
+		return defaultSLOAlerts
+	}
+	return cfg.Alerts
+}
+
+func validateSLOAlerts(cfg config.SLO) error {
+	for i, rule := range cfg.Alerts {
+		if rule.ShortWindowMinutes <= 0 || rule.LongWindowMinutes <= rule.ShortWindowMinutes {
+			return fmt.Errorf("slo: alert %d needs 0 < short_window_minutes < long_window_minutes", i+1)
+		}
+		if rule.BurnRate <= 0 {
+			return fmt.Errorf("slo: alert %d burn_rate must be positive", i+1)
+		}
+		if rule.Severity != "" && !notify.ValidSeverity(rule.Severity) {
+			return fmt.Errorf("slo: alert %d has invalid severity %q", i+1, rule.Severity)
+		}
+	}
+	return nil
+}
+
+// buildSLOReport evaluates every objective of a service over logs covering
+// its SLO window, oldest first
+func buildSLOReport(service *models.ExternalService, logs []*models.ServiceCheckLog, rules []config.SLOAlert, from, now time.Time) SLOReport {
+	report := SLOReport{
+		ServiceID:  service.ID,
+		Service:    service.Name,
+		SLO:        *service.SLO,
+		From:       from,
+		To:         now,
+		Checks:     int64(len(logs)),
+		Objectives: []SLOObjective{},
+	}
+
+	// Every window a rule looks at, shortest first
+	seen := map[int]bool{}
+	var windows []int
+	for _, rule := range rules {
+		for _, w := range []int{rule.ShortWindowMinutes, rule.LongWindowMinutes} {
+			if !seen[w] {
+				seen[w] = true
+				windows = append(windows, w)
+			}
+		}
+	}
+	sort.Ints(windows)
+
+	for _, o := range sloObjectives(service.SLO) {
+		counted, bad := o.tally(logs, from)
+		view := SLOObjective{
+			Objective:            o.name,
+			Target:               o.target,
+			ThresholdMs:          o.threshold,
+			GoodChecks:           counted - bad,
+			BadChecks:            bad,
+			Actual:               100,
+			ErrorBudget:          o.allowed() * float64(counted),
+			ErrorBudgetRemaining: 1,
+			BurnRates:            make([]SLOBurnRate, 0, len(windows)),
+			Firing:               []SLOFiringRule{},
+		}
+		if counted > 0 {
+			view.Actual = float64(counted-bad) / float64(counted) * 100
+			view.ErrorBudgetRemaining = 1 - float64(bad)/view.ErrorBudget
+		}
+		if o.name == sloLatency {
+			view.ObservedMs = observedLatency(logs, o.target)
+		}
+		for _, w := range windows {
+			checks, rate := o.burnRate(logs, now.Add(-time.Duration(w)*time.Minute))
+			view.BurnRates = append(view.BurnRates, SLOBurnRate{WindowMinutes: w, Checks: checks, BurnRate: rate})
+		}
+		for _, rule := range rules {
+			if firing, _, _ := o.fires(logs, rule, now); firing {
+				view.Firing = append(view.Firing, SLOFiringRule(rule))
+			}
+		}
+		report.Objectives = append(report.Objectives, view)
+	}
+	return report
+}
+
+// GetSLO reports the objectives of a service with their error budgets and
+// burn rates, computed from the check logs of the SLO window
+func (e *Engine) GetSLO(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("serviceId"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "invalid service id"})
+		return
+	}
+
+	service, ok := e.serviceByID(c, uint(id))
+	if !ok {
+		return
+	}
+	if service.SLO == nil {
+		c.JSON(404, gin.H{"error": "service has no slo"})
+		return
+	}
+
+	now := time.Now().UTC()
+	from := now.AddDate(0, 0, -service.SLO.WindowDays)
+	logs, err := e.Repo.GetServiceCheckLogsInRange(c.Request.Context(), service.ID, from, now)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, buildSLOReport(service, logs, sloAlertRules(e.Cnfg.SLO), from, now))
+}
+
+// SLOEvaluator periodically computes the burn rates of every service with an
+// SLO and alerts when a rule of slo.alerts starts or stops firing. With HA
+// only the leader evaluates; which rules fire is kept in memory, so a new
+// leader alerts again for burning that is still going on.
+func (e *Engine) SLOEvaluator(ctx context.Context) error {
+	if !e.Cnfg.SLO.Enabled {
+		return nil
+	}
+
+	interval := time.Duration(e.Cnfg.SLO.IntervalSeconds) * time.Second
+	if interval <= 0 {
+		interval = defaultSLOInterval
+	}
+	rules := sloAlertRules(e.Cnfg.SLO)
+
+	logging.For(ctx, "slo").Info("started", "rules", len(rules), "interval", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	firing := make(map[string]bool) // "service id/objective/rule index"
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if e.Leader != nil && !e.Leader.Leader() {
+				continue
+			}
+			e.evaluateSLOs(ctx, time.Now().UTC(), rules, firing)
+		}
+	}
+}
+
+func (e *Engine) evaluateSLOs(ctx context.Context, now time.Time, rules []config.SLOAlert, firing map[string]bool) {
+	logger := logging.For(ctx, "slo")
+
+	services, err := e.Repo.GetAllServices(ctx)
+	if err != nil {
+		if !errors.Is(err, Repository.ErrNoServices) {
+			logger.Error("fetch_services_failed", "err", err)
+		}
+		return
+	}
+
+	// Burning during maintenance is expected; it alerts once the window ends
+	inMaintenance, err := e.Repo.ServicesInMaintenance(ctx, now)
+	if err != nil {
+		logger.Error("fetch_maintenance_windows_failed", "err", err)
+		inMaintenance = map[uint]bool{}
+	}
+
+	longest := 0
+	for _, rule := range rules {
+		longest = max(longest, rule.LongWindowMinutes)
+	}
+
+	live := make(map[string]bool)
+	for _, service := range services {
+		if service.SLO == nil {
+			continue
+		}
+		logs, err := e.Repo.GetServiceCheckLogsInRange(ctx, service.ID, now.Add(-time.Duration(longest)*time.Minute), now)
+		if err != nil {
+			logger.Error("fetch_logs_failed", "service", service.Name, "err", err)
+			continue
+		}
+
+		for _, o := range sloObjectives(service.SLO) {
+			for i, rule := range rules {
+				key := fmt.Sprintf("%d/%s/%d", service.ID, o.name, i)
+				live[key] = true
+
+				fires, long, short := o.fires(logs, rule, now)
+				if fires == firing[key] || (fires && inMaintenance[service.ID]) {
+					continue
+				}
+				firing[key] = fires
+				e.sloAlert(ctx, service, o, rule, fires, long, short, now)
+			}
+		}
+	}
+
+	// Services deleted or without an SLO any more
+	for key := range firing {
+		if !live[key] {
+			delete(firing, key)
+		}
+	}
+}
+
+func (e *Engine) sloAlert(ctx context.Context, service *models.ExternalService, o sloObjective, rule config.SLOAlert, fires bool, long, short float64, now time.Time) {
+	alert := notify.Alert{
+		Type:      "slo_burn",
+		ServiceID: service.ID,
+		Service:   service.Name,
+		Tags:      service.Tags,
+		Ownership: service.Ownership(),
+		Metadata:  service.Metadata,
+		Reason: fmt.Sprintf("%s objective burning %.1fx over %dm and %.1fx over %dm (threshold %gx)",
+			o.name, long, rule.LongWindowMinutes, short, rule.ShortWindowMinutes, rule.BurnRate),
+		Timestamp: now,
+	}
+	if fires {
+		alert.Severity = rule.Severity
+		if alert.Severity == "" {
+			alert.Severity = e.Notifier.Severity("slo_burn")
+		}
+	} else {
+		alert.Type = "slo_burn_end"
+		alert.Severity = e.Notifier.Severity("slo_burn_end")
+	}
+
+	logging.For(ctx, "slo").Info(alert.Type,
+		"service", service.Name,
+		"objective", o.name,
+		"long_window_minutes", rule.LongWindowMinutes,
+		"burn_rate", long,
+		"severity", alert.Severity,
+	)
+	e.Notifier.Dispatch(alert)
+}
@@ -0,0 +1,261 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/models"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"html/template"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	statusPageDays = 90
+	statusPageTTL  = 60 * time.Second
+)
+
+// StatusSummary is the public view of the monitored services. It never
+// includes URLs, error messages or other internals.
+type StatusSummary struct {
+	Status    string           `json:"status"` // worst status over the public services
+	Services  []PublicService  `json:"services"`
+	Incidents []PublicIncident `json:"incidents"`
+	UpdatedAt time.Time        `json:"updated_at"`
+}
+
+type PublicService struct {
+	Name          string      `json:"name"`
+	Status        string      `json:"status"`
+	UptimePercent float64     `json:"uptime_percent"` // over the last 90 days
+	Days          []UptimeDay `json:"days"`
+}
+
+// UptimeDay is one bar of the 90-day uptime chart
+type UptimeDay struct {
+	Date          string  `json:"date"`
+	UptimePercent float64 `json:"uptime_percent"`
+	Checks        int     `json:"checks"`
+}
+
+type PublicIncident struct {
+	Service   string    `json:"service"`
+	Reason    string    `json:"reason"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// statusPageCache holds the rendered JSON summary so public traffic never
+// reaches the database more than once per TTL
+type statusPageCache struct {
+	mu      sync.Mutex
+	summary *StatusSummary
+	body    []byte
+	hash    string // of body, used for ETags
+	builtAt time.Time
+}
+
+// GetStatusJSON serves the embeddable status summary
+func (e *Engine) GetStatusJSON(c *gin.Context) {
+	_, body, hash, err := e.cachedStatus(c.Request.Context())
+	if err != nil {
+		c.JSON(500, gin.H{"error": "status unavailable"})
+		return
+	}
+
+	if !setCacheHeaders(c, `"`+hash+`"`) {
+		return
+	}
+	c.Header("Access-Control-Allow-Origin", "*")
+	c.Data(200, "application/json; charset=utf-8", body)
+}
+
+// GetStatusPage serves the same summary as a minimal HTML page
+func (e *Engine) GetStatusPage(c *gin.Context) {
+	summary, _, hash, err := e.cachedStatus(c.Request.Context())
+	if err != nil {
+		c.String(500, "status unavailable")
+		return
+	}
+
+	if !setCacheHeaders(c, `"`+hash+`-html"`) {
+		return
+	}
+
+	var page bytes.Buffer
+	if err := statusPageTemplate.Execute(&page, summary); err != nil {
+		c.String(500, "status unavailable")
+		return
+	}
+	c.Data(200, "text/html; charset=utf-8", page.Bytes())
+}
+
+// setCacheHeaders answers conditional requests; it returns false after a 304
+func setCacheHeaders(c *gin.Context, etag string) bool {
+	c.Header("Cache-Control", "public, max-age=60")
+	c.Header("ETag", etag)
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(304)
+		return false
+	}
+	return true
+}
+
+func (e *Engine) cachedStatus(ctx context.Context) (*StatusSummary, []byte, string, error) {
+	cache := &e.statusPage
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if cache.summary != nil && time.Since(cache.builtAt) < statusPageTTL {
+		return cache.summary, cache.body, cache.hash, nil
+	}
+
+	summary, err := e.buildStatusSummary(ctx)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	body, err := json.Marshal(summary)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	sum := sha256.Sum256(body)
+
+	cache.summary = summary
+	cache.body = body
+	cache.hash = hex.EncodeToString(sum[:8])
+	cache.builtAt = time.Now()
+
+	return cache.summary, cache.body, cache.hash, nil
+}
+
+func (e *Engine) buildStatusSummary(ctx context.Context) (*StatusSummary, error) {
+	now := time.Now().UTC()
+	summary := &StatusSummary{
+		Status:    "UP",
+		Services:  make([]PublicService, 0),
+		Incidents: make([]PublicIncident, 0),
+		UpdatedAt: now,
+	}
+
+	services, err := e.Repo.GetAllServices(ctx)
+	if err != nil {
+		// No services registered yet is an empty page, not an outage
+		services = nil
+	}
+
+	public := make(map[uint]*models.ExternalService)
+	for id, s := range services {
+		if s.Public {
+			public[id] = s
+		}
+	}
+	presented := e.presentServices(ctx, public)
+
+	day := 24 * time.Hour
+	to := now.Truncate(day).Add(day)
+	from := to.Add(-statusPageDays * day)
+
+	for id, s := range public {
+		logs, err := e.Repo.GetServiceCheckLogsInRange(ctx, id, from, now)
+		if err != nil {
+			return nil, err
+		}
+		buckets := buildHeatmap(logs, time.Duration(s.Interval)*time.Second, from, to, now, day)
+
+		view := PublicService{
+			Name:   s.Name,
+			Status: presented[id].Status,
+			Days:   make([]UptimeDay, len(buckets)),
+		}
+
+		var observed, downtime float64
+		for i, b := range buckets {
+			view.Days[i] = UptimeDay{Date: b.Start.Format("2006-01-02"), UptimePercent: 100, Checks: b.Checks}
+			if b.Checks == 0 {
+				continue
+			}
+			minutes := day.Minutes()
+			if b.Start.Add(day).After(now) {
+				minutes = now.Sub(b.Start).Minutes()
+			}
+			view.Days[i].UptimePercent = 100 * (1 - b.DowntimeMinutes/minutes)
+			observed += minutes
+			downtime += b.DowntimeMinutes
+		}
+		view.UptimePercent = 100
+		if observed > 0 {
+			view.UptimePercent = 100 * (1 - downtime/observed)
+		}
+		summary.Services = append(summary.Services, view)
+
+		if statusSeverity[view.Status] > statusSeverity[summary.Status] {
+			summary.Status = view.Status
+		}
+
+		incident, err := e.Repo.GetOpenIncident(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if incident != nil {
+			summary.Incidents = append(summary.Incidents, PublicIncident{
+				Service:   s.Name,
+				Reason:    incident.Reason,
+				StartedAt: incident.StartedAt,
+			})
+		}
+	}
+
+	sort.Slice(summary.Services, func(i, j int) bool { return summary.Services[i].Name < summary.Services[j].Name })
+	sort.Slice(summary.Incidents, func(i, j int) bool {
+		return summary.Incidents[i].StartedAt.After(summary.Incidents[j].StartedAt)
+	})
+
+	return summary, nil
+}
+
+var statusPageTemplate = template.Must(template.New("status").Funcs(template.FuncMap{
+	"barColor": func(d UptimeDay) string {
+		switch {
+		case d.Checks == 0:
+			return "#d0d4d9"
+		case d.UptimePercent >= 99.9:
+			return "#2ea043"
+		case d.UptimePercent >= 95:
+			return "#d29922"
+		default:
+			return "#da3633"
+		}
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Service Status</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 860px; margin: 2rem auto; padding: 0 1rem; color: #1f2328; }
+.overall { padding: 1rem; border-radius: 6px; color: #fff; background: {{if eq .Status "UP"}}#2ea043{{else}}#da3633{{end}}; }
+.service { margin: 1.5rem 0; }
+.bars { display: flex; gap: 2px; height: 28px; }
+.bars span { flex: 1; border-radius: 2px; }
+.meta { display: flex; justify-content: space-between; font-size: .85rem; color: #59636e; }
+</style>
+</head>
+<body>
+<h1>Service Status</h1>
+<div class="overall">{{if eq .Status "UP"}}All systems operational{{else}}Some systems are degraded ({{.Status}}){{end}}</div>
+{{if .Incidents}}<h2>Active incidents</h2>
+<ul>{{range .Incidents}}<li><strong>{{.Service}}</strong> since {{.StartedAt.Format "2006-01-02 15:04 MST"}}{{if .Reason}} ({{.Reason}}){{end}}</li>{{end}}</ul>{{end}}
+{{range .Services}}<div class="service">
+<div class="meta"><strong>{{.Name}}</strong><span>{{.Status}}</span></div>
+<div class="bars">{{range .Days}}<span title="{{.Date}}: {{printf "%.2f" .UptimePercent}}%" style="background: {{barColor .}}"></span>{{end}}</div>
+<div class="meta"><span>90 days ago</span><span>{{printf "%.3f" .UptimePercent}}% uptime</span><span>Today</span></div>
+</div>{{end}}
+<p class="meta">Updated {{.UpdatedAt.Format "2006-01-02 15:04:05 MST"}}</p>
+</body>
+</html>
+`))
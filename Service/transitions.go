@@ -0,0 +1,90 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/apiv1"
+	"Distributed-Health-Monitoring/logging"
+	"Distributed-Health-Monitoring/models"
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// recordTransition stores a status change with the check log that caused
+// it. Transitions are rare, so waiting for a buffered log to be written, to
+// learn its id, is fine here.
+func (e *Engine) recordTransition(ctx context.Context, service *models.ExternalService, change *models.StateChange, result models.CheckResult, checkLog models.ServiceCheckLog, suppressed string) {
+	transition := models.ServiceStateTransition{
+		ExternalServiceID: service.ID,
+		From:              change.From,
+		To:                change.To,
+		Suppressed:        suppressed,
+		ChangedAt:         checkLog.CheckedAt,
+	}
+	if change.To != "UP" {
+		transition.Reason = result.Reason
+	}
+	if transition.ChangedAt.IsZero() {
+		// Decided by a re-check, which isn't logged
+		transition.ChangedAt = time.Now()
+	} else {
+		id, err := e.Repo.CheckLogID(ctx, checkLog)
+		if err != nil {
+			logging.For(ctx, "worker").Warn("trigger_log_lookup_failed", "err", err)
+		}
+		if id != 0 {
+			transition.TriggerLogID = &id
+		}
+	}
+
+	if err := e.Repo.SaveStateTransition(ctx, &transition); err != nil {
+		logging.For(ctx, "worker").Error("transition_save_failed", "from", change.From, "to", change.To, "err", err)
+	}
+}
+
+// ListStateTransitions returns the status changes of a service, newest
+// first unless ?order=asc, optionally within ?from= and ?to=
+func (e *Engine) ListStateTransitions(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("serviceId"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "invalid service id"})
+		return
+	}
+
+	filter := models.TransitionFilter{ServiceID: uint(id), Order: c.DefaultQuery("order", "desc")}
+	if filter.Order != "asc" && filter.Order != "desc" {
+		c.JSON(400, gin.H{"error": "order must be asc or desc"})
+		return
+	}
+	for key, dst := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		raw := c.Query(key)
+		if raw == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			c.JSON(400, gin.H{"error": fmt.Sprintf("%s must be an RFC 3339 time", key)})
+			return
+		}
+		*dst = &t
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		c.JSON(400, gin.H{"error": "from must be before to"})
+		return
+	}
+	filter.Limit, filter.Offset = pageParams(c)
+
+	transitions, total, err := e.Repo.ListStateTransitions(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, struct {
+		ServiceID   uint                            `json:"service_id"`
+		Transitions []models.ServiceStateTransition `json:"transitions"`
+		apiv1.Page
+	}{uint(id), transitions, apiv1.NewPage(total, filter.Limit, filter.Offset)})
+}
@@ -0,0 +1,466 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/config"
+	"Distributed-Health-Monitoring/logging"
+	"Distributed-Health-Monitoring/models"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Webhook event types
+const (
+	WebhookStateChange          = "state_change"
+	WebhookIncidentOpened       = "incident_opened"
+	WebhookIncidentResolved     = "incident_resolved"
+	WebhookIncidentAcknowledged = "incident_acknowledged"
+	WebhookServiceAcknowledged  = "service_acknowledged"
+	WebhookCertExpiry           = "cert_expiry"
+)
+
+var webhookEvents = []string{
+	WebhookStateChange,
+	WebhookIncidentOpened,
+	WebhookIncidentResolved,
+	WebhookIncidentAcknowledged,
+	WebhookServiceAcknowledged,
+	WebhookCertExpiry,
+}
+
+const (
+	defaultWebhookAttempts       = 5
+	defaultWebhookBackoff        = 2 * time.Second
+	defaultWebhookMaxBackoff     = 5 * time.Minute
+	defaultWebhookTimeout        = 10 * time.Second
+	defaultCertExpiryDays        = 14
+	maxWebhookResponseErrorBytes = 512
+)
+
+// webhookPayload is the JSON body POSTed to a webhook. The signature covers
+// "<X-Monitor-Timestamp>.<body>" so a captured request can't be replayed
+// later with a fresh timestamp.
+type webhookPayload struct {
+	ID        string      `json:"id"` // delivery id, the same for every retry
+	Event     string      `json:"event"`
+	Timestamp time.Time   `json:"timestamp"`
+	Data      interface{} `json:"data"`
+}
+
+type webhookSender struct {
+	cfg    config.Webhooks
+	client *http.Client
+
+	mu         sync.Mutex
+	certWarned map[uint]string // service id -> UTC date of the last cert_expiry event
+}
+
+func newWebhookSender(cfg config.Webhooks) *webhookSender {
+	timeout := time.Duration(cfg.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = defaultWebhookTimeout
+	}
+	return &webhookSender{
+		cfg:        cfg,
+		client:     &http.Client{Timeout: timeout},
+		certWarned: make(map[uint]string),
+	}
+}
+
+func (w *webhookSender) maxAttempts() int {
+	if w.cfg.MaxAttempts > 0 {
+		return w.cfg.MaxAttempts
+	}
+	return defaultWebhookAttempts
+}
+
+// backoff returns the wait after the given failed attempt
+func (w *webhookSender) backoff(attempt int) time.Duration {
+	wait, limit := defaultWebhookBackoff, defaultWebhookMaxBackoff
+	if w.cfg.InitialBackoffSeconds > 0 {
+		wait = time.Duration(w.cfg.InitialBackoffSeconds) * time.Second
+	}
+	if w.cfg.MaxBackoffSeconds > 0 {
+		limit = time.Duration(w.cfg.MaxBackoffSeconds) * time.Second
+	}
+	for i := 1; i < attempt && wait < limit; i++ {
+		wait *= 2
+	}
+	return min(wait, limit)
+}
+
+// claimCertWarning reports whether no cert_expiry event was sent for the
+// service today, recording that one is now
+func (w *webhookSender) claimCertWarning(serviceID uint, now time.Time) bool {
+	day := now.UTC().Format(time.DateOnly)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.certWarned[serviceID] == day {
+		return false
+	}
+	w.certWarned[serviceID] = day
+	return true
+}
+
+// emitWebhook delivers the event to every enabled webhook subscribed to it.
+// Deliveries run in the background and outlive the request or job that
+// triggered them; they are not resumed after a restart.
+func (e *Engine) emitWebhook(ctx context.Context, event string, service *models.ExternalService, data interface{}) {
+	webhooks, err := e.Repo.ListWebhooks(ctx)
+	if err != nil {
+		logging.For(ctx, "webhook").Error("list_failed", "event", event, "err", err)
+		return
+	}
+
+	now := time.Now().UTC()
+	for _, hook := range webhooks {
+		if !hook.Enabled || !webhookWants(hook, event, service) {
+			continue
+		}
+
+		payload := webhookPayload{ID: logging.NewID(), Event: event, Timestamp: now, Data: data}
+		body, err := json.Marshal(payload)
+		if err != nil {
+			logging.For(ctx, "webhook").Error("encode_failed", "event", event, "err", err)
+			return
+		}
+		go e.deliverWebhook(context.WithoutCancel(ctx), hook, payload, body)
+	}
+}
+
+func webhookWants(hook models.Webhook, event string, service *models.ExternalService) bool {
+	if len(hook.Events) > 0 && !slices.Contains(hook.Events, event) {
+		return false
+	}
+	if len(hook.Tags) == 0 {
+		return true
+	}
+	if service == nil {
+		return false
+	}
+	for _, tag := range hook.Tags {
+		if service.HasTag(tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// deliverWebhook posts the payload until it is accepted, the receiver rejects
+// it with a 4xx other than 408 and 429, or the attempts run out. Every
+// attempt is recorded in the delivery log.
+func (e *Engine) deliverWebhook(ctx context.Context, hook models.Webhook, payload webhookPayload, body []byte) {
+	logger := logging.For(ctx, "webhook").With("webhook_id", hook.ID, "delivery_id", payload.ID, "event", payload.Event)
+	maxAttempts := e.webhooks.maxAttempts()
+
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+		status, err := e.webhooks.post(ctx, hook, payload, body)
+
+		delivery := models.WebhookDelivery{
+			WebhookID:   hook.ID,
+			DeliveryID:  payload.ID,
+			Event:       payload.Event,
+			Attempt:     attempt,
+			Success:     err == nil,
+			StatusCode:  status,
+			DurationMs:  time.Since(start).Milliseconds(),
+			AttemptedAt: start.UTC(),
+		}
+		if err != nil {
+			delivery.Error = err.Error()
+		}
+
+		retry := err != nil && retryableWebhookStatus(status) && attempt < maxAttempts
+		var wait time.Duration
+		if retry {
+			wait = e.webhooks.backoff(attempt)
+			next := start.Add(wait).UTC()
+			delivery.NextRetryAt = &next
+		}
+
+		if saveErr := e.Repo.SaveWebhookDelivery(ctx, &delivery); saveErr != nil {
+			logger.Error("delivery_log_failed", "err", saveErr)
+		}
+
+		switch {
+		case err == nil:
+			logger.Info("delivered", "attempt", attempt, "status_code", status)
+			return
+		case retry:
+			logger.Warn("delivery_retry", "attempt", attempt, "status_code", status, "retry_in", wait, "err", err)
+			time.Sleep(wait)
+		default:
+			logger.Error("delivery_failed", "attempt", attempt, "status_code", status, "err", err)
+			return
+		}
+	}
+}
+
+// post makes one signed delivery attempt, returning the response status (0
+// when there was no response)
+func (w *webhookSender) post(ctx context.Context, hook models.Webhook, payload webhookPayload, body []byte) (int, error) {
+	status, _, err := w.send(ctx, hook, payload, body, 0)
+	return status, err
+}
+
+// send is post that also returns up to keep bytes of an accepted response
+func (w *webhookSender) send(ctx context.Context, hook models.Webhook, payload webhookPayload, body []byte, keep int64) (int, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Distributed-Health-Monitoring-Webhook")
+	req.Header.Set("X-Monitor-Event", payload.Event)
+	req.Header.Set("X-Monitor-Delivery", payload.ID)
+	req.Header.Set("X-Monitor-Timestamp", ts)
+	req.Header.Set("X-Monitor-Signature", "sha256="+signWebhook(hook.Secret, ts, body))
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, maxWebhookResponseErrorBytes))
+		return resp.StatusCode, snippet, fmt.Errorf("webhook returned %d: %s", resp.StatusCode, bytes.TrimSpace(snippet))
+	}
+	output, _ := io.ReadAll(io.LimitReader(resp.Body, keep))
+	io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode, output, nil
+}
+
+// signWebhook returns the hex HMAC-SHA256 of "<timestamp>.<body>"
+func signWebhook(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// retryableWebhookStatus reports whether a failed attempt with this status
+// may succeed later; other 4xx mean the receiver won't accept the payload
+func retryableWebhookStatus(status int) bool {
+	return status == 0 ||
+		status == http.StatusRequestTimeout ||
+		status == http.StatusTooManyRequests ||
+		status >= 500
+}
+
+// incidentWebhook emits an incident event; service may be nil and is then
+// loaded for the tag filter and the payload
+func (e *Engine) incidentWebhook(ctx context.Context, event string, incident *models.Incident, service *models.ExternalService) {
+	if service == nil {
+		s, err := e.Repo.GetServiceByID(ctx, incident.ExternalServiceID)
+		if err != nil {
+			logging.For(ctx, "webhook").Error("service_lookup_failed", "incident_id", incident.ID, "err", err)
+			return
+		}
+		service = s
+	}
+
+	e.emitWebhook(ctx, event, service, gin.H{
+		"incident":   incident,
+		"service_id": service.ID,
+		"service":    service.Name,
+		"tags":       service.Tags,
+		"ownership":  service.Ownership(),
+	})
+}
+
+// checkCertExpiry emits cert_expiry, at most once a day per service and
+// replica, while the certificate of an HTTPS check expires within
+// webhooks.cert_expiry_days
+func (e *Engine) checkCertExpiry(ctx context.Context, service *models.ExternalService, result models.CheckResult) {
+	days := e.Cnfg.Webhooks.CertExpiryDays
+	if days == 0 {
+		days = defaultCertExpiryDays
+	}
+	if days < 0 || result.CertExpiresAt == nil {
+		return
+	}
+
+	now := time.Now()
+	left := result.CertExpiresAt.Sub(now)
+	if left > time.Duration(days)*24*time.Hour || !e.webhooks.claimCertWarning(service.ID, now) {
+		return
+	}
+
+	logging.For(ctx, "webhook").Warn("cert_expiring", "service", service.Name, "expires_at", result.CertExpiresAt.UTC())
+
+	e.emitWebhook(ctx, WebhookCertExpiry, service, gin.H{
+		"service_id": service.ID,
+		"service":    service.Name,
+		"url":        service.URL,
+		"tags":       service.Tags,
+		"ownership":  service.Ownership(),
+		"expires_at": result.CertExpiresAt.UTC(),
+		"days_left":  int(left.Hours() / 24),
+		"expired":    left <= 0,
+	})
+}
+
+type webhookRequest struct {
+	URL     string   `json:"url" binding:"required"`
+	Secret  string   `json:"secret"` // generated when empty
+	Events  []string `json:"events"`
+	Tags    []string `json:"tags"`
+	Enabled *bool    `json:"enabled"` // default true
+}
+
+// CreateWebhook registers an endpoint. The response is the only place the
+// secret is shown.
+func (e *Engine) CreateWebhook(c *gin.Context) {
+	var req webhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if u, err := url.Parse(req.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		c.JSON(400, gin.H{"error": "webhook url must be an absolute http or https url"})
+		return
+	}
+	for _, event := range req.Events {
+		if !slices.Contains(webhookEvents, event) {
+			c.JSON(400, gin.H{"error": fmt.Sprintf("unknown webhook event %q", event), "events": webhookEvents})
+			return
+		}
+	}
+
+	hook := models.Webhook{URL: req.URL, Secret: req.Secret, Events: req.Events, Tags: req.Tags, Enabled: true}
+	if req.Enabled != nil {
+		hook.Enabled = *req.Enabled
+	}
+	if hook.Secret == "" {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			c.JSON(500, gin.H{"error": "failed to generate webhook secret"})
+			return
+		}
+		hook.Secret = hex.EncodeToString(b)
+	}
+
+	if err := e.Repo.CreateWebhook(c.Request.Context(), &hook); err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	e.audit(c.Request.Context(), auditCreate, auditWebhook, hook.ID, hook.URL, "", nil, auditedWebhook(&hook))
+
+	logging.For(c.Request.Context(), "webhook").Info("created", "webhook_id", hook.ID, "events", hook.Events, "enabled", hook.Enabled)
+
+	c.JSON(201, gin.H{"message": "webhook created", "webhook": hook})
+}
+
+func (e *Engine) ListWebhooks(c *gin.Context) {
+	webhooks, err := e.Repo.ListWebhooks(c.Request.Context())
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+	}
+	c.JSON(200, gin.H{"webhooks": webhooks, "events": webhookEvents})
+}
+
+func (e *Engine) GetWebhook(c *gin.Context) {
+	hook, ok := e.webhookByID(c)
+	if !ok {
+		return
+	}
+
+	hook.Secret = ""
+	c.JSON(200, gin.H{"webhook": hook})
+}
+
+func (e *Engine) DeleteWebhook(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "invalid webhook id"})
+		return
+	}
+
+	hook, err := e.Repo.GetWebhook(c.Request.Context(), uint(id))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(404, gin.H{"error": "webhook not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = e.Repo.DeleteWebhook(c.Request.Context(), uint(id))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(404, gin.H{"error": "webhook not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	e.audit(c.Request.Context(), auditDelete, auditWebhook, hook.ID, hook.URL, "", auditedWebhook(hook), nil)
+
+	logging.For(c.Request.Context(), "webhook").Info("deleted", "webhook_id", id)
+
+	c.JSON(200, gin.H{"message": "webhook deleted"})
+}
+
+// ListWebhookDeliveries returns the delivery attempt log of a webhook, newest first
+func (e *Engine) ListWebhookDeliveries(c *gin.Context) {
+	hook, ok := e.webhookByID(c)
+	if !ok {
+		return
+	}
+
+	deliveries, err := e.Repo.ListWebhookDeliveries(c.Request.Context(), hook.ID, queryLimit(c, 50))
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, gin.H{"webhook_id": hook.ID, "deliveries": deliveries})
+}
+
+func (e *Engine) webhookByID(c *gin.Context) (*models.Webhook, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "invalid webhook id"})
+		return nil, false
+	}
+
+	hook, err := e.Repo.GetWebhook(c.Request.Context(), uint(id))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(404, gin.H{"error": "webhook not found"})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return hook, true
+}
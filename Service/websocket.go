@@ -0,0 +1,279 @@
+package service
+
+import (
+	"Distributed-Health-Monitoring/config"
+	"Distributed-Health-Monitoring/logging"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	defaultWSMaxConnections = 1000
+	defaultWSPingInterval   = 30 * time.Second
+	defaultWSPongTimeout    = 60 * time.Second
+	defaultWSWriteTimeout   = 10 * time.Second
+	defaultWSTokenTTL       = 60 * time.Second
+
+	// Clients only send resume requests, so anything bigger is hostile
+	wsMaxMessageBytes = 4096
+)
+
+// wsDurations returns the ping interval and pong timeout, applying defaults
+func wsDurations(cfg config.WebSocket) (time.Duration, time.Duration) {
+	ping, pong := defaultWSPingInterval, defaultWSPongTimeout
+	if cfg.PingIntervalSeconds > 0 {
+		ping = time.Duration(cfg.PingIntervalSeconds) * time.Second
+	}
+	if cfg.PongTimeoutSeconds > 0 {
+		pong = time.Duration(cfg.PongTimeoutSeconds) * time.Second
+	}
+	return ping, pong
+}
+
+// wsServer upgrades /ws requests and counts the connections of this replica
+type wsServer struct {
+	upgrader       websocket.Upgrader
+	maxConnections int64
+	pingInterval   time.Duration
+	pongTimeout    time.Duration
+	writeTimeout   time.Duration
+	tokenTTL       time.Duration
+	anonymous      bool
+
+	connections atomic.Int64
+	// Upgrades refused since startup
+	rejectedAuth  atomic.Uint64
+	rejectedLimit atomic.Uint64
+	pingTimeouts  atomic.Uint64 // connections closed for missing pongs
+}
+
+func newWSServer(cfg config.WebSocket) *wsServer {
+	s := &wsServer{
+		maxConnections: int64(cfg.MaxConnections),
+		writeTimeout:   time.Duration(cfg.WriteTimeoutSeconds) * time.Second,
+		tokenTTL:       time.Duration(cfg.TokenTTLSeconds) * time.Second,
+		anonymous:      cfg.AllowAnonymous,
+	}
+	s.pingInterval, s.pongTimeout = wsDurations(cfg)
+	if s.maxConnections == 0 {
+		s.maxConnections = defaultWSMaxConnections
+	}
+	if s.writeTimeout == 0 {
+		s.writeTimeout = defaultWSWriteTimeout
+	}
+	if s.tokenTTL == 0 {
+		s.tokenTTL = defaultWSTokenTTL
+	}
+
+	s.upgrader = websocket.Upgrader{ReadBufferSize: 1024, WriteBufferSize: 1024}
+	switch {
+	case slices.Contains(cfg.AllowedOrigins, "*"):
+		s.upgrader.CheckOrigin = func(r *http.Request) bool { return true }
+	case len(cfg.AllowedOrigins) > 0:
+		allowed := cfg.AllowedOrigins
+		s.upgrader.CheckOrigin = func(r *http.Request) bool {
+			origin := r.Header.Get("Origin")
+			// Non-browser clients send no Origin; same-origin pages are always fine
+			return origin == "" || slices.Contains(allowed, origin) || sameOrigin(r)
+		}
+	}
+	// Otherwise gorilla's default applies: no Origin or the request's own host
+	return s
+}
+
+func sameOrigin(r *http.Request) bool {
+	origin, err := http.NewRequest(http.MethodGet, r.Header.Get("Origin"), nil)
+	return err == nil && origin.URL.Host == r.Host
+}
+
+// IssueWebSocketToken returns a short-lived token for ?token= on /ws.
+// Browsers can't set an Authorization header on the upgrade request, so a
+// page fetches a token with its session first.
+func (e *Engine) IssueWebSocketToken(c *gin.Context) {
+	principal := c.MustGet(principalKey).(*Principal)
+	expires := time.Now().Add(e.ws.tokenTTL)
+
+	token, err := sealValue(e.auth.tokenKey, "ws", session{Principal: *principal, Expires: expires.Unix()})
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, gin.H{"token": token, "expires_at": expires.UTC()})
+}
+
+// wsPrincipal authenticates the upgrade request with ?token= or any of the
+// REST credentials
+func (e *Engine) wsPrincipal(c *gin.Context) (*Principal, error) {
+	if token := c.Query("token"); token != "" {
+		var s session
+		if err := openValue(e.auth.tokenKey, "ws", token, &s); err != nil || time.Now().Unix() > s.Expires {
+			return nil, errUnauthorized
+		}
+		return &s.Principal, nil
+	}
+
+	principal, err := e.authenticate(c)
+	if err == nil && principal == nil {
+		err = errUnauthorized
+	}
+	return principal, err
+}
+
+func (e *Engine) HandleWebSocket(c *gin.Context) {
+	logger := logging.For(c.Request.Context(), "ws")
+
+	var principal *Principal
+	if !e.ws.anonymous {
+		var err error
+		principal, err = e.wsPrincipal(c)
+		if errors.Is(err, errNoRole) {
+			e.ws.rejectedAuth.Add(1)
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			e.ws.rejectedAuth.Add(1)
+			e.limits.authFailed(c)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		if roleRank[principal.Role] < roleRank[RoleViewer] {
+			e.ws.rejectedAuth.Add(1)
+			c.JSON(http.StatusForbidden, gin.H{"error": "forbidden", "required_role": RoleViewer})
+			return
+		}
+	}
+
+	// Reserve the slot before upgrading so concurrent upgrades can't overshoot
+	if e.ws.connections.Add(1) > e.ws.maxConnections {
+		e.ws.connections.Add(-1)
+		e.ws.rejectedLimit.Add(1)
+		logger.Warn("connection_limit_reached", "max_connections", e.ws.maxConnections)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "too many websocket connections"})
+		return
+	}
+
+	conn, err := e.ws.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		e.ws.connections.Add(-1)
+		// The upgrader has already answered the request
+		logger.Warn("upgrade_failed", "err", err)
+		return
+	}
+
+	client := GlobalHub.NewClient(conn)
+	if principal != nil {
+		client.Subject = principal.Subject
+	}
+
+	if lastSeq, err := strconv.ParseUint(c.Query("last_seq"), 10, 64); err == nil {
+		GlobalHub.Resume(client, lastSeq, false)
+	} else {
+		GlobalHub.register <- client
+	}
+
+	handle := func(req wsRequest) {
+		switch req.Type {
+		case "resume":
+			GlobalHub.Resume(client, req.LastSeq, true)
+		case "ack", "unack":
+			e.wsAcknowledge(context.Background(), client, principal, req)
+		}
+	}
+	go e.wsReadLoop(client.Conn, handle, func() {
+		GlobalHub.unregister <- client
+		e.ws.connections.Add(-1)
+	})
+	go e.wsWriteLoop(conn, client.Send)
+}
+
+// wsRequest is a message from a client
+type wsRequest struct {
+	Type    string `json:"type"` // resume, ack or unack
+	LastSeq uint64 `json:"last_seq,omitempty"`
+
+	// ack and unack
+	ServiceID  uint   `json:"service_id,omitempty"`
+	Note       string `json:"note,omitempty"`
+	TTLSeconds int64  `json:"ttl_seconds,omitempty"`
+}
+
+// wsReadLoop hands client messages to handle until the connection fails or
+// goes quiet for longer than the pong timeout
+func (e *Engine) wsReadLoop(conn *websocket.Conn, handle func(req wsRequest), done func()) {
+	defer func() {
+		done()
+		conn.Close()
+	}()
+
+	conn.SetReadLimit(wsMaxMessageBytes)
+	conn.SetReadDeadline(time.Now().Add(e.ws.pongTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(e.ws.pongTimeout))
+	})
+
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			var netErr interface{ Timeout() bool }
+			switch {
+			case errors.As(err, &netErr) && netErr.Timeout():
+				e.ws.pingTimeouts.Add(1)
+				logging.For(context.Background(), "ws").Info("pong_timeout", "remote", conn.RemoteAddr().String())
+			case websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure):
+				logging.For(context.Background(), "ws").Warn("read_error", "err", err)
+			}
+			return
+		}
+		conn.SetReadDeadline(time.Now().Add(e.ws.pongTimeout))
+
+		// {"type": "resume", "last_seq": N} replays missed events on an open
+		// connection; {"type": "ack", "service_id": N} acknowledges a service
+		var req wsRequest
+		if json.Unmarshal(message, &req) == nil {
+			handle(req)
+		}
+	}
+}
+
+// wsWriteLoop sends queued events and periodic pings, each bounded by the
+// write timeout. It closes the connection when the hub drops the client or a
+// write fails, which also ends the read loop.
+func (e *Engine) wsWriteLoop(conn *websocket.Conn, send <-chan []byte) {
+	ticker := time.NewTicker(e.ws.pingInterval)
+	defer func() {
+		ticker.Stop()
+		conn.Close()
+	}()
+
+	for {
+		select {
+		case message, ok := <-send:
+			conn.SetWriteDeadline(time.Now().Add(e.ws.writeTimeout))
+			if !ok {
+				conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "too slow"))
+				return
+			}
+			if err := conn.WriteMessage(websocket.TextMessage, message); err != nil {
+				logging.For(context.Background(), "ws").Warn("write_error", "err", err)
+				return
+			}
+
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(e.ws.writeTimeout)); err != nil {
+				logging.For(context.Background(), "ws").Info("ping_failed", "remote", conn.RemoteAddr().String(), "err", err)
+				return
+			}
+		}
+	}
+}
@@ -1,16 +1,26 @@
 package service
 
 import (
-	"Distributed-Health-Monitoring/grpc"
+	"Distributed-Health-Monitoring/checker"
+	"Distributed-Health-Monitoring/clock"
+	"Distributed-Health-Monitoring/logging"
 	"Distributed-Health-Monitoring/models"
+	"Distributed-Health-Monitoring/notify"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
-	"net/http"
+	"slices"
 	"time"
 
-	"github.com/streadway/amqp"
+	"gorm.io/gorm"
+
+	// The built-in checkers register themselves
+	_ "Distributed-Health-Monitoring/database"
+	_ "Distributed-Health-Monitoring/dns"
+	_ "Distributed-Health-Monitoring/grpc"
+	_ "Distributed-Health-Monitoring/httpcheck"
+	_ "Distributed-Health-Monitoring/tcp"
 )
 
 func (e *Engine) AMQPURL() string {
@@ -29,155 +39,349 @@ func (e *Engine) AMQPURL() string {
 	)
 }
 
-func (e *Engine) StartWorker(amqpURL, queueName string) error {
-	conn, err := amqp.Dial(amqpURL)
+// StartWorker consumes jobs from the configured queue until the connection is
+// lost. A worker with worker.region also consumes that region's queue.
+// Re-checks confirming a failure are published through the same connection.
+func (e *Engine) StartWorker() error {
+	queue, err := e.openQueue("")
 	if err != nil {
 		return err
 	}
-	defer conn.Close()
+	e.confirms = &Scheduler{queue: queue, open: e.openQueue, regional: make(map[string]Queue)}
+	defer e.confirms.Close()
+
+	e.health.setConsumer(queue)
+
+	region := e.Cnfg.Worker.Region
+	if region == "" || e.Cnfg.Queue.InProcess() {
+		return queue.Consume(context.Background(), e.handleDelivery)
+	}
 
-	ch, err := conn.Channel()
+	regional, err := e.openQueue(region)
 	if err != nil {
 		return err
 	}
-	defer ch.Close()
+	defer regional.Close()
 
-	msgs, err := ch.Consume(
-		queueName,
-		"",
-		false,
-		false,
-		false,
-		false,
-		nil,
-	)
+	logging.For(context.Background(), "worker").Info("region_consumer_started", "region", region)
+
+	errs := make(chan error, 2)
+	go func() { errs <- queue.Consume(context.Background(), e.handleDelivery) }()
+	go func() { errs <- regional.Consume(context.Background(), e.handleDelivery) }()
+	return <-errs
+}
+
+func (e *Engine) handleDelivery(d Delivery) {
+	var job HealthCheckJob
+	if err := json.Unmarshal(d.Body(), &job); err != nil {
+		logging.For(context.Background(), "worker").Error("invalid_job", "err", err)
+		d.Reject()
+		return
+	}
+
+	if err := e.processJob(job); err != nil {
+		d.Reject()
+		return
+	}
+
+	// Acknowledge only after successful processing
+	d.Ack()
+}
+
+// processJob runs one health check end to end: probe, log, state update and
+// notifications. It returns an error when the job itself can't be processed
+// (invalid request, database failure), in which case the job is rejected.
+// Jobs of services deleted since they were queued are dropped.
+func (e *Engine) processJob(job HealthCheckJob) error {
+	ctx := job.Context()
+	logger := logging.For(ctx, "worker").With("service", job.ServiceName)
+
+	e.health.jobStarted(job.ScheduledAt)
+	e.sched.running.Add(1)
+	defer e.sched.running.Add(-1)
+
+	service, err := e.jobService(ctx, job)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Info("job_dropped", "reason", "service_deleted", "service_id", job.ServiceID)
+		return nil
+	}
 	if err != nil {
+		logger.Error("service_load_failed", "err", err)
 		return err
 	}
-
-	for msg := range msgs {
-		var job HealthCheckJob
-		if err := json.Unmarshal(msg.Body, &job); err != nil {
-			log.Printf("[WORKER] invalid_job err=%v", err)
-			msg.Nack(false, false)
-			continue
+	if job.Recheck > 0 {
+		if reason := recheckDropReason(service, job); reason != "" {
+			logger.Info("job_dropped", "reason", reason, "region", job.Region, "recheck", job.Recheck)
+			return nil
 		}
+	} else if job.Region != "" && !slices.Contains(service.Regions, job.Region) {
+		logger.Info("job_dropped", "reason", "region_removed", "region", job.Region)
+		return nil
+	}
+	admitted, reason, err := e.admitJob(ctx, service, job)
+	if err != nil {
+		logger.Error("job_claim_failed", "job_id", job.JobID, "err", err)
+		return err
+	}
+	if !admitted {
+		if reason == "stale" {
+			e.sched.droppedStale.Add(1)
+		} else {
+			e.sched.droppedDuplicate.Add(1)
+		}
+		logger.Info("job_dropped", "reason", reason, "job_id", job.JobID, "scheduled_at", job.ScheduledAt)
+		return nil
+	}
+	e.recordExecution(ctx, service, job)
+
+	result, err := e.runCheck(ctx, service)
+	if err != nil {
+		logger.Error("invalid_request", "err", err)
+		e.releaseJob(ctx, job)
+		return err
+	}
 
-		// Load service from DB
-		service, err := e.Repo.GetServiceByName(context.Background(), job.ServiceName)
+	// Save append-only log. Re-checks only confirm a failure that was logged
+	// already; logging them too would count one blip as several failed checks
+	// in uptime, SLOs and heatmaps.
+	var checkLog models.ServiceCheckLog
+	if job.Recheck == 0 {
+		checkLog, err = e.Repo.SaveServiceCheckLog(
+			*service,
+			e.Cnfg.Worker.Region,
+			result.Status,
+			result.StatusCode,
+			result.LatencyMs,
+			result.ErrorMessage,
+			result.Timings,
+		)
 		if err != nil {
-			log.Printf("[WORKER] service_not_found service=%s", job.ServiceName)
-			msg.Nack(false, false)
-			continue
+			logger.Error("log_save_failed", "err", err)
 		}
+	}
 
-		latencyMs := int64(0)
-
-		status := "DOWN"
-		statusCode := 0
-		errorMsg := ""
-		success := false
-
-		switch service.Protocol { //	Switch case to send to the right protocol
-		case "gRPC":
-			res := grpc.Check_gRPC(service.URL, time.Duration(service.TimeoutSeconds))
-			if res.Error != nil {
-				log.Printf("[WORKER] service_not_healthy service=%s err=%v", service.Name, res.Error)
-				status = "DOWN"
-				latencyMs = int64(res.Latency.Abs().Seconds())
-				statusCode = int(res.StatusCode)
-				errorMsg = res.Error.Error()
-				success = false
-			}
+	if result.Response != nil {
+		if err := e.Repo.SaveLastResponse(ctx, result.Response); err != nil {
+			logger.Error("last_response_save_failed", "err", err)
+		}
+	}
 
-			if res.IsHealthy {
-				status = "UP"
-				latencyMs = int64(res.Latency.Abs().Seconds())
-				statusCode = int(res.StatusCode)
-				success = true
-			}
+	// Every check sees the certificate, including those that don't decide a round
+	e.checkCertExpiry(ctx, service, result)
 
-		default:
-			req, err := http.NewRequest(
-				job.Method,
-				job.URL,
-				nil,
-			)
-			if err != nil {
-				log.Printf("[WORKER] invalid_request service=%s err=%v", service.Name, err)
-				msg.Nack(false, false)
-				continue
-			}
+	// Regional checks and re-checks only change the state once the quorum of
+	// their round is decided; a failure waiting for its re-checks doesn't yet
+	switch {
+	case job.Recheck > 0:
+		decided, ok := e.applyConfirmation(ctx, service, job, result)
+		if !ok {
+			logger.Info("recheck_completed", "recheck", job.Recheck, "region", job.Region, "status", result.Status, "latency_ms", result.LatencyMs, "error", result.ErrorMessage)
+			return nil
+		}
+		result = decided
+	case job.Region != "":
+		decided, ok := e.applyRegionResult(ctx, service, job, result)
+		if !ok {
+			logger.Info("region_check_completed", "region", job.Region, "status", result.Status, "latency_ms", result.LatencyMs, "error", result.ErrorMessage)
+			return nil
+		}
+		result = decided
+	case e.needsConfirmation(service, result):
+		if e.startConfirmation(ctx, service, job, result) {
+			logger.Info("check_completed", "status", result.Status, "latency_ms", result.LatencyMs, "attempts", result.Attempts, "error", result.ErrorMessage, "confirming", true)
+			return nil
+		}
+	}
 
-			client := &http.Client{
-				Timeout: job.Timeout,
-			}
+	// Update service state
+	stateChange, err := e.Repo.UpdateServiceState(ctx, service, result)
+	if err != nil {
+		logger.Error("state_update_failed", "err", err)
+	}
+
+	flapping := e.trackFlapping(ctx, service, stateChange != nil)
+
+	if !result.Success {
+		e.remediate(ctx, service, job.InMaintenance)
+	}
 
-			start := time.Now()
-			resp, err := client.Do(req)
-			latencyMs = time.Since(start).Milliseconds()
-
-			status = "DOWN"
-			statusCode = 0
-			errorMsg = ""
-			success = false
-
-			if err != nil {
-				errorMsg = err.Error()
-			} else {
-				defer resp.Body.Close()
-				statusCode = resp.StatusCode
-				if resp.StatusCode < 400 {
-					status = "UP"
-					success = true
-				}
+	// 🔹 Broadcast only on transition
+	if stateChange != nil {
+		LogStateTransition(ctx, service.Name, stateChange)
+		e.trackIncident(ctx, service, stateChange, result)
+
+		// An acknowledgement covers one outage; the recovery is announced as usual
+		acknowledged := service.Acknowledgement.Active(clock.Now())
+		if stateChange.To == "UP" && service.Acknowledgement != nil {
+			if _, err := e.clearAcknowledgement(ctx, service, models.AckRecovered, "system"); err != nil {
+				logger.Error("acknowledgement_clear_failed", "err", err)
 			}
+			acknowledged = false
 		}
 
-		// Save append-only log
-		if err := e.Repo.SaveServiceCheckLog(
-			*service,
-			status,
-			statusCode,
-			latencyMs,
-			errorMsg,
-		); err != nil {
-			log.Printf("[WORKER] log_save_failed service=%s err=%v", service.Name, err)
+		event := NewStateChangeEvent(*service, stateChange, result)
+		event.Maintenance = job.InMaintenance
+		if acknowledged {
+			event.Acknowledgement = service.Acknowledgement
+		}
+		event.Severity = e.Notifier.Severity(notify.TransitionKey(stateChange.From, stateChange.To))
+		if stateChange.To == "DOWN" {
+			event.RootCause = e.dependencyRootCause(ctx, service)
+		}
+
+		var suppressed string
+		switch {
+		case job.InMaintenance && stateChange.To == "DOWN":
+			suppressed = "maintenance"
+		case flapping:
+			suppressed = "flapping"
+		case acknowledged:
+			suppressed = "acknowledged"
+		case event.RootCause != "":
+			// The dependency's own alert covers this outage; dashboards still
+			// see the transition, marked with its root cause
+			suppressed = "dependency"
+		case stateChange.Resolved() && e.dependencyOutage(ctx, service):
+			// The outage was never announced, so neither is its end
+			suppressed = "dependency"
 		}
+		e.recordTransition(ctx, service, stateChange, result, checkLog, suppressed)
 
-		// Update service state
-		stateChange, err := e.Repo.UpdateServiceState(context.Background(), service, success)
+		switch suppressed {
+		case "maintenance", "flapping":
+			LogAlertSuppressed(ctx, service.Name, stateChange, suppressed)
+		case "acknowledged", "dependency":
+			LogAlertSuppressed(ctx, service.Name, stateChange, suppressed)
+			BroadcastStateChange(event)
+		default:
+			BroadcastStateChange(event) // Broadcast the transition with the WebSocket endpoint
+			alert := stateChangeAlert(*service, event)
+			e.Notifier.Dispatch(alert)
+			e.emitWebhook(ctx, WebhookStateChange, service, alert)
+		}
+	}
+
+	logger.Info(
+		"check_completed",
+		"status", result.Status,
+		"latency_ms", result.LatencyMs,
+		"attempts", result.Attempts,
+		"error", result.ErrorMessage,
+	)
+
+	return nil
+}
+
+// jobService loads the service of a job as it is now. The check follows its
+// current definition, so an edit made while the job was queued applies to it.
+func (e *Engine) jobService(ctx context.Context, job HealthCheckJob) (*models.ExternalService, error) {
+	var service *models.ExternalService
+	var err error
+	if job.ServiceID != 0 {
+		service, err = e.Repo.GetServiceByID(ctx, job.ServiceID)
+	} else {
+		// Queued before jobs carried the id
+		service, err = e.Repo.GetServiceByName(ctx, job.ServiceName)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return service, nil
+}
+
+// runCheck produces the result for one job, honouring any injected chaos result
+// before falling back to a real probe of the target. Failed probes are retried
+// up to service.Retries times so a single transient error isn't a failure;
+// the wait between attempts ends early with ctx.
+func (e *Engine) runCheck(ctx context.Context, service *models.ExternalService) (models.CheckResult, error) {
+	if injection, ok := e.Chaos.Take(service.ID); ok {
+		LogChaosInjected(ctx, service.Name, injection)
+		injection.Wait(ctx)
+		result := injection.Result(service)
+		result.Attempts = 1
+		applyLatencyThresholds(service, &result)
+		return result, nil
+	}
+
+	var result models.CheckResult
+	for attempt := int64(1); ; attempt++ {
+		var err error
+		result, err = probe(ctx, service)
 		if err != nil {
-			log.Printf("[WORKER] state_update_failed service=%s err=%v", service.Name, err)
+			return result, err
 		}
+		result.Attempts = int(attempt)
+		applyLatencyThresholds(service, &result)
 
-		// 🔹 Broadcast only on transition
-		if stateChange != nil {
-			LogStateTransition(service.Name, stateChange) // Log the transition in the db
-			BroadcastStateChange(*service, stateChange)   // Broadcast the transition with the WebSocket endpoint
+		if result.Success || attempt > service.Retries {
+			return result, nil
 		}
 
-		log.Printf(
-			"[WORKER] check_completed service=%s status=%s latency_ms=%d error=%s",
-			service.Name,
-			status,
-			latencyMs,
-			errorMsg,
+		logging.For(ctx, "worker").Warn(
+			"check_retry",
+			"service", service.Name,
+			"attempt", attempt,
+			"retries", service.Retries,
+			"error", result.ErrorMessage,
 		)
+		select {
+		case <-ctx.Done():
+			return result, ctx.Err()
+		case <-time.After(time.Duration(service.RetryDelayMs) * time.Millisecond):
+		}
+	}
+}
 
-		// Acknowledge only after successful processing
-		msg.Ack(false)
+// applyLatencyThresholds grades a successful check by how long it took:
+// latency_warn_ms or slower is DEGRADED, latency_crit_ms or slower a failure
+func applyLatencyThresholds(service *models.ExternalService, result *models.CheckResult) {
+	if !result.Success {
+		return
 	}
 
-	return nil
+	switch {
+	case service.LatencyCritMs > 0 && result.LatencyMs >= service.LatencyCritMs:
+		result.Status, result.Success, result.Reason = "DOWN", false, "latency"
+		result.ErrorMessage = fmt.Sprintf("response took %dms, critical threshold is %dms", result.LatencyMs, service.LatencyCritMs)
+	case service.LatencyWarnMs > 0 && result.LatencyMs >= service.LatencyWarnMs:
+		result.Status, result.Reason = models.StatusDegraded, "latency"
+		result.ErrorMessage = fmt.Sprintf("response took %dms, warning threshold is %dms", result.LatencyMs, service.LatencyWarnMs)
+	}
 }
 
-func LogStateTransition(serviceName string, change *models.StateChange) {
-	log.Printf(
-		"[STATE_TRANSITION] service=%s from=%s to=%s at=%s",
-		serviceName,
-		change.From,
-		change.To,
-		time.Now().Format(time.RFC3339),
-	)
+// probe makes one attempt with the checker registered for the service
+// protocol, or the HTTP one for a protocol with none. It only fails when the
+// build has neither, which rejects the job.
+func probe(ctx context.Context, service *models.ExternalService) (models.CheckResult, error) {
+	c, ok := checker.Resolve(service.Protocol)
+	if !ok {
+		return models.CheckResult{Status: "DOWN"}, fmt.Errorf("no checker is registered for protocol %q", service.Protocol)
+	}
+	return c.Check(ctx, service), nil
+}
+
+func stateChangeAlert(service models.ExternalService, event models.ServiceStateChangeEvent) notify.Alert {
+	return notify.Alert{
+		Type:             "state_change",
+		Severity:         event.Severity,
+		ServiceID:        service.ID,
+		Service:          service.Name,
+		Tags:             service.Tags,
+		Ownership:        event.Ownership,
+		Metadata:         service.Metadata,
+		From:             event.From,
+		To:               event.To,
+		Reason:           event.Reason,
+		Error:            event.Error,
+		AssertionFailure: event.AssertionFailure,
+		Timestamp:        event.Timestamp,
+	}
+}
+
+func LogStateTransition(ctx context.Context, serviceName string, change *models.StateChange) {
+	logging.For(ctx, "worker").Info("state_transition", "service", serviceName, "from", change.From, "to", change.To)
+}
+
+func LogAlertSuppressed(ctx context.Context, serviceName string, change *models.StateChange, cause string) {
+	logging.For(ctx, "notifier").Info("alert_suppressed", "service", serviceName, "from", change.From, "to", change.To, "cause", cause)
 }
@@ -0,0 +1,490 @@
+// Package apiv1 holds the request and response types of the /api/v1 routes.
+// They are copied from the models rather than aliased, so a schema change
+// inside the monitor never changes the wire format. Within v1 fields are only
+// ever added; renames and removals go to a v2.
+package apiv1
+
+import (
+	"Distributed-Health-Monitoring/models"
+	"Distributed-Health-Monitoring/secrets"
+	"time"
+)
+
+const Prefix = "/api/v1"
+
+type Error struct {
+	Error string `json:"error"`
+}
+
+// Auth is the credentials block of an HTTP check; secrets come back as "xxxxx"
+type Auth struct {
+	Type         string   `json:"type"` // basic, bearer or oauth2
+	Username     string   `json:"username,omitempty"`
+	Password     string   `json:"password,omitempty"`
+	Token        string   `json:"token,omitempty"`
+	TokenURL     string   `json:"token_url,omitempty"`
+	ClientID     string   `json:"client_id,omitempty"`
+	ClientSecret string   `json:"client_secret,omitempty"`
+	Scopes       []string `json:"scopes,omitempty"`
+}
+
+type Remediation struct {
+	Type            string `json:"type"` // webhook, command or kubernetes
+	URL             string `json:"url,omitempty"`
+	Command         string `json:"command,omitempty"`
+	Namespace       string `json:"namespace,omitempty"`
+	Deployment      string `json:"deployment,omitempty"`
+	AfterFailures   int64  `json:"after_failures,omitempty"`
+	CooldownSeconds int64  `json:"cooldown_seconds,omitempty"`
+	MaxAttempts     int64  `json:"max_attempts,omitempty"`
+}
+
+// Confirmation is the re-checks a failure needs to pass before it counts
+type Confirmation struct {
+	Checks  int64    `json:"checks"`
+	Quorum  int64    `json:"quorum,omitempty"`
+	Regions []string `json:"regions,omitempty"`
+}
+
+// Acknowledgement is an operator handling a failing service; its alerts are
+// held back until expires_at
+type Acknowledgement struct {
+	By        string    `json:"by"`
+	Note      string    `json:"note,omitempty"`
+	At        time.Time `json:"at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type SLO struct {
+	SuccessTarget      float64 `json:"success_target,omitempty"`
+	LatencyPercentile  float64 `json:"latency_percentile,omitempty"`
+	LatencyThresholdMs int64   `json:"latency_threshold_ms,omitempty"`
+	WindowDays         int     `json:"window_days,omitempty"`
+}
+
+type GRPCTLS struct {
+	ServerName         string `json:"server_name,omitempty"`
+	CACert             string `json:"ca_cert,omitempty"`
+	InsecureSkipVerify bool   `json:"insecure_skip_verify,omitempty"`
+}
+
+// SuccessCriteria decides which HTTP responses are UP
+type SuccessCriteria struct {
+	StatusCodes     []string `json:"status_codes,omitempty"` // e.g. "200-299", "401"
+	Accept          []int    `json:"accept,omitempty"`
+	Reject          []int    `json:"reject,omitempty"`
+	FollowRedirects *bool    `json:"follow_redirects,omitempty"`
+	MaxRedirects    int      `json:"max_redirects,omitempty"`
+}
+
+type Assertion struct {
+	Type     string `json:"type"`
+	Path     string `json:"path,omitempty"`
+	Expected string `json:"expected"`
+}
+
+// ServiceRequest registers a service
+type ServiceRequest struct {
+	Name                  string                 `json:"name"`
+	URL                   string                 `json:"url"`
+	Protocol              string                 `json:"protocol"`
+	HTTPMethod            string                 `json:"http_method"`
+	Interval              int64                  `json:"interval"`
+	Schedule              string                 `json:"schedule,omitempty"`
+	ScheduleTimezone      string                 `json:"schedule_timezone,omitempty"`
+	TimeoutSeconds        int64                  `json:"timeout_seconds"`
+	FailureThreshold      int64                  `json:"failure_threshold"`
+	Retries               int64                  `json:"retries"`
+	RetryDelayMs          int64                  `json:"retry_delay_ms"`
+	LatencyWarnMs         int64                  `json:"latency_warn_ms,omitempty"`
+	LatencyCritMs         int64                  `json:"latency_crit_ms,omitempty"`
+	Assertions            []Assertion            `json:"assertions,omitempty"`
+	SuccessCriteria       *SuccessCriteria       `json:"success_criteria,omitempty"`
+	ProxyURL              string                 `json:"proxy_url,omitempty"`
+	ResolveOverride       map[string]string      `json:"resolve_override,omitempty"`
+	SourceInterface       string                 `json:"source_interface,omitempty"`
+	Auth                  *Auth                  `json:"auth,omitempty"`
+	GRPCServiceName       string                 `json:"grpc_service_name,omitempty"`
+	GRPCWatch             bool                   `json:"grpc_watch,omitempty"`
+	GRPCRequireHealth     bool                   `json:"grpc_require_health,omitempty"`
+	GRPCTLS               *GRPCTLS               `json:"grpc_tls,omitempty"`
+	DNSResolver           string                 `json:"dns_resolver,omitempty"`
+	DNSRecordType         string                 `json:"dns_record_type,omitempty"`
+	DNSExpected           []string               `json:"dns_expected,omitempty"`
+	DatabaseDSN           string                 `json:"database_dsn,omitempty"` // password redacted in responses
+	DatabaseQuery         string                 `json:"database_query,omitempty"`
+	HeartbeatGraceSeconds int64                  `json:"heartbeat_grace_seconds,omitempty"`
+	Public                bool                   `json:"public"`
+	Tags                  []string               `json:"tags,omitempty"`
+	Owner                 string                 `json:"owner,omitempty"`
+	Team                  string                 `json:"team,omitempty"`
+	RunbookURL            string                 `json:"runbook_url,omitempty"`
+	Description           string                 `json:"description,omitempty"`
+	DependsOn             []string               `json:"depends_on,omitempty"`
+	Metadata              map[string]interface{} `json:"metadata,omitempty"`
+	Regions               []string               `json:"regions,omitempty"`
+	RegionQuorum          int64                  `json:"region_quorum,omitempty"`
+	Confirmation          *Confirmation          `json:"confirmation,omitempty"`
+	SLO                   *SLO                   `json:"slo,omitempty"`
+	Remediation           *Remediation           `json:"remediation,omitempty"`
+}
+
+type Service struct {
+	ID                    uint                   `json:"id"`
+	Name                  string                 `json:"name"`
+	URL                   string                 `json:"url"`
+	Protocol              string                 `json:"protocol"`
+	HTTPMethod            string                 `json:"http_method"`
+	Interval              int64                  `json:"interval"`
+	Schedule              string                 `json:"schedule,omitempty"`
+	ScheduleTimezone      string                 `json:"schedule_timezone,omitempty"`
+	TimeoutSeconds        int64                  `json:"timeout_seconds"`
+	FailureThreshold      int64                  `json:"failure_threshold"`
+	Retries               int64                  `json:"retries"`
+	RetryDelayMs          int64                  `json:"retry_delay_ms"`
+	LatencyWarnMs         int64                  `json:"latency_warn_ms,omitempty"`
+	LatencyCritMs         int64                  `json:"latency_crit_ms,omitempty"`
+	Assertions            []Assertion            `json:"assertions"`
+	SuccessCriteria       *SuccessCriteria       `json:"success_criteria,omitempty"`
+	ProxyURL              string                 `json:"proxy_url,omitempty"`
+	ResolveOverride       map[string]string      `json:"resolve_override,omitempty"`
+	SourceInterface       string                 `json:"source_interface,omitempty"`
+	Auth                  *Auth                  `json:"auth,omitempty"`
+	GRPCServiceName       string                 `json:"grpc_service_name,omitempty"`
+	GRPCWatch             bool                   `json:"grpc_watch,omitempty"`
+	GRPCRequireHealth     bool                   `json:"grpc_require_health,omitempty"`
+	GRPCTLS               *GRPCTLS               `json:"grpc_tls,omitempty"`
+	DNSResolver           string                 `json:"dns_resolver,omitempty"`
+	DNSRecordType         string                 `json:"dns_record_type,omitempty"`
+	DNSExpected           []string               `json:"dns_expected,omitempty"`
+	DatabaseDSN           string                 `json:"database_dsn,omitempty"` // password redacted in responses
+	DatabaseQuery         string                 `json:"database_query,omitempty"`
+	HeartbeatGraceSeconds int64                  `json:"heartbeat_grace_seconds,omitempty"`
+	HeartbeatToken        string                 `json:"heartbeat_token,omitempty"` // only in the registration response
+	Public                bool                   `json:"public"`
+	Tags                  []string               `json:"tags"`
+	Owner                 string                 `json:"owner,omitempty"`
+	Team                  string                 `json:"team,omitempty"`
+	RunbookURL            string                 `json:"runbook_url,omitempty"`
+	Description           string                 `json:"description,omitempty"`
+	DependsOn             []string               `json:"depends_on,omitempty"`
+	Metadata              map[string]interface{} `json:"metadata,omitempty"`
+	Regions               []string               `json:"regions,omitempty"`
+	RegionQuorum          int64                  `json:"region_quorum,omitempty"`
+	Confirmation          *Confirmation          `json:"confirmation,omitempty"`
+	SLO                   *SLO                   `json:"slo,omitempty"`
+	Remediation           *Remediation           `json:"remediation,omitempty"`
+	Status                string                 `json:"status"`               // PENDING, UP, DEGRADED, DOWN, FLAPPING or MAINTENANCE
+	RootCause             string                 `json:"root_cause,omitempty"` // while DOWN: the DOWN dependency blamed for it
+	Acknowledgement       *Acknowledgement       `json:"acknowledgement,omitempty"`
+	ConsecutiveFailures   int64                  `json:"consecutive_failures"`
+	LastCheckedAt         *time.Time             `json:"last_checked_at"`
+	LastHeartbeatAt       *time.Time             `json:"last_heartbeat_at,omitempty"`
+	CreatedAt             time.Time              `json:"created_at"`
+	UpdatedAt             time.Time              `json:"updated_at"`
+}
+
+type CheckLog struct {
+	ID             uint      `json:"id"`
+	ServiceID      uint      `json:"service_id"`
+	Status         string    `json:"status"`
+	StatusCode     int       `json:"status_code"`
+	ResponseTimeMs int64     `json:"response_time_ms"`
+	Error          string    `json:"error,omitempty"`
+	Phases         *Phases   `json:"phases,omitempty"` // HTTP checks only
+	CheckedAt      time.Time `json:"checked_at"`
+}
+
+// Phases is the latency breakdown of an HTTP check. Connection phases are
+// omitted when a kept-alive connection was reused.
+type Phases struct {
+	DNSMs     *int64 `json:"dns_ms,omitempty"`
+	ConnectMs *int64 `json:"connect_ms,omitempty"`
+	TLSMs     *int64 `json:"tls_ms,omitempty"`
+	TTFBMs    *int64 `json:"ttfb_ms,omitempty"`
+	QueryMs   *int64 `json:"query_ms,omitempty"` // DATABASE checks only
+	TotalMs   *int64 `json:"total_ms,omitempty"`
+}
+
+type Incident struct {
+	ID              uint       `json:"id"`
+	ServiceID       uint       `json:"service_id"`
+	Status          string     `json:"status"` // open, resolved
+	Reason          string     `json:"reason,omitempty"`
+	Cause           string     `json:"cause,omitempty"`
+	StartedAt       time.Time  `json:"started_at"`
+	ResolvedAt      *time.Time `json:"resolved_at,omitempty"`
+	AcknowledgedAt  *time.Time `json:"acknowledged_at,omitempty"`
+	AcknowledgedBy  string     `json:"acknowledged_by,omitempty"`
+	EscalationLevel int        `json:"escalation_level"`
+}
+
+type Escalation struct {
+	Policy  string            `json:"policy"`
+	Step    int               `json:"step"`
+	Results map[string]string `json:"results"`
+	FiredAt time.Time         `json:"fired_at"`
+}
+
+type AckRequest struct {
+	By string `json:"by" binding:"required"`
+}
+
+type ServiceResponse struct {
+	Service Service `json:"service"`
+}
+
+// Page is the pagination metadata of list responses. NextOffset is null
+// on the last page.
+type Page struct {
+	Total      int64 `json:"total"`
+	Limit      int   `json:"limit"`
+	Offset     int   `json:"offset"`
+	NextOffset *int  `json:"next_offset"`
+}
+
+func NewPage(total int64, limit, offset int) Page {
+	page := Page{Total: total, Limit: limit, Offset: offset}
+	if next := offset + limit; int64(next) < total {
+		page.NextOffset = &next
+	}
+	return page
+}
+
+type ServiceListResponse struct {
+	Services []Service `json:"services"`
+	Page
+}
+
+type DeleteServiceResponse struct {
+	ArchiveID uint `json:"archive_id"`          // 0 when a service deleted earlier was removed permanently
+	Permanent bool `json:"permanent,omitempty"` // the service and its history are gone and can't be restored
+}
+
+type CheckLogListResponse struct {
+	Logs []CheckLog `json:"logs"`
+	Page
+}
+
+// RecentCheckLogsResponse holds the newest logs of several services, in
+// the order they were asked for
+type RecentCheckLogsResponse struct {
+	Services []ServiceCheckLogs `json:"services"`
+}
+
+type ServiceCheckLogs struct {
+	ServiceID uint       `json:"service_id"`
+	Logs      []CheckLog `json:"logs"` // newest first
+}
+
+type IncidentListResponse struct {
+	Incidents []Incident `json:"incidents"`
+}
+
+type IncidentResponse struct {
+	Incident    Incident     `json:"incident"`
+	Escalations []Escalation `json:"escalations"`
+}
+
+// Model converts the request to a new, unsaved service
+func (r ServiceRequest) Model() *models.ExternalService {
+	s := &models.ExternalService{
+		Name:              r.Name,
+		URL:               r.URL,
+		Protocol:          r.Protocol,
+		HTTPMethod:        r.HTTPMethod,
+		Interval:          r.Interval,
+		Schedule:          r.Schedule,
+		ScheduleTimezone:  r.ScheduleTimezone,
+		TimeoutSeconds:    r.TimeoutSeconds,
+		FailureThreshold:  r.FailureThreshold,
+		Retries:           r.Retries,
+		RetryDelayMs:      r.RetryDelayMs,
+		LatencyWarnMs:     r.LatencyWarnMs,
+		LatencyCritMs:     r.LatencyCritMs,
+		ProxyURL:          r.ProxyURL,
+		ResolveOverride:   r.ResolveOverride,
+		SourceInterface:   r.SourceInterface,
+		GRPCServiceName:   r.GRPCServiceName,
+		GRPCWatch:         r.GRPCWatch,
+		GRPCRequireHealth: r.GRPCRequireHealth,
+		DNSResolver:       r.DNSResolver,
+		DNSRecordType:     r.DNSRecordType,
+		DNSExpected:       r.DNSExpected,
+		DatabaseDSN:       r.DatabaseDSN,
+		DatabaseQuery:     r.DatabaseQuery,
+		HeartbeatGrace:    r.HeartbeatGraceSeconds,
+		Public:            r.Public,
+		Tags:              r.Tags,
+		Owner:             r.Owner,
+		Team:              r.Team,
+		RunbookURL:        r.RunbookURL,
+		Description:       r.Description,
+		DependsOn:         r.DependsOn,
+		Metadata:          r.Metadata,
+		Regions:           r.Regions,
+		RegionQuorum:      r.RegionQuorum,
+	}
+	if s.Protocol == "" {
+		s.Protocol = "HTTP"
+	}
+	if r.Auth != nil {
+		a := models.CheckAuth(*r.Auth)
+		s.Auth = &a
+	}
+	if r.SLO != nil {
+		slo := models.SLO(*r.SLO)
+		s.SLO = &slo
+	}
+	if r.Remediation != nil {
+		rem := models.Remediation(*r.Remediation)
+		s.Remediation = &rem
+	}
+	if r.Confirmation != nil {
+		conf := models.Confirmation(*r.Confirmation)
+		s.Confirmation = &conf
+	}
+	if r.SuccessCriteria != nil {
+		sc := models.SuccessCriteria(*r.SuccessCriteria)
+		s.SuccessCriteria = &sc
+	}
+	if r.GRPCTLS != nil {
+		t := models.GRPCTLS(*r.GRPCTLS)
+		s.GRPCTLS = &t
+	}
+	for _, a := range r.Assertions {
+		s.Assertions = append(s.Assertions, models.Assertion{Type: a.Type, Path: a.Path, Expected: a.Expected})
+	}
+	return s
+}
+
+// NewService converts a service as presented to clients, with the
+// MAINTENANCE/FLAPPING status already applied
+func NewService(s models.ExternalService) Service {
+	out := Service{
+		ID:                    s.ID,
+		Name:                  s.Name,
+		URL:                   s.URL,
+		Protocol:              s.Protocol,
+		HTTPMethod:            s.HTTPMethod,
+		Interval:              s.Interval,
+		Schedule:              s.Schedule,
+		ScheduleTimezone:      s.ScheduleTimezone,
+		TimeoutSeconds:        s.TimeoutSeconds,
+		FailureThreshold:      s.FailureThreshold,
+		Retries:               s.Retries,
+		RetryDelayMs:          s.RetryDelayMs,
+		LatencyWarnMs:         s.LatencyWarnMs,
+		LatencyCritMs:         s.LatencyCritMs,
+		Assertions:            make([]Assertion, 0, len(s.Assertions)),
+		ProxyURL:              secrets.RedactURL(s.ProxyURL),
+		ResolveOverride:       s.ResolveOverride,
+		SourceInterface:       s.SourceInterface,
+		GRPCServiceName:       s.GRPCServiceName,
+		GRPCWatch:             s.GRPCWatch,
+		GRPCRequireHealth:     s.GRPCRequireHealth,
+		DNSResolver:           s.DNSResolver,
+		DNSRecordType:         s.DNSRecordType,
+		DNSExpected:           s.DNSExpected,
+		DatabaseDSN:           secrets.RedactURL(s.DatabaseDSN),
+		DatabaseQuery:         s.DatabaseQuery,
+		HeartbeatGraceSeconds: s.HeartbeatGrace,
+		Public:                s.Public,
+		Tags:                  s.Tags,
+		Owner:                 s.Owner,
+		Team:                  s.Team,
+		RunbookURL:            s.RunbookURL,
+		Description:           s.Description,
+		DependsOn:             s.DependsOn,
+		Metadata:              s.Metadata,
+		Regions:               s.Regions,
+		RegionQuorum:          s.RegionQuorum,
+		Status:                s.Status,
+		RootCause:             s.RootCause,
+		ConsecutiveFailures:   s.ConsecutiveFailures,
+		LastCheckedAt:         s.LastCheckedAt,
+		LastHeartbeatAt:       s.LastHeartbeatAt,
+		CreatedAt:             s.CreatedAt,
+		UpdatedAt:             s.UpdatedAt,
+	}
+	if out.Tags == nil {
+		out.Tags = []string{}
+	}
+	if s.Auth != nil {
+		a := Auth(*s.Auth)
+		a.Password = redact(a.Password)
+		a.Token = redact(a.Token)
+		a.ClientSecret = redact(a.ClientSecret)
+		out.Auth = &a
+	}
+	if s.SLO != nil {
+		slo := SLO(*s.SLO)
+		out.SLO = &slo
+	}
+	if s.Remediation != nil {
+		rem := Remediation(*s.Remediation)
+		out.Remediation = &rem
+	}
+	if s.Confirmation != nil {
+		conf := Confirmation(*s.Confirmation)
+		out.Confirmation = &conf
+	}
+	if s.SuccessCriteria != nil {
+		sc := SuccessCriteria(*s.SuccessCriteria)
+		out.SuccessCriteria = &sc
+	}
+	if s.GRPCTLS != nil {
+		t := GRPCTLS(*s.GRPCTLS)
+		out.GRPCTLS = &t
+	}
+	if s.Acknowledgement != nil {
+		ack := Acknowledgement(*s.Acknowledgement)
+		out.Acknowledgement = &ack
+	}
+	for _, a := range s.Assertions {
+		out.Assertions = append(out.Assertions, Assertion{Type: a.Type, Path: a.Path, Expected: a.Expected})
+	}
+	return out
+}
+
+func NewCheckLog(l *models.ServiceCheckLog) CheckLog {
+	log := CheckLog{
+		ID:             l.ID,
+		ServiceID:      l.ExternalServiceID,
+		Status:         l.Status,
+		StatusCode:     l.StatusCode,
+		ResponseTimeMs: l.ResponseTimeMs,
+		Error:          l.ErrorMessage,
+		CheckedAt:      l.CheckedAt,
+	}
+	if t := l.CheckTimings; t.TotalMs != nil {
+		log.Phases = &Phases{DNSMs: t.DNSMs, ConnectMs: t.ConnectMs, TLSMs: t.TLSMs, TTFBMs: t.TTFBMs, QueryMs: t.QueryMs, TotalMs: t.TotalMs}
+	}
+	return log
+}
+
+func NewIncident(i models.Incident) Incident {
+	return Incident{
+		ID:              i.ID,
+		ServiceID:       i.ExternalServiceID,
+		Status:          i.Status,
+		Reason:          i.Reason,
+		Cause:           i.Cause,
+		StartedAt:       i.StartedAt,
+		ResolvedAt:      i.ResolvedAt,
+		AcknowledgedAt:  i.AcknowledgedAt,
+		AcknowledgedBy:  i.AcknowledgedBy,
+		EscalationLevel: i.EscalationLevel,
+	}
+}
+
+func NewEscalation(e models.IncidentEscalation) Escalation {
+	return Escalation{Policy: e.Policy, Step: e.Step, Results: e.Results, FiredAt: e.FiredAt}
+}
+
+func redact(v string) string {
+	if v == "" {
+		return ""
+	}
+	return secrets.RedactedPassword
+}
@@ -0,0 +1,168 @@
+package assertions
+
+import (
+	"Distributed-Health-Monitoring/models"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	TypeBodyContains = "body_contains"
+	TypeJSONPath     = "json_path"
+
+	// maxExcerpt bounds the expected/actual excerpts and the response snapshot
+	// so a large body never bloats the check log or the notification payload
+	maxExcerpt = 1024
+)
+
+// Evaluate runs every assertion against the response body and returns the first failure, or nil
+func Evaluate(body []byte, list []models.Assertion) *models.AssertionFailure {
+	for _, a := range list {
+		actual, ok, err := evaluate(body, a)
+		if err != nil {
+			return failure(a, err.Error(), body)
+		}
+		if !ok {
+			return failure(a, actual, body)
+		}
+	}
+	return nil
+}
+
+// Validate reports whether an assertion definition is well-formed
+func Validate(a models.Assertion) error {
+	switch a.Type {
+	case TypeBodyContains:
+		if a.Expected == "" {
+			return fmt.Errorf("body_contains assertion requires expected")
+		}
+	case TypeJSONPath:
+		if _, err := parsePath(a.Path); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown assertion type %q", a.Type)
+	}
+	return nil
+}
+
+func evaluate(body []byte, a models.Assertion) (string, bool, error) {
+	switch a.Type {
+	case TypeBodyContains:
+		return excerpt(string(body)), strings.Contains(string(body), a.Expected), nil
+
+	case TypeJSONPath:
+		var doc interface{}
+		if err := json.Unmarshal(body, &doc); err != nil {
+			return "", false, fmt.Errorf("response is not valid JSON: %w", err)
+		}
+		value, err := lookup(doc, a.Path)
+		if err != nil {
+			return "", false, err
+		}
+		actual := stringify(value)
+		return excerpt(actual), actual == a.Expected, nil
+	}
+
+	return "", false, fmt.Errorf("unknown assertion type %q", a.Type)
+}
+
+func failure(a models.Assertion, actual string, body []byte) *models.AssertionFailure {
+	return &models.AssertionFailure{
+		Assertion:        a,
+		Expected:         excerpt(a.Expected),
+		Actual:           actual,
+		ResponseSnapshot: excerpt(string(body)),
+	}
+}
+
+// parsePath splits a JSONPath subset ($.a.b[0].c) into object keys and array indices
+func parsePath(path string) ([]interface{}, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("json path %q must start with $", path)
+	}
+
+	var steps []interface{}
+	rest := path[1:]
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end == -1 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("json path %q has an empty key", path)
+			}
+			steps = append(steps, rest[:end])
+			rest = rest[end:]
+
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end == -1 {
+				return nil, fmt.Errorf("json path %q has an unterminated index", path)
+			}
+			idx, err := strconv.Atoi(rest[1:end])
+			if err != nil || idx < 0 {
+				return nil, fmt.Errorf("json path %q has an invalid index", path)
+			}
+			steps = append(steps, idx)
+			rest = rest[end+1:]
+
+		default:
+			return nil, fmt.Errorf("json path %q is malformed", path)
+		}
+	}
+
+	return steps, nil
+}
+
+func lookup(doc interface{}, path string) (interface{}, error) {
+	steps, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	current := doc
+	for _, step := range steps {
+		switch s := step.(type) {
+		case string:
+			obj, ok := current.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("json path %s: %q is not an object key here", path, s)
+			}
+			if current, ok = obj[s]; !ok {
+				return nil, fmt.Errorf("json path %s: key %q not found", path, s)
+			}
+		case int:
+			arr, ok := current.([]interface{})
+			if !ok || s >= len(arr) {
+				return nil, fmt.Errorf("json path %s: index %d out of range", path, s)
+			}
+			current = arr[s]
+		}
+	}
+
+	return current, nil
+}
+
+func stringify(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+func excerpt(s string) string {
+	if len(s) <= maxExcerpt {
+		return s
+	}
+	return s[:maxExcerpt] + "...(truncated)"
+}
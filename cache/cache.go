@@ -2,6 +2,94 @@ package cache
 
 import (
 	"Distributed-Health-Monitoring/models"
+	"sync"
+	"time"
 )
 
-var MapExternalServices = make(map[uint]*models.ExternalService)
+// Services caches the service definitions of this replica. The API
+// handlers, the worker and the cluster subscriber all write it, so every
+// access goes through the lock.
+var Services = NewServiceCache()
+
+type ServiceCache struct {
+	mu          sync.RWMutex
+	services    map[uint]*models.ExternalService
+	refreshedAt time.Time
+}
+
+func NewServiceCache() *ServiceCache {
+	return &ServiceCache{services: make(map[uint]*models.ExternalService)}
+}
+
+func (c *ServiceCache) Get(id uint) (*models.ExternalService, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	s, ok := c.services[id]
+	return s, ok
+}
+
+func (c *ServiceCache) Set(service *models.ExternalService) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.services[service.ID] = service
+}
+
+// Update replaces a cached service with a copy changed by fn, so readers
+// holding the old entry never see it change. It does nothing when the
+// service isn't cached.
+func (c *ServiceCache) Update(id uint, fn func(service *models.ExternalService)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cached, ok := c.services[id]
+	if !ok {
+		return
+	}
+	updated := *cached
+	fn(&updated)
+	c.services[id] = &updated
+}
+
+func (c *ServiceCache) Delete(id uint) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.services, id)
+}
+
+// Snapshot returns a copy of the cache that is safe to range over
+func (c *ServiceCache) Snapshot() map[uint]*models.ExternalService {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	out := make(map[uint]*models.ExternalService, len(c.services))
+	for id, s := range c.services {
+		out[id] = s
+	}
+	return out
+}
+
+// Replace swaps in a full load from the database, dropping services deleted
+// elsewhere since the last one
+func (c *ServiceCache) Replace(services []*models.ExternalService) {
+	loaded := make(map[uint]*models.ExternalService, len(services))
+	for _, s := range services {
+		loaded[s.ID] = s
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.services = loaded
+	c.refreshedAt = time.Now()
+}
+
+// RefreshedAt is when the cache was last replaced by a full load
+func (c *ServiceCache) RefreshedAt() time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.refreshedAt
+}
+
+// Invalidate marks the cache stale, so the next read that checks its age
+// reloads it
+func (c *ServiceCache) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.refreshedAt = time.Time{}
+}
@@ -0,0 +1,120 @@
+// Package checker holds the checkers the worker probes services with, keyed
+// by protocol. The built-in protocols register themselves from their own
+// packages (httpcheck, tcp, grpc, dns, database); a build can add its own by
+// calling Register from an init function:
+//
+//	func init() {
+//		checker.Register("MQTT", checker.Func(checkMQTT))
+//	}
+//
+// Services registered with that protocol are then checked like any other,
+// with retries, latency thresholds and alerts applied by the worker.
+package checker
+
+import (
+	"Distributed-Health-Monitoring/models"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MaxProtocolLength is the longest protocol name a service can store
+const MaxProtocolLength = 10
+
+// Checker makes one attempt at checking a service. The worker retries
+// failed attempts and grades latency, so a checker only reports what it saw:
+// Status "UP" with Success for a healthy target, otherwise Status "DOWN" with
+// a Reason (unreachable, assertion_failed, ...) and ErrorMessage. Timeouts
+// come from service.TimeoutSeconds.
+type Checker interface {
+	Check(ctx context.Context, service *models.ExternalService) models.CheckResult
+}
+
+// Validator is implemented by checkers that verify the protocol-specific
+// fields of a service before it is registered
+type Validator interface {
+	Validate(service *models.ExternalService) error
+}
+
+// Func adapts a function to a Checker
+type Func func(ctx context.Context, service *models.ExternalService) models.CheckResult
+
+func (f Func) Check(ctx context.Context, service *models.ExternalService) models.CheckResult {
+	return f(ctx, service)
+}
+
+var (
+	mu       sync.RWMutex
+	checkers = make(map[string]Checker)
+)
+
+// Register makes a checker available for a protocol. Like database/sql
+// drivers, it panics when the protocol is taken or can't be stored, since
+// that is a mistake in the build.
+func Register(protocol string, c Checker) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	switch {
+	case c == nil:
+		panic("checker: Register checker is nil for " + protocol)
+	case protocol == "" || len(protocol) > MaxProtocolLength:
+		panic(fmt.Sprintf("checker: protocol %q must be 1 to %d characters", protocol, MaxProtocolLength))
+	}
+	if _, dup := checkers[protocol]; dup {
+		panic("checker: Register called twice for " + protocol)
+	}
+	checkers[protocol] = c
+}
+
+// Get returns the checker of a protocol. Services stored without one are
+// HTTP checks.
+func Get(protocol string) (Checker, bool) {
+	if protocol == "" {
+		protocol = models.ProtocolHTTP
+	}
+
+	mu.RLock()
+	defer mu.RUnlock()
+	c, ok := checkers[protocol]
+	return c, ok
+}
+
+// Resolve returns the checker the worker probes a stored service with.
+// Services saved before protocols were validated can carry one with no
+// checker; they were always checked as HTTP and still are, so only creates
+// and updates are held to Validate.
+func Resolve(protocol string) (Checker, bool) {
+	if c, ok := Get(protocol); ok {
+		return c, true
+	}
+	return Get(models.ProtocolHTTP)
+}
+
+// Validate runs the Validator of the service's protocol, if it has one. A
+// protocol with no checker is an error.
+func Validate(service *models.ExternalService) error {
+	c, ok := Get(service.Protocol)
+	if !ok {
+		return fmt.Errorf("service protocol %q is not supported; use one of %s", service.Protocol, strings.Join(Protocols(), ", "))
+	}
+	if v, ok := c.(Validator); ok {
+		return v.Validate(service)
+	}
+	return nil
+}
+
+// Protocols lists the registered protocols, sorted
+func Protocols() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	out := make([]string, 0, len(checkers))
+	for protocol := range checkers {
+		out = append(out, protocol)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,42 @@
+// Package clock is the time every replica schedules by: the local clock in
+// UTC, corrected by its measured offset to the database clock. Replicas
+// whose machine clocks drift apart still agree on when a service is due,
+// since they all compare against the same reference.
+package clock
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// offset is the database clock minus the local clock, in nanoseconds
+var offset atomic.Int64
+
+// Now returns the current time in UTC on the database clock
+func Now() time.Time {
+	return time.Now().Add(time.Duration(offset.Load())).UTC()
+}
+
+// Offset is how far the database clock is ahead of this machine; negative
+// when this machine is ahead
+func Offset() time.Duration {
+	return time.Duration(offset.Load())
+}
+
+// Sync reads the reference clock and stores its offset from the local
+// clock. The reading is assumed to be taken halfway through the round trip,
+// so the error of the offset is at most half of the round trip, also
+// returned.
+func Sync(ctx context.Context, reference func(ctx context.Context) (time.Time, error)) (time.Duration, time.Duration, error) {
+	start := time.Now()
+	ref, err := reference(ctx)
+	if err != nil {
+		return Offset(), 0, err
+	}
+	rtt := time.Since(start)
+
+	d := ref.Sub(start.Add(rtt / 2))
+	offset.Store(int64(d))
+	return d, rtt, nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"Distributed-Health-Monitoring/models"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+// benchPollInterval is how often a running bench is polled for progress
+const benchPollInterval = 2 * time.Second
+
+type benchResponse struct {
+	Run models.BenchRun `json:"run"`
+}
+
+// bench starts a load test on the server, reports its progress on stderr
+// and prints the result once the run ends
+func bench(c *client, args []string) error {
+	fs := flag.NewFlagSet("bench", flag.ContinueOnError)
+	services := fs.Int("services", 1000, "synthetic services to register")
+	rounds := fs.Int("rounds", 1, "jobs to publish per service, all at once")
+	interval := fs.Int64("interval", 60, "check interval in seconds the required throughput is computed for")
+	latency := fs.Duration("target-latency", 0, "how long the mock target takes to answer")
+	timeout := fs.Duration("timeout", 10*time.Minute, "give up on the run after this long")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	req := map[string]interface{}{
+		"services":          *services,
+		"rounds":            *rounds,
+		"interval_seconds":  *interval,
+		"target_latency_ms": latency.Milliseconds(),
+		"timeout_seconds":   int(timeout.Seconds()),
+	}
+	var out benchResponse
+	if err := c.do(http.MethodPost, "/health-app/admin/bench", req, &out); err != nil {
+		return err
+	}
+
+	for out.Run.Status == models.BenchRunning {
+		time.Sleep(benchPollInterval)
+		if err := c.do(http.MethodGet, "/health-app/admin/bench/"+out.Run.ID, nil, &out); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "bench %s: %d published, %d of %d done\n", out.Run.ID, out.Run.Published, out.Run.Completed+out.Run.Failed, out.Run.Services*out.Run.Rounds)
+	}
+
+	if c.json {
+		if err := printJSON(out.Run); err != nil {
+			return err
+		}
+	} else if err := printBench(out.Run); err != nil {
+		return err
+	}
+	if out.Run.Status == models.BenchFailed {
+		return errors.New(out.Run.Error)
+	}
+	return nil
+}
+
+func printBench(run models.BenchRun) error {
+	fmt.Printf("%d services x %d rounds through %s: %d completed, %d failed in %s\n",
+		run.Services, run.Rounds, run.Queue, run.Completed, run.Failed, time.Duration(run.DurationMs)*time.Millisecond)
+	fmt.Printf("throughput %.2f jobs/s, %.2f jobs/s needed for a %ds interval\n\n", run.JobsPerSecond, run.RequiredJobsPerSecond, run.IntervalSeconds)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "LATENCY\tP50 MS\tP95 MS\tP99 MS\tMAX MS")
+	for _, row := range []struct {
+		name string
+		l    models.BenchLatency
+	}{
+		{"queue", run.QueueLatency},
+		{"check", run.CheckLatency},
+		{"db write", run.DBWriteLatency},
+		{"end to end", run.EndToEndLatency},
+	} {
+		fmt.Fprintf(w, "%s\t%.1f\t%.1f\t%.1f\t%.1f\n", row.name, row.l.P50Ms, row.l.P95Ms, row.l.P99Ms, row.l.MaxMs)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"Distributed-Health-Monitoring/apiv1"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// client sends authenticated requests to the monitor
+type client struct {
+	server   string
+	user     string
+	password string
+	token    string
+	json     bool
+
+	http *http.Client
+}
+
+// requestTimeout bounds ordinary calls; streams use their own client
+const requestTimeout = 30 * time.Second
+
+func (c *client) newRequest(method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(method, strings.TrimRight(c.server, "/")+path, body)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case c.token != "":
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	case c.user != "":
+		req.SetBasicAuth(c.user, c.password)
+	}
+	return req, nil
+}
+
+// do sends a request with an optional JSON body and decodes the response
+// into out. Non-2xx statuses become errors carrying the server's message.
+func (c *client) do(method, path string, body interface{}, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(raw)
+	}
+
+	req, err := c.newRequest(method, path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return c.send(req, out)
+}
+
+func (c *client) send(req *http.Request, out interface{}) error {
+	if c.http == nil {
+		c.http = &http.Client{Timeout: requestTimeout}
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode/100 != 2 {
+		// Some errors, like a partly failed import, still carry a result
+		if out != nil {
+			json.Unmarshal(raw, out)
+		}
+		return responseError(resp.StatusCode, raw)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(raw, out)
+}
+
+func responseError(status int, body []byte) error {
+	var apiErr apiv1.Error
+	if json.Unmarshal(body, &apiErr) == nil && apiErr.Error != "" {
+		return fmt.Errorf("%s: %s", http.StatusText(status), apiErr.Error)
+	}
+	return fmt.Errorf("%s: %s", http.StatusText(status), strings.TrimSpace(string(body)))
+}
+
+// printJSON writes v indented, for --json
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func optionalText(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Local().Format(time.DateTime)
+}
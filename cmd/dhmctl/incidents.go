@@ -0,0 +1,73 @@
+package main
+
+import (
+	"Distributed-Health-Monitoring/apiv1"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"text/tabwriter"
+)
+
+func incidentList(c *client, args []string) error {
+	fs := flag.NewFlagSet("incident list", flag.ContinueOnError)
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	var out apiv1.IncidentListResponse
+	if err := c.do(http.MethodGet, apiv1.Prefix+"/incidents", nil, &out); err != nil {
+		return err
+	}
+	if c.json {
+		return printJSON(out.Incidents)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSERVICE\tSTARTED\tLEVEL\tACKED BY\tREASON")
+	for _, i := range out.Incidents {
+		acked := i.AcknowledgedBy
+		if acked == "" {
+			acked = "-"
+		}
+		fmt.Fprintf(w, "%d\t%d\t%s\t%d\t%s\t%s\n", i.ID, i.ServiceID, formatTime(&i.StartedAt), i.EscalationLevel, acked, i.Reason)
+	}
+	return w.Flush()
+}
+
+func incidentAck(c *client, args []string) error {
+	fs := flag.NewFlagSet("incident ack", flag.ContinueOnError)
+	by := fs.String("by", "", "who acknowledges (default: the authenticated user)")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	id, err := idArg(positional, "incident")
+	if err != nil {
+		return err
+	}
+
+	if *by == "" {
+		var me struct {
+			Subject string `json:"subject"`
+			Email   string `json:"email"`
+		}
+		if err := c.do(http.MethodGet, "/auth/me", nil, &me); err != nil {
+			return err
+		}
+		*by = me.Subject
+		if me.Email != "" {
+			*by = me.Email
+		}
+	}
+
+	var out apiv1.IncidentResponse
+	if err := c.do(http.MethodPost, fmt.Sprintf("%s/incidents/%d/ack", apiv1.Prefix, id), apiv1.AckRequest{By: *by}, &out); err != nil {
+		return err
+	}
+	if c.json {
+		return printJSON(out.Incident)
+	}
+	fmt.Printf("incident %d acknowledged by %s\n", out.Incident.ID, out.Incident.AcknowledgedBy)
+	return nil
+}
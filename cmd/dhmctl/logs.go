@@ -0,0 +1,156 @@
+package main
+
+import (
+	"Distributed-Health-Monitoring/apiv1"
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// reconnectDelay is the wait before reopening a dropped log stream
+const reconnectDelay = 2 * time.Second
+
+func logs(c *client, args []string) error {
+	fs := flag.NewFlagSet("logs", flag.ContinueOnError)
+	limit := fs.Int("limit", 20, "recent logs to show first")
+	status := fs.String("status", "", "only logs with these statuses, comma separated")
+	follow := fs.Bool("follow", false, "keep streaming new logs")
+	fs.BoolVar(follow, "f", false, "shorthand for --follow")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	id, err := idArg(positional, "service")
+	if err != nil {
+		return err
+	}
+
+	query := url.Values{"limit": {strconv.Itoa(*limit)}}
+	if *status != "" {
+		query.Set("status", strings.ToUpper(*status))
+	}
+	var page apiv1.CheckLogListResponse
+	if err := c.do(http.MethodGet, fmt.Sprintf("%s/services/%d/logs?%s", apiv1.Prefix, id, query.Encode()), nil, &page); err != nil {
+		return err
+	}
+
+	// The API returns newest first; print in time order like tail
+	from := time.Now()
+	for i := len(page.Logs) - 1; i >= 0; i-- {
+		printLog(c, page.Logs[i])
+	}
+	if len(page.Logs) > 0 {
+		from = page.Logs[0].CheckedAt.Add(time.Nanosecond)
+	}
+
+	if !*follow {
+		return nil
+	}
+
+	statuses := strings.Split(strings.ToUpper(*status), ",")
+	for {
+		next, err := streamLogs(c, id, from, func(l apiv1.CheckLog) {
+			if *status == "" || containsStatus(statuses, l.Status) {
+				printLog(c, l)
+			}
+		})
+		if err != nil {
+			var rejected *streamError
+			if errors.As(err, &rejected) {
+				return err
+			}
+			fmt.Fprintln(os.Stderr, "dhmctl: stream dropped, reconnecting:", err)
+		}
+		from = next
+		time.Sleep(reconnectDelay)
+	}
+}
+
+// streamError is a rejected stream request, which retrying won't fix
+type streamError struct{ err error }
+
+func (e *streamError) Error() string { return e.err.Error() }
+
+// streamLogs reads the server-sent log events until the connection ends and
+// returns where a reconnect should resume
+func streamLogs(c *client, id uint64, from time.Time, handle func(apiv1.CheckLog)) (time.Time, error) {
+	path := fmt.Sprintf("%s/services/%d/logs/stream?from=%s", apiv1.Prefix, id, url.QueryEscape(from.UTC().Format(time.RFC3339Nano)))
+	req, err := c.newRequest(http.MethodGet, path, nil)
+	if err != nil {
+		return from, &streamError{err}
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	// No timeout: the stream stays open for as long as the user watches
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return from, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return from, &streamError{responseError(resp.StatusCode, body)}
+	}
+
+	var event string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+			switch event {
+			case "log":
+				var l apiv1.CheckLog
+				if err := json.Unmarshal([]byte(data), &l); err != nil {
+					return from, err
+				}
+				handle(l)
+				if l.CheckedAt.After(from) {
+					from = l.CheckedAt.Add(time.Nanosecond)
+				}
+			case "error":
+				return from, fmt.Errorf("server: %s", data)
+			}
+		case line == "":
+			event = ""
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return from, err
+	}
+	return from, io.ErrUnexpectedEOF
+}
+
+func printLog(c *client, l apiv1.CheckLog) {
+	if c.json {
+		raw, _ := json.Marshal(l)
+		fmt.Println(string(raw))
+		return
+	}
+	line := fmt.Sprintf("%s  %-8s  %3d  %5dms", l.CheckedAt.Local().Format(time.DateTime), l.Status, l.StatusCode, l.ResponseTimeMs)
+	if l.Error != "" {
+		line += "  " + l.Error
+	}
+	fmt.Println(line)
+}
+
+func containsStatus(statuses []string, status string) bool {
+	for _, s := range statuses {
+		if strings.TrimSpace(s) == status {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+// Command dhmctl manages the monitor over its REST API:
+//
+//	dhmctl service add -f svc.yaml
+//	dhmctl service list --status down
+//	dhmctl logs 42 --follow
+//	dhmctl incident ack 7
+//	dhmctl bench --services 5000
+//
+// The server and credentials come from flags or the DHMCTL_SERVER,
+// DHMCTL_USER, DHMCTL_PASSWORD and DHMCTL_TOKEN environment variables.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+)
+
+const usage = `Usage: dhmctl [global flags] <command> [flags]
+
+Commands:
+  service add -f FILE           create or update services from a YAML or JSON file ("-" for stdin)
+  service list                  list services (--status, --tag, --team, --sort)
+  service delete ID             archive and delete a service (--reason)
+  logs ID                       show recent check logs (--limit, --status, --follow)
+  incident list                 list open incidents
+  incident ack ID               acknowledge an incident (--by)
+  bench                         load test the monitor (--services, --rounds, --interval, --target-latency)
+
+Global flags:
+`
+
+// errUsage is returned for bad command lines; main prints the usage for it
+var errUsage = errors.New("usage")
+
+func main() {
+	global := flag.NewFlagSet("dhmctl", flag.ContinueOnError)
+	server := global.String("server", envOr("DHMCTL_SERVER", "http://localhost:8080"), "monitor base URL")
+	user := global.String("user", os.Getenv("DHMCTL_USER"), "basic auth user")
+	password := global.String("password", os.Getenv("DHMCTL_PASSWORD"), "basic auth password")
+	token := global.String("token", os.Getenv("DHMCTL_TOKEN"), "bearer token (an OIDC ID token), instead of basic auth")
+	asJSON := global.Bool("json", false, "print raw JSON instead of tables")
+	global.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		global.PrintDefaults()
+	}
+	if err := global.Parse(os.Args[1:]); err != nil {
+		os.Exit(2)
+	}
+
+	c := &client{server: *server, user: *user, password: *password, token: *token, json: *asJSON}
+
+	err := run(c, global.Args())
+	if errors.Is(err, errUsage) {
+		global.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "dhmctl:", err)
+		os.Exit(1)
+	}
+}
+
+func run(c *client, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	switch args[0] {
+	case "service", "services":
+		if len(args) < 2 {
+			return errUsage
+		}
+		switch args[1] {
+		case "add", "apply":
+			return serviceAdd(c, args[2:])
+		case "list", "ls":
+			return serviceList(c, args[2:])
+		case "delete", "rm":
+			return serviceDelete(c, args[2:])
+		}
+	case "logs":
+		return logs(c, args[1:])
+	case "bench":
+		return bench(c, args[1:])
+	case "incident", "incidents":
+		if len(args) < 2 {
+			return errUsage
+		}
+		switch args[1] {
+		case "list", "ls":
+			return incidentList(c, args[2:])
+		case "ack":
+			return incidentAck(c, args[2:])
+		}
+	}
+	return errUsage
+}
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// parseFlags parses flags that may come before or after the positional
+// arguments, so both `logs 42 --follow` and `logs --follow 42` work
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, errUsage
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"Distributed-Health-Monitoring/apiv1"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/goccy/go-yaml"
+)
+
+// importResult is one entry of the import response
+type importResult struct {
+	Name   string `json:"name"`
+	Action string `json:"action"` // created, updated, unchanged, failed
+	ID     uint   `json:"id,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+type importResponse struct {
+	Created   int            `json:"created"`
+	Updated   int            `json:"updated"`
+	Unchanged int            `json:"unchanged"`
+	Failed    int            `json:"failed"`
+	Services  []importResult `json:"services"`
+}
+
+// serviceAdd goes through the import endpoint, which takes the same YAML or
+// JSON files as GitOps imports and upserts by name, so re-running is safe
+func serviceAdd(c *client, args []string) error {
+	fs := flag.NewFlagSet("service add", flag.ContinueOnError)
+	file := fs.String("f", "", `YAML or JSON file with one service or a list ("-" for stdin)`)
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return errors.New("service add needs -f FILE")
+	}
+
+	var data []byte
+	var err error
+	if *file == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(*file)
+	}
+	if err != nil {
+		return err
+	}
+	body, err := serviceDocument(data)
+	if err != nil {
+		return err
+	}
+
+	req, err := c.newRequest(http.MethodPost, "/health-app/externalServices/import", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	// 422 still carries the per-service results, so decode before failing
+	var out importResponse
+	sendErr := c.send(req, &out)
+	if sendErr != nil && len(out.Services) == 0 {
+		return sendErr
+	}
+
+	if c.json {
+		if err := printJSON(out); err != nil {
+			return err
+		}
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tACTION\tID\tERROR")
+		for _, r := range out.Services {
+			fmt.Fprintf(w, "%s\t%s\t%d\t%s\n", r.Name, r.Action, r.ID, r.Error)
+		}
+		w.Flush()
+	}
+
+	if out.Failed > 0 {
+		return fmt.Errorf("%d of %d services failed", out.Failed, len(out.Services))
+	}
+	return nil
+}
+
+// serviceDocument converts the file to the JSON the import endpoint takes.
+// Import wants a list or {"services": [...]}; a file with a single service
+// is wrapped in a list.
+func serviceDocument(data []byte) ([]byte, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] != '[' && data[0] != '{' {
+		converted, err := yaml.YAMLToJSON(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse YAML: %w", err)
+		}
+		data = converted
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse service file: %w", err)
+	}
+	if obj, ok := doc.(map[string]interface{}); ok {
+		if _, wrapped := obj["services"]; !wrapped {
+			return json.Marshal([]interface{}{obj})
+		}
+	}
+	return data, nil
+}
+
+func serviceList(c *client, args []string) error {
+	fs := flag.NewFlagSet("service list", flag.ContinueOnError)
+	status := fs.String("status", "", "only services with these statuses, comma separated (down, degraded, ...)")
+	tag := fs.String("tag", "", "only services with this tag")
+	team := fs.String("team", "", "only services owned by this team")
+	sort := fs.String("sort", "", "id, name, status, last_checked_at or created_at; prefix - for descending")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	query := url.Values{}
+	if *status != "" {
+		query.Set("status", strings.ToUpper(*status))
+	}
+	if *tag != "" {
+		query.Set("tag", *tag)
+	}
+	if *team != "" {
+		query.Set("team", *team)
+	}
+	if *sort != "" {
+		query.Set("sort", *sort)
+	}
+	query.Set("limit", "1000")
+
+	// Follow next_offset until every matching service is fetched
+	var services []apiv1.Service
+	for offset := 0; ; {
+		query.Set("offset", strconv.Itoa(offset))
+		var page apiv1.ServiceListResponse
+		if err := c.do(http.MethodGet, apiv1.Prefix+"/services?"+query.Encode(), nil, &page); err != nil {
+			return err
+		}
+		services = append(services, page.Services...)
+		if page.NextOffset == nil {
+			break
+		}
+		offset = *page.NextOffset
+	}
+
+	if c.json {
+		return printJSON(services)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tSTATUS\tPROTOCOL\tINTERVAL\tLAST CHECKED\tTEAM\tURL")
+	for _, s := range services {
+		status := s.Status
+		if s.RootCause != "" {
+			status += " (dependency)"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%ds\t%s\t%s\t%s\n", s.ID, s.Name, status, s.Protocol, s.Interval, formatTime(s.LastCheckedAt), optionalText(s.Team), s.URL)
+	}
+	return w.Flush()
+}
+
+func serviceDelete(c *client, args []string) error {
+	fs := flag.NewFlagSet("service delete", flag.ContinueOnError)
+	reason := fs.String("reason", "", "reason stored with the archive and in the audit log")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	id, err := idArg(positional, "service")
+	if err != nil {
+		return err
+	}
+
+	path := fmt.Sprintf("%s/services/%d", apiv1.Prefix, id)
+	if *reason != "" {
+		path += "?reason=" + url.QueryEscape(*reason)
+	}
+
+	var out apiv1.DeleteServiceResponse
+	if err := c.do(http.MethodDelete, path, nil, &out); err != nil {
+		return err
+	}
+	if c.json {
+		return printJSON(out)
+	}
+	fmt.Printf("service %d deleted (archive %d)\n", id, out.ArchiveID)
+	return nil
+}
+
+// idArg expects exactly one numeric argument
+func idArg(positional []string, what string) (uint64, error) {
+	if len(positional) != 1 {
+		return 0, fmt.Errorf("expected one %s id", what)
+	}
+	id, err := strconv.ParseUint(positional[0], 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s id %q", what, positional[0])
+	}
+	return id, nil
+}
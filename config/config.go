@@ -5,17 +5,74 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
-
-	"gorm.io/driver/postgres"
-	"gorm.io/gorm"
 )
 
 // Config holds the structure of config.json
 type Config struct {
-	PostgreSQL PostgreSQL `json:"postgresql"`
-	RabbitMQ   RabbitMQ   `json:"rabbitmq"`
-	Server     Server     `json:"server"`
-	Auth       AuthConfig `json:"auth"`
+	Database    Database    `json:"database"`
+	PostgreSQL  PostgreSQL  `json:"postgresql"`
+	RabbitMQ    RabbitMQ    `json:"rabbitmq"`
+	Queue       Queue       `json:"queue"`
+	Server      Server      `json:"server"`
+	Worker      Worker      `json:"worker"`
+	GRPCAPI     GRPCAPI     `json:"grpc_api"`
+	Auth        AuthConfig  `json:"auth"`
+	Chaos       Chaos       `json:"chaos"`
+	Bench       Bench       `json:"bench"`
+	Flapping    Flapping    `json:"flapping"`
+	HA          HA          `json:"ha"`
+	Cluster     Cluster     `json:"cluster"`
+	Scheduler   Scheduler   `json:"scheduler"`
+	LogStore    LogStore    `json:"log_store"`
+	DBHealth    DBHealth    `json:"db_health"`
+	Consistency Consistency `json:"consistency"`
+	WebSocket   WebSocket   `json:"websocket"`
+
+	Notifications Notifications `json:"notifications"`
+	Escalation    Escalation    `json:"escalation"`
+	Consul        Consul        `json:"consul"`
+	Gates         []Gate        `json:"gates"`
+	SelfMonitor   SelfMonitor   `json:"self_monitor"`
+	Logging       Logging       `json:"logging"`
+	Offboarding   Offboarding   `json:"offboarding"`
+	Probes        Probes        `json:"probes"`
+	Fairness      Fairness      `json:"fairness"`
+	Webhooks      Webhooks      `json:"webhooks"`
+	Remediation   Remediation   `json:"remediation"`
+	SLO           SLO           `json:"slo"`
+	LogArchive    LogArchive    `json:"log_archive"`
+	Secrets       Secrets       `json:"secrets"`
+}
+
+// Database picks the SQL database behind the repository
+type Database struct {
+	Driver string `json:"driver"` // postgres (default), sqlite or mysql
+	SQLite SQLite `json:"sqlite"`
+	MySQL  MySQL  `json:"mysql"`
+}
+
+// DriverName returns the driver, applying the default
+func (d Database) DriverName() string {
+	if d.Driver == "" {
+		return "postgres"
+	}
+	return d.Driver
+}
+
+// SQLite keeps everything in one file, for development and single-node setups
+type SQLite struct {
+	Path string `json:"path"` // default monitor.db
+}
+
+type MySQL struct {
+	Host         string `json:"host"`
+	Port         int    `json:"port"`
+	User         string `json:"user"`
+	Password     string `json:"password"`
+	Database     string `json:"database"`
+	Params       string `json:"params"` // extra DSN parameters, e.g. tls=true
+	MaxOpenConns int    `json:"max_open_conns"`
+	MaxIdleConns int    `json:"max_idle_conns"`
 }
 
 type PostgreSQL struct {
@@ -38,17 +95,471 @@ type RabbitMQ struct {
 	QueueName  string `json:"queue_name"`
 	Exchange   string `json:"exchange"`
 	RoutingKey string `json:"routing_key"`
+
+	DeadLetterExchange string `json:"dead_letter_exchange"` // default: <queue_name>.dlx
+	DeadLetterQueue    string `json:"dead_letter_queue"`    // default: <queue_name>.dead
+}
+
+// DeadLetterNames returns the dead-letter exchange and queue, applying defaults
+func (r RabbitMQ) DeadLetterNames() (string, string) {
+	exchange := r.DeadLetterExchange
+	if exchange == "" {
+		exchange = r.QueueName + ".dlx"
+	}
+	queue := r.DeadLetterQueue
+	if queue == "" {
+		queue = r.QueueName + ".dead"
+	}
+	return exchange, queue
 }
 
+// Server bounds what a single client can make the API do. Zero values fall
+// back to the defaults in the comments.
 type Server struct {
 	Address string `json:"address"`
+
+	ReadHeaderTimeoutSeconds int   `json:"read_header_timeout_seconds"` // default 10
+	ReadTimeoutSeconds       int   `json:"read_timeout_seconds"`        // headers and body; default 30
+	WriteTimeoutSeconds      int   `json:"write_timeout_seconds"`       // default 60; streams and WebSockets are exempt
+	IdleTimeoutSeconds       int   `json:"idle_timeout_seconds"`        // keep-alive; default 120
+	MaxHeaderBytes           int   `json:"max_header_bytes"`            // default 64 KiB
+	MaxBodyBytes             int64 `json:"max_body_bytes"`              // request bodies except imports and lint; default 1 MiB
+
+	// Proxies whose X-Forwarded-For is believed when finding the client IP;
+	// empty to use the connection's address
+	TrustedProxies []string  `json:"trusted_proxies"`
+	RateLimit      RateLimit `json:"rate_limit"`
+}
+
+// RateLimit is a token bucket per client: per credential for requests that
+// carry one, per IP otherwise. Failed logins also drain a bucket of their
+// IP, so guessing credentials from one address is throttled.
+type RateLimit struct {
+	Enabled           bool    `json:"enabled"`
+	RequestsPerSecond float64 `json:"requests_per_second"` // refill rate; default 10
+	Burst             int     `json:"burst"`               // bucket size; default 40
+	AuthFailureBurst  int     `json:"auth_failure_burst"`  // failed logins per IP before throttling, refilled at one a minute; default 10
+	MaxClients        int     `json:"max_clients"`         // buckets kept; beyond that new clients share one; default 10000
 }
 
+// GRPCAPI serves MonitorService from proto/monitor/v1 next to the REST API
+type GRPCAPI struct {
+	Enabled   bool   `json:"enabled"`
+	Address   string `json:"address"`    // own listener, default :9090
+	SharePort bool   `json:"share_port"` // serve on server.address instead, told apart by content type
+}
+
+// AuthConfig protects the API. The shared Basic credential is accepted when
+// username is set and acts as an admin; OIDC logs operators in through the
+// organisation's identity provider with a role from their groups.
 type AuthConfig struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
+	OIDC     OIDC   `json:"oidc"`
 }
 
+// OIDC configures login through an OpenID Connect provider
+type OIDC struct {
+	Enabled      bool     `json:"enabled"`
+	Issuer       string   `json:"issuer"`
+	ClientID     string   `json:"client_id"`
+	ClientSecret string   `json:"client_secret"`
+	RedirectURL  string   `json:"redirect_url"` // this server's /auth/callback as registered with the provider
+	Scopes       []string `json:"scopes"`       // defaults to openid, profile, email, groups
+	GroupsClaim  string   `json:"groups_claim"` // defaults to "groups"
+	// RoleMapping maps provider groups to viewer, operator or admin; a user
+	// in several groups gets the highest role
+	RoleMapping map[string]string `json:"role_mapping"`
+	DefaultRole string            `json:"default_role"` // for users in no mapped group; empty refuses them
+	// SessionKey signs session cookies; defaults to one derived from the
+	// client secret. Replicas must share it.
+	SessionKey        string `json:"session_key"`
+	SessionTTLSeconds int    `json:"session_ttl_seconds"`
+}
+
+// Chaos controls the admin-only synthetic failure injection used to test alerting end-to-end
+type Chaos struct {
+	Enabled bool `json:"enabled"`
+}
+
+// Bench controls the admin-only load test, which registers synthetic
+// services for the length of a run
+type Bench struct {
+	Enabled bool `json:"enabled"`
+}
+
+// Flapping configures transition-rate based alert suppression.
+// A Threshold of 0 disables flapping detection.
+type Flapping struct {
+	WindowSeconds int64 `json:"window_seconds"`
+	Threshold     int   `json:"threshold"`
+}
+
+// HA enables leader election so several replicas can run side by side while
+// only one of them schedules jobs
+type HA struct {
+	Enabled    bool   `json:"enabled"`
+	LockKey    int64  `json:"lock_key"`    // Postgres advisory lock id shared by all replicas
+	InstanceID string `json:"instance_id"` // defaults to the hostname
+}
+
+// Instance returns the configured instance id or the hostname
+func (h HA) Instance() string {
+	if h.InstanceID != "" {
+		return h.InstanceID
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		return "unknown"
+	}
+	return host
+}
+
+// Cluster shares the service cache and live events between replicas
+// through Redis pub/sub
+type Cluster struct {
+	Enabled  bool   `json:"enabled"`
+	Address  string `json:"address"` // host:port
+	Password string `json:"password"`
+	DB       int    `json:"db"`
+	Channel  string `json:"channel"` // default: dhm:cluster
+}
+
+// Scheduler spreads runs with jitter. Mode is the older way to select
+// in-process checks: "inline" is read as queue.driver "memory" with the
+// inline_* sizes.
+type Scheduler struct {
+	Mode            string `json:"mode"`
+	InlineWorkers   int    `json:"inline_workers"`
+	InlineQueueSize int    `json:"inline_queue_size"`
+
+	// Each run is delayed by a random amount of up to jitter_percent of the
+	// time to the next run, capped at max_jitter_seconds (0 for no cap)
+	JitterPercent    int `json:"jitter_percent"`
+	MaxJitterSeconds int `json:"max_jitter_seconds"`
+
+	// A check overdue by more than lag_warn_seconds (default 120, negative
+	// to disable) raises a scheduler_lag warning
+	LagWarnSeconds int `json:"lag_warn_seconds"`
+
+	// Every replica reads the database clock every clock_sync_seconds
+	// (default 60, negative to disable) and schedules by it. An offset above
+	// clock_skew_warn_seconds (default 2, negative to disable) raises a
+	// clock_skew warning.
+	ClockSyncSeconds     int     `json:"clock_sync_seconds"`
+	ClockSkewWarnSeconds float64 `json:"clock_skew_warn_seconds"`
+}
+
+// Worker labels the checks this replica runs. A worker with a region also
+// consumes the jobs of services that require that region, from a queue or
+// stream named after the job queue with ".<region>" appended.
+type Worker struct {
+	Region string     `json:"region"` // e.g. eu-west; empty runs only the shared queue
+	HTTP   WorkerHTTP `json:"http"`
+}
+
+// WorkerHTTP tunes the connection pools of HTTP checks
+type WorkerHTTP struct {
+	MaxIdleConns           int  `json:"max_idle_conns"`            // across all targets, default 256
+	MaxIdleConnsPerHost    int  `json:"max_idle_conns_per_host"`   // default 4
+	IdleConnTimeoutSeconds int  `json:"idle_conn_timeout_seconds"` // default 90
+	DisableCompression     bool `json:"disable_compression"`       // send no Accept-Encoding: gzip, so bodies arrive as the target serves them
+	DisableKeepAlives      bool `json:"disable_keep_alives"`       // open a new connection for every check
+	ExcludeDNSFromLatency  bool `json:"exclude_dns_from_latency"`  // leave name resolution out of the measured latency
+}
+
+// Queue picks the broker that carries jobs from the scheduler to the workers
+type Queue struct {
+	Driver string      `json:"driver"` // rabbitmq (default), redis or memory
+	Redis  RedisQueue  `json:"redis"`
+	Memory MemoryQueue `json:"memory"`
+}
+
+// DriverName returns the driver, applying the default
+func (q Queue) DriverName() string {
+	if q.Driver == "" {
+		return "rabbitmq"
+	}
+	return q.Driver
+}
+
+// InProcess reports whether jobs stay inside this process, with no broker
+func (q Queue) InProcess() bool {
+	return q.DriverName() == "memory"
+}
+
+// MemoryQueue sizes the in-process driver, which suits single-node installs
+// monitoring fewer than ~100 services
+type MemoryQueue struct {
+	Workers   int `json:"workers"`    // goroutines running checks, default 10
+	QueueSize int `json:"queue_size"` // jobs buffered before publishing fails, default 1000
+}
+
+// RedisQueue configures the Redis Streams driver
+type RedisQueue struct {
+	Address    string `json:"address"` // host:port
+	Password   string `json:"password"`
+	DB         int    `json:"db"`
+	Stream     string `json:"stream"`      // default: health_checks
+	Group      string `json:"group"`       // consumer group shared by the workers, default: workers
+	DeadStream string `json:"dead_stream"` // default: <stream>.dead
+	// ClaimIdleSeconds is how long a job may stay unacknowledged before
+	// another worker takes it over, e.g. after a crash; default 60
+	ClaimIdleSeconds int `json:"claim_idle_seconds"`
+	// MaxDeliveries dead-letters a job taken over this many times; default 3
+	MaxDeliveries int `json:"max_deliveries"`
+}
+
+// Notifications configures alert severities and the channels alerts go to
+type Notifications struct {
+	// Severity per transition ("UP->DOWN", "*->DEGRADED") or event
+	// ("flapping_start", "cert_expiry", "slo_burn"): info, warning or critical
+	Severity  map[string]string `json:"severity"`
+	Notifiers []Notifier        `json:"notifiers"`
+}
+
+// Notifier is one delivery channel and its routing rule
+type Notifier struct {
+	ID          string   `json:"id"`
+	Type        string   `json:"type"`         // webhook, slack, pagerduty
+	URL         string   `json:"url"`          // webhook or Slack incoming webhook URL; optional for pagerduty
+	RoutingKey  string   `json:"routing_key"`  // pagerduty integration key
+	MinSeverity string   `json:"min_severity"` // lowest severity delivered, default info
+	Severities  []string `json:"severities"`   // exact severities delivered, all when empty
+	Tags        []string `json:"tags"`         // only services with one of these tags, all when empty
+	To          []string `json:"to"`           // email recipients
+	SMTP        SMTP     `json:"smtp"`         // email server
+}
+
+type SMTP struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	From     string `json:"from"`
+}
+
+// Consul syncs services from a Consul catalog into the monitor
+type Consul struct {
+	Enabled             bool   `json:"enabled"`
+	Address             string `json:"address"` // HTTP API, default http://127.0.0.1:8500
+	Token               string `json:"token"`
+	Datacenter          string `json:"datacenter"`
+	Tag                 string `json:"tag"` // only services carrying this tag, all when empty
+	SyncIntervalSeconds int    `json:"sync_interval_seconds"`
+	CheckInterval       int64  `json:"check_interval"`    // seconds, when the Consul check has none
+	TimeoutSeconds      int64  `json:"timeout_seconds"`   // when the Consul check has none
+	FailureThreshold    int64  `json:"failure_threshold"` // for created services
+}
+
+// Escalation advances unacknowledged incidents through notifier chains
+type Escalation struct {
+	IntervalSeconds int                `json:"interval_seconds"` // how often open incidents are evaluated
+	Policies        []EscalationPolicy `json:"policies"`
+}
+
+// EscalationPolicy applies to the listed services, or else to services with
+// one of its tags; the first matching policy wins
+type EscalationPolicy struct {
+	ID       string           `json:"id"`
+	Services []string         `json:"services"`
+	Tags     []string         `json:"tags"`
+	Steps    []EscalationStep `json:"steps"`
+}
+
+type EscalationStep struct {
+	AfterMinutes int      `json:"after_minutes"` // since the incident started
+	Notifiers    []string `json:"notifiers"`     // notifier ids
+}
+
+// Secrets holds the key that encrypts credentials stored with services
+type Secrets struct {
+	Key    string `json:"key"`     // base64 32-byte AES key
+	KeyEnv string `json:"key_env"` // environment variable holding the key when key is empty, e.g. injected from a KMS
+}
+
+// Probes sets the thresholds of /healthz and /readyz
+type Probes struct {
+	SchedulerStaleSeconds int `json:"scheduler_stale_seconds"` // no scheduler tick for this long fails both probes
+	MaxWorkerLagSeconds   int `json:"max_worker_lag_seconds"`  // jobs waiting longer than this to start fail /readyz
+	TimeoutSeconds        int `json:"timeout_seconds"`         // per dependency check
+}
+
+// Fairness sets when a service counts as starved: at least min_samples of
+// its last window checks seen, and half of them started more than one
+// interval after the service was due
+type Fairness struct {
+	Window     int `json:"window"`      // default 20
+	MinSamples int `json:"min_samples"` // default 5
+}
+
+// Webhooks configures delivery to the endpoints registered through the API.
+// A failed attempt is retried after initial_backoff_seconds, doubling up to
+// max_backoff_seconds, until max_attempts attempts were made.
+type Webhooks struct {
+	MaxAttempts           int `json:"max_attempts"`            // default 5
+	InitialBackoffSeconds int `json:"initial_backoff_seconds"` // default 2
+	MaxBackoffSeconds     int `json:"max_backoff_seconds"`     // default 300
+	TimeoutSeconds        int `json:"timeout_seconds"`         // per attempt; default 10
+	CertExpiryDays        int `json:"cert_expiry_days"`        // warn when an HTTPS certificate expires within this many days; default 14, -1 disables
+}
+
+// Remediation runs the self-healing actions attached to services. Commands
+// are only defined here and referenced by name, so the API can't make the
+// monitor run arbitrary programs.
+type Remediation struct {
+	Enabled        bool                          `json:"enabled"`
+	TimeoutSeconds int                           `json:"timeout_seconds"` // per action; default 30
+	WebhookSecret  string                        `json:"webhook_secret"`  // signs webhook actions like webhook deliveries; required for them
+	Commands       map[string]RemediationCommand `json:"commands"`
+	Kubernetes     Kubernetes                    `json:"kubernetes"`
+}
+
+// RemediationCommand is a program run without a shell. It gets the service
+// in DHM_SERVICE_ID, DHM_SERVICE_NAME, DHM_SERVICE_URL and
+// DHM_CONSECUTIVE_FAILURES, but none of the monitor's environment.
+type RemediationCommand struct {
+	Args []string `json:"args"` // program and its arguments
+	Dir  string   `json:"dir"`  // working directory; empty for the monitor's
+	Env  []string `json:"env"`  // extra KEY=value variables
+}
+
+// Kubernetes is the API server deployments are restarted through. The
+// defaults are the in-cluster service account.
+type Kubernetes struct {
+	APIServer string `json:"api_server"` // default https://kubernetes.default.svc
+	TokenFile string `json:"token_file"` // default /var/run/secrets/kubernetes.io/serviceaccount/token, read before each call
+	CAFile    string `json:"ca_file"`    // default /var/run/secrets/kubernetes.io/serviceaccount/ca.crt
+}
+
+// SLO configures the evaluator that alerts when a service spends its error
+// budget too fast. A rule fires while the burn rate is at least burn_rate
+// over both its windows: the long one makes it significant, the short one
+// makes it stop soon after the burning does.
+type SLO struct {
+	Enabled         bool       `json:"enabled"`          // send burn-rate alerts; GET /slo works either way
+	IntervalSeconds int        `json:"interval_seconds"` // how often burn rates are evaluated; default 60
+	Alerts          []SLOAlert `json:"alerts"`           // default: 14.4x over 1h and 5m, critical; 6x over 6h and 30m, warning
+}
+
+type SLOAlert struct {
+	LongWindowMinutes  int     `json:"long_window_minutes"`
+	ShortWindowMinutes int     `json:"short_window_minutes"`
+	BurnRate           float64 `json:"burn_rate"` // 1 spends the budget exactly over the SLO window
+	Severity           string  `json:"severity"`  // default the notifications severity of slo_burn
+}
+
+// LogArchive uploads the check logs of each day as gzipped CSV to S3 or an
+// S3-compatible store once the day is older than retention_days, then
+// removes them from the log store
+type LogArchive struct {
+	Enabled         bool `json:"enabled"`
+	RetentionDays   int  `json:"retention_days"`   // whole days kept in the log store; default 30
+	IntervalMinutes int  `json:"interval_minutes"` // how often to look for days to archive; default 60
+	S3              S3   `json:"s3"`
+}
+
+type S3 struct {
+	Endpoint        string `json:"endpoint"` // default https://s3.<region>.amazonaws.com
+	Region          string `json:"region"`   // default us-east-1
+	Bucket          string `json:"bucket"`
+	Prefix          string `json:"prefix"`            // object key prefix; default check-logs
+	PathStyle       bool   `json:"path_style"`        // bucket in the path rather than the host, as MinIO needs
+	AccessKeyID     string `json:"access_key_id"`     // default $AWS_ACCESS_KEY_ID
+	SecretAccessKey string `json:"secret_access_key"` // default $AWS_SECRET_ACCESS_KEY; $AWS_SESSION_TOKEN is sent too when set
+}
+
+// Offboarding configures per-organization exports
+type Offboarding struct {
+	Dir string `json:"dir"` // each export job writes a subdirectory here
+}
+
+// Logging configures the structured logger
+type Logging struct {
+	Level  string `json:"level"`  // debug, info, warn or error
+	Format string `json:"format"` // text or json
+}
+
+// SelfMonitor registers checks for the monitor's own dependencies at startup
+type SelfMonitor struct {
+	Enabled      bool   `json:"enabled"`
+	Interval     int64  `json:"interval"`      // check interval in seconds
+	AdvertiseURL string `json:"advertise_url"` // HA: base URL other replicas reach this one on, e.g. http://monitor-1:8080
+
+	ReplicaTTLSeconds int64 `json:"replica_ttl_seconds"` // HA: remove another replica's check once it has been DOWN this long; default 86400
+}
+
+// Gate is a deploy-gate policy evaluated by GET /gates/:name. It applies to
+// the listed services plus every service carrying one of its tags.
+type Gate struct {
+	Name             string   `json:"name"`
+	Services         []string `json:"services"`
+	Tags             []string `json:"tags"`
+	UpForMinutes     int      `json:"up_for_minutes"`    // every service UP for at least this long
+	NoOpenIncidents  bool     `json:"no_open_incidents"` // fail while any service has an open incident
+	AllowMaintenance bool     `json:"allow_maintenance"` // services in a maintenance window don't fail the gate
+}
+
+// WebSocket bounds the event history kept for reconnecting clients and the
+// per-client send queues
+type WebSocket struct {
+	ReplayBuffer     int    `json:"replay_buffer"`      // events kept for last_seq replay, 0 disables replay
+	SendQueue        int    `json:"send_queue"`         // live events queued per client on top of a full replay (default 256)
+	SlowClientPolicy string `json:"slow_client_policy"` // disconnect (default) or drop_oldest
+
+	AllowAnonymous      bool     `json:"allow_anonymous"`       // accept connections without credentials
+	AllowedOrigins      []string `json:"allowed_origins"`       // browser origins allowed to connect, "*" for any; empty for same-origin only
+	MaxConnections      int      `json:"max_connections"`       // per replica, default 1000
+	PingIntervalSeconds int      `json:"ping_interval_seconds"` // default 30
+	PongTimeoutSeconds  int      `json:"pong_timeout_seconds"`  // close when nothing, not even a pong, arrives for this long; default 60
+	WriteTimeoutSeconds int      `json:"write_timeout_seconds"` // default 10
+	TokenTTLSeconds     int      `json:"token_ttl_seconds"`     // lifetime of tokens from /auth/ws-token, default 60
+}
+
+// Consistency controls the check for contradicting service and incident state
+type Consistency struct {
+	CheckOnStartup  bool `json:"check_on_startup"`
+	RepairOnStartup bool `json:"repair_on_startup"` // repair what the startup check finds instead of only logging it
+}
+
+// DBHealth controls the index and query-plan verification
+type DBHealth struct {
+	CheckOnStartup bool `json:"check_on_startup"`
+	CreateMissing  bool `json:"create_missing"` // create missing indexes concurrently at startup
+}
+
+// LogStore selects where check logs are persisted
+type LogStore struct {
+	Driver        string     `json:"driver"`         // postgres (default), timescale, clickhouse, file
+	Path          string     `json:"path"`           // file driver: JSON-lines file
+	ChunkInterval string     `json:"chunk_interval"` // timescale driver: hypertable chunk size, e.g. "1 day"
+	ClickHouse    ClickHouse `json:"clickhouse"`
+	Cache         LogCache   `json:"cache"`
+	Batch         LogBatch   `json:"batch"`
+}
+
+// LogBatch buffers check logs in the worker and writes them in batches
+type LogBatch struct {
+	Enabled         bool `json:"enabled"`
+	BatchSize       int  `json:"batch_size"`        // rows per insert; a full batch is written at once
+	FlushIntervalMs int  `json:"flush_interval_ms"` // longest a log waits for its batch
+	QueueSize       int  `json:"queue_size"`        // buffered logs before checks wait for the writer
+}
+
+// LogCache keeps the newest logs of each service in memory for dashboard polling
+type LogCache struct {
+	Enabled    bool `json:"enabled"`
+	Rows       int  `json:"rows"`        // newest rows kept per service; deeper pages go to the store
+	TTLSeconds int  `json:"ttl_seconds"` // how long a cached page may miss writes made by other replicas
+}
+
+type ClickHouse struct {
+	URL      string `json:"url"` // HTTP interface, e.g. http://clickhouse:8123
+	Database string `json:"database"`
+	Table    string `json:"table"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
 
 // LoadConfig reads the config file and unmarshals it
 func LoadConfig(filePath string) (*Config, error) {
@@ -69,31 +580,17 @@ func LoadConfig(filePath string) (*Config, error) {
 		return nil, fmt.Errorf("failed to parse config JSON: %w", err)
 	}
 
-	return &config, nil
-}
-
-// ConnectPostgres establishes a PostgreSQL connection using GORM
-func ConnectPostgres(cfg *Config) (*gorm.DB, error) {
-	pgCfg := cfg.PostgreSQL
-	dsn := fmt.Sprintf(
-		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		pgCfg.Host, pgCfg.Port, pgCfg.User, pgCfg.Password, pgCfg.Database, pgCfg.SSLMode,
-	)
-
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to PostgreSQL: %w", err)
-	}
-
-	sqlDB, err := db.DB()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get sql.DB from GORM: %w", err)
+	if config.Scheduler.Mode == "inline" && config.Queue.Driver == "" {
+		config.Queue.Driver = "memory"
+		if config.Queue.Memory.Workers == 0 {
+			config.Queue.Memory.Workers = config.Scheduler.InlineWorkers
+		}
+		if config.Queue.Memory.QueueSize == 0 {
+			config.Queue.Memory.QueueSize = config.Scheduler.InlineQueueSize
+		}
 	}
 
-	sqlDB.SetMaxOpenConns(pgCfg.MaxOpenConns)
-	sqlDB.SetMaxIdleConns(pgCfg.MaxIdleConns)
-
-	return db, nil
+	return &config, nil
 }
 
 func GetServerAddress(cfg *Config) string {
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"Distributed-Health-Monitoring/clock"
+	"fmt"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// dialectors holds the database drivers compiled into this binary. Postgres
+// is always there; sqlite and mysql register themselves when built with the
+// tag of the same name, so the default build doesn't pull in cgo or MySQL.
+var dialectors = map[string]func(cfg *Config) gorm.Dialector{
+	"postgres": postgresDialector,
+}
+
+func postgresDialector(cfg *Config) gorm.Dialector {
+	pgCfg := cfg.PostgreSQL
+	dsn := fmt.Sprintf(
+		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s TimeZone=UTC",
+		pgCfg.Host, pgCfg.Port, pgCfg.User, pgCfg.Password, pgCfg.Database, pgCfg.SSLMode,
+	)
+	return postgres.Open(dsn)
+}
+
+// ConnectDatabase opens the database selected by database.driver using GORM
+func ConnectDatabase(cfg *Config) (*gorm.DB, error) {
+	driver := cfg.Database.DriverName()
+	dialector, ok := dialectors[driver]
+	if !ok {
+		switch driver {
+		case "sqlite", "mysql":
+			return nil, fmt.Errorf("database driver %q is not compiled in; build with -tags %s", driver, driver)
+		}
+		return nil, fmt.Errorf("unknown database driver %q", driver)
+	}
+
+	// Timestamps gorm fills in (created_at, updated_at, deleted_at) are in
+	// UTC on the database clock, like the ones the scheduler compares
+	db, err := gorm.Open(dialector(cfg), &gorm.Config{NowFunc: clock.Now})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", driver, err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sql.DB from GORM: %w", err)
+	}
+
+	switch driver {
+	case "postgres":
+		sqlDB.SetMaxOpenConns(cfg.PostgreSQL.MaxOpenConns)
+		sqlDB.SetMaxIdleConns(cfg.PostgreSQL.MaxIdleConns)
+	case "mysql":
+		sqlDB.SetMaxOpenConns(cfg.Database.MySQL.MaxOpenConns)
+		sqlDB.SetMaxIdleConns(cfg.Database.MySQL.MaxIdleConns)
+	case "sqlite":
+		// SQLite allows one writer at a time; one connection queues writes in
+		// the pool instead of failing them with "database is locked"
+		sqlDB.SetMaxOpenConns(1)
+	}
+
+	return db, nil
+}
+
+// AutoMigrate creates or updates the tables of models. The models declare
+// their JSON columns as jsonb, which MySQL calls json; SQLite accepts any
+// type name.
+func AutoMigrate(db *gorm.DB, models ...interface{}) error {
+	if db.Dialector.Name() == "mysql" {
+		for _, model := range models {
+			// Parsed schemas are cached on db, so AutoMigrate sees the change
+			stmt := &gorm.Statement{DB: db}
+			if err := stmt.Parse(model); err != nil {
+				return err
+			}
+			for _, field := range stmt.Schema.Fields {
+				if field.DataType == "jsonb" {
+					field.DataType = "json"
+				}
+			}
+		}
+	}
+	return db.AutoMigrate(models...)
+}
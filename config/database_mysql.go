@@ -0,0 +1,23 @@
+//go:build mysql
+
+package config
+
+import (
+	"fmt"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dialectors["mysql"] = func(cfg *Config) gorm.Dialector {
+		my := cfg.Database.MySQL
+		// parseTime scans DATETIME and TIMESTAMP columns into time.Time
+		dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=true&loc=UTC",
+			my.User, my.Password, my.Host, my.Port, my.Database)
+		if my.Params != "" {
+			dsn += "&" + my.Params
+		}
+		return mysql.Open(dsn)
+	}
+}
@@ -0,0 +1,20 @@
+//go:build sqlite
+
+package config
+
+import (
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dialectors["sqlite"] = func(cfg *Config) gorm.Dialector {
+		path := cfg.Database.SQLite.Path
+		if path == "" {
+			path = "monitor.db"
+		}
+		// WAL lets the API read while the worker writes; foreign keys are off
+		// by default in SQLite and the log and incident tables cascade on them
+		return sqlite.Open(path + "?_busy_timeout=5000&_journal_mode=WAL&_foreign_keys=on")
+	}
+}
@@ -0,0 +1,149 @@
+package consul
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Client reads the service catalog through the Consul HTTP API
+type Client struct {
+	address    string
+	token      string
+	datacenter string
+	http       *http.Client
+}
+
+func NewClient(address, token, datacenter string) *Client {
+	if address == "" {
+		address = "http://127.0.0.1:8500"
+	}
+	return &Client{
+		address:    strings.TrimRight(address, "/"),
+		token:      token,
+		datacenter: datacenter,
+		http:       &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// ServiceEntry is one instance of a service with its registered checks
+type ServiceEntry struct {
+	Node struct {
+		Node    string `json:"Node"`
+		Address string `json:"Address"`
+	} `json:"Node"`
+	Service struct {
+		ID      string   `json:"ID"`
+		Service string   `json:"Service"`
+		Address string   `json:"Address"`
+		Port    int      `json:"Port"`
+		Tags    []string `json:"Tags"`
+	} `json:"Service"`
+	Checks []Check `json:"Checks"`
+}
+
+type Check struct {
+	CheckID    string `json:"CheckID"`
+	Name       string `json:"Name"`
+	Type       string `json:"Type"` // http, tcp, ttl, script, ...
+	ServiceID  string `json:"ServiceID"`
+	Definition struct {
+		HTTP     string `json:"HTTP"`
+		Method   string `json:"Method"`
+		TCP      string `json:"TCP"`
+		Interval string `json:"Interval"` // Go duration, e.g. "10s"
+		Timeout  string `json:"Timeout"`
+	} `json:"Definition"`
+}
+
+// Address is where the instance listens, falling back to the node address
+func (e ServiceEntry) Address() string {
+	host := e.Service.Address
+	if host == "" {
+		host = e.Node.Address
+	}
+	return host
+}
+
+// Services returns the catalog service names, only those with tag when it is set
+func (c *Client) Services(ctx context.Context, tag string) ([]string, error) {
+	var catalog map[string][]string
+	if err := c.get(ctx, "/v1/catalog/services", nil, &catalog); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(catalog))
+	for name, tags := range catalog {
+		if name == "consul" {
+			continue
+		}
+		if tag != "" && !contains(tags, tag) {
+			continue
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// Instances returns every instance of a service with its checks, only those with tag when it is set
+func (c *Client) Instances(ctx context.Context, service, tag string) ([]ServiceEntry, error) {
+	params := url.Values{}
+	if tag != "" {
+		params.Set("tag", tag)
+	}
+
+	var entries []ServiceEntry
+	if err := c.get(ctx, "/v1/health/service/"+url.PathEscape(service), params, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (c *Client) get(ctx context.Context, path string, params url.Values, out interface{}) error {
+	if params == nil {
+		params = url.Values{}
+	}
+	if c.datacenter != "" {
+		params.Set("dc", c.datacenter)
+	}
+
+	u := c.address + path
+	if len(params) > 0 {
+		u += "?" + params.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	if c.token != "" {
+		req.Header.Set("X-Consul-Token", c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("consul request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("consul returned %d: %s", resp.StatusCode, strings.TrimSpace(string(snippet)))
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func contains(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
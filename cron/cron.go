@@ -0,0 +1,226 @@
+// Package cron parses standard five-field cron expressions
+// (minute hour day-of-month month day-of-week) and finds their next match.
+package cron
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed expression; each field is a bit set of allowed values
+type Schedule struct {
+	minute, hour, dom, month, dow uint64
+
+	// With both day fields restricted a day matches either of them, as in
+	// Vixie cron; with one of them "*" only the other one counts
+	domAny, dowAny bool
+
+	// A restricted hour runs once in an hour repeated when clocks go back
+	hourAny bool
+}
+
+type field struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	minuteField = field{name: "minute", min: 0, max: 59}
+	hourField   = field{name: "hour", min: 0, max: 23}
+	domField    = field{name: "day of month", min: 1, max: 31}
+	monthField  = field{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// 7 is accepted for Sunday and folded onto 0
+	dowField = field{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Parse reads an expression such as "*/5 9-17 * * mon-fri" or a macro like "@hourly"
+func Parse(expr string) (*Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if m, ok := macros[strings.ToLower(expr)]; ok {
+		expr = m
+	}
+
+	parts := strings.Fields(expr)
+	if len(parts) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields: minute hour day-of-month month day-of-week", expr)
+	}
+
+	s := &Schedule{
+		domAny:  strings.HasPrefix(parts[2], "*"),
+		dowAny:  strings.HasPrefix(parts[4], "*"),
+		hourAny: strings.HasPrefix(parts[1], "*"),
+	}
+	var err error
+	if s.minute, err = minuteField.parse(parts[0]); err != nil {
+		return nil, err
+	}
+	if s.hour, err = hourField.parse(parts[1]); err != nil {
+		return nil, err
+	}
+	if s.dom, err = domField.parse(parts[2]); err != nil {
+		return nil, err
+	}
+	if s.month, err = monthField.parse(parts[3]); err != nil {
+		return nil, err
+	}
+	if s.dow, err = dowField.parse(parts[4]); err != nil {
+		return nil, err
+	}
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	return s, nil
+}
+
+// parse reads a comma separated list of *, values, ranges and /steps
+func (f field) parse(spec string) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(spec, ",") {
+		rng, step := item, 1
+		if i := strings.Index(item, "/"); i >= 0 {
+			n, err := strconv.Atoi(item[i+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("cron %s: invalid step in %q", f.name, item)
+			}
+			rng, step = item[:i], n
+		}
+
+		lo, hi := f.min, f.max
+		switch {
+		case rng == "*":
+		case strings.Contains(rng, "-"):
+			a, b, _ := strings.Cut(rng, "-")
+			var err error
+			if lo, err = f.value(a); err != nil {
+				return 0, err
+			}
+			if hi, err = f.value(b); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("cron %s: range %q runs backwards", f.name, rng)
+			}
+		default:
+			v, err := f.value(rng)
+			if err != nil {
+				return 0, err
+			}
+			lo = v
+			// "5/15" means every 15 starting at 5
+			if step == 1 {
+				hi = v
+			}
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << v
+		}
+	}
+	if bits == 0 {
+		return 0, fmt.Errorf("cron %s: %q matches nothing", f.name, spec)
+	}
+	return bits, nil
+}
+
+func (f field) value(s string) (int, error) {
+	if v, ok := f.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("cron %s: invalid value %q", f.name, s)
+	}
+	if v < f.min || v > f.max {
+		return 0, fmt.Errorf("cron %s: %d is outside %d-%d", f.name, v, f.min, f.max)
+	}
+	return v, nil
+}
+
+// ErrNoMatch is returned by Next for expressions that never match, like "0 0 31 2 *"
+var ErrNoMatch = errors.New("cron expression has no upcoming match")
+
+// searchYears bounds the search for a match; leap days recur within 8 years
+const searchYears = 8
+
+// Next returns the first matching minute strictly after t, in t's location
+func (s *Schedule) Next(t time.Time) (time.Time, error) {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Year() + searchYears
+
+	for t.Year() <= limit {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = date(t.Year(), t.Month()+1, 1, 0, loc)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = date(t.Year(), t.Month(), t.Day()+1, 0, loc)
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			next := date(t.Year(), t.Month(), t.Day(), t.Hour()+1, loc)
+			// An hour skipped when clocks go forward normalizes onto this one
+			if !next.After(t) {
+				next = t.Add(time.Duration(60-t.Minute()) * time.Minute)
+			}
+			t = next
+			continue
+		}
+		if _, again := earlier(t); s.minute&(1<<uint(t.Minute())) == 0 || (again && !s.hourAny) {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t, nil
+	}
+	return time.Time{}, ErrNoMatch
+}
+
+// date is the start of an hour like time.Date, which can pick either time
+// of an hour repeated when clocks go back; date always picks the first
+func date(year int, month time.Month, day, hour int, loc *time.Location) time.Time {
+	t := time.Date(year, month, day, hour, 0, 0, 0, loc)
+	if first, ok := earlier(t); ok {
+		return first
+	}
+	return t
+}
+
+// earlier returns the first time showing the wall clock of t, when clocks
+// went back since then
+func earlier(t time.Time) (time.Time, bool) {
+	_, before := t.Add(-3 * time.Hour).Zone()
+	_, offset := t.Zone()
+	if offset >= before {
+		return time.Time{}, false
+	}
+	first := t.Add(-time.Duration(before-offset) * time.Second)
+	return first, first.Format(time.DateTime) == t.Format(time.DateTime)
+}
+
+func (s *Schedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domAny || s.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
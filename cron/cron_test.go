@@ -0,0 +1,131 @@
+package cron
+
+import (
+	"errors"
+	"testing"
+	"time"
+	_ "time/tzdata" // the DST cases need zones on hosts without tzdata
+)
+
+func TestParseErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"* * * foo *",
+		"@every 5m",
+	}
+	for _, expr := range tests {
+		t.Run(expr, func(t *testing.T) {
+			if _, err := Parse(expr); err == nil {
+				t.Errorf("Parse(%q) succeeded, want an error", expr)
+			}
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	utc := func(year int, month time.Month, day, hour, min int) time.Time {
+		return time.Date(year, month, day, hour, min, 0, 0, time.UTC)
+	}
+	// at reads an unambiguous local time; zoned places one with its offset
+	at := func(loc *time.Location, year int, month time.Month, day, hour, min int) time.Time {
+		return time.Date(year, month, day, hour, min, 0, 0, loc)
+	}
+	zoned := func(loc *time.Location, value string) time.Time {
+		v, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v.In(loc)
+	}
+
+	tests := []struct {
+		name  string
+		expr  string
+		after time.Time
+		want  time.Time
+	}{
+		// Fields
+		{"every minute is strictly after", "* * * * *", utc(2026, 3, 2, 10, 0).Add(30 * time.Second), utc(2026, 3, 2, 10, 1)},
+		{"steps", "*/15 * * * *", utc(2026, 3, 2, 10, 1), utc(2026, 3, 2, 10, 15)},
+		{"step from a value", "5/20 * * * *", utc(2026, 3, 2, 10, 30), utc(2026, 3, 2, 10, 45)},
+		{"business hours roll over to the next weekday", "0 9-17 * * mon-fri", utc(2026, 3, 6, 17, 0), utc(2026, 3, 9, 9, 0)},
+		{"month names", "0 0 1 jun,dec *", utc(2026, 7, 1, 0, 0), utc(2026, 12, 1, 0, 0)},
+		{"7 is Sunday", "0 12 * * 7", utc(2026, 3, 2, 0, 0), utc(2026, 3, 8, 12, 0)},
+		{"macro", "@monthly", utc(2026, 3, 2, 0, 0), utc(2026, 4, 1, 0, 0)},
+
+		// Both day fields restricted match with OR, one of them * with AND
+		{"day of month or weekday: the Friday comes first", "0 0 13 * fri", utc(2026, 3, 2, 0, 0), utc(2026, 3, 6, 0, 0)},
+		{"day of month or weekday: the 13th comes first", "0 0 13 * fri", utc(2026, 3, 10, 0, 0), utc(2026, 3, 13, 0, 0)},
+		{"any day of month with a weekday", "0 0 * * fri", utc(2026, 3, 7, 0, 0), utc(2026, 3, 13, 0, 0)},
+		{"day of month with any weekday", "0 0 13 * *", utc(2026, 3, 14, 0, 0), utc(2026, 4, 13, 0, 0)},
+		{"a stepped day of month counts as any", "0 0 */10 * mon", utc(2026, 3, 1, 0, 0), utc(2026, 5, 11, 0, 0)},
+
+		// Leap days
+		{"next leap day", "0 0 29 2 *", utc(2026, 3, 1, 0, 0), utc(2028, 2, 29, 0, 0)},
+		{"leap day 8 years out, past 2100", "0 0 29 2 *", utc(2096, 3, 1, 0, 0), utc(2104, 2, 29, 0, 0)},
+
+		// Clocks going forward: 2:00 doesn't exist on 2026-03-08 in New York
+		// and 2026-03-29 in Berlin
+		{"new york: skipped time waits a day", "30 2 * * *", at(newYork, 2026, 3, 8, 0, 0), at(newYork, 2026, 3, 9, 2, 30)},
+		{"new york: hour after the skipped one", "0 3 * * *", at(newYork, 2026, 3, 8, 0, 30), zoned(newYork, "2026-03-08T03:00:00-04:00")},
+		{"new york: steps continue across the jump", "*/20 * * * *", at(newYork, 2026, 3, 8, 1, 45), zoned(newYork, "2026-03-08T03:00:00-04:00")},
+		{"berlin: skipped time waits a day", "30 2 * * *", at(berlin, 2026, 3, 29, 0, 0), at(berlin, 2026, 3, 30, 2, 30)},
+		{"berlin: hour after the skipped one", "0 3 * * *", at(berlin, 2026, 3, 29, 1, 30), zoned(berlin, "2026-03-29T03:00:00+02:00")},
+
+		// Clocks going back: 1:00-2:00 repeats on 2026-11-01 in New York,
+		// 2:00-3:00 on 2026-10-25 in Berlin
+		{"new york: repeated time runs in the first hour", "30 1 * * *", at(newYork, 2026, 11, 1, 0, 0), zoned(newYork, "2026-11-01T01:30:00-04:00")},
+		{"new york: repeated time runs once", "30 1 * * *", zoned(newYork, "2026-11-01T01:30:00-04:00"), at(newYork, 2026, 11, 2, 1, 30)},
+		{"new york: any hour runs in both", "30 * * * *", zoned(newYork, "2026-11-01T01:30:00-04:00"), zoned(newYork, "2026-11-01T01:30:00-05:00")},
+		{"berlin: repeated time runs in the first hour", "30 2 * * *", at(berlin, 2026, 10, 25, 0, 0), zoned(berlin, "2026-10-25T02:30:00+02:00")},
+		{"berlin: repeated time runs once", "30 2 * * *", zoned(berlin, "2026-10-25T02:30:00+02:00"), at(berlin, 2026, 10, 26, 2, 30)},
+		{"berlin: any hour runs in both", "30 * * * *", zoned(berlin, "2026-10-25T02:30:00+02:00"), zoned(berlin, "2026-10-25T02:30:00+01:00")},
+		{"berlin: hour after the repeated one", "0 3 * * *", zoned(berlin, "2026-10-25T02:10:00+02:00"), zoned(berlin, "2026-10-25T03:00:00+01:00")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := s.Next(tt.after)
+			if err != nil {
+				t.Fatalf("Next(%s) failed: %v", tt.after, err)
+			}
+			if !got.Equal(tt.want) || got.Location() != tt.after.Location() {
+				t.Errorf("Next(%s) = %s, want %s", tt.after, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextNoMatch(t *testing.T) {
+	for _, expr := range []string{"0 0 31 2 *", "0 0 30 2 *", "0 0 31 4,6,9,11 *"} {
+		t.Run(expr, func(t *testing.T) {
+			s, err := Parse(expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := s.Next(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)); !errors.Is(err, ErrNoMatch) {
+				t.Errorf("Next() error = %v, want ErrNoMatch", err)
+			}
+		})
+	}
+}
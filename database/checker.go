@@ -0,0 +1,38 @@
+package database
+
+import (
+	"Distributed-Health-Monitoring/checker"
+	"Distributed-Health-Monitoring/models"
+	"context"
+	"time"
+)
+
+func init() {
+	checker.Register(models.ProtocolDatabase, checker.Func(check))
+}
+
+// check connects with the service's DSN and pings it, timing the connect
+// and the ping and query separately
+func check(ctx context.Context, service *models.ExternalService) models.CheckResult {
+	result := models.CheckResult{Status: "DOWN"}
+
+	res := CheckDatabase(service.DatabaseDSN, service.DatabaseQuery, time.Duration(service.TimeoutSeconds)*time.Second)
+	connectMs, queryMs := res.Connect.Milliseconds(), res.Query.Milliseconds()
+	totalMs := (res.Connect + res.Query).Milliseconds()
+	result.LatencyMs = totalMs
+	result.Timings = models.CheckTimings{ConnectMs: &connectMs, TotalMs: &totalMs}
+	if res.Stage != StageConnect {
+		result.Timings.QueryMs = &queryMs
+	}
+	if res.Error != nil {
+		result.ErrorMessage = res.Error.Error()
+		result.Reason = "unreachable"
+		if res.Stage == StageQuery {
+			result.Reason = "query_failed"
+		}
+		return result
+	}
+	result.Status = "UP"
+	result.Success = true
+	return result
+}
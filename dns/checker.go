@@ -0,0 +1,38 @@
+package dns
+
+import (
+	"Distributed-Health-Monitoring/checker"
+	"Distributed-Health-Monitoring/models"
+	"context"
+	"time"
+)
+
+func init() {
+	checker.Register(models.ProtocolDNS, checker.Func(check))
+}
+
+// check resolves the service's hostname; records that miss an expected
+// value fail the assertion rather than the lookup
+func check(ctx context.Context, service *models.ExternalService) models.CheckResult {
+	result := models.CheckResult{Status: "DOWN"}
+
+	res := CheckDNS(
+		service.URL,
+		service.DNSResolver,
+		service.DNSRecordType,
+		service.DNSExpected,
+		time.Duration(service.TimeoutSeconds)*time.Second,
+	)
+	result.LatencyMs = res.Latency.Milliseconds()
+	if res.Error != nil {
+		result.ErrorMessage = res.Error.Error()
+		result.Reason = "unreachable"
+		if len(res.Records) > 0 {
+			result.Reason = "assertion_failed"
+		}
+		return result
+	}
+	result.Status = "UP"
+	result.Success = true
+	return result
+}
@@ -0,0 +1,121 @@
+package dns
+
+import (
+	"Distributed-Health-Monitoring/models"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// RecordTypes are the DNS record types a DNS check can resolve
+var RecordTypes = map[string]bool{"A": true, "AAAA": true, "CNAME": true, "TXT": true}
+
+// CheckDNS resolves host against resolver (host:port, empty for the system
+// resolver) and verifies every expected value is among the returned records
+func CheckDNS(host, resolver, recordType string, expected []string, timeout time.Duration) models.DNSCheckResult {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	r := net.DefaultResolver
+	if resolver != "" {
+		if _, _, err := net.SplitHostPort(resolver); err != nil {
+			resolver = net.JoinHostPort(resolver, "53")
+		}
+		r = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				d := net.Dialer{}
+				return d.DialContext(ctx, network, resolver)
+			},
+		}
+	}
+
+	startTime := time.Now()
+	records, err := lookup(ctx, r, host, strings.ToUpper(recordType))
+	latency := time.Since(startTime)
+
+	if err != nil {
+		return models.DNSCheckResult{
+			IsHealthy: false,
+			Latency:   latency,
+			Error:     fmt.Errorf("resolution failed: %w", err),
+		}
+	}
+
+	if len(records) == 0 {
+		return models.DNSCheckResult{
+			IsHealthy: false,
+			Latency:   latency,
+			Error:     fmt.Errorf("no %s records for %s", recordType, host),
+		}
+	}
+
+	if missing := missingValues(records, expected); len(missing) > 0 {
+		return models.DNSCheckResult{
+			IsHealthy: false,
+			Latency:   latency,
+			Records:   records,
+			Error:     fmt.Errorf("expected %s records %v not found, got %v", recordType, missing, records),
+		}
+	}
+
+	return models.DNSCheckResult{
+		IsHealthy: true,
+		Latency:   latency,
+		Records:   records,
+		Error:     nil,
+	}
+}
+
+func lookup(ctx context.Context, r *net.Resolver, host, recordType string) ([]string, error) {
+	switch recordType {
+	case "A", "AAAA", "":
+		addrs, err := r.LookupIPAddr(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		var records []string
+		for _, a := range addrs {
+			isV4 := a.IP.To4() != nil
+			if (recordType == "AAAA") == !isV4 {
+				records = append(records, a.IP.String())
+			}
+		}
+		return records, nil
+
+	case "CNAME":
+		cname, err := r.LookupCNAME(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		return []string{cname}, nil
+
+	case "TXT":
+		return r.LookupTXT(ctx, host)
+	}
+
+	return nil, fmt.Errorf("unsupported record type %q", recordType)
+}
+
+// missingValues returns the expected values absent from records, comparing
+// case-insensitively and ignoring the trailing dot of fully-qualified names
+func missingValues(records, expected []string) []string {
+	have := make(map[string]bool, len(records))
+	for _, r := range records {
+		have[normalize(r)] = true
+	}
+
+	var missing []string
+	for _, e := range expected {
+		if !have[normalize(e)] {
+			missing = append(missing, e)
+		}
+	}
+	return missing
+}
+
+func normalize(v string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(v)), ".")
+}
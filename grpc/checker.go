@@ -0,0 +1,37 @@
+package grpc
+
+import (
+	"Distributed-Health-Monitoring/checker"
+	"Distributed-Health-Monitoring/logging"
+	"Distributed-Health-Monitoring/models"
+	"context"
+	"time"
+)
+
+func init() {
+	checker.Register(models.ProtocolGRPC, checker.Func(check))
+}
+
+// check asks the service's server for its health through grpc.health.v1;
+// a server that answers with anything but SERVING is not_serving, and one
+// without the health service only fails when the service requires it
+func check(ctx context.Context, service *models.ExternalService) models.CheckResult {
+	result := models.CheckResult{Status: "DOWN"}
+
+	res := Check_gRPC(service.URL, service.GRPCServiceName, service.GRPCWatch, service.GRPCRequireHealth, service.GRPCTLS, time.Duration(service.TimeoutSeconds)*time.Second)
+	result.LatencyMs = res.Latency.Abs().Milliseconds()
+	result.StatusCode = int(res.StatusCode)
+	if res.Error != nil {
+		logging.For(ctx, "worker").Warn("service_not_healthy", "service", service.Name, "err", res.Error)
+		result.ErrorMessage = res.Error.Error()
+		result.Reason = "unreachable"
+		if res.ServingStatus != "" {
+			result.Reason = "not_serving"
+		}
+	}
+	if res.IsHealthy {
+		result.Status = "UP"
+		result.Success = true
+	}
+	return result
+}
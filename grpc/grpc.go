@@ -3,45 +3,104 @@ package grpc
 import (
 	"Distributed-Health-Monitoring/models"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"time"
 
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
 )
 
-// CheckGRPCWithLatency returns health status and connection latency
-func Check_gRPC(address string, timeout time.Duration) models.GRPCHealthResult {
+// Check_gRPC asks the server at address for the health of serviceName
+// (empty for the server as a whole) through grpc.health.v1.Health. Only
+// SERVING is healthy. With watch the first status of a Watch stream is used
+// instead of Check. A server without the health service answered the call,
+// so it is healthy unless requireHealth is set. The timeout covers
+// connecting and the call.
+func Check_gRPC(address, serviceName string, watch, requireHealth bool, tlsCfg *models.GRPCTLS, timeout time.Duration) models.GRPCHealthResult {
 	startTime := time.Now()
-	
+
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
-	conn, err := grpc.DialContext(ctx, address,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-		grpc.WithBlock(),
-	)
-	
+	creds, err := transportCredentials(tlsCfg)
+	if err != nil {
+		return models.GRPCHealthResult{Latency: time.Since(startTime), StatusCode: codes.InvalidArgument, Error: err}
+	}
+	conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return models.GRPCHealthResult{Latency: time.Since(startTime), StatusCode: codes.InvalidArgument, Error: fmt.Errorf("invalid address: %w", err)}
+	}
+	defer conn.Close()
+
+	client := healthpb.NewHealthClient(conn)
+	req := &healthpb.HealthCheckRequest{Service: serviceName}
+	var resp *healthpb.HealthCheckResponse
+	if watch {
+		var stream healthpb.Health_WatchClient
+		if stream, err = client.Watch(ctx, req); err == nil {
+			resp, err = stream.Recv()
+		}
+	} else {
+		resp, err = client.Check(ctx, req)
+	}
 	latency := time.Since(startTime)
-	
+
 	if err != nil {
-		return models.GRPCHealthResult{
-			IsHealthy:  false,
-			Latency:    latency,
-			StatusCode: connectivity.TransientFailure,
-			Error:      fmt.Errorf("connection failed: %w", err),
+		code := status.Code(err)
+		switch code {
+		case codes.Unimplemented:
+			if !requireHealth {
+				return models.GRPCHealthResult{IsHealthy: true, Latency: latency, StatusCode: code}
+			}
+			err = errors.New("server does not implement grpc.health.v1.Health")
+		case codes.NotFound:
+			// Check's answer for a service name the server doesn't know
+			return models.GRPCHealthResult{
+				Latency:       latency,
+				StatusCode:    code,
+				ServingStatus: healthpb.HealthCheckResponse_SERVICE_UNKNOWN.String(),
+				Error:         fmt.Errorf("service %q is unknown to the server", serviceName),
+			}
+		default:
+			err = fmt.Errorf("health check failed: %w", err)
 		}
+		return models.GRPCHealthResult{Latency: latency, StatusCode: code, Error: err}
 	}
-	defer conn.Close()
 
-	state := conn.GetState()
-	isHealthy := state == connectivity.Ready
+	result := models.GRPCHealthResult{
+		IsHealthy:     resp.GetStatus() == healthpb.HealthCheckResponse_SERVING,
+		Latency:       latency,
+		StatusCode:    codes.OK,
+		ServingStatus: resp.GetStatus().String(),
+	}
+	if !result.IsHealthy {
+		result.Error = fmt.Errorf("health status is %s", result.ServingStatus)
+	}
+	return result
+}
 
-	return models.GRPCHealthResult{
-		IsHealthy:  isHealthy,
-		Latency:    latency,
-		StatusCode: state,
-		Error:      nil,
+func transportCredentials(cfg *models.GRPCTLS) (credentials.TransportCredentials, error) {
+	if cfg == nil {
+		return insecure.NewCredentials(), nil
+	}
+	tlsConfig := &tls.Config{
+		ServerName:         cfg.ServerName,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+		MinVersion:         tls.VersionTLS12,
+	}
+	if cfg.CACert != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(cfg.CACert)) {
+			return nil, errors.New("grpc tls ca_cert holds no PEM certificate")
+		}
+		tlsConfig.RootCAs = pool
 	}
-}
\ No newline at end of file
+	return credentials.NewTLS(tlsConfig), nil
+}
@@ -0,0 +1,159 @@
+package httpcheck
+
+import (
+	"Distributed-Health-Monitoring/models"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// oauthRefreshSkew renews a token this long before it expires, so a
+	// check never starts with a token that runs out mid-request
+	oauthRefreshSkew = 30 * time.Second
+	// oauthDefaultLifetime is used when the token endpoint sends no expires_in
+	oauthDefaultLifetime  = 5 * time.Minute
+	maxTokenResponseBytes = 64 << 10
+)
+
+// oauthTokens caches client-credentials tokens for the worker
+var oauthTokens = &oauthTokenCache{tokens: make(map[string]oauthToken)}
+
+type oauthToken struct {
+	value     string
+	expiresAt time.Time
+}
+
+// oauthTokenCache is keyed by a hash of the credentials, so services sharing
+// a client share its token and an edited definition fetches a new one
+type oauthTokenCache struct {
+	mu     sync.Mutex
+	tokens map[string]oauthToken
+}
+
+func oauthKey(auth *models.CheckAuth) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		auth.TokenURL, auth.ClientID, auth.ClientSecret, strings.Join(auth.Scopes, " "),
+	}, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// get returns a cached token or fetches a new one through client
+func (c *oauthTokenCache) get(ctx context.Context, auth *models.CheckAuth, client *http.Client) (string, error) {
+	key := oauthKey(auth)
+	now := time.Now()
+
+	c.mu.Lock()
+	token, ok := c.tokens[key]
+	c.mu.Unlock()
+	if ok && now.Before(token.expiresAt) {
+		return token.value, nil
+	}
+
+	token, err := fetchOAuthToken(ctx, auth, client)
+	if err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	for k, t := range c.tokens {
+		if now.After(t.expiresAt) {
+			delete(c.tokens, k)
+		}
+	}
+	c.tokens[key] = token
+	c.mu.Unlock()
+	return token.value, nil
+}
+
+// invalidate drops the token after the target rejected it
+func (c *oauthTokenCache) invalidate(auth *models.CheckAuth) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.tokens, oauthKey(auth))
+}
+
+// fetchOAuthToken runs the client credentials grant (RFC 6749 section 4.4),
+// authenticating the client with HTTP Basic
+func fetchOAuthToken(ctx context.Context, auth *models.CheckAuth, client *http.Client) (oauthToken, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(auth.Scopes) > 0 {
+		form.Set("scope", strings.Join(auth.Scopes, " "))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, auth.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return oauthToken{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(auth.ClientID), url.QueryEscape(auth.ClientSecret))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return oauthToken{}, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxTokenResponseBytes))
+	if err != nil {
+		return oauthToken{}, fmt.Errorf("failed to read token response: %w", err)
+	}
+
+	var parsed struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+		Error       string `json:"error"`
+	}
+	decodeErr := json.Unmarshal(body, &parsed)
+
+	if resp.StatusCode != http.StatusOK {
+		if parsed.Error != "" {
+			return oauthToken{}, fmt.Errorf("token endpoint returned %d: %s", resp.StatusCode, parsed.Error)
+		}
+		return oauthToken{}, fmt.Errorf("token endpoint returned %d", resp.StatusCode)
+	}
+	if decodeErr != nil {
+		return oauthToken{}, fmt.Errorf("invalid token response: %w", decodeErr)
+	}
+	if parsed.AccessToken == "" {
+		return oauthToken{}, fmt.Errorf("token response has no access_token")
+	}
+
+	lifetime := oauthDefaultLifetime
+	if parsed.ExpiresIn > 0 {
+		lifetime = time.Duration(parsed.ExpiresIn) * time.Second
+	}
+	if lifetime > 2*oauthRefreshSkew {
+		lifetime -= oauthRefreshSkew
+	}
+	return oauthToken{value: parsed.AccessToken, expiresAt: time.Now().Add(lifetime)}, nil
+}
+
+// applyCheckAuth adds the service's credentials to a check request
+func applyCheckAuth(ctx context.Context, req *http.Request, auth *models.CheckAuth, client *http.Client) error {
+	if auth == nil {
+		return nil
+	}
+
+	switch auth.Type {
+	case models.AuthBasic:
+		req.SetBasicAuth(auth.Username, auth.Password)
+	case models.AuthBearer:
+		req.Header.Set("Authorization", "Bearer "+auth.Token)
+	case models.AuthOAuth2:
+		token, err := oauthTokens.get(ctx, auth, client)
+		if err != nil {
+			return fmt.Errorf("failed to get oauth2 token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return nil
+}
@@ -0,0 +1,310 @@
+package httpcheck
+
+import (
+	"Distributed-Health-Monitoring/config"
+	"Distributed-Health-Monitoring/models"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMaxIdleConns        = 256
+	defaultMaxIdleConnsPerHost = 4
+	defaultIdleConnTimeout     = 90 * time.Second
+
+	// httpClientIdle is how long a pooled client may go unused before it is
+	// dropped, e.g. after its service was edited or deleted
+	httpClientIdle = 10 * time.Minute
+)
+
+// errTooManyRedirects fails a check whose redirects exceed its max_redirects
+var errTooManyRedirects = errors.New("too many redirects")
+
+// clients is the worker's client pool; Setup rebuilds it from config
+var clients = newHTTPClientPool(config.WorkerHTTP{})
+
+// Setup sizes the client pool from worker.http. Call it once at startup,
+// before any check runs.
+func Setup(cfg config.WorkerHTTP) {
+	clients = newHTTPClientPool(cfg)
+}
+
+// httpClientPool hands out one client per distinct connection setup, so
+// checks of the same target reuse keep-alive connections instead of paying
+// for a TCP and TLS handshake every time
+type httpClientPool struct {
+	cfg config.WorkerHTTP
+
+	mu        sync.Mutex
+	clients   map[string]*pooledClient
+	lastSweep time.Time
+}
+
+type pooledClient struct {
+	client   *http.Client
+	lastUsed time.Time
+}
+
+func newHTTPClientPool(cfg config.WorkerHTTP) *httpClientPool {
+	if cfg.MaxIdleConns <= 0 {
+		cfg.MaxIdleConns = defaultMaxIdleConns
+	}
+	if cfg.MaxIdleConnsPerHost <= 0 {
+		cfg.MaxIdleConnsPerHost = defaultMaxIdleConnsPerHost
+	}
+	if cfg.IdleConnTimeoutSeconds <= 0 {
+		cfg.IdleConnTimeoutSeconds = int(defaultIdleConnTimeout / time.Second)
+	}
+	return &httpClientPool{cfg: cfg, clients: make(map[string]*pooledClient)}
+}
+
+// httpClientKey covers everything the transport is built from. It is hashed
+// because proxy URLs may carry credentials.
+func httpClientKey(service *models.ExternalService, timeout time.Duration) string {
+	hosts := make([]string, 0, len(service.ResolveOverride))
+	for host, ip := range service.ResolveOverride {
+		hosts = append(hosts, host+"="+ip)
+	}
+	sort.Strings(hosts)
+
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		timeout.String(), service.ProxyURL, service.SourceInterface, strings.Join(hosts, ","),
+	}, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// get returns the pooled client for the service, building it on first use
+func (p *httpClientPool) get(service *models.ExternalService, timeout time.Duration) (*http.Client, error) {
+	key := httpClientKey(service, timeout)
+	now := time.Now()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if now.Sub(p.lastSweep) > time.Minute {
+		p.sweep(now)
+	}
+	if pooled, ok := p.clients[key]; ok {
+		pooled.lastUsed = now
+		return pooled.client, nil
+	}
+
+	client, err := newHTTPClient(p.cfg, service, timeout)
+	if err != nil {
+		return nil, err
+	}
+	p.clients[key] = &pooledClient{client: client, lastUsed: now}
+	return client, nil
+}
+
+// withRedirectPolicy applies the redirect settings of a service to a copy of
+// the pooled client. The copy shares the transport, so its connections too.
+func withRedirectPolicy(client *http.Client, criteria *models.SuccessCriteria) *http.Client {
+	if criteria == nil || (criteria.FollowRedirects == nil && criteria.MaxRedirects == 0) {
+		return client
+	}
+	follow, hops := criteria.Redirects()
+	c := *client
+	c.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if !follow {
+			return http.ErrUseLastResponse
+		}
+		if len(via) > hops {
+			return fmt.Errorf("%w: stopped after %d", errTooManyRedirects, hops)
+		}
+		return nil
+	}
+	return &c
+}
+
+// sweep closes the clients nobody used lately; p.mu must be held
+func (p *httpClientPool) sweep(now time.Time) {
+	p.lastSweep = now
+	for key, pooled := range p.clients {
+		if now.Sub(pooled.lastUsed) > httpClientIdle {
+			pooled.client.CloseIdleConnections()
+			delete(p.clients, key)
+		}
+	}
+}
+
+// newHTTPClient builds the client for one connection setup: the proxy,
+// resolve override and source interface of the service
+func newHTTPClient(cfg config.WorkerHTTP, service *models.ExternalService, timeout time.Duration) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = cfg.MaxIdleConns
+	transport.MaxIdleConnsPerHost = cfg.MaxIdleConnsPerHost
+	transport.IdleConnTimeout = time.Duration(cfg.IdleConnTimeoutSeconds) * time.Second
+	transport.DisableCompression = cfg.DisableCompression
+	transport.DisableKeepAlives = cfg.DisableKeepAlives
+
+	client := &http.Client{Timeout: timeout, Transport: transport}
+
+	if service.ProxyURL != "" {
+		proxy, err := url.Parse(service.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy url: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	if len(service.ResolveOverride) == 0 && service.SourceInterface == "" {
+		return client, nil
+	}
+
+	dialer := &net.Dialer{Timeout: timeout, KeepAlive: 30 * time.Second}
+	if service.SourceInterface != "" {
+		ip, err := sourceAddr(service.SourceInterface)
+		if err != nil {
+			return nil, err
+		}
+		dialer.LocalAddr = &net.TCPAddr{IP: ip}
+	}
+
+	// Only the dialed address changes; the Host header and TLS server name
+	// still come from the URL, so certificates for the public name verify
+	overrides := service.ResolveOverride
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if host, port, err := net.SplitHostPort(addr); err == nil {
+			if ip, ok := overrides[host]; ok {
+				addr = net.JoinHostPort(ip, port)
+			}
+		}
+		return dialer.DialContext(ctx, network, addr)
+	}
+
+	return client, nil
+}
+
+// checkTrace times the phases of one request with httptrace. The hooks can
+// run on the transport's dialing goroutines, so fields are guarded by mu.
+type checkTrace struct {
+	mu                     sync.Mutex
+	dns, connect, tls      tracePhase
+	gotConnAt, firstByteAt time.Time
+}
+
+// tracePhase adds up the time spent in one phase; redirects may repeat it
+type tracePhase struct {
+	start time.Time
+	total time.Duration
+	seen  bool
+}
+
+func (p *tracePhase) begin() { p.start = time.Now() }
+
+func (p *tracePhase) end() {
+	if p.start.IsZero() {
+		return
+	}
+	p.total += time.Since(p.start)
+	p.seen = true
+	p.start = time.Time{}
+}
+
+func (p *tracePhase) ms() *int64 {
+	if !p.seen {
+		return nil
+	}
+	ms := p.total.Milliseconds()
+	return &ms
+}
+
+func (t *checkTrace) context(ctx context.Context) context.Context {
+	locked := func(f func()) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		f()
+	}
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GetConn: func(string) {
+			locked(func() {
+				if t.gotConnAt.IsZero() {
+					t.gotConnAt = time.Now()
+				}
+			})
+		},
+		DNSStart:          func(httptrace.DNSStartInfo) { locked(t.dns.begin) },
+		DNSDone:           func(httptrace.DNSDoneInfo) { locked(t.dns.end) },
+		ConnectStart:      func(string, string) { locked(t.connect.begin) },
+		ConnectDone:       func(string, string, error) { locked(t.connect.end) },
+		TLSHandshakeStart: func() { locked(t.tls.begin) },
+		TLSHandshakeDone:  func(tls.ConnectionState, error) { locked(t.tls.end) },
+		GotFirstResponseByte: func() {
+			locked(func() { t.firstByteAt = time.Now() })
+		},
+	})
+}
+
+// dnsTime is the time spent resolving names, zero on a reused connection
+func (t *checkTrace) dnsTime() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.dns.total
+}
+
+// timings reports the phases seen so far, with total as the whole request
+func (t *checkTrace) timings(total time.Duration) models.CheckTimings {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	totalMs := total.Milliseconds()
+	timings := models.CheckTimings{
+		DNSMs:     t.dns.ms(),
+		ConnectMs: t.connect.ms(),
+		TLSMs:     t.tls.ms(),
+		TotalMs:   &totalMs,
+	}
+	if !t.gotConnAt.IsZero() && !t.firstByteAt.IsZero() {
+		ttfb := t.firstByteAt.Sub(t.gotConnAt).Milliseconds()
+		timings.TTFBMs = &ttfb
+	}
+	return timings
+}
+
+// sourceAddr resolves a source_interface value, either a local IP or the
+// name of an interface whose first address is used (IPv4 preferred)
+func sourceAddr(source string) (net.IP, error) {
+	if ip := net.ParseIP(source); ip != nil {
+		return ip, nil
+	}
+
+	iface, err := net.InterfaceByName(source)
+	if err != nil {
+		return nil, fmt.Errorf("source interface %q: %w", source, err)
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, fmt.Errorf("source interface %q: %w", source, err)
+	}
+
+	var fallback net.IP
+	for _, a := range addrs {
+		ipNet, ok := a.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		if ipNet.IP.To4() != nil {
+			return ipNet.IP, nil
+		}
+		if fallback == nil {
+			fallback = ipNet.IP
+		}
+	}
+	if fallback == nil {
+		return nil, fmt.Errorf("source interface %q has no addresses", source)
+	}
+	return fallback, nil
+}
@@ -0,0 +1,118 @@
+// Package httpcheck checks HTTP services: the request goes through a pooled
+// client per connection setup, with the service's credentials, and the
+// response is graded by its success criteria and assertions
+package httpcheck
+
+import (
+	"Distributed-Health-Monitoring/assertions"
+	"Distributed-Health-Monitoring/checker"
+	"Distributed-Health-Monitoring/models"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// maxResponseBodyBytes caps how much of a response body is read for assertions
+const maxResponseBodyBytes = 1 << 20
+
+func init() {
+	checker.Register(models.ProtocolHTTP, checker.Func(check))
+}
+
+func check(ctx context.Context, service *models.ExternalService) models.CheckResult {
+	result := models.CheckResult{Status: "DOWN"}
+
+	var trace checkTrace
+	req, err := http.NewRequestWithContext(
+		trace.context(ctx),
+		service.HTTPMethod,
+		service.URL,
+		nil,
+	)
+	if err != nil {
+		result.ErrorMessage = fmt.Sprintf("invalid request: %v", err)
+		result.Reason = "unreachable"
+		return result
+	}
+
+	// A missing source interface on this worker fails the check rather than the job
+	client, err := clients.get(service, time.Duration(service.TimeoutSeconds)*time.Second)
+	if err != nil {
+		result.ErrorMessage = err.Error()
+		result.Reason = "unreachable"
+		return result
+	}
+
+	if err := applyCheckAuth(ctx, req, service.Auth, client); err != nil {
+		result.ErrorMessage = err.Error()
+		result.Reason = "auth"
+		return result
+	}
+
+	start := time.Now()
+	resp, err := withRedirectPolicy(client, service.SuccessCriteria).Do(req)
+	elapsed := time.Since(start)
+	if clients.cfg.ExcludeDNSFromLatency {
+		elapsed -= trace.dnsTime()
+	}
+	result.LatencyMs = elapsed.Milliseconds()
+
+	if err != nil {
+		result.Timings = trace.timings(time.Since(start))
+		result.ErrorMessage = err.Error()
+		result.Reason = "unreachable"
+		if errors.Is(err, errTooManyRedirects) {
+			result.Reason = "http_status"
+		}
+		result.Response = &models.LastResponse{
+			ExternalServiceID: service.ID,
+			Error:             err.Error(),
+			LatencyMs:         result.LatencyMs,
+			CheckedAt:         time.Now(),
+		}
+		return result
+	}
+
+	// A rejected token may have been revoked early; the next attempt fetches a fresh one
+	if resp.StatusCode == http.StatusUnauthorized && service.Auth != nil && service.Auth.Type == models.AuthOAuth2 {
+		oauthTokens.invalidate(service.Auth)
+	}
+
+	body, readErr := io.ReadAll(io.LimitReader(resp.Body, maxResponseBodyBytes))
+	resp.Body.Close()
+	result.Timings = trace.timings(time.Since(start))
+	result.StatusCode = resp.StatusCode
+	if resp.TLS != nil && len(resp.TLS.PeerCertificates) > 0 {
+		expires := resp.TLS.PeerCertificates[0].NotAfter
+		result.CertExpiresAt = &expires
+	}
+	result.Response = newLastResponse(service.ID, resp, body, result.LatencyMs)
+
+	switch {
+	case !service.SuccessCriteria.Succeeds(resp.StatusCode):
+		result.Reason = "http_status"
+		result.ErrorMessage = fmt.Sprintf("unexpected status code %d", resp.StatusCode)
+	case readErr != nil && len(service.Assertions) > 0:
+		result.Reason = "unreachable"
+		result.ErrorMessage = fmt.Sprintf("failed to read response body: %v", readErr)
+	default:
+		result.AssertionFailure = assertions.Evaluate(body, service.Assertions)
+		if result.AssertionFailure != nil {
+			result.Reason = "assertion_failed"
+			result.ErrorMessage = fmt.Sprintf(
+				"assertion %s failed: expected=%q actual=%q",
+				result.AssertionFailure.Assertion.Type,
+				result.AssertionFailure.Expected,
+				result.AssertionFailure.Actual,
+			)
+		} else {
+			result.Status = "UP"
+			result.Success = true
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,67 @@
+package httpcheck
+
+import (
+	"Distributed-Health-Monitoring/models"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	lastResponseBodyBytes   = 4096
+	lastResponseMaxHeaders  = 50
+	lastResponseHeaderBytes = 512
+)
+
+// redactedHeaders may carry credentials or session state and are never stored
+var redactedHeaders = map[string]bool{
+	"Set-Cookie":          true,
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Www-Authenticate":    true,
+}
+
+// newLastResponse keeps the status line, a bounded set of headers and the
+// start of the body
+func newLastResponse(serviceID uint, resp *http.Response, body []byte, latencyMs int64) *models.LastResponse {
+	last := &models.LastResponse{
+		ExternalServiceID: serviceID,
+		StatusLine:        resp.Proto + " " + resp.Status,
+		Headers:           make(map[string][]string),
+		LatencyMs:         latencyMs,
+		CheckedAt:         time.Now(),
+	}
+
+	names := make([]string, 0, len(resp.Header))
+	for name := range resp.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if len(last.Headers) == lastResponseMaxHeaders {
+			break
+		}
+		if redactedHeaders[name] {
+			last.Headers[name] = []string{"[redacted]"}
+			continue
+		}
+		values := make([]string, 0, len(resp.Header[name]))
+		for _, v := range resp.Header[name] {
+			if len(v) > lastResponseHeaderBytes {
+				v = v[:lastResponseHeaderBytes]
+			}
+			values = append(values, v)
+		}
+		last.Headers[name] = values
+	}
+
+	if len(body) > lastResponseBodyBytes {
+		body = body[:lastResponseBodyBytes]
+		last.BodyTruncated = true
+	}
+	last.BodySnippet = strings.ToValidUTF8(string(body), "\uFFFD")
+
+	return last
+}
@@ -0,0 +1,101 @@
+// Package logging configures the process-wide structured logger and carries
+// request and correlation ids through contexts, so one check can be followed
+// from the API request or scheduler tick that caused it to the worker that ran it.
+package logging
+
+import (
+	"Distributed-Health-Monitoring/config"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+type ctxKey int
+
+const (
+	requestIDKey ctxKey = iota
+	correlationIDKey
+)
+
+// Setup installs the configured logger as the slog default. The standard
+// log package writes through it as well.
+func Setup(cfg config.Logging) error {
+	logger, err := New(cfg, os.Stderr)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+	return nil
+}
+
+// New builds a logger for level debug, info (default), warn or error, and
+// format text (default) or json
+func New(cfg config.Logging, w io.Writer) (*slog.Logger, error) {
+	var level slog.Level
+	switch strings.ToLower(cfg.Level) {
+	case "", "info":
+		level = slog.LevelInfo
+	case "debug":
+		level = slog.LevelDebug
+	case "warn", "warning":
+		level = slog.LevelWarn
+	case "error":
+		level = slog.LevelError
+	default:
+		return nil, fmt.Errorf("logging: invalid level %q", cfg.Level)
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	switch strings.ToLower(cfg.Format) {
+	case "", "text":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	}
+	return nil, fmt.Errorf("logging: invalid format %q", cfg.Format)
+}
+
+// For returns the default logger tagged with the component and any ids
+// carried by the context
+func For(ctx context.Context, component string) *slog.Logger {
+	logger := slog.Default().With("component", component)
+	if id := RequestID(ctx); id != "" {
+		logger = logger.With("request_id", id)
+	}
+	if id := CorrelationID(ctx); id != "" {
+		logger = logger.With("correlation_id", id)
+	}
+	return logger
+}
+
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIDKey, id)
+}
+
+func CorrelationID(ctx context.Context) string {
+	id, _ := ctx.Value(correlationIDKey).(string)
+	return id
+}
+
+// NewID returns a random 16-character hex id
+func NewID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "0000000000000000"
+	}
+	return hex.EncodeToString(b)
+}
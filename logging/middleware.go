@@ -0,0 +1,63 @@
+package logging
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	RequestIDHeader     = "X-Request-ID"
+	CorrelationIDHeader = "X-Correlation-ID"
+)
+
+// validID keeps client-supplied ids short and log-safe
+var validID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// ValidID reports whether a client-supplied request or correlation id is kept
+func ValidID(id string) bool {
+	return validID.MatchString(id)
+}
+
+// Middleware assigns every request a request id, keeps the caller's
+// correlation id (or starts one from the request id), echoes both as
+// response headers and logs the request when it completes
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validID.MatchString(requestID) {
+			requestID = NewID()
+		}
+		correlationID := c.GetHeader(CorrelationIDHeader)
+		if !validID.MatchString(correlationID) {
+			correlationID = requestID
+		}
+
+		ctx := WithCorrelationID(WithRequestID(c.Request.Context(), requestID), correlationID)
+		c.Request = c.Request.WithContext(ctx)
+		c.Header(RequestIDHeader, requestID)
+		c.Header(CorrelationIDHeader, correlationID)
+
+		c.Next()
+
+		logger := For(ctx, "http")
+		attrs := []any{
+			"method", c.Request.Method,
+			"path", c.FullPath(),
+			"status", c.Writer.Status(),
+			"latency_ms", time.Since(start).Milliseconds(),
+			"client_ip", c.ClientIP(),
+		}
+		switch {
+		case c.Writer.Status() >= 500:
+			logger.Error("request_completed", attrs...)
+		case c.Writer.Status() >= 400:
+			logger.Warn("request_completed", attrs...)
+		default:
+			logger.Info("request_completed", attrs...)
+		}
+	}
+}
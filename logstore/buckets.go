@@ -0,0 +1,85 @@
+package logstore
+
+import (
+	"Distributed-Health-Monitoring/models"
+	"context"
+	"math"
+	"sort"
+	"time"
+)
+
+// Bucketer is implemented by stores that can aggregate the logs of a
+// service per time bucket in their query engine, so no rows are loaded
+type Bucketer interface {
+	Buckets(ctx context.Context, serviceID uint, from time.Time, to time.Time, step time.Duration) ([]models.CheckLogBucket, error)
+}
+
+// Buckets aggregates the logs of one service checked in [from, to) into
+// buckets of step starting at from, oldest first. Buckets without checks
+// are left out. Stores without a query engine are read a chunk at a time,
+// so only one chunk of rows is held in memory.
+func Buckets(ctx context.Context, store LogStore, serviceID uint, from time.Time, to time.Time, step time.Duration) ([]models.CheckLogBucket, error) {
+	if b, ok := Unwrap(store).(Bucketer); ok {
+		return b.Buckets(ctx, serviceID, from, to, step)
+	}
+	return rangeBuckets(ctx, store, serviceID, from, to, step)
+}
+
+// bucketChunk is how much of the range rangeBuckets reads at once
+const bucketChunk = time.Hour
+
+func rangeBuckets(ctx context.Context, store LogStore, serviceID uint, from time.Time, to time.Time, step time.Duration) ([]models.CheckLogBucket, error) {
+	// Whole buckets per chunk, so no bucket spans two reads
+	chunk := step * time.Duration(max(1, int64(math.Ceil(float64(bucketChunk)/float64(step)))))
+
+	buckets := []models.CheckLogBucket{}
+	for start := from; start.Before(to); start = start.Add(chunk) {
+		end := start.Add(chunk)
+		if end.After(to) {
+			end = to
+		}
+		logs, err := store.Range(ctx, serviceID, start, end)
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < len(logs); {
+			bucket := from.Add(logs[i].CheckedAt.Sub(from) / step * step)
+			j := i
+			for j < len(logs) && logs[j].CheckedAt.Before(bucket.Add(step)) {
+				j++
+			}
+			buckets = append(buckets, aggregate(bucket, logs[i:j]))
+			i = j
+		}
+	}
+	return buckets, nil
+}
+
+func aggregate(start time.Time, logs []*models.ServiceCheckLog) models.CheckLogBucket {
+	b := models.CheckLogBucket{Start: start, Checks: int64(len(logs))}
+	var latencies []int64
+	for _, l := range logs {
+		if !models.IsAvailable(l.Status) {
+			continue
+		}
+		b.Available++
+		if l.Status == models.StatusDegraded {
+			b.Degraded++
+		}
+		b.LatencySum += l.ResponseTimeMs
+		latencies = append(latencies, l.ResponseTimeMs)
+	}
+	if len(latencies) > 0 {
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		b.LatencyP95 = nearestRank(latencies, 95)
+		b.LatencyP99 = nearestRank(latencies, 99)
+	}
+	return b
+}
+
+// nearestRank is the percentile of sorted values, as percentile_disc computes it
+func nearestRank(sorted []int64, percentile float64) *float64 {
+	rank := int(math.Ceil(percentile / 100 * float64(len(sorted))))
+	v := float64(sorted[max(rank, 1)-1])
+	return &v
+}
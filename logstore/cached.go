@@ -0,0 +1,166 @@
+package logstore
+
+import (
+	"Distributed-Health-Monitoring/models"
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	defaultCacheRows = 100
+	defaultCacheTTL  = 30 * time.Second
+)
+
+// CachedStore keeps the newest rows of each service in memory so dashboards
+// polling recent logs don't reach the backing store on every request. Saves
+// and deletes through this store update the cache; writes by other replicas
+// are picked up once an entry is older than the TTL.
+type CachedStore struct {
+	LogStore
+
+	rows int
+	ttl  time.Duration
+
+	mu      sync.Mutex
+	entries map[uint]*cacheEntry
+	// gen counts writes per service, so a load that raced a save is not cached
+	gen map[uint]uint64
+}
+
+type cacheEntry struct {
+	logs     []*models.ServiceCheckLog // newest first, at most rows
+	loadedAt time.Time
+}
+
+// NewCachedStore wraps a store; rows and ttl fall back to 100 and 30s when not positive
+func NewCachedStore(store LogStore, rows int, ttl time.Duration) *CachedStore {
+	if rows <= 0 {
+		rows = defaultCacheRows
+	}
+	if ttl <= 0 {
+		ttl = defaultCacheTTL
+	}
+	return &CachedStore{
+		LogStore: store,
+		rows:     rows,
+		ttl:      ttl,
+		entries:  make(map[uint]*cacheEntry),
+		gen:      make(map[uint]uint64),
+	}
+}
+
+// Unwrap returns the store the logs actually live in
+func Unwrap(store LogStore) LogStore {
+	if c, ok := store.(*CachedStore); ok {
+		return c.LogStore
+	}
+	return store
+}
+
+func (s *CachedStore) Save(ctx context.Context, entry *models.ServiceCheckLog) error {
+	if err := s.LogStore.Save(ctx, entry); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.remember(entry)
+	return nil
+}
+
+// SaveBatch forwards the batch and then adds the entries to the cache
+func (s *CachedStore) SaveBatch(ctx context.Context, entries []*models.ServiceCheckLog) error {
+	if err := SaveBatch(ctx, s.LogStore, entries); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, entry := range entries {
+		s.remember(entry)
+	}
+	return nil
+}
+
+// remember puts a saved entry in front of its service's cached rows; s.mu must be held
+func (s *CachedStore) remember(entry *models.ServiceCheckLog) {
+	s.gen[entry.ExternalServiceID]++
+	if e, ok := s.entries[entry.ExternalServiceID]; ok {
+		logs := make([]*models.ServiceCheckLog, 0, s.rows)
+		logs = append(logs, entry)
+		logs = append(logs, e.logs...)
+		if len(logs) > s.rows {
+			logs = logs[:s.rows]
+		}
+		e.logs = logs
+	}
+}
+
+// List serves pages that fall within the cached rows from memory and
+// passes deeper pages through
+func (s *CachedStore) List(ctx context.Context, serviceID uint, limit int, offset int) ([]*models.ServiceCheckLog, error) {
+	if limit == 0 {
+		limit = DefaultLimit
+	}
+	if offset+limit > s.rows {
+		return s.LogStore.List(ctx, serviceID, limit, offset)
+	}
+
+	s.mu.Lock()
+	e, ok := s.entries[serviceID]
+	if ok && time.Since(e.loadedAt) < s.ttl {
+		page := window(e.logs, limit, offset)
+		s.mu.Unlock()
+		return page, nil
+	}
+	gen := s.gen[serviceID]
+	s.mu.Unlock()
+
+	logs, err := s.LogStore.List(ctx, serviceID, s.rows, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	if s.gen[serviceID] == gen {
+		s.entries[serviceID] = &cacheEntry{logs: logs, loadedAt: time.Now()}
+	}
+	s.mu.Unlock()
+
+	return window(logs, limit, offset), nil
+}
+
+func (s *CachedStore) DeleteService(ctx context.Context, serviceID uint) (int64, error) {
+	n, err := s.LogStore.DeleteService(ctx, serviceID)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.gen[serviceID]++
+	delete(s.entries, serviceID)
+	return n, err
+}
+
+func (s *CachedStore) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	n, err := s.LogStore.DeleteBefore(ctx, before)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id := range s.entries {
+		s.gen[id]++
+	}
+	clear(s.entries)
+	return n, err
+}
+
+// window copies one page out of a newest-first slice
+func window(logs []*models.ServiceCheckLog, limit int, offset int) []*models.ServiceCheckLog {
+	if offset >= len(logs) {
+		return []*models.ServiceCheckLog{}
+	}
+	end := offset + limit
+	if end > len(logs) {
+		end = len(logs)
+	}
+	return append([]*models.ServiceCheckLog{}, logs[offset:end]...)
+}
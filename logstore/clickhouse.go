@@ -0,0 +1,463 @@
+package logstore
+
+import (
+	"Distributed-Health-Monitoring/models"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// ClickHouseStore writes check logs to ClickHouse through its HTTP interface.
+// Every user-supplied value is sent as a typed query parameter ({name:Type}),
+// never interpolated into the SQL text.
+type ClickHouseStore struct {
+	endpoint string
+	username string
+	password string
+	table    string
+	client   *http.Client
+	lastID   atomic.Uint64
+}
+
+type ClickHouseOptions struct {
+	URL      string // e.g. http://clickhouse:8123
+	Database string
+	Table    string
+	Username string
+	Password string
+}
+
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+const clickHouseTimeLayout = "2006-01-02 15:04:05.000"
+
+func NewClickHouseStore(opts ClickHouseOptions) (*ClickHouseStore, error) {
+	if opts.URL == "" {
+		return nil, fmt.Errorf("clickhouse log store requires a url")
+	}
+	if opts.Database == "" {
+		opts.Database = "default"
+	}
+	if opts.Table == "" {
+		opts.Table = "service_check_logs"
+	}
+	if !identifier.MatchString(opts.Database) || !identifier.MatchString(opts.Table) {
+		return nil, fmt.Errorf("clickhouse database and table must be plain identifiers")
+	}
+
+	s := &ClickHouseStore{
+		endpoint: strings.TrimRight(opts.URL, "/") + "/",
+		username: opts.Username,
+		password: opts.Password,
+		table:    opts.Database + "." + opts.Table,
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}
+	s.lastID.Store(uint64(time.Now().UnixNano()))
+
+	if _, err := s.exec(context.Background(), `CREATE TABLE IF NOT EXISTS `+s.table+` (
+		id UInt64,
+		external_service_id UInt32,
+		status LowCardinality(String),
+		status_code Int32,
+		response_time_ms Int64,
+		error_message String,
+		region LowCardinality(String),
+		dns_ms Nullable(Int64),
+		connect_ms Nullable(Int64),
+		tls_ms Nullable(Int64),
+		ttfb_ms Nullable(Int64),
+		total_ms Nullable(Int64),
+		query_ms Nullable(Int64),
+		checked_at DateTime64(3, 'UTC')
+	) ENGINE = MergeTree ORDER BY (external_service_id, checked_at)`, nil, nil); err != nil {
+		return nil, fmt.Errorf("failed to create clickhouse table: %w", err)
+	}
+	// Tables created before checks carried a region
+	if _, err := s.exec(context.Background(), "ALTER TABLE "+s.table+" ADD COLUMN IF NOT EXISTS region LowCardinality(String) AFTER error_message", nil, nil); err != nil {
+		return nil, fmt.Errorf("failed to add clickhouse region column: %w", err)
+	}
+	// and before they carried the latency phases
+	after := "region"
+	for _, column := range []string{"dns_ms", "connect_ms", "tls_ms", "ttfb_ms", "total_ms", "query_ms"} {
+		if _, err := s.exec(context.Background(), "ALTER TABLE "+s.table+" ADD COLUMN IF NOT EXISTS "+column+" Nullable(Int64) AFTER "+after, nil, nil); err != nil {
+			return nil, fmt.Errorf("failed to add clickhouse %s column: %w", column, err)
+		}
+		after = column
+	}
+
+	return s, nil
+}
+
+func (s *ClickHouseStore) Save(ctx context.Context, entry *models.ServiceCheckLog) error {
+	// ClickHouse has no sequences; IDs are unique, increasing nanosecond stamps
+	entry.ID = uint(s.nextID())
+
+	row, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.exec(ctx, "INSERT INTO "+s.table+" FORMAT JSONEachRow", nil, row)
+	return err
+}
+
+// SaveBatch sends every entry in one JSONEachRow insert, which is how
+// ClickHouse wants to be written to: few large inserts rather than many rows
+func (s *ClickHouseStore) SaveBatch(ctx context.Context, entries []*models.ServiceCheckLog) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	var body bytes.Buffer
+	for _, entry := range entries {
+		entry.ID = uint(s.nextID())
+		row, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		body.Write(row)
+		body.WriteByte('\n')
+	}
+
+	_, err := s.exec(ctx, "INSERT INTO "+s.table+" FORMAT JSONEachRow", nil, body.Bytes())
+	return err
+}
+
+func (s *ClickHouseStore) List(ctx context.Context, serviceID uint, limit int, offset int) ([]*models.ServiceCheckLog, error) {
+	logs, _, err := s.Query(ctx, models.CheckLogFilter{
+		ServiceIDs: []uint{serviceID},
+		Limit:      limit,
+		Offset:     offset,
+	})
+	return logs, err
+}
+
+func (s *ClickHouseStore) Query(ctx context.Context, filter models.CheckLogFilter) ([]*models.ServiceCheckLog, int64, error) {
+	filter = normalizeFilter(filter)
+	where, params := clickHouseWhere(filter)
+
+	var counts []struct {
+		Total int64 `json:"total"`
+	}
+	if err := s.query(ctx, "SELECT count() AS total FROM "+s.table+where, params, &counts); err != nil {
+		return nil, 0, err
+	}
+
+	order := "DESC"
+	if strings.EqualFold(filter.Order, "asc") {
+		order = "ASC"
+	}
+	params.Set("param_limit", strconv.Itoa(filter.Limit))
+	params.Set("param_offset", strconv.Itoa(filter.Offset))
+
+	var logs []*models.ServiceCheckLog
+	if err := s.query(ctx,
+		"SELECT * FROM "+s.table+where+" ORDER BY checked_at "+order+" LIMIT {limit:UInt32} OFFSET {offset:UInt32}",
+		params, &logs); err != nil {
+		return nil, 0, err
+	}
+
+	var total int64
+	if len(counts) > 0 {
+		total = counts[0].Total
+	}
+	return logs, total, nil
+}
+
+func (s *ClickHouseStore) Range(ctx context.Context, serviceID uint, from time.Time, to time.Time) ([]*models.ServiceCheckLog, error) {
+	where, params := clickHouseWhere(models.CheckLogFilter{ServiceIDs: []uint{serviceID}, From: &from, To: &to})
+
+	var logs []*models.ServiceCheckLog
+	if err := s.query(ctx, "SELECT * FROM "+s.table+where+" ORDER BY checked_at ASC", params, &logs); err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
+
+func (s *ClickHouseStore) Uptime(ctx context.Context, serviceID uint, since time.Time) (models.UptimeStat, error) {
+	where, params := clickHouseWhere(models.CheckLogFilter{ServiceIDs: []uint{serviceID}, From: &since})
+
+	var rows []struct {
+		Checks  int64 `json:"checks"`
+		Success int64 `json:"success"`
+	}
+	if err := s.query(ctx,
+		"SELECT count() AS checks, countIf(status IN ('UP', 'DEGRADED')) AS success FROM "+s.table+where,
+		params, &rows); err != nil {
+		return models.UptimeStat{}, err
+	}
+
+	if len(rows) == 0 {
+		return newUptimeStat(0, 0), nil
+	}
+	return newUptimeStat(rows[0].Checks, rows[0].Success), nil
+}
+
+// Buckets aggregates in ClickHouse
+func (s *ClickHouseStore) Buckets(ctx context.Context, serviceID uint, from time.Time, to time.Time, step time.Duration) ([]models.CheckLogBucket, error) {
+	where, params := clickHouseWhere(models.CheckLogFilter{ServiceIDs: []uint{serviceID}, From: &from, To: &to})
+	params.Set("param_from_ms", strconv.FormatInt(from.UnixMilli(), 10))
+	params.Set("param_step_ms", strconv.FormatInt(step.Milliseconds(), 10))
+
+	available := "status IN ('UP', 'DEGRADED')"
+	quantile := func(level string) string {
+		return "if(countIf(" + available + ") > 0, toFloat64(quantileExactIf(" + level + ")(response_time_ms, " + available + ")), NULL)"
+	}
+	var rows []struct {
+		Bucket     int64    `json:"bucket"`
+		Checks     int64    `json:"checks"`
+		Available  int64    `json:"available"`
+		Degraded   int64    `json:"degraded"`
+		LatencySum int64    `json:"latency_sum"`
+		LatencyP95 *float64 `json:"latency_p95"`
+		LatencyP99 *float64 `json:"latency_p99"`
+	}
+	if err := s.query(ctx,
+		"SELECT intDiv(toUnixTimestamp64Milli(checked_at) - {from_ms:Int64}, {step_ms:Int64}) AS bucket, "+
+			"count() AS checks, countIf("+available+") AS available, countIf(status = 'DEGRADED') AS degraded, "+
+			"sumIf(response_time_ms, "+available+") AS latency_sum, "+
+			quantile("0.95")+" AS latency_p95, "+quantile("0.99")+" AS latency_p99 "+
+			"FROM "+s.table+where+" GROUP BY bucket ORDER BY bucket",
+		params, &rows); err != nil {
+		return nil, err
+	}
+
+	buckets := make([]models.CheckLogBucket, 0, len(rows))
+	for _, r := range rows {
+		buckets = append(buckets, models.CheckLogBucket{
+			Start:      from.Add(time.Duration(r.Bucket) * step),
+			Checks:     r.Checks,
+			Available:  r.Available,
+			Degraded:   r.Degraded,
+			LatencySum: r.LatencySum,
+			LatencyP95: r.LatencyP95,
+			LatencyP99: r.LatencyP99,
+		})
+	}
+	return buckets, nil
+}
+
+// DeleteService runs a mutation and waits for it, so the rows are gone when it returns
+func (s *ClickHouseStore) DeleteService(ctx context.Context, serviceID uint) (int64, error) {
+	where, params := clickHouseWhere(models.CheckLogFilter{ServiceIDs: []uint{serviceID}})
+
+	var counts []struct {
+		Total int64 `json:"total"`
+	}
+	if err := s.query(ctx, "SELECT count() AS total FROM "+s.table+where, params, &counts); err != nil {
+		return 0, err
+	}
+
+	where, params = clickHouseWhere(models.CheckLogFilter{ServiceIDs: []uint{serviceID}})
+	params.Set("mutations_sync", "1")
+	if _, err := s.exec(ctx, "ALTER TABLE "+s.table+" DELETE"+where, params, nil); err != nil {
+		return 0, err
+	}
+
+	if len(counts) == 0 {
+		return 0, nil
+	}
+	return counts[0].Total, nil
+}
+
+// DeleteBefore runs a mutation and waits for it, like DeleteService
+func (s *ClickHouseStore) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	filter := models.CheckLogFilter{To: &before}
+
+	var counts []struct {
+		Total int64 `json:"total"`
+	}
+	where, params := clickHouseWhere(filter)
+	if err := s.query(ctx, "SELECT count() AS total FROM "+s.table+where, params, &counts); err != nil {
+		return 0, err
+	}
+	if len(counts) == 0 || counts[0].Total == 0 {
+		return 0, nil
+	}
+
+	where, params = clickHouseWhere(filter)
+	params.Set("mutations_sync", "1")
+	if _, err := s.exec(ctx, "ALTER TABLE "+s.table+" DELETE"+where, params, nil); err != nil {
+		return 0, err
+	}
+	return counts[0].Total, nil
+}
+
+func (s *ClickHouseStore) ServiceIDs(ctx context.Context) ([]uint, error) {
+	var rows []struct {
+		ID uint `json:"external_service_id"`
+	}
+	if err := s.query(ctx, "SELECT DISTINCT external_service_id FROM "+s.table+" ORDER BY external_service_id", nil, &rows); err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint, 0, len(rows))
+	for _, r := range rows {
+		ids = append(ids, r.ID)
+	}
+	return ids, nil
+}
+
+func (s *ClickHouseStore) nextID() uint64 {
+	for {
+		last := s.lastID.Load()
+		next := uint64(time.Now().UnixNano())
+		if next <= last {
+			next = last + 1
+		}
+		if s.lastID.CompareAndSwap(last, next) {
+			return next
+		}
+	}
+}
+
+// clickHouseWhere builds the WHERE clause for a filter with typed parameters
+func clickHouseWhere(f models.CheckLogFilter) (string, url.Values) {
+	params := url.Values{}
+	var conds []string
+
+	if len(f.ServiceIDs) > 0 {
+		ids := make([]string, len(f.ServiceIDs))
+		for i, id := range f.ServiceIDs {
+			ids[i] = strconv.FormatUint(uint64(id), 10)
+		}
+		conds = append(conds, "external_service_id IN {service_ids:Array(UInt32)}")
+		params.Set("param_service_ids", "["+strings.Join(ids, ",")+"]")
+	}
+	if len(f.Statuses) > 0 {
+		quoted := make([]string, len(f.Statuses))
+		for i, st := range f.Statuses {
+			quoted[i] = "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(st) + "'"
+		}
+		conds = append(conds, "status IN {statuses:Array(String)}")
+		params.Set("param_statuses", "["+strings.Join(quoted, ",")+"]")
+	}
+	if len(f.Regions) > 0 {
+		quoted := make([]string, len(f.Regions))
+		for i, r := range f.Regions {
+			quoted[i] = "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(r) + "'"
+		}
+		conds = append(conds, "region IN {regions:Array(String)}")
+		params.Set("param_regions", "["+strings.Join(quoted, ",")+"]")
+	}
+	if len(f.StatusCodes) > 0 {
+		codes := make([]string, len(f.StatusCodes))
+		for i, c := range f.StatusCodes {
+			codes[i] = strconv.Itoa(c)
+		}
+		conds = append(conds, "status_code IN {status_codes:Array(Int32)}")
+		params.Set("param_status_codes", "["+strings.Join(codes, ",")+"]")
+	}
+	if f.LatencyGtMs != nil {
+		conds = append(conds, "response_time_ms > {latency_gt:Int64}")
+		params.Set("param_latency_gt", strconv.FormatInt(*f.LatencyGtMs, 10))
+	}
+	if f.LatencyLtMs != nil {
+		conds = append(conds, "response_time_ms < {latency_lt:Int64}")
+		params.Set("param_latency_lt", strconv.FormatInt(*f.LatencyLtMs, 10))
+	}
+	if f.ErrorContains != "" {
+		conds = append(conds, "position(error_message, {error_contains:String}) > 0")
+		params.Set("param_error_contains", escapeParam(f.ErrorContains))
+	}
+	if f.From != nil {
+		conds = append(conds, "checked_at >= {from:DateTime64(3, 'UTC')}")
+		params.Set("param_from", f.From.UTC().Format(clickHouseTimeLayout))
+	}
+	if f.To != nil {
+		conds = append(conds, "checked_at < {to:DateTime64(3, 'UTC')}")
+		params.Set("param_to", f.To.UTC().Format(clickHouseTimeLayout))
+	}
+
+	if len(conds) == 0 {
+		return "", params
+	}
+	return " WHERE " + strings.Join(conds, " AND "), params
+}
+
+// escapeParam encodes a string parameter in ClickHouse's escaped text format
+func escapeParam(v string) string {
+	return strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`).Replace(v)
+}
+
+// query runs a SELECT and decodes the JSONEachRow output into out (a pointer to a slice)
+func (s *ClickHouseStore) query(ctx context.Context, sql string, params url.Values, out interface{}) error {
+	body, err := s.exec(ctx, sql+" FORMAT JSONEachRow", params, nil)
+	if err != nil {
+		return err
+	}
+
+	// Re-wrap the newline-delimited rows as a JSON array
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	first := true
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if !first {
+			buf.WriteByte(',')
+		}
+		buf.Write(line)
+		first = false
+	}
+	buf.WriteByte(']')
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	return json.Unmarshal(buf.Bytes(), out)
+}
+
+func (s *ClickHouseStore) exec(ctx context.Context, sql string, params url.Values, data []byte) ([]byte, error) {
+	if params == nil {
+		params = url.Values{}
+	}
+	params.Set("output_format_json_quote_64bit_integers", "0")
+	params.Set("date_time_output_format", "iso")
+	params.Set("date_time_input_format", "best_effort")
+
+	// The statement goes in the query string when a row payload is the body
+	var reqBody io.Reader = strings.NewReader(sql)
+	if data != nil {
+		params.Set("query", sql)
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint+"?"+params.Encode(), reqBody)
+	if err != nil {
+		return nil, err
+	}
+	if s.username != "" {
+		req.SetBasicAuth(s.username, s.password)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("clickhouse request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("clickhouse returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return body, nil
+}
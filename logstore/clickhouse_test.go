@@ -0,0 +1,55 @@
+package logstore
+
+import (
+	"Distributed-Health-Monitoring/models"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestClickHouseWhere(t *testing.T) {
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.FixedZone("CET", 3600))
+	gt := int64(250)
+
+	tests := []struct {
+		name   string
+		filter models.CheckLogFilter
+		where  string
+		params url.Values
+	}{
+		{
+			name:   "no filter",
+			params: url.Values{},
+		},
+		{
+			name:   "services and status codes",
+			filter: models.CheckLogFilter{ServiceIDs: []uint{1, 2}, StatusCodes: []int{503}},
+			where:  " WHERE external_service_id IN {service_ids:Array(UInt32)} AND status_code IN {status_codes:Array(Int32)}",
+			params: url.Values{"param_service_ids": {"[1,2]"}, "param_status_codes": {"[503]"}},
+		},
+		{
+			name:   "quoted statuses and regions",
+			filter: models.CheckLogFilter{Statuses: []string{"DOWN", `it's\`}, Regions: []string{"eu-west"}},
+			where:  " WHERE status IN {statuses:Array(String)} AND region IN {regions:Array(String)}",
+			params: url.Values{"param_statuses": {`['DOWN','it\'s\\']`}, "param_regions": {"['eu-west']"}},
+		},
+		{
+			name:   "latency, error text and time in UTC",
+			filter: models.CheckLogFilter{LatencyGtMs: &gt, ErrorContains: "bad\tgateway", From: &from},
+			where:  " WHERE response_time_ms > {latency_gt:Int64} AND position(error_message, {error_contains:String}) > 0 AND checked_at >= {from:DateTime64(3, 'UTC')}",
+			params: url.Values{"param_latency_gt": {"250"}, "param_error_contains": {`bad\tgateway`}, "param_from": {"2026-02-28 23:00:00.000"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			where, params := clickHouseWhere(tt.filter)
+			if where != tt.where {
+				t.Errorf("where = %q, want %q", where, tt.where)
+			}
+			if !reflect.DeepEqual(params, tt.params) {
+				t.Errorf("params = %v, want %v", params, tt.params)
+			}
+		})
+	}
+}
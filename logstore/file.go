@@ -0,0 +1,254 @@
+package logstore
+
+import (
+	"Distributed-Health-Monitoring/models"
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// FileStore appends check logs as JSON lines to a local file. It needs no
+// database and suits lightweight agents; reads scan the whole file.
+type FileStore struct {
+	mu     sync.Mutex
+	path   string
+	file   *os.File
+	nextID uint
+}
+
+func NewFileStore(path string) (*FileStore, error) {
+	if path == "" {
+		return nil, fmt.Errorf("file log store requires a path")
+	}
+
+	s := &FileStore{path: path, nextID: 1}
+
+	// Continue numbering after the existing entries
+	if err := s.scan(func(l *models.ServiceCheckLog) {
+		if l.ID >= s.nextID {
+			s.nextID = l.ID + 1
+		}
+	}); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+	s.file = file
+
+	return s, nil
+}
+
+func (s *FileStore) Save(ctx context.Context, entry *models.ServiceCheckLog) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry.ID = s.nextID
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+
+	s.nextID++
+	return nil
+}
+
+// SaveBatch writes all entries with a single write call
+func (s *FileStore) SaveBatch(ctx context.Context, entries []*models.ServiceCheckLog) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var buf []byte
+	for i, entry := range entries {
+		entry.ID = s.nextID + uint(i)
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		buf = append(append(buf, line...), '\n')
+	}
+	if _, err := s.file.Write(buf); err != nil {
+		return err
+	}
+
+	s.nextID += uint(len(entries))
+	return nil
+}
+
+func (s *FileStore) List(ctx context.Context, serviceID uint, limit int, offset int) ([]*models.ServiceCheckLog, error) {
+	logs, _, err := s.Query(ctx, models.CheckLogFilter{
+		ServiceIDs: []uint{serviceID},
+		Limit:      limit,
+		Offset:     offset,
+	})
+	return logs, err
+}
+
+func (s *FileStore) Query(ctx context.Context, filter models.CheckLogFilter) ([]*models.ServiceCheckLog, int64, error) {
+	filter = normalizeFilter(filter)
+
+	var matched []*models.ServiceCheckLog
+	if err := s.scan(func(l *models.ServiceCheckLog) {
+		if matches(l, filter) {
+			matched = append(matched, l)
+		}
+	}); err != nil {
+		return nil, 0, err
+	}
+
+	return page(matched, filter), int64(len(matched)), nil
+}
+
+func (s *FileStore) Range(ctx context.Context, serviceID uint, from time.Time, to time.Time) ([]*models.ServiceCheckLog, error) {
+	filter := models.CheckLogFilter{ServiceIDs: []uint{serviceID}, From: &from, To: &to}
+
+	var matched []*models.ServiceCheckLog
+	if err := s.scan(func(l *models.ServiceCheckLog) {
+		if matches(l, filter) {
+			matched = append(matched, l)
+		}
+	}); err != nil {
+		return nil, err
+	}
+
+	filter.Order = "asc"
+	filter.Limit = len(matched)
+	return page(matched, filter), nil
+}
+
+func (s *FileStore) Uptime(ctx context.Context, serviceID uint, since time.Time) (models.UptimeStat, error) {
+	filter := models.CheckLogFilter{ServiceIDs: []uint{serviceID}, From: &since}
+
+	var checks, success int64
+	if err := s.scan(func(l *models.ServiceCheckLog) {
+		if matches(l, filter) {
+			checks++
+			if models.IsAvailable(l.Status) {
+				success++
+			}
+		}
+	}); err != nil {
+		return models.UptimeStat{}, err
+	}
+
+	return newUptimeStat(checks, success), nil
+}
+
+func (s *FileStore) ServiceIDs(ctx context.Context) ([]uint, error) {
+	seen := make(map[uint]bool)
+	if err := s.scan(func(l *models.ServiceCheckLog) {
+		seen[l.ExternalServiceID] = true
+	}); err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint, 0, len(seen))
+	for id := range seen {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
+// DeleteService rewrites the file without the service's entries
+func (s *FileStore) DeleteService(ctx context.Context, serviceID uint) (int64, error) {
+	return s.rewrite(func(l *models.ServiceCheckLog) bool { return l.ExternalServiceID == serviceID })
+}
+
+// DeleteBefore rewrites the file without the entries checked before the time
+func (s *FileStore) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	return s.rewrite(func(l *models.ServiceCheckLog) bool { return l.CheckedAt.Before(before) })
+}
+
+// rewrite writes the file again without the entries drop matches. The new
+// file is written next to the old one and renamed over it, so a crash leaves
+// one or the other intact.
+func (s *FileStore) rewrite(drop func(l *models.ServiceCheckLog) bool) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tmpPath := s.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create log file: %w", err)
+	}
+
+	var removed int64
+	w := bufio.NewWriter(tmp)
+	err = s.scan(func(l *models.ServiceCheckLog) {
+		if drop(l) {
+			removed++
+			return
+		}
+		line, merr := json.Marshal(l)
+		if merr != nil {
+			return
+		}
+		w.Write(append(line, '\n'))
+	})
+	if err == nil {
+		err = w.Flush()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return 0, err
+	}
+
+	if removed == 0 {
+		os.Remove(tmpPath)
+		return 0, nil
+	}
+
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		os.Remove(tmpPath)
+		return 0, fmt.Errorf("failed to replace log file: %w", err)
+	}
+
+	// Reopen so later appends go to the new file, not the unlinked one
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return removed, fmt.Errorf("failed to open log file: %w", err)
+	}
+	s.file.Close()
+	s.file = file
+
+	return removed, nil
+}
+
+// scan calls fn for every entry in the file, skipping corrupt lines
+func (s *FileStore) scan(fn func(l *models.ServiceCheckLog)) error {
+	f, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for scanner.Scan() {
+		var l models.ServiceCheckLog
+		if err := json.Unmarshal(scanner.Bytes(), &l); err != nil {
+			continue
+		}
+		fn(&l)
+	}
+
+	return scanner.Err()
+}
@@ -0,0 +1,212 @@
+package logstore
+
+import (
+	"Distributed-Health-Monitoring/models"
+	"context"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// GormStore keeps check logs in the service_check_logs table of the main database
+type GormStore struct {
+	db *gorm.DB
+}
+
+func NewGormStore(db *gorm.DB) *GormStore {
+	return &GormStore{db: db}
+}
+
+// likeEscaper escapes LIKE wildcards so user input only ever matches literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func (s *GormStore) Save(ctx context.Context, entry *models.ServiceCheckLog) error {
+	return s.db.WithContext(ctx).Create(entry).Error
+}
+
+// saveBatchRows bounds one INSERT, keeping well under the bind parameter limit
+const saveBatchRows = 500
+
+func (s *GormStore) SaveBatch(ctx context.Context, entries []*models.ServiceCheckLog) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	return s.db.WithContext(ctx).CreateInBatches(entries, saveBatchRows).Error
+}
+
+func (s *GormStore) List(ctx context.Context, serviceID uint, limit int, offset int) ([]*models.ServiceCheckLog, error) {
+	var logs []*models.ServiceCheckLog
+
+	if limit == 0 {
+		limit = DefaultLimit
+	}
+
+	if err := s.db.WithContext(ctx).
+		Where("external_service_id = ?", serviceID).
+		Order("checked_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&logs).Error; err != nil {
+		return nil, err
+	}
+
+	return logs, nil
+}
+
+// Query applies a structured filter using bound parameters only
+func (s *GormStore) Query(ctx context.Context, filter models.CheckLogFilter) ([]*models.ServiceCheckLog, int64, error) {
+	var logs []*models.ServiceCheckLog
+	var total int64
+
+	filter = normalizeFilter(filter)
+	query := applyFilter(s.db.WithContext(ctx).Model(&models.ServiceCheckLog{}), filter)
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	order := "checked_at DESC"
+	if strings.EqualFold(filter.Order, "asc") {
+		order = "checked_at ASC"
+	}
+
+	if err := query.
+		Order(order).
+		Limit(filter.Limit).
+		Offset(filter.Offset).
+		Find(&logs).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return logs, total, nil
+}
+
+func (s *GormStore) Range(ctx context.Context, serviceID uint, from time.Time, to time.Time) ([]*models.ServiceCheckLog, error) {
+	var logs []*models.ServiceCheckLog
+
+	if err := s.db.WithContext(ctx).
+		Where("external_service_id = ? AND checked_at >= ? AND checked_at < ?", serviceID, from, to).
+		Order("checked_at ASC").
+		Find(&logs).Error; err != nil {
+		return nil, err
+	}
+
+	return logs, nil
+}
+
+func (s *GormStore) Uptime(ctx context.Context, serviceID uint, since time.Time) (models.UptimeStat, error) {
+	var checks, success int64
+
+	base := s.db.WithContext(ctx).
+		Model(&models.ServiceCheckLog{}).
+		Where("external_service_id = ? AND checked_at >= ?", serviceID, since)
+
+	if err := base.Session(&gorm.Session{}).Count(&checks).Error; err != nil {
+		return models.UptimeStat{}, err
+	}
+	if err := base.Session(&gorm.Session{}).Where("status IN ?", []string{"UP", models.StatusDegraded}).Count(&success).Error; err != nil {
+		return models.UptimeStat{}, err
+	}
+
+	return newUptimeStat(checks, success), nil
+}
+
+func applyFilter(query *gorm.DB, filter models.CheckLogFilter) *gorm.DB {
+	if len(filter.ServiceIDs) > 0 {
+		query = query.Where("external_service_id IN ?", filter.ServiceIDs)
+	}
+	if len(filter.Statuses) > 0 {
+		query = query.Where("status IN ?", filter.Statuses)
+	}
+	if len(filter.StatusCodes) > 0 {
+		query = query.Where("status_code IN ?", filter.StatusCodes)
+	}
+	if filter.LatencyGtMs != nil {
+		query = query.Where("response_time_ms > ?", *filter.LatencyGtMs)
+	}
+	if filter.LatencyLtMs != nil {
+		query = query.Where("response_time_ms < ?", *filter.LatencyLtMs)
+	}
+	if len(filter.Regions) > 0 {
+		query = query.Where("COALESCE(region, '') IN ?", filter.Regions)
+	}
+	if filter.ErrorContains != "" {
+		query = query.Where(`error_message LIKE ? ESCAPE '\'`, "%"+likeEscaper.Replace(filter.ErrorContains)+"%")
+	}
+	if filter.From != nil {
+		query = query.Where("checked_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("checked_at < ?", *filter.To)
+	}
+
+	return query
+}
+
+func (s *GormStore) DeleteService(ctx context.Context, serviceID uint) (int64, error) {
+	res := s.db.WithContext(ctx).Where("external_service_id = ?", serviceID).Delete(&models.ServiceCheckLog{})
+	return res.RowsAffected, res.Error
+}
+
+func (s *GormStore) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	res := s.db.WithContext(ctx).Where("checked_at < ?", before).Delete(&models.ServiceCheckLog{})
+	return res.RowsAffected, res.Error
+}
+
+func (s *GormStore) ServiceIDs(ctx context.Context) ([]uint, error) {
+	var ids []uint
+	err := s.db.WithContext(ctx).Model(&models.ServiceCheckLog{}).Distinct().Pluck("external_service_id", &ids).Error
+	return ids, err
+}
+
+// availableStatuses is the SQL list of the statuses that count as available
+const availableStatuses = "('UP', 'DEGRADED')"
+
+// Buckets aggregates in Postgres; other databases are read a chunk at a time
+func (s *GormStore) Buckets(ctx context.Context, serviceID uint, from time.Time, to time.Time, step time.Duration) ([]models.CheckLogBucket, error) {
+	if s.db.Dialector.Name() != "postgres" {
+		return rangeBuckets(ctx, s, serviceID, from, to, step)
+	}
+
+	var rows []struct {
+		Bucket     int64
+		Checks     int64
+		Available  int64
+		Degraded   int64
+		LatencySum int64
+		LatencyP95 *float64
+		LatencyP99 *float64
+	}
+	available := "FILTER (WHERE status IN " + availableStatuses + ")"
+	if err := s.db.WithContext(ctx).
+		Model(&models.ServiceCheckLog{}).
+		Select("floor(extract(epoch from (checked_at - ?)) * 1000 / ?)::bigint AS bucket, "+
+			"count(*) AS checks, "+
+			"count(*) "+available+" AS available, "+
+			"count(*) FILTER (WHERE status = 'DEGRADED') AS degraded, "+
+			"coalesce(sum(response_time_ms) "+available+", 0) AS latency_sum, "+
+			"(percentile_disc(0.95) WITHIN GROUP (ORDER BY response_time_ms) "+available+")::float8 AS latency_p95, "+
+			"(percentile_disc(0.99) WITHIN GROUP (ORDER BY response_time_ms) "+available+")::float8 AS latency_p99",
+			from, step.Milliseconds()).
+		Where("external_service_id = ? AND checked_at >= ? AND checked_at < ?", serviceID, from, to).
+		Group("bucket").
+		Order("bucket").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	buckets := make([]models.CheckLogBucket, 0, len(rows))
+	for _, r := range rows {
+		buckets = append(buckets, models.CheckLogBucket{
+			Start:      from.Add(time.Duration(r.Bucket) * step),
+			Checks:     r.Checks,
+			Available:  r.Available,
+			Degraded:   r.Degraded,
+			LatencySum: r.LatencySum,
+			LatencyP95: r.LatencyP95,
+			LatencyP99: r.LatencyP99,
+		})
+	}
+	return buckets, nil
+}
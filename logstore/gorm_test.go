@@ -0,0 +1,122 @@
+package logstore
+
+import (
+	"Distributed-Health-Monitoring/models"
+	_ "Distributed-Health-Monitoring/secrets" // registers the serializers of the service columns
+	"reflect"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// dryRunDB builds statements without a database, so the SQL a filter
+// generates can be checked as it would be sent
+func dryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.Open("host=localhost"), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+		Logger:               logger.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestApplyFilter(t *testing.T) {
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+	gt, lt := int64(250), int64(1000)
+
+	const selectLogs = `SELECT * FROM "service_check_logs"`
+	tests := []struct {
+		name   string
+		filter models.CheckLogFilter
+		where  string
+		vars   []interface{}
+	}{
+		{
+			name:   "no filter",
+			filter: models.CheckLogFilter{},
+		},
+		{
+			name:   "services and statuses",
+			filter: models.CheckLogFilter{ServiceIDs: []uint{1, 2}, Statuses: []string{"DOWN", "DEGRADED"}},
+			where:  ` WHERE external_service_id IN ($1,$2) AND status IN ($3,$4)`,
+			vars:   []interface{}{uint(1), uint(2), "DOWN", "DEGRADED"},
+		},
+		{
+			name:   "status codes and latency",
+			filter: models.CheckLogFilter{StatusCodes: []int{500, 503}, LatencyGtMs: &gt, LatencyLtMs: &lt},
+			where:  ` WHERE status_code IN ($1,$2) AND response_time_ms > $3 AND response_time_ms < $4`,
+			vars:   []interface{}{500, 503, gt, lt},
+		},
+		{
+			name:   "regions",
+			filter: models.CheckLogFilter{Regions: []string{"eu-west", ""}},
+			where:  ` WHERE COALESCE(region, '') IN ($1,$2)`,
+			vars:   []interface{}{"eu-west", ""},
+		},
+		{
+			name:   "time range",
+			filter: models.CheckLogFilter{From: &from, To: &to},
+			where:  ` WHERE checked_at >= $1 AND checked_at < $2`,
+			vars:   []interface{}{from, to},
+		},
+		{
+			name:   "error text is bound",
+			filter: models.CheckLogFilter{ErrorContains: "timeout'; DROP TABLE service_check_logs; --"},
+			where:  ` WHERE error_message LIKE $1 ESCAPE '\'`,
+			vars:   []interface{}{"%timeout'; DROP TABLE service\\_check\\_logs; --%"},
+		},
+		{
+			name:   "error wildcards match literally",
+			filter: models.CheckLogFilter{ErrorContains: `100% _done\`},
+			where:  ` WHERE error_message LIKE $1 ESCAPE '\'`,
+			vars:   []interface{}{`%100\% \_done\\%`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs []*models.ServiceCheckLog
+			res := applyFilter(dryRunDB(t).Model(&models.ServiceCheckLog{}), tt.filter).Find(&logs)
+			if res.Error != nil {
+				t.Fatal(res.Error)
+			}
+			stmt := res.Statement
+
+			if got, want := stmt.SQL.String(), selectLogs+tt.where; got != want {
+				t.Errorf("SQL = %s\nwant  %s", got, want)
+			}
+			if len(stmt.Vars) != len(tt.vars) || (len(tt.vars) > 0 && !reflect.DeepEqual(stmt.Vars, tt.vars)) {
+				t.Errorf("vars = %#v, want %#v", stmt.Vars, tt.vars)
+			}
+		})
+	}
+}
+
+func TestNormalizeFilter(t *testing.T) {
+	tests := []struct {
+		name          string
+		limit, offset int
+		wantLimit     int
+		wantOffset    int
+	}{
+		{"defaults", 0, 0, DefaultLimit, 0},
+		{"negative", -5, -10, DefaultLimit, 0},
+		{"within bounds", 50, 20, 50, 20},
+		{"capped", MaxQueryLimit + 1, 0, MaxQueryLimit, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := normalizeFilter(models.CheckLogFilter{Limit: tt.limit, Offset: tt.offset})
+			if got.Limit != tt.wantLimit || got.Offset != tt.wantOffset {
+				t.Errorf("normalizeFilter() = limit %d offset %d, want %d and %d", got.Limit, got.Offset, tt.wantLimit, tt.wantOffset)
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package logstore
+
+import (
+	"Distributed-Health-Monitoring/config"
+	"Distributed-Health-Monitoring/models"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// LogStore persists and queries service check logs. The Repository delegates
+// all log reads and writes to it, so the backend can be swapped via config.
+type LogStore interface {
+	// Save appends one check log, assigning its ID
+	Save(ctx context.Context, entry *models.ServiceCheckLog) error
+	// List returns a page of the logs of one service, newest first
+	List(ctx context.Context, serviceID uint, limit int, offset int) ([]*models.ServiceCheckLog, error)
+	// Query applies a structured filter and returns the page plus the total number of matches
+	Query(ctx context.Context, filter models.CheckLogFilter) ([]*models.ServiceCheckLog, int64, error)
+	// Range returns the logs of one service checked in [from, to), oldest first
+	Range(ctx context.Context, serviceID uint, from time.Time, to time.Time) ([]*models.ServiceCheckLog, error)
+	// Uptime counts the checks of one service since the given time and how many succeeded
+	Uptime(ctx context.Context, serviceID uint, since time.Time) (models.UptimeStat, error)
+	// DeleteService removes every log of one service and reports how many were removed
+	DeleteService(ctx context.Context, serviceID uint) (int64, error)
+	// DeleteBefore removes the logs of every service checked before the given time
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+	// ServiceIDs returns the distinct service ids that have logs
+	ServiceIDs(ctx context.Context) ([]uint, error)
+}
+
+// BatchSaver is implemented by stores that can append many check logs in one
+// round trip. Entries are in check order and each gets its ID.
+type BatchSaver interface {
+	SaveBatch(ctx context.Context, entries []*models.ServiceCheckLog) error
+}
+
+// SaveBatch appends the entries in one call when the store supports it and
+// one by one otherwise
+func SaveBatch(ctx context.Context, store LogStore, entries []*models.ServiceCheckLog) error {
+	if b, ok := store.(BatchSaver); ok {
+		return b.SaveBatch(ctx, entries)
+	}
+	for _, entry := range entries {
+		if err := store.Save(ctx, entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const (
+	// DefaultLimit is used when a page size isn't given
+	DefaultLimit = 100
+	// MaxQueryLimit bounds a single page of filtered logs
+	MaxQueryLimit = 1000
+)
+
+// normalizeFilter applies the default and maximum page size
+func normalizeFilter(filter models.CheckLogFilter) models.CheckLogFilter {
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultLimit
+	}
+	if filter.Limit > MaxQueryLimit {
+		filter.Limit = MaxQueryLimit
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+	return filter
+}
+
+func newUptimeStat(checks, success int64) models.UptimeStat {
+	stat := models.UptimeStat{Checks: checks, SuccessChecks: success, UptimePercent: 100}
+	if checks > 0 {
+		stat.UptimePercent = float64(success) / float64(checks) * 100
+	}
+	return stat
+}
+
+// matches evaluates a filter in memory, for stores without a query engine
+func matches(l *models.ServiceCheckLog, f models.CheckLogFilter) bool {
+	if len(f.ServiceIDs) > 0 && !containsUint(f.ServiceIDs, l.ExternalServiceID) {
+		return false
+	}
+	if len(f.Statuses) > 0 && !containsString(f.Statuses, l.Status) {
+		return false
+	}
+	if len(f.StatusCodes) > 0 && !containsInt(f.StatusCodes, l.StatusCode) {
+		return false
+	}
+	if f.LatencyGtMs != nil && l.ResponseTimeMs <= *f.LatencyGtMs {
+		return false
+	}
+	if f.LatencyLtMs != nil && l.ResponseTimeMs >= *f.LatencyLtMs {
+		return false
+	}
+	if len(f.Regions) > 0 && !containsString(f.Regions, l.Region) {
+		return false
+	}
+	if f.ErrorContains != "" && !strings.Contains(l.ErrorMessage, f.ErrorContains) {
+		return false
+	}
+	if f.From != nil && l.CheckedAt.Before(*f.From) {
+		return false
+	}
+	if f.To != nil && !l.CheckedAt.Before(*f.To) {
+		return false
+	}
+	return true
+}
+
+// page sorts matched logs by check time and cuts the requested page
+func page(logs []*models.ServiceCheckLog, f models.CheckLogFilter) []*models.ServiceCheckLog {
+	asc := strings.EqualFold(f.Order, "asc")
+	sort.SliceStable(logs, func(i, j int) bool {
+		if asc {
+			return logs[i].CheckedAt.Before(logs[j].CheckedAt)
+		}
+		return logs[i].CheckedAt.After(logs[j].CheckedAt)
+	})
+
+	if f.Offset >= len(logs) {
+		return []*models.ServiceCheckLog{}
+	}
+	end := f.Offset + f.Limit
+	if end > len(logs) {
+		end = len(logs)
+	}
+	return logs[f.Offset:end]
+}
+
+func containsUint(list []uint, v uint) bool {
+	for _, x := range list {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+func containsInt(list []int, v int) bool {
+	for _, x := range list {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(list []string, v string) bool {
+	for _, x := range list {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Open builds the store selected by config: postgres (default), timescale,
+// clickhouse or file, behind the read cache when it is enabled
+func Open(cfg config.LogStore, db *gorm.DB) (LogStore, error) {
+	store, err := open(cfg, db)
+	if err != nil || !cfg.Cache.Enabled {
+		return store, err
+	}
+	return NewCachedStore(store, cfg.Cache.Rows, time.Duration(cfg.Cache.TTLSeconds)*time.Second), nil
+}
+
+func open(cfg config.LogStore, db *gorm.DB) (LogStore, error) {
+	switch cfg.Driver {
+	case "", "postgres":
+		return NewGormStore(db), nil
+	case "timescale":
+		if name := db.Dialector.Name(); name != "postgres" {
+			return nil, fmt.Errorf("the timescale log store needs postgres, not %s", name)
+		}
+		return NewTimescaleStore(db, cfg.ChunkInterval)
+	case "clickhouse":
+		return NewClickHouseStore(ClickHouseOptions{
+			URL:      cfg.ClickHouse.URL,
+			Database: cfg.ClickHouse.Database,
+			Table:    cfg.ClickHouse.Table,
+			Username: cfg.ClickHouse.Username,
+			Password: cfg.ClickHouse.Password,
+		})
+	case "file":
+		return NewFileStore(cfg.Path)
+	}
+
+	return nil, fmt.Errorf("unknown log store driver %q", cfg.Driver)
+}
+
+// UsesMainDatabase reports whether logs live in the service_check_logs table of the main database
+func UsesMainDatabase(cfg config.LogStore) bool {
+	return cfg.Driver == "" || cfg.Driver == "postgres" || cfg.Driver == "timescale"
+}
@@ -0,0 +1,54 @@
+package logstore
+
+import (
+	"Distributed-Health-Monitoring/models"
+	"testing"
+	"time"
+)
+
+func TestMatches(t *testing.T) {
+	checked := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	log := &models.ServiceCheckLog{
+		ExternalServiceID: 7,
+		Status:            "DOWN",
+		StatusCode:        503,
+		ResponseTimeMs:    300,
+		ErrorMessage:      "upstream 100% busy",
+		CheckedAt:         checked,
+	}
+	at := func(d time.Duration) *time.Time {
+		v := checked.Add(d)
+		return &v
+	}
+	ms := func(v int64) *int64 { return &v }
+
+	tests := []struct {
+		name   string
+		filter models.CheckLogFilter
+		want   bool
+	}{
+		{"no filter", models.CheckLogFilter{}, true},
+		{"service", models.CheckLogFilter{ServiceIDs: []uint{7}}, true},
+		{"other service", models.CheckLogFilter{ServiceIDs: []uint{8}}, false},
+		{"status", models.CheckLogFilter{Statuses: []string{"UP", "DOWN"}}, true},
+		{"other status", models.CheckLogFilter{Statuses: []string{"UP"}}, false},
+		{"status code", models.CheckLogFilter{StatusCodes: []int{500}}, false},
+		{"latency above", models.CheckLogFilter{LatencyGtMs: ms(299)}, true},
+		{"latency above is strict", models.CheckLogFilter{LatencyGtMs: ms(300)}, false},
+		{"latency below is strict", models.CheckLogFilter{LatencyLtMs: ms(300)}, false},
+		{"no region", models.CheckLogFilter{Regions: []string{""}}, true},
+		{"other region", models.CheckLogFilter{Regions: []string{"eu-west"}}, false},
+		{"error text is literal", models.CheckLogFilter{ErrorContains: "100%"}, true},
+		{"error text", models.CheckLogFilter{ErrorContains: "timeout"}, false},
+		{"from is inclusive", models.CheckLogFilter{From: at(0)}, true},
+		{"to is exclusive", models.CheckLogFilter{To: at(0)}, false},
+		{"within range", models.CheckLogFilter{From: at(-time.Hour), To: at(time.Hour)}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matches(log, tt.filter); got != tt.want {
+				t.Errorf("matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package logstore
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// NewTimescaleStore uses the main Postgres database with service_check_logs
+// converted to a TimescaleDB hypertable partitioned by checked_at. Queries
+// are the same as GormStore; Timescale handles chunking and retention.
+func NewTimescaleStore(db *gorm.DB, chunkInterval string) (*GormStore, error) {
+	if chunkInterval == "" {
+		chunkInterval = "1 day"
+	}
+
+	if err := db.Exec("CREATE EXTENSION IF NOT EXISTS timescaledb").Error; err != nil {
+		return nil, fmt.Errorf("failed to enable timescaledb: %w", err)
+	}
+
+	var isHypertable bool
+	if err := db.Raw(
+		"SELECT EXISTS (SELECT 1 FROM timescaledb_information.hypertables WHERE hypertable_name = ?)",
+		"service_check_logs",
+	).Scan(&isHypertable).Error; err != nil {
+		return nil, fmt.Errorf("failed to inspect hypertables: %w", err)
+	}
+
+	if !isHypertable {
+		// Unique indexes on a hypertable must include the partitioning column
+		if err := db.Exec(
+			"ALTER TABLE service_check_logs DROP CONSTRAINT IF EXISTS service_check_logs_pkey, ADD PRIMARY KEY (id, checked_at)",
+		).Error; err != nil {
+			return nil, fmt.Errorf("failed to prepare service_check_logs primary key: %w", err)
+		}
+
+		if err := db.Exec(
+			"SELECT create_hypertable('service_check_logs', 'checked_at', chunk_time_interval => ?::interval, migrate_data => true)",
+			chunkInterval,
+		).Error; err != nil {
+			return nil, fmt.Errorf("failed to create hypertable: %w", err)
+		}
+	}
+
+	return NewGormStore(db), nil
+}
@@ -3,14 +3,39 @@ package main
 import (
 	service "Distributed-Health-Monitoring/Service"
 	"context"
-	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
 )
 
 func main() {
 
+	// Offline subcommands that don't need the database or the broker
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "simulate":
+			if err := runSimulate(os.Args[2:]); err != nil {
+				fatal("simulate_failed", err)
+			}
+			return
+		}
+	}
+
 	engine, err := service.NewEngine()
 	if err != nil {
-		log.Fatalf("Failed to create engine: %v", err)
+		fatal("engine_create_failed", err)
+	}
+
+	// VERIFY INDEXES AND LOG TABLE HEALTH
+	engine.CheckDBHealthOnStartup()
+
+	// DETECT AND REPAIR STATE LEFT INCONSISTENT BY CRASHES OR MANUAL EDITS
+	engine.CheckConsistencyOnStartup()
+
+	// LOAD EVERY SERVICE INTO THE CACHE BEFORE SERVING
+	if err := engine.WarmCache(context.Background()); err != nil {
+		fatal("cache_warm_failed", err)
 	}
 
 	// Setup all routes
@@ -19,27 +44,87 @@ func main() {
 	// WebSocket hub
 	hub := engine.NewHub()
 	service.GlobalHub = hub
-	
+
 	// START WEBSOCKET
 	go hub.Run()
 
-	// START WORKER
+	// REGISTER CHECKS FOR OUR OWN DEPENDENCIES
+	engine.BootstrapSelfMonitors()
+
+	// START WORKER (with queue.driver memory it consumes the scheduler's in-process queue)
 	go func() {
-		if err := engine.StartWorker(engine.AMQPURL(), engine.Cnfg.RabbitMQ.QueueName); err != nil {
-			log.Fatalf("worker failed: %v", err)
+		if err := engine.StartWorker(); err != nil {
+			fatal("worker_failed", err)
+		}
+	}()
+
+	// START CLOCK SYNC (every replica schedules by the database clock)
+	go func() {
+		if err := engine.ClockSync(context.Background()); err != nil {
+			fatal("clock_sync_failed", err)
 		}
 	}()
 
 	// START SCHEDULER
 	go func() {
 		if err := engine.Scheduler(context.Background()); err != nil {
-			log.Fatalf("scheduler failed: %v", err)
+			fatal("scheduler_failed", err)
+		}
+	}()
+
+	// START ESCALATOR
+	go func() {
+		if err := engine.Escalator(context.Background()); err != nil {
+			fatal("escalator_failed", err)
+		}
+	}()
+
+	// START SLO EVALUATOR (burn-rate alerts)
+	go func() {
+		if err := engine.SLOEvaluator(context.Background()); err != nil {
+			fatal("slo_evaluator_failed", err)
+		}
+	}()
+
+	// START LOG ARCHIVER (daily check log partitions to S3, then pruned)
+	go func() {
+		if err := engine.LogArchiver(context.Background()); err != nil {
+			fatal("log_archiver_failed", err)
+		}
+	}()
+
+	// START CLUSTER EVENTS (with cluster.enabled replicas share events and cache invalidations)
+	go func() {
+		if err := engine.ClusterEvents(context.Background()); err != nil {
+			fatal("cluster_events_failed", err)
 		}
 	}()
 
+	// START CONSUL SYNC
+	go func() {
+		if err := engine.ConsulSync(context.Background()); err != nil {
+			fatal("consul_sync_failed", err)
+		}
+	}()
+
+	// FLUSH BUFFERED CHECK LOGS BEFORE EXITING
+	go func() {
+		stop := make(chan os.Signal, 1)
+		signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+		<-stop
+		engine.Shutdown()
+		os.Exit(0)
+	}()
+
 	// START GIN SERVER
 	if err := engine.Run(); err != nil {
-		log.Fatalf("Failed to run engine: %v", err)
+		fatal("server_failed", err)
 	}
 
 }
+
+// fatal logs a startup or background failure and exits
+func fatal(event string, err error) {
+	slog.Error(event, "component", "main", "err", err)
+	os.Exit(1)
+}
@@ -1,27 +1,79 @@
 package models
 
 import (
+	"Distributed-Health-Monitoring/clock"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gorilla/websocket"
-	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/codes"
+	"gorm.io/gorm"
 )
 
 // ExternalService represents a service to be monitored
 type ExternalService struct {
-	ID                  uint       `json:"id" gorm:"primaryKey;autoIncrement"`
-	Name                string     `json:"name" gorm:"type:varchar(255);not null;uniqueIndex"`
-	URL                 string     `json:"url" gorm:"type:varchar(500);not null"`
-	HTTPMethod          string     `json:"http_method" gorm:"type:varchar(10);not null;default:'GET'"`
-	Protocol            string     `json:"protocol" gorm:"type:varchar(10);not null;default:'HTTP'"`
-	Interval            int64      `json:"interval" gorm:"type:bigint;not null;default:60"` // check interval in seconds
-	TimeoutSeconds      int64      `json:"timeout_seconds" gorm:"type:bigint;not null;default:10"`
-	FailureThreshold    int64      `json:"failure_threshold" gorm:"type:bigint;not null;default:3"`    // consecutive failures before marking as down
-	Status              string     `json:"status" gorm:"type:varchar(20);not null;default:'up';index"` // "up" or "down"
-	ConsecutiveFailures int64      `json:"consecutive_failures" gorm:"type:bigint;not null;default:0"`
-	LastCheckedAt       *time.Time `json:"last_checked_at" gorm:"type:timestamp"`
-	CreatedAt           time.Time  `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt           time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+	ID                  uint                   `json:"id" gorm:"primaryKey;autoIncrement"`
+	Name                string                 `json:"name" gorm:"type:varchar(255);not null;uniqueIndex"`
+	URL                 string                 `json:"url" gorm:"type:varchar(500);not null"`
+	HTTPMethod          string                 `json:"http_method" gorm:"type:varchar(10);not null;default:'GET'"`
+	Protocol            string                 `json:"protocol" gorm:"type:varchar(10);not null;default:'HTTP'"`
+	Interval            int64                  `json:"interval" gorm:"type:bigint;not null;default:60"`     // check interval in seconds
+	Schedule            string                 `json:"schedule,omitempty" gorm:"type:varchar(100)"`         // cron expression run instead of the interval, e.g. "*/5 9-17 * * mon-fri"
+	ScheduleTimezone    string                 `json:"schedule_timezone,omitempty" gorm:"type:varchar(64)"` // IANA zone the schedule is read in; empty for UTC
+	TimeoutSeconds      int64                  `json:"timeout_seconds" gorm:"type:bigint;not null;default:10"`
+	FailureThreshold    int64                  `json:"failure_threshold" gorm:"type:bigint;not null;default:3"`         // consecutive failures before marking as down
+	Retries             int64                  `json:"retries" gorm:"type:bigint;not null;default:0"`                   // extra probe attempts within one check before it counts as failed
+	RetryDelayMs        int64                  `json:"retry_delay_ms" gorm:"type:bigint;not null;default:0"`            // pause between retry attempts
+	LatencyWarnMs       int64                  `json:"latency_warn_ms,omitempty" gorm:"type:bigint;not null;default:0"` // successful checks at least this slow are DEGRADED; 0 disables
+	LatencyCritMs       int64                  `json:"latency_crit_ms,omitempty" gorm:"type:bigint;not null;default:0"` // checks at least this slow count as failures; 0 disables
+	Status              string                 `json:"status" gorm:"type:varchar(20);not null;default:'PENDING';index"` // PENDING until the first result, then UP, DEGRADED or DOWN
+	ConsecutiveFailures int64                  `json:"consecutive_failures" gorm:"type:bigint;not null;default:0"`
+	Flapping            bool                   `json:"flapping" gorm:"not null;default:false"`                      // too many transitions in the flapping window
+	Acknowledgement     *Acknowledgement       `json:"acknowledgement,omitempty" gorm:"type:jsonb;serializer:json"` // an operator is on the outage; its alerts are held back
+	RootCause           string                 `json:"root_cause,omitempty" gorm:"-"`                               // presented copies only: the DOWN dependency blamed while this service is DOWN
+	LastCheckedAt       *time.Time             `json:"last_checked_at" gorm:"type:timestamp"`
+	NextRunAt           *time.Time             `json:"next_run_at" gorm:"type:timestamp"`                                       // set by the scheduler when it publishes a job
+	InFlightUntil       *time.Time             `json:"in_flight_until" gorm:"type:timestamp"`                                   // lease held while a job is outstanding, cleared by the worker
+	Assertions          []Assertion            `json:"assertions,omitempty" gorm:"type:jsonb;serializer:json"`                  // evaluated against the HTTP response body
+	SuccessCriteria     *SuccessCriteria       `json:"success_criteria,omitempty" gorm:"type:jsonb;serializer:json"`            // HTTP protocol: which responses are UP; default any status below 400
+	ProxyURL            string                 `json:"proxy_url,omitempty" gorm:"type:text;serializer:encrypted"`               // HTTP protocol: http, https or socks5 proxy for the check; encrypted at rest
+	ResolveOverride     map[string]string      `json:"resolve_override,omitempty" gorm:"type:jsonb;serializer:json"`            // HTTP protocol: host -> IP to connect to instead of resolving
+	SourceInterface     string                 `json:"source_interface,omitempty" gorm:"type:varchar(100)"`                     // HTTP protocol: local IP or interface name to connect from
+	Auth                *CheckAuth             `json:"auth,omitempty" gorm:"type:text;serializer:encrypted_json"`               // HTTP protocol: credentials sent with each check; encrypted at rest
+	GRPCServiceName     string                 `json:"grpc_service_name,omitempty" gorm:"type:varchar(255)"`                    // gRPC protocol: service whose health is checked; empty for the whole server
+	GRPCWatch           bool                   `json:"grpc_watch,omitempty" gorm:"not null;default:false"`                      // gRPC protocol: read the first status of Health/Watch instead of calling Check
+	GRPCRequireHealth   bool                   `json:"grpc_require_health,omitempty" gorm:"not null;default:false"`             // gRPC protocol: a server without grpc.health.v1 is DOWN instead of UP
+	GRPCTLS             *GRPCTLS               `json:"grpc_tls,omitempty" gorm:"type:jsonb;serializer:json"`                    // gRPC protocol: connect with TLS; plaintext when unset
+	DNSResolver         string                 `json:"dns_resolver,omitempty" gorm:"type:varchar(255)"`                         // DNS protocol: resolver host:port, empty for the system resolver
+	DNSRecordType       string                 `json:"dns_record_type,omitempty" gorm:"type:varchar(10)"`                       // DNS protocol: A, AAAA, CNAME or TXT
+	DNSExpected         []string               `json:"dns_expected,omitempty" gorm:"type:jsonb;serializer:json"`                // DNS protocol: values that must be among the answers
+	DatabaseDSN         string                 `json:"database_dsn,omitempty" gorm:"type:text;serializer:encrypted"`            // DATABASE protocol: postgres://, mysql:// or redis:// URL; encrypted at rest
+	DatabaseQuery       string                 `json:"database_query,omitempty" gorm:"type:varchar(1000)"`                      // DATABASE protocol: read-only query run after the ping, e.g. SELECT 1
+	Public              bool                   `json:"public" gorm:"not null;default:false"`                                    // listed on the unauthenticated status page
+	Tags                []string               `json:"tags,omitempty" gorm:"type:jsonb;serializer:json"`                        // team/environment labels used for group rollups
+	Owner               string                 `json:"owner,omitempty" gorm:"type:varchar(255)"`                                // person or contact answering for the service, e.g. an email or chat handle
+	Team                string                 `json:"team,omitempty" gorm:"type:varchar(100);index"`                           // owning team; list and stats endpoints filter on it
+	RunbookURL          string                 `json:"runbook_url,omitempty" gorm:"type:varchar(500)"`                          // what to do when it breaks
+	Description         string                 `json:"description,omitempty" gorm:"type:text"`                                  // what the service is, for a responder who doesn't know it
+	DependsOn           []string               `json:"depends_on,omitempty" gorm:"type:jsonb;serializer:json"`                  // names of the services this one needs; while one is DOWN this one's DOWN alerts are suppressed
+	Regions             []string               `json:"regions,omitempty" gorm:"type:jsonb;serializer:json"`                     // worker regions that must each check the service; empty for any worker
+	RegionQuorum        int64                  `json:"region_quorum,omitempty" gorm:"type:bigint;not null;default:0"`           // failing regions that make a check fail; 0 for a majority
+	LastRoundAt         *time.Time             `json:"last_round_at,omitempty" gorm:"type:timestamp"`                           // regional and confirmed checks: due time of the last round whose result was applied; shared because a service can't have both
+	Confirmation        *Confirmation          `json:"confirmation,omitempty" gorm:"type:jsonb;serializer:json"`                // re-checks a failure needs to pass before it counts toward the failure threshold
+	Metadata            map[string]interface{} `json:"metadata,omitempty" gorm:"type:jsonb;serializer:json"`                    // free-form details such as runbook_url or dashboard, repeated in alerts
+	SLO                 *SLO                   `json:"slo,omitempty" gorm:"type:jsonb;serializer:json"`                         // objectives evaluated over the check logs, with error budgets
+	Remediation         *Remediation           `json:"remediation,omitempty" gorm:"type:jsonb;serializer:json"`                 // self-healing action run after repeated failures
+	RemediationAttempts int64                  `json:"remediation_attempts,omitempty" gorm:"type:bigint;not null;default:0"`    // remediation runs in the current failure streak
+	LastRemediationAt   *time.Time             `json:"last_remediation_at,omitempty" gorm:"type:timestamp"`                     // start of the last remediation run; the cooldown counts from here
+	HeartbeatToken      *string                `json:"heartbeat_token,omitempty" gorm:"type:varchar(64);uniqueIndex"`           // HEARTBEAT protocol: secret in the ping URL
+	HeartbeatGrace      int64                  `json:"heartbeat_grace_seconds,omitempty" gorm:"type:bigint;not null;default:0"` // HEARTBEAT protocol: allowed lateness on top of the interval
+	LastHeartbeatAt     *time.Time             `json:"last_heartbeat_at,omitempty" gorm:"type:timestamp"`
+	CreatedAt           time.Time              `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt           time.Time              `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt           gorm.DeletedAt         `json:"-" gorm:"index"` // set by a delete; the service is hidden but its history stays until it is restored or purged
 }
 
 // ServiceCheckLog records the result of each health check
@@ -32,8 +84,573 @@ type ServiceCheckLog struct {
 	StatusCode        int             `json:"status_code" gorm:"type:int"`                          // HTTP status code
 	ResponseTimeMs    int64           `json:"response_time_ms" gorm:"type:bigint"`                  // response time in milliseconds
 	ErrorMessage      string          `json:"error_message,omitempty" gorm:"type:text"`
+	Region            string          `json:"region,omitempty" gorm:"type:varchar(50)"` // region of the worker that ran the check
 	CheckedAt         time.Time       `json:"checked_at" gorm:"type:timestamp;not null;index:idx_service_time"`
 	ExternalService   ExternalService `json:"-" gorm:"foreignKey:ExternalServiceID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+
+	CheckTimings `gorm:"embedded"`
+}
+
+// CheckTimings breaks an HTTP check down by phase. A phase is nil when it
+// didn't happen: the connection phases are skipped when a kept-alive
+// connection is reused. DATABASE checks record connect, query and total;
+// other protocols record none.
+type CheckTimings struct {
+	DNSMs     *int64 `json:"dns_ms,omitempty" gorm:"type:bigint"`     // name resolution
+	ConnectMs *int64 `json:"connect_ms,omitempty" gorm:"type:bigint"` // TCP connect
+	TLSMs     *int64 `json:"tls_ms,omitempty" gorm:"type:bigint"`     // TLS handshake
+	TTFBMs    *int64 `json:"ttfb_ms,omitempty" gorm:"type:bigint"`    // from getting a connection to the first response byte
+	TotalMs   *int64 `json:"total_ms,omitempty" gorm:"type:bigint"`   // whole request, including reading the body
+	QueryMs   *int64 `json:"query_ms,omitempty" gorm:"type:bigint"`   // DATABASE: ping and query on the open connection
+}
+
+// Acknowledgement is an operator taking on a failing service. Until it
+// expires, or the service is UP again, the service's alerts are held back;
+// checks and logs go on.
+type Acknowledgement struct {
+	By        string    `json:"by"`
+	Note      string    `json:"note,omitempty"`
+	At        time.Time `json:"at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Active reports whether the acknowledgement still holds at t
+func (a *Acknowledgement) Active(t time.Time) bool {
+	return a != nil && t.Before(a.ExpiresAt)
+}
+
+// Acknowledgement trail actions
+const (
+	AckAcknowledged = "acknowledged"
+	AckCleared      = "cleared"   // removed by an operator
+	AckRecovered    = "recovered" // ended by the service coming back UP
+)
+
+// ServiceAcknowledgement records an acknowledgement of a service, or its end
+type ServiceAcknowledgement struct {
+	ID                uint            `json:"id" gorm:"primaryKey;autoIncrement"`
+	ExternalServiceID uint            `json:"external_service_id" gorm:"not null;index"`
+	Action            string          `json:"action" gorm:"type:varchar(20);not null"` // acknowledged, cleared, recovered
+	By                string          `json:"by" gorm:"type:varchar(255);not null"`
+	Note              string          `json:"note,omitempty" gorm:"type:text"`
+	Status            string          `json:"status" gorm:"type:varchar(20)"` // service status at the time
+	ExpiresAt         *time.Time      `json:"expires_at,omitempty" gorm:"type:timestamp"`
+	CreatedAt         time.Time       `json:"created_at" gorm:"autoCreateTime"`
+	ExternalService   ExternalService `json:"-" gorm:"foreignKey:ExternalServiceID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// ServiceStateTransition records one change of a service's status, so
+// outage timelines don't need a scan of the check logs
+type ServiceStateTransition struct {
+	ID                uint            `json:"id" gorm:"primaryKey;autoIncrement"`
+	ExternalServiceID uint            `json:"external_service_id" gorm:"not null;index:idx_transition_service_time"`
+	From              string          `json:"from" gorm:"column:from_status;type:varchar(20);not null"`
+	To                string          `json:"to" gorm:"column:to_status;type:varchar(20);not null"`
+	Reason            string          `json:"reason,omitempty" gorm:"type:varchar(50)"`                                                      // on DOWN and DEGRADED: unreachable, http_status, latency, ...
+	TriggerLogID      *uint           `json:"trigger_log_id"`                                                                                // the check log that caused it; null when the log store assigns no ids
+	Suppressed        string          `json:"suppressed,omitempty" gorm:"type:varchar(20)"`                                                  // why no alert was sent: maintenance, flapping, acknowledged or dependency
+	ChangedAt         time.Time       `json:"changed_at" gorm:"column:changed_at;type:timestamp;not null;index:idx_transition_service_time"` // checked_at of the trigger log
+	ExternalService   ExternalService `json:"-" gorm:"foreignKey:ExternalServiceID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// MaintenanceWindow silences DOWN alerts for a service while it is active.
+// Checks keep running and logging during the window.
+type MaintenanceWindow struct {
+	ID                uint            `json:"id" gorm:"primaryKey;autoIncrement"`
+	ExternalServiceID uint            `json:"external_service_id" gorm:"not null;index"`
+	StartsAt          time.Time       `json:"starts_at" gorm:"type:timestamp;not null"`
+	EndsAt            time.Time       `json:"ends_at" gorm:"type:timestamp;not null"`
+	Recurrence        string          `json:"recurrence" gorm:"type:varchar(10);not null;default:'none'"` // none, daily, weekly
+	Until             *time.Time      `json:"until,omitempty" gorm:"type:timestamp"`                      // last occurrence start for recurring windows
+	Reason            string          `json:"reason,omitempty" gorm:"type:text"`
+	CreatedAt         time.Time       `json:"created_at" gorm:"autoCreateTime"`
+	ExternalService   ExternalService `json:"-" gorm:"foreignKey:ExternalServiceID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// Incident spans the time a service spent DOWN, from the transition to DOWN until it recovers
+type Incident struct {
+	ID                uint            `json:"id" gorm:"primaryKey;autoIncrement"`
+	ExternalServiceID uint            `json:"external_service_id" gorm:"not null;index:idx_incident_service_status"`
+	Status            string          `json:"status" gorm:"type:varchar(20);not null;default:'open';index:idx_incident_service_status"` // open, resolved
+	Reason            string          `json:"reason,omitempty" gorm:"type:varchar(50)"`                                                 // reason of the check that opened it
+	Cause             string          `json:"cause,omitempty" gorm:"type:text"`                                                         // error message of that check
+	StartedAt         time.Time       `json:"started_at" gorm:"type:timestamp;not null"`
+	ResolvedAt        *time.Time      `json:"resolved_at,omitempty" gorm:"type:timestamp"`
+	AcknowledgedAt    *time.Time      `json:"acknowledged_at,omitempty" gorm:"type:timestamp"` // stops escalation
+	AcknowledgedBy    string          `json:"acknowledged_by,omitempty" gorm:"type:varchar(255)"`
+	EscalationLevel   int             `json:"escalation_level" gorm:"not null;default:0"` // escalation steps fired so far
+	CreatedAt         time.Time       `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt         time.Time       `json:"updated_at" gorm:"autoUpdateTime"`
+	ExternalService   ExternalService `json:"-" gorm:"foreignKey:ExternalServiceID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// IncidentEscalation records one escalation step fired for an incident
+type IncidentEscalation struct {
+	ID         uint              `json:"id" gorm:"primaryKey;autoIncrement"`
+	IncidentID uint              `json:"incident_id" gorm:"not null;index"`
+	Policy     string            `json:"policy" gorm:"type:varchar(255);not null"`
+	Step       int               `json:"step" gorm:"not null"`                      // zero-based index in the policy
+	Results    map[string]string `json:"results" gorm:"type:jsonb;serializer:json"` // notifier id -> "ok" or the delivery error
+	FiredAt    time.Time         `json:"fired_at" gorm:"type:timestamp;not null"`
+	Incident   Incident          `json:"-" gorm:"foreignKey:IncidentID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// RegionResult is the latest check of a service from one region
+type RegionResult struct {
+	ExternalServiceID uint            `json:"external_service_id" gorm:"primaryKey;autoIncrement:false"`
+	Region            string          `json:"region" gorm:"primaryKey;type:varchar(50)"`
+	RoundAt           time.Time       `json:"round_at" gorm:"type:timestamp;not null"` // due time shared by the jobs of one round
+	Status            string          `json:"status" gorm:"type:varchar(20);not null"`
+	Success           bool            `json:"success" gorm:"not null"`
+	StatusCode        int             `json:"status_code,omitempty"`
+	LatencyMs         int64           `json:"latency_ms"`
+	Reason            string          `json:"reason,omitempty" gorm:"type:varchar(50)"`
+	Error             string          `json:"error,omitempty" gorm:"type:text"`
+	CheckedAt         time.Time       `json:"checked_at" gorm:"type:timestamp;not null"`
+	ExternalService   ExternalService `json:"-" gorm:"foreignKey:ExternalServiceID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// ConfirmationResult is one vote on a failed check of a service that
+// confirms its failures: the failure itself (recheck 0) or a re-check
+type ConfirmationResult struct {
+	ExternalServiceID uint            `json:"external_service_id" gorm:"primaryKey;autoIncrement:false"`
+	RoundAt           time.Time       `json:"round_at" gorm:"primaryKey;type:timestamp"` // due time of the failed check
+	Recheck           int             `json:"recheck" gorm:"primaryKey;autoIncrement:false"`
+	Region            string          `json:"region,omitempty" gorm:"type:varchar(50)"` // worker region that ran it
+	Status            string          `json:"status" gorm:"type:varchar(20);not null"`
+	Success           bool            `json:"success" gorm:"not null"`
+	StatusCode        int             `json:"status_code,omitempty"`
+	LatencyMs         int64           `json:"latency_ms"`
+	Reason            string          `json:"reason,omitempty" gorm:"type:varchar(50)"`
+	Error             string          `json:"error,omitempty" gorm:"type:text"`
+	CheckedAt         time.Time       `json:"checked_at" gorm:"type:timestamp;not null"`
+	ExternalService   ExternalService `json:"-" gorm:"foreignKey:ExternalServiceID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// Webhook is an endpoint registered to receive signed event payloads
+type Webhook struct {
+	ID        uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	URL       string    `json:"url" gorm:"type:varchar(500);not null"`
+	Secret    string    `json:"secret,omitempty" gorm:"type:text;not null;serializer:encrypted"` // HMAC key for the signature header; encrypted at rest
+	Events    []string  `json:"events" gorm:"type:jsonb;serializer:json"`                        // event types to deliver; empty for all
+	Tags      []string  `json:"tags,omitempty" gorm:"type:jsonb;serializer:json"`                // only events of services with one of these tags; empty for all
+	Enabled   bool      `json:"enabled" gorm:"not null;default:true"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// AuditLog records one configuration change: who made it, when, and the
+// fields it changed. Rows outlive the entity they describe.
+type AuditLog struct {
+	ID         uint                   `json:"id" gorm:"primaryKey;autoIncrement"`
+	Actor      string                 `json:"actor" gorm:"type:varchar(255);not null;index"` // principal subject, or system:<component>
+	ActorName  string                 `json:"actor_name,omitempty" gorm:"type:varchar(255)"`
+	Role       string                 `json:"role,omitempty" gorm:"type:varchar(20)"`
+	AuthMethod string                 `json:"auth_method" gorm:"type:varchar(20);not null"` // basic, session, bearer or system
+	Action     string                 `json:"action" gorm:"type:varchar(20);not null"`      // create, update, delete
+	Entity     string                 `json:"entity" gorm:"type:varchar(50);not null;index:idx_audit_entity"`
+	EntityID   uint                   `json:"entity_id" gorm:"not null;index:idx_audit_entity"`
+	EntityName string                 `json:"entity_name,omitempty" gorm:"type:varchar(255)"`
+	Changes    map[string]AuditChange `json:"changes" gorm:"type:jsonb;serializer:json"` // by JSON field name, secrets redacted
+	Reason     string                 `json:"reason,omitempty" gorm:"type:text"`
+	RequestID  string                 `json:"request_id,omitempty" gorm:"type:varchar(64)"`
+	CreatedAt  time.Time              `json:"created_at" gorm:"autoCreateTime;index"`
+}
+
+// AuditChange is one field's value before and after a change; From is null
+// on create and To on delete
+type AuditChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// AuditFilter selects audit log entries; zero fields match everything
+type AuditFilter struct {
+	Entity   string
+	EntityID uint
+	Actor    string
+	Limit    int
+	Offset   int
+}
+
+// SLO is a service level objective over the last WindowDays: a share of
+// checks that succeed, a latency percentile, or both. The checks allowed to
+// miss an objective are its error budget.
+type SLO struct {
+	SuccessTarget      float64 `json:"success_target,omitempty"`       // percent of checks that succeed, e.g. 99.9
+	LatencyPercentile  float64 `json:"latency_percentile,omitempty"`   // e.g. 95: that percent of successful checks answer within latency_threshold_ms
+	LatencyThresholdMs int64   `json:"latency_threshold_ms,omitempty"` // required with latency_percentile
+	WindowDays         int     `json:"window_days,omitempty"`          // default 30
+}
+
+// Remediation is the action run when a service keeps failing. Only the
+// fields of its type are used.
+type Remediation struct {
+	Type            string `json:"type"`                       // webhook, command or kubernetes
+	URL             string `json:"url,omitempty"`              // webhook: receives a POST with the service and failure count
+	Command         string `json:"command,omitempty"`          // command: name of a command in remediation.commands
+	Namespace       string `json:"namespace,omitempty"`        // kubernetes
+	Deployment      string `json:"deployment,omitempty"`       // kubernetes: restarted like kubectl rollout restart
+	AfterFailures   int64  `json:"after_failures,omitempty"`   // consecutive failures before the first run; default the failure threshold
+	CooldownSeconds int64  `json:"cooldown_seconds,omitempty"` // minimum time between runs; default 300
+	MaxAttempts     int64  `json:"max_attempts,omitempty"`     // runs per failure streak; default 3
+}
+
+const (
+	RemediationWebhook    = "webhook"
+	RemediationCommand    = "command"
+	RemediationKubernetes = "kubernetes"
+)
+
+// RemediationRun records one execution of a service's remediation action
+type RemediationRun struct {
+	ID                  uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	ExternalServiceID   uint      `json:"external_service_id" gorm:"not null;index:idx_remediation_service_time"`
+	ServiceName         string    `json:"service_name" gorm:"type:varchar(255);not null"`
+	Type                string    `json:"type" gorm:"type:varchar(20);not null"`
+	Target              string    `json:"target" gorm:"type:varchar(500);not null"` // webhook URL, command name or namespace/deployment
+	Attempt             int64     `json:"attempt" gorm:"not null"`                  // 1 for the first run of a failure streak
+	ConsecutiveFailures int64     `json:"consecutive_failures" gorm:"not null"`
+	Success             bool      `json:"success" gorm:"not null"`
+	Error               string    `json:"error,omitempty" gorm:"type:text"`
+	Output              string    `json:"output,omitempty" gorm:"type:text"` // command output or response body, truncated
+	DurationMs          int64     `json:"duration_ms"`
+	StartedAt           time.Time `json:"started_at" gorm:"type:timestamp;not null;index:idx_remediation_service_time"`
+}
+
+// WebhookDelivery records one attempt to deliver an event to a webhook
+type WebhookDelivery struct {
+	ID          uint       `json:"id" gorm:"primaryKey;autoIncrement"`
+	WebhookID   uint       `json:"webhook_id" gorm:"not null;index:idx_delivery_webhook_time"`
+	DeliveryID  string     `json:"delivery_id" gorm:"type:varchar(32);not null;index"` // shared by the retries of one event, sent as X-Monitor-Delivery
+	Event       string     `json:"event" gorm:"type:varchar(50);not null"`
+	Attempt     int        `json:"attempt" gorm:"not null"` // 1 for the first try
+	Success     bool       `json:"success" gorm:"not null"`
+	StatusCode  int        `json:"status_code,omitempty"`
+	Error       string     `json:"error,omitempty" gorm:"type:text"`
+	DurationMs  int64      `json:"duration_ms"`
+	NextRetryAt *time.Time `json:"next_retry_at,omitempty" gorm:"type:timestamp"` // unset after a success or the last attempt
+	AttemptedAt time.Time  `json:"attempted_at" gorm:"type:timestamp;not null;index:idx_delivery_webhook_time"`
+	Webhook     Webhook    `json:"-" gorm:"foreignKey:WebhookID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// LastResponse is the most recent raw HTTP response of a service, bounded so
+// one row per service stays small
+type LastResponse struct {
+	ExternalServiceID uint                `json:"external_service_id" gorm:"primaryKey;autoIncrement:false"`
+	StatusLine        string              `json:"status_line,omitempty" gorm:"type:varchar(255)"` // e.g. "HTTP/1.1 503 Service Unavailable"
+	Headers           map[string][]string `json:"headers,omitempty" gorm:"type:jsonb;serializer:json"`
+	BodySnippet       string              `json:"body_snippet,omitempty" gorm:"type:text"`
+	BodyTruncated     bool                `json:"body_truncated" gorm:"not null;default:false"`
+	Error             string              `json:"error,omitempty" gorm:"type:text"` // transport error when there was no response
+	LatencyMs         int64               `json:"latency_ms" gorm:"type:bigint"`
+	CheckedAt         time.Time           `json:"checked_at" gorm:"type:timestamp;not null"`
+	ExternalService   ExternalService     `json:"-" gorm:"foreignKey:ExternalServiceID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// ServiceArchive is the snapshot written when a service is deleted, kept as
+// SLA evidence after its logs and incidents are gone
+type ServiceArchive struct {
+	ID         uint                  `json:"id" gorm:"primaryKey;autoIncrement"`
+	ServiceID  uint                  `json:"service_id" gorm:"not null;index"` // id the service had; no foreign key
+	Name       string                `json:"name" gorm:"type:varchar(255);not null;index"`
+	Config     ExternalService       `json:"config" gorm:"type:jsonb;serializer:json"`
+	Uptime     map[string]UptimeStat `json:"uptime" gorm:"type:jsonb;serializer:json"` // 24h, 7d, 30d, 90d and lifetime
+	Incidents  []Incident            `json:"incidents" gorm:"type:jsonb;serializer:json"`
+	Reason     string                `json:"reason,omitempty" gorm:"type:text"`
+	DeletedAt  time.Time             `json:"deleted_at" gorm:"type:timestamp;not null"`
+	RestoredAt *time.Time            `json:"restored_at,omitempty" gorm:"type:timestamp"` // the service was brought back after this deletion
+}
+
+// SchedulerControl is the single row holding whether the scheduler may publish
+// jobs. It lives in the database so a pause survives restarts and leader changes.
+type SchedulerControl struct {
+	ID        uint      `json:"-" gorm:"primaryKey;autoIncrement:false"`                 // always 1
+	Mode      string    `json:"mode" gorm:"type:varchar(20);not null;default:'running'"` // running, paused or draining
+	Reason    string    `json:"reason,omitempty" gorm:"type:text"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"type:timestamp"`
+}
+
+// ProcessedJob records that a worker took a health check job, so a
+// redelivered or duplicated message isn't checked twice. Rows are pruned
+// once the job would be dropped as stale anyway.
+type ProcessedJob struct {
+	JobID             string    `json:"job_id" gorm:"primaryKey;type:varchar(64)"`
+	ExternalServiceID uint      `json:"external_service_id"`
+	ProcessedAt       time.Time `json:"processed_at" gorm:"type:timestamp"`
+	ExpiresAt         time.Time `json:"expires_at" gorm:"type:timestamp;index"`
+}
+
+const (
+	SchedulerRunning  = "running"
+	SchedulerPaused   = "paused"
+	SchedulerDraining = "draining"
+)
+
+// UptimeStat summarises check results over a period
+type UptimeStat struct {
+	Checks        int64   `json:"checks"`
+	SuccessChecks int64   `json:"success_checks"`
+	UptimePercent float64 `json:"uptime_percent"` // 100 when there were no checks
+}
+
+// CheckLogBucket aggregates the checks of one service in one time bucket.
+// Latencies are of the available (UP or DEGRADED) checks; the percentiles
+// are nil when there were none.
+type CheckLogBucket struct {
+	Start      time.Time `json:"start"`
+	Checks     int64     `json:"checks"`
+	Available  int64     `json:"available"`
+	Degraded   int64     `json:"degraded"`
+	LatencySum int64     `json:"latency_sum"`
+	LatencyP95 *float64  `json:"latency_p95"`
+	LatencyP99 *float64  `json:"latency_p99"`
+}
+
+// ConsistencyReport lists state that contradicts itself, e.g. after a crash
+// or a manual database edit, and what was repaired
+type ConsistencyReport struct {
+	Issues    []ConsistencyIssue `json:"issues"`
+	Repaired  int                `json:"repaired"`
+	CheckedAt time.Time          `json:"checked_at"`
+}
+
+type ConsistencyIssue struct {
+	Check     string `json:"check"` // status_failures_mismatch, open_incident_for_available_service, down_without_incident, orphaned_logs
+	ServiceID uint   `json:"service_id"`
+	Service   string `json:"service,omitempty"`
+	Detail    string `json:"detail"`
+	Repair    string `json:"repair"` // what repairing does or did
+	Repaired  bool   `json:"repaired"`
+	Error     string `json:"error,omitempty"` // why the repair failed
+}
+
+// DBHealthReport is the result of verifying indexes, bloat and query plans
+type DBHealthReport struct {
+	Indexes   []IndexStatus `json:"indexes"`
+	Tables    []TableHealth `json:"tables"`
+	Plans     []PlanCheck   `json:"plans"`
+	Warnings  []string      `json:"warnings"`
+	CheckedAt time.Time     `json:"checked_at"`
+}
+
+type IndexStatus struct {
+	Table   string   `json:"table"`
+	Name    string   `json:"name"`
+	Columns []string `json:"columns"`
+	Exists  bool     `json:"exists"`
+	Created bool     `json:"created"`
+	Error   string   `json:"error,omitempty"`
+}
+
+type TableHealth struct {
+	Table       string     `json:"table"`
+	LiveRows    int64      `json:"live_rows"`
+	DeadRows    int64      `json:"dead_rows"`
+	DeadPercent float64    `json:"dead_percent"`
+	TotalBytes  int64      `json:"total_bytes"`
+	LastVacuum  *time.Time `json:"last_vacuum"`
+}
+
+// PlanCheck records whether a hot query is served by an index
+type PlanCheck struct {
+	Query   string `json:"query"`
+	SeqScan bool   `json:"seq_scan"`
+	Plan    string `json:"plan"` // top plan node, e.g. "Index Scan using idx_service_time"
+}
+
+// Bench run statuses
+const (
+	BenchRunning   = "running"
+	BenchCompleted = "completed"
+	BenchFailed    = "failed"
+)
+
+// BenchRun is a load test of the check pipeline: synthetic services are
+// checked against a local mock target through the configured queue and
+// database, rounds times each, all published at once
+type BenchRun struct {
+	ID              string `json:"id"`
+	Status          string `json:"status"` // running, completed, failed
+	Error           string `json:"error,omitempty"`
+	Queue           string `json:"queue"` // queue driver the jobs went through
+	Services        int    `json:"services"`
+	Rounds          int    `json:"rounds"`
+	IntervalSeconds int64  `json:"interval_seconds"`
+	TargetLatencyMs int64  `json:"target_latency_ms"`
+
+	Published     int     `json:"published"`
+	Completed     int     `json:"completed"`
+	Failed        int     `json:"failed"`      // jobs that couldn't be processed or whose check didn't pass
+	DurationMs    int64   `json:"duration_ms"` // from the first publish to the last job done
+	JobsPerSecond float64 `json:"jobs_per_second"`
+	// RequiredJobsPerSecond is what the services need when each is checked
+	// once per interval_seconds
+	RequiredJobsPerSecond float64 `json:"required_jobs_per_second"`
+
+	QueueLatency    BenchLatency `json:"queue_latency"`      // publish to a worker taking the job
+	CheckLatency    BenchLatency `json:"check_latency"`      // probe of the mock target
+	DBWriteLatency  BenchLatency `json:"db_write_latency"`   // job claim, check log and state update
+	EndToEndLatency BenchLatency `json:"end_to_end_latency"` // publish to acknowledgement
+
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// BenchLatency summarises the latencies of the jobs of a bench run
+type BenchLatency struct {
+	P50Ms float64 `json:"p50_ms"`
+	P95Ms float64 `json:"p95_ms"`
+	P99Ms float64 `json:"p99_ms"`
+	MaxMs float64 `json:"max_ms"`
+}
+
+// CheckAuth holds the credentials an HTTP check sends. Only the fields of
+// its type are used.
+type CheckAuth struct {
+	Type         string   `json:"type"`                    // basic, bearer or oauth2
+	Username     string   `json:"username,omitempty"`      // basic
+	Password     string   `json:"password,omitempty"`      // basic
+	Token        string   `json:"token,omitempty"`         // bearer
+	TokenURL     string   `json:"token_url,omitempty"`     // oauth2: client credentials token endpoint
+	ClientID     string   `json:"client_id,omitempty"`     // oauth2
+	ClientSecret string   `json:"client_secret,omitempty"` // oauth2
+	Scopes       []string `json:"scopes,omitempty"`        // oauth2
+}
+
+const (
+	AuthBasic  = "basic"
+	AuthBearer = "bearer"
+	AuthOAuth2 = "oauth2"
+)
+
+// Assertion is a response-content check evaluated after a successful HTTP probe
+type Assertion struct {
+	Type     string `json:"type"`           // body_contains, json_path
+	Path     string `json:"path,omitempty"` // JSONPath subset for json_path, e.g. $.status or $.checks[0].ok
+	Expected string `json:"expected"`
+}
+
+// GRPCTLS is how a gRPC check verifies the server's certificate
+type GRPCTLS struct {
+	ServerName         string `json:"server_name,omitempty"`          // name verified instead of the host of the url
+	CACert             string `json:"ca_cert,omitempty"`              // PEM roots instead of the system pool
+	InsecureSkipVerify bool   `json:"insecure_skip_verify,omitempty"` // accept any certificate
+}
+
+// SuccessCriteria decides which HTTP responses count as UP. Reject wins
+// over Accept, and both over StatusCodes.
+type SuccessCriteria struct {
+	StatusCodes     []string `json:"status_codes,omitempty"`     // codes and ranges such as "200-299" or "401"; default "100-399"
+	Accept          []int    `json:"accept,omitempty"`           // codes that are UP even outside status_codes
+	Reject          []int    `json:"reject,omitempty"`           // codes that are DOWN even inside status_codes
+	FollowRedirects *bool    `json:"follow_redirects,omitempty"` // default true; false judges the redirect response itself
+	MaxRedirects    int      `json:"max_redirects,omitempty"`    // hops followed before the check fails; default 10
+}
+
+// DefaultMaxRedirects is how many redirects a check follows by default
+const DefaultMaxRedirects = 10
+
+// Succeeds reports whether a response with the status code counts as UP
+func (c *SuccessCriteria) Succeeds(code int) bool {
+	if c == nil {
+		return code < 400
+	}
+	if slices.Contains(c.Reject, code) {
+		return false
+	}
+	if slices.Contains(c.Accept, code) {
+		return true
+	}
+	if len(c.StatusCodes) == 0 {
+		return code < 400
+	}
+	for _, r := range c.StatusCodes {
+		if lo, hi, err := ParseStatusRange(r); err == nil && code >= lo && code <= hi {
+			return true
+		}
+	}
+	return false
+}
+
+// Redirects returns whether redirects are followed, and how many at most
+func (c *SuccessCriteria) Redirects() (bool, int) {
+	if c == nil {
+		return true, DefaultMaxRedirects
+	}
+	max := c.MaxRedirects
+	if max == 0 {
+		max = DefaultMaxRedirects
+	}
+	return c.FollowRedirects == nil || *c.FollowRedirects, max
+}
+
+// ParseStatusRange parses "401" or "200-299" into an inclusive range
+func ParseStatusRange(s string) (int, int, error) {
+	from, to, isRange := strings.Cut(strings.TrimSpace(s), "-")
+	lo, err := strconv.Atoi(strings.TrimSpace(from))
+	if err != nil {
+		return 0, 0, fmt.Errorf("status code %q is not a number or range", s)
+	}
+	hi := lo
+	if isRange {
+		if hi, err = strconv.Atoi(strings.TrimSpace(to)); err != nil {
+			return 0, 0, fmt.Errorf("status code %q is not a number or range", s)
+		}
+	}
+	if lo < 100 || hi > 599 || lo > hi {
+		return 0, 0, fmt.Errorf("status code %q must be within 100-599, low to high", s)
+	}
+	return lo, hi, nil
+}
+
+// AssertionFailure describes the first assertion that failed during a check
+type AssertionFailure struct {
+	Assertion        Assertion `json:"assertion"`
+	Expected         string    `json:"expected"`
+	Actual           string    `json:"actual"`
+	ResponseSnapshot string    `json:"response_snapshot"`
+}
+
+// CheckResult is the outcome of a single health check of an external service
+type CheckResult struct {
+	Status           string
+	StatusCode       int
+	LatencyMs        int64
+	ErrorMessage     string
+	Success          bool
+	Reason           string // assertion_failed, http_status, unreachable, latency
+	AssertionFailure *AssertionFailure
+	Attempts         int           // probes made, including retries
+	CertExpiresAt    *time.Time    // HTTPS: expiry of the leaf certificate the target presented
+	Response         *LastResponse // raw HTTP response of the final attempt
+	Timings          CheckTimings  // HTTP: phases of the final attempt
+}
+
+// CheckLogFilter is a structured query over service check logs.
+// Every set field narrows the result; empty fields are ignored.
+type CheckLogFilter struct {
+	ServiceIDs    []uint     `json:"service_ids"`
+	Statuses      []string   `json:"status"`         // status IN (...)
+	StatusCodes   []int      `json:"status_codes"`   // status_code IN (...)
+	LatencyGtMs   *int64     `json:"latency_gt_ms"`  // response_time_ms > X
+	LatencyLtMs   *int64     `json:"latency_lt_ms"`  // response_time_ms < X
+	ErrorContains string     `json:"error_contains"` // error_message LIKE %X%
+	Regions       []string   `json:"regions"`        // region IN (...); "" matches checks without a region
+	From          *time.Time `json:"from"`           // checked_at >= from
+	To            *time.Time `json:"to"`             // checked_at < to
+	Order         string     `json:"order"`          // asc or desc (default)
+	Limit         int        `json:"limit"`
+	Offset        int        `json:"offset"`
+}
+
+// TransitionFilter selects the state transitions of one service
+type TransitionFilter struct {
+	ServiceID uint
+	From      *time.Time // changed_at >= from
+	To        *time.Time // changed_at < to
+	Order     string     // asc or desc (default)
+	Limit     int
+	Offset    int
 }
 
 type StateChange struct {
@@ -41,9 +658,16 @@ type StateChange struct {
 	To   string
 }
 
+// Resolved reports whether the change ends an outage, which resolves its
+// incident and its page
+func (c StateChange) Resolved() bool {
+	return c.From == "DOWN" && c.To != "DOWN"
+}
+
 type Client struct {
-	Conn *websocket.Conn
-	Send chan []byte
+	Conn    *websocket.Conn
+	Send    chan []byte
+	Subject string // authenticated caller, empty for anonymous connections
 }
 
 type ServiceStateChangeEvent struct {
@@ -53,13 +677,74 @@ type ServiceStateChangeEvent struct {
 	From      string    `json:"from"`
 	To        string    `json:"to"`
 	Timestamp time.Time `json:"timestamp"`
+
+	Severity         string            `json:"severity,omitempty"`        // info, warning, critical
+	Maintenance      bool              `json:"maintenance,omitempty"`     // transition happened inside a maintenance window
+	Acknowledgement  *Acknowledgement  `json:"acknowledgement,omitempty"` // active acknowledgement; no alert was sent
+	RootCause        string            `json:"root_cause,omitempty"`      // DOWN dependency blamed for a DOWN transition; no alert was sent
+	Reason           string            `json:"reason,omitempty"`          // assertion_failed, http_status, unreachable, latency
+	Error            string            `json:"error,omitempty"`
+	AssertionFailure *AssertionFailure `json:"assertion_failure,omitempty"`
+	Ownership        *Ownership        `json:"ownership,omitempty"` // who to call; nil when the service has no owner, team, runbook or description
+}
+
+// ServiceFlappingEvent is broadcast once when a service starts or stops flapping
+type ServiceFlappingEvent struct {
+	Type          string    `json:"type"` // service_flapping_start, service_flapping_end
+	ServiceID     uint      `json:"service_id"`
+	Name          string    `json:"name"`
+	Status        string    `json:"status"`
+	Transitions   int       `json:"transitions"`
+	WindowSeconds int64     `json:"window_seconds"`
+	Severity      string    `json:"severity,omitempty"`
+	Timestamp     time.Time `json:"timestamp"`
+}
+
+// SchedulerLagEvent is broadcast when checks fall behind schedule and when
+// they have caught up again
+type SchedulerLagEvent struct {
+	Type           string    `json:"type"`              // scheduler_lagging, scheduler_caught_up
+	Service        string    `json:"service,omitempty"` // the most behind service
+	MaxLagSeconds  float64   `json:"max_lag_seconds"`
+	Lagging        int       `json:"lagging"` // services behind by more than lag_warn_seconds
+	LagWarnSeconds float64   `json:"lag_warn_seconds"`
+	QueueDepth     *int      `json:"queue_depth"` // null when the queue can't be inspected
+	Timestamp      time.Time `json:"timestamp"`
+}
+
+// ServiceAcknowledgementEvent is broadcast when a service is acknowledged or
+// the acknowledgement ends
+type ServiceAcknowledgementEvent struct {
+	Type            string           `json:"type"` // service_acknowledged, service_acknowledgement_cleared
+	ServiceID       uint             `json:"service_id"`
+	Name            string           `json:"name"`
+	Status          string           `json:"status"`
+	Acknowledgement *Acknowledgement `json:"acknowledgement,omitempty"` // the new one when acknowledged
+	By              string           `json:"by,omitempty"`              // who cleared it; "system" on recovery
+	Timestamp       time.Time        `json:"timestamp"`
 }
 
 type GRPCHealthResult struct {
-	IsHealthy  bool
-	Latency    time.Duration
-	StatusCode connectivity.State // IDLE, CONNECTING, READY, TRANSIENT_FAILURE, SHUTDOWN
-	Error      error
+	IsHealthy     bool
+	Latency       time.Duration
+	StatusCode    codes.Code // status of the health call; OK when the server answered
+	ServingStatus string     // SERVING, NOT_SERVING, SERVICE_UNKNOWN or UNKNOWN once the server answered
+	Error         error
+}
+
+type DNSCheckResult struct {
+	IsHealthy bool
+	Latency   time.Duration
+	Records   []string
+	Error     error
+}
+
+type DatabaseCheckResult struct {
+	IsHealthy bool
+	Connect   time.Duration // opening and authenticating the connection
+	Query     time.Duration // the driver ping plus the query, when one is set
+	Stage     string        // connect or query: where a failed check stopped
+	Error     error
 }
 
 // TableName specifies the table name for ExternalService
@@ -72,23 +757,188 @@ func (ServiceCheckLog) TableName() string {
 	return "service_check_logs"
 }
 
+// TableName specifies the table name for MaintenanceWindow
+func (MaintenanceWindow) TableName() string {
+	return "maintenance_windows"
+}
+
+// TableName specifies the table name for Incident
+func (Incident) TableName() string {
+	return "incidents"
+}
+
+// TableName specifies the table name for IncidentEscalation
+func (IncidentEscalation) TableName() string {
+	return "incident_escalations"
+}
+
+// TableName specifies the table name for LastResponse
+func (LastResponse) TableName() string {
+	return "last_responses"
+}
+
+// TableName specifies the table name for ServiceArchive
+func (ServiceArchive) TableName() string {
+	return "service_archives"
+}
+
+// TableName specifies the table name for SchedulerControl
+func (SchedulerControl) TableName() string {
+	return "scheduler_controls"
+}
+
+// ActiveAt reports whether the window (or one of its recurrences) covers t
+func (w *MaintenanceWindow) ActiveAt(t time.Time) bool {
+	if t.Before(w.StartsAt) {
+		return false
+	}
+
+	duration := w.EndsAt.Sub(w.StartsAt)
+
+	var period time.Duration
+	switch w.Recurrence {
+	case "daily":
+		period = 24 * time.Hour
+	case "weekly":
+		period = 7 * 24 * time.Hour
+	default:
+		return t.Before(w.EndsAt)
+	}
+
+	elapsed := t.Sub(w.StartsAt)
+	occurrenceStart := w.StartsAt.Add(elapsed - elapsed%period)
+	if w.Until != nil && occurrenceStart.After(*w.Until) {
+		return false
+	}
+
+	return elapsed%period < duration
+}
+
+// StatusPending is the state of a registered service that has no check result yet
+const StatusPending = "PENDING"
+
+// StatusDegraded is a service that answers correctly but slower than its latency_warn_ms
+const StatusDegraded = "DEGRADED"
+
+// IsAvailable reports whether a check status counts towards uptime. A
+// DEGRADED service is slow but still serving.
+func IsAvailable(status string) bool {
+	return status == "UP" || status == StatusDegraded
+}
+
+// Ownership is who runs a service and where its runbook is, repeated in
+// its state change events and alerts so the responder knows whom to call
+type Ownership struct {
+	Owner       string `json:"owner,omitempty"`
+	Team        string `json:"team,omitempty"`
+	RunbookURL  string `json:"runbook_url,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// Ownership returns the ownership fields of the service, or nil when none is set
+func (s *ExternalService) Ownership() *Ownership {
+	if s.Owner == "" && s.Team == "" && s.RunbookURL == "" && s.Description == "" {
+		return nil
+	}
+	return &Ownership{Owner: s.Owner, Team: s.Team, RunbookURL: s.RunbookURL, Description: s.Description}
+}
+
+// BenchTag marks the synthetic services of a bench run
+const BenchTag = "system:bench"
+
+// HasTag reports whether the service carries the tag
+func (s *ExternalService) HasTag(tag string) bool {
+	for _, t := range s.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// RegionQuorumSize is how many of the service's regions must fail for a
+// check to fail: region_quorum, or a majority of the regions when unset
+func (s *ExternalService) RegionQuorumSize() int {
+	if s.RegionQuorum > 0 {
+		return int(s.RegionQuorum)
+	}
+	return len(s.Regions)/2 + 1
+}
+
+// Confirmation has a failed check re-run right away, out of band, before it
+// counts toward the failure threshold. The re-checks go to the shared queue,
+// so another worker usually runs them, or one to each of regions.
+type Confirmation struct {
+	Checks  int64    `json:"checks"`            // re-checks published after a failure, 1 to 5; default one per region
+	Quorum  int64    `json:"quorum,omitempty"`  // failing re-checks that confirm the failure; 0 for a majority
+	Regions []string `json:"regions,omitempty"` // worker regions that run one re-check each; empty for any worker
+}
+
+// Enabled reports whether failures are confirmed before they count
+func (c *Confirmation) Enabled() bool {
+	return c != nil && c.Checks > 0
+}
+
+// QuorumSize is how many re-checks must fail to confirm a failure: quorum,
+// or a majority of the re-checks when unset
+func (c *Confirmation) QuorumSize() int {
+	if c.Quorum > 0 {
+		return int(c.Quorum)
+	}
+	return int(c.Checks)/2 + 1
+}
+
+// Probed protocols; a service with no protocol is an HTTP check
+const (
+	ProtocolHTTP = "HTTP"
+	ProtocolTCP  = "TCP"
+	ProtocolGRPC = "gRPC"
+	ProtocolDNS  = "DNS"
+)
+
+// ProtocolHeartbeat services are never probed; they must ping the heartbeat endpoint
+const ProtocolHeartbeat = "HEARTBEAT"
+
+// ProtocolDatabase services are checked by connecting to a Postgres, MySQL
+// or Redis server with their DSN and pinging it
+const ProtocolDatabase = "DATABASE"
+
+// HeartbeatDeadline is when the service counts as missing: the last ping (or
+// registration) plus the interval and grace period
+func (s *ExternalService) HeartbeatDeadline() time.Time {
+	last := s.CreatedAt
+	if s.LastHeartbeatAt != nil {
+		last = *s.LastHeartbeatAt
+	}
+	return last.Add(time.Duration(s.Interval+s.HeartbeatGrace) * time.Second)
+}
+
 // ShouldMarkDown determines if the service should be marked as down
 func (s *ExternalService) ShouldMarkDown() bool {
 	return s.ConsecutiveFailures >= s.FailureThreshold
 }
 
-// RecordSuccess resets the consecutive failures counter
+// RecordSuccess resets the consecutive failures counter, which starts a new
+// budget of remediation attempts
 func (s *ExternalService) RecordSuccess() {
 	s.Status = "UP"
 	s.ConsecutiveFailures = 0
-	now := time.Now()
+	s.RemediationAttempts = 0
+	now := clock.Now()
 	s.LastCheckedAt = &now
 }
 
-// RecordFailure increments the consecutive failures counter
+// RecordDegraded is a success that was too slow: failures reset, but the status is DEGRADED
+func (s *ExternalService) RecordDegraded() {
+	s.RecordSuccess()
+	s.Status = StatusDegraded
+}
+
+// RecordFailure increments the consecutive failures counter.
+// A PENDING service stays PENDING until it succeeds or reaches the failure threshold.
 func (s *ExternalService) RecordFailure() {
 	s.ConsecutiveFailures++
-	now := time.Now()
+	now := clock.Now()
 	s.LastCheckedAt = &now
 
 	if s.ShouldMarkDown() {
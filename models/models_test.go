@@ -0,0 +1,46 @@
+package models
+
+import "testing"
+
+func TestConfirmationQuorumSize(t *testing.T) {
+	tests := []struct {
+		name   string
+		c      Confirmation
+		quorum int
+	}{
+		{"one re-check", Confirmation{Checks: 1}, 1},
+		{"majority of two", Confirmation{Checks: 2}, 2},
+		{"majority of three", Confirmation{Checks: 3}, 2},
+		{"majority of four", Confirmation{Checks: 4}, 3},
+		{"majority of five", Confirmation{Checks: 5}, 3},
+		{"explicit quorum", Confirmation{Checks: 5, Quorum: 1}, 1},
+		{"quorum of all", Confirmation{Checks: 3, Quorum: 3}, 3},
+		{"one per region", Confirmation{Checks: 2, Regions: []string{"eu-west", "us-east"}}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.c.QuorumSize(); got != tt.quorum {
+				t.Errorf("QuorumSize() = %d, want %d", got, tt.quorum)
+			}
+		})
+	}
+}
+
+func TestConfirmationEnabled(t *testing.T) {
+	tests := []struct {
+		name    string
+		c       *Confirmation
+		enabled bool
+	}{
+		{"unset", nil, false},
+		{"no re-checks", &Confirmation{}, false},
+		{"re-checks", &Confirmation{Checks: 2}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.c.Enabled(); got != tt.enabled {
+				t.Errorf("Enabled() = %v, want %v", got, tt.enabled)
+			}
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package notify
+
+import (
+	"Distributed-Health-Monitoring/config"
+	"Distributed-Health-Monitoring/models"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// Webhook posts the alert as JSON
+type Webhook struct {
+	URL    string
+	client *http.Client
+}
+
+func (w *Webhook) Send(ctx context.Context, alert Alert) error {
+	return postJSON(ctx, w.client, w.URL, alert)
+}
+
+// Slack posts to an incoming webhook with the attachment colour set by severity
+type Slack struct {
+	WebhookURL string
+	client     *http.Client
+}
+
+var slackColors = map[string]string{
+	SeverityInfo:     "#2ea043",
+	SeverityWarning:  "#d29922",
+	SeverityCritical: "#da3633",
+}
+
+func (s *Slack) Send(ctx context.Context, alert Alert) error {
+	text := alert.Error
+	if alert.Reason != "" {
+		text = fmt.Sprintf("reason: %s\n%s", alert.Reason, alert.Error)
+	}
+
+	attachment := map[string]interface{}{
+		"color":  slackColors[alert.Severity],
+		"title":  fmt.Sprintf("[%s] %s", strings.ToUpper(alert.Severity), alert.Summary()),
+		"text":   strings.TrimSpace(text),
+		"footer": "Distributed Health Monitoring",
+		"ts":     alert.Timestamp.Unix(),
+	}
+	// Ownership and metadata become attachment fields; Slack turns URLs such as a runbook into links
+	if alertFields := alert.Fields(); len(alertFields) > 0 {
+		fields := make([]map[string]interface{}, 0, len(alertFields))
+		for _, f := range alertFields {
+			fields = append(fields, map[string]interface{}{"title": f.Key, "value": f.Value, "short": len(f.Value) <= 40})
+		}
+		attachment["fields"] = fields
+	}
+
+	msg := map[string]interface{}{
+		"attachments": []map[string]interface{}{attachment},
+	}
+	return postJSON(ctx, s.client, s.WebhookURL, msg)
+}
+
+// PagerDuty sends Events API v2 events, deduplicated per service so a
+// recovery resolves the incident the outage triggered
+type PagerDuty struct {
+	RoutingKey string
+	URL        string // defaults to the public Events API
+	client     *http.Client
+}
+
+const pagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+func (p *PagerDuty) Send(ctx context.Context, alert Alert) error {
+	url := p.URL
+	if url == "" {
+		url = pagerDutyEventsURL
+	}
+
+	action := "trigger"
+	// A DEGRADED page resolves on UP, an outage as soon as it ends, like its incident
+	resolved := models.StateChange{From: alert.From, To: alert.To}.Resolved() || alert.To == "UP"
+	if (alert.Type == "state_change" && resolved) || alert.Type == "scheduler_lag_end" || alert.Type == "clock_skew_end" {
+		action = "resolve"
+	}
+	dedupKey := fmt.Sprintf("dhm-service-%d", alert.ServiceID)
+	switch alert.Type {
+	case "scheduler_lag", "scheduler_lag_end":
+		dedupKey = "dhm-scheduler-lag" // about the monitor, not the service named in it
+	case "clock_skew", "clock_skew_end":
+		dedupKey = "dhm-clock-skew-" + alert.Instance // each replica has its own clock
+	}
+
+	event := map[string]interface{}{
+		"routing_key":  p.RoutingKey,
+		"event_action": action,
+		"dedup_key":    dedupKey,
+		"payload": map[string]interface{}{
+			"summary":        alert.Summary(),
+			"source":         alert.Service,
+			"severity":       alert.Severity, // info, warning and critical are valid PagerDuty severities
+			"timestamp":      alert.Timestamp,
+			"custom_details": alert,
+		},
+	}
+	if links := metadataLinks(alert); len(links) > 0 {
+		event["links"] = links
+	}
+	if alert.Type == "test" {
+		// Mark the event as a test and resolve it straight away, so a test
+		// never leaves an incident open
+		event["dedup_key"] = fmt.Sprintf("dhm-test-%d", alert.Timestamp.UnixNano())
+		payload := event["payload"].(map[string]interface{})
+		payload["summary"] = "[TEST] " + alert.Summary()
+		payload["class"] = "test"
+		payload["custom_details"] = map[string]interface{}{"test": true, "alert": alert}
+		if err := postJSON(ctx, p.client, url, event); err != nil {
+			return err
+		}
+		event["event_action"] = "resolve"
+	}
+	return postJSON(ctx, p.client, url, event)
+}
+
+// metadataLinks turns the runbook and metadata values that are http(s)
+// URLs into PagerDuty links
+func metadataLinks(alert Alert) []map[string]string {
+	var links []map[string]string
+	for _, f := range alert.Fields() {
+		if strings.HasPrefix(f.Value, "https://") || strings.HasPrefix(f.Value, "http://") {
+			links = append(links, map[string]string{"href": f.Value, "text": f.Key})
+		}
+	}
+	return links
+}
+
+// Email sends a plain-text message through an SMTP server
+type Email struct {
+	SMTP config.SMTP
+	To   []string
+}
+
+// headerSafe keeps service names from injecting extra mail headers
+var headerSafe = strings.NewReplacer("\r", " ", "\n", " ")
+
+func (m *Email) Send(ctx context.Context, alert Alert) error {
+	port := m.SMTP.Port
+	if port == 0 {
+		port = 587
+	}
+	from := m.SMTP.From
+	if from == "" {
+		from = m.SMTP.Username
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "From: %s\r\n", from)
+	fmt.Fprintf(&body, "To: %s\r\n", strings.Join(m.To, ", "))
+	fmt.Fprintf(&body, "Subject: [%s] %s\r\n", strings.ToUpper(alert.Severity), headerSafe.Replace(alert.Summary()))
+	fmt.Fprintf(&body, "Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	fmt.Fprintf(&body, "%s\r\n\r\n", alert.Summary())
+	if alert.Reason != "" {
+		fmt.Fprintf(&body, "Reason: %s\r\n", alert.Reason)
+	}
+	if alert.Error != "" {
+		fmt.Fprintf(&body, "Error: %s\r\n", alert.Error)
+	}
+	fmt.Fprintf(&body, "Time: %s\r\n", alert.Timestamp.Format(time.RFC3339))
+	if fields := alert.Fields(); len(fields) > 0 {
+		fmt.Fprintf(&body, "\r\n")
+		for _, f := range fields {
+			fmt.Fprintf(&body, "%s: %s\r\n", f.Key, f.Value)
+		}
+	}
+
+	var auth smtp.Auth
+	if m.SMTP.Username != "" {
+		auth = smtp.PlainAuth("", m.SMTP.Username, m.SMTP.Password, m.SMTP.Host)
+	}
+
+	// net/smtp has no context support; run it aside so the timeout still applies
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(fmt.Sprintf("%s:%d", m.SMTP.Host, port), auth, from, m.To, []byte(body.String()))
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func postJSON(ctx context.Context, client *http.Client, url string, body interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(snippet)))
+	}
+	return nil
+}
@@ -0,0 +1,345 @@
+package notify
+
+import (
+	"Distributed-Health-Monitoring/config"
+	"Distributed-Health-Monitoring/logging"
+	"Distributed-Health-Monitoring/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+
+	sendTimeout = 10 * time.Second
+)
+
+var severityRank = map[string]int{
+	SeverityInfo:     0,
+	SeverityWarning:  1,
+	SeverityCritical: 2,
+}
+
+// defaultSeverities apply when notifications.severity has no matching rule.
+// Keys are "FROM->TO" transitions (either side may be *) or event names.
+var defaultSeverities = map[string]string{
+	"*->DOWN":           SeverityCritical,
+	"*->DEGRADED":       SeverityWarning,
+	"*->UP":             SeverityInfo,
+	"flapping_start":    SeverityWarning,
+	"flapping_end":      SeverityInfo,
+	"cert_expiry":       SeverityWarning,
+	"escalation":        SeverityCritical,
+	"slo_burn":          SeverityWarning,
+	"slo_burn_end":      SeverityInfo,
+	"scheduler_lag":     SeverityWarning,
+	"scheduler_lag_end": SeverityInfo,
+	"clock_skew":        SeverityWarning,
+	"clock_skew_end":    SeverityInfo,
+}
+
+// ValidSeverity reports whether s is info, warning or critical
+func ValidSeverity(s string) bool {
+	_, ok := severityRank[s]
+	return ok
+}
+
+// Alert is what every channel receives
+type Alert struct {
+	Type             string                   `json:"type"` // state_change, flapping_start, flapping_end, escalation, slo_burn, slo_burn_end, scheduler_lag, scheduler_lag_end, clock_skew, clock_skew_end, test
+	Severity         string                   `json:"severity"`
+	ServiceID        uint                     `json:"service_id"`
+	Service          string                   `json:"service"`
+	Instance         string                   `json:"instance,omitempty"` // replica a clock_skew alert is about
+	Tags             []string                 `json:"tags,omitempty"`
+	Ownership        *models.Ownership        `json:"ownership,omitempty"` // owner, team, runbook and description of the service
+	Metadata         map[string]interface{}   `json:"metadata,omitempty"`  // the service's metadata, e.g. dashboard
+	From             string                   `json:"from,omitempty"`
+	To               string                   `json:"to,omitempty"`
+	Reason           string                   `json:"reason,omitempty"`
+	Error            string                   `json:"error,omitempty"`
+	AssertionFailure *models.AssertionFailure `json:"assertion_failure,omitempty"`
+	IncidentID       uint                     `json:"incident_id,omitempty"`
+	EscalationStep   int                      `json:"escalation_step,omitempty"` // 1-based
+	Timestamp        time.Time                `json:"timestamp"`
+}
+
+// Summary is the one-line description used as title by chat and paging channels
+func (a Alert) Summary() string {
+	switch a.Type {
+	case "state_change":
+		return fmt.Sprintf("%s is %s (was %s)", a.Service, a.To, a.From)
+	case "flapping_start":
+		return fmt.Sprintf("%s is flapping", a.Service)
+	case "flapping_end":
+		return fmt.Sprintf("%s stopped flapping (%s)", a.Service, a.To)
+	case "escalation":
+		return fmt.Sprintf("%s is still DOWN and unacknowledged (escalation step %d)", a.Service, a.EscalationStep)
+	case "slo_burn":
+		return fmt.Sprintf("%s is burning its error budget too fast: %s", a.Service, a.Reason)
+	case "slo_burn_end":
+		return fmt.Sprintf("%s stopped burning its error budget too fast: %s", a.Service, a.Reason)
+	case "scheduler_lag":
+		return "Health checks are falling behind schedule: " + a.Reason
+	case "scheduler_lag_end":
+		return "Health checks caught up with their schedule: " + a.Reason
+	case "clock_skew":
+		return "Clock skew may disturb scheduling: " + a.Reason
+	case "clock_skew_end":
+		return "Clock skew is back within limits: " + a.Reason
+	case "test":
+		return "Test alert from the health monitor, no action needed"
+	}
+	return fmt.Sprintf("%s: %s", a.Service, a.Type)
+}
+
+// MetadataField is one metadata entry rendered for a text channel
+type MetadataField struct {
+	Key   string
+	Value string
+}
+
+// MetadataFields returns the metadata sorted by key; non-string values are
+// written as JSON
+func (a Alert) MetadataFields() []MetadataField {
+	keys := make([]string, 0, len(a.Metadata))
+	for k := range a.Metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	fields := make([]MetadataField, 0, len(keys))
+	for _, k := range keys {
+		fields = append(fields, MetadataField{Key: k, Value: metadataValue(a.Metadata[k])})
+	}
+	return fields
+}
+
+// Fields returns the ownership of the service (owner, team, runbook_url,
+// description) followed by its metadata, for channels that show both as
+// key/value pairs
+func (a Alert) Fields() []MetadataField {
+	var fields []MetadataField
+	if o := a.Ownership; o != nil {
+		for _, f := range []MetadataField{
+			{Key: "owner", Value: o.Owner},
+			{Key: "team", Value: o.Team},
+			{Key: "runbook_url", Value: o.RunbookURL},
+			{Key: "description", Value: o.Description},
+		} {
+			if f.Value != "" {
+				fields = append(fields, f)
+			}
+		}
+	}
+	return append(fields, a.MetadataFields()...)
+}
+
+func metadataValue(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(raw)
+}
+
+// Notifier delivers alerts to one channel
+type Notifier interface {
+	Send(ctx context.Context, alert Alert) error
+}
+
+type route struct {
+	id          string
+	kind        string
+	notifier    Notifier
+	minSeverity string
+	severities  map[string]bool
+	tags        []string
+}
+
+// Dispatcher resolves alert severities and fans alerts out to the notifiers
+// whose routing rules match
+type Dispatcher struct {
+	severities map[string]string
+	routes     []route
+}
+
+func NewDispatcher(cfg config.Notifications) (*Dispatcher, error) {
+	d := &Dispatcher{severities: make(map[string]string)}
+
+	for key, severity := range cfg.Severity {
+		if _, ok := severityRank[severity]; !ok {
+			return nil, fmt.Errorf("notifications: invalid severity %q for %s", severity, key)
+		}
+		d.severities[key] = severity
+	}
+
+	client := &http.Client{Timeout: sendTimeout}
+	for _, n := range cfg.Notifiers {
+		if n.ID == "" {
+			return nil, fmt.Errorf("notifications: notifier id is empty")
+		}
+
+		var notifier Notifier
+		switch n.Type {
+		case "webhook":
+			notifier = &Webhook{URL: n.URL, client: client}
+		case "slack":
+			notifier = &Slack{WebhookURL: n.URL, client: client}
+		case "pagerduty":
+			notifier = &PagerDuty{RoutingKey: n.RoutingKey, URL: n.URL, client: client}
+		case "email":
+			if n.SMTP.Host == "" || len(n.To) == 0 {
+				return nil, fmt.Errorf("notifications: email notifier %s needs smtp.host and to", n.ID)
+			}
+			notifier = &Email{SMTP: n.SMTP, To: n.To}
+		default:
+			return nil, fmt.Errorf("notifications: notifier %s has unknown type %q", n.ID, n.Type)
+		}
+
+		r := route{id: n.ID, kind: n.Type, notifier: notifier, minSeverity: n.MinSeverity, tags: n.Tags}
+		if r.minSeverity == "" {
+			r.minSeverity = SeverityInfo
+		}
+		if _, ok := severityRank[r.minSeverity]; !ok {
+			return nil, fmt.Errorf("notifications: notifier %s has invalid min_severity %q", n.ID, n.MinSeverity)
+		}
+		if len(n.Severities) > 0 {
+			r.severities = make(map[string]bool, len(n.Severities))
+			for _, s := range n.Severities {
+				r.severities[s] = true
+			}
+		}
+		d.routes = append(d.routes, r)
+	}
+
+	return d, nil
+}
+
+// TransitionKey is the severity rule key of a state change
+func TransitionKey(from, to string) string {
+	return from + "->" + to
+}
+
+// Severity resolves the severity of a transition key or event name: exact
+// rule, then wildcard rules, then the built-in defaults
+func (d *Dispatcher) Severity(key string) string {
+	candidates := []string{key}
+	if from, to, ok := strings.Cut(key, "->"); ok {
+		candidates = append(candidates, "*->"+to, from+"->*")
+	}
+
+	for _, rules := range []map[string]string{d.severities, defaultSeverities} {
+		for _, k := range candidates {
+			if s, ok := rules[k]; ok {
+				return s
+			}
+		}
+	}
+	return SeverityInfo
+}
+
+func (r route) matches(alert Alert) bool {
+	if severityRank[alert.Severity] < severityRank[r.minSeverity] {
+		return false
+	}
+	if r.severities != nil && !r.severities[alert.Severity] {
+		return false
+	}
+	if len(r.tags) == 0 {
+		return true
+	}
+	for _, want := range r.tags {
+		for _, have := range alert.Tags {
+			if want == have {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// TestAlert is the synthetic alert sent by the notifier test endpoint
+func TestAlert(at time.Time) Alert {
+	return Alert{
+		Type:      "test",
+		Severity:  SeverityInfo,
+		Service:   "notifier-test",
+		Reason:    "test",
+		Timestamp: at,
+	}
+}
+
+// NotifierInfo describes a configured notifier without its credentials
+type NotifierInfo struct {
+	ID          string   `json:"id"`
+	Type        string   `json:"type"`
+	MinSeverity string   `json:"min_severity"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+// Notifiers lists the configured notifiers in config order
+func (d *Dispatcher) Notifiers() []NotifierInfo {
+	out := make([]NotifierInfo, 0, len(d.routes))
+	for _, r := range d.routes {
+		out = append(out, NotifierInfo{ID: r.id, Type: r.kind, MinSeverity: r.minSeverity, Tags: r.tags})
+	}
+	return out
+}
+
+// Has reports whether a notifier with the id is configured
+func (d *Dispatcher) Has(id string) bool {
+	return d.Kind(id) != ""
+}
+
+// Kind returns the type of a notifier (slack, pagerduty, ...), or "" when
+// none has the id
+func (d *Dispatcher) Kind(id string) string {
+	for _, r := range d.routes {
+		if r.id == id {
+			return r.kind
+		}
+	}
+	return ""
+}
+
+// SendTo delivers the alert to one notifier, ignoring its routing rules
+func (d *Dispatcher) SendTo(ctx context.Context, id string, alert Alert) error {
+	for _, r := range d.routes {
+		if r.id == id {
+			return r.notifier.Send(ctx, alert)
+		}
+	}
+	return fmt.Errorf("unknown notifier %q", id)
+}
+
+// Dispatch sends the alert to every matching notifier in the background
+func (d *Dispatcher) Dispatch(alert Alert) {
+	for _, r := range d.routes {
+		if !r.matches(alert) {
+			continue
+		}
+
+		go func(r route) {
+			ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+			defer cancel()
+
+			logger := logging.For(ctx, "notifier").With("notifier", r.id, "type", r.kind, "service", alert.Service, "severity", alert.Severity)
+			if err := r.notifier.Send(ctx, alert); err != nil {
+				logger.Error("send_failed", "err", err)
+				return
+			}
+			logger.Info("sent")
+		}(r)
+	}
+}
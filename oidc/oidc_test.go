@@ -0,0 +1,215 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testProvider serves discovery and a key set holding an RSA key "rsa"
+// and a P-256 key "ec"
+type testProvider struct {
+	server *httptest.Server
+	rsaKey *rsa.PrivateKey
+	ecKey  *ecdsa.PrivateKey
+}
+
+func newTestProvider(t *testing.T) *testProvider {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tp := &testProvider{rsaKey: rsaKey, ecKey: ecKey}
+
+	b64 := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 tp.server.URL,
+			"authorization_endpoint": tp.server.URL + "/authorize",
+			"token_endpoint":         tp.server.URL + "/token",
+			"jwks_uri":               tp.server.URL + "/keys",
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{
+			{"kid": "rsa", "kty": "RSA", "use": "sig", "n": b64(rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(rsaKey.E)).Bytes())},
+			{"kid": "ec", "kty": "EC", "crv": "P-256", "x": b64(ecKey.X.FillBytes(make([]byte, 32))), "y": b64(ecKey.Y.FillBytes(make([]byte, 32)))},
+		}})
+	})
+	tp.server = httptest.NewServer(mux)
+	t.Cleanup(tp.server.Close)
+	return tp
+}
+
+// sign builds a token; alg names the header, kid picks the key it is
+// really signed with
+func (tp *testProvider) sign(t *testing.T, alg, kid string, claims map[string]interface{}) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+
+	var signature []byte
+	switch kid {
+	case "rsa":
+		var err error
+		if signature, err = rsa.SignPKCS1v15(rand.Reader, tp.rsaKey, crypto.SHA256, digest[:]); err != nil {
+			t.Fatal(err)
+		}
+	case "ec":
+		r, s, err := ecdsa.Sign(rand.Reader, tp.ecKey, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestVerify(t *testing.T) {
+	tp := newTestProvider(t)
+	p := NewProvider(tp.server.URL, "monitor", "secret", "", nil)
+
+	now := time.Now()
+	claims := func(change func(c map[string]interface{})) map[string]interface{} {
+		c := map[string]interface{}{
+			"iss":   tp.server.URL,
+			"aud":   "monitor",
+			"sub":   "user-1",
+			"exp":   now.Add(time.Hour).Unix(),
+			"nonce": "n-1",
+		}
+		if change != nil {
+			change(c)
+		}
+		return c
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		nonce string
+		err   string
+	}{
+		{name: "valid RS256", token: tp.sign(t, "RS256", "rsa", claims(nil)), nonce: "n-1"},
+		{name: "valid ES256", token: tp.sign(t, "ES256", "ec", claims(nil)), nonce: "n-1"},
+		{name: "bearer skips the nonce", token: tp.sign(t, "RS256", "rsa", claims(nil))},
+		{
+			name:  "audience list with this client as authorized party",
+			token: tp.sign(t, "RS256", "rsa", claims(func(c map[string]interface{}) { c["aud"] = []string{"monitor", "api"}; c["azp"] = "monitor" })),
+		},
+		{
+			name:  "expired within the leeway",
+			token: tp.sign(t, "RS256", "rsa", claims(func(c map[string]interface{}) { c["exp"] = now.Add(-30 * time.Second).Unix() })),
+		},
+		{
+			name:  "wrong issuer",
+			token: tp.sign(t, "RS256", "rsa", claims(func(c map[string]interface{}) { c["iss"] = "https://evil.example.com" })),
+			err:   "token issuer",
+		},
+		{
+			name:  "wrong audience",
+			token: tp.sign(t, "RS256", "rsa", claims(func(c map[string]interface{}) { c["aud"] = "api" })),
+			err:   "not issued for this client",
+		},
+		{
+			name:  "several audiences without an authorized party",
+			token: tp.sign(t, "RS256", "rsa", claims(func(c map[string]interface{}) { c["aud"] = []string{"monitor", "api"} })),
+			err:   "no authorized party",
+		},
+		{
+			name:  "authorized party is another client",
+			token: tp.sign(t, "RS256", "rsa", claims(func(c map[string]interface{}) { c["aud"] = []string{"monitor", "api"}; c["azp"] = "api" })),
+			err:   "issued to another client",
+		},
+		{
+			name:  "expired",
+			token: tp.sign(t, "RS256", "rsa", claims(func(c map[string]interface{}) { c["exp"] = now.Add(-2 * clockLeeway).Unix() })),
+			err:   "expired",
+		},
+		{
+			name:  "no expiry",
+			token: tp.sign(t, "RS256", "rsa", claims(func(c map[string]interface{}) { delete(c, "exp") })),
+			err:   "no expiry",
+		},
+		{
+			name:  "no subject",
+			token: tp.sign(t, "RS256", "rsa", claims(func(c map[string]interface{}) { delete(c, "sub") })),
+			err:   "no subject",
+		},
+		{
+			name:  "wrong nonce",
+			token: tp.sign(t, "RS256", "rsa", claims(nil)),
+			nonce: "n-2",
+			err:   "nonce does not match",
+		},
+		{
+			name:  "missing nonce",
+			token: tp.sign(t, "RS256", "rsa", claims(func(c map[string]interface{}) { delete(c, "nonce") })),
+			nonce: "n-1",
+			err:   "nonce does not match",
+		},
+		{
+			name:  "RSA algorithm on the EC key",
+			token: tp.sign(t, "RS256", "ec", claims(nil)),
+			err:   "does not match the key",
+		},
+		{
+			name:  "EC algorithm on the RSA key",
+			token: tp.sign(t, "ES256", "rsa", claims(nil)),
+			err:   "does not match the key",
+		},
+		{
+			name:  "unsigned",
+			token: tp.sign(t, "none", "rsa", claims(nil)),
+			err:   "unsupported signing algorithm",
+		},
+		{
+			name:  "unknown key",
+			token: tp.sign(t, "RS256", "other", claims(nil)),
+			err:   "unknown signing key",
+		},
+		{
+			name: "tampered claims",
+			token: func() string {
+				parts := strings.Split(tp.sign(t, "RS256", "rsa", claims(nil)), ".")
+				payload, _ := json.Marshal(claims(func(c map[string]interface{}) { c["sub"] = "admin" }))
+				return parts[0] + "." + base64.RawURLEncoding.EncodeToString(payload) + "." + parts[2]
+			}(),
+			err: "invalid token signature",
+		},
+		{name: "malformed", token: "not-a-token", err: "malformed token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := p.Verify(context.Background(), tt.token, tt.nonce)
+			switch {
+			case tt.err == "" && err != nil:
+				t.Fatalf("Verify() failed: %v", err)
+			case tt.err == "" && token.Subject != "user-1":
+				t.Errorf("Verify() subject = %q, want user-1", token.Subject)
+			case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+				t.Errorf("Verify() error = %v, want one containing %q", err, tt.err)
+			}
+		})
+	}
+}
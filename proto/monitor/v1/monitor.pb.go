@@ -0,0 +1,1621 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        (unknown)
+// source: monitor/v1/monitor.proto
+
+package monitorv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	wrapperspb "google.golang.org/protobuf/types/known/wrapperspb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Assertion is evaluated against the HTTP response body.
+type Assertion struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"` // body_contains or json_path
+	Path          string                 `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"` // JSONPath for json_path, e.g. $.status
+	Expected      string                 `protobuf:"bytes,3,opt,name=expected,proto3" json:"expected,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Assertion) Reset() {
+	*x = Assertion{}
+	mi := &file_monitor_v1_monitor_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Assertion) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Assertion) ProtoMessage() {}
+
+func (x *Assertion) ProtoReflect() protoreflect.Message {
+	mi := &file_monitor_v1_monitor_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Assertion.ProtoReflect.Descriptor instead.
+func (*Assertion) Descriptor() ([]byte, []int) {
+	return file_monitor_v1_monitor_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Assertion) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Assertion) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *Assertion) GetExpected() string {
+	if x != nil {
+		return x.Expected
+	}
+	return ""
+}
+
+// CheckAuth holds the credentials sent with each HTTP check. Secrets come
+// back as "xxxxx".
+type CheckAuth struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"` // basic, bearer or oauth2
+	Username      string                 `protobuf:"bytes,2,opt,name=username,proto3" json:"username,omitempty"`
+	Password      string                 `protobuf:"bytes,3,opt,name=password,proto3" json:"password,omitempty"`
+	Token         string                 `protobuf:"bytes,4,opt,name=token,proto3" json:"token,omitempty"`
+	TokenUrl      string                 `protobuf:"bytes,5,opt,name=token_url,json=tokenUrl,proto3" json:"token_url,omitempty"`
+	ClientId      string                 `protobuf:"bytes,6,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`
+	ClientSecret  string                 `protobuf:"bytes,7,opt,name=client_secret,json=clientSecret,proto3" json:"client_secret,omitempty"`
+	Scopes        []string               `protobuf:"bytes,8,rep,name=scopes,proto3" json:"scopes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckAuth) Reset() {
+	*x = CheckAuth{}
+	mi := &file_monitor_v1_monitor_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckAuth) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckAuth) ProtoMessage() {}
+
+func (x *CheckAuth) ProtoReflect() protoreflect.Message {
+	mi := &file_monitor_v1_monitor_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckAuth.ProtoReflect.Descriptor instead.
+func (*CheckAuth) Descriptor() ([]byte, []int) {
+	return file_monitor_v1_monitor_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CheckAuth) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *CheckAuth) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *CheckAuth) GetPassword() string {
+	if x != nil {
+		return x.Password
+	}
+	return ""
+}
+
+func (x *CheckAuth) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *CheckAuth) GetTokenUrl() string {
+	if x != nil {
+		return x.TokenUrl
+	}
+	return ""
+}
+
+func (x *CheckAuth) GetClientId() string {
+	if x != nil {
+		return x.ClientId
+	}
+	return ""
+}
+
+func (x *CheckAuth) GetClientSecret() string {
+	if x != nil {
+		return x.ClientSecret
+	}
+	return ""
+}
+
+func (x *CheckAuth) GetScopes() []string {
+	if x != nil {
+		return x.Scopes
+	}
+	return nil
+}
+
+// ServiceSpec is what a client sets when registering a service. The fields
+// match the service object of the REST API.
+type ServiceSpec struct {
+	state                 protoimpl.MessageState `protogen:"open.v1"`
+	Name                  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Url                   string                 `protobuf:"bytes,2,opt,name=url,proto3" json:"url,omitempty"`
+	Protocol              string                 `protobuf:"bytes,3,opt,name=protocol,proto3" json:"protocol,omitempty"` // HTTP (default), TCP, DNS, gRPC or HEARTBEAT
+	HttpMethod            string                 `protobuf:"bytes,4,opt,name=http_method,json=httpMethod,proto3" json:"http_method,omitempty"`
+	Interval              int64                  `protobuf:"varint,5,opt,name=interval,proto3" json:"interval,omitempty"` // seconds between checks
+	TimeoutSeconds        int64                  `protobuf:"varint,6,opt,name=timeout_seconds,json=timeoutSeconds,proto3" json:"timeout_seconds,omitempty"`
+	FailureThreshold      int64                  `protobuf:"varint,7,opt,name=failure_threshold,json=failureThreshold,proto3" json:"failure_threshold,omitempty"` // consecutive failures before the service is DOWN
+	Retries               int64                  `protobuf:"varint,8,opt,name=retries,proto3" json:"retries,omitempty"`
+	RetryDelayMs          int64                  `protobuf:"varint,9,opt,name=retry_delay_ms,json=retryDelayMs,proto3" json:"retry_delay_ms,omitempty"`
+	LatencyWarnMs         int64                  `protobuf:"varint,10,opt,name=latency_warn_ms,json=latencyWarnMs,proto3" json:"latency_warn_ms,omitempty"`
+	LatencyCritMs         int64                  `protobuf:"varint,11,opt,name=latency_crit_ms,json=latencyCritMs,proto3" json:"latency_crit_ms,omitempty"`
+	Assertions            []*Assertion           `protobuf:"bytes,12,rep,name=assertions,proto3" json:"assertions,omitempty"`
+	ProxyUrl              string                 `protobuf:"bytes,13,opt,name=proxy_url,json=proxyUrl,proto3" json:"proxy_url,omitempty"`
+	ResolveOverride       map[string]string      `protobuf:"bytes,14,rep,name=resolve_override,json=resolveOverride,proto3" json:"resolve_override,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // host -> IP to connect to instead of resolving
+	SourceInterface       string                 `protobuf:"bytes,15,opt,name=source_interface,json=sourceInterface,proto3" json:"source_interface,omitempty"`
+	Auth                  *CheckAuth             `protobuf:"bytes,16,opt,name=auth,proto3" json:"auth,omitempty"`
+	DnsResolver           string                 `protobuf:"bytes,17,opt,name=dns_resolver,json=dnsResolver,proto3" json:"dns_resolver,omitempty"`
+	DnsRecordType         string                 `protobuf:"bytes,18,opt,name=dns_record_type,json=dnsRecordType,proto3" json:"dns_record_type,omitempty"`
+	DnsExpected           []string               `protobuf:"bytes,19,rep,name=dns_expected,json=dnsExpected,proto3" json:"dns_expected,omitempty"`
+	HeartbeatGraceSeconds int64                  `protobuf:"varint,20,opt,name=heartbeat_grace_seconds,json=heartbeatGraceSeconds,proto3" json:"heartbeat_grace_seconds,omitempty"`
+	Public                bool                   `protobuf:"varint,21,opt,name=public,proto3" json:"public,omitempty"` // listed on the public status page
+	Tags                  []string               `protobuf:"bytes,22,rep,name=tags,proto3" json:"tags,omitempty"`
+	Metadata              *structpb.Struct       `protobuf:"bytes,23,opt,name=metadata,proto3" json:"metadata,omitempty"` // free-form details repeated in alerts
+	unknownFields         protoimpl.UnknownFields
+	sizeCache             protoimpl.SizeCache
+}
+
+func (x *ServiceSpec) Reset() {
+	*x = ServiceSpec{}
+	mi := &file_monitor_v1_monitor_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ServiceSpec) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ServiceSpec) ProtoMessage() {}
+
+func (x *ServiceSpec) ProtoReflect() protoreflect.Message {
+	mi := &file_monitor_v1_monitor_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ServiceSpec.ProtoReflect.Descriptor instead.
+func (*ServiceSpec) Descriptor() ([]byte, []int) {
+	return file_monitor_v1_monitor_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ServiceSpec) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ServiceSpec) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *ServiceSpec) GetProtocol() string {
+	if x != nil {
+		return x.Protocol
+	}
+	return ""
+}
+
+func (x *ServiceSpec) GetHttpMethod() string {
+	if x != nil {
+		return x.HttpMethod
+	}
+	return ""
+}
+
+func (x *ServiceSpec) GetInterval() int64 {
+	if x != nil {
+		return x.Interval
+	}
+	return 0
+}
+
+func (x *ServiceSpec) GetTimeoutSeconds() int64 {
+	if x != nil {
+		return x.TimeoutSeconds
+	}
+	return 0
+}
+
+func (x *ServiceSpec) GetFailureThreshold() int64 {
+	if x != nil {
+		return x.FailureThreshold
+	}
+	return 0
+}
+
+func (x *ServiceSpec) GetRetries() int64 {
+	if x != nil {
+		return x.Retries
+	}
+	return 0
+}
+
+func (x *ServiceSpec) GetRetryDelayMs() int64 {
+	if x != nil {
+		return x.RetryDelayMs
+	}
+	return 0
+}
+
+func (x *ServiceSpec) GetLatencyWarnMs() int64 {
+	if x != nil {
+		return x.LatencyWarnMs
+	}
+	return 0
+}
+
+func (x *ServiceSpec) GetLatencyCritMs() int64 {
+	if x != nil {
+		return x.LatencyCritMs
+	}
+	return 0
+}
+
+func (x *ServiceSpec) GetAssertions() []*Assertion {
+	if x != nil {
+		return x.Assertions
+	}
+	return nil
+}
+
+func (x *ServiceSpec) GetProxyUrl() string {
+	if x != nil {
+		return x.ProxyUrl
+	}
+	return ""
+}
+
+func (x *ServiceSpec) GetResolveOverride() map[string]string {
+	if x != nil {
+		return x.ResolveOverride
+	}
+	return nil
+}
+
+func (x *ServiceSpec) GetSourceInterface() string {
+	if x != nil {
+		return x.SourceInterface
+	}
+	return ""
+}
+
+func (x *ServiceSpec) GetAuth() *CheckAuth {
+	if x != nil {
+		return x.Auth
+	}
+	return nil
+}
+
+func (x *ServiceSpec) GetDnsResolver() string {
+	if x != nil {
+		return x.DnsResolver
+	}
+	return ""
+}
+
+func (x *ServiceSpec) GetDnsRecordType() string {
+	if x != nil {
+		return x.DnsRecordType
+	}
+	return ""
+}
+
+func (x *ServiceSpec) GetDnsExpected() []string {
+	if x != nil {
+		return x.DnsExpected
+	}
+	return nil
+}
+
+func (x *ServiceSpec) GetHeartbeatGraceSeconds() int64 {
+	if x != nil {
+		return x.HeartbeatGraceSeconds
+	}
+	return 0
+}
+
+func (x *ServiceSpec) GetPublic() bool {
+	if x != nil {
+		return x.Public
+	}
+	return false
+}
+
+func (x *ServiceSpec) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *ServiceSpec) GetMetadata() *structpb.Struct {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+// Service is a registered service with its current state.
+type Service struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	Id                  uint32                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Spec                *ServiceSpec           `protobuf:"bytes,2,opt,name=spec,proto3" json:"spec,omitempty"`
+	HeartbeatToken      string                 `protobuf:"bytes,3,opt,name=heartbeat_token,json=heartbeatToken,proto3" json:"heartbeat_token,omitempty"` // only set in the RegisterService response
+	Status              string                 `protobuf:"bytes,4,opt,name=status,proto3" json:"status,omitempty"`                                       // PENDING, UP, DEGRADED, DOWN, FLAPPING or MAINTENANCE
+	ConsecutiveFailures int64                  `protobuf:"varint,5,opt,name=consecutive_failures,json=consecutiveFailures,proto3" json:"consecutive_failures,omitempty"`
+	LastCheckedAt       *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=last_checked_at,json=lastCheckedAt,proto3" json:"last_checked_at,omitempty"`
+	LastHeartbeatAt     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=last_heartbeat_at,json=lastHeartbeatAt,proto3" json:"last_heartbeat_at,omitempty"`
+	CreatedAt           *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt           *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *Service) Reset() {
+	*x = Service{}
+	mi := &file_monitor_v1_monitor_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Service) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Service) ProtoMessage() {}
+
+func (x *Service) ProtoReflect() protoreflect.Message {
+	mi := &file_monitor_v1_monitor_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Service.ProtoReflect.Descriptor instead.
+func (*Service) Descriptor() ([]byte, []int) {
+	return file_monitor_v1_monitor_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Service) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Service) GetSpec() *ServiceSpec {
+	if x != nil {
+		return x.Spec
+	}
+	return nil
+}
+
+func (x *Service) GetHeartbeatToken() string {
+	if x != nil {
+		return x.HeartbeatToken
+	}
+	return ""
+}
+
+func (x *Service) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Service) GetConsecutiveFailures() int64 {
+	if x != nil {
+		return x.ConsecutiveFailures
+	}
+	return 0
+}
+
+func (x *Service) GetLastCheckedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastCheckedAt
+	}
+	return nil
+}
+
+func (x *Service) GetLastHeartbeatAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastHeartbeatAt
+	}
+	return nil
+}
+
+func (x *Service) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Service) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+// CheckLog is the result of one check.
+type CheckLog struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Id             uint32                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	ServiceId      uint32                 `protobuf:"varint,2,opt,name=service_id,json=serviceId,proto3" json:"service_id,omitempty"`
+	Status         string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
+	StatusCode     int32                  `protobuf:"varint,4,opt,name=status_code,json=statusCode,proto3" json:"status_code,omitempty"`
+	ResponseTimeMs int64                  `protobuf:"varint,5,opt,name=response_time_ms,json=responseTimeMs,proto3" json:"response_time_ms,omitempty"`
+	Error          string                 `protobuf:"bytes,6,opt,name=error,proto3" json:"error,omitempty"`
+	CheckedAt      *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=checked_at,json=checkedAt,proto3" json:"checked_at,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *CheckLog) Reset() {
+	*x = CheckLog{}
+	mi := &file_monitor_v1_monitor_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckLog) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckLog) ProtoMessage() {}
+
+func (x *CheckLog) ProtoReflect() protoreflect.Message {
+	mi := &file_monitor_v1_monitor_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckLog.ProtoReflect.Descriptor instead.
+func (*CheckLog) Descriptor() ([]byte, []int) {
+	return file_monitor_v1_monitor_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *CheckLog) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *CheckLog) GetServiceId() uint32 {
+	if x != nil {
+		return x.ServiceId
+	}
+	return 0
+}
+
+func (x *CheckLog) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *CheckLog) GetStatusCode() int32 {
+	if x != nil {
+		return x.StatusCode
+	}
+	return 0
+}
+
+func (x *CheckLog) GetResponseTimeMs() int64 {
+	if x != nil {
+		return x.ResponseTimeMs
+	}
+	return 0
+}
+
+func (x *CheckLog) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *CheckLog) GetCheckedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CheckedAt
+	}
+	return nil
+}
+
+type ListServicesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tag           string                 `protobuf:"bytes,1,opt,name=tag,proto3" json:"tag,omitempty"` // only services with this tag
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListServicesRequest) Reset() {
+	*x = ListServicesRequest{}
+	mi := &file_monitor_v1_monitor_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListServicesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListServicesRequest) ProtoMessage() {}
+
+func (x *ListServicesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monitor_v1_monitor_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListServicesRequest.ProtoReflect.Descriptor instead.
+func (*ListServicesRequest) Descriptor() ([]byte, []int) {
+	return file_monitor_v1_monitor_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListServicesRequest) GetTag() string {
+	if x != nil {
+		return x.Tag
+	}
+	return ""
+}
+
+type ListServicesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Services      []*Service             `protobuf:"bytes,1,rep,name=services,proto3" json:"services,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListServicesResponse) Reset() {
+	*x = ListServicesResponse{}
+	mi := &file_monitor_v1_monitor_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListServicesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListServicesResponse) ProtoMessage() {}
+
+func (x *ListServicesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monitor_v1_monitor_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListServicesResponse.ProtoReflect.Descriptor instead.
+func (*ListServicesResponse) Descriptor() ([]byte, []int) {
+	return file_monitor_v1_monitor_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ListServicesResponse) GetServices() []*Service {
+	if x != nil {
+		return x.Services
+	}
+	return nil
+}
+
+type GetServiceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint32                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetServiceRequest) Reset() {
+	*x = GetServiceRequest{}
+	mi := &file_monitor_v1_monitor_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetServiceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetServiceRequest) ProtoMessage() {}
+
+func (x *GetServiceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monitor_v1_monitor_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetServiceRequest.ProtoReflect.Descriptor instead.
+func (*GetServiceRequest) Descriptor() ([]byte, []int) {
+	return file_monitor_v1_monitor_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GetServiceRequest) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type RegisterServiceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Service       *ServiceSpec           `protobuf:"bytes,1,opt,name=service,proto3" json:"service,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RegisterServiceRequest) Reset() {
+	*x = RegisterServiceRequest{}
+	mi := &file_monitor_v1_monitor_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RegisterServiceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterServiceRequest) ProtoMessage() {}
+
+func (x *RegisterServiceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monitor_v1_monitor_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterServiceRequest.ProtoReflect.Descriptor instead.
+func (*RegisterServiceRequest) Descriptor() ([]byte, []int) {
+	return file_monitor_v1_monitor_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *RegisterServiceRequest) GetService() *ServiceSpec {
+	if x != nil {
+		return x.Service
+	}
+	return nil
+}
+
+type DeleteServiceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint32                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Reason        string                 `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"` // stored with the archive
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteServiceRequest) Reset() {
+	*x = DeleteServiceRequest{}
+	mi := &file_monitor_v1_monitor_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteServiceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteServiceRequest) ProtoMessage() {}
+
+func (x *DeleteServiceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monitor_v1_monitor_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteServiceRequest.ProtoReflect.Descriptor instead.
+func (*DeleteServiceRequest) Descriptor() ([]byte, []int) {
+	return file_monitor_v1_monitor_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *DeleteServiceRequest) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *DeleteServiceRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type DeleteServiceResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ArchiveId     uint32                 `protobuf:"varint,1,opt,name=archive_id,json=archiveId,proto3" json:"archive_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteServiceResponse) Reset() {
+	*x = DeleteServiceResponse{}
+	mi := &file_monitor_v1_monitor_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteServiceResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteServiceResponse) ProtoMessage() {}
+
+func (x *DeleteServiceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monitor_v1_monitor_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteServiceResponse.ProtoReflect.Descriptor instead.
+func (*DeleteServiceResponse) Descriptor() ([]byte, []int) {
+	return file_monitor_v1_monitor_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *DeleteServiceResponse) GetArchiveId() uint32 {
+	if x != nil {
+		return x.ArchiveId
+	}
+	return 0
+}
+
+type ListCheckLogsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ServiceId     uint32                 `protobuf:"varint,1,opt,name=service_id,json=serviceId,proto3" json:"service_id,omitempty"`
+	Limit         int32                  `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"` // default 100
+	Offset        int32                  `protobuf:"varint,3,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListCheckLogsRequest) Reset() {
+	*x = ListCheckLogsRequest{}
+	mi := &file_monitor_v1_monitor_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListCheckLogsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCheckLogsRequest) ProtoMessage() {}
+
+func (x *ListCheckLogsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monitor_v1_monitor_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCheckLogsRequest.ProtoReflect.Descriptor instead.
+func (*ListCheckLogsRequest) Descriptor() ([]byte, []int) {
+	return file_monitor_v1_monitor_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ListCheckLogsRequest) GetServiceId() uint32 {
+	if x != nil {
+		return x.ServiceId
+	}
+	return 0
+}
+
+func (x *ListCheckLogsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListCheckLogsRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type ListCheckLogsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Logs          []*CheckLog            `protobuf:"bytes,1,rep,name=logs,proto3" json:"logs,omitempty"`
+	Limit         int32                  `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	Offset        int32                  `protobuf:"varint,3,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListCheckLogsResponse) Reset() {
+	*x = ListCheckLogsResponse{}
+	mi := &file_monitor_v1_monitor_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListCheckLogsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCheckLogsResponse) ProtoMessage() {}
+
+func (x *ListCheckLogsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monitor_v1_monitor_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCheckLogsResponse.ProtoReflect.Descriptor instead.
+func (*ListCheckLogsResponse) Descriptor() ([]byte, []int) {
+	return file_monitor_v1_monitor_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ListCheckLogsResponse) GetLogs() []*CheckLog {
+	if x != nil {
+		return x.Logs
+	}
+	return nil
+}
+
+func (x *ListCheckLogsResponse) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListCheckLogsResponse) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+// QueryCheckLogsRequest is the filter of POST /health-app/healthLogs/query.
+// Every set field narrows the result.
+type QueryCheckLogsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ServiceIds    []uint32               `protobuf:"varint,1,rep,packed,name=service_ids,json=serviceIds,proto3" json:"service_ids,omitempty"`
+	Tag           string                 `protobuf:"bytes,2,opt,name=tag,proto3" json:"tag,omitempty"` // only services with this tag
+	Statuses      []string               `protobuf:"bytes,3,rep,name=statuses,proto3" json:"statuses,omitempty"`
+	StatusCodes   []int32                `protobuf:"varint,4,rep,packed,name=status_codes,json=statusCodes,proto3" json:"status_codes,omitempty"`
+	LatencyGtMs   *wrapperspb.Int64Value `protobuf:"bytes,5,opt,name=latency_gt_ms,json=latencyGtMs,proto3" json:"latency_gt_ms,omitempty"`
+	LatencyLtMs   *wrapperspb.Int64Value `protobuf:"bytes,6,opt,name=latency_lt_ms,json=latencyLtMs,proto3" json:"latency_lt_ms,omitempty"`
+	ErrorContains string                 `protobuf:"bytes,7,opt,name=error_contains,json=errorContains,proto3" json:"error_contains,omitempty"`
+	From          *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=from,proto3" json:"from,omitempty"`    // checked_at >= from
+	To            *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=to,proto3" json:"to,omitempty"`        // checked_at < to
+	Order         string                 `protobuf:"bytes,10,opt,name=order,proto3" json:"order,omitempty"` // asc or desc (default)
+	Limit         int32                  `protobuf:"varint,11,opt,name=limit,proto3" json:"limit,omitempty"`
+	Offset        int32                  `protobuf:"varint,12,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QueryCheckLogsRequest) Reset() {
+	*x = QueryCheckLogsRequest{}
+	mi := &file_monitor_v1_monitor_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QueryCheckLogsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueryCheckLogsRequest) ProtoMessage() {}
+
+func (x *QueryCheckLogsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monitor_v1_monitor_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueryCheckLogsRequest.ProtoReflect.Descriptor instead.
+func (*QueryCheckLogsRequest) Descriptor() ([]byte, []int) {
+	return file_monitor_v1_monitor_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *QueryCheckLogsRequest) GetServiceIds() []uint32 {
+	if x != nil {
+		return x.ServiceIds
+	}
+	return nil
+}
+
+func (x *QueryCheckLogsRequest) GetTag() string {
+	if x != nil {
+		return x.Tag
+	}
+	return ""
+}
+
+func (x *QueryCheckLogsRequest) GetStatuses() []string {
+	if x != nil {
+		return x.Statuses
+	}
+	return nil
+}
+
+func (x *QueryCheckLogsRequest) GetStatusCodes() []int32 {
+	if x != nil {
+		return x.StatusCodes
+	}
+	return nil
+}
+
+func (x *QueryCheckLogsRequest) GetLatencyGtMs() *wrapperspb.Int64Value {
+	if x != nil {
+		return x.LatencyGtMs
+	}
+	return nil
+}
+
+func (x *QueryCheckLogsRequest) GetLatencyLtMs() *wrapperspb.Int64Value {
+	if x != nil {
+		return x.LatencyLtMs
+	}
+	return nil
+}
+
+func (x *QueryCheckLogsRequest) GetErrorContains() string {
+	if x != nil {
+		return x.ErrorContains
+	}
+	return ""
+}
+
+func (x *QueryCheckLogsRequest) GetFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *QueryCheckLogsRequest) GetTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.To
+	}
+	return nil
+}
+
+func (x *QueryCheckLogsRequest) GetOrder() string {
+	if x != nil {
+		return x.Order
+	}
+	return ""
+}
+
+func (x *QueryCheckLogsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *QueryCheckLogsRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type QueryCheckLogsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Logs          []*CheckLog            `protobuf:"bytes,1,rep,name=logs,proto3" json:"logs,omitempty"`
+	Total         int64                  `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"` // matching logs, ignoring limit and offset
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QueryCheckLogsResponse) Reset() {
+	*x = QueryCheckLogsResponse{}
+	mi := &file_monitor_v1_monitor_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QueryCheckLogsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueryCheckLogsResponse) ProtoMessage() {}
+
+func (x *QueryCheckLogsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monitor_v1_monitor_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueryCheckLogsResponse.ProtoReflect.Descriptor instead.
+func (*QueryCheckLogsResponse) Descriptor() ([]byte, []int) {
+	return file_monitor_v1_monitor_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *QueryCheckLogsResponse) GetLogs() []*CheckLog {
+	if x != nil {
+		return x.Logs
+	}
+	return nil
+}
+
+func (x *QueryCheckLogsResponse) GetTotal() int64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+type WatchEventsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	LastSeq       uint64                 `protobuf:"varint,1,opt,name=last_seq,json=lastSeq,proto3" json:"last_seq,omitempty"`                 // replay buffered events after this seq, like the WebSocket last_seq
+	ServiceIds    []uint32               `protobuf:"varint,2,rep,packed,name=service_ids,json=serviceIds,proto3" json:"service_ids,omitempty"` // only events of these services
+	Types         []string               `protobuf:"bytes,3,rep,name=types,proto3" json:"types,omitempty"`                                     // only these event types, e.g. service_state_change
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchEventsRequest) Reset() {
+	*x = WatchEventsRequest{}
+	mi := &file_monitor_v1_monitor_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchEventsRequest) ProtoMessage() {}
+
+func (x *WatchEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monitor_v1_monitor_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchEventsRequest.ProtoReflect.Descriptor instead.
+func (*WatchEventsRequest) Descriptor() ([]byte, []int) {
+	return file_monitor_v1_monitor_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *WatchEventsRequest) GetLastSeq() uint64 {
+	if x != nil {
+		return x.LastSeq
+	}
+	return 0
+}
+
+func (x *WatchEventsRequest) GetServiceIds() []uint32 {
+	if x != nil {
+		return x.ServiceIds
+	}
+	return nil
+}
+
+func (x *WatchEventsRequest) GetTypes() []string {
+	if x != nil {
+		return x.Types
+	}
+	return nil
+}
+
+// Event is one event of the WebSocket stream. The common fields are copied
+// out; payload holds the whole event as sent to WebSocket clients.
+type Event struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Seq           uint64                 `protobuf:"varint,1,opt,name=seq,proto3" json:"seq,omitempty"`
+	Type          string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"` // service_state_change, service_registered, service_flapping_start, replay_gap, ...
+	ServiceId     uint32                 `protobuf:"varint,3,opt,name=service_id,json=serviceId,proto3" json:"service_id,omitempty"`
+	Name          string                 `protobuf:"bytes,4,opt,name=name,proto3" json:"name,omitempty"`
+	From          string                 `protobuf:"bytes,5,opt,name=from,proto3" json:"from,omitempty"`
+	To            string                 `protobuf:"bytes,6,opt,name=to,proto3" json:"to,omitempty"`
+	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Severity      string                 `protobuf:"bytes,8,opt,name=severity,proto3" json:"severity,omitempty"`
+	Reason        string                 `protobuf:"bytes,9,opt,name=reason,proto3" json:"reason,omitempty"`
+	Error         string                 `protobuf:"bytes,10,opt,name=error,proto3" json:"error,omitempty"`
+	Payload       *structpb.Struct       `protobuf:"bytes,11,opt,name=payload,proto3" json:"payload,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	mi := &file_monitor_v1_monitor_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_monitor_v1_monitor_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_monitor_v1_monitor_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *Event) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *Event) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Event) GetServiceId() uint32 {
+	if x != nil {
+		return x.ServiceId
+	}
+	return 0
+}
+
+func (x *Event) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Event) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *Event) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+func (x *Event) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *Event) GetSeverity() string {
+	if x != nil {
+		return x.Severity
+	}
+	return ""
+}
+
+func (x *Event) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *Event) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *Event) GetPayload() *structpb.Struct {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+var File_monitor_v1_monitor_proto protoreflect.FileDescriptor
+
+const file_monitor_v1_monitor_proto_rawDesc = "" +
+	"\n" +
+	"\x18monitor/v1/monitor.proto\x12\n" +
+	"monitor.v1\x1a\x1cgoogle/protobuf/struct.proto\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x1egoogle/protobuf/wrappers.proto\"O\n" +
+	"\tAssertion\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x12\n" +
+	"\x04path\x18\x02 \x01(\tR\x04path\x12\x1a\n" +
+	"\bexpected\x18\x03 \x01(\tR\bexpected\"\xe4\x01\n" +
+	"\tCheckAuth\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x1a\n" +
+	"\busername\x18\x02 \x01(\tR\busername\x12\x1a\n" +
+	"\bpassword\x18\x03 \x01(\tR\bpassword\x12\x14\n" +
+	"\x05token\x18\x04 \x01(\tR\x05token\x12\x1b\n" +
+	"\ttoken_url\x18\x05 \x01(\tR\btokenUrl\x12\x1b\n" +
+	"\tclient_id\x18\x06 \x01(\tR\bclientId\x12#\n" +
+	"\rclient_secret\x18\a \x01(\tR\fclientSecret\x12\x16\n" +
+	"\x06scopes\x18\b \x03(\tR\x06scopes\"\xc0\a\n" +
+	"\vServiceSpec\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x10\n" +
+	"\x03url\x18\x02 \x01(\tR\x03url\x12\x1a\n" +
+	"\bprotocol\x18\x03 \x01(\tR\bprotocol\x12\x1f\n" +
+	"\vhttp_method\x18\x04 \x01(\tR\n" +
+	"httpMethod\x12\x1a\n" +
+	"\binterval\x18\x05 \x01(\x03R\binterval\x12'\n" +
+	"\x0ftimeout_seconds\x18\x06 \x01(\x03R\x0etimeoutSeconds\x12+\n" +
+	"\x11failure_threshold\x18\a \x01(\x03R\x10failureThreshold\x12\x18\n" +
+	"\aretries\x18\b \x01(\x03R\aretries\x12$\n" +
+	"\x0eretry_delay_ms\x18\t \x01(\x03R\fretryDelayMs\x12&\n" +
+	"\x0flatency_warn_ms\x18\n" +
+	" \x01(\x03R\rlatencyWarnMs\x12&\n" +
+	"\x0flatency_crit_ms\x18\v \x01(\x03R\rlatencyCritMs\x125\n" +
+	"\n" +
+	"assertions\x18\f \x03(\v2\x15.monitor.v1.AssertionR\n" +
+	"assertions\x12\x1b\n" +
+	"\tproxy_url\x18\r \x01(\tR\bproxyUrl\x12W\n" +
+	"\x10resolve_override\x18\x0e \x03(\v2,.monitor.v1.ServiceSpec.ResolveOverrideEntryR\x0fresolveOverride\x12)\n" +
+	"\x10source_interface\x18\x0f \x01(\tR\x0fsourceInterface\x12)\n" +
+	"\x04auth\x18\x10 \x01(\v2\x15.monitor.v1.CheckAuthR\x04auth\x12!\n" +
+	"\fdns_resolver\x18\x11 \x01(\tR\vdnsResolver\x12&\n" +
+	"\x0fdns_record_type\x18\x12 \x01(\tR\rdnsRecordType\x12!\n" +
+	"\fdns_expected\x18\x13 \x03(\tR\vdnsExpected\x126\n" +
+	"\x17heartbeat_grace_seconds\x18\x14 \x01(\x03R\x15heartbeatGraceSeconds\x12\x16\n" +
+	"\x06public\x18\x15 \x01(\bR\x06public\x12\x12\n" +
+	"\x04tags\x18\x16 \x03(\tR\x04tags\x123\n" +
+	"\bmetadata\x18\x17 \x01(\v2\x17.google.protobuf.StructR\bmetadata\x1aB\n" +
+	"\x14ResolveOverrideEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xbc\x03\n" +
+	"\aService\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\rR\x02id\x12+\n" +
+	"\x04spec\x18\x02 \x01(\v2\x17.monitor.v1.ServiceSpecR\x04spec\x12'\n" +
+	"\x0fheartbeat_token\x18\x03 \x01(\tR\x0eheartbeatToken\x12\x16\n" +
+	"\x06status\x18\x04 \x01(\tR\x06status\x121\n" +
+	"\x14consecutive_failures\x18\x05 \x01(\x03R\x13consecutiveFailures\x12B\n" +
+	"\x0flast_checked_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\rlastCheckedAt\x12F\n" +
+	"\x11last_heartbeat_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\x0flastHeartbeatAt\x129\n" +
+	"\n" +
+	"created_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"\xed\x01\n" +
+	"\bCheckLog\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\rR\x02id\x12\x1d\n" +
+	"\n" +
+	"service_id\x18\x02 \x01(\rR\tserviceId\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\x12\x1f\n" +
+	"\vstatus_code\x18\x04 \x01(\x05R\n" +
+	"statusCode\x12(\n" +
+	"\x10response_time_ms\x18\x05 \x01(\x03R\x0eresponseTimeMs\x12\x14\n" +
+	"\x05error\x18\x06 \x01(\tR\x05error\x129\n" +
+	"\n" +
+	"checked_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tcheckedAt\"'\n" +
+	"\x13ListServicesRequest\x12\x10\n" +
+	"\x03tag\x18\x01 \x01(\tR\x03tag\"G\n" +
+	"\x14ListServicesResponse\x12/\n" +
+	"\bservices\x18\x01 \x03(\v2\x13.monitor.v1.ServiceR\bservices\"#\n" +
+	"\x11GetServiceRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\rR\x02id\"K\n" +
+	"\x16RegisterServiceRequest\x121\n" +
+	"\aservice\x18\x01 \x01(\v2\x17.monitor.v1.ServiceSpecR\aservice\">\n" +
+	"\x14DeleteServiceRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\rR\x02id\x12\x16\n" +
+	"\x06reason\x18\x02 \x01(\tR\x06reason\"6\n" +
+	"\x15DeleteServiceResponse\x12\x1d\n" +
+	"\n" +
+	"archive_id\x18\x01 \x01(\rR\tarchiveId\"c\n" +
+	"\x14ListCheckLogsRequest\x12\x1d\n" +
+	"\n" +
+	"service_id\x18\x01 \x01(\rR\tserviceId\x12\x14\n" +
+	"\x05limit\x18\x02 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06offset\x18\x03 \x01(\x05R\x06offset\"o\n" +
+	"\x15ListCheckLogsResponse\x12(\n" +
+	"\x04logs\x18\x01 \x03(\v2\x14.monitor.v1.CheckLogR\x04logs\x12\x14\n" +
+	"\x05limit\x18\x02 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06offset\x18\x03 \x01(\x05R\x06offset\"\xd2\x03\n" +
+	"\x15QueryCheckLogsRequest\x12\x1f\n" +
+	"\vservice_ids\x18\x01 \x03(\rR\n" +
+	"serviceIds\x12\x10\n" +
+	"\x03tag\x18\x02 \x01(\tR\x03tag\x12\x1a\n" +
+	"\bstatuses\x18\x03 \x03(\tR\bstatuses\x12!\n" +
+	"\fstatus_codes\x18\x04 \x03(\x05R\vstatusCodes\x12?\n" +
+	"\rlatency_gt_ms\x18\x05 \x01(\v2\x1b.google.protobuf.Int64ValueR\vlatencyGtMs\x12?\n" +
+	"\rlatency_lt_ms\x18\x06 \x01(\v2\x1b.google.protobuf.Int64ValueR\vlatencyLtMs\x12%\n" +
+	"\x0eerror_contains\x18\a \x01(\tR\rerrorContains\x12.\n" +
+	"\x04from\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\x04from\x12*\n" +
+	"\x02to\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\x02to\x12\x14\n" +
+	"\x05order\x18\n" +
+	" \x01(\tR\x05order\x12\x14\n" +
+	"\x05limit\x18\v \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06offset\x18\f \x01(\x05R\x06offset\"X\n" +
+	"\x16QueryCheckLogsResponse\x12(\n" +
+	"\x04logs\x18\x01 \x03(\v2\x14.monitor.v1.CheckLogR\x04logs\x12\x14\n" +
+	"\x05total\x18\x02 \x01(\x03R\x05total\"f\n" +
+	"\x12WatchEventsRequest\x12\x19\n" +
+	"\blast_seq\x18\x01 \x01(\x04R\alastSeq\x12\x1f\n" +
+	"\vservice_ids\x18\x02 \x03(\rR\n" +
+	"serviceIds\x12\x14\n" +
+	"\x05types\x18\x03 \x03(\tR\x05types\"\xbb\x02\n" +
+	"\x05Event\x12\x10\n" +
+	"\x03seq\x18\x01 \x01(\x04R\x03seq\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x1d\n" +
+	"\n" +
+	"service_id\x18\x03 \x01(\rR\tserviceId\x12\x12\n" +
+	"\x04name\x18\x04 \x01(\tR\x04name\x12\x12\n" +
+	"\x04from\x18\x05 \x01(\tR\x04from\x12\x0e\n" +
+	"\x02to\x18\x06 \x01(\tR\x02to\x128\n" +
+	"\ttimestamp\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12\x1a\n" +
+	"\bseverity\x18\b \x01(\tR\bseverity\x12\x16\n" +
+	"\x06reason\x18\t \x01(\tR\x06reason\x12\x14\n" +
+	"\x05error\x18\n" +
+	" \x01(\tR\x05error\x121\n" +
+	"\apayload\x18\v \x01(\v2\x17.google.protobuf.StructR\apayload2\xba\x04\n" +
+	"\x0eMonitorService\x12Q\n" +
+	"\fListServices\x12\x1f.monitor.v1.ListServicesRequest\x1a .monitor.v1.ListServicesResponse\x12@\n" +
+	"\n" +
+	"GetService\x12\x1d.monitor.v1.GetServiceRequest\x1a\x13.monitor.v1.Service\x12J\n" +
+	"\x0fRegisterService\x12\".monitor.v1.RegisterServiceRequest\x1a\x13.monitor.v1.Service\x12T\n" +
+	"\rDeleteService\x12 .monitor.v1.DeleteServiceRequest\x1a!.monitor.v1.DeleteServiceResponse\x12T\n" +
+	"\rListCheckLogs\x12 .monitor.v1.ListCheckLogsRequest\x1a!.monitor.v1.ListCheckLogsResponse\x12W\n" +
+	"\x0eQueryCheckLogs\x12!.monitor.v1.QueryCheckLogsRequest\x1a\".monitor.v1.QueryCheckLogsResponse\x12B\n" +
+	"\vWatchEvents\x12\x1e.monitor.v1.WatchEventsRequest\x1a\x11.monitor.v1.Event0\x01B:Z8Distributed-Health-Monitoring/proto/monitor/v1;monitorv1b\x06proto3"
+
+var (
+	file_monitor_v1_monitor_proto_rawDescOnce sync.Once
+	file_monitor_v1_monitor_proto_rawDescData []byte
+)
+
+func file_monitor_v1_monitor_proto_rawDescGZIP() []byte {
+	file_monitor_v1_monitor_proto_rawDescOnce.Do(func() {
+		file_monitor_v1_monitor_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_monitor_v1_monitor_proto_rawDesc), len(file_monitor_v1_monitor_proto_rawDesc)))
+	})
+	return file_monitor_v1_monitor_proto_rawDescData
+}
+
+var file_monitor_v1_monitor_proto_msgTypes = make([]protoimpl.MessageInfo, 18)
+var file_monitor_v1_monitor_proto_goTypes = []any{
+	(*Assertion)(nil),              // 0: monitor.v1.Assertion
+	(*CheckAuth)(nil),              // 1: monitor.v1.CheckAuth
+	(*ServiceSpec)(nil),            // 2: monitor.v1.ServiceSpec
+	(*Service)(nil),                // 3: monitor.v1.Service
+	(*CheckLog)(nil),               // 4: monitor.v1.CheckLog
+	(*ListServicesRequest)(nil),    // 5: monitor.v1.ListServicesRequest
+	(*ListServicesResponse)(nil),   // 6: monitor.v1.ListServicesResponse
+	(*GetServiceRequest)(nil),      // 7: monitor.v1.GetServiceRequest
+	(*RegisterServiceRequest)(nil), // 8: monitor.v1.RegisterServiceRequest
+	(*DeleteServiceRequest)(nil),   // 9: monitor.v1.DeleteServiceRequest
+	(*DeleteServiceResponse)(nil),  // 10: monitor.v1.DeleteServiceResponse
+	(*ListCheckLogsRequest)(nil),   // 11: monitor.v1.ListCheckLogsRequest
+	(*ListCheckLogsResponse)(nil),  // 12: monitor.v1.ListCheckLogsResponse
+	(*QueryCheckLogsRequest)(nil),  // 13: monitor.v1.QueryCheckLogsRequest
+	(*QueryCheckLogsResponse)(nil), // 14: monitor.v1.QueryCheckLogsResponse
+	(*WatchEventsRequest)(nil),     // 15: monitor.v1.WatchEventsRequest
+	(*Event)(nil),                  // 16: monitor.v1.Event
+	nil,                            // 17: monitor.v1.ServiceSpec.ResolveOverrideEntry
+	(*structpb.Struct)(nil),        // 18: google.protobuf.Struct
+	(*timestamppb.Timestamp)(nil),  // 19: google.protobuf.Timestamp
+	(*wrapperspb.Int64Value)(nil),  // 20: google.protobuf.Int64Value
+}
+var file_monitor_v1_monitor_proto_depIdxs = []int32{
+	0,  // 0: monitor.v1.ServiceSpec.assertions:type_name -> monitor.v1.Assertion
+	17, // 1: monitor.v1.ServiceSpec.resolve_override:type_name -> monitor.v1.ServiceSpec.ResolveOverrideEntry
+	1,  // 2: monitor.v1.ServiceSpec.auth:type_name -> monitor.v1.CheckAuth
+	18, // 3: monitor.v1.ServiceSpec.metadata:type_name -> google.protobuf.Struct
+	2,  // 4: monitor.v1.Service.spec:type_name -> monitor.v1.ServiceSpec
+	19, // 5: monitor.v1.Service.last_checked_at:type_name -> google.protobuf.Timestamp
+	19, // 6: monitor.v1.Service.last_heartbeat_at:type_name -> google.protobuf.Timestamp
+	19, // 7: monitor.v1.Service.created_at:type_name -> google.protobuf.Timestamp
+	19, // 8: monitor.v1.Service.updated_at:type_name -> google.protobuf.Timestamp
+	19, // 9: monitor.v1.CheckLog.checked_at:type_name -> google.protobuf.Timestamp
+	3,  // 10: monitor.v1.ListServicesResponse.services:type_name -> monitor.v1.Service
+	2,  // 11: monitor.v1.RegisterServiceRequest.service:type_name -> monitor.v1.ServiceSpec
+	4,  // 12: monitor.v1.ListCheckLogsResponse.logs:type_name -> monitor.v1.CheckLog
+	20, // 13: monitor.v1.QueryCheckLogsRequest.latency_gt_ms:type_name -> google.protobuf.Int64Value
+	20, // 14: monitor.v1.QueryCheckLogsRequest.latency_lt_ms:type_name -> google.protobuf.Int64Value
+	19, // 15: monitor.v1.QueryCheckLogsRequest.from:type_name -> google.protobuf.Timestamp
+	19, // 16: monitor.v1.QueryCheckLogsRequest.to:type_name -> google.protobuf.Timestamp
+	4,  // 17: monitor.v1.QueryCheckLogsResponse.logs:type_name -> monitor.v1.CheckLog
+	19, // 18: monitor.v1.Event.timestamp:type_name -> google.protobuf.Timestamp
+	18, // 19: monitor.v1.Event.payload:type_name -> google.protobuf.Struct
+	5,  // 20: monitor.v1.MonitorService.ListServices:input_type -> monitor.v1.ListServicesRequest
+	7,  // 21: monitor.v1.MonitorService.GetService:input_type -> monitor.v1.GetServiceRequest
+	8,  // 22: monitor.v1.MonitorService.RegisterService:input_type -> monitor.v1.RegisterServiceRequest
+	9,  // 23: monitor.v1.MonitorService.DeleteService:input_type -> monitor.v1.DeleteServiceRequest
+	11, // 24: monitor.v1.MonitorService.ListCheckLogs:input_type -> monitor.v1.ListCheckLogsRequest
+	13, // 25: monitor.v1.MonitorService.QueryCheckLogs:input_type -> monitor.v1.QueryCheckLogsRequest
+	15, // 26: monitor.v1.MonitorService.WatchEvents:input_type -> monitor.v1.WatchEventsRequest
+	6,  // 27: monitor.v1.MonitorService.ListServices:output_type -> monitor.v1.ListServicesResponse
+	3,  // 28: monitor.v1.MonitorService.GetService:output_type -> monitor.v1.Service
+	3,  // 29: monitor.v1.MonitorService.RegisterService:output_type -> monitor.v1.Service
+	10, // 30: monitor.v1.MonitorService.DeleteService:output_type -> monitor.v1.DeleteServiceResponse
+	12, // 31: monitor.v1.MonitorService.ListCheckLogs:output_type -> monitor.v1.ListCheckLogsResponse
+	14, // 32: monitor.v1.MonitorService.QueryCheckLogs:output_type -> monitor.v1.QueryCheckLogsResponse
+	16, // 33: monitor.v1.MonitorService.WatchEvents:output_type -> monitor.v1.Event
+	27, // [27:34] is the sub-list for method output_type
+	20, // [20:27] is the sub-list for method input_type
+	20, // [20:20] is the sub-list for extension type_name
+	20, // [20:20] is the sub-list for extension extendee
+	0,  // [0:20] is the sub-list for field type_name
+}
+
+func init() { file_monitor_v1_monitor_proto_init() }
+func file_monitor_v1_monitor_proto_init() {
+	if File_monitor_v1_monitor_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_monitor_v1_monitor_proto_rawDesc), len(file_monitor_v1_monitor_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   18,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_monitor_v1_monitor_proto_goTypes,
+		DependencyIndexes: file_monitor_v1_monitor_proto_depIdxs,
+		MessageInfos:      file_monitor_v1_monitor_proto_msgTypes,
+	}.Build()
+	File_monitor_v1_monitor_proto = out.File
+	file_monitor_v1_monitor_proto_goTypes = nil
+	file_monitor_v1_monitor_proto_depIdxs = nil
+}
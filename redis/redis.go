@@ -0,0 +1,336 @@
+// Package redis is a minimal Redis client speaking RESP2 over a small pool of
+// connections. It covers what the monitor needs and nothing more.
+package redis
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	dialTimeout = 5 * time.Second
+	maxIdle     = 8
+)
+
+// Error is an error reply from the server, e.g. "BUSYGROUP Consumer Group name already exists"
+type Error string
+
+func (e Error) Error() string { return string(e) }
+
+// Client sends commands to one Redis server
+type Client struct {
+	addr     string
+	password string
+	db       int
+
+	mu   sync.Mutex
+	idle []*conn
+}
+
+type conn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func NewClient(addr, password string, db int) *Client {
+	if addr == "" {
+		addr = "127.0.0.1:6379"
+	}
+	return &Client{addr: addr, password: password, db: db}
+}
+
+// Do sends one command and returns its reply: string, int64, []interface{},
+// nil for a null reply, or an Error. The context deadline bounds the round trip.
+func (c *Client) Do(ctx context.Context, args ...interface{}) (interface{}, error) {
+	cn, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := cn.do(ctx, args)
+	var replyErr Error
+	if err != nil && !errors.As(err, &replyErr) {
+		// The connection is in an unknown state after an I/O error
+		cn.Close()
+		return nil, err
+	}
+	c.put(cn)
+	return reply, err
+}
+
+// Ping checks that the server answers
+func (c *Client) Ping(ctx context.Context) error {
+	_, err := c.Do(ctx, "PING")
+	return err
+}
+
+// Connect opens an authenticated connection and adds it to the pool, so the
+// next command doesn't pay for the connection setup
+func (c *Client) Connect(ctx context.Context) error {
+	cn, err := c.dial(ctx)
+	if err != nil {
+		return err
+	}
+	c.put(cn)
+	return nil
+}
+
+// Close closes the idle connections; connections in use close when returned
+func (c *Client) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, cn := range c.idle {
+		cn.Close()
+	}
+	c.idle = nil
+}
+
+func (c *Client) get(ctx context.Context) (*conn, error) {
+	c.mu.Lock()
+	if n := len(c.idle); n > 0 {
+		cn := c.idle[n-1]
+		c.idle = c.idle[:n-1]
+		c.mu.Unlock()
+		return cn, nil
+	}
+	c.mu.Unlock()
+	return c.dial(ctx)
+}
+
+// dial opens a connection, authenticated and on the configured database
+func (c *Client) dial(ctx context.Context) (*conn, error) {
+	d := net.Dialer{Timeout: dialTimeout}
+	nc, err := d.DialContext(ctx, "tcp", c.addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+	cn := &conn{Conn: nc, r: bufio.NewReader(nc)}
+
+	if c.password != "" {
+		if _, err := cn.do(ctx, []interface{}{"AUTH", c.password}); err != nil {
+			cn.Close()
+			return nil, fmt.Errorf("redis auth failed: %w", err)
+		}
+	}
+	if c.db != 0 {
+		if _, err := cn.do(ctx, []interface{}{"SELECT", c.db}); err != nil {
+			cn.Close()
+			return nil, fmt.Errorf("redis select failed: %w", err)
+		}
+	}
+	return cn, nil
+}
+
+// Publish sends a message on a channel and returns how many subscribers received it
+func (c *Client) Publish(ctx context.Context, channel string, message []byte) (int64, error) {
+	return Int(c.Do(ctx, "PUBLISH", channel, message))
+}
+
+// Subscribe calls subscribed once the server confirmed the subscription and
+// then handle for every message published on the channel, until ctx ends or
+// the connection fails. A subscribed connection can't send other commands,
+// so it gets a connection of its own outside the pool.
+func (c *Client) Subscribe(ctx context.Context, channel string, subscribed func(), handle func(message []byte)) error {
+	cn, err := c.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer cn.Close()
+
+	// Closing the connection is what ends the blocking read below
+	stop := context.AfterFunc(ctx, func() { cn.Close() })
+	defer stop()
+
+	if _, err := cn.do(context.Background(), []interface{}{"SUBSCRIBE", channel}); err != nil {
+		return err
+	}
+	subscribed()
+
+	for {
+		reply, err := cn.read()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		items, ok := reply.([]interface{})
+		if !ok || len(items) != 3 || items[0] != "message" {
+			continue
+		}
+		message, _ := items[2].(string)
+		handle([]byte(message))
+	}
+}
+
+func (c *Client) put(cn *conn) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.idle) >= maxIdle {
+		cn.Close()
+		return
+	}
+	c.idle = append(c.idle, cn)
+}
+
+func (cn *conn) do(ctx context.Context, args []interface{}) (interface{}, error) {
+	deadline, _ := ctx.Deadline()
+	if err := cn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 0, 64)
+	buf = append(buf, '*')
+	buf = strconv.AppendInt(buf, int64(len(args)), 10)
+	buf = append(buf, '\r', '\n')
+	for _, arg := range args {
+		var s string
+		switch v := arg.(type) {
+		case string:
+			s = v
+		case []byte:
+			s = string(v)
+		case int:
+			s = strconv.Itoa(v)
+		case int64:
+			s = strconv.FormatInt(v, 10)
+		default:
+			s = fmt.Sprint(v)
+		}
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(len(s)), 10)
+		buf = append(buf, '\r', '\n')
+		buf = append(buf, s...)
+		buf = append(buf, '\r', '\n')
+	}
+	if _, err := cn.Write(buf); err != nil {
+		return nil, err
+	}
+	return cn.read()
+}
+
+func (cn *conn) read() (interface{}, error) {
+	line, err := cn.r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, errors.New("redis: malformed reply")
+	}
+	kind, payload := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return payload, nil
+	case '-':
+		return nil, Error(payload)
+	case ':':
+		return strconv.ParseInt(payload, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(payload)
+		if err != nil {
+			return nil, errors.New("redis: malformed bulk length")
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(cn.r, data); err != nil {
+			return nil, err
+		}
+		return string(data[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(payload)
+		if err != nil {
+			return nil, errors.New("redis: malformed array length")
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		items := make([]interface{}, n)
+		// An error nested in an array is a value, not a failure of the command
+		for i := range items {
+			item, err := cn.read()
+			var replyErr Error
+			if errors.As(err, &replyErr) {
+				item, err = replyErr, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			items[i] = item
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("redis: unexpected reply type %q", kind)
+}
+
+// Int converts an integer reply
+func Int(reply interface{}, err error) (int64, error) {
+	if err != nil {
+		return 0, err
+	}
+	n, ok := reply.(int64)
+	if !ok {
+		return 0, fmt.Errorf("redis: expected an integer, got %T", reply)
+	}
+	return n, nil
+}
+
+// Values converts an array reply; a null reply is an empty array
+func Values(reply interface{}, err error) ([]interface{}, error) {
+	if err != nil {
+		return nil, err
+	}
+	if reply == nil {
+		return nil, nil
+	}
+	items, ok := reply.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("redis: expected an array, got %T", reply)
+	}
+	return items, nil
+}
+
+// StreamEntry is one entry of a stream
+type StreamEntry struct {
+	ID     string
+	Fields map[string]string
+}
+
+// Entries converts the entry list returned by XRANGE and XCLAIM. Entries
+// deleted while pending come back without fields and are skipped.
+func Entries(reply interface{}, err error) ([]StreamEntry, error) {
+	items, err := Values(reply, err)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]StreamEntry, 0, len(items))
+	for _, item := range items {
+		pair, ok := item.([]interface{})
+		if !ok || len(pair) != 2 {
+			continue
+		}
+		id, _ := pair[0].(string)
+		kv, _ := pair[1].([]interface{})
+		if id == "" || kv == nil {
+			continue
+		}
+		entry := StreamEntry{ID: id, Fields: make(map[string]string, len(kv)/2)}
+		for i := 0; i+1 < len(kv); i += 2 {
+			k, _ := kv[i].(string)
+			v, _ := kv[i+1].(string)
+			entry.Fields[k] = v
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
@@ -0,0 +1,228 @@
+// Package s3 uploads objects to Amazon S3 or an S3-compatible store (MinIO,
+// Ceph, R2) with AWS Signature Version 4.
+package s3
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	service         = "s3"
+	algorithm       = "AWS4-HMAC-SHA256"
+	amzDateLayout   = "20060102T150405Z"
+	shortDateLayout = "20060102"
+)
+
+// Client puts objects into one bucket
+type Client struct {
+	Endpoint        string // e.g. https://s3.eu-west-1.amazonaws.com or http://minio:9000
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string // temporary credentials only
+	// PathStyle addresses the bucket in the path (endpoint/bucket/key), as
+	// most S3-compatible stores need, rather than as a subdomain
+	PathStyle bool
+
+	HTTPClient *http.Client // nil for http.DefaultClient
+}
+
+// Error is a request S3 refused
+type Error struct {
+	StatusCode int
+	Code       string // e.g. AccessDenied, NoSuchBucket
+	Message    string
+}
+
+// ErrExists is returned by CreateObject when the key is taken
+var ErrExists = errors.New("s3: object already exists")
+
+func (e *Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("s3: status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("s3: %s: %s", e.Code, e.Message)
+}
+
+// PutObject uploads body as key. The payload hash is signed, so S3 rejects
+// an upload corrupted on the way.
+func (c *Client) PutObject(ctx context.Context, key string, body []byte, contentType string) error {
+	return c.put(ctx, key, bytes.NewReader(body), contentType, false)
+}
+
+// CreateObject uploads body as key unless the key already exists, in which
+// case it returns ErrExists. body is read twice, once for the payload hash,
+// so a file never has to be held in memory.
+func (c *Client) CreateObject(ctx context.Context, key string, body io.ReadSeeker, contentType string) error {
+	return c.put(ctx, key, body, contentType, true)
+}
+
+func (c *Client) put(ctx context.Context, key string, body io.ReadSeeker, contentType string, create bool) error {
+	if c.Bucket == "" || c.Endpoint == "" {
+		return errors.New("s3: endpoint and bucket are required")
+	}
+	u, err := c.objectURL(key)
+	if err != nil {
+		return err
+	}
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, body)
+	if err != nil {
+		return err
+	}
+	if _, err := body.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u.String(), io.NopCloser(body))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	if size == 0 {
+		req.Body = http.NoBody
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if create {
+		req.Header.Set("If-None-Match", "*")
+	}
+	c.sign(req, hex.EncodeToString(hash.Sum(nil)), time.Now().UTC())
+
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if create && resp.StatusCode == http.StatusPreconditionFailed {
+		io.Copy(io.Discard, resp.Body)
+		return ErrExists
+	}
+	return responseError(resp)
+}
+
+func (c *Client) objectURL(key string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimRight(c.Endpoint, "/"))
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("s3: invalid endpoint %q", c.Endpoint)
+	}
+	key = strings.TrimLeft(key, "/")
+	if c.PathStyle {
+		u.Path += "/" + c.Bucket + "/" + key
+	} else {
+		u.Host = c.Bucket + "." + u.Host
+		u.Path += "/" + key
+	}
+	u.RawPath = escapePath(u.Path)
+	return u, nil
+}
+
+// sign adds the SigV4 Authorization header
+func (c *Client) sign(req *http.Request, payloadHash string, now time.Time) {
+	amzDate := now.Format(amzDateLayout)
+	date := now.Format(shortDateLayout)
+
+	req.Header.Set("Host", req.URL.Host)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if c.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", c.SessionToken)
+	}
+
+	// Every header set so far is signed
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		names = append(names, strings.ToLower(name))
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(req.Header.Get(name)) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + c.Region + "/" + service + "/aws4_request"
+	stringToSign := strings.Join([]string{algorithm, amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+c.SecretAccessKey), date)
+	key = hmacSHA256(key, c.Region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Del("Host") // net/http sends it from req.Host
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		algorithm, c.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// escapePath encodes a path the way SigV4 expects: everything but unreserved
+// characters and the slashes
+func escapePath(path string) string {
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		ch := path[i]
+		if ch == '/' || ch == '-' || ch == '_' || ch == '.' || ch == '~' ||
+			('A' <= ch && ch <= 'Z') || ('a' <= ch && ch <= 'z') || ('0' <= ch && ch <= '9') {
+			b.WriteByte(ch)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", ch)
+	}
+	return b.String()
+}
+
+// responseError reads the XML error document S3 answers with
+func responseError(resp *http.Response) error {
+	var doc struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	xml.Unmarshal(raw, &doc)
+	return &Error{StatusCode: resp.StatusCode, Code: doc.Code, Message: doc.Message}
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,226 @@
+// Package secrets encrypts credentials stored with services using AES-256-GCM.
+// Fields tagged `gorm:"serializer:encrypted"` (strings) or
+// `gorm:"serializer:encrypted_json"` (structs) go through it on every read
+// and write, so the database only ever holds ciphertext for them.
+package secrets
+
+import (
+	"Distributed-Health-Monitoring/config"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm/schema"
+)
+
+// prefix marks ciphertext; values without it are legacy plaintext
+const prefix = "enc:v1:"
+
+// RedactedPassword is what url.URL.Redacted puts in place of a password
+const RedactedPassword = "xxxxx"
+
+var (
+	mu   sync.RWMutex
+	aead cipher.AEAD
+)
+
+// ErrNoKey is returned when encrypted data is read without a key configured
+var ErrNoKey = errors.New("secrets.key is not configured")
+
+func init() {
+	schema.RegisterSerializer("encrypted", Serializer{})
+	schema.RegisterSerializer("encrypted_json", JSONSerializer{})
+}
+
+// Setup loads the key from secrets.key, or from the environment variable
+// named by secrets.key_env. Without a key values are stored as they are,
+// so credentials are refused instead (see Enabled).
+func Setup(cfg config.Secrets) error {
+	key := cfg.Key
+	if key == "" && cfg.KeyEnv != "" {
+		key = os.Getenv(cfg.KeyEnv)
+	}
+	if key == "" {
+		mu.Lock()
+		aead = nil
+		mu.Unlock()
+		return nil
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return fmt.Errorf("secrets key must be base64: %w", err)
+	}
+	if len(raw) != 32 {
+		return fmt.Errorf("secrets key must be 32 bytes, got %d", len(raw))
+	}
+	block, err := aes.NewCipher(raw)
+	if err != nil {
+		return err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return err
+	}
+
+	mu.Lock()
+	aead = gcm
+	mu.Unlock()
+	return nil
+}
+
+// Enabled reports whether a key is configured
+func Enabled() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return aead != nil
+}
+
+// Encrypt seals a value; empty values and values without a key are returned unchanged
+func Encrypt(plaintext string) (string, error) {
+	mu.RLock()
+	gcm := aead
+	mu.RUnlock()
+	if plaintext == "" || gcm == nil {
+		return plaintext, nil
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return prefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a value written by Encrypt. Plaintext from before
+// encryption was enabled is returned as it is.
+func Decrypt(value string) (string, error) {
+	encoded, ok := strings.CutPrefix(value, prefix)
+	if !ok {
+		return value, nil
+	}
+
+	mu.RLock()
+	gcm := aead
+	mu.RUnlock()
+	if gcm == nil {
+		return "", ErrNoKey
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < gcm.NonceSize() {
+		return "", errors.New("encrypted value is malformed")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", errors.New("encrypted value can't be decrypted with the configured key")
+	}
+	return string(plaintext), nil
+}
+
+// IsEncrypted reports whether a stored value is ciphertext
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
+
+// Serializer is the gorm serializer behind `serializer:encrypted`, for string fields
+type Serializer struct{}
+
+func (Serializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var stored string
+	switch v := dbValue.(type) {
+	case nil:
+	case string:
+		stored = v
+	case []byte:
+		stored = string(v)
+	default:
+		return fmt.Errorf("failed to decrypt %s: unsupported value %T", field.Name, dbValue)
+	}
+
+	plaintext, err := Decrypt(stored)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt %s: %w", field.Name, err)
+	}
+	return field.Set(ctx, dst, plaintext)
+}
+
+func (Serializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	plaintext, ok := fieldValue.(string)
+	if !ok {
+		return nil, fmt.Errorf("failed to encrypt %s: unsupported value %T", field.Name, fieldValue)
+	}
+	return Encrypt(plaintext)
+}
+
+// JSONSerializer is the gorm serializer behind `serializer:encrypted_json`:
+// the field is stored as encrypted JSON, and a nil pointer as NULL
+type JSONSerializer struct{}
+
+func (JSONSerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	value := reflect.New(field.FieldType)
+
+	var stored string
+	switch v := dbValue.(type) {
+	case nil:
+	case string:
+		stored = v
+	case []byte:
+		stored = string(v)
+	default:
+		return fmt.Errorf("failed to decrypt %s: unsupported value %T", field.Name, dbValue)
+	}
+
+	if stored != "" {
+		plaintext, err := Decrypt(stored)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt %s: %w", field.Name, err)
+		}
+		if err := json.Unmarshal([]byte(plaintext), value.Interface()); err != nil {
+			return fmt.Errorf("failed to decode %s: %w", field.Name, err)
+		}
+	}
+
+	field.ReflectValueOf(ctx, dst).Set(value.Elem())
+	return nil
+}
+
+func (JSONSerializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	if v := reflect.ValueOf(fieldValue); !v.IsValid() || (v.Kind() == reflect.Ptr && v.IsNil()) {
+		return nil, nil
+	}
+	raw, err := json.Marshal(fieldValue)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", field.Name, err)
+	}
+	return Encrypt(string(raw))
+}
+
+// RedactURL replaces the password of a URL with xxxxx, for responses and logs.
+// A user without a password is replaced instead, since it is often a token.
+// Values that don't parse are redacted completely.
+func RedactURL(raw string) string {
+	if raw == "" || !strings.Contains(raw, "@") {
+		return raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "[redacted]"
+	}
+	if _, ok := u.User.Password(); u.User != nil && !ok {
+		u.User = url.User(RedactedPassword)
+	}
+	return u.Redacted()
+}
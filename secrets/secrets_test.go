@@ -0,0 +1,26 @@
+package secrets
+
+import "testing"
+
+func TestRedactURL(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{"empty", "", ""},
+		{"no credentials", "https://example.com/health", "https://example.com/health"},
+		{"password", "postgres://app:s3cret@db:5432/app", "postgres://app:xxxxx@db:5432/app"},
+		{"empty password", "postgres://app:@db:5432/app", "postgres://app:xxxxx@db:5432/app"},
+		{"user only", "https://ghp_token@example.com/health", "https://xxxxx@example.com/health"},
+		{"at sign in the path", "https://example.com/users/@me", "https://example.com/users/@me"},
+		{"unparsable", "postgres://app:s3cret@db:port/app", "[redacted]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RedactURL(tt.raw); got != tt.want {
+				t.Errorf("RedactURL(%q) = %q, want %q", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	service "Distributed-Health-Monitoring/Service"
+	"Distributed-Health-Monitoring/config"
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"time"
+)
+
+// runSimulate implements `simulate --services services.yaml --duration 24h`
+func runSimulate(args []string) error {
+	fs := flag.NewFlagSet("simulate", flag.ExitOnError)
+	servicesFile := fs.String("services", "services.yaml", "YAML or JSON file with service definitions")
+	duration := fs.Duration("duration", 24*time.Hour, "simulated time span")
+	latency := fs.Duration("latency", 200*time.Millisecond, "assumed check latency (capped by each service timeout)")
+	queue := fs.String("queue", "health_checks", "queue name jobs are published to")
+	jitterPercent := fs.Int("jitter-percent", 10, "scheduler.jitter_percent to simulate")
+	maxJitter := fs.Int("max-jitter-seconds", 30, "scheduler.max_jitter_seconds to simulate")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *duration <= 0 {
+		return errors.New("duration must be positive")
+	}
+
+	data, err := os.ReadFile(*servicesFile)
+	if err != nil {
+		return err
+	}
+
+	services, err := service.ParseServiceDefinitions(data)
+	if err != nil {
+		return err
+	}
+
+	report := service.Simulate(services, service.SimulationOptions{
+		Duration: *duration,
+		Latency:  *latency,
+		Queue:    *queue,
+		Jitter:   config.Scheduler{JitterPercent: *jitterPercent, MaxJitterSeconds: *maxJitter},
+	})
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+
+	report.Print(os.Stdout)
+	return nil
+}
@@ -0,0 +1,28 @@
+package tcp
+
+import (
+	"Distributed-Health-Monitoring/checker"
+	"Distributed-Health-Monitoring/models"
+	"context"
+	"time"
+)
+
+func init() {
+	checker.Register(models.ProtocolTCP, checker.Func(check))
+}
+
+// check is UP when the connection to the service's host:port opens
+func check(ctx context.Context, service *models.ExternalService) models.CheckResult {
+	result := models.CheckResult{Status: "DOWN"}
+
+	latency, err := CheckTCP(service.URL, time.Duration(service.TimeoutSeconds)*time.Second)
+	result.LatencyMs = latency.Milliseconds()
+	if err != nil {
+		result.ErrorMessage = err.Error()
+		result.Reason = "unreachable"
+		return result
+	}
+	result.Status = "UP"
+	result.Success = true
+	return result
+}
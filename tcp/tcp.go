@@ -0,0 +1,20 @@
+package tcp
+
+import (
+	"net"
+	"time"
+)
+
+// CheckTCP opens (and immediately closes) a TCP connection to address (host:port)
+// and reports how long the handshake took
+func CheckTCP(address string, timeout time.Duration) (time.Duration, error) {
+	start := time.Now()
+	conn, err := net.DialTimeout("tcp", address, timeout)
+	latency := time.Since(start)
+	if err != nil {
+		return latency, err
+	}
+	conn.Close()
+
+	return latency, nil
+}